@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/app"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+)
+
+const inventoryUsage = `usage: api inventory <command>
+
+commands:
+  reconcile             verify the stock of every product and variant equals its ledger sum`
+
+func runInventory(args []string) error {
+
+	if len(args) != 1 || args[0] != "reconcile" {
+		return errors.New(inventoryUsage)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := app.OpenDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	txRunner := repository.NewTransactionRunner(db)
+	productRepo := repository.NewProductRepository(db)
+
+	inventoryService := service.NewInventoryService(
+		txRunner,
+		productRepo,
+		repository.NewInventoryMovementRepository(db),
+		service.NewStockNotificationService(txRunner, productRepo, repository.NewStockSubscriptionRepository(db), notification.NewLogNotifier()))
+
+	discrepancies, err := inventoryService.Reconcile(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if len(discrepancies) == 0 {
+		fmt.Println("stock matches the inventory ledger")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PRODUCT\tVARIANT\tSTOCK\tLEDGER\tDIFFERENCE")
+
+	for _, discrepancy := range discrepancies {
+
+		variant := "-"
+		if discrepancy.VariantID != nil {
+			variant = fmt.Sprint(*discrepancy.VariantID)
+		}
+
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%d\t%+d\n",
+			discrepancy.ProductID, variant, discrepancy.Stock, discrepancy.LedgerStock, discrepancy.Stock-discrepancy.LedgerStock)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%d stock discrepancies found", len(discrepancies))
+}
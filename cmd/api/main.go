@@ -2,120 +2,99 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jhasudungan/terraloom-core-api/internal/common"
-	"github.com/jhasudungan/terraloom-core-api/internal/handler"
-	"github.com/jhasudungan/terraloom-core-api/internal/middlewares"
-	"github.com/jhasudungan/terraloom-core-api/internal/repository"
-	"github.com/jhasudungan/terraloom-core-api/internal/route"
-	"github.com/jhasudungan/terraloom-core-api/internal/service"
-	"github.com/joho/godotenv"
+	"github.com/jhasudungan/terraloom-core-api/internal/app"
+	"github.com/jhasudungan/terraloom-core-api/internal/config"
+	"github.com/jhasudungan/terraloom-core-api/internal/migration"
 	"github.com/sirupsen/logrus"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
 )
 
-func init() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-}
-
 func main() {
 
-	// Service port
-	srvPort := os.Getenv("PORT")
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if err != nil {
+			logrus.WithError(err).Fatal("Migration failed")
+		}
+		return
+	}
 
-	// Mode
-	env := os.Getenv("ENV")
+	if len(os.Args) > 1 && os.Args[1] == "inventory" {
+		err := runInventory(os.Args[2:])
+		if err != nil {
+			logrus.WithError(err).Fatal("Inventory check failed")
+		}
+		return
+	}
 
-	// JWT Secret
-	jwtSecret := os.Getenv("JWT_SECRET")
+	if len(os.Args) > 1 && os.Args[1] == "wishlist" {
+		err := runWishlist(os.Args[2:])
+		if err != nil {
+			logrus.WithError(err).Fatal("Wishlist check failed")
+		}
+		return
+	}
 
-	// Prepare DB
-	dbHost := os.Getenv("DATABASE_HOST")
-	dbPort := os.Getenv("DATABASE_PORT")
-	dbUser := os.Getenv("DATABASE_USER")
-	dbPass := os.Getenv("DATABASE_PASS")
-	dbName := os.Getenv("DATABASE_NAME")
+	if len(os.Args) > 1 && os.Args[1] == "pricing" {
+		err := runPricing(os.Args[2:])
+		if err != nil {
+			logrus.WithError(err).Fatal("Pricing job failed")
+		}
+		return
+	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable", dbHost, dbUser, dbPass, dbName, dbPort)
+	cfg, err := loadConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load configuration")
+	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := app.OpenDatabase(cfg.Database)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		logrus.WithError(err).Fatal("Failed to connect to database")
 	}
 
-	idGenerator := common.NewIDGenerator()
-
-	// Initialize repository
-	productRepo := repository.NewProductRepository(db)
-	orderRepo := repository.NewOrderRepository(db)
-	orderItemRepo := repository.NewOrderItemRepository(db)
-	paymentRepo := repository.NewPaymentRepository(db)
-	accountRepo := repository.NewAccountRepository(db)
-
-	// Initalize service
-	jwtService := service.NewJwtService(jwtSecret)
-
-	productService := service.NewProductService(productRepo)
-	orderService := service.NewOrderService(
-		orderRepo,
-		productRepo,
-		orderItemRepo,
-		paymentRepo,
-		accountRepo,
-		idGenerator)
-	accountService := service.NewAccountService(jwtService, accountRepo)
-	paymentService := service.NewPaymentService(orderRepo, paymentRepo)
-
-	// Initalize handler
-	errorHandler := handler.NewErrorHandler()
-	productHandler := handler.NewProductHandler(productService, errorHandler)
-	orderHandler := handler.NewOrderHandler(orderService, errorHandler)
-	accountHandler := handler.NewAccountHandler(accountService, orderService, errorHandler)
-	paymentHandler := handler.NewPaymentHandler(paymentService, errorHandler)
-
-	// Initialize middleware
-	authMiddleware := middlewares.NewAuthMiddleware(jwtService, errorHandler)
-
-	// Setup routes
-	router := gin.New()
-
-	router = route.SetupProductRoutes(productHandler, router)
-	router = route.SetupOrderRoutes(orderHandler, authMiddleware, router)
-	router = route.SetupAuthRoutes(accountHandler, authMiddleware, router)
-	router = route.SetupAccountRoutes(accountHandler, orderHandler, authMiddleware, router)
-	router = route.SetuPaymentRoutes(paymentHandler, authMiddleware, router)
+	// Refuse to serve against an outdated schema
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load migrations")
+	}
 
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:    ":" + srvPort,
-		Handler: router}
+	err = migrator.EnsureUpToDate(context.Background())
+	if err != nil {
+		logrus.WithError(err).Fatal("Database schema is not up to date")
+	}
 
-	if env == "PRODUCTION" {
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	} else {
 		gin.SetMode(gin.DebugMode)
 	}
 
+	router := app.NewRouter(cfg, db)
+
+	// Create HTTP server
+	srv := &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout}
+
 	// Start in go routine
 	go func() {
 
-		logrus.WithField("port", srvPort).Info("Starting HTTP server")
+		logrus.WithField("port", cfg.Server.Port).Info("Starting HTTP server")
 
-		err = srv.ListenAndServe()
+		err := srv.ListenAndServe()
 
-		if err != nil {
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logrus.WithError(err).Fatal("Failed to start server")
 		}
 
@@ -128,7 +107,7 @@ func main() {
 	logrus.Info("Shutting down server...")
 
 	// Create a deadline for the shutdown (Gracefull Shutdown)
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	// Shutdown the server
@@ -138,3 +117,22 @@ func main() {
 
 	logrus.Info("Server shutdown complete")
 }
+
+// loadConfig lists every configuration problem before failing
+func loadConfig() (config.Config, error) {
+
+	cfg, err := config.Load()
+	if err != nil {
+		var validationErr *config.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, problem := range validationErr.Problems {
+				logrus.Error(problem)
+			}
+		}
+		return cfg, err
+	}
+
+	logrus.WithField("config", cfg.String()).Info("Configuration loaded")
+
+	return cfg, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/app"
+	"github.com/jhasudungan/terraloom-core-api/internal/migration"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up                    apply all pending migrations
+  down [steps]          revert the last applied migration(s), default 1
+  status                list migrations and whether they are applied
+  create [-dir d] name  create a new numbered up/down migration pair`
+
+func runMigrate(args []string) error {
+
+	if len(args) < 1 {
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+
+	// create works on the source tree and needs no database
+	if args[0] == "create" {
+
+		flags := flag.NewFlagSet("create", flag.ContinueOnError)
+		dir := flags.String("dir", migration.SourceDir, "directory holding the migration files")
+
+		err := flags.Parse(args[1:])
+		if err != nil {
+			return err
+		}
+
+		if flags.NArg() != 1 {
+			return errors.New(migrateUsage)
+		}
+
+		upPath, downPath, err := migration.Create(*dir, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("created", upPath)
+		fmt.Println("created", downPath)
+		return nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := app.OpenDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("applied %d migration(s), schema version %d\n", len(applied), migrator.LatestVersion())
+		return nil
+
+	case "down":
+
+		steps := 1
+
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("reverted %d migration(s)\n", len(reverted))
+		return nil
+
+	case "status":
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+
+		for _, status := range statuses {
+
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Fprintf(writer, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return writer.Flush()
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/app"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+)
+
+const pricingUsage = `usage: api pricing <command>
+
+commands:
+  apply   write the scheduled price changes due by now and notify the resulting wishlist price drops`
+
+func runPricing(args []string) error {
+
+	if len(args) != 1 || args[0] != "apply" {
+		return errors.New(pricingUsage)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := app.OpenDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	wishlistService := newWishlistService(cfg, db)
+
+	pricingService := service.NewPricingService(
+		repository.NewTransactionRunner(db),
+		repository.NewProductRepository(db),
+		repository.NewProductPriceChangeRepository(db),
+		repository.NewProductPriceHistoryRepository(db),
+		repository.NewProductCurrencyPriceRepository(db),
+		wishlistService)
+
+	productIDs, err := pricingService.ApplyDuePriceChanges(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d products repriced\n", len(productIDs))
+
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	sent, err := wishlistService.NotifyPriceDrops(context.Background(), productIDs...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d price drop notices sent\n", sent)
+
+	return nil
+}
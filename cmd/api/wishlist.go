@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/app"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/config"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"gorm.io/gorm"
+)
+
+const wishlistUsage = `usage: api wishlist <command>
+
+commands:
+  price-drops [productId...]   notify the accounts whose wishlisted products got cheaper`
+
+func runWishlist(args []string) error {
+
+	if len(args) < 1 || args[0] != "price-drops" {
+		return errors.New(wishlistUsage)
+	}
+
+	productIDs := make([]int64, 0, len(args)-1)
+
+	for _, arg := range args[1:] {
+
+		productID, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || productID <= 0 {
+			return fmt.Errorf("invalid product id %q\n%s", arg, wishlistUsage)
+		}
+
+		productIDs = append(productIDs, productID)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	db, err := app.OpenDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	wishlistService := newWishlistService(cfg, db)
+
+	sent, err := wishlistService.NotifyPriceDrops(context.Background(), productIDs...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d price drop notices sent\n", sent)
+
+	return nil
+}
+
+func newWishlistService(cfg config.Config, db *gorm.DB) *service.WishlistService {
+
+	txRunner := repository.NewTransactionRunner(db)
+	productRepo := repository.NewProductRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	notifier := notification.NewLogNotifier()
+
+	orderService := service.NewOrderService(
+		txRunner,
+		repository.NewOrderRepository(db),
+		productRepo,
+		repository.NewOrderItemRepository(db),
+		repository.NewPaymentRepository(db),
+		accountRepo,
+		common.NewIDGenerator(),
+		service.NewCursorService(cfg.Auth.CursorSigningSecret()),
+		service.NewStockNotificationService(txRunner, productRepo, repository.NewStockSubscriptionRepository(db), notifier))
+
+	return service.NewWishlistService(
+		repository.NewWishlistRepository(db),
+		productRepo,
+		repository.NewProductVariantRepository(db),
+		repository.NewProductPriceChangeRepository(db),
+		accountRepo,
+		orderService,
+		notifier)
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/config"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/handler"
+	"github.com/jhasudungan/terraloom-core-api/internal/middlewares"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/route"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/jhasudungan/terraloom-core-api/internal/storage"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// NewRouter wires repositories, services, handlers and routes. It is shared by
+// cmd/api and the integration tests so both exercise the same graph
+func NewRouter(cfg config.Config, db *gorm.DB) *gin.Engine {
+
+	idGenerator := common.NewIDGenerator()
+
+	// Initialize repository
+	productRepo := repository.NewProductRepository(db)
+	orderRepo := repository.NewOrderRepository(db)
+	orderItemRepo := repository.NewOrderItemRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	accountRepo := repository.NewAccountRepository(db)
+	productVariantRepo := repository.NewProductVariantRepository(db)
+	productImageRepo := repository.NewProductImageRepository(db)
+	inventoryMovementRepo := repository.NewInventoryMovementRepository(db)
+	stockSubscriptionRepo := repository.NewStockSubscriptionRepository(db)
+	productReviewRepo := repository.NewProductReviewRepository(db)
+	wishlistRepo := repository.NewWishlistRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	priceChangeRepo := repository.NewProductPriceChangeRepository(db)
+	priceHistoryRepo := repository.NewProductPriceHistoryRepository(db)
+	priceListRepo := repository.NewProductCurrencyPriceRepository(db)
+	taxRuleRepo := repository.NewTaxRuleRepository(db)
+	shippingMethodRepo := repository.NewShippingMethodRepository(db)
+	addressRepo := repository.NewAddressRepository(db)
+	txRunner := repository.NewTransactionRunner(db)
+
+	// Initalize service
+	jwtService := service.NewJwtService(cfg.Auth.JWTSecret, cfg.Auth.TokenLifetime)
+	cursorService := service.NewCursorService(cfg.Auth.CursorSigningSecret())
+
+	blobStore := storage.NewLocalBlobStore(cfg.Storage.LocalDir, cfg.Storage.BaseURL)
+	notifier := notification.NewLogNotifier()
+
+	productService := service.NewProductService(productRepo, productVariantRepo, productImageRepo, priceChangeRepo, cursorService)
+	productReviewService := service.NewProductReviewService(txRunner, productReviewRepo, productRepo)
+	productImageService := service.NewProductImageService(txRunner, productRepo, blobStore, idGenerator)
+	stockNotificationService := service.NewStockNotificationService(txRunner, productRepo, stockSubscriptionRepo, notifier)
+	inventoryService := service.NewInventoryService(txRunner, productRepo, inventoryMovementRepo, stockNotificationService)
+	orderService := service.NewOrderService(
+		txRunner,
+		orderRepo,
+		productRepo,
+		orderItemRepo,
+		paymentRepo,
+		accountRepo,
+		idGenerator,
+		cursorService,
+		stockNotificationService)
+	wishlistService := service.NewWishlistService(wishlistRepo, productRepo, productVariantRepo, priceChangeRepo, accountRepo, orderService, notifier)
+	promotionService := service.NewPromotionService(txRunner, promotionRepo)
+	pricingService := service.NewPricingService(txRunner, productRepo, priceChangeRepo, priceHistoryRepo, priceListRepo, wishlistService)
+	taxService := service.NewTaxService(txRunner, taxRuleRepo)
+	shippingService := service.NewShippingService(txRunner, shippingMethodRepo, productRepo, productVariantRepo, priceChangeRepo, priceListRepo)
+	accountService := service.NewAccountService(jwtService, accountRepo, notifier)
+	paymentService := service.NewPaymentService(txRunner, orderRepo, paymentRepo)
+	variantService := service.NewVariantService(txRunner, stockNotificationService)
+	shipmentService := service.NewShipmentService(txRunner, orderRepo, idGenerator)
+	addressService := service.NewAddressService(txRunner, addressRepo)
+	guestCheckoutService := service.NewGuestCheckoutService(txRunner, orderRepo, orderService, paymentService)
+
+	// Initalize handler
+	errorHandler := handler.NewErrorHandler()
+	productHandler := handler.NewProductHandler(productService, errorHandler)
+	orderHandler := handler.NewOrderHandler(orderService, errorHandler)
+	accountHandler := handler.NewAccountHandler(accountService, orderService, errorHandler)
+	paymentHandler := handler.NewPaymentHandler(paymentService, errorHandler)
+	productImageHandler := handler.NewProductImageHandler(productImageService, errorHandler)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService, errorHandler)
+	variantHandler := handler.NewVariantHandler(variantService, errorHandler)
+	stockSubscriptionHandler := handler.NewStockSubscriptionHandler(stockNotificationService, errorHandler)
+	productReviewHandler := handler.NewProductReviewHandler(productReviewService, errorHandler)
+	wishlistHandler := handler.NewWishlistHandler(wishlistService, errorHandler)
+	promotionHandler := handler.NewPromotionHandler(promotionService, errorHandler)
+	pricingHandler := handler.NewPricingHandler(pricingService, errorHandler)
+	taxHandler := handler.NewTaxHandler(taxService, errorHandler)
+	shippingHandler := handler.NewShippingHandler(shippingService, errorHandler)
+	shipmentHandler := handler.NewShipmentHandler(shipmentService, errorHandler)
+	addressHandler := handler.NewAddressHandler(addressService, errorHandler)
+	guestCheckoutHandler := handler.NewGuestCheckoutHandler(guestCheckoutService, errorHandler)
+
+	// Initialize middleware
+	authMiddleware := middlewares.NewAuthMiddleware(jwtService, errorHandler)
+	staffMiddleware := middlewares.NewRoleMiddleware(accountService, errorHandler, constant.AccountRoleStaff, constant.AccountRoleAdmin)
+
+	// Setup routes
+	router := gin.New()
+
+	router = route.SetupProductRoutes(productHandler, productReviewHandler, router)
+	router = route.SetupShippingRoutes(shippingHandler, router)
+	router = route.SetupGuestRoutes(guestCheckoutHandler, router)
+	router = route.SetupOrderRoutes(orderHandler, shipmentHandler, authMiddleware, router)
+	router = route.SetupAuthRoutes(accountHandler, authMiddleware, router)
+	router = route.SetupAccountRoutes(accountHandler, orderHandler, stockSubscriptionHandler, productReviewHandler, wishlistHandler, addressHandler, guestCheckoutHandler, authMiddleware, router)
+	router = route.SetuPaymentRoutes(paymentHandler, authMiddleware, router)
+	router = route.SetupAdminRoutes(productImageHandler, inventoryHandler, productReviewHandler, promotionHandler, pricingHandler, taxHandler, shippingHandler, shipmentHandler, variantHandler, authMiddleware, staffMiddleware, router)
+
+	if cfg.Storage.ServesMedia() {
+		router.Static(cfg.Storage.BaseURL, cfg.Storage.LocalDir)
+	}
+
+	return router
+}
+
+func OpenDatabase(cfg config.DatabaseConfig) (*gorm.DB, error) {
+
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	return db, nil
+}
@@ -0,0 +1,97 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+)
+
+/*
+*
+
+	Money is an amount in the minor unit of its currency (cents of USD, whole rupiah) :
+	- Amounts of different currencies never add up, Add and Sub refuse them with an ErrValidation
+	- An amount that does not fit in 64 bits is an ErrConflict, like the order totals
+
+*
+*/
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// NormalizeCurrency upper cases a currency code, the default currency when it is empty
+func NormalizeCurrency(currency string) string {
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+
+	if currency == "" {
+		return constant.DefaultCurrency
+	}
+
+	return currency
+}
+
+// IsSupportedCurrency reports whether the code is one of the currencies sold in
+func IsSupportedCurrency(currency string) bool {
+
+	_, supported := constant.CurrencyDecimals[currency]
+
+	return supported
+}
+
+func (m Money) Add(other Money) (Money, error) {
+
+	err := m.sameCurrency(other)
+
+	if err != nil {
+		return m, err
+	}
+
+	if (other.Amount > 0 && m.Amount > math.MaxInt64-other.Amount) || (other.Amount < 0 && m.Amount < math.MinInt64-other.Amount) {
+		return m, NewError(fmt.Errorf("%s overflows adding %s", m, other), ErrConflict)
+	}
+
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// String formats the amount with the decimals of its currency, e.g. USD 12.50 or IDR 15000
+func (m Money) String() string {
+
+	decimals := constant.CurrencyDecimals[m.Currency]
+
+	if decimals == 0 {
+		return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+	}
+
+	sign := ""
+	amount := m.Amount
+
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	unit := int64(math.Pow10(decimals))
+
+	return fmt.Sprintf("%s %s%d.%0*d", m.Currency, sign, amount/unit, decimals, amount%unit)
+}
+
+func (m Money) sameCurrency(other Money) error {
+
+	if m.Currency != other.Currency {
+		return NewError(fmt.Errorf("can not mix %s and %s amounts", m.Currency, other.Currency), ErrValidation)
+	}
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvLocal      = "LOCAL"
+	EnvDev        = "DEV"
+	EnvProduction = "PRODUCTION"
+
+	redacted = "******"
+)
+
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Storage  StorageConfig  `yaml:"storage"`
+}
+
+type ServerConfig struct {
+	Port            string        `yaml:"port"`
+	Env             string        `yaml:"env"`
+	ReadTimeout     time.Duration `yaml:"readTimeout"`
+	WriteTimeout    time.Duration `yaml:"writeTimeout"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+}
+
+type DatabaseConfig struct {
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	User            string        `yaml:"user"`
+	Pass            string        `yaml:"pass"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"sslMode"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"`
+}
+
+type AuthConfig struct {
+	JWTSecret     string        `yaml:"jwtSecret"`
+	TokenLifetime time.Duration `yaml:"tokenLifetime"`
+
+	// Signs pagination cursors, falls back to JWTSecret when empty
+	CursorSecret string `yaml:"cursorSecret"`
+}
+
+type StorageConfig struct {
+
+	// Uploaded media are written under LocalDir, their urls start with BaseURL.
+	// A BaseURL starting with "/" is served by the API itself
+	LocalDir string `yaml:"localDir"`
+	BaseURL  string `yaml:"baseUrl"`
+}
+
+// ValidationError collects every problem found while loading the configuration,
+// so all of them can be reported at startup instead of one per restart
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// Default returns the configuration used when no source overrides a value,
+// the database host, user and name and the JWT secret have no default and must be set
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			Env:             EnvLocal,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Port:            "5432",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			TokenLifetime: 24 * time.Hour,
+		},
+		Storage: StorageConfig{
+			LocalDir: "./data/media",
+			BaseURL:  "/media",
+		},
+	}
+}
+
+/*
+*
+
+	Load builds the configuration, later sources override earlier ones :
+	- Defaults
+	- YAML file pointed by CONFIG_FILE (optional)
+	- .env file in the working directory (optional)
+	- Process environment variables
+
+*
+*/
+func Load() (Config, error) {
+
+	cfg := Default()
+
+	// .env never overrides variables already present in the environment
+	err := godotenv.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, fmt.Errorf("load .env file: %w", err)
+	}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		err = loadYAML(path, &cfg)
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	problems := &ValidationError{}
+
+	applyEnv(&cfg, problems)
+	cfg.validate(problems)
+
+	if len(problems.Problems) > 0 {
+		return cfg, problems
+	}
+
+	return cfg, nil
+}
+
+func loadYAML(path string, cfg *Config) error {
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file %s: %w", path, err)
+	}
+
+	err = yaml.Unmarshal(content, cfg)
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func applyEnv(cfg *Config, problems *ValidationError) {
+
+	setString(&cfg.Server.Port, "PORT")
+	setString(&cfg.Server.Env, "ENV")
+	setDuration(&cfg.Server.ReadTimeout, "SERVER_READ_TIMEOUT", problems)
+	setDuration(&cfg.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT", problems)
+	setDuration(&cfg.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT", problems)
+	setDuration(&cfg.Server.ShutdownTimeout, "SERVER_SHUTDOWN_TIMEOUT", problems)
+
+	setString(&cfg.Database.Host, "DATABASE_HOST")
+	setString(&cfg.Database.Port, "DATABASE_PORT")
+	setString(&cfg.Database.User, "DATABASE_USER")
+	setString(&cfg.Database.Pass, "DATABASE_PASS")
+	setString(&cfg.Database.Name, "DATABASE_NAME")
+	setString(&cfg.Database.SSLMode, "DATABASE_SSLMODE")
+	setInt(&cfg.Database.MaxOpenConns, "DATABASE_MAX_OPEN_CONNS", problems)
+	setInt(&cfg.Database.MaxIdleConns, "DATABASE_MAX_IDLE_CONNS", problems)
+	setDuration(&cfg.Database.ConnMaxLifetime, "DATABASE_CONN_MAX_LIFETIME", problems)
+	setDuration(&cfg.Database.ConnMaxIdleTime, "DATABASE_CONN_MAX_IDLE_TIME", problems)
+
+	setString(&cfg.Auth.JWTSecret, "JWT_SECRET")
+	setDuration(&cfg.Auth.TokenLifetime, "JWT_TOKEN_LIFETIME", problems)
+	setString(&cfg.Auth.CursorSecret, "CURSOR_SECRET")
+
+	setString(&cfg.Storage.LocalDir, "STORAGE_LOCAL_DIR")
+	setString(&cfg.Storage.BaseURL, "STORAGE_BASE_URL")
+}
+
+func setString(target *string, key string) {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		*target = value
+	}
+}
+
+func setInt(target *int, key string, problems *ValidationError) {
+
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		problems.add("%s must be an integer, got %q", key, value)
+		return
+	}
+
+	*target = parsed
+}
+
+func setDuration(target *time.Duration, key string, problems *ValidationError) {
+
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		problems.add("%s must be a duration such as 30s or 5m, got %q", key, value)
+		return
+	}
+
+	*target = parsed
+}
+
+func (c *Config) validate(problems *ValidationError) {
+
+	port, err := strconv.Atoi(c.Server.Port)
+	if err != nil || port < 1 || port > 65535 {
+		problems.add("PORT must be a number between 1 and 65535, got %q", c.Server.Port)
+	}
+
+	switch c.Server.Env {
+	case EnvLocal, EnvDev, EnvProduction:
+	default:
+		problems.add("ENV must be one of %s, %s or %s, got %q", EnvLocal, EnvDev, EnvProduction, c.Server.Env)
+	}
+
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 || c.Server.ShutdownTimeout <= 0 {
+		problems.add("server timeouts must be greater than 0")
+	}
+
+	if c.Database.Host == "" {
+		problems.add("DATABASE_HOST is required")
+	}
+
+	if c.Database.User == "" {
+		problems.add("DATABASE_USER is required")
+	}
+
+	if c.Database.Name == "" {
+		problems.add("DATABASE_NAME is required")
+	}
+
+	dbPort, err := strconv.Atoi(c.Database.Port)
+	if err != nil || dbPort < 1 || dbPort > 65535 {
+		problems.add("DATABASE_PORT must be a number between 1 and 65535, got %q", c.Database.Port)
+	}
+
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems.add("DATABASE_SSLMODE is not a valid postgres sslmode, got %q", c.Database.SSLMode)
+	}
+
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		problems.add("database pool sizes must not be negative")
+	}
+
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems.add("DATABASE_MAX_IDLE_CONNS (%d) must not exceed DATABASE_MAX_OPEN_CONNS (%d)", c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		problems.add("database connection lifetimes must not be negative")
+	}
+
+	if c.Auth.JWTSecret == "" {
+		problems.add("JWT_SECRET is required")
+	}
+
+	if c.Auth.TokenLifetime <= 0 {
+		problems.add("JWT_TOKEN_LIFETIME must be greater than 0")
+	}
+
+	if c.Storage.LocalDir == "" {
+		problems.add("STORAGE_LOCAL_DIR is required")
+	}
+
+	if c.Storage.BaseURL == "" {
+		problems.add("STORAGE_BASE_URL is required")
+	}
+}
+
+func (c Config) IsProduction() bool {
+	return c.Server.Env == EnvProduction
+}
+
+func (a AuthConfig) CursorSigningSecret() string {
+
+	if a.CursorSecret != "" {
+		return a.CursorSecret
+	}
+
+	return a.JWTSecret
+}
+
+// ServesMedia is true when uploaded media are served by the API rather than a CDN
+func (s StorageConfig) ServesMedia() bool {
+	return strings.HasPrefix(s.BaseURL, "/")
+}
+
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", d.Host, d.User, d.Pass, d.Name, d.Port, d.SSLMode)
+}
+
+// Redacted returns a copy that is safe to print or log
+func (c Config) Redacted() Config {
+
+	if c.Database.Pass != "" {
+		c.Database.Pass = redacted
+	}
+
+	if c.Auth.JWTSecret != "" {
+		c.Auth.JWTSecret = redacted
+	}
+
+	if c.Auth.CursorSecret != "" {
+		c.Auth.CursorSecret = redacted
+	}
+
+	return c
+}
+
+// plainConfig has no String method, printing it can not recurse into Config.String
+type plainConfig Config
+
+func (c Config) String() string {
+	return fmt.Sprintf("%+v", plainConfig(c.Redacted()))
+}
@@ -0,0 +1,182 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/config"
+)
+
+var configKeys = []string{
+	"CONFIG_FILE", "PORT", "ENV",
+	"SERVER_READ_TIMEOUT", "SERVER_WRITE_TIMEOUT", "SERVER_IDLE_TIMEOUT", "SERVER_SHUTDOWN_TIMEOUT",
+	"DATABASE_HOST", "DATABASE_PORT", "DATABASE_USER", "DATABASE_PASS", "DATABASE_NAME", "DATABASE_SSLMODE",
+	"DATABASE_MAX_OPEN_CONNS", "DATABASE_MAX_IDLE_CONNS", "DATABASE_CONN_MAX_LIFETIME", "DATABASE_CONN_MAX_IDLE_TIME",
+	"JWT_SECRET", "JWT_TOKEN_LIFETIME", "CURSOR_SECRET", "STORAGE_LOCAL_DIR", "STORAGE_BASE_URL",
+}
+
+// isolate runs the test in an empty directory without any configuration variable,
+// the variables set by the test or its .env file are restored afterwards
+func isolate(t *testing.T) string {
+
+	t.Helper()
+
+	for _, key := range configKeys {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	return dir
+}
+
+func writeFile(t *testing.T, path string, content string) {
+
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestLoadLaterSourcesOverrideEarlierOnes(t *testing.T) {
+
+	dir := isolate(t)
+
+	writeFile(t, filepath.Join(dir, "config.yaml"), `
+server:
+  port: "7000"
+  readTimeout: 20s
+database:
+  host: yaml-host
+  user: yaml-user
+  name: yaml-db
+auth:
+  jwtSecret: yaml-secret
+`)
+
+	writeFile(t, filepath.Join(dir, ".env"), "PORT=7100\nDATABASE_USER=dotenv-user\nCONFIG_FILE=config.yaml\n")
+
+	t.Setenv("PORT", "7200")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		got      interface{}
+		expected interface{}
+	}{
+		{"environment over .env", cfg.Server.Port, "7200"},
+		{".env over yaml", cfg.Database.User, "dotenv-user"},
+		{"yaml over defaults", cfg.Database.Host, "yaml-host"},
+		{"yaml duration", cfg.Server.ReadTimeout, 20 * time.Second},
+		{"default", cfg.Server.WriteTimeout, 15 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, tt.got)
+			}
+		})
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+
+	isolate(t)
+
+	t.Setenv("PORT", "0")
+	t.Setenv("DATABASE_USER", "terraloom")
+	t.Setenv("DATABASE_NAME", "terraloom")
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("DATABASE_MAX_OPEN_CONNS", "many")
+	t.Setenv("SERVER_READ_TIMEOUT", "soon")
+
+	_, err := config.Load()
+
+	var validationError *config.ValidationError
+
+	if !errors.As(err, &validationError) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	expected := []string{
+		"SERVER_READ_TIMEOUT must be a duration",
+		"DATABASE_MAX_OPEN_CONNS must be an integer",
+		"PORT must be a number between 1 and 65535",
+		"DATABASE_HOST is required",
+	}
+
+	if len(validationError.Problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %q", len(expected), validationError.Problems)
+	}
+
+	for i, problem := range expected {
+		if !strings.HasPrefix(validationError.Problems[i], problem) {
+			t.Fatalf("expected %q, got %q", problem, validationError.Problems[i])
+		}
+	}
+}
+
+func TestLoadRejectsAnUnreadableConfigFile(t *testing.T) {
+
+	dir := isolate(t)
+
+	writeFile(t, filepath.Join(dir, "config.yaml"), "server:\n  readTimeout: [15s\n")
+	t.Setenv("CONFIG_FILE", filepath.Join(dir, "config.yaml"))
+
+	_, err := config.Load()
+
+	if err == nil || !strings.Contains(err.Error(), "parse config file") {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+
+	t.Setenv("CONFIG_FILE", filepath.Join(dir, "missing.yaml"))
+
+	_, err = config.Load()
+
+	if err == nil || !strings.Contains(err.Error(), "read config file") {
+		t.Fatalf("expected a read error, got %v", err)
+	}
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+
+	cfg := config.Default()
+	cfg.Database.User = "terraloom"
+	cfg.Database.Pass = "db-password"
+	cfg.Auth.JWTSecret = "jwt-secret"
+	cfg.Auth.CursorSecret = "cursor-secret"
+
+	printed := cfg.String()
+
+	for _, secret := range []string{"db-password", "jwt-secret", "cursor-secret"} {
+		if strings.Contains(printed, secret) {
+			t.Fatalf("expected %q to be redacted from %s", secret, printed)
+		}
+	}
+
+	if !strings.Contains(printed, "terraloom") || strings.Count(printed, "******") != 3 {
+		t.Fatalf("expected only the secrets redacted, got %s", printed)
+	}
+
+	if cfg.Database.Pass != "db-password" || cfg.Auth.CursorSigningSecret() != "cursor-secret" {
+		t.Fatalf("expected the configuration itself untouched, got %+v", cfg.Auth)
+	}
+
+	// An unset secret stays visibly unset
+	if redacted := config.Default().Redacted(); redacted.Auth.JWTSecret != "" || redacted.Database.Pass != "" {
+		t.Fatalf("expected empty secrets to stay empty, got %+v", redacted)
+	}
+}
@@ -0,0 +1,19 @@
+package constant
+
+// Currencies sold in, ISO 4217 codes. Amounts are integers in the minor unit of their currency
+const (
+	CurrencyIDR = "IDR"
+	CurrencySGD = "SGD"
+	CurrencyUSD = "USD"
+
+	// DefaultCurrency prices the products and the orders that do not name a currency
+	DefaultCurrency = CurrencyIDR
+)
+
+// CurrencyDecimals is the number of decimals of the minor unit of each supported currency,
+// rupiah amounts are whole rupiah
+var CurrencyDecimals = map[string]int{
+	CurrencyIDR: 0,
+	CurrencySGD: 2,
+	CurrencyUSD: 2,
+}
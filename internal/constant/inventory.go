@@ -0,0 +1,12 @@
+package constant
+
+// Inventory movement types, quantities are negative when stock leaves
+const (
+	MovementTypeOpeningBalance     = "OPENING_BALANCE"
+	MovementTypeOrderReservation   = "ORDER_RESERVATION"
+	MovementTypeCancellationReturn = "CANCELLATION_RETURN"
+	MovementTypeModificationReturn = "MODIFICATION_RETURN"
+	MovementTypeManualAdjustment   = "MANUAL_ADJUSTMENT"
+	MovementTypeRestock            = "RESTOCK"
+	MovementTypeRefund             = "REFUND"
+)
@@ -0,0 +1,14 @@
+package constant
+
+// Order history actions
+const (
+	OrderActionModified = "MODIFIED"
+)
+
+// Changes recorded by a modification of an order
+const (
+	OrderChangeItemAdded       = "ITEM_ADDED"
+	OrderChangeItemRemoved     = "ITEM_REMOVED"
+	OrderChangeQuantityChanged = "QUANTITY_CHANGED"
+	OrderChangeDeliveryChanged = "DELIVERY_CHANGED"
+)
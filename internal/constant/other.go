@@ -2,4 +2,5 @@ package constant
 
 const (
 	SYSTEM string = "SYSTEM"
+	GUEST  string = "GUEST" // created_by of the rows written by a guest checkout
 )
@@ -0,0 +1,6 @@
+package constant
+
+const (
+	PaginationModeOffset = "offset"
+	PaginationModeCursor = "cursor"
+)
@@ -0,0 +1,7 @@
+package constant
+
+// Price history entries, BASE is the list price and SALE the sale price
+const (
+	PriceTypeBase = "BASE"
+	PriceTypeSale = "SALE"
+)
@@ -0,0 +1,9 @@
+package constant
+
+// Promotion types, FREE_SHIPPING waives the shipping fee and discounts no item
+const (
+	PromotionTypePercentage   = "PERCENTAGE"
+	PromotionTypeFixedAmount  = "FIXED_AMOUNT"
+	PromotionTypeFreeShipping = "FREE_SHIPPING"
+	PromotionTypeBuyXGetY     = "BUY_X_GET_Y"
+)
@@ -0,0 +1,10 @@
+package constant
+
+// Why a line of a previous order is dropped or changed by a reorder
+const (
+	ReorderReasonNotSold         = "NOT_SOLD"         // the product or its variant is no longer sold
+	ReorderReasonVariantRequired = "VARIANT_REQUIRED" // the product is now sold by variant
+	ReorderReasonOutOfStock      = "OUT_OF_STOCK"
+	ReorderReasonNotInCurrency   = "NOT_IN_CURRENCY" // the product has no price in the currency of the order
+	ReorderReasonQuantityReduced = "QUANTITY_REDUCED"
+)
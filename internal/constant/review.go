@@ -0,0 +1,12 @@
+package constant
+
+// Review statuses, hidden reviews are only visible to their author and staff
+const (
+	ReviewStatusPublished = "PUBLISHED"
+	ReviewStatusHidden    = "HIDDEN"
+)
+
+const (
+	ReviewRatingMin = 1
+	ReviewRatingMax = 5
+)
@@ -0,0 +1,7 @@
+package constant
+
+const (
+	AccountRoleCustomer = "CUSTOMER"
+	AccountRoleStaff    = "STAFF"
+	AccountRoleAdmin    = "ADMIN"
+)
@@ -0,0 +1,6 @@
+package constant
+
+const (
+	SearchModeFullText = "fulltext"
+	SearchModeFuzzy    = "fuzzy"
+)
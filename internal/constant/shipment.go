@@ -0,0 +1,7 @@
+package constant
+
+// Shipment statuses, a shipment is delivered once it has a delivery date
+const (
+	ShipmentStatusInTransit = "IN TRANSIT"
+	ShipmentStatusDelivered = "DELIVERED"
+)
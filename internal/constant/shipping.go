@@ -0,0 +1,7 @@
+package constant
+
+// Shipping method types, FLAT_RATE charges one fee, WEIGHT_TIERED charges by parcel weight
+const (
+	ShippingTypeFlatRate     = "FLAT_RATE"
+	ShippingTypeWeightTiered = "WEIGHT_TIERED"
+)
@@ -0,0 +1,12 @@
+package constant
+
+const (
+	SortDirectionAsc  = "asc"
+	SortDirectionDesc = "desc"
+
+	ProductSortPrice       = "price"
+	ProductSortName        = "name"
+	ProductSortNewest      = "newest"
+	ProductSortBestSelling = "best-selling"
+	ProductSortRating      = "rating"
+)
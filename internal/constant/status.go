@@ -4,6 +4,7 @@ const (
 	OrderStatusPendingPayment  = "PENDING PAYMENT"
 	OrderStatusPaymentReceived = "PAYMENT RECEIVED"
 	OrderStatusProcessed       = "PROCESSED"
+	OrderStatusShipped         = "SHIPPED"
 	OrderStatusFinished        = "FINISHED"
 	OrderStatusCancelled       = "CANCELLED"
 	PaymentStatusPending       = "PENDING"
@@ -0,0 +1,7 @@
+package constant
+
+// Tax modes, an INCLUSIVE tax is part of the price, an EXCLUSIVE tax is added on top of it
+const (
+	TaxModeInclusive = "INCLUSIVE"
+	TaxModeExclusive = "EXCLUSIVE"
+)
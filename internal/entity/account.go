@@ -7,15 +7,26 @@ type Account struct {
 	Username          string     `gorm:"column:username"`
 	DisplayName       string     `gorm:"column:display_name"`
 	Email             string     `gorm:"column:email"`
+	EmailVerifiedAt   *time.Time `gorm:"column:email_verified_at"` // nil until the email is verified, reset when it changes
 	LoginPassword     string     `gorm:"column:login_password"`
 	RegisteredAddress string     `gorm:"column:registered_address"`
 	IsActive          bool       `gorm:"column:is_active"`
+	Role              string     `gorm:"column:role;default:CUSTOMER"`
 	CreatedAt         time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
 	UpdatedAt         time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
 	CreatedBy         string     `gorm:"column:created_by;size:100"`
 	UpdatedBy         string     `gorm:"column:updated_by;size:100"`
 	DeletedAt         *time.Time `gorm:"column:deleted_at"`
 
+	// Pending verification code, SHA-256 of the code sent to the email
+	EmailVerificationHash      string     `gorm:"column:email_verification_hash"`
+	EmailVerificationExpiresAt *time.Time `gorm:"column:email_verification_expires_at"`
+	EmailVerificationAttempts  int64      `gorm:"column:email_verification_attempts"`
+
+	// Codes requested since the window started, see AccountRepository.StartEmailVerification
+	EmailVerificationRequests        int64      `gorm:"column:email_verification_requests"`
+	EmailVerificationWindowStartedAt *time.Time `gorm:"column:email_verification_window_started_at"`
+
 	Orders []Order `gorm:"foreignKey:AccountUsername;references:Username"`
 }
 
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Address is an entry of the address book of an account
+type Address struct {
+	ID              int64  `gorm:"primaryKey;column:id"`
+	AccountUsername string `gorm:"column:account_username"`
+	PostalAddress   `gorm:"embedded"`
+	IsDefault       bool      `gorm:"column:is_default"`
+	CreatedAt       time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+}
+
+func (Address) TableName() string {
+	return "addresses"
+}
+
+// PostalAddress is where a parcel is delivered, the order keeps a copy of it
+type PostalAddress struct {
+	RecipientName string `gorm:"column:recipient_name"`
+	Phone         string `gorm:"column:phone"`
+	Line1         string `gorm:"column:line1"`
+	Line2         string `gorm:"column:line2"`
+	City          string `gorm:"column:city"`
+	Region        string `gorm:"column:region"`
+	PostalCode    string `gorm:"column:postal_code"`
+	Country       string `gorm:"column:country"`
+}
+
+// String formats the address on one line, empty parts are left out
+func (pa PostalAddress) String() string {
+
+	var parts []string
+
+	for _, part := range []string{pa.RecipientName, pa.Line1, pa.Line2, pa.City, strings.TrimSpace(pa.Region + " " + pa.PostalCode), pa.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
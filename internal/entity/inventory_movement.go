@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// InventoryMovement is an entry of the append-only stock ledger. StockAfter is the stock of the
+// variant when VariantID is set, of the product otherwise
+type InventoryMovement struct {
+	ID           int64     `gorm:"primaryKey;column:id"`
+	ProductID    int64     `gorm:"column:product_id"`
+	VariantID    *int64    `gorm:"column:variant_id"`
+	MovementType string    `gorm:"column:movement_type"`
+	Quantity     int64     `gorm:"column:quantity"`
+	StockAfter   int64     `gorm:"column:stock_after"`
+	Reference    string    `gorm:"column:reference"`
+	Note         string    `gorm:"column:note"`
+	CreatedAt    time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy    string    `gorm:"column:created_by"`
+}
+
+func (InventoryMovement) TableName() string {
+	return "inventory_movements"
+}
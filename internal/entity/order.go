@@ -3,21 +3,43 @@ package entity
 import "time"
 
 type Order struct {
-	OrderReference  string     `gorm:"primaryKey;column:order_reference"`
-	OrderDate       time.Time  `gorm:"column:order_date;default:CURRENT_TIMESTAMP"`
-	AccountUsername string     `gorm:"column:account_username"`
-	DeliveryAddress string     `gorm:"column:delivery_address"`
-	Status          string     `gorm:"column:status;default:PENDING;size:200"`
-	Total           int64      `gorm:"column:total;default:0"`
-	CreatedAt       time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
-	UpdatedAt       time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
-	CreatedBy       string     `gorm:"column:created_by;size:100"`
-	UpdatedBy       string     `gorm:"column:updated_by;size:100"`
-	DeletedAt       *time.Time `gorm:"column:deleted_at"`
+	OrderReference   string     `gorm:"primaryKey;column:order_reference"`
+	OrderDate        time.Time  `gorm:"column:order_date;default:CURRENT_TIMESTAMP"`
+	AccountUsername  *string    `gorm:"column:account_username"` // nil for a guest order until it is claimed
+	GuestEmail       string     `gorm:"column:guest_email"`
+	GuestTokenHash   string     `gorm:"column:guest_token_hash"` // SHA-256 of the access token of a guest order
+	DeliveryAddress  string     `gorm:"column:delivery_address"` // one line, the structured copy is in Delivery
+	AddressID        *int64     `gorm:"column:address_id"`       // address book entry, nil once deleted
+	Status           string     `gorm:"column:status;default:PENDING;size:200"`
+	Currency         string     `gorm:"column:currency"` // of every amount of the order, its items and its payment
+	Subtotal         int64      `gorm:"column:subtotal;default:0"`
+	DiscountTotal    int64      `gorm:"column:discount_total;default:0"`
+	TaxRegion        string     `gorm:"column:tax_region"`
+	ShippingMethodID *int64     `gorm:"column:shipping_method_id"` // nil when the order is not shipped
+	ShippingMethod   string     `gorm:"column:shipping_method"`
+	ShippingWeight   int64      `gorm:"column:shipping_weight;default:0"` // grams
+	ShippingFee      int64      `gorm:"column:shipping_fee;default:0"`
+	TaxTotal         int64      `gorm:"column:tax_total;default:0"`
+	TaxIncluded      int64      `gorm:"column:tax_included;default:0"` // part of TaxTotal included in the prices
+	Total            int64      `gorm:"column:total;default:0"`        // subtotal plus shipping minus discounts plus exclusive taxes
+	CreatedAt        time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy        string     `gorm:"column:created_by;size:100"`
+	UpdatedBy        string     `gorm:"column:updated_by;size:100"`
+	DeletedAt        *time.Time `gorm:"column:deleted_at"`
+
+	// Empty for orders placed with a free text address
+	Delivery PostalAddress `gorm:"embedded;embeddedPrefix:delivery_"`
 
 	// Relationship: One order has many order items
 	OrderItems []OrderItem `gorm:"foreignKey:OrderReference;references:OrderReference"`
 
+	Discounts []OrderDiscount `gorm:"foreignKey:OrderReference;references:OrderReference"`
+
+	Shipments []Shipment `gorm:"foreignKey:OrderReference;references:OrderReference"`
+
+	History []OrderHistory `gorm:"foreignKey:OrderReference;references:OrderReference"`
+
 	// Pointer avoids recursive allocation
 	Payment *Payment `gorm:"foreignKey:OrderReference;references:OrderReference"`
 
@@ -27,3 +49,13 @@ type Order struct {
 func (Order) TableName() string {
 	return "orders"
 }
+
+// IsOwnedBy reports whether the order belongs to the account, a guest order belongs to no account
+func (o Order) IsOwnedBy(username string) bool {
+	return o.AccountUsername != nil && *o.AccountUsername == username
+}
+
+// IsGuest reports whether the order is a guest order not claimed into an account yet
+func (o Order) IsGuest() bool {
+	return o.AccountUsername == nil
+}
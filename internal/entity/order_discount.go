@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// OrderDiscount is a promotion applied to an order, the code and amount are kept as redeemed
+type OrderDiscount struct {
+	ID             int64     `gorm:"primaryKey;column:id"`
+	OrderReference string    `gorm:"column:order_reference"`
+	PromotionID    int64     `gorm:"column:promotion_id"`
+	Code           string    `gorm:"column:code"`
+	PromotionType  string    `gorm:"column:promotion_type"`
+	Description    string    `gorm:"column:description"`
+	Amount         int64     `gorm:"column:amount"`
+	CreatedAt      time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+}
+
+func (OrderDiscount) TableName() string {
+	return "order_discounts"
+}
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OrderHistory records a change of an order after it was submitted
+type OrderHistory struct {
+	ID             int64        `gorm:"primaryKey;column:id"`
+	OrderReference string       `gorm:"column:order_reference"`
+	Action         string       `gorm:"column:action"`
+	Changes        OrderChanges `gorm:"column:changes;type:jsonb"`
+	PreviousTotal  int64        `gorm:"column:previous_total"`
+	Total          int64        `gorm:"column:total"`
+	CreatedAt      time.Time    `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy      string       `gorm:"column:created_by;size:100"`
+}
+
+func (OrderHistory) TableName() string {
+	return "order_history"
+}
+
+// OrderChange is a line added, removed or changed, or the delivery changed
+type OrderChange struct {
+	Type               string `json:"type"`
+	OrderItemReference string `json:"orderItemReference,omitempty"`
+	ProductID          int64  `json:"productId,omitempty"`
+	VariantID          *int64 `json:"variantId,omitempty"`
+	PreviousQuantity   int64  `json:"previousQuantity,omitempty"`
+	Quantity           int64  `json:"quantity,omitempty"`
+	PreviousDelivery   string `json:"previousDelivery,omitempty"`
+	Delivery           string `json:"delivery,omitempty"`
+}
+
+// OrderChanges is stored as jsonb on the order history
+type OrderChanges []OrderChange
+
+func (c OrderChanges) Value() (driver.Value, error) {
+
+	if c == nil {
+		return "[]", nil
+	}
+
+	content, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(content), nil
+}
+
+func (c *OrderChanges) Scan(src interface{}) error {
+
+	switch value := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, c)
+	case string:
+		return json.Unmarshal([]byte(value), c)
+	default:
+		return fmt.Errorf("unsupported order changes type %T", src)
+	}
+}
@@ -6,6 +6,7 @@ type Payment struct {
 	PaymentReference string     `gorm:"primaryKey;column:payment_reference"`
 	OrderReference   string     `gorm:"not null;index"`
 	Total            int64      `gorm:"column:total"`
+	Currency         string     `gorm:"column:currency"` // always the currency of the order
 	CardHolderName   string     `gorm:"column:card_holder_name"`
 	CardNumber       string     `gorm:"column:card_number"`
 	Status           string     `gorm:"column:status"`
@@ -2,20 +2,37 @@ package entity
 
 import "time"
 
+// volumetricDivisor turns the volume of a unit in cubic millimetres into its volumetric weight in grams
+const volumetricDivisor = 5000
+
 type Product struct {
-	ID          int64      `gorm:"primaryKey;column:id"`
-	CategoryID  int64      `gorm:"column:category_id"`
-	Name        string     `gorm:"column:name"`
-	Description string     `gorm:"column:description"`
-	Stock       int64      `gorm:"column:stock"`
-	Price       int64      `gorm:"column:price"`
-	ImageUrl    string     `gorm:"column:image_url"`
-	IsActive    bool       `gorm:"column:is_active"`
-	CreatedAt   time.Time  `gorm:"column:created_at"`
-	UpdatedAt   time.Time  `gorm:"column:updated_at"`
-	CreatedBy   string     `gorm:"column:created_by"`
-	UpdatedBy   string     `gorm:"column:updated_by"`
-	DeletedAt   *time.Time `gorm:"column:deleted_at"`
+	ID                int64      `gorm:"primaryKey;column:id"`
+	CategoryID        int64      `gorm:"column:category_id"`
+	Name              string     `gorm:"column:name"`
+	Description       string     `gorm:"column:description"`
+	Stock             int64      `gorm:"column:stock"`
+	Price             int64      `gorm:"column:price"`
+	Currency          string     `gorm:"column:currency"`   // of the price, the sale price and the variant prices
+	SalePrice         *int64     `gorm:"column:sale_price"` // charged instead of Price while the sale runs
+	SaleStartsAt      *time.Time `gorm:"column:sale_starts_at"`
+	SaleEndsAt        *time.Time `gorm:"column:sale_ends_at"`
+	ImageUrl          string     `gorm:"column:image_url"`
+	IsActive          bool       `gorm:"column:is_active"`
+	LowStockThreshold int64      `gorm:"column:low_stock_threshold"` // 0 disables the low stock alert
+	RatingAverage     float64    `gorm:"column:rating_average"`      // average of the published reviews
+	ReviewCount       int64      `gorm:"column:review_count"`
+	Weight            int64      `gorm:"column:weight"` // grams per unit
+	Length            int64      `gorm:"column:length"` // millimetres
+	Width             int64      `gorm:"column:width"`
+	Height            int64      `gorm:"column:height"`
+	CreatedAt         time.Time  `gorm:"column:created_at"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at"`
+	CreatedBy         string     `gorm:"column:created_by"`
+	UpdatedBy         string     `gorm:"column:updated_by"`
+	DeletedAt         *time.Time `gorm:"column:deleted_at"`
+
+	// Price in the currency of a listing, only read by the listings and the searches
+	ListingPrice int64 `gorm:"column:listing_price;->"`
 }
 
 func (Product) TableName() string {
@@ -25,3 +42,32 @@ func (Product) TableName() string {
 func (p *Product) IsDeleted() bool {
 	return p.DeletedAt != nil
 }
+
+// ShippingWeight is the weight charged for one unit, the volumetric weight when the unit is bulkier than heavy
+func (p *Product) ShippingWeight() int64 {
+	return max(p.Weight, p.Length*p.Width*p.Height/volumetricDivisor)
+}
+
+// IsOnSale reports whether the sale runs at the given time and undercuts the price
+func (p *Product) IsOnSale(at time.Time) bool {
+
+	if p.SalePrice == nil || *p.SalePrice >= p.Price {
+		return false
+	}
+
+	if p.SaleStartsAt != nil && at.Before(*p.SaleStartsAt) {
+		return false
+	}
+
+	return p.SaleEndsAt == nil || at.Before(*p.SaleEndsAt)
+}
+
+// PriceAt is the price charged at the given time, the sale price while the sale runs
+func (p *Product) PriceAt(at time.Time) int64 {
+
+	if p.IsOnSale(at) {
+		return *p.SalePrice
+	}
+
+	return p.Price
+}
@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+type ProductImage struct {
+	ID          int64     `gorm:"primaryKey;column:id"`
+	ProductID   int64     `gorm:"column:product_id"`
+	StorageKey  string    `gorm:"column:storage_key"`
+	URL         string    `gorm:"column:url"`
+	AltText     string    `gorm:"column:alt_text"`
+	Position    int       `gorm:"column:position"`
+	IsPrimary   bool      `gorm:"column:is_primary"`
+	ContentType string    `gorm:"column:content_type"`
+	Width       int       `gorm:"column:width"`
+	Height      int       `gorm:"column:height"`
+	CreatedAt   time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy   string    `gorm:"column:created_by"`
+	UpdatedBy   string    `gorm:"column:updated_by"`
+
+	Sizes []ProductImageSize `gorm:"foreignKey:ImageID;references:ID"`
+}
+
+func (ProductImage) TableName() string {
+	return "product_images"
+}
+
+// ProductImageSize is a thumbnail generated from a product image
+type ProductImageSize struct {
+	ImageID    int64  `gorm:"primaryKey;column:image_id"`
+	Size       string `gorm:"primaryKey;column:size"`
+	StorageKey string `gorm:"column:storage_key"`
+	URL        string `gorm:"column:url"`
+	Width      int    `gorm:"column:width"`
+	Height     int    `gorm:"column:height"`
+}
+
+func (ProductImageSize) TableName() string {
+	return "product_image_sizes"
+}
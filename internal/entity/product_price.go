@@ -0,0 +1,53 @@
+package entity
+
+import "time"
+
+// ProductPriceChange is a base price change scheduled at EffectiveAt, AppliedAt is set once the price is written
+type ProductPriceChange struct {
+	ID          int64      `gorm:"primaryKey;column:id"`
+	ProductID   int64      `gorm:"column:product_id"`
+	Price       int64      `gorm:"column:price"`
+	EffectiveAt time.Time  `gorm:"column:effective_at"`
+	AppliedAt   *time.Time `gorm:"column:applied_at"`
+	CreatedAt   time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy   string     `gorm:"column:created_by"`
+}
+
+func (ProductPriceChange) TableName() string {
+	return "product_price_changes"
+}
+
+// ProductPriceHistory records a change of the base price or of the sale
+type ProductPriceHistory struct {
+	ID            int64      `gorm:"primaryKey;column:id"`
+	ProductID     int64      `gorm:"column:product_id"`
+	PriceType     string     `gorm:"column:price_type"`
+	OldPrice      *int64     `gorm:"column:old_price"` // nil when there was no sale
+	NewPrice      *int64     `gorm:"column:new_price"` // nil when the sale ended
+	SaleStartsAt  *time.Time `gorm:"column:sale_starts_at"`
+	SaleEndsAt    *time.Time `gorm:"column:sale_ends_at"`
+	PriceChangeID *int64     `gorm:"column:price_change_id"` // the scheduled change that was applied
+	CreatedAt     time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy     string     `gorm:"column:created_by"`
+}
+
+func (ProductPriceHistory) TableName() string {
+	return "product_price_history"
+}
+
+// ProductCurrencyPrice is the price of a product, or of one of its variants, in a currency other than the product currency
+type ProductCurrencyPrice struct {
+	ID        int64     `gorm:"primaryKey;column:id"`
+	ProductID int64     `gorm:"column:product_id"`
+	VariantID *int64    `gorm:"column:variant_id"` // nil for the product
+	Currency  string    `gorm:"column:currency"`
+	Price     int64     `gorm:"column:price"`
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy string    `gorm:"column:created_by"`
+	UpdatedBy string    `gorm:"column:updated_by"`
+}
+
+func (ProductCurrencyPrice) TableName() string {
+	return "product_currency_prices"
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// ProductReview is the rating and review of a product by a customer who received it
+type ProductReview struct {
+	ID              int64      `gorm:"primaryKey;column:id"`
+	ProductID       int64      `gorm:"column:product_id"`
+	AccountUsername string     `gorm:"column:account_username"`
+	Rating          int        `gorm:"column:rating"`
+	Body            string     `gorm:"column:body"`
+	Status          string     `gorm:"column:status"`
+	ModerationNote  string     `gorm:"column:moderation_note"`
+	ModeratedAt     *time.Time `gorm:"column:moderated_at"`
+	ModeratedBy     string     `gorm:"column:moderated_by"`
+	CreatedAt       time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy       string     `gorm:"column:created_by"`
+	UpdatedBy       string     `gorm:"column:updated_by"`
+}
+
+func (ProductReview) TableName() string {
+	return "product_reviews"
+}
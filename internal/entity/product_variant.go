@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type ProductOptionType struct {
+	ID        int64  `gorm:"primaryKey;column:id"`
+	ProductID int64  `gorm:"column:product_id"`
+	Name      string `gorm:"column:name"`
+	Position  int    `gorm:"column:position"`
+}
+
+func (ProductOptionType) TableName() string {
+	return "product_option_types"
+}
+
+type ProductVariant struct {
+	ID        int64      `gorm:"primaryKey;column:id"`
+	ProductID int64      `gorm:"column:product_id"`
+	SKU       string     `gorm:"column:sku"`
+	Price     *int64     `gorm:"column:price"`
+	Stock     int64      `gorm:"column:stock"`
+	IsActive  bool       `gorm:"column:is_active"`
+	CreatedAt time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy string     `gorm:"column:created_by"`
+	UpdatedBy string     `gorm:"column:updated_by"`
+	DeletedAt *time.Time `gorm:"column:deleted_at"`
+
+	Options []ProductVariantOption `gorm:"foreignKey:VariantID;references:ID"`
+}
+
+func (ProductVariant) TableName() string {
+	return "product_variants"
+}
+
+// EffectivePrice is the variant price, or the product price at the given time when the variant does not override it
+func (v *ProductVariant) EffectivePrice(product Product, at time.Time) int64 {
+
+	if v.Price != nil {
+		return *v.Price
+	}
+
+	return product.PriceAt(at)
+}
+
+type ProductVariantOption struct {
+	VariantID    int64  `gorm:"primaryKey;column:variant_id"`
+	OptionTypeID int64  `gorm:"primaryKey;column:option_type_id"`
+	Value        string `gorm:"column:value"`
+
+	OptionType ProductOptionType `gorm:"foreignKey:OptionTypeID;references:ID"`
+}
+
+func (ProductVariantOption) TableName() string {
+	return "product_variant_options"
+}
+
+type VariantOptionSnapshot struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// VariantOptionsSnapshot is stored as jsonb on the order item
+type VariantOptionsSnapshot []VariantOptionSnapshot
+
+func (s VariantOptionsSnapshot) Value() (driver.Value, error) {
+
+	if s == nil {
+		return nil, nil
+	}
+
+	content, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(content), nil
+}
+
+func (s *VariantOptionsSnapshot) Scan(src interface{}) error {
+
+	switch value := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, s)
+	case string:
+		return json.Unmarshal([]byte(value), s)
+	default:
+		return fmt.Errorf("unsupported variant options snapshot type %T", src)
+	}
+}
@@ -0,0 +1,59 @@
+package entity
+
+import "time"
+
+// Promotion is a coupon, redeemed by entering its code at order submission
+type Promotion struct {
+	ID              int64      `gorm:"primaryKey;column:id"`
+	Code            string     `gorm:"column:code"`
+	Description     string     `gorm:"column:description"`
+	PromotionType   string     `gorm:"column:promotion_type"`
+	Value           int64      `gorm:"column:value"` // percentage or amount
+	BuyQuantity     int64      `gorm:"column:buy_quantity"`
+	GetQuantity     int64      `gorm:"column:get_quantity"`
+	MinOrderAmount  int64      `gorm:"column:min_order_amount"`
+	Currency        string     `gorm:"column:currency"`    // the coupon is only redeemed on orders in this currency
+	UsageLimit      *int64     `gorm:"column:usage_limit"` // nil for unlimited
+	PerAccountLimit *int64     `gorm:"column:per_account_limit"`
+	ProductID       *int64     `gorm:"column:product_id"` // scope, the whole order when both are nil
+	CategoryID      *int64     `gorm:"column:category_id"`
+	StartsAt        *time.Time `gorm:"column:starts_at"`
+	EndsAt          *time.Time `gorm:"column:ends_at"`
+	IsActive        bool       `gorm:"column:is_active"`
+	CreatedAt       time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy       string     `gorm:"column:created_by"`
+	UpdatedBy       string     `gorm:"column:updated_by"`
+}
+
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// IsRunning reports whether the promotion is active and now is inside its validity window
+func (p *Promotion) IsRunning(now time.Time) bool {
+
+	if !p.IsActive {
+		return false
+	}
+
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+
+	return p.EndsAt == nil || now.Before(*p.EndsAt)
+}
+
+// Applies reports whether a product is in the scope of the promotion
+func (p *Promotion) Applies(productID int64, categoryID int64) bool {
+
+	if p.ProductID != nil {
+		return *p.ProductID == productID
+	}
+
+	if p.CategoryID != nil {
+		return *p.CategoryID == categoryID
+	}
+
+	return true
+}
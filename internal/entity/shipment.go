@@ -0,0 +1,40 @@
+package entity
+
+import "time"
+
+// Shipment is a parcel of an order, DeliveredAt is nil while it is in transit
+type Shipment struct {
+	ShipmentReference string     `gorm:"primaryKey;column:shipment_reference"`
+	OrderReference    string     `gorm:"column:order_reference"`
+	Carrier           string     `gorm:"column:carrier"`
+	TrackingNumber    string     `gorm:"column:tracking_number"`
+	ShippedAt         time.Time  `gorm:"column:shipped_at"`
+	DeliveredAt       *time.Time `gorm:"column:delivered_at"`
+	CreatedAt         time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy         string     `gorm:"column:created_by"`
+	UpdatedBy         string     `gorm:"column:updated_by"`
+
+	Items []ShipmentItem `gorm:"foreignKey:ShipmentReference;references:ShipmentReference"`
+}
+
+func (Shipment) TableName() string {
+	return "shipments"
+}
+
+// IsDelivered reports whether the parcel reached the customer
+func (s *Shipment) IsDelivered() bool {
+	return s.DeliveredAt != nil
+}
+
+// ShipmentItem is the quantity of an order item in a shipment
+type ShipmentItem struct {
+	ID                 int64  `gorm:"primaryKey;column:id"`
+	ShipmentReference  string `gorm:"column:shipment_reference"`
+	OrderItemReference string `gorm:"column:order_item_reference"`
+	Quantity           int64  `gorm:"column:quantity"`
+}
+
+func (ShipmentItem) TableName() string {
+	return "shipment_items"
+}
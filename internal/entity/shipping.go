@@ -0,0 +1,74 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ShippingMethod carries the parcels of its region (every region when nil), weights are in grams
+type ShippingMethod struct {
+	ID         int64         `gorm:"primaryKey;column:id"`
+	Code       string        `gorm:"column:code"`
+	Name       string        `gorm:"column:name"`
+	MethodType string        `gorm:"column:method_type"`
+	FlatFee    int64         `gorm:"column:flat_fee"`
+	Currency   string        `gorm:"column:currency"` // of the fees, the method only carries orders in this currency
+	Tiers      ShippingTiers `gorm:"column:tiers;type:jsonb"`
+	FreeAbove  *int64        `gorm:"column:free_above"` // items subtotal shipped for free, nil for never
+	Region     *string       `gorm:"column:region"`
+	MaxWeight  *int64        `gorm:"column:max_weight"` // nil for no limit
+	IsActive   bool          `gorm:"column:is_active"`
+	CreatedAt  time.Time     `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time     `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy  string        `gorm:"column:created_by"`
+	UpdatedBy  string        `gorm:"column:updated_by"`
+}
+
+func (ShippingMethod) TableName() string {
+	return "shipping_methods"
+}
+
+// Serves reports whether the method ships to the region
+func (sm *ShippingMethod) Serves(region string) bool {
+	return sm.Region == nil || *sm.Region == region
+}
+
+// ShippingTier charges Fee for the parcels up to UpToWeight grams
+type ShippingTier struct {
+	UpToWeight int64 `json:"upToWeight"`
+	Fee        int64 `json:"fee"`
+}
+
+// ShippingTiers is stored as jsonb on the shipping method, by ascending weight
+type ShippingTiers []ShippingTier
+
+func (t ShippingTiers) Value() (driver.Value, error) {
+
+	if t == nil {
+		return nil, nil
+	}
+
+	content, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(content), nil
+}
+
+func (t *ShippingTiers) Scan(src interface{}) error {
+
+	switch value := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(value, t)
+	case string:
+		return json.Unmarshal([]byte(value), t)
+	default:
+		return fmt.Errorf("unsupported shipping tiers type %T", src)
+	}
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// StockSubscription records that a customer wants to know when a product is back in stock,
+// NotifiedAt is set once the notice has been dispatched
+type StockSubscription struct {
+	ID              int64      `gorm:"primaryKey;column:id"`
+	ProductID       int64      `gorm:"column:product_id"`
+	AccountUsername string     `gorm:"column:account_username"`
+	CreatedAt       time.Time  `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	NotifiedAt      *time.Time `gorm:"column:notified_at"`
+}
+
+func (StockSubscription) TableName() string {
+	return "stock_subscriptions"
+}
@@ -0,0 +1,67 @@
+package entity
+
+import "time"
+
+// TaxRule charges Rate basis points (1100 is 11%) of the items in its scope,
+// a nil Region or CategoryID matches every region or category
+type TaxRule struct {
+	ID         int64     `gorm:"primaryKey;column:id"`
+	Name       string    `gorm:"column:name"`
+	Region     *string   `gorm:"column:region"`
+	CategoryID *int64    `gorm:"column:category_id"`
+	Rate       int64     `gorm:"column:rate"`
+	Mode       string    `gorm:"column:mode"`
+	IsActive   bool      `gorm:"column:is_active"`
+	CreatedAt  time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+	CreatedBy  string    `gorm:"column:created_by"`
+	UpdatedBy  string    `gorm:"column:updated_by"`
+}
+
+func (TaxRule) TableName() string {
+	return "tax_rules"
+}
+
+// Matches reports whether an item of the category sold in the region is in the scope of the rule
+func (tr *TaxRule) Matches(region string, categoryID int64) bool {
+
+	if tr.Region != nil && *tr.Region != region {
+		return false
+	}
+
+	return tr.CategoryID == nil || *tr.CategoryID == categoryID
+}
+
+// Specificity ranks the scopes of a tax : region and category, region, category, then every item
+func (tr *TaxRule) Specificity() int {
+
+	specificity := 0
+
+	if tr.Region != nil {
+		specificity += 2
+	}
+
+	if tr.CategoryID != nil {
+		specificity++
+	}
+
+	return specificity
+}
+
+// OrderItemTax is a tax charged on an order item, the rule is copied as applied
+type OrderItemTax struct {
+	ID                 int64     `gorm:"primaryKey;column:id"`
+	OrderReference     string    `gorm:"column:order_reference"`
+	OrderItemReference string    `gorm:"column:order_item_reference"`
+	TaxRuleID          int64     `gorm:"column:tax_rule_id"`
+	Name               string    `gorm:"column:name"`
+	Rate               int64     `gorm:"column:rate"`
+	Mode               string    `gorm:"column:mode"`
+	TaxableAmount      int64     `gorm:"column:taxable_amount"` // the item net of every tax
+	Amount             int64     `gorm:"column:amount"`
+	CreatedAt          time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+}
+
+func (OrderItemTax) TableName() string {
+	return "order_item_taxes"
+}
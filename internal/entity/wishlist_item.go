@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// WishlistItem is a product (or variant) an account saved for later. AddedPrice is the price
+// when it was saved, ReferencePrice the price the next price drop is measured against
+type WishlistItem struct {
+	ID              int64     `gorm:"primaryKey;column:id"`
+	AccountUsername string    `gorm:"column:account_username"`
+	ProductID       int64     `gorm:"column:product_id"`
+	VariantID       *int64    `gorm:"column:variant_id"`
+	AddedPrice      int64     `gorm:"column:added_price"`
+	ReferencePrice  int64     `gorm:"column:reference_price"`
+	CreatedAt       time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP"`
+}
+
+func (WishlistItem) TableName() string {
+	return "wishlist_items"
+}
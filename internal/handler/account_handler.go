@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
 	"github.com/jhasudungan/terraloom-core-api/internal/model"
 	"github.com/jhasudungan/terraloom-core-api/internal/service"
 	"github.com/sirupsen/logrus"
@@ -109,30 +110,34 @@ func (oh *AccountHandler) GetAccountOrders(ctx *gin.Context) {
 
 	request.AccountUserame = username.(string)
 	request.OrderReference = ctx.Query("orderReference")
+	request.Mode = ctx.Query("mode")
+	request.Cursor = ctx.Query("cursor")
 
-	isPaginate, err := strconv.ParseBool(ctx.Query("isPaginate"))
+	withTotal, err := queryBool(ctx, "withTotal", true)
 
 	if err != nil {
-		logrus.Error(err)
-		oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		oh.errorHandler.Handle(ctx, err)
 		return
 	}
 
-	request.IsPaginate = isPaginate
+	request.SkipCount = !withTotal
 
-	if isPaginate {
+	// Cursor mode always paginates and has no page number
+	if request.Mode == constant.PaginationModeCursor || request.Cursor != "" {
 
-		page, err := strconv.Atoi(ctx.Query("page"))
+		request.IsPaginate = true
+		request.Page = 1
+
+		request.PerPage, err = queryInt(ctx, "perPage", 5)
 
 		if err != nil {
-			logrus.Error(err)
-			oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+			oh.errorHandler.Handle(ctx, err)
 			return
 		}
 
-		request.Page = page
+	} else {
 
-		perPage, err := strconv.Atoi(ctx.Query("perPage"))
+		isPaginate, err := strconv.ParseBool(ctx.Query("isPaginate"))
 
 		if err != nil {
 			logrus.Error(err)
@@ -140,11 +145,34 @@ func (oh *AccountHandler) GetAccountOrders(ctx *gin.Context) {
 			return
 		}
 
-		request.PerPage = perPage
+		request.IsPaginate = isPaginate
 
-	} else {
-		request.Page = 1
-		request.PerPage = 1
+		if isPaginate {
+
+			page, err := strconv.Atoi(ctx.Query("page"))
+
+			if err != nil {
+				logrus.Error(err)
+				oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+				return
+			}
+
+			request.Page = page
+
+			perPage, err := strconv.Atoi(ctx.Query("perPage"))
+
+			if err != nil {
+				logrus.Error(err)
+				oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+				return
+			}
+
+			request.PerPage = perPage
+
+		} else {
+			request.Page = 1
+			request.PerPage = 1
+		}
 	}
 
 	response, err := oh.orderService.GetAccountOrders(ctx, request)
@@ -219,3 +247,42 @@ func (ah *AccountHandler) UpdatePassword(ctx *gin.Context) {
 
 	ctx.JSON(200, response)
 }
+
+func (ah *AccountHandler) RequestEmailVerification(ctx *gin.Context) {
+
+	request := model.EmailVerificationRequest{
+		Username: ctx.GetString("username"),
+	}
+
+	response, err := ah.accountService.RequestEmailVerification(ctx, request)
+
+	if err != nil {
+		ah.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ah *AccountHandler) VerifyEmail(ctx *gin.Context) {
+
+	request := model.VerifyEmailRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ah.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := ah.accountService.VerifyEmail(ctx, request)
+
+	if err != nil {
+		ah.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
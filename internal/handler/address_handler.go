@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type AddressHandler struct {
+	addressService *service.AddressService
+	errorHandler   *ErrorHandler
+}
+
+func NewAddressHandler(addressService *service.AddressService, errorHandler *ErrorHandler) *AddressHandler {
+	return &AddressHandler{
+		addressService: addressService,
+		errorHandler:   errorHandler,
+	}
+}
+
+func (ah *AddressHandler) GetAddresses(ctx *gin.Context) {
+
+	request := model.GetAddressesRequest{
+		Username: ctx.GetString("username"),
+	}
+
+	response, err := ah.addressService.GetAddresses(ctx, request)
+
+	if err != nil {
+		ah.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ah *AddressHandler) CreateAddress(ctx *gin.Context) {
+
+	request := model.SaveAddressRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ah.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := ah.addressService.CreateAddress(ctx, request)
+
+	if err != nil {
+		ah.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (ah *AddressHandler) UpdateAddress(ctx *gin.Context) {
+
+	addressID, err := strconv.ParseInt(ctx.Param("addressId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ah.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.SaveAddressRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ah.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.AddressID = addressID
+	request.Username = ctx.GetString("username")
+
+	response, err := ah.addressService.UpdateAddress(ctx, request)
+
+	if err != nil {
+		ah.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ah *AddressHandler) DeleteAddress(ctx *gin.Context) {
+
+	addressID, err := strconv.ParseInt(ctx.Param("addressId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ah.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.DeleteAddressRequest{
+		AddressID: addressID,
+		Username:  ctx.GetString("username"),
+	}
+
+	response, err := ah.addressService.DeleteAddress(ctx, request)
+
+	if err != nil {
+		ah.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// OrderTokenHeader carries the access token of a guest order
+const OrderTokenHeader = "X-Order-Token"
+
+type GuestCheckoutHandler struct {
+	guestCheckoutService *service.GuestCheckoutService
+	errorHandler         *ErrorHandler
+}
+
+func NewGuestCheckoutHandler(guestCheckoutService *service.GuestCheckoutService, errorHandler *ErrorHandler) *GuestCheckoutHandler {
+	return &GuestCheckoutHandler{
+		guestCheckoutService: guestCheckoutService,
+		errorHandler:         errorHandler,
+	}
+}
+
+func (gh *GuestCheckoutHandler) SubmitGuestOrder(ctx *gin.Context) {
+
+	request := model.SubmitGuestOrderRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		gh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	response, err := gh.guestCheckoutService.SubmitGuestOrder(ctx, request)
+
+	if err != nil {
+		gh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (gh *GuestCheckoutHandler) GetGuestOrder(ctx *gin.Context) {
+
+	request := model.GuestOrderRequest{
+		OrderReference: ctx.Param("orderReference"),
+		AccessToken:    ctx.GetHeader(OrderTokenHeader),
+	}
+
+	response, err := gh.guestCheckoutService.GetGuestOrder(ctx, request)
+
+	if err != nil {
+		gh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (gh *GuestCheckoutHandler) PayGuestOrder(ctx *gin.Context) {
+
+	request := model.PayGuestOrderRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		gh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.OrderReference = ctx.Param("orderReference")
+	request.AccessToken = ctx.GetHeader(OrderTokenHeader)
+
+	response, err := gh.guestCheckoutService.PayGuestOrder(ctx, request)
+
+	if err != nil {
+		gh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (gh *GuestCheckoutHandler) ClaimGuestOrders(ctx *gin.Context) {
+
+	request := model.ClaimGuestOrdersRequest{
+		Username: ctx.GetString("username"),
+	}
+
+	response, err := gh.guestCheckoutService.ClaimGuestOrders(ctx, request)
+
+	if err != nil {
+		gh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
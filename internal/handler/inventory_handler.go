@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type InventoryHandler struct {
+	inventoryService *service.InventoryService
+	errorHandler     *ErrorHandler
+}
+
+func NewInventoryHandler(
+	inventoryService *service.InventoryService,
+	errorHandler *ErrorHandler) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+		errorHandler:     errorHandler,
+	}
+}
+
+// GetInventoryMovements query parameters : isPaginate (default true), page (default 1), perPage (default 20)
+func (ih *InventoryHandler) GetInventoryMovements(ctx *gin.Context) {
+
+	request := model.GetInventoryMovementsRequest{}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		ih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Page, err = queryInt(ctx, "page", 1)
+	if err != nil {
+		ih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.PerPage, err = queryInt(ctx, "perPage", 20)
+	if err != nil {
+		ih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	response, err := ih.inventoryService.GetInventoryMovements(ctx, request)
+
+	if err != nil {
+		ih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ih *InventoryHandler) AdjustStock(ctx *gin.Context) {
+
+	request := model.AdjustStockRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.Username = ctx.GetString("username")
+
+	response, err := ih.inventoryService.AdjustStock(ctx, request)
+
+	if err != nil {
+		ih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (ih *InventoryHandler) UpdateLowStockThreshold(ctx *gin.Context) {
+
+	request := model.UpdateLowStockThresholdRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.Username = ctx.GetString("username")
+
+	response, err := ih.inventoryService.UpdateLowStockThreshold(ctx, request)
+
+	if err != nil {
+		ih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
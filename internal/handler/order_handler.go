@@ -92,8 +92,18 @@ func (oh *OrderHandler) GetOrderDetail(ctx *gin.Context) {
 
 	request := model.GetOrderDetailRequest{}
 
+	username, exists := ctx.Get("username")
+
+	if !exists {
+		err := errors.New("missing required data")
+		logrus.Error(err)
+		oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
 	orderReference := ctx.Param("orderReference")
 	request.OrderReference = orderReference
+	request.AccountUsername = username.(string)
 
 	response, err := oh.orderService.GetOrderDetail(ctx, request)
 
@@ -104,3 +114,69 @@ func (oh *OrderHandler) GetOrderDetail(ctx *gin.Context) {
 
 	ctx.JSON(200, response)
 }
+
+func (oh *OrderHandler) Reorder(ctx *gin.Context) {
+
+	request := model.ReorderRequest{}
+	err := ctx.ShouldBind(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	username, exists := ctx.Get("username")
+
+	if !exists {
+		err := errors.New("missing required data")
+		logrus.Error(err)
+		oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.OrderReference = ctx.Param("orderReference")
+	request.Username = username.(string)
+
+	response, err := oh.orderService.Reorder(ctx, request)
+
+	if err != nil {
+		oh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (oh *OrderHandler) ModifyOrder(ctx *gin.Context) {
+
+	request := model.ModifyOrderRequest{}
+	err := ctx.ShouldBind(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	username, exists := ctx.Get("username")
+
+	if !exists {
+		err := errors.New("missing required data")
+		logrus.Error(err)
+		oh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.OrderReference = ctx.Param("orderReference")
+	request.Username = username.(string)
+
+	response, err := oh.orderService.ModifyOrder(ctx, request)
+
+	if err != nil {
+		oh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
@@ -34,6 +34,8 @@ func (ph *PaymentHandler) SubmitPayment(ctx *gin.Context) {
 		return
 	}
 
+	request.AccountUsername = ctx.GetString("username")
+
 	response, err := ph.paymentService.SubmitPayment(ctx, request)
 
 	if err != nil {
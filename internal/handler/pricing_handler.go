@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type PricingHandler struct {
+	pricingService *service.PricingService
+	errorHandler   *ErrorHandler
+}
+
+func NewPricingHandler(pricingService *service.PricingService, errorHandler *ErrorHandler) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+		errorHandler:   errorHandler,
+	}
+}
+
+func (ph *PricingHandler) GetPricing(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	response, err := ph.pricingService.GetPricing(ctx, productID)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ph *PricingHandler) SchedulePriceChange(ctx *gin.Context) {
+
+	request := model.SchedulePriceChangeRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.Username = ctx.GetString("username")
+
+	response, err := ph.pricingService.SchedulePriceChange(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (ph *PricingHandler) CancelPriceChange(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	changeID, err := strconv.ParseInt(ctx.Param("changeId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.CancelPriceChangeRequest{
+		ProductID: productID,
+		ChangeID:  changeID,
+		Username:  ctx.GetString("username"),
+	}
+
+	response, err := ph.pricingService.CancelPriceChange(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ph *PricingHandler) SetSale(ctx *gin.Context) {
+
+	request := model.SetSaleRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.Username = ctx.GetString("username")
+
+	response, err := ph.pricingService.SetSale(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ph *PricingHandler) EndSale(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.EndSaleRequest{
+		ProductID: productID,
+		Username:  ctx.GetString("username"),
+	}
+
+	response, err := ph.pricingService.EndSale(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+// SetCurrencyPrice sets the price of the product, or of one of its variants, in another currency, a price of 0 removes it
+func (ph *PricingHandler) SetCurrencyPrice(ctx *gin.Context) {
+
+	request := model.SetCurrencyPriceRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.Username = ctx.GetString("username")
+
+	response, err := ph.pricingService.SetCurrencyPrice(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+// GetPriceHistory query parameters : isPaginate (default true), page (default 1), perPage (default 20)
+func (ph *PricingHandler) GetPriceHistory(ctx *gin.Context) {
+
+	request := model.GetPriceHistoryRequest{}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Page, err = queryInt(ctx, "page", 1)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.PerPage, err = queryInt(ctx, "perPage", 20)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	response, err := ph.pricingService.GetPriceHistory(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
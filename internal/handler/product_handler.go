@@ -24,60 +24,180 @@ func NewProductHandler(
 	}
 }
 
+/*
+*
+
+	Every query parameter is optional :
+	- name, categoryId (repeated or comma separated), minPrice, maxPrice
+	- currency (default IDR) : the products sold in it, priced, filtered and sorted in it
+	- isActive (default true), inStock (default false)
+	- sort : price, name, newest (default), best-selling, rating
+	- direction : asc or desc, default depends on the sort
+	- isPaginate (default true), page (default 1), perPage (default 5)
+	- mode : offset (default) or cursor, cursor : the nextCursor / prevCursor of a previous page
+	- withTotal (default true) : false skips counting the matching products
+
+*
+*/
 func (ph *ProductHandler) GetProducts(ctx *gin.Context) {
 
 	// Parse query parameters
 	request := model.GetProductsRequest{}
 
 	request.Name = ctx.Query("name")
+	request.Currency = ctx.Query("currency")
+	request.Sort = ctx.Query("sort")
+	request.SortDirection = ctx.Query("direction")
+	request.Mode = ctx.Query("mode")
+	request.Cursor = ctx.Query("cursor")
 
-	isActive, err := strconv.ParseBool(ctx.Query("isActive"))
+	var err error
 
+	withTotal, err := queryBool(ctx, "withTotal", true)
 	if err != nil {
-		logrus.Error(err)
-		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		ph.errorHandler.Handle(ctx, err)
 		return
 	}
 
-	request.IsActive = isActive
-	isPaginate, err := strconv.ParseBool(ctx.Query("isPaginate"))
+	request.SkipCount = !withTotal
 
+	request.IsActive, err = queryBool(ctx, "isActive", true)
 	if err != nil {
-		logrus.Error(err)
-		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.InStockOnly, err = queryBool(ctx, "inStock", false)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.MinPrice, err = queryInt64Ptr(ctx, "minPrice")
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.MaxPrice, err = queryInt64Ptr(ctx, "maxPrice")
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
 		return
 	}
 
-	request.IsPaginate = isPaginate
+	request.CategoryIDs, err = queryInt64List(ctx, "categoryId")
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
 
-	if isPaginate {
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
 
-		page, err := strconv.Atoi(ctx.Query("page"))
+	if request.IsPaginate {
 
+		request.Page, err = queryInt(ctx, "page", 1)
 		if err != nil {
-			logrus.Error(err)
-			ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+			ph.errorHandler.Handle(ctx, err)
 			return
 		}
 
-		request.Page = page
+		request.PerPage, err = queryInt(ctx, "perPage", 5)
+		if err != nil {
+			ph.errorHandler.Handle(ctx, err)
+			return
+		}
 
-		perPage, err := strconv.Atoi(ctx.Query("perPage"))
+	} else {
+		request.Page = 1
+		request.PerPage = 1
+	}
+
+	response, err := ph.productService.GetProducts(ctx, request)
 
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+/*
+*
+
+	Query parameters :
+	- q (required) : the words to search for
+	- categoryId, minPrice, maxPrice, currency, inStock : same as GetProducts
+	- isPaginate (default true), page (default 1), perPage (default 5)
+
+*
+*/
+func (ph *ProductHandler) SearchProducts(ctx *gin.Context) {
+
+	request := model.SearchProductsRequest{}
+
+	request.Query = ctx.Query("q")
+	request.Currency = ctx.Query("currency")
+
+	// Only active products are searchable
+	request.IsActive = true
+
+	var err error
+
+	request.InStockOnly, err = queryBool(ctx, "inStock", false)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.MinPrice, err = queryInt64Ptr(ctx, "minPrice")
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.MaxPrice, err = queryInt64Ptr(ctx, "maxPrice")
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.CategoryIDs, err = queryInt64List(ctx, "categoryId")
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	if request.IsPaginate {
+
+		request.Page, err = queryInt(ctx, "page", 1)
 		if err != nil {
-			logrus.Error(err)
-			ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+			ph.errorHandler.Handle(ctx, err)
 			return
 		}
 
-		request.PerPage = perPage
+		request.PerPage, err = queryInt(ctx, "perPage", 5)
+		if err != nil {
+			ph.errorHandler.Handle(ctx, err)
+			return
+		}
 
 	} else {
 		request.Page = 1
 		request.PerPage = 1
 	}
 
-	response, err := ph.productService.GetProducts(ctx, request)
+	response, err := ph.productService.SearchProducts(ctx, request)
 
 	if err != nil {
 		ph.errorHandler.Handle(ctx, err)
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// Room for the multipart envelope and the other form fields
+const multipartOverhead = 1 << 20
+
+type ProductImageHandler struct {
+	productImageService *service.ProductImageService
+	errorHandler        *ErrorHandler
+}
+
+func NewProductImageHandler(
+	productImageService *service.ProductImageService,
+	errorHandler *ErrorHandler) *ProductImageHandler {
+	return &ProductImageHandler{
+		productImageService: productImageService,
+		errorHandler:        errorHandler,
+	}
+}
+
+/*
+*
+
+	Multipart form :
+	- file (required) : JPEG, PNG or GIF, at most 10MB
+	- altText, position (default : end of the gallery), isPrimary (default false)
+
+*
+*/
+func (pih *ProductImageHandler) UploadProductImage(ctx *gin.Context) {
+
+	request := model.UploadProductImageRequest{}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		pih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, service.MaxProductImageBytes+multipartOverhead)
+
+	fileHeader, err := ctx.FormFile("file")
+
+	if err != nil {
+		logrus.Error(err)
+		pih.errorHandler.Handle(ctx, common.NewError(fmt.Errorf("file is required and must not be larger than %d bytes", service.MaxProductImageBytes), common.ErrValidation))
+		return
+	}
+
+	file, err := fileHeader.Open()
+
+	if err != nil {
+		logrus.Error(err)
+		pih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	defer file.Close()
+
+	// One byte more than allowed lets the service report the size
+	request.Content, err = io.ReadAll(io.LimitReader(file, service.MaxProductImageBytes+1))
+
+	if err != nil {
+		logrus.Error(err)
+		pih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.AltText = ctx.PostForm("altText")
+
+	if value := ctx.PostForm("position"); value != "" {
+
+		position, err := strconv.Atoi(value)
+
+		if err != nil {
+			logrus.Error(err)
+			pih.errorHandler.Handle(ctx, common.NewError(errors.New("position must be an integer"), common.ErrValidation))
+			return
+		}
+
+		request.Position = &position
+	}
+
+	if value := ctx.PostForm("isPrimary"); value != "" {
+
+		request.IsPrimary, err = strconv.ParseBool(value)
+
+		if err != nil {
+			logrus.Error(err)
+			pih.errorHandler.Handle(ctx, common.NewError(errors.New("isPrimary must be a boolean"), common.ErrValidation))
+			return
+		}
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := pih.productImageService.UploadProductImage(ctx, request)
+
+	if err != nil {
+		pih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (pih *ProductImageHandler) UpdateProductImage(ctx *gin.Context) {
+
+	request := model.UpdateProductImageRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		pih.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID, request.ImageID, err = imagePathParams(ctx)
+
+	if err != nil {
+		pih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := pih.productImageService.UpdateProductImage(ctx, request)
+
+	if err != nil {
+		pih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (pih *ProductImageHandler) DeleteProductImage(ctx *gin.Context) {
+
+	request := model.DeleteProductImageRequest{}
+
+	var err error
+	request.ProductID, request.ImageID, err = imagePathParams(ctx)
+
+	if err != nil {
+		pih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := pih.productImageService.DeleteProductImage(ctx, request)
+
+	if err != nil {
+		pih.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func imagePathParams(ctx *gin.Context) (int64, int64, error) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, 0, common.NewError(err, common.ErrValidation)
+	}
+
+	imageID, err := strconv.ParseInt(ctx.Param("imageId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, 0, common.NewError(err, common.ErrValidation)
+	}
+
+	return productID, imageID, nil
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type ProductReviewHandler struct {
+	productReviewService *service.ProductReviewService
+	errorHandler         *ErrorHandler
+}
+
+func NewProductReviewHandler(
+	productReviewService *service.ProductReviewService,
+	errorHandler *ErrorHandler) *ProductReviewHandler {
+	return &ProductReviewHandler{
+		productReviewService: productReviewService,
+		errorHandler:         errorHandler,
+	}
+}
+
+// GetProductReviews lists the published reviews of a product : isPaginate (default true), page (default 1), perPage (default 10)
+func (prh *ProductReviewHandler) GetProductReviews(ctx *gin.Context) {
+
+	request, err := reviewListRequest(ctx)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.ProductID, err = strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		prh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	response, err := prh.productReviewService.GetProductReviews(ctx, request)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (prh *ProductReviewHandler) GetAccountReviews(ctx *gin.Context) {
+
+	request, err := reviewListRequest(ctx)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := prh.productReviewService.GetAccountReviews(ctx, request)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+// GetReviewsForModeration query parameters : status, productId and the pagination of GetProductReviews
+func (prh *ProductReviewHandler) GetReviewsForModeration(ctx *gin.Context) {
+
+	request, err := reviewListRequest(ctx)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Status = ctx.Query("status")
+
+	productID, err := queryInt64Ptr(ctx, "productId")
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	if productID != nil {
+		request.ProductID = *productID
+	}
+
+	response, err := prh.productReviewService.GetReviewsForModeration(ctx, request)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (prh *ProductReviewHandler) CreateReview(ctx *gin.Context) {
+
+	request := model.CreateReviewRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		prh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := prh.productReviewService.CreateReview(ctx, request)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (prh *ProductReviewHandler) UpdateReview(ctx *gin.Context) {
+
+	request := model.UpdateReviewRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		prh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ReviewID, err = reviewIDParam(ctx)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := prh.productReviewService.UpdateReview(ctx, request)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (prh *ProductReviewHandler) DeleteReview(ctx *gin.Context) {
+
+	reviewID, err := reviewIDParam(ctx)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request := model.DeleteReviewRequest{
+		ReviewID: reviewID,
+		Username: ctx.GetString("username"),
+	}
+
+	response, err := prh.productReviewService.DeleteReview(ctx, request)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (prh *ProductReviewHandler) ModerateReview(ctx *gin.Context) {
+
+	request := model.ModerateReviewRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		prh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ReviewID, err = reviewIDParam(ctx)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := prh.productReviewService.ModerateReview(ctx, request)
+
+	if err != nil {
+		prh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func reviewListRequest(ctx *gin.Context) (model.GetReviewsRequest, error) {
+
+	request := model.GetReviewsRequest{}
+
+	var err error
+
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		return request, err
+	}
+
+	request.Page, err = queryInt(ctx, "page", 1)
+	if err != nil {
+		return request, err
+	}
+
+	request.PerPage, err = queryInt(ctx, "perPage", 10)
+	if err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+func reviewIDParam(ctx *gin.Context) (int64, error) {
+
+	reviewID, err := strconv.ParseInt(ctx.Param("reviewId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, common.NewError(err, common.ErrValidation)
+	}
+
+	return reviewID, nil
+}
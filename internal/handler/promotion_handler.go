@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type PromotionHandler struct {
+	promotionService *service.PromotionService
+	errorHandler     *ErrorHandler
+}
+
+func NewPromotionHandler(promotionService *service.PromotionService, errorHandler *ErrorHandler) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+		errorHandler:     errorHandler,
+	}
+}
+
+func (ph *PromotionHandler) GetPromotions(ctx *gin.Context) {
+
+	request := model.GetPromotionsRequest{}
+
+	var err error
+
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Page, err = queryInt(ctx, "page", 1)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.PerPage, err = queryInt(ctx, "perPage", 10)
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	response, err := ph.promotionService.GetPromotions(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ph *PromotionHandler) CreatePromotion(ctx *gin.Context) {
+
+	request := model.CreatePromotionRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := ph.promotionService.CreatePromotion(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (ph *PromotionHandler) UpdatePromotion(ctx *gin.Context) {
+
+	promotionID, err := strconv.ParseInt(ctx.Param("promotionId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.UpdatePromotionRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ph.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.PromotionID = promotionID
+	request.Username = ctx.GetString("username")
+
+	response, err := ph.promotionService.UpdatePromotion(ctx, request)
+
+	if err != nil {
+		ph.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
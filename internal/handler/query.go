@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/sirupsen/logrus"
+)
+
+/**
+	Optional query parameter parsing, a missing or empty parameter yields the default
+**/
+
+func queryBool(ctx *gin.Context, key string, defaultValue bool) (bool, error) {
+
+	raw := ctx.Query(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		err = fmt.Errorf("%s must be a boolean", key)
+		logrus.Error(err)
+		return defaultValue, common.NewError(err, common.ErrValidation)
+	}
+
+	return value, nil
+}
+
+func queryInt(ctx *gin.Context, key string, defaultValue int) (int, error) {
+
+	raw := ctx.Query(key)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		err = fmt.Errorf("%s must be a number", key)
+		logrus.Error(err)
+		return defaultValue, common.NewError(err, common.ErrValidation)
+	}
+
+	return value, nil
+}
+
+func queryInt64Ptr(ctx *gin.Context, key string) (*int64, error) {
+
+	raw := ctx.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("%s must be a number", key)
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
+	}
+
+	return &value, nil
+}
+
+// queryInt64List accepts both repeated (?id=1&id=2) and comma separated (?id=1,2) values
+func queryInt64List(ctx *gin.Context, key string) ([]int64, error) {
+
+	var values []int64
+
+	for _, raw := range ctx.QueryArray(key) {
+		for _, part := range strings.Split(raw, ",") {
+
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			value, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				err = fmt.Errorf("%s must be a list of numbers", key)
+				logrus.Error(err)
+				return nil, common.NewError(err, common.ErrValidation)
+			}
+
+			values = append(values, value)
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type ShipmentHandler struct {
+	shipmentService *service.ShipmentService
+	errorHandler    *ErrorHandler
+}
+
+func NewShipmentHandler(shipmentService *service.ShipmentService, errorHandler *ErrorHandler) *ShipmentHandler {
+	return &ShipmentHandler{
+		shipmentService: shipmentService,
+		errorHandler:    errorHandler,
+	}
+}
+
+func (sh *ShipmentHandler) CreateShipment(ctx *gin.Context) {
+
+	request := model.CreateShipmentRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.OrderReference = ctx.Param("orderReference")
+	request.Username = ctx.GetString("username")
+
+	response, err := sh.shipmentService.CreateShipment(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (sh *ShipmentHandler) UpdateShipment(ctx *gin.Context) {
+
+	request := model.UpdateShipmentRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ShipmentReference = ctx.Param("shipmentReference")
+	request.Username = ctx.GetString("username")
+
+	response, err := sh.shipmentService.UpdateShipment(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (sh *ShipmentHandler) GetOrderTracking(ctx *gin.Context) {
+
+	request := model.GetOrderTrackingRequest{
+		OrderReference:  ctx.Param("orderReference"),
+		AccountUsername: ctx.GetString("username"),
+	}
+
+	response, err := sh.shipmentService.GetOrderTracking(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
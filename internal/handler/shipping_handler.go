@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type ShippingHandler struct {
+	shippingService *service.ShippingService
+	errorHandler    *ErrorHandler
+}
+
+func NewShippingHandler(shippingService *service.ShippingService, errorHandler *ErrorHandler) *ShippingHandler {
+	return &ShippingHandler{
+		shippingService: shippingService,
+		errorHandler:    errorHandler,
+	}
+}
+
+func (sh *ShippingHandler) GetShippingMethods(ctx *gin.Context) {
+
+	request := model.GetShippingMethodsRequest{}
+
+	var err error
+
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Page, err = queryInt(ctx, "page", 1)
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.PerPage, err = queryInt(ctx, "perPage", 10)
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	response, err := sh.shippingService.GetShippingMethods(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (sh *ShippingHandler) CreateShippingMethod(ctx *gin.Context) {
+
+	request := model.CreateShippingMethodRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := sh.shippingService.CreateShippingMethod(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (sh *ShippingHandler) UpdateShippingMethod(ctx *gin.Context) {
+
+	shippingMethodID, err := strconv.ParseInt(ctx.Param("methodId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.UpdateShippingMethodRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ShippingMethodID = shippingMethodID
+	request.Username = ctx.GetString("username")
+
+	response, err := sh.shippingService.UpdateShippingMethod(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (sh *ShippingHandler) UpdateProductDimensions(ctx *gin.Context) {
+
+	request := model.UpdateProductDimensionsRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.Username = ctx.GetString("username")
+
+	response, err := sh.shippingService.UpdateProductDimensions(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (sh *ShippingHandler) QuoteShipping(ctx *gin.Context) {
+
+	request := model.QuoteShippingRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		sh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	response, err := sh.shippingService.QuoteShipping(ctx, request)
+
+	if err != nil {
+		sh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
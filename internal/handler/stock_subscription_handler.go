@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type StockSubscriptionHandler struct {
+	stockNotificationService *service.StockNotificationService
+	errorHandler             *ErrorHandler
+}
+
+func NewStockSubscriptionHandler(
+	stockNotificationService *service.StockNotificationService,
+	errorHandler *ErrorHandler) *StockSubscriptionHandler {
+	return &StockSubscriptionHandler{
+		stockNotificationService: stockNotificationService,
+		errorHandler:             errorHandler,
+	}
+}
+
+func (ssh *StockSubscriptionHandler) GetStockSubscriptions(ctx *gin.Context) {
+
+	response, err := ssh.stockNotificationService.GetStockSubscriptions(ctx, ctx.GetString("username"))
+
+	if err != nil {
+		ssh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (ssh *StockSubscriptionHandler) Subscribe(ctx *gin.Context) {
+
+	request := model.SubscribeStockRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		ssh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := ssh.stockNotificationService.Subscribe(ctx, request)
+
+	if err != nil {
+		ssh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (ssh *StockSubscriptionHandler) Unsubscribe(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("productId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		ssh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.UnsubscribeStockRequest{
+		ProductID: productID,
+		Username:  ctx.GetString("username"),
+	}
+
+	response, err := ssh.stockNotificationService.Unsubscribe(ctx, request)
+
+	if err != nil {
+		ssh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type TaxHandler struct {
+	taxService   *service.TaxService
+	errorHandler *ErrorHandler
+}
+
+func NewTaxHandler(taxService *service.TaxService, errorHandler *ErrorHandler) *TaxHandler {
+	return &TaxHandler{
+		taxService:   taxService,
+		errorHandler: errorHandler,
+	}
+}
+
+func (th *TaxHandler) GetTaxRules(ctx *gin.Context) {
+
+	request := model.GetTaxRulesRequest{}
+
+	var err error
+
+	request.IsPaginate, err = queryBool(ctx, "isPaginate", true)
+	if err != nil {
+		th.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.Page, err = queryInt(ctx, "page", 1)
+	if err != nil {
+		th.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	request.PerPage, err = queryInt(ctx, "perPage", 10)
+	if err != nil {
+		th.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	response, err := th.taxService.GetTaxRules(ctx, request)
+
+	if err != nil {
+		th.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (th *TaxHandler) CreateTaxRule(ctx *gin.Context) {
+
+	request := model.CreateTaxRuleRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		th.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := th.taxService.CreateTaxRule(ctx, request)
+
+	if err != nil {
+		th.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (th *TaxHandler) UpdateTaxRule(ctx *gin.Context) {
+
+	taxRuleID, err := strconv.ParseInt(ctx.Param("ruleId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		th.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.UpdateTaxRuleRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		th.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.TaxRuleID = taxRuleID
+	request.Username = ctx.GetString("username")
+
+	response, err := th.taxService.UpdateTaxRule(ctx, request)
+
+	if err != nil {
+		th.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type VariantHandler struct {
+	variantService *service.VariantService
+	errorHandler   *ErrorHandler
+}
+
+func NewVariantHandler(variantService *service.VariantService, errorHandler *ErrorHandler) *VariantHandler {
+	return &VariantHandler{
+		variantService: variantService,
+		errorHandler:   errorHandler,
+	}
+}
+
+func (vh *VariantHandler) CreateOptionType(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.CreateOptionTypeRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+
+	response, err := vh.variantService.CreateOptionType(ctx, request)
+
+	if err != nil {
+		vh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (vh *VariantHandler) UpdateOptionType(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	optionTypeID, err := strconv.ParseInt(ctx.Param("optionTypeId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.UpdateOptionTypeRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.OptionTypeID = optionTypeID
+
+	response, err := vh.variantService.UpdateOptionType(ctx, request)
+
+	if err != nil {
+		vh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (vh *VariantHandler) CreateVariant(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.CreateVariantRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.Username = ctx.GetString("username")
+
+	response, err := vh.variantService.CreateVariant(ctx, request)
+
+	if err != nil {
+		vh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (vh *VariantHandler) UpdateVariant(ctx *gin.Context) {
+
+	productID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	variantID, err := strconv.ParseInt(ctx.Param("variantId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.UpdateVariantRequest{}
+	err = ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		vh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.ProductID = productID
+	request.VariantID = variantID
+	request.Username = ctx.GetString("username")
+
+	response, err := vh.variantService.UpdateVariant(ctx, request)
+
+	if err != nil {
+		vh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+type WishlistHandler struct {
+	wishlistService *service.WishlistService
+	errorHandler    *ErrorHandler
+}
+
+func NewWishlistHandler(wishlistService *service.WishlistService, errorHandler *ErrorHandler) *WishlistHandler {
+	return &WishlistHandler{
+		wishlistService: wishlistService,
+		errorHandler:    errorHandler,
+	}
+}
+
+func (wh *WishlistHandler) GetWishlist(ctx *gin.Context) {
+
+	response, err := wh.wishlistService.GetWishlist(ctx, ctx.GetString("username"))
+
+	if err != nil {
+		wh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (wh *WishlistHandler) AddToWishlist(ctx *gin.Context) {
+
+	request := model.AddWishlistItemRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		wh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := wh.wishlistService.AddToWishlist(ctx, request)
+
+	if err != nil {
+		wh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(201, response)
+}
+
+func (wh *WishlistHandler) RemoveFromWishlist(ctx *gin.Context) {
+
+	itemID, err := strconv.ParseInt(ctx.Param("itemId"), 10, 64)
+
+	if err != nil {
+		logrus.Error(err)
+		wh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request := model.RemoveWishlistItemRequest{
+		ItemID:   itemID,
+		Username: ctx.GetString("username"),
+	}
+
+	response, err := wh.wishlistService.RemoveFromWishlist(ctx, request)
+
+	if err != nil {
+		wh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
+
+func (wh *WishlistHandler) OrderWishlistItems(ctx *gin.Context) {
+
+	request := model.OrderWishlistItemsRequest{}
+	err := ctx.ShouldBindJSON(&request)
+
+	if err != nil {
+		logrus.Error(err)
+		wh.errorHandler.Handle(ctx, common.NewError(err, common.ErrValidation))
+		return
+	}
+
+	request.Username = ctx.GetString("username")
+
+	response, err := wh.wishlistService.OrderWishlistItems(ctx, request)
+
+	if err != nil {
+		wh.errorHandler.Handle(ctx, err)
+		return
+	}
+
+	ctx.JSON(200, response)
+}
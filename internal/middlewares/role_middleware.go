@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/handler"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+// NewRoleMiddleware runs after the auth middleware, the role is read from the account on every
+// request so a revoked role takes effect before the token expires
+func NewRoleMiddleware(accountService *service.AccountService, errorHandler *handler.ErrorHandler, roles ...string) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+
+		username, exists := c.Get("username")
+
+		if !exists {
+			err := errors.New("missing authenticated account")
+			logrus.Error(err)
+			errorHandler.Handle(c, common.NewError(err, common.ErrAccessDenied))
+			c.Abort()
+			return
+		}
+
+		err := accountService.Authorize(c, username.(string), roles...)
+
+		if err != nil {
+			errorHandler.Handle(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,408 @@
+package migration
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SourceDir is where `migrate create` writes new files, relative to the repository root
+const SourceDir = "internal/migration/sql"
+
+// advisoryLockKey serializes concurrent migrators (e.g. several replicas starting at once)
+const advisoryLockKey = 7306228861
+
+//go:embed sql/*.sql
+var files embed.FS
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;column:version"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LatestVersion is the schema version this binary expects
+func (m *Migrator) LatestVersion() int64 {
+
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) CurrentVersion(ctx context.Context) (int64, error) {
+
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	err = m.db.WithContext(ctx).Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// EnsureUpToDate fails when an embedded migration is not applied, a gap below the latest version included
+func (m *Migrator) EnsureUpToDate(ctx context.Context) error {
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+
+	for _, status := range statuses {
+		if !status.Applied {
+			missing = append(missing, fmt.Sprintf("%04d_%s", status.Version, status.Name))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("database schema is missing migrations %s, run `migrate up`", strings.Join(missing, ", "))
+	}
+
+	current, err := m.CurrentVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if latest := m.LatestVersion(); current > latest {
+		logrus.Warnf("database schema version %d is ahead of this binary (%d)", current, latest)
+	}
+
+	return nil
+}
+
+// Up applies every pending migration, each one in its own transaction
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+
+	for _, migration := range m.migrations {
+
+		done := false
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+			err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error
+			if err != nil {
+				return err
+			}
+
+			var count int64
+			err = tx.Model(&schemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error
+			if err != nil {
+				return err
+			}
+
+			if count > 0 {
+				return nil
+			}
+
+			err = tx.Exec(migration.Up).Error
+			if err != nil {
+				return err
+			}
+
+			done = true
+
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+
+		if err != nil {
+			return applied, fmt.Errorf("apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		if done {
+			logrus.Infof("applied migration %04d_%s", migration.Version, migration.Name)
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// Down reverts the given number of most recently applied migrations
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	var reverted []Migration
+
+	for i := 0; i < steps; i++ {
+
+		var last schemaMigration
+		stop := false
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+			err := tx.Exec("SELECT pg_advisory_xact_lock(?)", advisoryLockKey).Error
+			if err != nil {
+				return err
+			}
+
+			result := tx.Order("version DESC").Limit(1).Find(&last)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				stop = true
+				return nil
+			}
+
+			migration, ok := byVersion[last.Version]
+			if !ok {
+				return fmt.Errorf("migration %d is applied but unknown to this binary", last.Version)
+			}
+
+			err = tx.Exec(migration.Down).Error
+			if err != nil {
+				return err
+			}
+
+			return tx.Where("version = ?", last.Version).Delete(&schemaMigration{}).Error
+		})
+
+		if err != nil {
+			return reverted, fmt.Errorf("revert migration %04d_%s: %w", last.Version, last.Name, err)
+		}
+
+		if stop {
+			break
+		}
+
+		logrus.Infof("reverted migration %04d_%s", last.Version, last.Name)
+		reverted = append(reverted, byVersion[last.Version])
+	}
+
+	return reverted, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+
+	err := m.ensureTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+	err = m.db.WithContext(ctx).Order("version ASC").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("read applied migrations: %w", err)
+	}
+
+	appliedAt := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		appliedAt[row.Version] = row.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+
+		status := Status{Version: migration.Version, Name: migration.Name}
+
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+
+	err := m.db.WithContext(ctx).Exec(`CREATE TABLE IF NOT EXISTS public.schema_migrations (
+	version int8 NOT NULL,
+	"name" varchar(255) NOT NULL,
+	applied_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT schema_migrations_pkey PRIMARY KEY (version)
+)`).Error
+
+	if err != nil {
+		return fmt.Errorf("create schema_migrations table: %w", err)
+	}
+
+	return nil
+}
+
+// Create writes an empty up/down pair numbered after the highest version found in dir
+func Create(dir string, name string) (string, string, error) {
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.ReplaceAll(name, " ", "_")
+	name = strings.ReplaceAll(name, "-", "_")
+
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name %q, use letters, digits and underscores", name)
+	}
+
+	existing, err := load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	version := int64(1)
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%04d_%s", version, name)
+	upPath := filepath.Join(dir, base+".up.sql")
+	downPath := filepath.Join(dir, base+".down.sql")
+
+	err = os.WriteFile(upPath, []byte("-- "+base+" (up)\n"), 0o644)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = os.WriteFile(downPath, []byte("-- "+base+" (down)\n"), 0o644)
+	if err != nil {
+		return "", "", err
+	}
+
+	return upPath, downPath, nil
+}
+
+// load reads and pairs every *.up.sql / *.down.sql file, sorted by version
+func load(fsys fs.FS) ([]Migration, error) {
+
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	// embedded files live under sql/, files on disk are read from the directory itself
+	if len(paths) == 0 {
+		paths, err = fs.Glob(fsys, "sql/*.sql")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, path := range paths {
+
+		match := fileNamePattern.FindStringSubmatch(filepath.Base(path))
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %s", path)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", path, err)
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, migration := range byVersion {
+
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
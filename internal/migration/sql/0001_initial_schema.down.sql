@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS public.payments;
+DROP TABLE IF EXISTS public.order_items;
+DROP TABLE IF EXISTS public.orders;
+DROP TABLE IF EXISTS public.products;
+DROP TABLE IF EXISTS public.categories;
+DROP TABLE IF EXISTS public.accounts;
+DROP TABLE IF EXISTS public.users;
+
+DROP SEQUENCE IF EXISTS public.user_id_sequence;
+DROP SEQUENCE IF EXISTS public.product_id_sequence;
+DROP SEQUENCE IF EXISTS public.category_id_sequence;
+DROP SEQUENCE IF EXISTS public.account_id_sequence;
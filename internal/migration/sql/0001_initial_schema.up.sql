@@ -1,6 +1,7 @@
-create database terraloom;
+-- Baseline schema. Statements are idempotent so databases created from the
+-- former hand-run ddl.sql can adopt versioned migrations without data loss.
 
-CREATE SEQUENCE public.account_id_sequence
+CREATE SEQUENCE IF NOT EXISTS public.account_id_sequence
 	INCREMENT BY 1
 	MINVALUE 1
 	MAXVALUE 9223372036854775807
@@ -8,7 +9,7 @@ CREATE SEQUENCE public.account_id_sequence
 	CACHE 1
 	NO CYCLE;
 
-CREATE SEQUENCE public.category_id_sequence
+CREATE SEQUENCE IF NOT EXISTS public.category_id_sequence
 	INCREMENT BY 1
 	MINVALUE 1
 	MAXVALUE 9223372036854775807
@@ -16,7 +17,7 @@ CREATE SEQUENCE public.category_id_sequence
 	CACHE 1
 	NO CYCLE;
 
-CREATE SEQUENCE public.product_id_sequence
+CREATE SEQUENCE IF NOT EXISTS public.product_id_sequence
 	INCREMENT BY 1
 	MINVALUE 1
 	MAXVALUE 9223372036854775807
@@ -24,7 +25,7 @@ CREATE SEQUENCE public.product_id_sequence
 	CACHE 1
 	NO CYCLE;
 
-CREATE SEQUENCE public.user_id_sequence
+CREATE SEQUENCE IF NOT EXISTS public.user_id_sequence
 	INCREMENT BY 1
 	MINVALUE 1
 	MAXVALUE 9223372036854775807
@@ -32,7 +33,7 @@ CREATE SEQUENCE public.user_id_sequence
 	CACHE 1
 	NO CYCLE;
 
-CREATE TABLE public.accounts (
+CREATE TABLE IF NOT EXISTS public.accounts (
 	id int8 DEFAULT nextval('account_id_sequence'::regclass) NOT NULL,
 	display_name varchar(100) NOT NULL,
 	email varchar(200) NOT NULL,
@@ -48,7 +49,7 @@ CREATE TABLE public.accounts (
 	CONSTRAINT accounts_pkey PRIMARY KEY (id)
 );
 
-CREATE TABLE public.categories (
+CREATE TABLE IF NOT EXISTS public.categories (
 	id int8 DEFAULT nextval('category_id_sequence'::regclass) NOT NULL,
 	"name" varchar(100) NOT NULL,
 	description text NOT NULL,
@@ -61,7 +62,7 @@ CREATE TABLE public.categories (
 	CONSTRAINT categories_pkey PRIMARY KEY (id)
 );
 
-CREATE TABLE public.orders (
+CREATE TABLE IF NOT EXISTS public.orders (
 	order_reference varchar(255) NOT NULL,
 	order_date timestamp DEFAULT CURRENT_TIMESTAMP NULL,
 	status varchar(200) DEFAULT 'PENDING'::character varying NULL,
@@ -76,9 +77,9 @@ CREATE TABLE public.orders (
 	CONSTRAINT orders_pkey PRIMARY KEY (order_reference)
 );
 
-CREATE TABLE public.order_items (
+CREATE TABLE IF NOT EXISTS public.order_items (
 	order_item_reference varchar(255) NOT NULL,
-	order_reference varchar(255) NULL,
+	order_reference varchar(255) NOT NULL,
 	product_id int8 NULL,
 	price_snapshot int8 DEFAULT 0 NULL,
 	quantity int8 DEFAULT 0 NULL,
@@ -93,9 +94,9 @@ CREATE TABLE public.order_items (
 	CONSTRAINT order_items_pkey PRIMARY KEY (order_item_reference)
 );
 
-CREATE TABLE public.payments (
+CREATE TABLE IF NOT EXISTS public.payments (
 	payment_reference varchar(255) NOT NULL,
-	order_reference varchar(255) NULL,
+	order_reference varchar(255) NOT NULL,
 	total int8 DEFAULT 0 NULL,
 	status varchar(200) DEFAULT 'PENDING'::character varying NULL,
 	payment_date timestamp DEFAULT CURRENT_TIMESTAMP NULL,
@@ -109,7 +110,7 @@ CREATE TABLE public.payments (
 	CONSTRAINT payments_pkey PRIMARY KEY (payment_reference)
 );
 
-CREATE TABLE public.products (
+CREATE TABLE IF NOT EXISTS public.products (
 	id int8 DEFAULT nextval('product_id_sequence'::regclass) NOT NULL,
 	category_id int8 NOT NULL,
 	"name" varchar(100) NOT NULL,
@@ -126,7 +127,7 @@ CREATE TABLE public.products (
 	CONSTRAINT products_pkey PRIMARY KEY (id)
 );
 
-CREATE TABLE public.users (
+CREATE TABLE IF NOT EXISTS public.users (
 	id int8 DEFAULT nextval('user_id_sequence'::regclass) NOT NULL,
 	"role" varchar(100) NOT NULL,
 	username varchar(100) NOT NULL,
@@ -142,3 +143,11 @@ CREATE TABLE public.users (
 	CONSTRAINT users_pkey PRIMARY KEY (id),
 	CONSTRAINT users_username_key UNIQUE (username)
 );
+
+-- entity.OrderItem and entity.Payment declare order_reference as `not null;index`,
+-- which the hand-run ddl.sql never reflected
+ALTER TABLE public.order_items ALTER COLUMN order_reference SET NOT NULL;
+ALTER TABLE public.payments ALTER COLUMN order_reference SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order_reference ON public.order_items (order_reference);
+CREATE INDEX IF NOT EXISTS idx_payments_order_reference ON public.payments (order_reference);
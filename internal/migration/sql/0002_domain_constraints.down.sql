@@ -0,0 +1,20 @@
+ALTER TABLE public.payments DROP CONSTRAINT IF EXISTS payments_total_check;
+ALTER TABLE public.payments DROP CONSTRAINT IF EXISTS payments_order_reference_fkey;
+
+DROP INDEX IF EXISTS public.idx_order_items_product_id;
+ALTER TABLE public.order_items DROP CONSTRAINT IF EXISTS order_items_total_check;
+ALTER TABLE public.order_items DROP CONSTRAINT IF EXISTS order_items_quantity_check;
+ALTER TABLE public.order_items DROP CONSTRAINT IF EXISTS order_items_product_id_fkey;
+ALTER TABLE public.order_items DROP CONSTRAINT IF EXISTS order_items_order_reference_fkey;
+
+DROP INDEX IF EXISTS public.idx_orders_account_username;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_total_check;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_account_username_fkey;
+
+ALTER TABLE public.products DROP CONSTRAINT IF EXISTS products_category_id_fkey;
+ALTER TABLE public.products DROP CONSTRAINT IF EXISTS products_price_check;
+ALTER TABLE public.products DROP CONSTRAINT IF EXISTS products_stock_check;
+
+ALTER TABLE public.accounts DROP CONSTRAINT IF EXISTS accounts_email_key;
+ALTER TABLE public.accounts DROP CONSTRAINT IF EXISTS accounts_username_key;
+ALTER TABLE public.accounts ALTER COLUMN username DROP NOT NULL;
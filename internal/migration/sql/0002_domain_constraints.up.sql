@@ -0,0 +1,32 @@
+-- Accounts : username is the natural key referenced by orders
+ALTER TABLE public.accounts ALTER COLUMN username SET NOT NULL;
+ALTER TABLE public.accounts ADD CONSTRAINT accounts_username_key UNIQUE (username);
+ALTER TABLE public.accounts ADD CONSTRAINT accounts_email_key UNIQUE (email);
+
+-- Products
+ALTER TABLE public.products ADD CONSTRAINT products_stock_check CHECK (stock >= 0);
+ALTER TABLE public.products ADD CONSTRAINT products_price_check CHECK (price >= 0);
+ALTER TABLE public.products ADD CONSTRAINT products_category_id_fkey
+	FOREIGN KEY (category_id) REFERENCES public.categories (id);
+
+-- Orders
+ALTER TABLE public.orders ADD CONSTRAINT orders_account_username_fkey
+	FOREIGN KEY (account_username) REFERENCES public.accounts (username) ON UPDATE CASCADE;
+ALTER TABLE public.orders ADD CONSTRAINT orders_total_check CHECK (total >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_orders_account_username ON public.orders (account_username, order_date DESC);
+
+-- Order items
+ALTER TABLE public.order_items ADD CONSTRAINT order_items_order_reference_fkey
+	FOREIGN KEY (order_reference) REFERENCES public.orders (order_reference) ON DELETE CASCADE;
+ALTER TABLE public.order_items ADD CONSTRAINT order_items_product_id_fkey
+	FOREIGN KEY (product_id) REFERENCES public.products (id);
+ALTER TABLE public.order_items ADD CONSTRAINT order_items_quantity_check CHECK (quantity > 0);
+ALTER TABLE public.order_items ADD CONSTRAINT order_items_total_check CHECK (total >= 0);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON public.order_items (product_id);
+
+-- Payments
+ALTER TABLE public.payments ADD CONSTRAINT payments_order_reference_fkey
+	FOREIGN KEY (order_reference) REFERENCES public.orders (order_reference) ON DELETE CASCADE;
+ALTER TABLE public.payments ADD CONSTRAINT payments_total_check CHECK (total >= 0);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS public.idx_products_created_at;
+DROP INDEX IF EXISTS public.idx_products_price;
+DROP INDEX IF EXISTS public.idx_products_category_id;
@@ -0,0 +1,4 @@
+-- Filters and sorts used by the product listing
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON public.products (category_id);
+CREATE INDEX IF NOT EXISTS idx_products_price ON public.products (price, id);
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON public.products (created_at, id);
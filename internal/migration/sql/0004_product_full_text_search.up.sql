@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 'simple' configuration : no stemming, product names are not necessarily english
+ALTER TABLE public.products ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple', coalesce("name", '')), 'A') ||
+	setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON public.products USING GIN (search_vector);
+
+-- Typo tolerant fallback, also serves name ILIKE '%...%'
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON public.products USING GIN ("name" gin_trgm_ops);
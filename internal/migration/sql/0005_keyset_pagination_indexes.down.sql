@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_orders_account_username ON public.orders (account_username, order_date DESC);
+DROP INDEX IF EXISTS public.idx_orders_account_keyset;
+DROP INDEX IF EXISTS public.idx_products_name;
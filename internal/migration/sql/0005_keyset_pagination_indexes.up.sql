@@ -0,0 +1,6 @@
+-- Keyset pagination reads (sort key, id) ranges
+CREATE INDEX IF NOT EXISTS idx_products_name ON public.products (name, id);
+
+-- Replaces idx_orders_account_username, order_reference breaks ties between equal dates
+CREATE INDEX IF NOT EXISTS idx_orders_account_keyset ON public.orders (account_username, order_date DESC, order_reference DESC);
+DROP INDEX IF EXISTS public.idx_orders_account_username;
@@ -0,0 +1,9 @@
+ALTER TABLE public.order_items DROP CONSTRAINT IF EXISTS order_items_variant_fk;
+ALTER TABLE public.order_items DROP COLUMN IF EXISTS variant_options_snapshot;
+ALTER TABLE public.order_items DROP COLUMN IF EXISTS sku_snapshot;
+ALTER TABLE public.order_items DROP COLUMN IF EXISTS variant_id;
+DROP TABLE IF EXISTS public.product_variant_options;
+DROP TABLE IF EXISTS public.product_variants;
+DROP SEQUENCE IF EXISTS public.product_variant_id_sequence;
+DROP TABLE IF EXISTS public.product_option_types;
+DROP SEQUENCE IF EXISTS public.product_option_type_id_sequence;
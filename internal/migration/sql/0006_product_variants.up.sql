@@ -0,0 +1,63 @@
+-- Option types of a product (Size, Colour, ...), every variant picks one value per type
+CREATE SEQUENCE IF NOT EXISTS public.product_option_type_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.product_option_types (
+	id int8 DEFAULT nextval('product_option_type_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	"name" varchar(100) NOT NULL,
+	position int4 DEFAULT 0 NOT NULL,
+	CONSTRAINT product_option_types_pkey PRIMARY KEY (id),
+	CONSTRAINT product_option_types_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id) ON DELETE CASCADE,
+	CONSTRAINT product_option_types_name_unique UNIQUE (product_id, "name")
+);
+
+-- Sellable variants, a NULL price falls back to the product price
+CREATE SEQUENCE IF NOT EXISTS public.product_variant_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.product_variants (
+	id int8 DEFAULT nextval('product_variant_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	sku varchar(64) NOT NULL,
+	price int8 NULL,
+	stock int8 DEFAULT 0 NOT NULL,
+	is_active bool DEFAULT true NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	deleted_at timestamp NULL,
+	CONSTRAINT product_variants_pkey PRIMARY KEY (id),
+	CONSTRAINT product_variants_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id),
+	CONSTRAINT product_variants_sku_unique UNIQUE (sku),
+	CONSTRAINT product_variants_stock_check CHECK (stock >= 0),
+	CONSTRAINT product_variants_price_check CHECK (price IS NULL OR price >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON public.product_variants (product_id);
+
+CREATE TABLE IF NOT EXISTS public.product_variant_options (
+	variant_id int8 NOT NULL,
+	option_type_id int8 NOT NULL,
+	value varchar(100) NOT NULL,
+	CONSTRAINT product_variant_options_pkey PRIMARY KEY (variant_id, option_type_id),
+	CONSTRAINT product_variant_options_variant_fk FOREIGN KEY (variant_id) REFERENCES public.product_variants (id) ON DELETE CASCADE,
+	CONSTRAINT product_variant_options_option_type_fk FOREIGN KEY (option_type_id) REFERENCES public.product_option_types (id) ON DELETE CASCADE
+);
+
+-- Order items remember the variant and the options chosen at the time of the order
+ALTER TABLE public.order_items ADD COLUMN IF NOT EXISTS variant_id int8 NULL;
+ALTER TABLE public.order_items ADD COLUMN IF NOT EXISTS sku_snapshot varchar(64) NULL;
+ALTER TABLE public.order_items ADD COLUMN IF NOT EXISTS variant_options_snapshot jsonb NULL;
+ALTER TABLE public.order_items ADD CONSTRAINT order_items_variant_fk FOREIGN KEY (variant_id) REFERENCES public.product_variants (id);
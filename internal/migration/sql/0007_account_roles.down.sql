@@ -0,0 +1,2 @@
+ALTER TABLE public.accounts DROP CONSTRAINT IF EXISTS accounts_role_check;
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS "role";
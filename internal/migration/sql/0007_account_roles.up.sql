@@ -0,0 +1,3 @@
+-- Staff and admin accounts manage the catalogue, new accounts are customers
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS "role" varchar(20) DEFAULT 'CUSTOMER' NOT NULL;
+ALTER TABLE public.accounts ADD CONSTRAINT accounts_role_check CHECK ("role" IN ('CUSTOMER', 'STAFF', 'ADMIN'));
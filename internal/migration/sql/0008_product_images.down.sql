@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.product_image_sizes;
+DROP TABLE IF EXISTS public.product_images;
+DROP SEQUENCE IF EXISTS public.product_image_id_sequence;
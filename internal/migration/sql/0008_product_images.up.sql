@@ -0,0 +1,47 @@
+-- Product gallery, products.image_url keeps the primary image url for older clients
+CREATE SEQUENCE IF NOT EXISTS public.product_image_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.product_images (
+	id int8 DEFAULT nextval('product_image_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	storage_key varchar(255) NOT NULL,
+	url text NOT NULL,
+	alt_text varchar(255) DEFAULT '' NOT NULL,
+	position int4 DEFAULT 0 NOT NULL,
+	is_primary bool DEFAULT false NOT NULL,
+	content_type varchar(50) NOT NULL,
+	width int4 NOT NULL,
+	height int4 NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	CONSTRAINT product_images_pkey PRIMARY KEY (id),
+	CONSTRAINT product_images_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id) ON DELETE CASCADE,
+	CONSTRAINT product_images_storage_key_unique UNIQUE (storage_key),
+	CONSTRAINT product_images_position_check CHECK (position >= 0),
+	CONSTRAINT product_images_dimensions_check CHECK (width > 0 AND height > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product_id ON public.product_images (product_id, position, id);
+
+-- At most one primary image per product
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_images_primary ON public.product_images (product_id) WHERE is_primary;
+
+-- Thumbnails generated from an image, only sizes smaller than the original exist
+CREATE TABLE IF NOT EXISTS public.product_image_sizes (
+	image_id int8 NOT NULL,
+	"size" varchar(20) NOT NULL,
+	storage_key varchar(255) NOT NULL,
+	url text NOT NULL,
+	width int4 NOT NULL,
+	height int4 NOT NULL,
+	CONSTRAINT product_image_sizes_pkey PRIMARY KEY (image_id, "size"),
+	CONSTRAINT product_image_sizes_image_fk FOREIGN KEY (image_id) REFERENCES public.product_images (id) ON DELETE CASCADE
+);
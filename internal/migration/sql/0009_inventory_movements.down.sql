@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS inventory_movements_append_only ON public.inventory_movements;
+DROP FUNCTION IF EXISTS public.inventory_movements_append_only();
+DROP TABLE IF EXISTS public.inventory_movements;
+DROP SEQUENCE IF EXISTS public.inventory_movement_id_sequence;
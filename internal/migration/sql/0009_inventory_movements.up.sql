@@ -0,0 +1,52 @@
+-- Append-only ledger of stock changes, the stock of a product (or variant) equals the sum of its movements
+CREATE SEQUENCE IF NOT EXISTS public.inventory_movement_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.inventory_movements (
+	id int8 DEFAULT nextval('inventory_movement_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	variant_id int8 NULL,
+	movement_type varchar(30) NOT NULL,
+	quantity int8 NOT NULL,
+	stock_after int8 NOT NULL,
+	reference varchar(100) NULL,
+	note varchar(255) NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	CONSTRAINT inventory_movements_pkey PRIMARY KEY (id),
+	CONSTRAINT inventory_movements_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id),
+	CONSTRAINT inventory_movements_variant_fk FOREIGN KEY (variant_id) REFERENCES public.product_variants (id),
+	CONSTRAINT inventory_movements_type_check CHECK (movement_type IN ('OPENING_BALANCE', 'ORDER_RESERVATION', 'CANCELLATION_RETURN', 'MANUAL_ADJUSTMENT', 'RESTOCK', 'REFUND')),
+	CONSTRAINT inventory_movements_quantity_check CHECK (quantity <> 0 OR movement_type = 'OPENING_BALANCE')
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_product_id ON public.inventory_movements (product_id, id);
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_variant_id ON public.inventory_movements (variant_id) WHERE variant_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION public.inventory_movements_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'inventory_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER inventory_movements_append_only
+	BEFORE UPDATE OR DELETE ON public.inventory_movements
+	FOR EACH ROW EXECUTE FUNCTION public.inventory_movements_append_only();
+
+-- Opening balance of the stock held before the ledger existed.
+-- Variant stock is booked on the variant, the remainder on the product itself
+INSERT INTO public.inventory_movements (product_id, variant_id, movement_type, quantity, stock_after, note, created_by)
+SELECT v.product_id, v.id, 'OPENING_BALANCE', v.stock, v.stock, 'Stock before the inventory ledger', 'SYSTEM'
+FROM public.product_variants v
+WHERE v.stock <> 0;
+
+INSERT INTO public.inventory_movements (product_id, movement_type, quantity, stock_after, note, created_by)
+SELECT p.id, 'OPENING_BALANCE', p.stock - COALESCE(v.stock, 0), p.stock, 'Stock before the inventory ledger', 'SYSTEM'
+FROM public.products p
+LEFT JOIN (SELECT product_id, SUM(stock) AS stock FROM public.product_variants GROUP BY product_id) v ON v.product_id = p.id
+WHERE p.stock - COALESCE(v.stock, 0) <> 0;
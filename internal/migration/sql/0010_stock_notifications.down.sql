@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.stock_subscriptions;
+DROP SEQUENCE IF EXISTS public.stock_subscription_id_sequence;
+ALTER TABLE public.products DROP CONSTRAINT IF EXISTS products_low_stock_threshold_check;
+ALTER TABLE public.products DROP COLUMN IF EXISTS low_stock_threshold;
@@ -0,0 +1,27 @@
+-- Staff are alerted when the stock of a product falls to its threshold, 0 disables the alert
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS low_stock_threshold int8 DEFAULT 0 NOT NULL;
+ALTER TABLE public.products ADD CONSTRAINT products_low_stock_threshold_check CHECK (low_stock_threshold >= 0);
+
+-- Customers waiting for an out of stock product, notified_at is set once the back in stock notice is sent
+CREATE SEQUENCE IF NOT EXISTS public.stock_subscription_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.stock_subscriptions (
+	id int8 DEFAULT nextval('stock_subscription_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	account_username varchar(100) NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	notified_at timestamp NULL,
+	CONSTRAINT stock_subscriptions_pkey PRIMARY KEY (id),
+	CONSTRAINT stock_subscriptions_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id) ON DELETE CASCADE,
+	CONSTRAINT stock_subscriptions_account_fk FOREIGN KEY (account_username) REFERENCES public.accounts (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- One pending subscription per account and product, a notified customer may subscribe again
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_subscriptions_pending ON public.stock_subscriptions (product_id, account_username) WHERE notified_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_stock_subscriptions_account ON public.stock_subscriptions (account_username, id) WHERE notified_at IS NULL;
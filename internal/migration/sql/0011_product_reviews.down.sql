@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.idx_products_rating_keyset;
+ALTER TABLE public.products DROP COLUMN IF EXISTS review_count;
+ALTER TABLE public.products DROP COLUMN IF EXISTS rating_average;
+DROP TABLE IF EXISTS public.product_reviews;
+DROP SEQUENCE IF EXISTS public.product_review_id_sequence;
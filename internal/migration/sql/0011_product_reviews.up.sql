@@ -0,0 +1,40 @@
+-- Reviews by customers with a finished order of the product, one review per account and product
+CREATE SEQUENCE IF NOT EXISTS public.product_review_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.product_reviews (
+	id int8 DEFAULT nextval('product_review_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	account_username varchar(100) NOT NULL,
+	rating int2 NOT NULL,
+	body text NOT NULL,
+	status varchar(20) DEFAULT 'PUBLISHED' NOT NULL,
+	moderation_note varchar(255) NULL,
+	moderated_at timestamp NULL,
+	moderated_by varchar(100) NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	CONSTRAINT product_reviews_pkey PRIMARY KEY (id),
+	CONSTRAINT product_reviews_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id) ON DELETE CASCADE,
+	CONSTRAINT product_reviews_account_fk FOREIGN KEY (account_username) REFERENCES public.accounts (username) ON UPDATE CASCADE ON DELETE CASCADE,
+	CONSTRAINT product_reviews_account_product_unique UNIQUE (product_id, account_username),
+	CONSTRAINT product_reviews_rating_check CHECK (rating BETWEEN 1 AND 5),
+	CONSTRAINT product_reviews_status_check CHECK (status IN ('PUBLISHED', 'HIDDEN'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_reviews_product ON public.product_reviews (product_id, status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_account ON public.product_reviews (account_username, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_status ON public.product_reviews (status, created_at DESC, id DESC);
+
+-- Summary of the published reviews, kept on the product so listings can sort by rating
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS rating_average numeric(3, 2) DEFAULT 0 NOT NULL;
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS review_count int8 DEFAULT 0 NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_rating_keyset ON public.products (rating_average, id);
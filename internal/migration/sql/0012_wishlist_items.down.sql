@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.wishlist_items;
+DROP SEQUENCE IF EXISTS public.wishlist_item_id_sequence;
@@ -0,0 +1,28 @@
+-- Products saved for later, a product sold by variant is saved with its variant
+CREATE SEQUENCE IF NOT EXISTS public.wishlist_item_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.wishlist_items (
+	id int8 DEFAULT nextval('wishlist_item_id_sequence'::regclass) NOT NULL,
+	account_username varchar(100) NOT NULL,
+	product_id int8 NOT NULL,
+	variant_id int8 NULL,
+	added_price int8 NOT NULL,
+	-- Price the next price drop is measured against, lowered every time a drop is notified
+	reference_price int8 NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT wishlist_items_pkey PRIMARY KEY (id),
+	CONSTRAINT wishlist_items_account_fk FOREIGN KEY (account_username) REFERENCES public.accounts (username) ON UPDATE CASCADE ON DELETE CASCADE,
+	CONSTRAINT wishlist_items_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id) ON DELETE CASCADE,
+	CONSTRAINT wishlist_items_variant_fk FOREIGN KEY (variant_id) REFERENCES public.product_variants (id) ON DELETE CASCADE,
+	CONSTRAINT wishlist_items_price_check CHECK (added_price >= 0 AND reference_price >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wishlist_items_unique ON public.wishlist_items (account_username, product_id, COALESCE(variant_id, 0));
+CREATE INDEX IF NOT EXISTS idx_wishlist_items_product ON public.wishlist_items (product_id);
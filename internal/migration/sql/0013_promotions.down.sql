@@ -0,0 +1,7 @@
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_discount_total_check;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS discount_total;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS subtotal;
+DROP TABLE IF EXISTS public.order_discounts;
+DROP SEQUENCE IF EXISTS public.order_discount_id_sequence;
+DROP TABLE IF EXISTS public.promotions;
+DROP SEQUENCE IF EXISTS public.promotion_id_sequence;
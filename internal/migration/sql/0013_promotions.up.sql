@@ -0,0 +1,83 @@
+-- Coupons redeemed at order submission, one coupon per order
+CREATE SEQUENCE IF NOT EXISTS public.promotion_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.promotions (
+	id int8 DEFAULT nextval('promotion_id_sequence'::regclass) NOT NULL,
+	code varchar(50) NOT NULL,
+	description varchar(255) NULL,
+	promotion_type varchar(20) NOT NULL,
+	-- Percentage for PERCENTAGE, amount for FIXED_AMOUNT, unused otherwise
+	value int8 DEFAULT 0 NOT NULL,
+	buy_quantity int8 DEFAULT 0 NOT NULL,
+	get_quantity int8 DEFAULT 0 NOT NULL,
+	min_order_amount int8 DEFAULT 0 NOT NULL,
+	-- Redemptions by non cancelled orders, NULL for unlimited
+	usage_limit int8 NULL,
+	per_account_limit int8 NULL,
+	-- Scope, at most one of them, the whole order when both are NULL
+	product_id int8 NULL,
+	category_id int8 NULL,
+	starts_at timestamp NULL,
+	ends_at timestamp NULL,
+	is_active bool DEFAULT true NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	CONSTRAINT promotions_pkey PRIMARY KEY (id),
+	CONSTRAINT promotions_code_unique UNIQUE (code),
+	CONSTRAINT promotions_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id),
+	CONSTRAINT promotions_category_fk FOREIGN KEY (category_id) REFERENCES public.categories (id),
+	CONSTRAINT promotions_type_check CHECK (promotion_type IN ('PERCENTAGE', 'FIXED_AMOUNT', 'FREE_SHIPPING', 'BUY_X_GET_Y')),
+	CONSTRAINT promotions_value_check CHECK (
+		(promotion_type = 'PERCENTAGE' AND value BETWEEN 1 AND 100)
+		OR (promotion_type = 'FIXED_AMOUNT' AND value > 0)
+		OR (promotion_type = 'BUY_X_GET_Y' AND buy_quantity > 0 AND get_quantity > 0)
+		OR promotion_type = 'FREE_SHIPPING'),
+	CONSTRAINT promotions_amounts_check CHECK (min_order_amount >= 0 AND COALESCE(usage_limit, 1) > 0 AND COALESCE(per_account_limit, 1) > 0),
+	CONSTRAINT promotions_scope_check CHECK (product_id IS NULL OR category_id IS NULL),
+	CONSTRAINT promotions_window_check CHECK (starts_at IS NULL OR ends_at IS NULL OR ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_created ON public.promotions (created_at DESC, id DESC);
+
+-- Discount lines of an order, they also count the redemptions of a promotion
+CREATE SEQUENCE IF NOT EXISTS public.order_discount_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.order_discounts (
+	id int8 DEFAULT nextval('order_discount_id_sequence'::regclass) NOT NULL,
+	order_reference varchar(255) NOT NULL,
+	promotion_id int8 NOT NULL,
+	code varchar(50) NOT NULL,
+	promotion_type varchar(20) NOT NULL,
+	description varchar(255) NULL,
+	amount int8 NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT order_discounts_pkey PRIMARY KEY (id),
+	CONSTRAINT order_discounts_order_fk FOREIGN KEY (order_reference) REFERENCES public.orders (order_reference) ON DELETE CASCADE,
+	CONSTRAINT order_discounts_promotion_fk FOREIGN KEY (promotion_id) REFERENCES public.promotions (id),
+	CONSTRAINT order_discounts_order_promotion_unique UNIQUE (order_reference, promotion_id),
+	CONSTRAINT order_discounts_amount_check CHECK (amount >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_discounts_promotion ON public.order_discounts (promotion_id);
+
+-- total is the subtotal of the items minus the discounts
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS subtotal int8 DEFAULT 0 NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS discount_total int8 DEFAULT 0 NOT NULL;
+
+UPDATE public.orders SET subtotal = COALESCE(total, 0);
+
+ALTER TABLE public.orders ADD CONSTRAINT orders_discount_total_check CHECK (subtotal >= 0 AND discount_total >= 0 AND discount_total <= subtotal);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS public.product_price_history;
+DROP SEQUENCE IF EXISTS public.product_price_history_id_sequence;
+DROP TABLE IF EXISTS public.product_price_changes;
+DROP SEQUENCE IF EXISTS public.product_price_change_id_sequence;
+ALTER TABLE public.products DROP CONSTRAINT IF EXISTS products_sale_check;
+ALTER TABLE public.products DROP COLUMN IF EXISTS sale_ends_at;
+ALTER TABLE public.products DROP COLUMN IF EXISTS sale_starts_at;
+ALTER TABLE public.products DROP COLUMN IF EXISTS sale_price;
@@ -0,0 +1,62 @@
+-- Sale price, charged instead of price while the sale runs (NULL bounds are open)
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS sale_price int8 NULL;
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS sale_starts_at timestamp NULL;
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS sale_ends_at timestamp NULL;
+
+ALTER TABLE public.products ADD CONSTRAINT products_sale_check CHECK (
+	(sale_price IS NULL AND sale_starts_at IS NULL AND sale_ends_at IS NULL)
+	OR (sale_price > 0 AND (sale_starts_at IS NULL OR sale_ends_at IS NULL OR sale_ends_at > sale_starts_at)));
+
+-- Base price changes taking effect at effective_at, applied_at is set once the price is written
+CREATE SEQUENCE IF NOT EXISTS public.product_price_change_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.product_price_changes (
+	id int8 DEFAULT nextval('product_price_change_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	price int8 NOT NULL,
+	effective_at timestamp NOT NULL,
+	applied_at timestamp NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	CONSTRAINT product_price_changes_pkey PRIMARY KEY (id),
+	CONSTRAINT product_price_changes_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id),
+	CONSTRAINT product_price_changes_price_check CHECK (price > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_price_changes_pending ON public.product_price_changes (effective_at, id) WHERE applied_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_product_price_changes_product ON public.product_price_changes (product_id, effective_at) WHERE applied_at IS NULL;
+
+-- Every change of the base price or the sale, append only
+CREATE SEQUENCE IF NOT EXISTS public.product_price_history_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.product_price_history (
+	id int8 DEFAULT nextval('product_price_history_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	price_type varchar(10) NOT NULL,
+	-- NULL when there was no sale before or there is none after
+	old_price int8 NULL,
+	new_price int8 NULL,
+	sale_starts_at timestamp NULL,
+	sale_ends_at timestamp NULL,
+	price_change_id int8 NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	CONSTRAINT product_price_history_pkey PRIMARY KEY (id),
+	CONSTRAINT product_price_history_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id),
+	CONSTRAINT product_price_history_change_fk FOREIGN KEY (price_change_id) REFERENCES public.product_price_changes (id),
+	CONSTRAINT product_price_history_type_check CHECK (price_type IN ('BASE', 'SALE'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_price_history_product ON public.product_price_history (product_id, created_at DESC, id DESC);
@@ -0,0 +1,10 @@
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_tax_total_check;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS tax_included;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS tax_total;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS tax_region;
+ALTER TABLE public.order_items DROP CONSTRAINT IF EXISTS order_items_discount_amount_check;
+ALTER TABLE public.order_items DROP COLUMN IF EXISTS discount_amount;
+DROP TABLE IF EXISTS public.order_item_taxes;
+DROP SEQUENCE IF EXISTS public.order_item_tax_id_sequence;
+DROP TABLE IF EXISTS public.tax_rules;
+DROP SEQUENCE IF EXISTS public.tax_rule_id_sequence;
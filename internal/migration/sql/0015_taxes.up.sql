@@ -0,0 +1,74 @@
+-- Tax rules, rate in basis points (1100 is 11%), a NULL region or category matches every one
+CREATE SEQUENCE IF NOT EXISTS public.tax_rule_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.tax_rules (
+	id int8 DEFAULT nextval('tax_rule_id_sequence'::regclass) NOT NULL,
+	name varchar(50) NOT NULL,
+	region varchar(10) NULL,
+	category_id int8 NULL,
+	rate int8 NOT NULL,
+	mode varchar(10) NOT NULL,
+	is_active bool DEFAULT true NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	CONSTRAINT tax_rules_pkey PRIMARY KEY (id),
+	CONSTRAINT tax_rules_category_fk FOREIGN KEY (category_id) REFERENCES public.categories (id),
+	CONSTRAINT tax_rules_rate_check CHECK (rate BETWEEN 0 AND 10000),
+	CONSTRAINT tax_rules_mode_check CHECK (mode IN ('INCLUSIVE', 'EXCLUSIVE'))
+);
+
+-- One active rule per tax and scope, the most specific scope of a tax wins
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rules_active_scope ON public.tax_rules (name, COALESCE(region, ''), COALESCE(category_id, 0)) WHERE is_active;
+CREATE INDEX IF NOT EXISTS idx_tax_rules_created ON public.tax_rules (created_at DESC, id DESC);
+
+-- Tax lines of the order items, kept as computed at submission
+CREATE SEQUENCE IF NOT EXISTS public.order_item_tax_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.order_item_taxes (
+	id int8 DEFAULT nextval('order_item_tax_id_sequence'::regclass) NOT NULL,
+	order_reference varchar(255) NOT NULL,
+	order_item_reference varchar(255) NOT NULL,
+	tax_rule_id int8 NOT NULL,
+	name varchar(50) NOT NULL,
+	rate int8 NOT NULL,
+	mode varchar(10) NOT NULL,
+	-- Amount of the item net of every tax
+	taxable_amount int8 NOT NULL,
+	amount int8 NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT order_item_taxes_pkey PRIMARY KEY (id),
+	CONSTRAINT order_item_taxes_order_fk FOREIGN KEY (order_reference) REFERENCES public.orders (order_reference) ON DELETE CASCADE,
+	CONSTRAINT order_item_taxes_order_item_fk FOREIGN KEY (order_item_reference) REFERENCES public.order_items (order_item_reference) ON DELETE CASCADE,
+	CONSTRAINT order_item_taxes_rule_fk FOREIGN KEY (tax_rule_id) REFERENCES public.tax_rules (id),
+	CONSTRAINT order_item_taxes_amount_check CHECK (taxable_amount >= 0 AND amount >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_item_taxes_order ON public.order_item_taxes (order_reference);
+CREATE INDEX IF NOT EXISTS idx_order_item_taxes_order_item ON public.order_item_taxes (order_item_reference);
+
+-- Share of the order discounts taken off the item, taxes apply to the discounted amount
+ALTER TABLE public.order_items ADD COLUMN IF NOT EXISTS discount_amount int8 DEFAULT 0 NOT NULL;
+
+ALTER TABLE public.order_items ADD CONSTRAINT order_items_discount_amount_check CHECK (discount_amount >= 0 AND discount_amount <= total);
+
+-- total is the subtotal minus the discounts plus the exclusive taxes,
+-- tax_included is the part of tax_total already included in the prices
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS tax_region varchar(10) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS tax_total int8 DEFAULT 0 NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS tax_included int8 DEFAULT 0 NOT NULL;
+
+ALTER TABLE public.orders ADD CONSTRAINT orders_tax_total_check CHECK (tax_total >= 0 AND tax_included BETWEEN 0 AND tax_total);
@@ -0,0 +1,15 @@
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_discount_total_check;
+ALTER TABLE public.orders ADD CONSTRAINT orders_discount_total_check CHECK (subtotal >= 0 AND discount_total >= 0 AND discount_total <= subtotal);
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_shipping_check;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_shipping_method_fk;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS shipping_fee;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS shipping_weight;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS shipping_method;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS shipping_method_id;
+DROP TABLE IF EXISTS public.shipping_methods;
+DROP SEQUENCE IF EXISTS public.shipping_method_id_sequence;
+ALTER TABLE public.products DROP CONSTRAINT IF EXISTS products_dimensions_check;
+ALTER TABLE public.products DROP COLUMN IF EXISTS height;
+ALTER TABLE public.products DROP COLUMN IF EXISTS width;
+ALTER TABLE public.products DROP COLUMN IF EXISTS length;
+ALTER TABLE public.products DROP COLUMN IF EXISTS weight;
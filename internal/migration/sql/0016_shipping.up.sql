@@ -0,0 +1,59 @@
+-- Parcel size of one unit, weight in grams and dimensions in millimetres, 0 when unknown
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS weight int8 DEFAULT 0 NOT NULL;
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS length int8 DEFAULT 0 NOT NULL;
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS width int8 DEFAULT 0 NOT NULL;
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS height int8 DEFAULT 0 NOT NULL;
+
+ALTER TABLE public.products ADD CONSTRAINT products_dimensions_check CHECK (weight >= 0 AND length >= 0 AND width >= 0 AND height >= 0);
+
+-- Shipping methods, FLAT_RATE charges flat_fee, WEIGHT_TIERED the fee of the first tier carrying the parcel
+CREATE SEQUENCE IF NOT EXISTS public.shipping_method_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.shipping_methods (
+	id int8 DEFAULT nextval('shipping_method_id_sequence'::regclass) NOT NULL,
+	code varchar(50) NOT NULL,
+	name varchar(100) NOT NULL,
+	method_type varchar(20) NOT NULL,
+	flat_fee int8 DEFAULT 0 NOT NULL,
+	-- [{"upToWeight": grams, "fee": amount}] by ascending weight
+	tiers jsonb NULL,
+	-- Free when the items subtotal reaches free_above, never free when NULL
+	free_above int8 NULL,
+	-- NULL for every region
+	region varchar(10) NULL,
+	-- Heaviest parcel carried, in grams, NULL for no limit
+	max_weight int8 NULL,
+	is_active bool DEFAULT true NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	CONSTRAINT shipping_methods_pkey PRIMARY KEY (id),
+	CONSTRAINT shipping_methods_code_unique UNIQUE (code),
+	CONSTRAINT shipping_methods_type_check CHECK (method_type IN ('FLAT_RATE', 'WEIGHT_TIERED')),
+	CONSTRAINT shipping_methods_fee_check CHECK (
+		(method_type = 'FLAT_RATE' AND flat_fee >= 0 AND tiers IS NULL)
+		OR (method_type = 'WEIGHT_TIERED' AND flat_fee = 0 AND jsonb_typeof(tiers) = 'array')),
+	CONSTRAINT shipping_methods_limits_check CHECK (COALESCE(free_above, 1) > 0 AND COALESCE(max_weight, 1) > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_created ON public.shipping_methods (created_at DESC, id DESC);
+
+-- Shipping of the order, the method code is kept as selected
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS shipping_method_id int8 NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS shipping_method varchar(50) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS shipping_weight int8 DEFAULT 0 NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS shipping_fee int8 DEFAULT 0 NOT NULL;
+
+ALTER TABLE public.orders ADD CONSTRAINT orders_shipping_method_fk FOREIGN KEY (shipping_method_id) REFERENCES public.shipping_methods (id);
+ALTER TABLE public.orders ADD CONSTRAINT orders_shipping_check CHECK (shipping_weight >= 0 AND shipping_fee >= 0);
+
+-- A FREE_SHIPPING coupon discounts the shipping fee
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_discount_total_check;
+ALTER TABLE public.orders ADD CONSTRAINT orders_discount_total_check CHECK (subtotal >= 0 AND discount_total >= 0 AND discount_total <= subtotal + shipping_fee);
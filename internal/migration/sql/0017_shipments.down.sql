@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.shipment_items;
+DROP SEQUENCE IF EXISTS public.shipment_item_id_sequence;
+DROP TABLE IF EXISTS public.shipments;
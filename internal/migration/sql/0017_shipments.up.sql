@@ -0,0 +1,44 @@
+-- Shipments of an order, an order ships in one or more parcels
+CREATE TABLE IF NOT EXISTS public.shipments (
+	shipment_reference varchar(255) NOT NULL,
+	order_reference varchar(255) NOT NULL,
+	carrier varchar(50) NOT NULL,
+	tracking_number varchar(100) NOT NULL,
+	shipped_at timestamp NOT NULL,
+	-- NULL while the parcel is in transit
+	delivered_at timestamp NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	CONSTRAINT shipments_pkey PRIMARY KEY (shipment_reference),
+	CONSTRAINT shipments_order_fk FOREIGN KEY (order_reference) REFERENCES public.orders (order_reference) ON DELETE CASCADE,
+	CONSTRAINT shipments_delivered_check CHECK (delivered_at IS NULL OR delivered_at >= shipped_at)
+);
+
+-- A tracking number identifies one parcel of its carrier
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shipments_tracking ON public.shipments (LOWER(carrier), tracking_number);
+CREATE INDEX IF NOT EXISTS idx_shipments_order ON public.shipments (order_reference, shipped_at);
+
+-- Quantities of the order items in the parcel
+CREATE SEQUENCE IF NOT EXISTS public.shipment_item_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.shipment_items (
+	id int8 DEFAULT nextval('shipment_item_id_sequence'::regclass) NOT NULL,
+	shipment_reference varchar(255) NOT NULL,
+	order_item_reference varchar(255) NOT NULL,
+	quantity int8 NOT NULL,
+	CONSTRAINT shipment_items_pkey PRIMARY KEY (id),
+	CONSTRAINT shipment_items_shipment_fk FOREIGN KEY (shipment_reference) REFERENCES public.shipments (shipment_reference) ON DELETE CASCADE,
+	CONSTRAINT shipment_items_order_item_fk FOREIGN KEY (order_item_reference) REFERENCES public.order_items (order_item_reference) ON DELETE CASCADE,
+	CONSTRAINT shipment_items_unique UNIQUE (shipment_reference, order_item_reference),
+	CONSTRAINT shipment_items_quantity_check CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_items_order_item ON public.shipment_items (order_item_reference);
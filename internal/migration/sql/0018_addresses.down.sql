@@ -0,0 +1,12 @@
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_address_fk;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_country;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_postal_code;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_region;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_city;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_line2;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_line1;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_phone;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS delivery_recipient_name;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS address_id;
+DROP TABLE IF EXISTS public.addresses;
+DROP SEQUENCE IF EXISTS public.address_id_sequence;
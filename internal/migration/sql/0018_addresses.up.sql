@@ -0,0 +1,44 @@
+-- Address book of the accounts, one default address per account
+CREATE SEQUENCE IF NOT EXISTS public.address_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.addresses (
+	id int8 DEFAULT nextval('address_id_sequence'::regclass) NOT NULL,
+	account_username varchar(100) NOT NULL,
+	recipient_name varchar(100) NOT NULL,
+	phone varchar(20) NOT NULL,
+	line1 varchar(200) NOT NULL,
+	line2 varchar(200) DEFAULT '' NOT NULL,
+	city varchar(100) NOT NULL,
+	-- Same codes as the tax and shipping regions, e.g. ID-JK
+	region varchar(10) NOT NULL,
+	postal_code varchar(10) NOT NULL,
+	-- ISO 3166-1 alpha-2
+	country varchar(2) NOT NULL,
+	is_default bool DEFAULT false NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	CONSTRAINT addresses_pkey PRIMARY KEY (id),
+	CONSTRAINT addresses_account_fk FOREIGN KEY (account_username) REFERENCES public.accounts (username) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_default ON public.addresses (account_username) WHERE is_default;
+CREATE INDEX IF NOT EXISTS idx_addresses_account ON public.addresses (account_username, id);
+
+-- The delivery address is copied on the order, delivery_address keeps it as one line
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS address_id int8 NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_recipient_name varchar(100) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_phone varchar(20) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_line1 varchar(200) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_line2 varchar(200) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_city varchar(100) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_region varchar(10) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_postal_code varchar(10) DEFAULT '' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS delivery_country varchar(2) DEFAULT '' NOT NULL;
+
+ALTER TABLE public.orders ADD CONSTRAINT orders_address_fk FOREIGN KEY (address_id) REFERENCES public.addresses (id) ON DELETE SET NULL;
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS public.product_currency_prices;
+DROP SEQUENCE IF EXISTS public.product_currency_price_id_sequence;
+
+ALTER TABLE public.payments DROP CONSTRAINT IF EXISTS payments_order_currency_fk;
+ALTER TABLE public.order_items DROP CONSTRAINT IF EXISTS order_items_order_currency_fk;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_reference_currency_unique;
+
+ALTER TABLE public.payments DROP COLUMN IF EXISTS currency;
+ALTER TABLE public.order_items DROP COLUMN IF EXISTS currency;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS currency;
+ALTER TABLE public.shipping_methods DROP COLUMN IF EXISTS currency;
+ALTER TABLE public.promotions DROP COLUMN IF EXISTS currency;
+ALTER TABLE public.products DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,46 @@
+-- Currency of the amounts, ISO 4217 codes, amounts stay in the minor unit of their currency
+ALTER TABLE public.products ADD COLUMN IF NOT EXISTS currency varchar(3) DEFAULT 'IDR' NOT NULL;
+ALTER TABLE public.promotions ADD COLUMN IF NOT EXISTS currency varchar(3) DEFAULT 'IDR' NOT NULL;
+ALTER TABLE public.shipping_methods ADD COLUMN IF NOT EXISTS currency varchar(3) DEFAULT 'IDR' NOT NULL;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS currency varchar(3) DEFAULT 'IDR' NOT NULL;
+ALTER TABLE public.order_items ADD COLUMN IF NOT EXISTS currency varchar(3) DEFAULT 'IDR' NOT NULL;
+ALTER TABLE public.payments ADD COLUMN IF NOT EXISTS currency varchar(3) DEFAULT 'IDR' NOT NULL;
+
+ALTER TABLE public.products ADD CONSTRAINT products_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE public.promotions ADD CONSTRAINT promotions_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE public.shipping_methods ADD CONSTRAINT shipping_methods_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+ALTER TABLE public.orders ADD CONSTRAINT orders_currency_check CHECK (currency ~ '^[A-Z]{3}$');
+
+-- An order never mixes currencies, its items and its payment are in the currency of the order
+ALTER TABLE public.orders ADD CONSTRAINT orders_reference_currency_unique UNIQUE (order_reference, currency);
+ALTER TABLE public.order_items ADD CONSTRAINT order_items_order_currency_fk FOREIGN KEY (order_reference, currency) REFERENCES public.orders (order_reference, currency);
+ALTER TABLE public.payments ADD CONSTRAINT payments_order_currency_fk FOREIGN KEY (order_reference, currency) REFERENCES public.orders (order_reference, currency);
+
+-- Price lists, the price of a product or of one of its variants in a currency other than the product currency
+CREATE SEQUENCE IF NOT EXISTS public.product_currency_price_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.product_currency_prices (
+	id int8 DEFAULT nextval('product_currency_price_id_sequence'::regclass) NOT NULL,
+	product_id int8 NOT NULL,
+	-- NULL for the product, the variants without a price of their own follow it
+	variant_id int8 NULL,
+	currency varchar(3) NOT NULL,
+	price int8 NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	updated_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	updated_by varchar(100) NULL,
+	CONSTRAINT product_currency_prices_pkey PRIMARY KEY (id),
+	CONSTRAINT product_currency_prices_product_fk FOREIGN KEY (product_id) REFERENCES public.products (id) ON DELETE CASCADE,
+	CONSTRAINT product_currency_prices_variant_fk FOREIGN KEY (variant_id) REFERENCES public.product_variants (id) ON DELETE CASCADE,
+	CONSTRAINT product_currency_prices_currency_check CHECK (currency ~ '^[A-Z]{3}$'),
+	CONSTRAINT product_currency_prices_price_check CHECK (price > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_currency_prices_unique ON public.product_currency_prices (product_id, COALESCE(variant_id, 0), currency);
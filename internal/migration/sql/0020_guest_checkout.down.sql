@@ -0,0 +1,10 @@
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS email_verification_attempts;
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS email_verification_expires_at;
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS email_verification_hash;
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS email_verified_at;
+
+DROP INDEX IF EXISTS public.idx_orders_guest_email;
+
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_owner_check;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS guest_token_hash;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS guest_email;
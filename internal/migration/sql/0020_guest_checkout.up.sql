@@ -0,0 +1,14 @@
+-- Guest orders have no account, the shopper is known by the email and holds the access token of the order
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS guest_email varchar(200) DEFAULT '' NOT NULL;
+-- SHA-256 of the access token, cleared once the order is claimed into an account
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS guest_token_hash varchar(64) DEFAULT '' NOT NULL;
+
+ALTER TABLE public.orders ADD CONSTRAINT orders_owner_check CHECK (account_username IS NOT NULL OR (guest_email <> '' AND guest_token_hash <> ''));
+
+CREATE INDEX IF NOT EXISTS idx_orders_guest_email ON public.orders (lower(guest_email)) WHERE account_username IS NULL;
+
+-- Email verification, guest orders are only claimed into an account whose email is verified
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS email_verified_at timestamp NULL;
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS email_verification_hash varchar(64) DEFAULT '' NOT NULL;
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS email_verification_expires_at timestamp NULL;
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS email_verification_attempts int4 DEFAULT 0 NOT NULL;
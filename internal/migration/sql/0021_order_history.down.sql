@@ -0,0 +1,7 @@
+-- The ledger is append-only, earlier MODIFICATION_RETURN movements are left unchecked
+ALTER TABLE public.inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_type_check;
+ALTER TABLE public.inventory_movements ADD CONSTRAINT inventory_movements_type_check
+	CHECK (movement_type IN ('OPENING_BALANCE', 'ORDER_RESERVATION', 'CANCELLATION_RETURN', 'MANUAL_ADJUSTMENT', 'RESTOCK', 'REFUND')) NOT VALID;
+
+DROP TABLE IF EXISTS public.order_history;
+DROP SEQUENCE IF EXISTS public.order_history_id_sequence;
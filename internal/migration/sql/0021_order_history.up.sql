@@ -0,0 +1,30 @@
+-- Changes of an order after it was submitted, one entry per modification
+CREATE SEQUENCE IF NOT EXISTS public.order_history_id_sequence
+	INCREMENT BY 1
+	MINVALUE 1
+	MAXVALUE 9223372036854775807
+	START 1
+	CACHE 1
+	NO CYCLE;
+
+CREATE TABLE IF NOT EXISTS public.order_history (
+	id int8 DEFAULT nextval('order_history_id_sequence'::regclass) NOT NULL,
+	order_reference varchar(255) NOT NULL,
+	action varchar(30) NOT NULL,
+	-- Lines added, removed or changed and the delivery change, see entity.OrderChange
+	changes jsonb DEFAULT '[]'::jsonb NOT NULL,
+	previous_total int8 NOT NULL,
+	total int8 NOT NULL,
+	created_at timestamp DEFAULT CURRENT_TIMESTAMP NOT NULL,
+	created_by varchar(100) NULL,
+	CONSTRAINT order_history_pkey PRIMARY KEY (id),
+	CONSTRAINT order_history_order_fk FOREIGN KEY (order_reference) REFERENCES public.orders (order_reference) ON DELETE CASCADE,
+	CONSTRAINT order_history_action_check CHECK (action IN ('MODIFIED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_history_order ON public.order_history (order_reference, id);
+
+-- Stock released by a modification of an order
+ALTER TABLE public.inventory_movements DROP CONSTRAINT IF EXISTS inventory_movements_type_check;
+ALTER TABLE public.inventory_movements ADD CONSTRAINT inventory_movements_type_check
+	CHECK (movement_type IN ('OPENING_BALANCE', 'ORDER_RESERVATION', 'CANCELLATION_RETURN', 'MODIFICATION_RETURN', 'MANUAL_ADJUSTMENT', 'RESTOCK', 'REFUND'));
@@ -0,0 +1,2 @@
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS email_verification_window_started_at;
+ALTER TABLE public.accounts DROP COLUMN IF EXISTS email_verification_requests;
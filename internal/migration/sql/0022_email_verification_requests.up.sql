@@ -0,0 +1,3 @@
+-- Verification codes requested in the current window, an account requests a limited number of codes per window
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS email_verification_requests int4 DEFAULT 0 NOT NULL;
+ALTER TABLE public.accounts ADD COLUMN IF NOT EXISTS email_verification_window_started_at timestamp NULL;
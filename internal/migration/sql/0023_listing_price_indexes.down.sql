@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS public.idx_product_currency_prices_listing;
+DROP INDEX IF EXISTS public.idx_products_sale_price;
@@ -0,0 +1,3 @@
+-- Listing price filters select the running sales and the price list entries by range, next to idx_products_price
+CREATE INDEX IF NOT EXISTS idx_products_sale_price ON public.products (sale_price, id) WHERE sale_price IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_product_currency_prices_listing ON public.product_currency_prices (currency, price, product_id) WHERE variant_id IS NULL;
@@ -3,11 +3,24 @@ package model
 import "time"
 
 type OrderWithPaymentAndItemDTO struct {
-	OrderReference  string         `json:"orderReference"`
-	OrderDate       time.Time      `json:"orderDate"`
-	DeliveryAddress string         `json:"deliveryAddress"`
-	Status          string         `json:"status"`
-	Total           int64          `json:"total"`
-	Payment         PaymentDTO     `json:"payment"`
-	OrderItems      []OrderItemDTO `json:"orderItems"`
+	OrderReference  string             `json:"orderReference"`
+	OrderDate       time.Time          `json:"orderDate"`
+	DeliveryAddress string             `json:"deliveryAddress"`
+	Delivery        *PostalAddressDTO  `json:"delivery,omitempty"` // absent for free text addresses
+	Status          string             `json:"status"`
+	Currency        string             `json:"currency"` // of every amount of the order
+	Subtotal        int64              `json:"subtotal"`
+	DiscountTotal   int64              `json:"discountTotal"`
+	TaxRegion       string             `json:"taxRegion,omitempty"`
+	ShippingMethod  string             `json:"shippingMethod,omitempty"`
+	ShippingWeight  int64              `json:"shippingWeight"`
+	ShippingFee     int64              `json:"shippingFee"`
+	TaxTotal        int64              `json:"taxTotal"`
+	TaxIncluded     int64              `json:"taxIncluded"` // part of taxTotal included in the prices
+	Total           int64              `json:"total"`
+	Payment         PaymentDTO         `json:"payment"`
+	OrderItems      []OrderItemDTO     `json:"orderItems"`
+	Discounts       []OrderDiscountDTO `json:"discounts"`
+	Shipments       []ShipmentDTO      `json:"shipments"`
+	History         []OrderHistoryDTO  `json:"history"`
 }
@@ -9,14 +9,262 @@ type ProductDTO struct {
 	Description string `json:"description"`
 	Stock       int64  `json:"stock"`
 	Price       int64  `json:"price"`
+	Currency    string `json:"currency"`
 	ImageUrl    string `json:"imageUrl"`
 	IsActive    bool   `json:"isActive"`
+
+	// Price is the price charged now, OriginalPrice the struck-through list price while on sale
+	OriginalPrice *int64     `json:"originalPrice,omitempty"`
+	SaleEndsAt    *time.Time `json:"saleEndsAt,omitempty"`
+
+	// Parcel size of one unit, weight in grams and dimensions in millimetres
+	Weight int64 `json:"weight"`
+	Length int64 `json:"length"`
+	Width  int64 `json:"width"`
+	Height int64 `json:"height"`
+
+	// Published reviews, RatingAverage is 0 without reviews
+	RatingAverage float64 `json:"ratingAverage"`
+	ReviewCount   int64   `json:"reviewCount"`
+
+	// Gallery ordered by position, ImageUrl is the primary image
+	Images []ProductImageDTO `json:"images"`
+
+	// Only filled by the product detail, for products sold by variant
+	Options  []ProductOptionDTO  `json:"options,omitempty"`
+	Variants []ProductVariantDTO `json:"variants,omitempty"`
+}
+
+type ProductImageDTO struct {
+	ID        int64                 `json:"id"`
+	URL       string                `json:"url"`
+	AltText   string                `json:"altText"`
+	Position  int                   `json:"position"`
+	IsPrimary bool                  `json:"isPrimary"`
+	Width     int                   `json:"width"`
+	Height    int                   `json:"height"`
+	Sizes     []ProductImageSizeDTO `json:"sizes"`
+}
+
+type ProductImageSizeDTO struct {
+	Size   string `json:"size"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+type InventoryMovementDTO struct {
+	ID         int64     `json:"id"`
+	VariantID  *int64    `json:"variantId,omitempty"`
+	Type       string    `json:"type"`
+	Quantity   int64     `json:"quantity"`
+	StockAfter int64     `json:"stockAfter"`
+	Reference  string    `json:"reference,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+	CreatedBy  string    `json:"createdBy"`
+}
+
+type StockSubscriptionDTO struct {
+	ID          int64     `json:"id"`
+	ProductID   int64     `json:"productId"`
+	ProductName string    `json:"productName"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// WishlistItemDTO shows a saved item with its live price and availability
+type WishlistItemDTO struct {
+	ID          int64     `json:"id"`
+	ProductID   int64     `json:"productId"`
+	VariantID   *int64    `json:"variantId,omitempty"`
+	ProductName string    `json:"productName"`
+	SKU         string    `json:"sku,omitempty"`
+	ImageUrl    string    `json:"imageUrl"`
+	Price       int64     `json:"price"`
+	Currency    string    `json:"currency"`
+	AddedPrice  int64     `json:"addedPrice"`
+	Stock       int64     `json:"stock"`
+	IsAvailable bool      `json:"isAvailable"`
+	AddedAt     time.Time `json:"addedAt"`
+}
+
+type PromotionDTO struct {
+	ID              int64      `json:"id"`
+	Code            string     `json:"code"`
+	Description     string     `json:"description"`
+	Type            string     `json:"type"`
+	Value           int64      `json:"value"`
+	Currency        string     `json:"currency"`
+	BuyQuantity     int64      `json:"buyQuantity,omitempty"`
+	GetQuantity     int64      `json:"getQuantity,omitempty"`
+	MinOrderAmount  int64      `json:"minOrderAmount"`
+	UsageLimit      *int64     `json:"usageLimit"`
+	PerAccountLimit *int64     `json:"perAccountLimit"`
+	ProductID       *int64     `json:"productId,omitempty"`
+	CategoryID      *int64     `json:"categoryId,omitempty"`
+	StartsAt        *time.Time `json:"startsAt"`
+	EndsAt          *time.Time `json:"endsAt"`
+	IsActive        bool       `json:"isActive"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+// TaxRuleDTO rate is in basis points, 1100 is 11%
+type TaxRuleDTO struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Region     *string   `json:"region,omitempty"`
+	CategoryID *int64    `json:"categoryId,omitempty"`
+	Rate       int64     `json:"rate"`
+	Mode       string    `json:"mode"`
+	IsActive   bool      `json:"isActive"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// OrderItemTaxDTO is a tax line of an order item, taxableAmount is the item net of every tax
+type OrderItemTaxDTO struct {
+	Name          string `json:"name"`
+	Rate          int64  `json:"rate"`
+	Mode          string `json:"mode"`
+	TaxableAmount int64  `json:"taxableAmount"`
+	Amount        int64  `json:"amount"`
+}
+
+// ShippingMethodDTO weights are in grams, tiers only belong to WEIGHT_TIERED methods
+type ShippingMethodDTO struct {
+	ID        int64             `json:"id"`
+	Code      string            `json:"code"`
+	Name      string            `json:"name"`
+	Type      string            `json:"type"`
+	Currency  string            `json:"currency"`
+	FlatFee   int64             `json:"flatFee"`
+	Tiers     []ShippingTierDTO `json:"tiers,omitempty"`
+	FreeAbove *int64            `json:"freeAbove"`
+	Region    *string           `json:"region,omitempty"`
+	MaxWeight *int64            `json:"maxWeight"`
+	IsActive  bool              `json:"isActive"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+type ShippingTierDTO struct {
+	UpToWeight int64 `json:"upToWeight"`
+	Fee        int64 `json:"fee"`
+}
+
+// ShippingQuoteDTO is a method able to carry the cart, fee is 0 when the cart ships for free
+type ShippingQuoteDTO struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Fee    int64  `json:"fee"`
+	IsFree bool   `json:"isFree"`
+}
+
+// PostalAddressDTO region uses the tax and shipping region codes, country is ISO 3166-1 alpha-2
+type PostalAddressDTO struct {
+	RecipientName string `json:"recipientName"`
+	Phone         string `json:"phone"`
+	Line1         string `json:"line1"`
+	Line2         string `json:"line2"`
+	City          string `json:"city"`
+	Region        string `json:"region"`
+	PostalCode    string `json:"postalCode"`
+	Country       string `json:"country"`
+}
+
+type AddressDTO struct {
+	ID int64 `json:"id"`
+	PostalAddressDTO
+	IsDefault bool      `json:"isDefault"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ShipmentDTO is a parcel of an order, deliveredAt is absent while it is in transit
+type ShipmentDTO struct {
+	ShipmentReference string            `json:"shipmentReference"`
+	Carrier           string            `json:"carrier"`
+	TrackingNumber    string            `json:"trackingNumber"`
+	Status            string            `json:"status"`
+	ShippedAt         time.Time         `json:"shippedAt"`
+	DeliveredAt       *time.Time        `json:"deliveredAt,omitempty"`
+	Items             []ShipmentItemDTO `json:"items"`
+}
+
+type ShipmentItemDTO struct {
+	OrderItemReference string `json:"orderItemReference"`
+	ProductName        string `json:"productName"`
+	Quantity           int64  `json:"quantity"`
+}
+
+// OrderDiscountDTO is a discount line of an order
+type OrderDiscountDTO struct {
+	Code        string `json:"code"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Amount      int64  `json:"amount"`
+}
+
+type ReviewDTO struct {
+	ID             int64      `json:"id"`
+	ProductID      int64      `json:"productId"`
+	Author         string     `json:"author"`
+	Rating         int        `json:"rating"`
+	Body           string     `json:"body"`
+	Status         string     `json:"status"`
+	ModerationNote string     `json:"moderationNote,omitempty"`
+	ModeratedAt    *time.Time `json:"moderatedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// ProductRatingDTO is the summary of the published reviews of a product
+type ProductRatingDTO struct {
+	ProductID     int64   `json:"productId"`
+	RatingAverage float64 `json:"ratingAverage"`
+	ReviewCount   int64   `json:"reviewCount"`
+}
+
+type ProductOptionDTO struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type OptionTypeDTO struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+type VariantOptionDTO struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ProductVariantDTO struct {
+	ID            int64              `json:"id"`
+	SKU           string             `json:"sku"`
+	Price         int64              `json:"price"`
+	OriginalPrice *int64             `json:"originalPrice,omitempty"`
+	Stock         int64              `json:"stock"`
+	IsActive      bool               `json:"isActive"`
+	Options       []VariantOptionDTO `json:"options"`
+}
+
+type ProductHighlightDTO struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ProductSearchResultDTO struct {
+	Product   ProductDTO          `json:"product"`
+	Rank      float64             `json:"rank"`
+	Highlight ProductHighlightDTO `json:"highlight"`
 }
 
 type OrderDTO struct {
 	OrderReference string    `json:"orderReference"`
 	OrderDate      time.Time `json:"orderDate"`
 	Status         string    `json:"status"`
+	Currency       string    `json:"currency"`
 	Total          int64     `json:"total"`
 }
 
@@ -24,6 +272,7 @@ type PaymentDTO struct {
 	PaymentReference string `json:"paymentReference"`
 	Status           string `json:"status"`
 	Total            int64  `json:"total"`
+	Currency         string `json:"currency"`
 	CardHolderName   string `json:"cardHolderName"`
 	CardNumber       string `json:"cardNumber"`
 }
@@ -34,12 +283,22 @@ type OrderItemProductDTO struct {
 	ImageUrl string `json:"imageUrl"`
 }
 
+type OrderItemVariantDTO struct {
+	ID      int64              `json:"id"`
+	SKU     string             `json:"sku"`
+	Options []VariantOptionDTO `json:"options"`
+}
+
 type OrderItemDTO struct {
-	OrderItemReference string              `json:"orderItemReference"`
-	Quantity           int64               `json:"quantity"`
-	Price              int64               `json:"price"`
-	Total              int64               `json:"total"`
-	Product            OrderItemProductDTO `json:"product"`
+	OrderItemReference string               `json:"orderItemReference"`
+	Quantity           int64                `json:"quantity"`
+	Price              int64                `json:"price"`
+	Total              int64                `json:"total"`
+	DiscountAmount     int64                `json:"discountAmount"`
+	Taxes              []OrderItemTaxDTO    `json:"taxes"`
+	ShippedQuantity    int64                `json:"shippedQuantity"`
+	Product            OrderItemProductDTO  `json:"product"`
+	Variant            *OrderItemVariantDTO `json:"variant,omitempty"`
 }
 
 type AccountDTO struct {
@@ -47,8 +306,10 @@ type AccountDTO struct {
 	Username          string `json:"username"`
 	DisplayName       string `json:"displayName"`
 	Email             string `json:"email"`
+	EmailVerified     bool   `json:"emailVerified"`
 	RegisteredAddress string `json:"registeredAddress"`
 	IsActive          bool   `json:"isActive"`
+	Role              string `json:"role"`
 }
 
 type TokenDTO struct {
@@ -56,3 +317,79 @@ type TokenDTO struct {
 	Expiry    int64  `json:"expiry"`
 	ExpiredAt string `json:"expiredAt"`
 }
+
+// ProductPricingDTO is the pricing of a product as managed by the staff
+type ProductPricingDTO struct {
+	ProductID      int64                     `json:"productId"`
+	Currency       string                    `json:"currency"`
+	Price          int64                     `json:"price"`
+	SalePrice      *int64                    `json:"salePrice"`
+	SaleStartsAt   *time.Time                `json:"saleStartsAt"`
+	SaleEndsAt     *time.Time                `json:"saleEndsAt"`
+	EffectivePrice int64                     `json:"effectivePrice"`
+	IsOnSale       bool                      `json:"isOnSale"`
+	PendingChanges []ProductPriceChangeDTO   `json:"pendingChanges"`
+	CurrencyPrices []ProductCurrencyPriceDTO `json:"currencyPrices"`
+}
+
+// ProductCurrencyPriceDTO is a price list entry, of the product when variantId is absent
+type ProductCurrencyPriceDTO struct {
+	Currency  string `json:"currency"`
+	VariantID *int64 `json:"variantId,omitempty"`
+	Price     int64  `json:"price"`
+}
+
+type ProductPriceChangeDTO struct {
+	ID          int64      `json:"id"`
+	Price       int64      `json:"price"`
+	EffectiveAt time.Time  `json:"effectiveAt"`
+	AppliedAt   *time.Time `json:"appliedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CreatedBy   string     `json:"createdBy"`
+}
+
+type ProductPriceHistoryDTO struct {
+	ID            int64      `json:"id"`
+	PriceType     string     `json:"priceType"`
+	OldPrice      *int64     `json:"oldPrice"`
+	NewPrice      *int64     `json:"newPrice"`
+	SaleStartsAt  *time.Time `json:"saleStartsAt"`
+	SaleEndsAt    *time.Time `json:"saleEndsAt"`
+	PriceChangeID *int64     `json:"priceChangeId"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	CreatedBy     string     `json:"createdBy"`
+}
+
+// ReorderItemDTO is a line of a previous order at the current price, Reason tells why it was dropped or reduced
+type ReorderItemDTO struct {
+	ProductID        int64  `json:"productId"`
+	VariantID        *int64 `json:"variantId,omitempty"`
+	ProductName      string `json:"productName"`
+	SKU              string `json:"sku,omitempty"`
+	ImageUrl         string `json:"imageUrl"`
+	Quantity         int64  `json:"quantity"`
+	PreviousQuantity int64  `json:"previousQuantity"`
+	Price            int64  `json:"price"`
+	PreviousPrice    int64  `json:"previousPrice"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+type OrderHistoryDTO struct {
+	Action        string           `json:"action"`
+	Changes       []OrderChangeDTO `json:"changes"`
+	PreviousTotal int64            `json:"previousTotal"`
+	Total         int64            `json:"total"`
+	CreatedAt     time.Time        `json:"createdAt"`
+	CreatedBy     string           `json:"createdBy"`
+}
+
+type OrderChangeDTO struct {
+	Type               string `json:"type"`
+	OrderItemReference string `json:"orderItemReference,omitempty"`
+	ProductID          int64  `json:"productId,omitempty"`
+	VariantID          *int64 `json:"variantId,omitempty"`
+	PreviousQuantity   int64  `json:"previousQuantity"`
+	Quantity           int64  `json:"quantity"`
+	PreviousDelivery   string `json:"previousDelivery,omitempty"`
+	Delivery           string `json:"delivery,omitempty"`
+}
@@ -1,10 +1,23 @@
 package model
 
+// ProductFilter prices are in Currency, the products not sold in it are left out
 type ProductFilter struct {
-	Name     string
-	IsActive bool
+	Name        string
+	IsActive    bool
+	Currency    string
+	MinPrice    *int64
+	MaxPrice    *int64
+	CategoryIDs []int64
+	InStockOnly bool
 }
 
 type OrderFilter struct {
 	OrderReference string
 }
+
+// ReviewFilter empty fields do not filter
+type ReviewFilter struct {
+	ProductID       int64
+	AccountUsername string
+	Status          string
+}
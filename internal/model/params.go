@@ -1,9 +1,36 @@
 package model
 
+import "github.com/jhasudungan/terraloom-core-api/internal/constant"
+
+/*
+*
+
+	Offset mode reads PerPage rows starting at (Page - 1) * PerPage.
+
+	Keyset mode (IsKeyset) ignores Page and reads the rows following Cursor in the
+	sort order, or preceding it when Cursor.Backward is set (nil Cursor : first page).
+	Repositories return up to PerPage + 1 rows in the direction they were read,
+	the extra row only tells that another page exists.
+
+	SkipCount skips the total count query in both modes.
+
+*
+*/
 type PaginationParams struct {
 	IsPaginate bool
 	Page       int
 	PerPage    int
+	IsKeyset   bool
+	Cursor     *Cursor
+	SkipCount  bool
+}
+
+// Cursor is a keyset position : the sort key and id of the row at the edge of a page
+type Cursor struct {
+	Sort     string `json:"s"`
+	Key      string `json:"k"`
+	ID       string `json:"i"`
+	Backward bool   `json:"b,omitempty"`
 }
 
 func (p *PaginationParams) Validate() {
@@ -22,3 +49,17 @@ func (p *PaginationParams) Validate() {
 func (p *PaginationParams) GetOffset() int {
 	return (p.Page - 1) * p.PerPage
 }
+
+// IsBackward tells a keyset page is read towards the start of the sort order
+func (p *PaginationParams) IsBackward() bool {
+	return p.IsKeyset && p.Cursor != nil && p.Cursor.Backward
+}
+
+type SortParams struct {
+	By        string
+	Direction string
+}
+
+func (s *SortParams) IsDescending() bool {
+	return s.Direction == constant.SortDirectionDesc
+}
@@ -1,19 +1,418 @@
 package model
 
+import "time"
+
 type GetProductsRequest struct {
-	Name       string
-	IsActive   bool
+	Name          string
+	IsActive      bool
+	Page          int
+	PerPage       int
+	IsPaginate    bool
+	MinPrice      *int64
+	MaxPrice      *int64
+	Currency      string
+	CategoryIDs   []int64
+	InStockOnly   bool
+	Sort          string
+	SortDirection string
+	Mode          string
+	Cursor        string
+	SkipCount     bool
+}
+
+type SearchProductsRequest struct {
+	Query       string
+	IsActive    bool
+	MinPrice    *int64
+	MaxPrice    *int64
+	Currency    string
+	CategoryIDs []int64
+	InStockOnly bool
+	Page        int
+	PerPage     int
+	IsPaginate  bool
+}
+
+type GetProductDetailRequest struct {
+	ID int64
+}
+
+// CreateOptionTypeRequest adds an option type (Size, Colour, ...) to a product not sold by variant yet
+type CreateOptionTypeRequest struct {
+	ProductID int64
+	Name      string `json:"name"`
+	Position  int    `json:"position"`
+}
+
+// UpdateOptionTypeRequest nil fields are left unchanged
+type UpdateOptionTypeRequest struct {
+	ProductID    int64
+	OptionTypeID int64
+	Name         *string `json:"name"`
+	Position     *int    `json:"position"`
+}
+
+type VariantOptionRequest struct {
+	OptionTypeID int64  `json:"optionTypeId"`
+	Value        string `json:"value"`
+}
+
+// CreateVariantRequest picks one value per option type of the product, the stock is the opening balance of the variant
+type CreateVariantRequest struct {
+	ProductID int64
+	SKU       string                 `json:"sku"`
+	Price     *int64                 `json:"price"`    // overrides the product price when set
+	Stock     int64                  `json:"stock"`    // defaults to 0
+	IsActive  *bool                  `json:"isActive"` // defaults to true
+	Options   []VariantOptionRequest `json:"options"`
+	Username  string
+}
+
+// UpdateVariantRequest nil fields are left unchanged, a price of 0 removes the override,
+// a new stock is booked as a MANUAL_ADJUSTMENT explained by the note
+type UpdateVariantRequest struct {
+	ProductID int64
+	VariantID int64
+	SKU       *string `json:"sku"`
+	Price     *int64  `json:"price"`
+	IsActive  *bool   `json:"isActive"`
+	Stock     *int64  `json:"stock"`
+	Note      string  `json:"note"`
+	Username  string
+}
+
+type UploadProductImageRequest struct {
+	ProductID int64
+	Content   []byte
+	AltText   string
+	Position  *int
+	IsPrimary bool
+	Username  string
+}
+
+type UpdateProductImageRequest struct {
+	ProductID int64
+	ImageID   int64
+	AltText   *string `json:"altText"`
+	Position  *int    `json:"position"`
+	IsPrimary *bool   `json:"isPrimary"`
+	Username  string
+}
+
+type DeleteProductImageRequest struct {
+	ProductID int64
+	ImageID   int64
+	Username  string
+}
+
+type GetInventoryMovementsRequest struct {
+	ProductID  int64
 	Page       int
 	PerPage    int
 	IsPaginate bool
 }
 
-type GetProductDetailRequest struct {
-	ID int64
+type AdjustStockRequest struct {
+	ProductID    int64
+	VariantID    *int64 `json:"variantId"`
+	MovementType string `json:"type"`
+	Quantity     int64  `json:"quantity"`
+	Note         string `json:"note"`
+	Username     string
+}
+
+type UpdateLowStockThresholdRequest struct {
+	ProductID int64
+	Threshold *int64 `json:"threshold"`
+	Username  string
+}
+
+// SchedulePriceChangeRequest changes the price at EffectiveAt, right away when it is nil or past
+type SchedulePriceChangeRequest struct {
+	ProductID   int64
+	Price       int64      `json:"price"`
+	EffectiveAt *time.Time `json:"effectiveAt"`
+	Username    string
+}
+
+type CancelPriceChangeRequest struct {
+	ProductID int64
+	ChangeID  int64
+	Username  string
+}
+
+// SetSaleRequest nil bounds leave the sale open on that side
+type SetSaleRequest struct {
+	ProductID int64
+	SalePrice int64      `json:"salePrice"`
+	StartsAt  *time.Time `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt"`
+	Username  string
+}
+
+// SetCurrencyPriceRequest a 0 price removes the entry, a 0 variantId prices the product
+type SetCurrencyPriceRequest struct {
+	ProductID int64
+	Currency  string `json:"currency"`
+	VariantID int64  `json:"variantId"`
+	Price     int64  `json:"price"`
+	Username  string
+}
+
+type EndSaleRequest struct {
+	ProductID int64
+	Username  string
+}
+
+type GetPriceHistoryRequest struct {
+	ProductID  int64
+	Page       int
+	PerPage    int
+	IsPaginate bool
+}
+
+type SubscribeStockRequest struct {
+	ProductID int64 `json:"productId"`
+	Username  string
+}
+
+type UnsubscribeStockRequest struct {
+	ProductID int64
+	Username  string
+}
+
+type AddWishlistItemRequest struct {
+	ProductID int64  `json:"productId"`
+	VariantID *int64 `json:"variantId"`
+	Username  string
+}
+
+type RemoveWishlistItemRequest struct {
+	ItemID   int64
+	Username string
+}
+
+type WishlistOrderLine struct {
+	ItemID   int64 `json:"itemId"`
+	Quantity int64 `json:"quantity"` // defaults to 1
+}
+
+// OrderWishlistItemsRequest orders saved items at their current price
+type OrderWishlistItemsRequest struct {
+	AddressID       int64               `json:"addressId"`
+	DeliveryAddress string              `json:"deliveryAddress"`
+	Region          string              `json:"region"`
+	ShippingMethod  string              `json:"shippingMethod"`
+	Items           []WishlistOrderLine `json:"items"`
+	Username        string
+}
+
+type CreatePromotionRequest struct {
+	Code            string     `json:"code"`
+	Description     string     `json:"description"`
+	Type            string     `json:"type"`
+	Value           int64      `json:"value"`
+	Currency        string     `json:"currency"` // of the amounts, defaults to the default currency
+	BuyQuantity     int64      `json:"buyQuantity"`
+	GetQuantity     int64      `json:"getQuantity"`
+	MinOrderAmount  int64      `json:"minOrderAmount"`
+	UsageLimit      *int64     `json:"usageLimit"`
+	PerAccountLimit *int64     `json:"perAccountLimit"`
+	ProductID       *int64     `json:"productId"`
+	CategoryID      *int64     `json:"categoryId"`
+	StartsAt        *time.Time `json:"startsAt"`
+	EndsAt          *time.Time `json:"endsAt"`
+	IsActive        *bool      `json:"isActive"` // defaults to true
+	Username        string
+}
+
+// UpdatePromotionRequest nil fields are left unchanged, a limit of 0 removes the limit
+type UpdatePromotionRequest struct {
+	PromotionID     int64
+	Description     *string    `json:"description"`
+	UsageLimit      *int64     `json:"usageLimit"`
+	PerAccountLimit *int64     `json:"perAccountLimit"`
+	StartsAt        *time.Time `json:"startsAt"`
+	EndsAt          *time.Time `json:"endsAt"`
+	IsActive        *bool      `json:"isActive"`
+	Username        string
+}
+
+type GetPromotionsRequest struct {
+	Page       int
+	PerPage    int
+	IsPaginate bool
+}
+
+// CreateTaxRuleRequest rate is in basis points, a nil region or category covers every one
+type CreateTaxRuleRequest struct {
+	Name       string  `json:"name"`
+	Region     *string `json:"region"`
+	CategoryID *int64  `json:"categoryId"`
+	Rate       int64   `json:"rate"`
+	Mode       string  `json:"mode"`
+	IsActive   *bool   `json:"isActive"` // defaults to true
+	Username   string
+}
+
+// UpdateTaxRuleRequest nil fields are left unchanged
+type UpdateTaxRuleRequest struct {
+	TaxRuleID int64
+	Name      *string `json:"name"`
+	Rate      *int64  `json:"rate"`
+	Mode      *string `json:"mode"`
+	IsActive  *bool   `json:"isActive"`
+	Username  string
+}
+
+type GetTaxRulesRequest struct {
+	Page       int
+	PerPage    int
+	IsPaginate bool
+}
+
+// CreateShippingMethodRequest weights are in grams, tiers are required by WEIGHT_TIERED methods
+type CreateShippingMethodRequest struct {
+	Code      string            `json:"code"`
+	Name      string            `json:"name"`
+	Type      string            `json:"type"`
+	Currency  string            `json:"currency"` // of the fees, defaults to the default currency
+	FlatFee   int64             `json:"flatFee"`
+	Tiers     []ShippingTierDTO `json:"tiers"`
+	FreeAbove *int64            `json:"freeAbove"`
+	Region    *string           `json:"region"`
+	MaxWeight *int64            `json:"maxWeight"`
+	IsActive  *bool             `json:"isActive"` // defaults to true
+	Username  string
+}
+
+// UpdateShippingMethodRequest nil fields are left unchanged, a freeAbove or maxWeight of 0 removes it
+type UpdateShippingMethodRequest struct {
+	ShippingMethodID int64
+	Name             *string           `json:"name"`
+	FlatFee          *int64            `json:"flatFee"`
+	Tiers            []ShippingTierDTO `json:"tiers"`
+	FreeAbove        *int64            `json:"freeAbove"`
+	MaxWeight        *int64            `json:"maxWeight"`
+	IsActive         *bool             `json:"isActive"`
+	Username         string
+}
+
+type GetShippingMethodsRequest struct {
+	Page       int
+	PerPage    int
+	IsPaginate bool
+}
+
+// QuoteShippingRequest prices the shipping of a cart to a region
+type QuoteShippingRequest struct {
+	Region   string              `json:"region"`
+	Currency string              `json:"currency"`
+	Items    []ShippingQuoteItem `json:"items"`
+}
+
+type ShippingQuoteItem struct {
+	ProductID int64 `json:"productId"`
+	VariantID int64 `json:"variantId"`
+	Quantity  int64 `json:"quantity"`
+}
+
+// UpdateProductDimensionsRequest weight in grams, dimensions in millimetres
+type UpdateProductDimensionsRequest struct {
+	ProductID int64
+	Weight    int64 `json:"weight"`
+	Length    int64 `json:"length"`
+	Width     int64 `json:"width"`
+	Height    int64 `json:"height"`
+	Username  string
+}
+
+// SaveAddressRequest creates or replaces an address, isDefault makes it the default address
+type SaveAddressRequest struct {
+	AddressID int64
+	PostalAddressDTO
+	IsDefault bool `json:"isDefault"`
+	Username  string
+}
+
+type GetAddressesRequest struct {
+	Username string
+}
+
+type DeleteAddressRequest struct {
+	AddressID int64
+	Username  string
+}
+
+// CreateShipmentRequest ships the items of a paid order, every item left to ship when items is empty
+type CreateShipmentRequest struct {
+	OrderReference string
+	Carrier        string                `json:"carrier"`
+	TrackingNumber string                `json:"trackingNumber"`
+	ShippedAt      *time.Time            `json:"shippedAt"` // defaults to now
+	Items          []ShipmentItemRequest `json:"items"`
+	Username       string
+}
+
+type ShipmentItemRequest struct {
+	OrderItemReference string `json:"orderItemReference"`
+	Quantity           int64  `json:"quantity"`
+}
+
+// UpdateShipmentRequest nil fields are left unchanged, a deliveredAt marks the shipment delivered
+type UpdateShipmentRequest struct {
+	ShipmentReference string
+	Carrier           *string    `json:"carrier"`
+	TrackingNumber    *string    `json:"trackingNumber"`
+	ShippedAt         *time.Time `json:"shippedAt"`
+	DeliveredAt       *time.Time `json:"deliveredAt"`
+	Username          string
+}
+
+type GetOrderTrackingRequest struct {
+	OrderReference  string
+	AccountUsername string
+}
+
+// GetReviewsRequest lists reviews of a product, of an account or, for staff, of a status
+type GetReviewsRequest struct {
+	ProductID  int64
+	Username   string
+	Status     string
+	Page       int
+	PerPage    int
+	IsPaginate bool
+}
+
+type CreateReviewRequest struct {
+	ProductID int64  `json:"productId"`
+	Rating    int    `json:"rating"`
+	Body      string `json:"body"`
+	Username  string
+}
+
+type UpdateReviewRequest struct {
+	ReviewID int64
+	Rating   int    `json:"rating"`
+	Body     string `json:"body"`
+	Username string
+}
+
+type DeleteReviewRequest struct {
+	ReviewID int64
+	Username string
+}
+
+type ModerateReviewRequest struct {
+	ReviewID int64
+	Status   string `json:"status"`
+	Note     string `json:"note"`
+	Username string
 }
 
 type OrderItemRequest struct {
 	ProductId       int64  `json:"productId"`
+	VariantId       int64  `json:"variantId"`
 	PriceUsed       int64  `json:"priceUsed"`
 	Quantity        int64  `json:"quantity"`
 	ProductName     string `json:"productName"`
@@ -22,8 +421,75 @@ type OrderItemRequest struct {
 
 type SubmitOrderRequest struct {
 	AccountUsername string
-	DeliveryAddress string             `json:"deliveryAddress"`
+	AddressID       int64              `json:"addressId"`       // address book entry, replaces deliveryAddress and region
+	DeliveryAddress string             `json:"deliveryAddress"` // free text, when no addressId is given
 	OrderItems      []OrderItemRequest `json:"orderItems"`
+	CouponCode      string             `json:"couponCode"`
+	Currency        string             `json:"currency"`       // of the prices, the default currency when empty
+	Region          string             `json:"region"`         // tax and shipping region
+	ShippingMethod  string             `json:"shippingMethod"` // code, required once shipping methods are configured
+	Guest           *GuestOrder        `json:"-"`              // set by the guest checkout in place of AccountUsername
+}
+
+// GuestOrder is the shopper of an order placed without an account
+type GuestOrder struct {
+	Email     string
+	Address   PostalAddressDTO
+	TokenHash string
+}
+
+// SubmitGuestOrderRequest is an order placed without an account, the region is the region of the address
+type SubmitGuestOrderRequest struct {
+	Email          string             `json:"email"`
+	Address        PostalAddressDTO   `json:"address"`
+	OrderItems     []OrderItemRequest `json:"orderItems"`
+	CouponCode     string             `json:"couponCode"`
+	Currency       string             `json:"currency"`
+	ShippingMethod string             `json:"shippingMethod"`
+}
+
+// GuestOrderRequest reaches a guest order with the access token given at checkout
+type GuestOrderRequest struct {
+	OrderReference string
+	AccessToken    string
+}
+
+type PayGuestOrderRequest struct {
+	OrderReference string
+	AccessToken    string
+	CardHolderName string `json:"cardHolderName"`
+	CardNumber     string `json:"cardNumber"`
+	Status         string `json:"status"`
+}
+
+type EmailVerificationRequest struct {
+	Username string
+}
+
+type VerifyEmailRequest struct {
+	Code     string `json:"code"`
+	Username string
+}
+
+type ClaimGuestOrdersRequest struct {
+	Username string
+}
+
+// ModifyOrderRequest edits an order waiting for its payment, lines left out of items stay as they are
+type ModifyOrderRequest struct {
+	OrderReference  string
+	Username        string
+	Items           []ModifyOrderItemRequest `json:"items"`           // quantity changes, a quantity of 0 removes the line
+	AddItems        []OrderItemRequest       `json:"addItems"`        // new lines at the current price
+	AddressID       int64                    `json:"addressId"`       // new delivery, address book entry
+	DeliveryAddress string                   `json:"deliveryAddress"` // new delivery, free text
+	Region          string                   `json:"region"`
+}
+
+type ModifyOrderItemRequest struct {
+	OrderItemReference string `json:"orderItemReference"`
+	Quantity           int64  `json:"quantity"`
+	PriceUsed          int64  `json:"priceUsed"` // current unit price, required when the quantity grows
 }
 
 type CancelOrderRequest struct {
@@ -32,14 +498,28 @@ type CancelOrderRequest struct {
 }
 
 type SubmitPaymentRequest struct {
-	OrderReference string `json:"orderReference"`
-	CardHolderName string `json:"cardHolderName"`
-	CardNumber     string `json:"cardNumber"`
-	Status         string `json:"status"`
+	OrderReference  string `json:"orderReference"`
+	CardHolderName  string `json:"cardHolderName"`
+	CardNumber      string `json:"cardNumber"`
+	Status          string `json:"status"`
+	AccountUsername string
+}
+
+// ReorderRequest without submit returns the cart, the delivery and the shipping method default to the previous order
+type ReorderRequest struct {
+	OrderReference  string
+	Username        string
+	Submit          bool   `json:"submit"`
+	AddressID       int64  `json:"addressId"`
+	DeliveryAddress string `json:"deliveryAddress"`
+	Region          string `json:"region"`
+	ShippingMethod  string `json:"shippingMethod"`
+	CouponCode      string `json:"couponCode"`
 }
 
 type GetOrderDetailRequest struct {
-	OrderReference string `json:"orderReference"`
+	OrderReference  string `json:"orderReference"`
+	AccountUsername string
 }
 
 type RegisterRequest struct {
@@ -78,4 +558,7 @@ type GetAccountOrdersRequest struct {
 	Page           int
 	PerPage        int
 	IsPaginate     bool
+	Mode           string
+	Cursor         string
+	SkipCount      bool
 }
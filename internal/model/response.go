@@ -15,10 +15,13 @@ type ErrorResponse struct {
 }
 
 type MetadataDTO struct {
-	Page      int   `json:"page"`
-	PerPage   int   `json:"perPage"`
-	TotalData int64 `json:"totalData"`
-	TotalPage int   `json:"totalPage"`
+	Page         int    `json:"page"`
+	PerPage      int    `json:"perPage"`
+	TotalData    int64  `json:"totalData"`
+	TotalPage    int    `json:"totalPage"`
+	TotalSkipped bool   `json:"totalSkipped,omitempty"`
+	NextCursor   string `json:"nextCursor,omitempty"`
+	PrevCursor   string `json:"prevCursor,omitempty"`
 }
 
 type GetProductsResponseData struct {
@@ -26,15 +29,216 @@ type GetProductsResponseData struct {
 	Metadata MetadataDTO  `json:"metadata"`
 }
 
+type SearchProductsResponseData struct {
+	Query    string                   `json:"query"`
+	Mode     string                   `json:"mode"`
+	Results  []ProductSearchResultDTO `json:"results"`
+	Metadata MetadataDTO              `json:"metadata"`
+}
+
 type GetProductDetailResponseData struct {
 	Product ProductDTO `json:"product"`
 }
 
+type OptionTypeResponseData struct {
+	ProductID  int64         `json:"productId"`
+	OptionType OptionTypeDTO `json:"optionType"`
+}
+
+// VariantResponseData stock is the product stock, the sum of the stock of its variants
+type VariantResponseData struct {
+	ProductID int64             `json:"productId"`
+	Stock     int64             `json:"stock"`
+	Variant   ProductVariantDTO `json:"variant"`
+}
+
+type ProductImageResponseData struct {
+	ProductID int64           `json:"productId"`
+	Image     ProductImageDTO `json:"image"`
+}
+
+type DeleteProductImageResponseData struct {
+	ProductID int64 `json:"productId"`
+	ImageID   int64 `json:"imageId"`
+}
+
+type GetInventoryMovementsResponseData struct {
+	ProductID         int64                  `json:"productId"`
+	Stock             int64                  `json:"stock"`
+	LowStockThreshold int64                  `json:"lowStockThreshold"`
+	Movements         []InventoryMovementDTO `json:"movements"`
+	Metadata          MetadataDTO            `json:"metadata"`
+}
+
+type AdjustStockResponseData struct {
+	ProductID int64                `json:"productId"`
+	Stock     int64                `json:"stock"`
+	Movement  InventoryMovementDTO `json:"movement"`
+}
+
+type UpdateLowStockThresholdResponseData struct {
+	ProductID         int64 `json:"productId"`
+	Stock             int64 `json:"stock"`
+	LowStockThreshold int64 `json:"lowStockThreshold"`
+}
+
+type StockSubscriptionResponseData struct {
+	Subscription StockSubscriptionDTO `json:"subscription"`
+}
+
+type GetStockSubscriptionsResponseData struct {
+	Subscriptions []StockSubscriptionDTO `json:"subscriptions"`
+}
+
+type GetWishlistResponseData struct {
+	Items []WishlistItemDTO `json:"items"`
+}
+
+type WishlistItemResponseData struct {
+	Item WishlistItemDTO `json:"item"`
+}
+
+// OrderWishlistItemsResponseData is the submitted order and the items it took off the wishlist
+type OrderWishlistItemsResponseData struct {
+	Order          SubmitOrderResponseData `json:"order"`
+	RemovedItemIDs []int64                 `json:"removedItemIds"`
+}
+
+type ProductPricingResponseData struct {
+	Pricing ProductPricingDTO `json:"pricing"`
+}
+
+type GetPriceHistoryResponseData struct {
+	History  []ProductPriceHistoryDTO `json:"history"`
+	Metadata MetadataDTO              `json:"metadata"`
+}
+
+type PromotionResponseData struct {
+	Promotion PromotionDTO `json:"promotion"`
+}
+
+type GetPromotionsResponseData struct {
+	Promotions []PromotionDTO `json:"promotions"`
+	Metadata   MetadataDTO    `json:"metadata"`
+}
+
+type TaxRuleResponseData struct {
+	TaxRule TaxRuleDTO `json:"taxRule"`
+}
+
+type GetTaxRulesResponseData struct {
+	TaxRules []TaxRuleDTO `json:"taxRules"`
+	Metadata MetadataDTO  `json:"metadata"`
+}
+
+type ShippingMethodResponseData struct {
+	ShippingMethod ShippingMethodDTO `json:"shippingMethod"`
+}
+
+type GetShippingMethodsResponseData struct {
+	ShippingMethods []ShippingMethodDTO `json:"shippingMethods"`
+	Metadata        MetadataDTO         `json:"metadata"`
+}
+
+// QuoteShippingResponseData lists the methods able to carry the cart, cheapest first
+type QuoteShippingResponseData struct {
+	Currency string             `json:"currency"`
+	Subtotal int64              `json:"subtotal"`
+	Weight   int64              `json:"weight"`
+	Methods  []ShippingQuoteDTO `json:"methods"`
+}
+
+type ProductDimensionsResponseData struct {
+	ProductID      int64 `json:"productId"`
+	Weight         int64 `json:"weight"`
+	Length         int64 `json:"length"`
+	Width          int64 `json:"width"`
+	Height         int64 `json:"height"`
+	ShippingWeight int64 `json:"shippingWeight"`
+}
+
+type AddressResponseData struct {
+	Address AddressDTO `json:"address"`
+}
+
+type GetAddressesResponseData struct {
+	Addresses []AddressDTO `json:"addresses"`
+}
+
+// ShipmentResponseData the order status follows its shipments
+type ShipmentResponseData struct {
+	OrderReference string      `json:"orderReference"`
+	OrderStatus    string      `json:"orderStatus"`
+	Shipment       ShipmentDTO `json:"shipment"`
+}
+
+type GetOrderTrackingResponseData struct {
+	OrderReference string        `json:"orderReference"`
+	OrderStatus    string        `json:"orderStatus"`
+	ShippingMethod string        `json:"shippingMethod,omitempty"`
+	Shipments      []ShipmentDTO `json:"shipments"`
+}
+
+type GetReviewsResponseData struct {
+	Reviews  []ReviewDTO `json:"reviews"`
+	Metadata MetadataDTO `json:"metadata"`
+}
+
+type ReviewResponseData struct {
+	Review  ReviewDTO        `json:"review"`
+	Product ProductRatingDTO `json:"product"`
+}
+
+type DeleteReviewResponseData struct {
+	ReviewID int64            `json:"reviewId"`
+	Product  ProductRatingDTO `json:"product"`
+}
+
 type SubmitOrderResponseData struct {
-	OrderReference string    `json:"orderReference"`
-	OrderDate      time.Time `json:"orderDate"`
-	OrderStatus    string    `json:"orderStatus"`
-	Total          int64     `json:"total"`
+	OrderReference string             `json:"orderReference"`
+	OrderDate      time.Time          `json:"orderDate"`
+	OrderStatus    string             `json:"orderStatus"`
+	Currency       string             `json:"currency"`
+	Subtotal       int64              `json:"subtotal"`
+	DiscountTotal  int64              `json:"discountTotal"`
+	ShippingMethod string             `json:"shippingMethod,omitempty"`
+	ShippingFee    int64              `json:"shippingFee"`
+	TaxTotal       int64              `json:"taxTotal"`
+	TaxIncluded    int64              `json:"taxIncluded"`
+	Total          int64              `json:"total"`
+	Discounts      []OrderDiscountDTO `json:"discounts"`
+}
+
+// ModifyOrderResponseData is the order recomputed after the modification and the changes recorded in its history
+type ModifyOrderResponseData struct {
+	SubmitOrderResponseData
+	PreviousTotal int64            `json:"previousTotal"`
+	Changes       []OrderChangeDTO `json:"changes"`
+}
+
+// SubmitGuestOrderResponseData carries the access token of a guest order, it is only shown once
+type SubmitGuestOrderResponseData struct {
+	SubmitOrderResponseData
+	Email       string `json:"email"`
+	AccessToken string `json:"accessToken"`
+}
+
+type EmailVerificationResponseData struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ReorderResponseData is the cart rebuilt from a previous order, Order is set once it is submitted
+type ReorderResponseData struct {
+	PreviousOrderReference string                   `json:"previousOrderReference"`
+	Currency               string                   `json:"currency"`
+	Items                  []ReorderItemDTO         `json:"items"`
+	Unavailable            []ReorderItemDTO         `json:"unavailable"`
+	Order                  *SubmitOrderResponseData `json:"order,omitempty"`
+}
+
+type ClaimGuestOrdersResponseData struct {
+	Orders []OrderDTO `json:"orders"`
 }
 
 type CancelOrderResponseData struct {
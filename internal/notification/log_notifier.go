@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogNotifier writes notifications to the application log, it is the default until a
+// delivery channel is configured
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (ln *LogNotifier) NotifyLowStock(ctx context.Context, alert LowStockAlert) error {
+
+	logrus.WithFields(logrus.Fields{
+		"productId": alert.ProductID,
+		"product":   alert.ProductName,
+		"stock":     alert.Stock,
+		"threshold": alert.Threshold,
+		"reference": alert.Reference,
+	}).Warn("low stock")
+
+	return nil
+}
+
+func (ln *LogNotifier) NotifyBackInStock(ctx context.Context, notice BackInStockNotice) error {
+
+	logrus.WithFields(logrus.Fields{
+		"productId": notice.ProductID,
+		"product":   notice.ProductName,
+		"stock":     notice.Stock,
+		"account":   notice.AccountUsername,
+		"email":     notice.Email,
+	}).Info("back in stock")
+
+	return nil
+}
+
+func (ln *LogNotifier) NotifyPriceDrop(ctx context.Context, notice PriceDropNotice) error {
+
+	logrus.WithFields(logrus.Fields{
+		"productId": notice.ProductID,
+		"variantId": notice.VariantID,
+		"product":   notice.ProductName,
+		"oldPrice":  notice.OldPrice,
+		"newPrice":  notice.NewPrice,
+		"account":   notice.AccountUsername,
+		"email":     notice.Email,
+	}).Info("price drop")
+
+	return nil
+}
+
+func (ln *LogNotifier) NotifyEmailVerification(ctx context.Context, notice EmailVerificationNotice) error {
+
+	logrus.WithFields(logrus.Fields{
+		"account":   notice.AccountUsername,
+		"email":     notice.Email,
+		"code":      notice.Code,
+		"expiresAt": notice.ExpiresAt,
+	}).Info("email verification")
+
+	return nil
+}
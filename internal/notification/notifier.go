@@ -0,0 +1,59 @@
+package notification
+
+import (
+	"context"
+	"time"
+)
+
+// LowStockAlert tells staff the stock of a product fell to its low stock threshold
+type LowStockAlert struct {
+	ProductID   int64
+	ProductName string
+	Stock       int64
+	Threshold   int64
+	Reference   string // order reference or empty for a manual adjustment
+}
+
+// BackInStockNotice tells a subscribed customer a product can be ordered again
+type BackInStockNotice struct {
+	ProductID       int64
+	ProductName     string
+	Stock           int64
+	AccountUsername string
+	Email           string
+}
+
+// PriceDropNotice tells a customer a product on their wishlist got cheaper
+type PriceDropNotice struct {
+	ProductID       int64
+	VariantID       *int64
+	ProductName     string
+	OldPrice        int64
+	NewPrice        int64
+	AccountUsername string
+	Email           string
+}
+
+// EmailVerificationNotice sends a customer the code verifying the email of their account
+type EmailVerificationNotice struct {
+	AccountUsername string
+	Email           string
+	Code            string
+	ExpiresAt       time.Time
+}
+
+/*
+*
+
+	Notifier delivers stock, price and account notifications (log, email, chat webhook, ...) :
+	- Notifications are sent after the stock change is committed
+	- A failed delivery is logged by the caller and never undoes the stock change
+
+*
+*/
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, alert LowStockAlert) error
+	NotifyBackInStock(ctx context.Context, notice BackInStockNotice) error
+	NotifyPriceDrop(ctx context.Context, notice PriceDropNotice) error
+	NotifyEmailVerification(ctx context.Context, notice EmailVerificationNotice) error
+}
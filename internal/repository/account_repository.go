@@ -2,6 +2,10 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
 	"github.com/jhasudungan/terraloom-core-api/internal/entity"
@@ -10,40 +14,51 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-type AccountRepository struct {
+type AccountRepository interface {
+	Create(ctx context.Context, account entity.Account) error
+	Update(ctx context.Context, account entity.Account) error
+	FindByUsername(ctx context.Context, username string) (entity.Account, error)
+	CheckByUsername(ctx context.Context, username string) (bool, error)
+	CheckByEmail(ctx context.Context, email string) (bool, error)
+	StartEmailVerification(ctx context.Context, account entity.Account, windowStart time.Time, maxRequests int64) (entity.Account, error)
+	CountEmailVerificationAttempt(ctx context.Context, username string, maxAttempts int64) (entity.Account, error)
+}
+
+type accountRepository struct {
 	db *gorm.DB
 }
 
-func NewAccountRepository(db *gorm.DB) *AccountRepository {
-	return &AccountRepository{db: db}
+func NewAccountRepository(db *gorm.DB) AccountRepository {
+	return &accountRepository{db: db}
 }
 
-func (ar *AccountRepository) Create(ctx context.Context, account entity.Account) error {
+func (ar *accountRepository) Create(ctx context.Context, account entity.Account) error {
 
 	err := ar.db.WithContext(ctx).Create(&account).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (ar *AccountRepository) Update(ctx context.Context, account entity.Account) error {
+// Update leaves the verification counters, only StartEmailVerification and CountEmailVerificationAttempt change them
+func (ar *accountRepository) Update(ctx context.Context, account entity.Account) error {
 
-	err := ar.db.WithContext(ctx).Save(&account).Error
+	err := ar.db.WithContext(ctx).Omit(emailVerificationCounters...).Save(&account).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 
 }
 
-func (ar *AccountRepository) FindByUsername(ctx context.Context, username string) (entity.Account, error) {
+func (ar *accountRepository) FindByUsername(ctx context.Context, username string) (entity.Account, error) {
 
 	query := ar.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"})
 	var account entity.Account
@@ -57,7 +72,7 @@ func (ar *AccountRepository) FindByUsername(ctx context.Context, username string
 	return account, nil
 }
 
-func (ar *AccountRepository) CheckByUsername(ctx context.Context, username string) (bool, error) {
+func (ar *accountRepository) CheckByUsername(ctx context.Context, username string) (bool, error) {
 
 	var count int64
 
@@ -75,7 +90,7 @@ func (ar *AccountRepository) CheckByUsername(ctx context.Context, username strin
 	return false, nil
 }
 
-func (ar *AccountRepository) CheckByEmail(ctx context.Context, email string) (bool, error) {
+func (ar *accountRepository) CheckByEmail(ctx context.Context, email string) (bool, error) {
 
 	var count int64
 
@@ -92,3 +107,74 @@ func (ar *AccountRepository) CheckByEmail(ctx context.Context, email string) (bo
 
 	return false, nil
 }
+
+var emailVerificationCounters = []string{"email_verification_attempts", "email_verification_requests", "email_verification_window_started_at"}
+
+/*
+*
+
+	StartEmailVerification saves the pending code of the account, hash, expiry and audit columns, in one statement :
+	- The attempts of the code start again from zero
+	- A window started at or before windowStart is over, the request starts a new window at the updated_at of the account
+	- The request is refused once maxRequests codes were requested in the current window, or the email is verified
+
+*
+*/
+func (ar *accountRepository) StartEmailVerification(ctx context.Context, account entity.Account, windowStart time.Time, maxRequests int64) (entity.Account, error) {
+
+	var accounts []entity.Account
+
+	err := ar.db.WithContext(ctx).Raw(`
+		UPDATE accounts SET
+			email_verification_hash = @hash,
+			email_verification_expires_at = @expires_at,
+			email_verification_attempts = 0,
+			email_verification_requests = CASE WHEN email_verification_window_started_at > @window_start THEN email_verification_requests + 1 ELSE 1 END,
+			email_verification_window_started_at = CASE WHEN email_verification_window_started_at > @window_start THEN email_verification_window_started_at ELSE @now END,
+			updated_at = @now,
+			updated_by = @updated_by
+		WHERE username = @username AND email_verified_at IS NULL
+			AND (email_verification_window_started_at IS NULL OR email_verification_window_started_at <= @window_start OR email_verification_requests < @max_requests)
+		RETURNING *`,
+		sql.Named("hash", account.EmailVerificationHash),
+		sql.Named("expires_at", account.EmailVerificationExpiresAt),
+		sql.Named("window_start", windowStart),
+		sql.Named("now", account.UpdatedAt),
+		sql.Named("updated_by", account.UpdatedBy),
+		sql.Named("username", account.Username),
+		sql.Named("max_requests", maxRequests)).Scan(&accounts).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return account, translateError(err)
+	}
+
+	if len(accounts) == 0 {
+		err := fmt.Errorf("too many verification codes requested for %s, try again later", account.Username)
+		logrus.Error(err)
+		return account, common.NewError(err, common.ErrConflict)
+	}
+
+	return accounts[0], nil
+}
+
+// CountEmailVerificationAttempt counts an attempt on the pending code, refused once maxAttempts were made
+func (ar *accountRepository) CountEmailVerificationAttempt(ctx context.Context, username string, maxAttempts int64) (entity.Account, error) {
+
+	var accounts []entity.Account
+
+	err := ar.db.WithContext(ctx).Raw("UPDATE accounts SET email_verification_attempts = email_verification_attempts + 1 WHERE username = ? AND email_verification_attempts < ? RETURNING *", username, maxAttempts).Scan(&accounts).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return entity.Account{}, translateError(err)
+	}
+
+	if len(accounts) == 0 {
+		err := errors.New("too many attempts, request a new code")
+		logrus.Error(err)
+		return entity.Account{}, common.NewError(err, common.ErrConflict)
+	}
+
+	return accounts[0], nil
+}
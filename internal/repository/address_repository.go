@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+*
+
+	Address book :
+	- An account has at most one default address, a second one is an ErrConflict,
+	  ClearDefault is called before another address becomes the default
+	- FindByID locks the address until the end of the transaction
+	- Delete keeps the orders placed to the address, their address_id becomes NULL
+
+*
+*/
+type AddressRepository interface {
+	Create(ctx context.Context, address entity.Address) (entity.Address, error)
+	FindByID(ctx context.Context, id int64) (entity.Address, error)
+	FindByAccount(ctx context.Context, username string) ([]entity.Address, error)
+	Update(ctx context.Context, address entity.Address) error
+	ClearDefault(ctx context.Context, username string) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type addressRepository struct {
+	db *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) AddressRepository {
+	return &addressRepository{db: db}
+}
+
+func (ar *addressRepository) Create(ctx context.Context, address entity.Address) (entity.Address, error) {
+
+	err := ar.db.WithContext(ctx).Create(&address).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return address, translateError(err)
+	}
+
+	return address, nil
+}
+
+func (ar *addressRepository) FindByID(ctx context.Context, id int64) (entity.Address, error) {
+
+	var address entity.Address
+
+	err := ar.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&address, id).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return address, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return address, nil
+}
+
+// FindByAccount lists the address book, the default address first
+func (ar *addressRepository) FindByAccount(ctx context.Context, username string) ([]entity.Address, error) {
+
+	var addresses []entity.Address
+
+	err := ar.db.WithContext(ctx).
+		Where("account_username = ?", username).
+		Order("is_default DESC, id").
+		Find(&addresses).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return addresses, nil
+}
+
+// Update writes the address and the default flag, the account never changes
+func (ar *addressRepository) Update(ctx context.Context, address entity.Address) error {
+
+	err := ar.db.WithContext(ctx).
+		Model(&entity.Address{ID: address.ID}).
+		Select("recipient_name", "phone", "line1", "line2", "city", "region", "postal_code", "country", "is_default", "updated_at").
+		Updates(&address).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (ar *addressRepository) ClearDefault(ctx context.Context, username string) error {
+
+	err := ar.db.WithContext(ctx).
+		Model(&entity.Address{}).
+		Where("account_username = ? AND is_default", username).
+		Update("is_default", false).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (ar *addressRepository) Delete(ctx context.Context, id int64) error {
+
+	result := ar.db.WithContext(ctx).Delete(&entity.Address{}, id)
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return nil
+}
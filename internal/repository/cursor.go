@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+/**
+	Keyset positions of products and orders, shared by every repository implementation.
+	A cursor only applies to the sort it was issued for, parsing it with another sort fails.
+**/
+
+const orderCursorSort = "order_date:" + constant.SortDirectionDesc
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// ProductCursor returns the position of product in the given sort, best-selling has no stable position
+func ProductCursor(product entity.Product, sort model.SortParams) model.Cursor {
+
+	cursor := model.Cursor{
+		Sort: productCursorSort(sort),
+		ID:   strconv.FormatInt(product.ID, 10),
+	}
+
+	switch sort.By {
+	case constant.ProductSortPrice:
+		cursor.Key = strconv.FormatInt(product.ListingPrice, 10)
+	case constant.ProductSortName:
+		cursor.Key = product.Name
+	case constant.ProductSortRating:
+		cursor.Key = strconv.FormatFloat(product.RatingAverage, 'f', -1, 64)
+	default:
+		cursor.Key = product.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	return cursor
+}
+
+// ParseProductCursor returns a product holding only the sort key and id of the cursor, the listing price for the price sort
+func ParseProductCursor(cursor model.Cursor, sort model.SortParams) (entity.Product, error) {
+
+	var product entity.Product
+
+	if cursor.Sort != productCursorSort(sort) || sort.By == constant.ProductSortBestSelling {
+		return product, invalidCursor()
+	}
+
+	id, err := strconv.ParseInt(cursor.ID, 10, 64)
+	if err != nil {
+		return product, invalidCursor()
+	}
+
+	product.ID = id
+
+	switch sort.By {
+	case constant.ProductSortPrice:
+		product.ListingPrice, err = strconv.ParseInt(cursor.Key, 10, 64)
+	case constant.ProductSortName:
+		product.Name = cursor.Key
+	case constant.ProductSortRating:
+		product.RatingAverage, err = strconv.ParseFloat(cursor.Key, 64)
+	default:
+		product.CreatedAt, err = time.Parse(time.RFC3339Nano, cursor.Key)
+	}
+
+	if err != nil {
+		return product, invalidCursor()
+	}
+
+	return product, nil
+}
+
+func OrderCursor(order entity.Order) model.Cursor {
+	return model.Cursor{
+		Sort: orderCursorSort,
+		Key:  order.OrderDate.UTC().Format(time.RFC3339Nano),
+		ID:   order.OrderReference,
+	}
+}
+
+// ParseOrderCursor returns an order holding only the order date and reference of the cursor
+func ParseOrderCursor(cursor model.Cursor) (entity.Order, error) {
+
+	var order entity.Order
+
+	if cursor.Sort != orderCursorSort || cursor.ID == "" {
+		return order, invalidCursor()
+	}
+
+	orderDate, err := time.Parse(time.RFC3339Nano, cursor.Key)
+	if err != nil {
+		return order, invalidCursor()
+	}
+
+	order.OrderDate = orderDate
+	order.OrderReference = cursor.ID
+
+	return order, nil
+}
+
+// KeysetAscending tells the direction rows are read in, backward pages read the sort order reversed
+func KeysetAscending(descending bool, pagination model.PaginationParams) bool {
+	return descending == pagination.IsBackward()
+}
+
+func productCursorSort(sort model.SortParams) string {
+	return sort.By + ":" + sort.Direction
+}
+
+func invalidCursor() error {
+	logrus.Error(errInvalidCursor)
+	return common.NewError(errInvalidCursor, common.ErrValidation)
+}
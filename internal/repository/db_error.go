@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+)
+
+// Postgres SQLSTATE codes for integrity constraint violations
+const (
+	pgNotNullViolation    = "23502"
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgCheckViolation      = "23514"
+)
+
+/*
+*
+
+	Translate write errors into their domain category :
+	- Unique violation (duplicate username, email, ...) : ErrConflict
+	- Foreign key, check, not null violation            : ErrValidation
+	- Anything else                                     : ErrDBOperation
+
+*
+*/
+func translateError(err error) error {
+
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return common.NewError(err, common.ErrConflict)
+		case pgForeignKeyViolation, pgCheckViolation, pgNotNullViolation:
+			return common.NewError(err, common.ErrValidation)
+		}
+	}
+
+	return common.NewError(err, common.ErrDBOperation)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Movements are only ever inserted, the table rejects updates and deletes
+type InventoryMovementRepository interface {
+	CreateBatch(ctx context.Context, movements []entity.InventoryMovement) error
+	FindByProductID(ctx context.Context, productID int64, pagination model.PaginationParams) ([]entity.InventoryMovement, int64, error)
+	FindDiscrepancies(ctx context.Context) ([]StockDiscrepancy, error)
+}
+
+// StockDiscrepancy is a product (VariantID nil) or variant whose stock differs from its ledger
+type StockDiscrepancy struct {
+	ProductID   int64  `gorm:"column:product_id"`
+	VariantID   *int64 `gorm:"column:variant_id"`
+	Stock       int64  `gorm:"column:stock"`
+	LedgerStock int64  `gorm:"column:ledger_stock"`
+}
+
+type inventoryMovementRepository struct {
+	db *gorm.DB
+}
+
+func NewInventoryMovementRepository(db *gorm.DB) InventoryMovementRepository {
+	return &inventoryMovementRepository{db: db}
+}
+
+// CreateBatch sets the id of every movement
+func (imr *inventoryMovementRepository) CreateBatch(ctx context.Context, movements []entity.InventoryMovement) error {
+
+	if len(movements) == 0 {
+		return nil
+	}
+
+	err := imr.db.WithContext(ctx).Create(&movements).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// FindByProductID returns the movements of a product and its variants, newest first
+func (imr *inventoryMovementRepository) FindByProductID(
+	ctx context.Context,
+	productID int64,
+	pagination model.PaginationParams) ([]entity.InventoryMovement, int64, error) {
+
+	baseQuery := imr.db.WithContext(ctx).Model(&entity.InventoryMovement{}).Where("product_id = ?", productID)
+	var movements []entity.InventoryMovement
+	var total int64
+
+	if err := baseQuery.Count(&total).Error; err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	dataQuery := baseQuery.Order("id DESC")
+
+	if pagination.IsPaginate {
+		dataQuery = dataQuery.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	if err := dataQuery.Find(&movements).Error; err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return movements, total, nil
+}
+
+// FindDiscrepancies compares every product and variant, soft deleted ones included, with its ledger sum
+func (imr *inventoryMovementRepository) FindDiscrepancies(ctx context.Context) ([]StockDiscrepancy, error) {
+
+	var discrepancies []StockDiscrepancy
+
+	err := imr.db.WithContext(ctx).Raw(`
+		SELECT p.id AS product_id, NULL::int8 AS variant_id, p.stock, COALESCE(m.total, 0) AS ledger_stock
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, SUM(quantity) AS total FROM inventory_movements GROUP BY product_id
+		) m ON m.product_id = p.id
+		WHERE p.stock <> COALESCE(m.total, 0)
+		UNION ALL
+		SELECT v.product_id, v.id, v.stock, COALESCE(m.total, 0)
+		FROM product_variants v
+		LEFT JOIN (
+			SELECT variant_id, SUM(quantity) AS total FROM inventory_movements WHERE variant_id IS NOT NULL GROUP BY variant_id
+		) m ON m.variant_id = v.id
+		WHERE v.stock <> COALESCE(m.total, 0)
+		ORDER BY product_id, variant_id NULLS FIRST`).Scan(&discrepancies).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return discrepancies, nil
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type accountRepository struct {
+	store *Store
+}
+
+func (ar *accountRepository) Create(ctx context.Context, account entity.Account) error {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	if _, exists := ar.store.accounts[account.Username]; exists {
+		return common.NewError(errors.New("duplicate username"), common.ErrConflict)
+	}
+
+	if ar.emailUsedLocked(account.Email, account.Username) {
+		return common.NewError(errors.New("duplicate email"), common.ErrConflict)
+	}
+
+	if account.ID == 0 {
+		ar.store.accountSeq++
+		account.ID = ar.store.accountSeq
+	}
+
+	// Column default
+	if account.Role == "" {
+		account.Role = constant.AccountRoleCustomer
+	}
+
+	account.Orders = nil
+	ar.store.accounts[account.Username] = account
+
+	return nil
+}
+
+func (ar *accountRepository) Update(ctx context.Context, account entity.Account) error {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	if ar.emailUsedLocked(account.Email, account.Username) {
+		return common.NewError(errors.New("duplicate email"), common.ErrConflict)
+	}
+
+	// Like the column list of the update, the verification counters are left
+	existing := ar.store.accounts[account.Username]
+	account.EmailVerificationAttempts = existing.EmailVerificationAttempts
+	account.EmailVerificationRequests = existing.EmailVerificationRequests
+	account.EmailVerificationWindowStartedAt = existing.EmailVerificationWindowStartedAt
+
+	account.Orders = nil
+	ar.store.accounts[account.Username] = account
+
+	return nil
+}
+
+func (ar *accountRepository) FindByUsername(ctx context.Context, username string) (entity.Account, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	account, exists := ar.store.accounts[username]
+
+	if !exists {
+		return account, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return account, nil
+}
+
+func (ar *accountRepository) CheckByUsername(ctx context.Context, username string) (bool, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	_, exists := ar.store.accounts[username]
+
+	return exists, nil
+}
+
+func (ar *accountRepository) CheckByEmail(ctx context.Context, email string) (bool, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	return ar.emailUsedLocked(email, ""), nil
+}
+
+func (ar *accountRepository) StartEmailVerification(ctx context.Context, account entity.Account, windowStart time.Time, maxRequests int64) (entity.Account, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	existing, exists := ar.store.accounts[account.Username]
+
+	if !exists || existing.EmailVerifiedAt != nil {
+		return account, common.NewError(fmt.Errorf("too many verification codes requested for %s, try again later", account.Username), common.ErrConflict)
+	}
+
+	windowRuns := existing.EmailVerificationWindowStartedAt != nil && existing.EmailVerificationWindowStartedAt.After(windowStart)
+
+	if windowRuns && existing.EmailVerificationRequests >= maxRequests {
+		return account, common.NewError(fmt.Errorf("too many verification codes requested for %s, try again later", account.Username), common.ErrConflict)
+	}
+
+	if windowRuns {
+		existing.EmailVerificationRequests++
+	} else {
+		startedAt := account.UpdatedAt
+		existing.EmailVerificationRequests = 1
+		existing.EmailVerificationWindowStartedAt = &startedAt
+	}
+
+	existing.EmailVerificationHash = account.EmailVerificationHash
+	existing.EmailVerificationExpiresAt = account.EmailVerificationExpiresAt
+	existing.EmailVerificationAttempts = 0
+	existing.UpdatedAt = account.UpdatedAt
+	existing.UpdatedBy = account.UpdatedBy
+	ar.store.accounts[account.Username] = existing
+
+	return existing, nil
+}
+
+func (ar *accountRepository) CountEmailVerificationAttempt(ctx context.Context, username string, maxAttempts int64) (entity.Account, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	account, exists := ar.store.accounts[username]
+
+	if !exists || account.EmailVerificationAttempts >= maxAttempts {
+		return entity.Account{}, common.NewError(errors.New("too many attempts, request a new code"), common.ErrConflict)
+	}
+
+	account.EmailVerificationAttempts++
+	ar.store.accounts[username] = account
+
+	return account, nil
+}
+
+// emailUsedLocked reports whether another account than exceptUsername owns the email
+func (ar *accountRepository) emailUsedLocked(email string, exceptUsername string) bool {
+
+	for username, account := range ar.store.accounts {
+		if account.Email == email && username != exceptUsername {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type addressRepository struct {
+	store *Store
+}
+
+func (ar *addressRepository) Create(ctx context.Context, address entity.Address) (entity.Address, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	if _, exists := ar.store.accounts[address.AccountUsername]; !exists {
+		return address, common.NewError(errors.New("address account does not exist"), common.ErrValidation)
+	}
+
+	err := ar.checkDefaultLocked(address)
+	if err != nil {
+		return address, err
+	}
+
+	ar.store.addressSeq++
+	address.ID = ar.store.addressSeq
+	ar.store.addresses[address.ID] = address
+
+	return address, nil
+}
+
+func (ar *addressRepository) FindByID(ctx context.Context, id int64) (entity.Address, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	address, exists := ar.store.addresses[id]
+
+	if !exists {
+		return address, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return address, nil
+}
+
+func (ar *addressRepository) FindByAccount(ctx context.Context, username string) ([]entity.Address, error) {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	var addresses []entity.Address
+
+	for _, address := range ar.store.addresses {
+		if address.AccountUsername == username {
+			addresses = append(addresses, address)
+		}
+	}
+
+	slices.SortFunc(addresses, func(a entity.Address, b entity.Address) int {
+
+		if a.IsDefault != b.IsDefault {
+			if a.IsDefault {
+				return -1
+			}
+			return 1
+		}
+
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return addresses, nil
+}
+
+func (ar *addressRepository) Update(ctx context.Context, address entity.Address) error {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	existing, exists := ar.store.addresses[address.ID]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	existing.PostalAddress = address.PostalAddress
+	existing.IsDefault = address.IsDefault
+	existing.UpdatedAt = address.UpdatedAt
+
+	err := ar.checkDefaultLocked(existing)
+	if err != nil {
+		return err
+	}
+
+	ar.store.addresses[address.ID] = existing
+
+	return nil
+}
+
+func (ar *addressRepository) ClearDefault(ctx context.Context, username string) error {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	for id, address := range ar.store.addresses {
+		if address.AccountUsername == username && address.IsDefault {
+			address.IsDefault = false
+			ar.store.addresses[id] = address
+		}
+	}
+
+	return nil
+}
+
+func (ar *addressRepository) Delete(ctx context.Context, id int64) error {
+
+	ar.store.mu.Lock()
+	defer ar.store.mu.Unlock()
+
+	if _, exists := ar.store.addresses[id]; !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	delete(ar.store.addresses, id)
+
+	// Mirrors ON DELETE SET NULL of the orders
+	for _, reference := range slices.Collect(maps.Keys(ar.store.orders)) {
+		if order := ar.store.orders[reference]; order.AddressID != nil && *order.AddressID == id {
+			order.AddressID = nil
+			ar.store.orders[reference] = order
+		}
+	}
+
+	return nil
+}
+
+// checkDefaultLocked mirrors the unique index on the default address of an account
+func (ar *addressRepository) checkDefaultLocked(address entity.Address) error {
+
+	if !address.IsDefault {
+		return nil
+	}
+
+	for _, existing := range ar.store.addresses {
+		if existing.ID != address.ID && existing.AccountUsername == address.AccountUsername && existing.IsDefault {
+			return common.NewError(errors.New("duplicate default address"), common.ErrConflict)
+		}
+	}
+
+	return nil
+}
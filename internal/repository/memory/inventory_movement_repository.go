@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+)
+
+type inventoryMovementRepository struct {
+	store *Store
+}
+
+func (imr *inventoryMovementRepository) CreateBatch(ctx context.Context, movements []entity.InventoryMovement) error {
+
+	imr.store.mu.Lock()
+	defer imr.store.mu.Unlock()
+
+	for _, movement := range movements {
+
+		if _, exists := imr.store.products[movement.ProductID]; !exists {
+			return common.NewError(errors.New("product does not exist"), common.ErrValidation)
+		}
+
+		if movement.VariantID != nil {
+			if _, exists := imr.store.variants[*movement.VariantID]; !exists {
+				return common.NewError(errors.New("variant does not exist"), common.ErrValidation)
+			}
+		}
+	}
+
+	// Ids are written back like gorm does
+	for i := range movements {
+		imr.store.movementSeq++
+		movements[i].ID = imr.store.movementSeq
+		imr.store.movements = append(imr.store.movements, movements[i])
+	}
+
+	return nil
+}
+
+func (imr *inventoryMovementRepository) FindByProductID(
+	ctx context.Context,
+	productID int64,
+	pagination model.PaginationParams) ([]entity.InventoryMovement, int64, error) {
+
+	imr.store.mu.Lock()
+	defer imr.store.mu.Unlock()
+
+	var movements []entity.InventoryMovement
+
+	for _, movement := range imr.store.movements {
+		if movement.ProductID == productID {
+			movements = append(movements, movement)
+		}
+	}
+
+	slices.SortFunc(movements, func(a entity.InventoryMovement, b entity.InventoryMovement) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+
+	total := int64(len(movements))
+
+	if pagination.IsPaginate {
+		movements = paginate(movements, pagination)
+	}
+
+	return movements, total, nil
+}
+
+func (imr *inventoryMovementRepository) FindDiscrepancies(ctx context.Context) ([]repository.StockDiscrepancy, error) {
+
+	imr.store.mu.Lock()
+	defer imr.store.mu.Unlock()
+
+	productLedger := make(map[int64]int64)
+	variantLedger := make(map[int64]int64)
+
+	for _, movement := range imr.store.movements {
+
+		productLedger[movement.ProductID] += movement.Quantity
+
+		if movement.VariantID != nil {
+			variantLedger[*movement.VariantID] += movement.Quantity
+		}
+	}
+
+	var discrepancies []repository.StockDiscrepancy
+
+	for _, product := range imr.store.products {
+		if product.Stock != productLedger[product.ID] {
+			discrepancies = append(discrepancies, repository.StockDiscrepancy{
+				ProductID:   product.ID,
+				Stock:       product.Stock,
+				LedgerStock: productLedger[product.ID],
+			})
+		}
+	}
+
+	for _, variant := range imr.store.variants {
+		if variant.Stock != variantLedger[variant.ID] {
+			discrepancies = append(discrepancies, repository.StockDiscrepancy{
+				ProductID:   variant.ProductID,
+				VariantID:   &variant.ID,
+				Stock:       variant.Stock,
+				LedgerStock: variantLedger[variant.ID],
+			})
+		}
+	}
+
+	// Products before their variants, like NULLS FIRST
+	variantKey := func(discrepancy repository.StockDiscrepancy) int64 {
+
+		if discrepancy.VariantID == nil {
+			return 0
+		}
+
+		return *discrepancy.VariantID
+	}
+
+	slices.SortFunc(discrepancies, func(a repository.StockDiscrepancy, b repository.StockDiscrepancy) int {
+		return cmp.Or(cmp.Compare(a.ProductID, b.ProductID), cmp.Compare(variantKey(a), variantKey(b)))
+	})
+
+	return discrepancies, nil
+}
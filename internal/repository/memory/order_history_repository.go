@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+)
+
+type orderHistoryRepository struct {
+	store *Store
+}
+
+func (ohr *orderHistoryRepository) Create(ctx context.Context, entry entity.OrderHistory) (entity.OrderHistory, error) {
+
+	ohr.store.mu.Lock()
+	defer ohr.store.mu.Unlock()
+
+	if _, exists := ohr.store.orders[entry.OrderReference]; !exists {
+		return entry, common.NewError(errors.New("order does not exist"), common.ErrValidation)
+	}
+
+	if entry.Changes == nil {
+		entry.Changes = entity.OrderChanges{}
+	}
+
+	ohr.store.historySeq++
+	entry.ID = ohr.store.historySeq
+	entry.Changes = slices.Clone(entry.Changes)
+	ohr.store.history = append(ohr.store.history, entry)
+
+	return entry, nil
+}
+
+func (ohr *orderHistoryRepository) FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderHistory, error) {
+
+	ohr.store.mu.Lock()
+	defer ohr.store.mu.Unlock()
+
+	return historyOfOrderLocked(ohr.store, orderReference), nil
+}
+
+func historyOfOrderLocked(store *Store, orderReference string) []entity.OrderHistory {
+
+	var entries []entity.OrderHistory
+
+	for _, entry := range store.history {
+		if entry.OrderReference == orderReference {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
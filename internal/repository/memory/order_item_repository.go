@@ -0,0 +1,197 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type orderItemRepository struct {
+	store *Store
+}
+
+func (oir *orderItemRepository) Create(ctx context.Context, orderItem entity.OrderItem) error {
+
+	oir.store.mu.Lock()
+	defer oir.store.mu.Unlock()
+
+	err := oir.validateLocked(orderItem)
+	if err != nil {
+		return err
+	}
+
+	oir.insertLocked(orderItem)
+
+	return nil
+}
+
+func (oir *orderItemRepository) FindByID(ctx context.Context, id string) (entity.OrderItem, error) {
+
+	oir.store.mu.Lock()
+	defer oir.store.mu.Unlock()
+
+	orderItem, exists := oir.store.orderItems[id]
+
+	if !exists {
+		return orderItem, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return orderItem, nil
+}
+
+func (oir *orderItemRepository) FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderItem, error) {
+
+	oir.store.mu.Lock()
+	defer oir.store.mu.Unlock()
+
+	return itemsOfOrderLocked(oir.store, orderReference), nil
+}
+
+// CreateBatch inserts all items or none of them
+func (oir *orderItemRepository) CreateBatch(ctx context.Context, orderItems []entity.OrderItem, batchSize int) error {
+
+	oir.store.mu.Lock()
+	defer oir.store.mu.Unlock()
+
+	seen := make(map[string]bool, len(orderItems))
+
+	for _, orderItem := range orderItems {
+
+		if seen[orderItem.OrderItemReference] {
+			return common.NewError(errors.New("duplicate order item reference"), common.ErrConflict)
+		}
+
+		seen[orderItem.OrderItemReference] = true
+
+		err := oir.validateLocked(orderItem)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, orderItem := range orderItems {
+		oir.insertLocked(orderItem)
+	}
+
+	return nil
+}
+
+func (oir *orderItemRepository) Update(ctx context.Context, orderItem entity.OrderItem) error {
+
+	oir.store.mu.Lock()
+	defer oir.store.mu.Unlock()
+
+	existing, exists := oir.store.orderItems[orderItem.OrderItemReference]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	if orderItem.Quantity <= 0 || orderItem.Total < 0 {
+		return common.NewError(errors.New("order item quantity or total out of range"), common.ErrValidation)
+	}
+
+	if orderItem.DiscountAmount < 0 || orderItem.DiscountAmount > orderItem.Total {
+		return common.NewError(errors.New("order item discount out of range"), common.ErrValidation)
+	}
+
+	existing.Quantity = orderItem.Quantity
+	existing.PriceSnapshot = orderItem.PriceSnapshot
+	existing.Total = orderItem.Total
+	existing.DiscountAmount = orderItem.DiscountAmount
+	existing.UpdatedAt = orderItem.UpdatedAt
+	existing.UpdatedBy = orderItem.UpdatedBy
+	oir.store.orderItems[orderItem.OrderItemReference] = existing
+
+	return nil
+}
+
+// DeleteByReferences deletes the items and their taxes, like the cascade of the table
+func (oir *orderItemRepository) DeleteByReferences(ctx context.Context, orderItemReferences []string) error {
+
+	oir.store.mu.Lock()
+	defer oir.store.mu.Unlock()
+
+	for _, reference := range orderItemReferences {
+		delete(oir.store.orderItems, reference)
+	}
+
+	oir.store.itemTaxes = slices.DeleteFunc(oir.store.itemTaxes, func(tax entity.OrderItemTax) bool {
+		return slices.Contains(orderItemReferences, tax.OrderItemReference)
+	})
+
+	return nil
+}
+
+func (oir *orderItemRepository) CheckFinishedPurchase(ctx context.Context, username string, productID int64) (bool, error) {
+
+	oir.store.mu.Lock()
+	defer oir.store.mu.Unlock()
+
+	for _, orderItem := range oir.store.orderItems {
+
+		if orderItem.ProductID != productID || orderItem.DeletedAt != nil {
+			continue
+		}
+
+		order, exists := oir.store.orders[orderItem.OrderReference]
+
+		if exists && order.IsOwnedBy(username) && order.Status == constant.OrderStatusFinished && order.DeletedAt == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (oir *orderItemRepository) validateLocked(orderItem entity.OrderItem) error {
+
+	if _, exists := oir.store.orderItems[orderItem.OrderItemReference]; exists {
+		return common.NewError(errors.New("duplicate order item reference"), common.ErrConflict)
+	}
+
+	order, exists := oir.store.orders[orderItem.OrderReference]
+
+	if !exists {
+		return common.NewError(errors.New("order item order does not exist"), common.ErrValidation)
+	}
+
+	if orderItem.Currency != order.Currency {
+		return common.NewError(errors.New("order item currency differs from the order currency"), common.ErrValidation)
+	}
+
+	if orderItem.ProductID != 0 {
+		if _, exists := oir.store.products[orderItem.ProductID]; !exists {
+			return common.NewError(errors.New("order item product does not exist"), common.ErrValidation)
+		}
+	}
+
+	if orderItem.VariantID != nil {
+		if _, exists := oir.store.variants[*orderItem.VariantID]; !exists {
+			return common.NewError(errors.New("order item variant does not exist"), common.ErrValidation)
+		}
+	}
+
+	if orderItem.Quantity <= 0 || orderItem.Total < 0 {
+		return common.NewError(errors.New("order item quantity or total out of range"), common.ErrValidation)
+	}
+
+	if orderItem.DiscountAmount < 0 || orderItem.DiscountAmount > orderItem.Total {
+		return common.NewError(errors.New("order item discount out of range"), common.ErrValidation)
+	}
+
+	return nil
+}
+
+func (oir *orderItemRepository) insertLocked(orderItem entity.OrderItem) {
+
+	orderItem.Order = entity.Order{}
+	orderItem.Product = entity.Product{}
+	orderItem.Taxes = nil
+	oir.store.orderItems[orderItem.OrderItemReference] = orderItem
+}
@@ -0,0 +1,276 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+type orderRepository struct {
+	store *Store
+}
+
+func (or *orderRepository) Create(ctx context.Context, order entity.Order) error {
+
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	if _, exists := or.store.orders[order.OrderReference]; exists {
+		return common.NewError(errors.New("duplicate order reference"), common.ErrConflict)
+	}
+
+	return or.saveLocked(order)
+}
+
+func (or *orderRepository) Update(ctx context.Context, order entity.Order) error {
+
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	return or.saveLocked(order)
+}
+
+func (or *orderRepository) FindByID(ctx context.Context, id string) (entity.Order, error) {
+
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	order, exists := or.store.orders[id]
+
+	if !exists {
+		return order, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return order, nil
+}
+
+func (or *orderRepository) FindByIDWithItems(ctx context.Context, id string) (entity.Order, error) {
+
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	order, exists := or.store.orders[id]
+
+	if !exists {
+		return order, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	order.OrderItems = itemsOfOrderLocked(or.store, id)
+	order.Discounts = discountsOfOrderLocked(or.store, id)
+	order.Shipments = shipmentsOfOrderLocked(or.store, id)
+	order.History = historyOfOrderLocked(or.store, id)
+
+	return order, nil
+}
+
+func (or *orderRepository) FindWithAccountAndFilters(
+	ctx context.Context,
+	accountUsername string,
+	filter model.OrderFilter,
+	pagination model.PaginationParams) ([]entity.Order, int64, error) {
+
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	var orders []entity.Order
+
+	for _, order := range or.store.orders {
+
+		if !order.IsOwnedBy(accountUsername) || order.DeletedAt != nil {
+			continue
+		}
+
+		if filter.OrderReference != "" && !containsFold(order.OrderReference, filter.OrderReference) {
+			continue
+		}
+
+		orders = append(orders, order)
+	}
+
+	// Newest first, order reference keeps equal dates stable
+	compare := func(a entity.Order, b entity.Order) int {
+
+		result := b.OrderDate.Compare(a.OrderDate)
+
+		if result == 0 {
+			result = strings.Compare(b.OrderReference, a.OrderReference)
+		}
+
+		return result
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return compare(orders[i], orders[j]) < 0
+	})
+
+	var total int64
+	if !pagination.SkipCount {
+		total = int64(len(orders))
+	}
+
+	if pagination.IsKeyset {
+
+		var position *entity.Order
+
+		if pagination.Cursor != nil {
+
+			order, err := repository.ParseOrderCursor(*pagination.Cursor)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			position = &order
+		}
+
+		return keysetPage(orders, position, compare, pagination), total, nil
+	}
+
+	if pagination.IsPaginate {
+		orders = paginate(orders, pagination)
+	}
+
+	return orders, total, nil
+}
+
+func (or *orderRepository) FindGuestOrdersByEmail(ctx context.Context, email string) ([]entity.Order, error) {
+
+	or.store.mu.Lock()
+	defer or.store.mu.Unlock()
+
+	var orders []entity.Order
+
+	for _, order := range or.store.orders {
+		if order.IsGuest() && strings.EqualFold(order.GuestEmail, email) {
+			orders = append(orders, order)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].OrderDate.Equal(orders[j].OrderDate) {
+			return orders[i].OrderDate.Before(orders[j].OrderDate)
+		}
+		return orders[i].OrderReference < orders[j].OrderReference
+	})
+
+	return orders, nil
+}
+
+func (or *orderRepository) saveLocked(order entity.Order) error {
+
+	if order.AccountUsername != nil {
+		if _, exists := or.store.accounts[*order.AccountUsername]; !exists {
+			return common.NewError(errors.New("order account does not exist"), common.ErrValidation)
+		}
+	} else if order.GuestEmail == "" || order.GuestTokenHash == "" {
+		return common.NewError(errors.New("a guest order needs an email and an access token"), common.ErrValidation)
+	}
+
+	if order.Total < 0 {
+		return common.NewError(errors.New("order total must not be negative"), common.ErrValidation)
+	}
+
+	if order.AddressID != nil {
+		if _, exists := or.store.addresses[*order.AddressID]; !exists {
+			return common.NewError(errors.New("order address does not exist"), common.ErrValidation)
+		}
+	}
+
+	if order.ShippingMethodID != nil {
+		if _, exists := or.store.shippingMethods[*order.ShippingMethodID]; !exists {
+			return common.NewError(errors.New("order shipping method does not exist"), common.ErrValidation)
+		}
+	}
+
+	if order.TaxTotal < 0 || order.TaxIncluded < 0 || order.TaxIncluded > order.TaxTotal {
+		return common.NewError(errors.New("order tax totals out of range"), common.ErrValidation)
+	}
+
+	order.OrderItems = nil
+	order.Discounts = nil
+	order.Shipments = nil
+	order.Payment = nil
+	order.Account = entity.Account{}
+	or.store.orders[order.OrderReference] = order
+
+	return nil
+}
+
+func itemsOfOrderLocked(store *Store, orderReference string) []entity.OrderItem {
+
+	var orderItems []entity.OrderItem
+
+	for _, orderItem := range store.orderItems {
+		if orderItem.OrderReference == orderReference {
+			orderItem.Taxes = taxesOfOrderItemLocked(store, orderItem.OrderItemReference)
+			orderItems = append(orderItems, orderItem)
+		}
+	}
+
+	sort.Slice(orderItems, func(i, j int) bool {
+		return orderItems[i].OrderItemReference < orderItems[j].OrderItemReference
+	})
+
+	return orderItems
+}
+
+// containsFold mirrors ILIKE '%needle%'
+func containsFold(haystack string, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle))
+}
+
+func paginate[T any](rows []T, pagination model.PaginationParams) []T {
+
+	offset := pagination.GetOffset()
+
+	if offset >= len(rows) {
+		return []T{}
+	}
+
+	end := offset + pagination.PerPage
+
+	if end > len(rows) {
+		end = len(rows)
+	}
+
+	return rows[offset:end]
+}
+
+// keysetPage mirrors the keyset queries : up to PerPage + 1 rows following position
+// (preceding it for backward pages, nearest first), rows must be sorted by compare
+func keysetPage[T any](rows []T, position *T, compare func(a T, b T) int, pagination model.PaginationParams) []T {
+
+	backward := pagination.IsBackward()
+	page := []T{}
+
+	for i := range rows {
+
+		row := rows[i]
+		if backward {
+			row = rows[len(rows)-1-i]
+		}
+
+		if position != nil {
+
+			result := compare(row, *position)
+
+			if (!backward && result <= 0) || (backward && result >= 0) {
+				continue
+			}
+		}
+
+		page = append(page, row)
+
+		if len(page) > pagination.PerPage {
+			break
+		}
+	}
+
+	return page
+}
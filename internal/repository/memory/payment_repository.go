@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type paymentRepository struct {
+	store *Store
+}
+
+func (pr *paymentRepository) Create(ctx context.Context, payment entity.Payment) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	if _, exists := pr.store.payments[payment.PaymentReference]; exists {
+		return common.NewError(errors.New("duplicate payment reference"), common.ErrConflict)
+	}
+
+	return pr.saveLocked(payment)
+}
+
+func (pr *paymentRepository) Update(ctx context.Context, payment entity.Payment) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	return pr.saveLocked(payment)
+}
+
+func (pr *paymentRepository) FindByID(ctx context.Context, id string) (entity.Payment, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	payment, exists := pr.store.payments[id]
+
+	if !exists {
+		return payment, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return payment, nil
+}
+
+// FindByOrderReference returns the payment with the lowest reference, like First()
+func (pr *paymentRepository) FindByOrderReference(ctx context.Context, orderReference string) (entity.Payment, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	var found *entity.Payment
+
+	for _, payment := range pr.store.payments {
+		if payment.OrderReference == orderReference && (found == nil || payment.PaymentReference < found.PaymentReference) {
+			payment := payment
+			found = &payment
+		}
+	}
+
+	if found == nil {
+		return entity.Payment{}, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return *found, nil
+}
+
+func (pr *paymentRepository) saveLocked(payment entity.Payment) error {
+
+	order, exists := pr.store.orders[payment.OrderReference]
+
+	if !exists {
+		return common.NewError(errors.New("payment order does not exist"), common.ErrValidation)
+	}
+
+	if payment.Currency != order.Currency {
+		return common.NewError(errors.New("payment currency differs from the order currency"), common.ErrValidation)
+	}
+
+	if payment.Total < 0 {
+		return common.NewError(errors.New("payment total must not be negative"), common.ErrValidation)
+	}
+
+	payment.Order = entity.Order{}
+	pr.store.payments[payment.PaymentReference] = payment
+
+	return nil
+}
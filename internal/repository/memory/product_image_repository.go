@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type productImageRepository struct {
+	store *Store
+}
+
+func (pir *productImageRepository) FindByProductIDs(ctx context.Context, productIDs []int64) ([]entity.ProductImage, error) {
+
+	pir.store.mu.Lock()
+	defer pir.store.mu.Unlock()
+
+	var images []entity.ProductImage
+
+	for _, image := range pir.store.images {
+		if slices.Contains(productIDs, image.ProductID) {
+			images = append(images, cloneImage(image))
+		}
+	}
+
+	slices.SortFunc(images, func(a entity.ProductImage, b entity.ProductImage) int {
+		return cmp.Or(
+			cmp.Compare(a.ProductID, b.ProductID),
+			cmp.Compare(a.Position, b.Position),
+			cmp.Compare(a.ID, b.ID))
+	})
+
+	return images, nil
+}
+
+func (pir *productImageRepository) FindByID(ctx context.Context, id int64) (entity.ProductImage, error) {
+
+	pir.store.mu.Lock()
+	defer pir.store.mu.Unlock()
+
+	image, exists := pir.store.images[id]
+
+	if !exists {
+		return entity.ProductImage{}, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return cloneImage(image), nil
+}
+
+func (pir *productImageRepository) Create(ctx context.Context, image entity.ProductImage) (entity.ProductImage, error) {
+
+	pir.store.mu.Lock()
+	defer pir.store.mu.Unlock()
+
+	if _, exists := pir.store.products[image.ProductID]; !exists {
+		return image, common.NewError(errors.New("product does not exist"), common.ErrValidation)
+	}
+
+	for _, existing := range pir.store.images {
+
+		if existing.StorageKey == image.StorageKey {
+			return image, common.NewError(errors.New("duplicate storage key"), common.ErrConflict)
+		}
+
+		if image.IsPrimary && existing.IsPrimary && existing.ProductID == image.ProductID {
+			return image, common.NewError(errors.New("product already has a primary image"), common.ErrConflict)
+		}
+	}
+
+	pir.store.imageSeq++
+	image.ID = pir.store.imageSeq
+
+	for i := range image.Sizes {
+		image.Sizes[i].ImageID = image.ID
+	}
+
+	pir.store.images[image.ID] = cloneImage(image)
+
+	return image, nil
+}
+
+func (pir *productImageRepository) Update(ctx context.Context, image entity.ProductImage) error {
+
+	pir.store.mu.Lock()
+	defer pir.store.mu.Unlock()
+
+	existing, exists := pir.store.images[image.ID]
+
+	if !exists {
+		return nil
+	}
+
+	if image.Position < 0 {
+		return common.NewError(errors.New("position must not be negative"), common.ErrValidation)
+	}
+
+	if image.IsPrimary {
+		for _, other := range pir.store.images {
+			if other.ID != image.ID && other.ProductID == existing.ProductID && other.IsPrimary {
+				return common.NewError(errors.New("product already has a primary image"), common.ErrConflict)
+			}
+		}
+	}
+
+	existing.AltText = image.AltText
+	existing.Position = image.Position
+	existing.IsPrimary = image.IsPrimary
+	existing.UpdatedAt = image.UpdatedAt
+	existing.UpdatedBy = image.UpdatedBy
+	pir.store.images[image.ID] = existing
+
+	return nil
+}
+
+func (pir *productImageRepository) ClearPrimary(ctx context.Context, productID int64) error {
+
+	pir.store.mu.Lock()
+	defer pir.store.mu.Unlock()
+
+	for id, image := range pir.store.images {
+		if image.ProductID == productID && image.IsPrimary {
+			image.IsPrimary = false
+			pir.store.images[id] = image
+		}
+	}
+
+	return nil
+}
+
+func (pir *productImageRepository) Delete(ctx context.Context, id int64) error {
+
+	pir.store.mu.Lock()
+	defer pir.store.mu.Unlock()
+
+	delete(pir.store.images, id)
+
+	return nil
+}
+
+// cloneImage copies the sizes so callers never share a slice with the store
+func cloneImage(image entity.ProductImage) entity.ProductImage {
+	image.Sizes = slices.Clone(image.Sizes)
+	return image
+}
@@ -0,0 +1,272 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type productPriceChangeRepository struct {
+	store *Store
+}
+
+func (pcr *productPriceChangeRepository) Create(ctx context.Context, change entity.ProductPriceChange) (entity.ProductPriceChange, error) {
+
+	pcr.store.mu.Lock()
+	defer pcr.store.mu.Unlock()
+
+	if _, exists := pcr.store.products[change.ProductID]; !exists {
+		return change, common.NewError(errors.New("product does not exist"), common.ErrValidation)
+	}
+
+	if change.Price <= 0 {
+		return change, common.NewError(errors.New("price must be positive"), common.ErrValidation)
+	}
+
+	pcr.store.priceChangeSeq++
+	change.ID = pcr.store.priceChangeSeq
+	pcr.store.priceChanges[change.ID] = change
+
+	return change, nil
+}
+
+func (pcr *productPriceChangeRepository) FindPendingByProductID(ctx context.Context, productID int64) ([]entity.ProductPriceChange, error) {
+
+	pcr.store.mu.Lock()
+	defer pcr.store.mu.Unlock()
+
+	return pcr.pendingLocked(func(change entity.ProductPriceChange) bool {
+		return change.ProductID == productID
+	}), nil
+}
+
+func (pcr *productPriceChangeRepository) FindDue(ctx context.Context, productIDs []int64, at time.Time) ([]entity.ProductPriceChange, error) {
+
+	pcr.store.mu.Lock()
+	defer pcr.store.mu.Unlock()
+
+	return pcr.pendingLocked(func(change entity.ProductPriceChange) bool {
+		return !change.EffectiveAt.After(at) && (len(productIDs) == 0 || slices.Contains(productIDs, change.ProductID))
+	}), nil
+}
+
+func (pcr *productPriceChangeRepository) MarkApplied(ctx context.Context, id int64, at time.Time) (bool, error) {
+
+	pcr.store.mu.Lock()
+	defer pcr.store.mu.Unlock()
+
+	change, exists := pcr.store.priceChanges[id]
+
+	if !exists || change.AppliedAt != nil {
+		return false, nil
+	}
+
+	change.AppliedAt = &at
+	pcr.store.priceChanges[id] = change
+
+	return true, nil
+}
+
+func (pcr *productPriceChangeRepository) DeletePending(ctx context.Context, productID int64, id int64) (int64, error) {
+
+	pcr.store.mu.Lock()
+	defer pcr.store.mu.Unlock()
+
+	change, exists := pcr.store.priceChanges[id]
+
+	if !exists || change.ProductID != productID || change.AppliedAt != nil {
+		return 0, nil
+	}
+
+	delete(pcr.store.priceChanges, id)
+
+	return 1, nil
+}
+
+// pendingLocked lists the pending changes matching keep by effective time then id
+func (pcr *productPriceChangeRepository) pendingLocked(keep func(change entity.ProductPriceChange) bool) []entity.ProductPriceChange {
+
+	var changes []entity.ProductPriceChange
+
+	for change := range maps.Values(pcr.store.priceChanges) {
+		if change.AppliedAt == nil && keep(change) {
+			changes = append(changes, change)
+		}
+	}
+
+	slices.SortFunc(changes, func(a entity.ProductPriceChange, b entity.ProductPriceChange) int {
+		return cmp.Or(a.EffectiveAt.Compare(b.EffectiveAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return changes
+}
+
+type productPriceHistoryRepository struct {
+	store *Store
+}
+
+func (phr *productPriceHistoryRepository) Create(ctx context.Context, entry entity.ProductPriceHistory) error {
+
+	phr.store.mu.Lock()
+	defer phr.store.mu.Unlock()
+
+	if _, exists := phr.store.products[entry.ProductID]; !exists {
+		return common.NewError(errors.New("product does not exist"), common.ErrValidation)
+	}
+
+	phr.store.priceHistorySeq++
+	entry.ID = phr.store.priceHistorySeq
+	phr.store.priceHistory = append(phr.store.priceHistory, entry)
+
+	return nil
+}
+
+func (phr *productPriceHistoryRepository) FindByProductID(ctx context.Context, productID int64, pagination model.PaginationParams) ([]entity.ProductPriceHistory, int64, error) {
+
+	phr.store.mu.Lock()
+	defer phr.store.mu.Unlock()
+
+	var entries []entity.ProductPriceHistory
+
+	for _, entry := range phr.store.priceHistory {
+		if entry.ProductID == productID {
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortFunc(entries, func(a entity.ProductPriceHistory, b entity.ProductPriceHistory) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	total := int64(len(entries))
+
+	if pagination.IsPaginate {
+		entries = paginate(entries, pagination)
+	}
+
+	return entries, total, nil
+}
+
+type productCurrencyPriceRepository struct {
+	store *Store
+}
+
+func (cpr *productCurrencyPriceRepository) Create(ctx context.Context, price entity.ProductCurrencyPrice) (entity.ProductCurrencyPrice, error) {
+
+	cpr.store.mu.Lock()
+	defer cpr.store.mu.Unlock()
+
+	err := cpr.validateLocked(price)
+	if err != nil {
+		return price, err
+	}
+
+	for _, existing := range cpr.store.priceList {
+		if existing.ProductID == price.ProductID && existing.Currency == price.Currency && equalPtr(existing.VariantID, price.VariantID) {
+			return price, common.NewError(errors.New("duplicate product currency price"), common.ErrConflict)
+		}
+	}
+
+	cpr.store.priceListSeq++
+	price.ID = cpr.store.priceListSeq
+	cpr.store.priceList[price.ID] = price
+
+	return price, nil
+}
+
+func (cpr *productCurrencyPriceRepository) FindByProductIDs(ctx context.Context, productIDs []int64, currency string) ([]entity.ProductCurrencyPrice, error) {
+
+	cpr.store.mu.Lock()
+	defer cpr.store.mu.Unlock()
+
+	var prices []entity.ProductCurrencyPrice
+
+	for price := range maps.Values(cpr.store.priceList) {
+		if slices.Contains(productIDs, price.ProductID) && (currency == "" || price.Currency == currency) {
+			prices = append(prices, price)
+		}
+	}
+
+	slices.SortFunc(prices, func(a entity.ProductCurrencyPrice, b entity.ProductCurrencyPrice) int {
+		return cmp.Or(
+			cmp.Compare(a.ProductID, b.ProductID),
+			cmp.Compare(a.Currency, b.Currency),
+			cmp.Compare(variantOrder(a.VariantID), variantOrder(b.VariantID)))
+	})
+
+	return prices, nil
+}
+
+func (cpr *productCurrencyPriceRepository) Update(ctx context.Context, price entity.ProductCurrencyPrice) error {
+
+	cpr.store.mu.Lock()
+	defer cpr.store.mu.Unlock()
+
+	existing, exists := cpr.store.priceList[price.ID]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	if price.Price <= 0 {
+		return common.NewError(errors.New("price must be positive"), common.ErrValidation)
+	}
+
+	existing.Price = price.Price
+	existing.UpdatedAt = price.UpdatedAt
+	existing.UpdatedBy = price.UpdatedBy
+	cpr.store.priceList[price.ID] = existing
+
+	return nil
+}
+
+func (cpr *productCurrencyPriceRepository) Delete(ctx context.Context, id int64) error {
+
+	cpr.store.mu.Lock()
+	defer cpr.store.mu.Unlock()
+
+	if _, exists := cpr.store.priceList[id]; !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	delete(cpr.store.priceList, id)
+
+	return nil
+}
+
+func (cpr *productCurrencyPriceRepository) validateLocked(price entity.ProductCurrencyPrice) error {
+
+	if _, exists := cpr.store.products[price.ProductID]; !exists {
+		return common.NewError(errors.New("product does not exist"), common.ErrValidation)
+	}
+
+	if price.VariantID != nil {
+		if _, exists := cpr.store.variants[*price.VariantID]; !exists {
+			return common.NewError(errors.New("variant does not exist"), common.ErrValidation)
+		}
+	}
+
+	if price.Price <= 0 {
+		return common.NewError(errors.New("price must be positive"), common.ErrValidation)
+	}
+
+	return nil
+}
+
+// variantOrder puts the product entry, without a variant, before the variant entries like NULLS FIRST
+func variantOrder(variantID *int64) int64 {
+
+	if variantID == nil {
+		return 0
+	}
+
+	return *variantID
+}
@@ -0,0 +1,424 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"gorm.io/gorm"
+)
+
+type productRepository struct {
+	store *Store
+}
+
+func (pr *productRepository) FindWithFilters(
+	ctx context.Context,
+	filter model.ProductFilter,
+	sortParams model.SortParams,
+	pagination model.PaginationParams) ([]entity.Product, int64, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	now := time.Now()
+	products := pr.filterLocked(filter, now)
+
+	sold := pr.soldQuantitiesLocked()
+
+	// compare orders products as the sort requires, id keeps equal keys stable
+	compare := func(a entity.Product, b entity.Product) int {
+
+		var result int
+
+		switch sortParams.By {
+		case constant.ProductSortPrice:
+			result = cmp.Compare(a.ListingPrice, b.ListingPrice)
+		case constant.ProductSortName:
+			result = strings.Compare(a.Name, b.Name)
+		case constant.ProductSortBestSelling:
+			result = cmp.Compare(sold[a.ID], sold[b.ID])
+		case constant.ProductSortRating:
+			result = cmp.Compare(a.RatingAverage, b.RatingAverage)
+		default:
+			result = a.CreatedAt.Compare(b.CreatedAt)
+		}
+
+		if result == 0 {
+			result = cmp.Compare(a.ID, b.ID)
+		}
+
+		if sortParams.IsDescending() {
+			return -result
+		}
+
+		return result
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return compare(products[i], products[j]) < 0
+	})
+
+	var total int64
+	if !pagination.SkipCount {
+		total = int64(len(products))
+	}
+
+	if pagination.IsKeyset {
+
+		var position *entity.Product
+
+		if pagination.Cursor != nil {
+
+			product, err := repository.ParseProductCursor(*pagination.Cursor, sortParams)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			position = &product
+		}
+
+		return keysetPage(products, position, compare, pagination), total, nil
+	}
+
+	if pagination.IsPaginate {
+		products = paginate(products, pagination)
+	}
+
+	return products, total, nil
+}
+
+func (pr *productRepository) FindByID(ctx context.Context, id int64) (entity.Product, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists {
+		return product, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return product, nil
+}
+
+func (pr *productRepository) FindMultipleByIDs(ctx context.Context, ids []int64) ([]entity.Product, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	seen := make(map[int64]bool, len(ids))
+	var products []entity.Product
+
+	for _, id := range ids {
+
+		product, exists := pr.store.products[id]
+
+		if exists && !seen[id] {
+			seen[id] = true
+			products = append(products, product)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID < products[j].ID
+	})
+
+	return products, nil
+}
+
+// LockByIDs reads like FindMultipleByIDs, transactions already hold every row
+func (pr *productRepository) LockByIDs(ctx context.Context, ids []int64) ([]entity.Product, error) {
+	return pr.FindMultipleByIDs(ctx, ids)
+}
+
+func (pr *productRepository) DecrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists || product.Stock < quantity {
+		return 0, common.NewError(fmt.Errorf("insufficient stock for product: %v , requested : %v", id, quantity), common.ErrValidation)
+	}
+
+	product.Stock -= quantity
+	product.UpdatedAt = time.Now()
+	product.UpdatedBy = updatedBy
+	pr.store.products[id] = product
+
+	return product.Stock, nil
+}
+
+func (pr *productRepository) IncrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists {
+		return 0, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	product.Stock += quantity
+	product.UpdatedAt = time.Now()
+	product.UpdatedBy = updatedBy
+	pr.store.products[id] = product
+
+	return product.Stock, nil
+}
+
+func (pr *productRepository) UpdateLowStockThreshold(ctx context.Context, id int64, threshold int64, updatedBy string) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	if threshold < 0 {
+		return common.NewError(errors.New("low stock threshold must not be negative"), common.ErrValidation)
+	}
+
+	product.LowStockThreshold = threshold
+	product.UpdatedAt = time.Now()
+	product.UpdatedBy = updatedBy
+	pr.store.products[id] = product
+
+	return nil
+}
+
+func (pr *productRepository) UpdatePrice(ctx context.Context, id int64, price int64, updatedBy string) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	if price <= 0 {
+		return common.NewError(errors.New("price must be positive"), common.ErrValidation)
+	}
+
+	product.Price = price
+	product.UpdatedAt = time.Now()
+	product.UpdatedBy = updatedBy
+	pr.store.products[id] = product
+
+	return nil
+}
+
+func (pr *productRepository) UpdateSale(ctx context.Context, id int64, salePrice *int64, startsAt *time.Time, endsAt *time.Time, updatedBy string) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	// products_sale_check
+	if salePrice == nil && (startsAt != nil || endsAt != nil) || salePrice != nil && *salePrice <= 0 {
+		return common.NewError(errors.New("invalid sale price"), common.ErrValidation)
+	}
+
+	if startsAt != nil && endsAt != nil && !endsAt.After(*startsAt) {
+		return common.NewError(errors.New("sale must end after it starts"), common.ErrValidation)
+	}
+
+	product.SalePrice = salePrice
+	product.SaleStartsAt = startsAt
+	product.SaleEndsAt = endsAt
+	product.UpdatedAt = time.Now()
+	product.UpdatedBy = updatedBy
+	pr.store.products[id] = product
+
+	return nil
+}
+
+func (pr *productRepository) UpdateDimensions(ctx context.Context, id int64, weight int64, length int64, width int64, height int64, updatedBy string) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	// products_dimensions_check
+	if weight < 0 || length < 0 || width < 0 || height < 0 {
+		return common.NewError(errors.New("dimensions must not be negative"), common.ErrValidation)
+	}
+
+	product.Weight = weight
+	product.Length = length
+	product.Width = width
+	product.Height = height
+	product.UpdatedAt = time.Now()
+	product.UpdatedBy = updatedBy
+	pr.store.products[id] = product
+
+	return nil
+}
+
+func (pr *productRepository) UpdateRating(ctx context.Context, id int64, average float64, count int64) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	product, exists := pr.store.products[id]
+
+	if !exists {
+		return nil
+	}
+
+	product.RatingAverage = average
+	product.ReviewCount = count
+	pr.store.products[id] = product
+
+	return nil
+}
+
+func (pr *productRepository) CheckById(ctx context.Context, id int64) (bool, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	_, exists := pr.store.products[id]
+
+	return exists, nil
+}
+
+func (pr *productRepository) Update(ctx context.Context, product entity.Product) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	err := validateProduct(product)
+	if err != nil {
+		return err
+	}
+
+	pr.store.products[product.ID] = product
+
+	return nil
+}
+
+// validateProduct mirrors the products table check constraints
+func validateProduct(product entity.Product) error {
+
+	if product.Stock < 0 || product.Price < 0 {
+		return common.NewError(errors.New("product stock and price must not be negative"), common.ErrValidation)
+	}
+
+	return nil
+}
+
+// soldQuantitiesLocked sums ordered quantities per product, cancelled orders excluded
+func (pr *productRepository) soldQuantitiesLocked() map[int64]int64 {
+
+	sold := make(map[int64]int64)
+
+	for _, orderItem := range pr.store.orderItems {
+
+		order, exists := pr.store.orders[orderItem.OrderReference]
+
+		if !exists || order.Status == constant.OrderStatusCancelled || order.DeletedAt != nil {
+			continue
+		}
+
+		sold[orderItem.ProductID] += orderItem.Quantity
+	}
+
+	return sold
+}
+
+func (pr *productRepository) filterLocked(filter model.ProductFilter, at time.Time) []entity.Product {
+
+	categories := make(map[int64]bool, len(filter.CategoryIDs))
+	for _, id := range filter.CategoryIDs {
+		categories[id] = true
+	}
+
+	var products []entity.Product
+
+	for _, product := range pr.store.products {
+
+		if product.DeletedAt != nil {
+			continue
+		}
+
+		if filter.Name != "" && !containsFold(product.Name, filter.Name) {
+			continue
+		}
+
+		if filter.IsActive && !product.IsActive {
+			continue
+		}
+
+		listingPrice, sold := pr.listingPriceLocked(product, filter.Currency, at)
+		if !sold {
+			continue
+		}
+
+		product.ListingPrice = listingPrice
+
+		// Prices are filtered on the listing price, the sale price while the sale runs
+		if filter.MinPrice != nil && listingPrice < *filter.MinPrice {
+			continue
+		}
+
+		if filter.MaxPrice != nil && listingPrice > *filter.MaxPrice {
+			continue
+		}
+
+		if len(categories) > 0 && !categories[product.CategoryID] {
+			continue
+		}
+
+		if filter.InStockOnly && product.Stock <= 0 {
+			continue
+		}
+
+		products = append(products, product)
+	}
+
+	return products
+}
+
+// listingPriceLocked prices the product in currency, its own price or else its price list entry
+func (pr *productRepository) listingPriceLocked(product entity.Product, currency string, at time.Time) (int64, bool) {
+
+	if product.Currency == currency {
+		return product.PriceAt(at), true
+	}
+
+	for _, price := range pr.store.priceList {
+		if price.ProductID == product.ID && price.VariantID == nil && price.Currency == currency {
+			return price.Price, true
+		}
+	}
+
+	return 0, false
+}
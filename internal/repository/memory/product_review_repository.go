@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type productReviewRepository struct {
+	store *Store
+}
+
+func (prr *productReviewRepository) Create(ctx context.Context, review entity.ProductReview) (entity.ProductReview, error) {
+
+	prr.store.mu.Lock()
+	defer prr.store.mu.Unlock()
+
+	if _, exists := prr.store.products[review.ProductID]; !exists {
+		return review, common.NewError(errors.New("product does not exist"), common.ErrValidation)
+	}
+
+	if _, exists := prr.store.accounts[review.AccountUsername]; !exists {
+		return review, common.NewError(errors.New("account does not exist"), common.ErrValidation)
+	}
+
+	err := validateReview(review)
+	if err != nil {
+		return review, err
+	}
+
+	for _, existing := range prr.store.reviews {
+		if existing.ProductID == review.ProductID && existing.AccountUsername == review.AccountUsername {
+			return review, common.NewError(errors.New("duplicate review"), common.ErrConflict)
+		}
+	}
+
+	prr.store.reviewSeq++
+	review.ID = prr.store.reviewSeq
+	prr.store.reviews[review.ID] = review
+
+	return review, nil
+}
+
+func (prr *productReviewRepository) FindByID(ctx context.Context, id int64) (entity.ProductReview, error) {
+
+	prr.store.mu.Lock()
+	defer prr.store.mu.Unlock()
+
+	review, exists := prr.store.reviews[id]
+
+	if !exists {
+		return review, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return review, nil
+}
+
+func (prr *productReviewRepository) FindWithFilters(ctx context.Context, filter model.ReviewFilter, pagination model.PaginationParams) ([]entity.ProductReview, int64, error) {
+
+	prr.store.mu.Lock()
+	defer prr.store.mu.Unlock()
+
+	var reviews []entity.ProductReview
+
+	for _, review := range prr.store.reviews {
+
+		if filter.ProductID != 0 && review.ProductID != filter.ProductID {
+			continue
+		}
+
+		if filter.AccountUsername != "" && review.AccountUsername != filter.AccountUsername {
+			continue
+		}
+
+		if filter.Status != "" && review.Status != filter.Status {
+			continue
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	// Newest first, the id breaks ties
+	slices.SortFunc(reviews, func(a entity.ProductReview, b entity.ProductReview) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	total := int64(len(reviews))
+
+	if pagination.IsPaginate {
+		reviews = paginate(reviews, pagination)
+	}
+
+	return reviews, total, nil
+}
+
+func (prr *productReviewRepository) Update(ctx context.Context, review entity.ProductReview) error {
+
+	prr.store.mu.Lock()
+	defer prr.store.mu.Unlock()
+
+	existing, exists := prr.store.reviews[review.ID]
+
+	if !exists {
+		return nil
+	}
+
+	err := validateReview(review)
+	if err != nil {
+		return err
+	}
+
+	existing.Rating = review.Rating
+	existing.Body = review.Body
+	existing.Status = review.Status
+	existing.ModerationNote = review.ModerationNote
+	existing.ModeratedAt = review.ModeratedAt
+	existing.ModeratedBy = review.ModeratedBy
+	existing.UpdatedAt = review.UpdatedAt
+	existing.UpdatedBy = review.UpdatedBy
+	prr.store.reviews[review.ID] = existing
+
+	return nil
+}
+
+func (prr *productReviewRepository) Delete(ctx context.Context, id int64) error {
+
+	prr.store.mu.Lock()
+	defer prr.store.mu.Unlock()
+
+	delete(prr.store.reviews, id)
+
+	return nil
+}
+
+func (prr *productReviewRepository) SummarizePublished(ctx context.Context, productID int64) (float64, int64, error) {
+
+	prr.store.mu.Lock()
+	defer prr.store.mu.Unlock()
+
+	var sum, count int64
+
+	for _, review := range prr.store.reviews {
+		if review.ProductID == productID && review.Status == constant.ReviewStatusPublished {
+			sum += int64(review.Rating)
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, 0, nil
+	}
+
+	return math.Round(float64(sum)/float64(count)*100) / 100, count, nil
+}
+
+// validateReview mirrors the product_reviews table check constraints
+func validateReview(review entity.ProductReview) error {
+
+	if review.Rating < constant.ReviewRatingMin || review.Rating > constant.ReviewRatingMax {
+		return common.NewError(errors.New("rating out of range"), common.ErrValidation)
+	}
+
+	if review.Status != constant.ReviewStatusPublished && review.Status != constant.ReviewStatusHidden {
+		return common.NewError(errors.New("invalid review status"), common.ErrValidation)
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+)
+
+/**
+	Approximations of the postgres search used by the gorm repository :
+	- Full text : every term prefix-matches a word of the name or description, name matches rank higher
+	- Fuzzy     : pg_trgm style word similarity between the text and the product name
+**/
+
+const fuzzySimilarityThreshold = 0.3
+
+func (pr *productRepository) SearchFullText(
+	ctx context.Context,
+	terms []string,
+	filter model.ProductFilter,
+	pagination model.PaginationParams) ([]repository.ProductSearchHit, int64, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	var hits []repository.ProductSearchHit
+
+	for _, product := range pr.filterLocked(filter, time.Now()) {
+
+		nameWords := words(product.Name)
+		descriptionWords := words(product.Description)
+		rank := 0.0
+		matched := true
+
+		for _, term := range terms {
+
+			switch {
+			case anyHasPrefix(nameWords, term):
+				rank += 1.0
+			case anyHasPrefix(descriptionWords, term):
+				rank += 0.4
+			default:
+				matched = false
+			}
+		}
+
+		if !matched {
+			continue
+		}
+
+		hits = append(hits, repository.ProductSearchHit{
+			Product:            product,
+			Rank:               rank,
+			NameHighlight:      highlight(product.Name, terms),
+			DescriptionSnippet: highlight(product.Description, terms),
+		})
+	}
+
+	return sortAndPaginateHits(hits, pagination), int64(len(hits)), nil
+}
+
+func (pr *productRepository) SearchFuzzy(
+	ctx context.Context,
+	text string,
+	filter model.ProductFilter,
+	pagination model.PaginationParams) ([]repository.ProductSearchHit, int64, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	var hits []repository.ProductSearchHit
+
+	for _, product := range pr.filterLocked(filter, time.Now()) {
+
+		similarity := wordSimilarity(text, product.Name)
+
+		if similarity < fuzzySimilarityThreshold {
+			continue
+		}
+
+		snippet := product.Description
+		if len(snippet) > 160 {
+			snippet = snippet[:160]
+		}
+
+		hits = append(hits, repository.ProductSearchHit{
+			Product:            product,
+			Rank:               similarity,
+			NameHighlight:      product.Name,
+			DescriptionSnippet: snippet,
+		})
+	}
+
+	return sortAndPaginateHits(hits, pagination), int64(len(hits)), nil
+}
+
+func sortAndPaginateHits(hits []repository.ProductSearchHit, pagination model.PaginationParams) []repository.ProductSearchHit {
+
+	sort.Slice(hits, func(i, j int) bool {
+
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+
+		return hits[i].Product.ID < hits[j].Product.ID
+	})
+
+	if pagination.IsPaginate {
+		return paginate(hits, pagination)
+	}
+
+	return hits
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func anyHasPrefix(words []string, prefix string) bool {
+
+	for _, word := range words {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// highlight wraps every word starting with one of the terms in <mark></mark>
+func highlight(text string, terms []string) string {
+
+	var builder strings.Builder
+	var word strings.Builder
+
+	flush := func() {
+
+		if word.Len() == 0 {
+			return
+		}
+
+		if matchesAnyTerm(strings.ToLower(word.String()), terms) {
+			builder.WriteString("<mark>" + word.String() + "</mark>")
+		} else {
+			builder.WriteString(word.String())
+		}
+
+		word.Reset()
+	}
+
+	for _, r := range text {
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word.WriteRune(r)
+			continue
+		}
+
+		flush()
+		builder.WriteRune(r)
+	}
+
+	flush()
+
+	return builder.String()
+}
+
+func matchesAnyTerm(word string, terms []string) bool {
+
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trigrams follows pg_trgm : lower case words padded with two spaces before and one after
+func trigrams(text string) map[string]bool {
+
+	set := make(map[string]bool)
+
+	for _, word := range words(text) {
+
+		padded := []rune("  " + word + " ")
+
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+
+	return set
+}
+
+// similarity is the share of the text trigrams found in the target, like pg_trgm word_similarity
+func similarity(text map[string]bool, target map[string]bool) float64 {
+
+	if len(text) == 0 {
+		return 0
+	}
+
+	common := 0
+	for trigram := range text {
+		if target[trigram] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(text))
+}
+
+// wordSimilarity is the best similarity between text and any run of consecutive words of target
+func wordSimilarity(text string, target string) float64 {
+
+	textTrigrams := trigrams(text)
+	targetWords := words(target)
+	best := 0.0
+
+	for start := range targetWords {
+		for end := start + 1; end <= len(targetWords); end++ {
+
+			score := similarity(textTrigrams, trigrams(strings.Join(targetWords[start:end], " ")))
+
+			if score > best {
+				best = score
+			}
+		}
+	}
+
+	return best
+}
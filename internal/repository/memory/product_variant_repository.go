@@ -0,0 +1,291 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type productVariantRepository struct {
+	store *Store
+}
+
+func (pvr *productVariantRepository) FindByProductID(ctx context.Context, productID int64) ([]entity.ProductVariant, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	var variants []entity.ProductVariant
+
+	for _, variant := range pvr.store.variants {
+		if variant.ProductID == productID && variant.DeletedAt == nil {
+			variants = append(variants, pvr.withOptionsLocked(variant))
+		}
+	}
+
+	sortVariants(variants)
+
+	return variants, nil
+}
+
+func (pvr *productVariantRepository) FindByIDs(ctx context.Context, ids []int64) ([]entity.ProductVariant, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	seen := make(map[int64]bool, len(ids))
+	var variants []entity.ProductVariant
+
+	for _, id := range ids {
+
+		variant, exists := pvr.store.variants[id]
+
+		if exists && variant.DeletedAt == nil && !seen[id] {
+			seen[id] = true
+			variants = append(variants, pvr.withOptionsLocked(variant))
+		}
+	}
+
+	sortVariants(variants)
+
+	return variants, nil
+}
+
+func (pvr *productVariantRepository) FindByID(ctx context.Context, id int64) (entity.ProductVariant, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	variant, exists := pvr.store.variants[id]
+
+	if !exists || variant.DeletedAt != nil {
+		return entity.ProductVariant{}, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return pvr.withOptionsLocked(variant), nil
+}
+
+// LockByIDs reads like FindByIDs, transactions already hold every row
+func (pvr *productVariantRepository) LockByIDs(ctx context.Context, ids []int64) ([]entity.ProductVariant, error) {
+	return pvr.FindByIDs(ctx, ids)
+}
+
+func (pvr *productVariantRepository) DecrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	variant, exists := pvr.store.variants[id]
+
+	if !exists || variant.Stock < quantity {
+		return 0, common.NewError(fmt.Errorf("insufficient stock for variant: %v , requested : %v", id, quantity), common.ErrValidation)
+	}
+
+	variant.Stock -= quantity
+	variant.UpdatedAt = time.Now()
+	variant.UpdatedBy = updatedBy
+	pvr.store.variants[id] = variant
+
+	return variant.Stock, nil
+}
+
+func (pvr *productVariantRepository) IncrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	variant, exists := pvr.store.variants[id]
+
+	if !exists {
+		return 0, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	variant.Stock += quantity
+	variant.UpdatedAt = time.Now()
+	variant.UpdatedBy = updatedBy
+	pvr.store.variants[id] = variant
+
+	return variant.Stock, nil
+}
+
+func (pvr *productVariantRepository) FindOptionTypesByProductID(ctx context.Context, productID int64) ([]entity.ProductOptionType, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	var optionTypes []entity.ProductOptionType
+
+	for _, optionType := range pvr.store.optionTypes {
+		if optionType.ProductID == productID {
+			optionTypes = append(optionTypes, optionType)
+		}
+	}
+
+	slices.SortFunc(optionTypes, compareOptionTypes)
+
+	return optionTypes, nil
+}
+
+// Create fails on a SKU already taken, soft deleted variants included, like the unique constraint
+func (pvr *productVariantRepository) Create(ctx context.Context, variant entity.ProductVariant) (entity.ProductVariant, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	if _, exists := pvr.store.products[variant.ProductID]; !exists {
+		return variant, common.NewError(errors.New("variant product does not exist"), common.ErrValidation)
+	}
+
+	for _, existing := range pvr.store.variants {
+		if existing.SKU == variant.SKU {
+			return variant, common.NewError(errors.New("duplicate variant sku"), common.ErrConflict)
+		}
+	}
+
+	for _, option := range variant.Options {
+		if _, exists := pvr.store.optionTypes[option.OptionTypeID]; !exists {
+			return variant, common.NewError(errors.New("variant option type does not exist"), common.ErrValidation)
+		}
+	}
+
+	pvr.store.variantSeq++
+	variant.ID = pvr.store.variantSeq
+
+	options := make([]entity.ProductVariantOption, len(variant.Options))
+
+	for i, option := range variant.Options {
+		options[i] = entity.ProductVariantOption{
+			VariantID:    variant.ID,
+			OptionTypeID: option.OptionTypeID,
+			Value:        option.Value,
+		}
+	}
+
+	variant.Options = options
+	pvr.store.variants[variant.ID] = variant
+
+	return variant, nil
+}
+
+func (pvr *productVariantRepository) Update(ctx context.Context, variant entity.ProductVariant) error {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	existing, exists := pvr.store.variants[variant.ID]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	for _, other := range pvr.store.variants {
+		if other.ID != variant.ID && other.SKU == variant.SKU {
+			return common.NewError(errors.New("duplicate variant sku"), common.ErrConflict)
+		}
+	}
+
+	existing.SKU = variant.SKU
+	existing.Price = variant.Price
+	existing.IsActive = variant.IsActive
+	existing.UpdatedAt = variant.UpdatedAt
+	existing.UpdatedBy = variant.UpdatedBy
+	pvr.store.variants[variant.ID] = existing
+
+	return nil
+}
+
+// CreateOptionType fails on a name the product already uses
+func (pvr *productVariantRepository) CreateOptionType(ctx context.Context, optionType entity.ProductOptionType) (entity.ProductOptionType, error) {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	if _, exists := pvr.store.products[optionType.ProductID]; !exists {
+		return optionType, common.NewError(errors.New("option type product does not exist"), common.ErrValidation)
+	}
+
+	if pvr.optionTypeNameTakenLocked(optionType) {
+		return optionType, common.NewError(errors.New("duplicate option type name"), common.ErrConflict)
+	}
+
+	pvr.store.optionTypeSeq++
+	optionType.ID = pvr.store.optionTypeSeq
+	pvr.store.optionTypes[optionType.ID] = optionType
+
+	return optionType, nil
+}
+
+func (pvr *productVariantRepository) UpdateOptionType(ctx context.Context, optionType entity.ProductOptionType) error {
+
+	pvr.store.mu.Lock()
+	defer pvr.store.mu.Unlock()
+
+	existing, exists := pvr.store.optionTypes[optionType.ID]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	existing.Name = optionType.Name
+	existing.Position = optionType.Position
+
+	if pvr.optionTypeNameTakenLocked(existing) {
+		return common.NewError(errors.New("duplicate option type name"), common.ErrConflict)
+	}
+
+	pvr.store.optionTypes[optionType.ID] = existing
+
+	return nil
+}
+
+func (pvr *productVariantRepository) optionTypeNameTakenLocked(optionType entity.ProductOptionType) bool {
+
+	for _, existing := range pvr.store.optionTypes {
+		if existing.ID != optionType.ID && existing.ProductID == optionType.ProductID && existing.Name == optionType.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withOptionsLocked returns a copy of the variant with option types filled, in option type order
+func (pvr *productVariantRepository) withOptionsLocked(variant entity.ProductVariant) entity.ProductVariant {
+
+	options := make([]entity.ProductVariantOption, len(variant.Options))
+
+	for i, option := range variant.Options {
+		option.OptionType = pvr.store.optionTypes[option.OptionTypeID]
+		options[i] = option
+	}
+
+	slices.SortFunc(options, func(a entity.ProductVariantOption, b entity.ProductVariantOption) int {
+		return compareOptionTypes(a.OptionType, b.OptionType)
+	})
+
+	variant.Options = options
+
+	return variant
+}
+
+func compareOptionTypes(a entity.ProductOptionType, b entity.ProductOptionType) int {
+
+	if a.Position != b.Position {
+		return cmp.Compare(a.Position, b.Position)
+	}
+
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func sortVariants(variants []entity.ProductVariant) {
+	slices.SortFunc(variants, func(a entity.ProductVariant, b entity.ProductVariant) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
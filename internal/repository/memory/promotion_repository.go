@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type promotionRepository struct {
+	store *Store
+}
+
+func (pr *promotionRepository) Create(ctx context.Context, promotion entity.Promotion) (entity.Promotion, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	for _, existing := range pr.store.promotions {
+		if existing.Code == promotion.Code {
+			return promotion, common.NewError(errors.New("duplicate promotion code"), common.ErrConflict)
+		}
+	}
+
+	if promotion.ProductID != nil {
+		if _, exists := pr.store.products[*promotion.ProductID]; !exists {
+			return promotion, common.NewError(errors.New("product does not exist"), common.ErrValidation)
+		}
+	}
+
+	pr.store.promotionSeq++
+	promotion.ID = pr.store.promotionSeq
+	pr.store.promotions[promotion.ID] = promotion
+
+	return promotion, nil
+}
+
+func (pr *promotionRepository) FindByID(ctx context.Context, id int64) (entity.Promotion, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	promotion, exists := pr.store.promotions[id]
+
+	if !exists {
+		return promotion, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return promotion, nil
+}
+
+func (pr *promotionRepository) FindByCode(ctx context.Context, code string) (entity.Promotion, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	for _, promotion := range pr.store.promotions {
+		if promotion.Code == code {
+			return promotion, nil
+		}
+	}
+
+	return entity.Promotion{}, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+}
+
+func (pr *promotionRepository) FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.Promotion, int64, error) {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	promotions := slices.Collect(maps.Values(pr.store.promotions))
+
+	slices.SortFunc(promotions, func(a entity.Promotion, b entity.Promotion) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	total := int64(len(promotions))
+
+	if pagination.IsPaginate {
+		promotions = paginate(promotions, pagination)
+	}
+
+	return promotions, total, nil
+}
+
+func (pr *promotionRepository) Update(ctx context.Context, promotion entity.Promotion) error {
+
+	pr.store.mu.Lock()
+	defer pr.store.mu.Unlock()
+
+	existing, exists := pr.store.promotions[promotion.ID]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	existing.Description = promotion.Description
+	existing.UsageLimit = promotion.UsageLimit
+	existing.PerAccountLimit = promotion.PerAccountLimit
+	existing.StartsAt = promotion.StartsAt
+	existing.EndsAt = promotion.EndsAt
+	existing.IsActive = promotion.IsActive
+	existing.UpdatedAt = promotion.UpdatedAt
+	existing.UpdatedBy = promotion.UpdatedBy
+	pr.store.promotions[promotion.ID] = existing
+
+	return nil
+}
+
+type orderDiscountRepository struct {
+	store *Store
+}
+
+func (odr *orderDiscountRepository) CreateBatch(ctx context.Context, discounts []entity.OrderDiscount) error {
+
+	odr.store.mu.Lock()
+	defer odr.store.mu.Unlock()
+
+	for _, discount := range discounts {
+
+		if _, exists := odr.store.orders[discount.OrderReference]; !exists {
+			return common.NewError(errors.New("order does not exist"), common.ErrValidation)
+		}
+
+		if _, exists := odr.store.promotions[discount.PromotionID]; !exists {
+			return common.NewError(errors.New("promotion does not exist"), common.ErrValidation)
+		}
+
+		if discount.Amount < 0 {
+			return common.NewError(errors.New("discount amount must not be negative"), common.ErrValidation)
+		}
+	}
+
+	for _, discount := range discounts {
+		odr.store.discountSeq++
+		discount.ID = odr.store.discountSeq
+		odr.store.discounts = append(odr.store.discounts, discount)
+	}
+
+	return nil
+}
+
+func (odr *orderDiscountRepository) FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderDiscount, error) {
+
+	odr.store.mu.Lock()
+	defer odr.store.mu.Unlock()
+
+	return discountsOfOrderLocked(odr.store, orderReference), nil
+}
+
+func (odr *orderDiscountRepository) UpdateAmount(ctx context.Context, id int64, amount int64) error {
+
+	odr.store.mu.Lock()
+	defer odr.store.mu.Unlock()
+
+	if amount < 0 {
+		return common.NewError(errors.New("discount amount must not be negative"), common.ErrValidation)
+	}
+
+	for i := range odr.store.discounts {
+		if odr.store.discounts[i].ID == id {
+			odr.store.discounts[i].Amount = amount
+			return nil
+		}
+	}
+
+	return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+}
+
+func (odr *orderDiscountRepository) CountRedemptions(ctx context.Context, promotionID int64, username string) (int64, int64, error) {
+
+	odr.store.mu.Lock()
+	defer odr.store.mu.Unlock()
+
+	total, byAccount := int64(0), int64(0)
+
+	for _, discount := range odr.store.discounts {
+
+		order := odr.store.orders[discount.OrderReference]
+
+		if discount.PromotionID != promotionID || order.Status == constant.OrderStatusCancelled {
+			continue
+		}
+
+		total++
+
+		if order.IsOwnedBy(username) {
+			byAccount++
+		}
+	}
+
+	return total, byAccount, nil
+}
+
+func discountsOfOrderLocked(store *Store, orderReference string) []entity.OrderDiscount {
+
+	var discounts []entity.OrderDiscount
+
+	for _, discount := range store.discounts {
+		if discount.OrderReference == orderReference {
+			discounts = append(discounts, discount)
+		}
+	}
+
+	return discounts
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type shipmentRepository struct {
+	store *Store
+}
+
+func (sr *shipmentRepository) Create(ctx context.Context, shipment entity.Shipment) error {
+
+	sr.store.mu.Lock()
+	defer sr.store.mu.Unlock()
+
+	if _, exists := sr.store.shipments[shipment.ShipmentReference]; exists {
+		return common.NewError(errors.New("duplicate shipment reference"), common.ErrConflict)
+	}
+
+	if _, exists := sr.store.orders[shipment.OrderReference]; !exists {
+		return common.NewError(errors.New("shipment order does not exist"), common.ErrValidation)
+	}
+
+	err := sr.checkTrackingLocked(shipment)
+	if err != nil {
+		return err
+	}
+
+	items := make([]entity.ShipmentItem, len(shipment.Items))
+
+	for i, item := range shipment.Items {
+
+		if _, exists := sr.store.orderItems[item.OrderItemReference]; !exists {
+			return common.NewError(errors.New("shipment order item does not exist"), common.ErrValidation)
+		}
+
+		if item.Quantity <= 0 {
+			return common.NewError(errors.New("shipment quantity must be greater than 0"), common.ErrValidation)
+		}
+
+		items[i] = item
+		items[i].ShipmentReference = shipment.ShipmentReference
+	}
+
+	for i := range items {
+		sr.store.shipmentItemSeq++
+		items[i].ID = sr.store.shipmentItemSeq
+	}
+
+	shipment.Items = items
+	sr.store.shipments[shipment.ShipmentReference] = shipment
+
+	return nil
+}
+
+func (sr *shipmentRepository) FindByReference(ctx context.Context, shipmentReference string) (entity.Shipment, error) {
+
+	sr.store.mu.Lock()
+	defer sr.store.mu.Unlock()
+
+	shipment, exists := sr.store.shipments[shipmentReference]
+
+	if !exists {
+		return shipment, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	shipment.Items = slices.Clone(shipment.Items)
+
+	return shipment, nil
+}
+
+func (sr *shipmentRepository) Update(ctx context.Context, shipment entity.Shipment) error {
+
+	sr.store.mu.Lock()
+	defer sr.store.mu.Unlock()
+
+	existing, exists := sr.store.shipments[shipment.ShipmentReference]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	existing.Carrier = shipment.Carrier
+	existing.TrackingNumber = shipment.TrackingNumber
+	existing.ShippedAt = shipment.ShippedAt
+	existing.DeliveredAt = shipment.DeliveredAt
+	existing.UpdatedAt = shipment.UpdatedAt
+	existing.UpdatedBy = shipment.UpdatedBy
+
+	err := sr.checkTrackingLocked(existing)
+	if err != nil {
+		return err
+	}
+
+	sr.store.shipments[shipment.ShipmentReference] = existing
+
+	return nil
+}
+
+// checkTrackingLocked mirrors the unique index on the carrier and the tracking number, and the delivery check
+func (sr *shipmentRepository) checkTrackingLocked(shipment entity.Shipment) error {
+
+	if shipment.DeliveredAt != nil && shipment.DeliveredAt.Before(shipment.ShippedAt) {
+		return common.NewError(errors.New("shipment delivered before it shipped"), common.ErrValidation)
+	}
+
+	for _, existing := range sr.store.shipments {
+
+		if existing.ShipmentReference == shipment.ShipmentReference {
+			continue
+		}
+
+		if strings.EqualFold(existing.Carrier, shipment.Carrier) && existing.TrackingNumber == shipment.TrackingNumber {
+			return common.NewError(errors.New("duplicate tracking number"), common.ErrConflict)
+		}
+	}
+
+	return nil
+}
+
+func shipmentsOfOrderLocked(store *Store, orderReference string) []entity.Shipment {
+
+	var shipments []entity.Shipment
+
+	for _, shipment := range store.shipments {
+		if shipment.OrderReference == orderReference {
+			shipment.Items = slices.Clone(shipment.Items)
+			shipments = append(shipments, shipment)
+		}
+	}
+
+	slices.SortFunc(shipments, func(a entity.Shipment, b entity.Shipment) int {
+		return cmp.Or(a.ShippedAt.Compare(b.ShippedAt), cmp.Compare(a.ShipmentReference, b.ShipmentReference))
+	})
+
+	return shipments
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type shippingMethodRepository struct {
+	store *Store
+}
+
+func (smr *shippingMethodRepository) Create(ctx context.Context, method entity.ShippingMethod) (entity.ShippingMethod, error) {
+
+	smr.store.mu.Lock()
+	defer smr.store.mu.Unlock()
+
+	for _, existing := range smr.store.shippingMethods {
+		if existing.Code == method.Code {
+			return method, common.NewError(errors.New("duplicate shipping method code"), common.ErrConflict)
+		}
+	}
+
+	smr.store.shippingMethodSeq++
+	method.ID = smr.store.shippingMethodSeq
+	method.Tiers = slices.Clone(method.Tiers)
+	smr.store.shippingMethods[method.ID] = method
+
+	return method, nil
+}
+
+func (smr *shippingMethodRepository) FindByID(ctx context.Context, id int64) (entity.ShippingMethod, error) {
+
+	smr.store.mu.Lock()
+	defer smr.store.mu.Unlock()
+
+	method, exists := smr.store.shippingMethods[id]
+
+	if !exists {
+		return method, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	method.Tiers = slices.Clone(method.Tiers)
+
+	return method, nil
+}
+
+func (smr *shippingMethodRepository) FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.ShippingMethod, int64, error) {
+
+	smr.store.mu.Lock()
+	defer smr.store.mu.Unlock()
+
+	methods := slices.Collect(maps.Values(smr.store.shippingMethods))
+
+	slices.SortFunc(methods, func(a entity.ShippingMethod, b entity.ShippingMethod) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	total := int64(len(methods))
+
+	if pagination.IsPaginate {
+		methods = paginate(methods, pagination)
+	}
+
+	return methods, total, nil
+}
+
+func (smr *shippingMethodRepository) FindActive(ctx context.Context) ([]entity.ShippingMethod, error) {
+
+	smr.store.mu.Lock()
+	defer smr.store.mu.Unlock()
+
+	var methods []entity.ShippingMethod
+
+	for _, id := range slices.Sorted(maps.Keys(smr.store.shippingMethods)) {
+		if method := smr.store.shippingMethods[id]; method.IsActive {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods, nil
+}
+
+func (smr *shippingMethodRepository) Update(ctx context.Context, method entity.ShippingMethod) error {
+
+	smr.store.mu.Lock()
+	defer smr.store.mu.Unlock()
+
+	existing, exists := smr.store.shippingMethods[method.ID]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	existing.Name = method.Name
+	existing.FlatFee = method.FlatFee
+	existing.Tiers = slices.Clone(method.Tiers)
+	existing.FreeAbove = method.FreeAbove
+	existing.MaxWeight = method.MaxWeight
+	existing.IsActive = method.IsActive
+	existing.UpdatedAt = method.UpdatedAt
+	existing.UpdatedBy = method.UpdatedBy
+	smr.store.shippingMethods[method.ID] = existing
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"gorm.io/gorm"
+)
+
+type stockSubscriptionRepository struct {
+	store *Store
+}
+
+func (ssr *stockSubscriptionRepository) Create(ctx context.Context, subscription entity.StockSubscription) (entity.StockSubscription, error) {
+
+	ssr.store.mu.Lock()
+	defer ssr.store.mu.Unlock()
+
+	if _, exists := ssr.store.products[subscription.ProductID]; !exists {
+		return subscription, common.NewError(errors.New("product does not exist"), common.ErrValidation)
+	}
+
+	if _, exists := ssr.store.accounts[subscription.AccountUsername]; !exists {
+		return subscription, common.NewError(errors.New("account does not exist"), common.ErrValidation)
+	}
+
+	for _, existing := range ssr.store.subscriptions {
+		if existing.NotifiedAt == nil && existing.ProductID == subscription.ProductID && existing.AccountUsername == subscription.AccountUsername {
+			return subscription, common.NewError(errors.New("duplicate pending stock subscription"), common.ErrConflict)
+		}
+	}
+
+	ssr.store.subscriptionSeq++
+	subscription.ID = ssr.store.subscriptionSeq
+
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now()
+	}
+
+	ssr.store.subscriptions[subscription.ID] = subscription
+
+	return subscription, nil
+}
+
+func (ssr *stockSubscriptionRepository) DeletePending(ctx context.Context, productID int64, username string) error {
+
+	ssr.store.mu.Lock()
+	defer ssr.store.mu.Unlock()
+
+	for id, subscription := range ssr.store.subscriptions {
+		if subscription.NotifiedAt == nil && subscription.ProductID == productID && subscription.AccountUsername == username {
+			delete(ssr.store.subscriptions, id)
+			return nil
+		}
+	}
+
+	return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+}
+
+func (ssr *stockSubscriptionRepository) FindPendingByAccount(ctx context.Context, username string) ([]entity.StockSubscription, error) {
+
+	ssr.store.mu.Lock()
+	defer ssr.store.mu.Unlock()
+
+	var subscriptions []entity.StockSubscription
+
+	for _, subscription := range ssr.store.subscriptions {
+		if subscription.NotifiedAt == nil && subscription.AccountUsername == username {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	slices.SortFunc(subscriptions, func(a entity.StockSubscription, b entity.StockSubscription) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return subscriptions, nil
+}
+
+func (ssr *stockSubscriptionRepository) ClaimPending(ctx context.Context, productID int64, notifiedAt time.Time) ([]entity.StockSubscription, error) {
+
+	ssr.store.mu.Lock()
+	defer ssr.store.mu.Unlock()
+
+	var subscriptions []entity.StockSubscription
+
+	for id, subscription := range ssr.store.subscriptions {
+		if subscription.NotifiedAt == nil && subscription.ProductID == productID {
+			subscription.NotifiedAt = &notifiedAt
+			ssr.store.subscriptions[id] = subscription
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	slices.SortFunc(subscriptions, func(a entity.StockSubscription, b entity.StockSubscription) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return subscriptions, nil
+}
@@ -0,0 +1,428 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+)
+
+/*
+*
+
+	Store is an in-memory database honoring the semantics the services rely on :
+	- Missing rows return ErrResourceNotFound, duplicate keys ErrConflict
+	- Broken references and negative stock return ErrValidation, like the table constraints
+	- Transactions are serialized (as if every row were locked FOR UPDATE)
+	  and fully rolled back when the callback returns an error or panics
+
+	Transactions are not reentrant, nested WithinTransaction calls deadlock.
+
+*
+*/
+type Store struct {
+
+	// txMu serializes transactions, mu guards the tables
+	txMu sync.Mutex
+	mu   sync.Mutex
+
+	accountSeq int64
+	accounts   map[string]entity.Account
+	products   map[int64]entity.Product
+	orders     map[string]entity.Order
+	orderItems map[string]entity.OrderItem
+	payments   map[string]entity.Payment
+
+	optionTypeSeq int64
+	optionTypes   map[int64]entity.ProductOptionType
+	variantSeq    int64
+	variants      map[int64]entity.ProductVariant
+
+	imageSeq int64
+	images   map[int64]entity.ProductImage
+
+	movementSeq int64
+	movements   []entity.InventoryMovement
+
+	subscriptionSeq int64
+	subscriptions   map[int64]entity.StockSubscription
+
+	reviewSeq int64
+	reviews   map[int64]entity.ProductReview
+
+	wishlistSeq int64
+	wishlist    map[int64]entity.WishlistItem
+
+	promotionSeq int64
+	promotions   map[int64]entity.Promotion
+
+	discountSeq int64
+	discounts   []entity.OrderDiscount
+
+	priceChangeSeq int64
+	priceChanges   map[int64]entity.ProductPriceChange
+
+	priceHistorySeq int64
+	priceHistory    []entity.ProductPriceHistory
+
+	priceListSeq int64
+	priceList    map[int64]entity.ProductCurrencyPrice
+
+	taxRuleSeq int64
+	taxRules   map[int64]entity.TaxRule
+
+	itemTaxSeq int64
+	itemTaxes  []entity.OrderItemTax
+
+	shippingMethodSeq int64
+	shippingMethods   map[int64]entity.ShippingMethod
+
+	shipmentItemSeq int64
+	shipments       map[string]entity.Shipment
+
+	addressSeq int64
+	addresses  map[int64]entity.Address
+
+	historySeq int64
+	history    []entity.OrderHistory
+}
+
+var _ repository.TransactionRunner = (*Store)(nil)
+
+type snapshot struct {
+	accountSeq int64
+	accounts   map[string]entity.Account
+	products   map[int64]entity.Product
+	orders     map[string]entity.Order
+	orderItems map[string]entity.OrderItem
+	payments   map[string]entity.Payment
+
+	optionTypeSeq int64
+	optionTypes   map[int64]entity.ProductOptionType
+	variantSeq    int64
+	variants      map[int64]entity.ProductVariant
+
+	imageSeq int64
+	images   map[int64]entity.ProductImage
+
+	movementSeq int64
+	movements   []entity.InventoryMovement
+
+	subscriptionSeq int64
+	subscriptions   map[int64]entity.StockSubscription
+
+	reviewSeq int64
+	reviews   map[int64]entity.ProductReview
+
+	wishlistSeq int64
+	wishlist    map[int64]entity.WishlistItem
+
+	promotionSeq int64
+	promotions   map[int64]entity.Promotion
+
+	discountSeq int64
+	discounts   []entity.OrderDiscount
+
+	priceChangeSeq int64
+	priceChanges   map[int64]entity.ProductPriceChange
+
+	priceHistorySeq int64
+	priceHistory    []entity.ProductPriceHistory
+
+	priceListSeq int64
+	priceList    map[int64]entity.ProductCurrencyPrice
+
+	taxRuleSeq int64
+	taxRules   map[int64]entity.TaxRule
+
+	itemTaxSeq int64
+	itemTaxes  []entity.OrderItemTax
+
+	shippingMethodSeq int64
+	shippingMethods   map[int64]entity.ShippingMethod
+
+	shipmentItemSeq int64
+	shipments       map[string]entity.Shipment
+
+	addressSeq int64
+	addresses  map[int64]entity.Address
+
+	historySeq int64
+	history    []entity.OrderHistory
+}
+
+func NewStore() *Store {
+	return &Store{
+		accounts:   make(map[string]entity.Account),
+		products:   make(map[int64]entity.Product),
+		orders:     make(map[string]entity.Order),
+		orderItems: make(map[string]entity.OrderItem),
+		payments:   make(map[string]entity.Payment),
+
+		optionTypes: make(map[int64]entity.ProductOptionType),
+		variants:    make(map[int64]entity.ProductVariant),
+
+		images: make(map[int64]entity.ProductImage),
+
+		subscriptions: make(map[int64]entity.StockSubscription),
+
+		reviews: make(map[int64]entity.ProductReview),
+
+		wishlist: make(map[int64]entity.WishlistItem),
+
+		promotions: make(map[int64]entity.Promotion),
+
+		priceChanges: make(map[int64]entity.ProductPriceChange),
+
+		priceList: make(map[int64]entity.ProductCurrencyPrice),
+
+		taxRules: make(map[int64]entity.TaxRule),
+
+		shippingMethods: make(map[int64]entity.ShippingMethod),
+
+		shipments: make(map[string]entity.Shipment),
+
+		addresses: make(map[int64]entity.Address),
+	}
+}
+
+func (s *Store) Repositories() repository.Repositories {
+	return repository.Repositories{
+		Account:      &accountRepository{store: s},
+		Order:        &orderRepository{store: s},
+		OrderItem:    &orderItemRepository{store: s},
+		Payment:      &paymentRepository{store: s},
+		Product:      &productRepository{store: s},
+		Variant:      &productVariantRepository{store: s},
+		Image:        &productImageRepository{store: s},
+		Movement:     &inventoryMovementRepository{store: s},
+		Subscription: &stockSubscriptionRepository{store: s},
+		Review:       &productReviewRepository{store: s},
+		Wishlist:     &wishlistRepository{store: s},
+		Promotion:    &promotionRepository{store: s},
+		Discount:     &orderDiscountRepository{store: s},
+		PriceChange:  &productPriceChangeRepository{store: s},
+		PriceHistory: &productPriceHistoryRepository{store: s},
+		PriceList:    &productCurrencyPriceRepository{store: s},
+		TaxRule:      &taxRuleRepository{store: s},
+		ItemTax:      &orderItemTaxRepository{store: s},
+		Shipping:     &shippingMethodRepository{store: s},
+		Shipment:     &shipmentRepository{store: s},
+		Address:      &addressRepository{store: s},
+		History:      &orderHistoryRepository{store: s},
+	}
+}
+
+func (s *Store) WithinTransaction(ctx context.Context, fn func(repos repository.Repositories) error) (err error) {
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	before := s.snapshot()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.restore(before)
+			panic(r)
+		}
+
+		if err != nil {
+			s.restore(before)
+		}
+	}()
+
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return fn(s.Repositories())
+}
+
+// SeedProducts stores products as-is, products have no create operation in the repository,
+// a product without a currency is in the default currency like in the database
+func (s *Store) SeedProducts(products ...entity.Product) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, product := range products {
+
+		if product.Currency == "" {
+			product.Currency = constant.DefaultCurrency
+		}
+
+		s.products[product.ID] = product
+	}
+}
+
+// SeedMovements appends ledger entries as-is, ids are assigned in order
+func (s *Store) SeedMovements(movements ...entity.InventoryMovement) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, movement := range movements {
+		s.movementSeq++
+		movement.ID = s.movementSeq
+		s.movements = append(s.movements, movement)
+	}
+}
+
+// SeedOptionTypes stores product option types as-is, later created ones follow the highest id
+func (s *Store) SeedOptionTypes(optionTypes ...entity.ProductOptionType) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, optionType := range optionTypes {
+		s.optionTypes[optionType.ID] = optionType
+		s.optionTypeSeq = max(s.optionTypeSeq, optionType.ID)
+	}
+}
+
+// SeedVariants stores variants with their options, only VariantID, OptionTypeID and Value of the options are kept,
+// later created variants follow the highest id
+func (s *Store) SeedVariants(variants ...entity.ProductVariant) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, variant := range variants {
+
+		options := make([]entity.ProductVariantOption, len(variant.Options))
+
+		for i, option := range variant.Options {
+			options[i] = entity.ProductVariantOption{
+				VariantID:    variant.ID,
+				OptionTypeID: option.OptionTypeID,
+				Value:        option.Value,
+			}
+		}
+
+		variant.Options = options
+		s.variants[variant.ID] = variant
+		s.variantSeq = max(s.variantSeq, variant.ID)
+	}
+}
+
+func (s *Store) snapshot() snapshot {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return snapshot{
+		accountSeq: s.accountSeq,
+		accounts:   maps.Clone(s.accounts),
+		products:   maps.Clone(s.products),
+		orders:     maps.Clone(s.orders),
+		orderItems: maps.Clone(s.orderItems),
+		payments:   maps.Clone(s.payments),
+
+		optionTypeSeq: s.optionTypeSeq,
+		optionTypes:   maps.Clone(s.optionTypes),
+		variantSeq:    s.variantSeq,
+		variants:      maps.Clone(s.variants),
+
+		imageSeq: s.imageSeq,
+		images:   maps.Clone(s.images),
+
+		movementSeq: s.movementSeq,
+		movements:   slices.Clone(s.movements),
+
+		subscriptionSeq: s.subscriptionSeq,
+		subscriptions:   maps.Clone(s.subscriptions),
+
+		reviewSeq: s.reviewSeq,
+		reviews:   maps.Clone(s.reviews),
+
+		wishlistSeq: s.wishlistSeq,
+		wishlist:    maps.Clone(s.wishlist),
+
+		promotionSeq: s.promotionSeq,
+		promotions:   maps.Clone(s.promotions),
+
+		discountSeq: s.discountSeq,
+		discounts:   slices.Clone(s.discounts),
+
+		priceChangeSeq: s.priceChangeSeq,
+		priceChanges:   maps.Clone(s.priceChanges),
+
+		priceHistorySeq: s.priceHistorySeq,
+		priceHistory:    slices.Clone(s.priceHistory),
+
+		priceListSeq: s.priceListSeq,
+		priceList:    maps.Clone(s.priceList),
+
+		taxRuleSeq: s.taxRuleSeq,
+		taxRules:   maps.Clone(s.taxRules),
+
+		itemTaxSeq: s.itemTaxSeq,
+		itemTaxes:  slices.Clone(s.itemTaxes),
+
+		shippingMethodSeq: s.shippingMethodSeq,
+		shippingMethods:   maps.Clone(s.shippingMethods),
+
+		shipmentItemSeq: s.shipmentItemSeq,
+		shipments:       maps.Clone(s.shipments),
+
+		addressSeq: s.addressSeq,
+		addresses:  maps.Clone(s.addresses),
+
+		historySeq: s.historySeq,
+		history:    slices.Clone(s.history),
+	}
+}
+
+func (s *Store) restore(before snapshot) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accountSeq = before.accountSeq
+	s.accounts = before.accounts
+	s.products = before.products
+	s.orders = before.orders
+	s.orderItems = before.orderItems
+	s.payments = before.payments
+	s.optionTypeSeq = before.optionTypeSeq
+	s.optionTypes = before.optionTypes
+	s.variantSeq = before.variantSeq
+	s.variants = before.variants
+	s.imageSeq = before.imageSeq
+	s.images = before.images
+	s.movementSeq = before.movementSeq
+	s.movements = before.movements
+	s.subscriptionSeq = before.subscriptionSeq
+	s.subscriptions = before.subscriptions
+	s.reviewSeq = before.reviewSeq
+	s.reviews = before.reviews
+	s.wishlistSeq = before.wishlistSeq
+	s.wishlist = before.wishlist
+	s.promotionSeq = before.promotionSeq
+	s.promotions = before.promotions
+	s.discountSeq = before.discountSeq
+	s.discounts = before.discounts
+	s.priceChangeSeq = before.priceChangeSeq
+	s.priceChanges = before.priceChanges
+	s.priceHistorySeq = before.priceHistorySeq
+	s.priceHistory = before.priceHistory
+	s.priceListSeq = before.priceListSeq
+	s.priceList = before.priceList
+	s.taxRuleSeq = before.taxRuleSeq
+	s.taxRules = before.taxRules
+	s.itemTaxSeq = before.itemTaxSeq
+	s.itemTaxes = before.itemTaxes
+	s.shippingMethodSeq = before.shippingMethodSeq
+	s.shippingMethods = before.shippingMethods
+	s.shipmentItemSeq = before.shipmentItemSeq
+	s.shipments = before.shipments
+	s.addressSeq = before.addressSeq
+	s.addresses = before.addresses
+	s.historySeq = before.historySeq
+	s.history = before.history
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"gorm.io/gorm"
+)
+
+type taxRuleRepository struct {
+	store *Store
+}
+
+func (trr *taxRuleRepository) Create(ctx context.Context, rule entity.TaxRule) (entity.TaxRule, error) {
+
+	trr.store.mu.Lock()
+	defer trr.store.mu.Unlock()
+
+	err := trr.checkScopeLocked(rule)
+	if err != nil {
+		return rule, err
+	}
+
+	trr.store.taxRuleSeq++
+	rule.ID = trr.store.taxRuleSeq
+	trr.store.taxRules[rule.ID] = rule
+
+	return rule, nil
+}
+
+func (trr *taxRuleRepository) FindByID(ctx context.Context, id int64) (entity.TaxRule, error) {
+
+	trr.store.mu.Lock()
+	defer trr.store.mu.Unlock()
+
+	rule, exists := trr.store.taxRules[id]
+
+	if !exists {
+		return rule, common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return rule, nil
+}
+
+func (trr *taxRuleRepository) FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.TaxRule, int64, error) {
+
+	trr.store.mu.Lock()
+	defer trr.store.mu.Unlock()
+
+	rules := slices.Collect(maps.Values(trr.store.taxRules))
+
+	slices.SortFunc(rules, func(a entity.TaxRule, b entity.TaxRule) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	total := int64(len(rules))
+
+	if pagination.IsPaginate {
+		rules = paginate(rules, pagination)
+	}
+
+	return rules, total, nil
+}
+
+func (trr *taxRuleRepository) FindActive(ctx context.Context) ([]entity.TaxRule, error) {
+
+	trr.store.mu.Lock()
+	defer trr.store.mu.Unlock()
+
+	var rules []entity.TaxRule
+
+	for _, id := range slices.Sorted(maps.Keys(trr.store.taxRules)) {
+		if rule := trr.store.taxRules[id]; rule.IsActive {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+func (trr *taxRuleRepository) Update(ctx context.Context, rule entity.TaxRule) error {
+
+	trr.store.mu.Lock()
+	defer trr.store.mu.Unlock()
+
+	existing, exists := trr.store.taxRules[rule.ID]
+
+	if !exists {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	existing.Name = rule.Name
+	existing.Rate = rule.Rate
+	existing.Mode = rule.Mode
+	existing.IsActive = rule.IsActive
+	existing.UpdatedAt = rule.UpdatedAt
+	existing.UpdatedBy = rule.UpdatedBy
+
+	err := trr.checkScopeLocked(existing)
+	if err != nil {
+		return err
+	}
+
+	trr.store.taxRules[rule.ID] = existing
+
+	return nil
+}
+
+// checkScopeLocked mirrors the unique index on the scope of the active rules
+func (trr *taxRuleRepository) checkScopeLocked(rule entity.TaxRule) error {
+
+	if !rule.IsActive {
+		return nil
+	}
+
+	for _, existing := range trr.store.taxRules {
+
+		if existing.ID == rule.ID || !existing.IsActive || existing.Name != rule.Name {
+			continue
+		}
+
+		if equalPtr(existing.Region, rule.Region) && equalPtr(existing.CategoryID, rule.CategoryID) {
+			return common.NewError(errors.New("duplicate active tax rule scope"), common.ErrConflict)
+		}
+	}
+
+	return nil
+}
+
+type orderItemTaxRepository struct {
+	store *Store
+}
+
+func (oitr *orderItemTaxRepository) CreateBatch(ctx context.Context, taxes []entity.OrderItemTax) error {
+
+	oitr.store.mu.Lock()
+	defer oitr.store.mu.Unlock()
+
+	for _, tax := range taxes {
+
+		if _, exists := oitr.store.orderItems[tax.OrderItemReference]; !exists {
+			return common.NewError(errors.New("order item does not exist"), common.ErrValidation)
+		}
+
+		if _, exists := oitr.store.taxRules[tax.TaxRuleID]; !exists {
+			return common.NewError(errors.New("tax rule does not exist"), common.ErrValidation)
+		}
+
+		if tax.Amount < 0 || tax.TaxableAmount < 0 {
+			return common.NewError(errors.New("tax amount must not be negative"), common.ErrValidation)
+		}
+	}
+
+	for _, tax := range taxes {
+		oitr.store.itemTaxSeq++
+		tax.ID = oitr.store.itemTaxSeq
+		oitr.store.itemTaxes = append(oitr.store.itemTaxes, tax)
+	}
+
+	return nil
+}
+
+func (oitr *orderItemTaxRepository) DeleteByOrderReference(ctx context.Context, orderReference string) error {
+
+	oitr.store.mu.Lock()
+	defer oitr.store.mu.Unlock()
+
+	oitr.store.itemTaxes = slices.DeleteFunc(oitr.store.itemTaxes, func(tax entity.OrderItemTax) bool {
+		return tax.OrderReference == orderReference
+	})
+
+	return nil
+}
+
+func taxesOfOrderItemLocked(store *Store, orderItemReference string) []entity.OrderItemTax {
+
+	var taxes []entity.OrderItemTax
+
+	for _, tax := range store.itemTaxes {
+		if tax.OrderItemReference == orderItemReference {
+			taxes = append(taxes, tax)
+		}
+	}
+
+	return taxes
+}
+
+func equalPtr[T comparable](a *T, b *T) bool {
+
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+)
+
+type wishlistRepository struct {
+	store *Store
+}
+
+func (wr *wishlistRepository) Create(ctx context.Context, item entity.WishlistItem) (entity.WishlistItem, error) {
+
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	if _, exists := wr.store.accounts[item.AccountUsername]; !exists {
+		return item, common.NewError(errors.New("account does not exist"), common.ErrValidation)
+	}
+
+	if _, exists := wr.store.products[item.ProductID]; !exists {
+		return item, common.NewError(errors.New("product does not exist"), common.ErrValidation)
+	}
+
+	if item.VariantID != nil {
+		if _, exists := wr.store.variants[*item.VariantID]; !exists {
+			return item, common.NewError(errors.New("variant does not exist"), common.ErrValidation)
+		}
+	}
+
+	for _, existing := range wr.store.wishlist {
+		if existing.AccountUsername == item.AccountUsername && existing.ProductID == item.ProductID && variantKey(existing.VariantID) == variantKey(item.VariantID) {
+			return item, common.NewError(errors.New("duplicate wishlist item"), common.ErrConflict)
+		}
+	}
+
+	wr.store.wishlistSeq++
+	item.ID = wr.store.wishlistSeq
+	wr.store.wishlist[item.ID] = item
+
+	return item, nil
+}
+
+func (wr *wishlistRepository) FindByAccount(ctx context.Context, username string) ([]entity.WishlistItem, error) {
+
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	var items []entity.WishlistItem
+
+	for _, item := range wr.store.wishlist {
+		if item.AccountUsername == username {
+			items = append(items, item)
+		}
+	}
+
+	slices.SortFunc(items, func(a entity.WishlistItem, b entity.WishlistItem) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+	})
+
+	return items, nil
+}
+
+func (wr *wishlistRepository) FindByProductIDs(ctx context.Context, productIDs []int64) ([]entity.WishlistItem, error) {
+
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	var items []entity.WishlistItem
+
+	for _, item := range wr.store.wishlist {
+		if slices.Contains(productIDs, item.ProductID) {
+			items = append(items, item)
+		}
+	}
+
+	slices.SortFunc(items, func(a entity.WishlistItem, b entity.WishlistItem) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return items, nil
+}
+
+func (wr *wishlistRepository) FindProductIDs(ctx context.Context) ([]int64, error) {
+
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	var productIDs []int64
+
+	for _, item := range wr.store.wishlist {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	slices.Sort(productIDs)
+
+	return slices.Compact(productIDs), nil
+}
+
+func (wr *wishlistRepository) Delete(ctx context.Context, username string, ids []int64) (int64, error) {
+
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	deleted := int64(0)
+
+	for _, id := range ids {
+		if item, exists := wr.store.wishlist[id]; exists && item.AccountUsername == username {
+			delete(wr.store.wishlist, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (wr *wishlistRepository) LowerReferencePrice(ctx context.Context, id int64, price int64) (bool, error) {
+
+	wr.store.mu.Lock()
+	defer wr.store.mu.Unlock()
+
+	item, exists := wr.store.wishlist[id]
+
+	if !exists || item.ReferencePrice <= price {
+		return false, nil
+	}
+
+	item.ReferencePrice = price
+	item.UpdatedAt = time.Now()
+	wr.store.wishlist[id] = item
+
+	return true, nil
+}
+
+// variantKey mirrors COALESCE(variant_id, 0) of the unique index
+func variantKey(variantID *int64) int64 {
+
+	if variantID == nil {
+		return 0
+	}
+
+	return *variantID
+}
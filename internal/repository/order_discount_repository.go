@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type OrderDiscountRepository interface {
+	CreateBatch(ctx context.Context, discounts []entity.OrderDiscount) error
+	FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderDiscount, error)
+	UpdateAmount(ctx context.Context, id int64, amount int64) error
+	CountRedemptions(ctx context.Context, promotionID int64, username string) (int64, int64, error)
+}
+
+type orderDiscountRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderDiscountRepository(db *gorm.DB) OrderDiscountRepository {
+	return &orderDiscountRepository{db: db}
+}
+
+func (odr *orderDiscountRepository) CreateBatch(ctx context.Context, discounts []entity.OrderDiscount) error {
+
+	if len(discounts) == 0 {
+		return nil
+	}
+
+	err := odr.db.WithContext(ctx).Create(&discounts).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (odr *orderDiscountRepository) FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderDiscount, error) {
+
+	var discounts []entity.OrderDiscount
+
+	err := odr.db.WithContext(ctx).
+		Where("order_reference = ?", orderReference).
+		Order("id").
+		Find(&discounts).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return discounts, nil
+}
+
+// UpdateAmount writes the amount of a discount recomputed on the modified order
+func (odr *orderDiscountRepository) UpdateAmount(ctx context.Context, id int64, amount int64) error {
+
+	err := odr.db.WithContext(ctx).Model(&entity.OrderDiscount{ID: id}).Update("amount", amount).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// CountRedemptions counts the non cancelled orders that redeemed the promotion, in total and by the account
+func (odr *orderDiscountRepository) CountRedemptions(ctx context.Context, promotionID int64, username string) (int64, int64, error) {
+
+	var counts struct {
+		Total   int64
+		Account int64
+	}
+
+	err := odr.db.WithContext(ctx).
+		Table("order_discounts AS od").
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE o.account_username = ?) AS account", username).
+		Joins("JOIN orders o ON o.order_reference = od.order_reference").
+		Where("od.promotion_id = ? AND o.status <> ?", promotionID, constant.OrderStatusCancelled).
+		Scan(&counts).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return counts.Total, counts.Account, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// OrderHistoryRepository records the changes of the orders, entries are listed oldest first
+type OrderHistoryRepository interface {
+	Create(ctx context.Context, entry entity.OrderHistory) (entity.OrderHistory, error)
+	FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderHistory, error)
+}
+
+type orderHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderHistoryRepository(db *gorm.DB) OrderHistoryRepository {
+	return &orderHistoryRepository{db: db}
+}
+
+func (ohr *orderHistoryRepository) Create(ctx context.Context, entry entity.OrderHistory) (entity.OrderHistory, error) {
+
+	err := ohr.db.WithContext(ctx).Create(&entry).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return entry, translateError(err)
+	}
+
+	return entry, nil
+}
+
+func (ohr *orderHistoryRepository) FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderHistory, error) {
+
+	var entries []entity.OrderHistory
+
+	err := ohr.db.WithContext(ctx).
+		Where("order_reference = ?", orderReference).
+		Order("id").
+		Find(&entries).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return entries, nil
+}
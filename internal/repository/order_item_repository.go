@@ -4,32 +4,43 @@ import (
 	"context"
 
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
 	"github.com/jhasudungan/terraloom-core-api/internal/entity"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
-type OrderItemRepository struct {
+type OrderItemRepository interface {
+	Create(ctx context.Context, orderItem entity.OrderItem) error
+	FindByID(ctx context.Context, id string) (entity.OrderItem, error)
+	FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderItem, error)
+	CreateBatch(ctx context.Context, orderItems []entity.OrderItem, batchSize int) error
+	Update(ctx context.Context, orderItem entity.OrderItem) error
+	DeleteByReferences(ctx context.Context, orderItemReferences []string) error
+	CheckFinishedPurchase(ctx context.Context, username string, productID int64) (bool, error)
+}
+
+type orderItemRepository struct {
 	db *gorm.DB
 }
 
-func NewOrderItemRepository(db *gorm.DB) *OrderItemRepository {
-	return &OrderItemRepository{db: db}
+func NewOrderItemRepository(db *gorm.DB) OrderItemRepository {
+	return &orderItemRepository{db: db}
 }
 
-func (oir *OrderItemRepository) Create(ctx context.Context, orderItem entity.OrderItem) error {
+func (oir *orderItemRepository) Create(ctx context.Context, orderItem entity.OrderItem) error {
 
 	err := oir.db.WithContext(ctx).Create(&orderItem).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (oir *OrderItemRepository) FindByID(ctx context.Context, id string) (entity.OrderItem, error) {
+func (oir *orderItemRepository) FindByID(ctx context.Context, id string) (entity.OrderItem, error) {
 
 	query := oir.db.WithContext(ctx).Model(&entity.OrderItem{})
 	var orderItem entity.OrderItem
@@ -45,11 +56,11 @@ func (oir *OrderItemRepository) FindByID(ctx context.Context, id string) (entity
 
 }
 
-func (oir *OrderItemRepository) FindByOrderID(ctx context.Context, orderID uint) ([]entity.OrderItem, error) {
+func (oir *orderItemRepository) FindByOrderReference(ctx context.Context, orderReference string) ([]entity.OrderItem, error) {
 
 	var orderItems []entity.OrderItem
 
-	err := oir.db.WithContext(ctx).Where("order_id = ?", orderID).Find(&orderItems).Error
+	err := oir.db.WithContext(ctx).Where("order_reference = ?", orderReference).Find(&orderItems).Error
 
 	if err != nil {
 		logrus.Error(err)
@@ -59,14 +70,68 @@ func (oir *OrderItemRepository) FindByOrderID(ctx context.Context, orderID uint)
 	return orderItems, nil
 }
 
-func (oir *OrderItemRepository) CreateBatch(ctx context.Context, orderItems []entity.OrderItem, batchSize int) error {
+func (oir *orderItemRepository) CreateBatch(ctx context.Context, orderItems []entity.OrderItem, batchSize int) error {
 
 	err := oir.db.WithContext(ctx).CreateInBatches(&orderItems, batchSize).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// Update writes the quantity and the amounts of the item, the product and the variant never change
+func (oir *orderItemRepository) Update(ctx context.Context, orderItem entity.OrderItem) error {
+
+	err := oir.db.WithContext(ctx).
+		Model(&entity.OrderItem{OrderItemReference: orderItem.OrderItemReference}).
+		Select("quantity", "price_snapshot", "total", "discount_amount", "updated_at", "updated_by").
+		Updates(&orderItem).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
 	}
 
 	return nil
 }
+
+// DeleteByReferences deletes the items, their taxes go with them
+func (oir *orderItemRepository) DeleteByReferences(ctx context.Context, orderItemReferences []string) error {
+
+	if len(orderItemReferences) == 0 {
+		return nil
+	}
+
+	err := oir.db.WithContext(ctx).Where("order_item_reference IN ?", orderItemReferences).Delete(&entity.OrderItem{}).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// CheckFinishedPurchase tells whether the account has a finished order containing the product
+func (oir *orderItemRepository) CheckFinishedPurchase(ctx context.Context, username string, productID int64) (bool, error) {
+
+	var count int64
+
+	err := oir.db.WithContext(ctx).
+		Model(&entity.OrderItem{}).
+		Joins("JOIN orders ON orders.order_reference = order_items.order_reference").
+		Where("order_items.product_id = ? AND orders.account_username = ? AND orders.status = ?", productID, username, constant.OrderStatusFinished).
+		Where("order_items.deleted_at IS NULL AND orders.deleted_at IS NULL").
+		Limit(1).
+		Count(&count).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return false, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return count > 0, nil
+}
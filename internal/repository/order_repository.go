@@ -11,40 +11,49 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-type OrderRepository struct {
+type OrderRepository interface {
+	Create(ctx context.Context, order entity.Order) error
+	Update(ctx context.Context, order entity.Order) error
+	FindByID(ctx context.Context, id string) (entity.Order, error)
+	FindByIDWithItems(ctx context.Context, id string) (entity.Order, error)
+	FindWithAccountAndFilters(ctx context.Context, accountUsername string, filter model.OrderFilter, pagination model.PaginationParams) ([]entity.Order, int64, error)
+	FindGuestOrdersByEmail(ctx context.Context, email string) ([]entity.Order, error)
+}
+
+type orderRepository struct {
 	db *gorm.DB
 }
 
-func NewOrderRepository(db *gorm.DB) *OrderRepository {
-	return &OrderRepository{db: db}
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
 }
 
-func (or *OrderRepository) Create(ctx context.Context, order entity.Order) error {
+func (or *orderRepository) Create(ctx context.Context, order entity.Order) error {
 
 	err := or.db.WithContext(ctx).Create(&order).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (or *OrderRepository) Update(ctx context.Context, order entity.Order) error {
+func (or *orderRepository) Update(ctx context.Context, order entity.Order) error {
 
 	err := or.db.WithContext(ctx).Save(&order).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 
 }
 
-func (or *OrderRepository) FindByID(ctx context.Context, id string) (entity.Order, error) {
+func (or *orderRepository) FindByID(ctx context.Context, id string) (entity.Order, error) {
 
 	query := or.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"})
 	var order entity.Order
@@ -58,12 +67,20 @@ func (or *OrderRepository) FindByID(ctx context.Context, id string) (entity.Orde
 	return order, nil
 }
 
-func (or *OrderRepository) FindByIDWithItems(ctx context.Context, id string) (entity.Order, error) {
+func (or *orderRepository) FindByIDWithItems(ctx context.Context, id string) (entity.Order, error) {
 
 	query := or.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"})
 	var order entity.Order
 
-	err := query.Preload("OrderItems").Where("order_reference = ?", id).First(&order).Error
+	err := query.Preload("OrderItems").Preload("OrderItems.Taxes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Discounts").Preload("Shipments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("shipped_at, shipment_reference")
+	}).Preload("Shipments.Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Where("order_reference = ?", id).First(&order).Error
 
 	if err != nil {
 		return order, common.NewError(err, common.ErrResourceNotFound)
@@ -72,7 +89,7 @@ func (or *OrderRepository) FindByIDWithItems(ctx context.Context, id string) (en
 	return order, nil
 }
 
-func (or *OrderRepository) FindWithAccountAndFilters(
+func (or *orderRepository) FindWithAccountAndFilters(
 	ctx context.Context,
 	accountUsername string,
 	filter model.OrderFilter,
@@ -93,18 +110,40 @@ func (or *OrderRepository) FindWithAccountAndFilters(
 	// Exclude soft deleted
 	baseQuery = baseQuery.Where("deleted_at IS NULL")
 
-	// Add ordering by order_date (newest first)
-	baseQuery = baseQuery.Order("order_date DESC")
-
 	// Get total count
-	if err := baseQuery.Count(&total).Error; err != nil {
-		logrus.Error(err)
-		return nil, 0, common.NewError(err, common.ErrResourceNotFound)
+	if !pagination.SkipCount {
+		if err := baseQuery.Count(&total).Error; err != nil {
+			logrus.Error(err)
+			return nil, 0, common.NewError(err, common.ErrResourceNotFound)
+		}
 	}
 
-	// Apply pagination if enabled
 	dataQuery := baseQuery
-	if pagination.IsPaginate {
+
+	// Newest first, order_reference keeps pages stable between equal dates
+	ascending := false
+
+	if pagination.IsKeyset {
+
+		ascending = KeysetAscending(true, pagination)
+
+		if pagination.Cursor != nil {
+
+			position, err := ParseOrderCursor(*pagination.Cursor)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			dataQuery = dataQuery.Where("(order_date, order_reference) "+keysetOperator(ascending)+" (?, ?)", position.OrderDate, position.OrderReference)
+		}
+	}
+
+	dataQuery = dataQuery.Order("order_date " + orderDirection(ascending) + ", order_reference " + orderDirection(ascending))
+
+	// Apply pagination if enabled
+	if pagination.IsKeyset {
+		dataQuery = dataQuery.Limit(pagination.PerPage + 1)
+	} else if pagination.IsPaginate {
 		dataQuery = dataQuery.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
 	}
 
@@ -117,6 +156,21 @@ func (or *OrderRepository) FindWithAccountAndFilters(
 	return orders, total, nil
 }
 
-func (or *OrderRepository) GetDB() *gorm.DB {
-	return or.db
+// FindGuestOrdersByEmail locks the unclaimed guest orders of the email, the email is compared case insensitively
+func (or *orderRepository) FindGuestOrdersByEmail(ctx context.Context, email string) ([]entity.Order, error) {
+
+	var orders []entity.Order
+
+	err := or.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("account_username IS NULL AND lower(guest_email) = lower(?)", email).
+		Order("order_date, order_reference").
+		Find(&orders).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return orders, nil
 }
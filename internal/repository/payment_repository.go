@@ -9,39 +9,46 @@ import (
 	"gorm.io/gorm"
 )
 
-type PaymentRepository struct {
+type PaymentRepository interface {
+	Create(ctx context.Context, payment entity.Payment) error
+	Update(ctx context.Context, payment entity.Payment) error
+	FindByID(ctx context.Context, id string) (entity.Payment, error)
+	FindByOrderReference(ctx context.Context, orderReference string) (entity.Payment, error)
+}
+
+type paymentRepository struct {
 	db *gorm.DB
 }
 
-func NewPaymentRepository(db *gorm.DB) *PaymentRepository {
-	return &PaymentRepository{db: db}
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
 }
 
-func (pr *PaymentRepository) Create(ctx context.Context, payment entity.Payment) error {
+func (pr *paymentRepository) Create(ctx context.Context, payment entity.Payment) error {
 
 	err := pr.db.WithContext(ctx).Create(&payment).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (pr *PaymentRepository) Update(ctx context.Context, payment entity.Payment) error {
+func (pr *paymentRepository) Update(ctx context.Context, payment entity.Payment) error {
 
 	err := pr.db.WithContext(ctx).Save(&payment).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (pr *PaymentRepository) FindByID(ctx context.Context, id string) (entity.Payment, error) {
+func (pr *paymentRepository) FindByID(ctx context.Context, id string) (entity.Payment, error) {
 
 	query := pr.db.WithContext(ctx).Model(&entity.Payment{})
 	var payment entity.Payment
@@ -56,10 +63,10 @@ func (pr *PaymentRepository) FindByID(ctx context.Context, id string) (entity.Pa
 	return payment, nil
 }
 
-func (pr *PaymentRepository) FindByOrderReference(ctx context.Context, orderReference string) (entity.Payment, error) {
+func (pr *paymentRepository) FindByOrderReference(ctx context.Context, orderReference string) (entity.Payment, error) {
 
 	var payment entity.Payment
-	if err := pr.db.Where("order_reference = ?", orderReference).First(&payment).Error; err != nil {
+	if err := pr.db.WithContext(ctx).Where("order_reference = ?", orderReference).First(&payment).Error; err != nil {
 		logrus.Error(err)
 		return payment, common.NewError(err, common.ErrResourceNotFound)
 	}
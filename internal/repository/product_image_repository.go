@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Images are read with their thumbnail sizes, ordered by position then id
+type ProductImageRepository interface {
+	FindByProductIDs(ctx context.Context, productIDs []int64) ([]entity.ProductImage, error)
+	FindByID(ctx context.Context, id int64) (entity.ProductImage, error)
+	Create(ctx context.Context, image entity.ProductImage) (entity.ProductImage, error)
+	Update(ctx context.Context, image entity.ProductImage) error
+	ClearPrimary(ctx context.Context, productID int64) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type productImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) ProductImageRepository {
+	return &productImageRepository{db: db}
+}
+
+func (pir *productImageRepository) FindByProductIDs(ctx context.Context, productIDs []int64) ([]entity.ProductImage, error) {
+
+	var images []entity.ProductImage
+
+	if len(productIDs) == 0 {
+		return images, nil
+	}
+
+	err := pir.db.WithContext(ctx).
+		Preload("Sizes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("width")
+		}).
+		Where("product_id IN ?", productIDs).
+		Order("product_id, position, id").
+		Find(&images).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return images, nil
+}
+
+func (pir *productImageRepository) FindByID(ctx context.Context, id int64) (entity.ProductImage, error) {
+
+	query := pir.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"})
+	var image entity.ProductImage
+
+	err := query.Preload("Sizes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("width")
+	}).First(&image, id).Error
+
+	if err != nil {
+		return image, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return image, nil
+}
+
+// Create inserts the image together with its sizes
+func (pir *productImageRepository) Create(ctx context.Context, image entity.ProductImage) (entity.ProductImage, error) {
+
+	err := pir.db.WithContext(ctx).Create(&image).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return image, translateError(err)
+	}
+
+	return image, nil
+}
+
+// Update only writes the gallery attributes, the stored files never change
+func (pir *productImageRepository) Update(ctx context.Context, image entity.ProductImage) error {
+
+	err := pir.db.WithContext(ctx).
+		Model(&entity.ProductImage{ID: image.ID}).
+		Select("alt_text", "position", "is_primary", "updated_at", "updated_by").
+		Updates(&image).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (pir *productImageRepository) ClearPrimary(ctx context.Context, productID int64) error {
+
+	err := pir.db.WithContext(ctx).
+		Model(&entity.ProductImage{}).
+		Where("product_id = ? AND is_primary", productID).
+		Update("is_primary", false).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// Delete removes the image, its sizes cascade
+func (pir *productImageRepository) Delete(ctx context.Context, id int64) error {
+
+	err := pir.db.WithContext(ctx).Delete(&entity.ProductImage{}, id).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+/*
+*
+
+	Scheduled base price changes :
+	- A change is pending until AppliedAt is set, pending changes are listed by effective time then id
+	- Applying and cancelling happen with the product locked, MarkApplied and DeletePending
+	  only touch pending changes so a change is never applied twice nor cancelled once applied
+
+*
+*/
+type ProductPriceChangeRepository interface {
+	Create(ctx context.Context, change entity.ProductPriceChange) (entity.ProductPriceChange, error)
+	FindPendingByProductID(ctx context.Context, productID int64) ([]entity.ProductPriceChange, error)
+	FindDue(ctx context.Context, productIDs []int64, at time.Time) ([]entity.ProductPriceChange, error)
+	MarkApplied(ctx context.Context, id int64, at time.Time) (bool, error)
+	DeletePending(ctx context.Context, productID int64, id int64) (int64, error)
+}
+
+// ProductPriceHistoryRepository is the append only log of the price changes of the products
+type ProductPriceHistoryRepository interface {
+	Create(ctx context.Context, entry entity.ProductPriceHistory) error
+	FindByProductID(ctx context.Context, productID int64, pagination model.PaginationParams) ([]entity.ProductPriceHistory, int64, error)
+}
+
+/*
+*
+
+	Price lists, the prices of the products in the currencies other than their own :
+	- A product has at most one price per currency, and each of its variants one more, a second one is an ErrConflict
+	- Entries are listed by product, currency, then the product entry before the variant entries
+
+*
+*/
+type ProductCurrencyPriceRepository interface {
+	Create(ctx context.Context, price entity.ProductCurrencyPrice) (entity.ProductCurrencyPrice, error)
+	FindByProductIDs(ctx context.Context, productIDs []int64, currency string) ([]entity.ProductCurrencyPrice, error)
+	Update(ctx context.Context, price entity.ProductCurrencyPrice) error
+	Delete(ctx context.Context, id int64) error
+}
+
+type productPriceChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewProductPriceChangeRepository(db *gorm.DB) ProductPriceChangeRepository {
+	return &productPriceChangeRepository{db: db}
+}
+
+func (pcr *productPriceChangeRepository) Create(ctx context.Context, change entity.ProductPriceChange) (entity.ProductPriceChange, error) {
+
+	err := pcr.db.WithContext(ctx).Create(&change).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return change, translateError(err)
+	}
+
+	return change, nil
+}
+
+func (pcr *productPriceChangeRepository) FindPendingByProductID(ctx context.Context, productID int64) ([]entity.ProductPriceChange, error) {
+
+	var changes []entity.ProductPriceChange
+
+	err := pcr.db.WithContext(ctx).
+		Where("product_id = ? AND applied_at IS NULL", productID).
+		Order("effective_at, id").
+		Find(&changes).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return changes, nil
+}
+
+// FindDue lists the pending changes effective at the given time, of every product when productIDs is empty
+func (pcr *productPriceChangeRepository) FindDue(ctx context.Context, productIDs []int64, at time.Time) ([]entity.ProductPriceChange, error) {
+
+	query := pcr.db.WithContext(ctx).Where("applied_at IS NULL AND effective_at <= ?", at)
+
+	if len(productIDs) > 0 {
+		query = query.Where("product_id IN ?", productIDs)
+	}
+
+	var changes []entity.ProductPriceChange
+
+	err := query.Order("effective_at, id").Find(&changes).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return changes, nil
+}
+
+// MarkApplied reports false when the change was applied already
+func (pcr *productPriceChangeRepository) MarkApplied(ctx context.Context, id int64, at time.Time) (bool, error) {
+
+	result := pcr.db.WithContext(ctx).
+		Model(&entity.ProductPriceChange{}).
+		Where("id = ? AND applied_at IS NULL", id).
+		Update("applied_at", at)
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return false, translateError(result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// DeletePending returns the number of deleted changes, 0 when the change is unknown or applied already
+func (pcr *productPriceChangeRepository) DeletePending(ctx context.Context, productID int64, id int64) (int64, error) {
+
+	result := pcr.db.WithContext(ctx).
+		Where("id = ? AND product_id = ? AND applied_at IS NULL", id, productID).
+		Delete(&entity.ProductPriceChange{})
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return 0, translateError(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+type productPriceHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewProductPriceHistoryRepository(db *gorm.DB) ProductPriceHistoryRepository {
+	return &productPriceHistoryRepository{db: db}
+}
+
+func (phr *productPriceHistoryRepository) Create(ctx context.Context, entry entity.ProductPriceHistory) error {
+
+	err := phr.db.WithContext(ctx).Create(&entry).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// FindByProductID lists the price changes of a product newest first
+func (phr *productPriceHistoryRepository) FindByProductID(ctx context.Context, productID int64, pagination model.PaginationParams) ([]entity.ProductPriceHistory, int64, error) {
+
+	query := phr.db.WithContext(ctx).Model(&entity.ProductPriceHistory{}).Where("product_id = ?", productID)
+
+	var total int64
+
+	err := query.Count(&total).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	query = query.Order("created_at DESC, id DESC")
+
+	if pagination.IsPaginate {
+		query = query.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	var entries []entity.ProductPriceHistory
+
+	err = query.Find(&entries).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return entries, total, nil
+}
+
+type productCurrencyPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewProductCurrencyPriceRepository(db *gorm.DB) ProductCurrencyPriceRepository {
+	return &productCurrencyPriceRepository{db: db}
+}
+
+func (cpr *productCurrencyPriceRepository) Create(ctx context.Context, price entity.ProductCurrencyPrice) (entity.ProductCurrencyPrice, error) {
+
+	err := cpr.db.WithContext(ctx).Create(&price).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return price, translateError(err)
+	}
+
+	return price, nil
+}
+
+// FindByProductIDs lists the prices of the products in the currency, in every currency when it is empty
+func (cpr *productCurrencyPriceRepository) FindByProductIDs(ctx context.Context, productIDs []int64, currency string) ([]entity.ProductCurrencyPrice, error) {
+
+	var prices []entity.ProductCurrencyPrice
+
+	if len(productIDs) == 0 {
+		return prices, nil
+	}
+
+	query := cpr.db.WithContext(ctx).Where("product_id IN ?", productIDs)
+
+	if currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+
+	err := query.Order("product_id, currency, variant_id NULLS FIRST").Find(&prices).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return prices, nil
+}
+
+func (cpr *productCurrencyPriceRepository) Update(ctx context.Context, price entity.ProductCurrencyPrice) error {
+
+	result := cpr.db.WithContext(ctx).
+		Model(&entity.ProductCurrencyPrice{}).
+		Where("id = ?", price.ID).
+		Updates(map[string]interface{}{
+			"price":      price.Price,
+			"updated_at": price.UpdatedAt,
+			"updated_by": price.UpdatedBy,
+		})
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return nil
+}
+
+func (cpr *productCurrencyPriceRepository) Delete(ctx context.Context, id int64) error {
+
+	result := cpr.db.WithContext(ctx).Delete(&entity.ProductCurrencyPrice{}, id)
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return common.NewError(gorm.ErrRecordNotFound, common.ErrResourceNotFound)
+	}
+
+	return nil
+}
@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
 	"github.com/jhasudungan/terraloom-core-api/internal/entity"
 	"github.com/jhasudungan/terraloom-core-api/internal/model"
 	"github.com/sirupsen/logrus"
@@ -11,44 +15,111 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-type ProductRepository struct {
+type ProductRepository interface {
+	FindWithFilters(ctx context.Context, filter model.ProductFilter, sort model.SortParams, pagination model.PaginationParams) ([]entity.Product, int64, error)
+	FindByID(ctx context.Context, id int64) (entity.Product, error)
+	FindMultipleByIDs(ctx context.Context, ids []int64) ([]entity.Product, error)
+	LockByIDs(ctx context.Context, ids []int64) ([]entity.Product, error)
+	DecrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error)
+	IncrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error)
+	UpdateLowStockThreshold(ctx context.Context, id int64, threshold int64, updatedBy string) error
+	UpdateRating(ctx context.Context, id int64, average float64, count int64) error
+	UpdatePrice(ctx context.Context, id int64, price int64, updatedBy string) error
+	UpdateSale(ctx context.Context, id int64, salePrice *int64, startsAt *time.Time, endsAt *time.Time, updatedBy string) error
+	UpdateDimensions(ctx context.Context, id int64, weight int64, length int64, width int64, height int64, updatedBy string) error
+	CheckById(ctx context.Context, id int64) (bool, error)
+	Update(ctx context.Context, product entity.Product) error
+	SearchFullText(ctx context.Context, terms []string, filter model.ProductFilter, pagination model.PaginationParams) ([]ProductSearchHit, int64, error)
+	SearchFuzzy(ctx context.Context, text string, filter model.ProductFilter, pagination model.PaginationParams) ([]ProductSearchHit, int64, error)
+}
+
+// ProductSearchHit is a product matched by a search, with its relevance and highlighted text
+type ProductSearchHit struct {
+	Product            entity.Product `gorm:"embedded"`
+	Rank               float64        `gorm:"column:rank"`
+	NameHighlight      string         `gorm:"column:name_highlight"`
+	DescriptionSnippet string         `gorm:"column:description_snippet"`
+}
+
+const (
+	// Minimum word_similarity for the typo tolerant fallback
+	fuzzySimilarityThreshold = 0.3
+
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+type productRepository struct {
 	db *gorm.DB
 }
 
-func NewProductRepository(db *gorm.DB) *ProductRepository {
-	return &ProductRepository{db: db}
+func NewProductRepository(db *gorm.DB) ProductRepository {
+	return &productRepository{db: db}
 }
 
-func (pr *ProductRepository) FindWithFilters(
+func (pr *productRepository) FindWithFilters(
 	ctx context.Context,
 	filter model.ProductFilter,
+	sort model.SortParams,
 	pagination model.PaginationParams) ([]entity.Product, int64, error) {
 
+	now := time.Now()
 	baseQuery := pr.db.WithContext(ctx).Model(&entity.Product{})
 	var products []entity.Product
 	var total int64
 
 	// Apply filters
-	if filter.Name != "" {
-		baseQuery = baseQuery.Where("name ILIKE ?", "%"+filter.Name+"%")
+	baseQuery = applyProductFilter(baseQuery, filter, now)
+
+	// Get total count
+	if !pagination.SkipCount {
+		if err := baseQuery.Count(&total).Error; err != nil {
+			logrus.Error(err)
+			return nil, 0, common.NewError(err, common.ErrResourceNotFound)
+		}
 	}
 
-	if filter.IsActive {
-		baseQuery = baseQuery.Where("is_active = ?", filter.IsActive)
+	dataQuery := baseQuery.Select("products.*, ? AS listing_price", listingPrice(now))
+
+	if sort.By == constant.ProductSortBestSelling {
+		dataQuery = dataQuery.Joins(`LEFT JOIN (
+			SELECT order_items.product_id, SUM(order_items.quantity) AS sold
+			FROM order_items
+			JOIN orders ON orders.order_reference = order_items.order_reference
+			WHERE orders.status <> ? AND orders.deleted_at IS NULL
+			GROUP BY order_items.product_id
+		) AS sales ON sales.product_id = products.id`, constant.OrderStatusCancelled)
 	}
 
-	// Exclude soft deleted
-	baseQuery = baseQuery.Where("deleted_at IS NULL")
+	column := productSortColumn(sort.By, now)
+	ascending := !sort.IsDescending()
 
-	// Get total count
-	if err := baseQuery.Count(&total).Error; err != nil {
-		logrus.Error(err)
-		return nil, 0, common.NewError(err, common.ErrResourceNotFound)
+	if pagination.IsKeyset {
+
+		ascending = KeysetAscending(sort.IsDescending(), pagination)
+
+		if pagination.Cursor != nil {
+
+			position, err := ParseProductCursor(*pagination.Cursor, sort)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			// Row comparison matches the (sort key, id) ordering below
+			dataQuery = dataQuery.Where("(?, products.id) "+keysetOperator(ascending)+" (?, ?)", column, productSortValue(position, sort.By), position.ID)
+		}
 	}
 
+	// Whitelisted ordering, id keeps pages stable between equal keys
+	dataQuery = dataQuery.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:  "? " + orderDirection(ascending) + ", products.id " + orderDirection(ascending),
+		Vars: []interface{}{column},
+	}})
+
 	// Apply pagination if enabled
-	dataQuery := baseQuery
-	if pagination.IsPaginate {
+	if pagination.IsKeyset {
+		dataQuery = dataQuery.Limit(pagination.PerPage + 1)
+	} else if pagination.IsPaginate {
 		dataQuery = dataQuery.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
 	}
 
@@ -61,7 +132,7 @@ func (pr *ProductRepository) FindWithFilters(
 	return products, total, nil
 }
 
-func (pr *ProductRepository) FindByID(ctx context.Context, id int64) (entity.Product, error) {
+func (pr *productRepository) FindByID(ctx context.Context, id int64) (entity.Product, error) {
 
 	query := pr.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"})
 	var product entity.Product
@@ -75,7 +146,7 @@ func (pr *ProductRepository) FindByID(ctx context.Context, id int64) (entity.Pro
 	return product, nil
 }
 
-func (pr *ProductRepository) FindMultipleByIDs(ctx context.Context, ids []int64) ([]entity.Product, error) {
+func (pr *productRepository) FindMultipleByIDs(ctx context.Context, ids []int64) ([]entity.Product, error) {
 
 	var products []entity.Product
 	err := pr.db.WithContext(ctx).Where("id IN ?", ids).Find(&products).Error
@@ -87,7 +158,171 @@ func (pr *ProductRepository) FindMultipleByIDs(ctx context.Context, ids []int64)
 	return products, nil
 }
 
-func (pr *ProductRepository) CheckById(ctx context.Context, id int64) (bool, error) {
+// LockByIDs locks the products FOR UPDATE in ascending id order, so concurrent orders never deadlock
+func (pr *productRepository) LockByIDs(ctx context.Context, ids []int64) ([]entity.Product, error) {
+
+	var products []entity.Product
+
+	err := pr.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&products).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return products, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return products, nil
+}
+
+// DecrementStock takes quantity from the stock only when enough is left and returns the new stock
+func (pr *productRepository) DecrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	var stock []int64
+
+	err := pr.db.WithContext(ctx).Raw(
+		"UPDATE products SET stock = stock - ?, updated_at = ?, updated_by = ? WHERE id = ? AND stock >= ? RETURNING stock",
+		quantity, time.Now(), updatedBy, id, quantity).Scan(&stock).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, translateError(err)
+	}
+
+	if len(stock) == 0 {
+		err := fmt.Errorf("insufficient stock for product: %v , requested : %v", id, quantity)
+		logrus.Error(err)
+		return 0, common.NewError(err, common.ErrValidation)
+	}
+
+	return stock[0], nil
+}
+
+// IncrementStock adds quantity to the stock and returns the new stock
+func (pr *productRepository) IncrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	var stock []int64
+
+	err := pr.db.WithContext(ctx).Raw(
+		"UPDATE products SET stock = stock + ?, updated_at = ?, updated_by = ? WHERE id = ? RETURNING stock",
+		quantity, time.Now(), updatedBy, id).Scan(&stock).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, translateError(err)
+	}
+
+	if len(stock) == 0 {
+		err := fmt.Errorf("product not found: %d", id)
+		logrus.Error(err)
+		return 0, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return stock[0], nil
+}
+
+func (pr *productRepository) UpdateLowStockThreshold(ctx context.Context, id int64, threshold int64, updatedBy string) error {
+
+	result := pr.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"low_stock_threshold": threshold,
+			"updated_at":          time.Now(),
+			"updated_by":          updatedBy,
+		})
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		err := fmt.Errorf("product not found: %d", id)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return nil
+}
+
+// UpdateRating stores the summary of the published reviews, audit columns are left alone
+func (pr *productRepository) UpdateRating(ctx context.Context, id int64, average float64, count int64) error {
+
+	err := pr.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"rating_average": average,
+			"review_count":   count,
+		}).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (pr *productRepository) UpdatePrice(ctx context.Context, id int64, price int64, updatedBy string) error {
+
+	return pr.updateColumns(ctx, id, map[string]interface{}{
+		"price":      price,
+		"updated_at": time.Now(),
+		"updated_by": updatedBy,
+	})
+}
+
+// UpdateSale sets the sale price and its window, a nil salePrice ends the sale
+func (pr *productRepository) UpdateSale(ctx context.Context, id int64, salePrice *int64, startsAt *time.Time, endsAt *time.Time, updatedBy string) error {
+
+	return pr.updateColumns(ctx, id, map[string]interface{}{
+		"sale_price":     salePrice,
+		"sale_starts_at": startsAt,
+		"sale_ends_at":   endsAt,
+		"updated_at":     time.Now(),
+		"updated_by":     updatedBy,
+	})
+}
+
+// UpdateDimensions sets the weight in grams and the dimensions in millimetres of one unit
+func (pr *productRepository) UpdateDimensions(ctx context.Context, id int64, weight int64, length int64, width int64, height int64, updatedBy string) error {
+
+	return pr.updateColumns(ctx, id, map[string]interface{}{
+		"weight":     weight,
+		"length":     length,
+		"width":      width,
+		"height":     height,
+		"updated_at": time.Now(),
+		"updated_by": updatedBy,
+	})
+}
+
+func (pr *productRepository) updateColumns(ctx context.Context, id int64, columns map[string]interface{}) error {
+
+	result := pr.db.WithContext(ctx).
+		Model(&entity.Product{}).
+		Where("id = ?", id).
+		Updates(columns)
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		err := fmt.Errorf("product not found: %d", id)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return nil
+}
+
+func (pr *productRepository) CheckById(ctx context.Context, id int64) (bool, error) {
 
 	var count int64
 
@@ -105,29 +340,261 @@ func (pr *ProductRepository) CheckById(ctx context.Context, id int64) (bool, err
 	return false, nil
 }
 
-func (pr *ProductRepository) Update(ctx context.Context, product entity.Product) error {
+func (pr *productRepository) Update(ctx context.Context, product entity.Product) error {
 
-	err := pr.db.Save(&product).Error
+	err := pr.db.WithContext(ctx).Save(&product).Error
 
 	if err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return translateError(err)
 	}
 
 	return nil
 }
 
-func (pr *ProductRepository) BatchUpsert(ctx context.Context, products []entity.Product) error {
+func productSortColumn(sortBy string, at time.Time) clause.Expr {
+
+	switch sortBy {
+	case constant.ProductSortPrice:
+		return listingPrice(at)
+	case constant.ProductSortName:
+		return clause.Expr{SQL: "products.name"}
+	case constant.ProductSortBestSelling:
+		return clause.Expr{SQL: "COALESCE(sales.sold, 0)"}
+	case constant.ProductSortRating:
+		return clause.Expr{SQL: "products.rating_average"}
+	default:
+		return clause.Expr{SQL: "products.created_at"}
+	}
+}
 
-	err := pr.db.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"stock", "updated_at", "updated_by"}),
-	}).Create(&products).Error
+// productSortValue is the value of productSortColumn for a product read from a cursor
+func productSortValue(product entity.Product, sortBy string) interface{} {
+
+	switch sortBy {
+	case constant.ProductSortPrice:
+		return product.ListingPrice
+	case constant.ProductSortName:
+		return product.Name
+	case constant.ProductSortRating:
+		return product.RatingAverage
+	default:
+		return product.CreatedAt
+	}
+}
 
-	if err != nil {
+func orderDirection(ascending bool) string {
+
+	if ascending {
+		return "ASC"
+	}
+
+	return "DESC"
+}
+
+// keysetOperator selects the rows after the cursor in the reading direction
+func keysetOperator(ascending bool) string {
+
+	if ascending {
+		return ">"
+	}
+
+	return "<"
+}
+
+/*
+*
+
+	Full text search over name (weight A) and description (weight B) :
+	- Every term must match, the last characters of a term may be missing (prefix match)
+	- Ranked by ts_rank, matched words wrapped in <mark></mark>
+
+*
+*/
+func (pr *productRepository) SearchFullText(
+	ctx context.Context,
+	terms []string,
+	filter model.ProductFilter,
+	pagination model.PaginationParams) ([]ProductSearchHit, int64, error) {
+
+	tsQuery := prefixTsQuery(terms)
+
+	now := time.Now()
+	baseQuery := applyProductFilter(pr.db.WithContext(ctx).Model(&entity.Product{}), filter, now)
+	baseQuery = baseQuery.Where("products.search_vector @@ to_tsquery('simple', ?)", tsQuery)
+
+	var hits []ProductSearchHit
+	var total int64
+
+	if err := baseQuery.Count(&total).Error; err != nil {
 		logrus.Error(err)
-		return common.NewError(err, common.ErrDBOperation)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
 	}
 
-	return nil
+	nameOptions := "HighlightAll=true, StartSel=" + highlightStart + ", StopSel=" + highlightStop
+	descriptionOptions := "MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" ... \", StartSel=" + highlightStart + ", StopSel=" + highlightStop
+
+	dataQuery := baseQuery.Select(`products.*, ? AS listing_price,
+		ts_rank(products.search_vector, to_tsquery('simple', ?)) AS rank,
+		ts_headline('simple', products.name, to_tsquery('simple', ?), ?) AS name_highlight,
+		ts_headline('simple', products.description, to_tsquery('simple', ?), ?) AS description_snippet`,
+		listingPrice(now), tsQuery, tsQuery, nameOptions, tsQuery, descriptionOptions).
+		Order("rank DESC, products.id ASC")
+
+	if pagination.IsPaginate {
+		dataQuery = dataQuery.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	if err := dataQuery.Scan(&hits).Error; err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return hits, total, nil
+}
+
+// SearchFuzzy matches product names by trigram word similarity, used when full text finds nothing
+func (pr *productRepository) SearchFuzzy(
+	ctx context.Context,
+	text string,
+	filter model.ProductFilter,
+	pagination model.PaginationParams) ([]ProductSearchHit, int64, error) {
+
+	now := time.Now()
+	baseQuery := applyProductFilter(pr.db.WithContext(ctx).Model(&entity.Product{}), filter, now)
+	baseQuery = baseQuery.Where("word_similarity(?, products.name) >= ?", text, fuzzySimilarityThreshold)
+
+	var hits []ProductSearchHit
+	var total int64
+
+	if err := baseQuery.Count(&total).Error; err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	dataQuery := baseQuery.Select(`products.*, ? AS listing_price,
+		word_similarity(?, products.name) AS rank,
+		products.name AS name_highlight,
+		left(products.description, 160) AS description_snippet`, listingPrice(now), text).
+		Order("rank DESC, products.id ASC")
+
+	if pagination.IsPaginate {
+		dataQuery = dataQuery.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	if err := dataQuery.Scan(&hits).Error; err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return hits, total, nil
+}
+
+/*
+*
+
+	Listings are in one currency, filtered and sorted on the price the customer sees :
+	- A product of the currency is listed at its effective price, the sale price while the sale runs and
+	  undercuts the price (like entity.Product.PriceAt), the price otherwise
+	- Any other product is listed at its price list entry in the currency, a product without one is left out
+	- Price filters select plain columns by range, each range is read from its index
+
+*
+*/
+const saleRuns = `products.sale_price IS NOT NULL AND products.sale_price < products.price
+	AND (products.sale_starts_at IS NULL OR products.sale_starts_at <= ?)
+	AND (products.sale_ends_at IS NULL OR products.sale_ends_at > ?)`
+
+// listingPrice is the price of the product in the listing, the price list entry is joined by applyProductFilter
+func listingPrice(at time.Time) clause.Expr {
+	return clause.Expr{
+		SQL:  "COALESCE(listed.price, CASE WHEN " + saleRuns + " THEN products.sale_price ELSE products.price END)",
+		Vars: []interface{}{at, at},
+	}
+}
+
+func applyProductFilter(query *gorm.DB, filter model.ProductFilter, at time.Time) *gorm.DB {
+
+	// Only products of another currency look up the price list
+	query = query.Joins(`LEFT JOIN product_currency_prices AS listed ON listed.product_id = products.id
+		AND listed.variant_id IS NULL AND listed.currency = ? AND products.currency <> ?`, filter.Currency, filter.Currency).
+		Where("(products.currency = ? OR listed.price IS NOT NULL)", filter.Currency)
+
+	if filter.Name != "" {
+		query = query.Where("products.name ILIKE ?", "%"+filter.Name+"%")
+	}
+
+	if filter.IsActive {
+		query = query.Where("products.is_active = ?", filter.IsActive)
+	}
+
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		query = query.Where("products.id IN (?)", listedInPriceRange(filter, at))
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("products.category_id IN ?", filter.CategoryIDs)
+	}
+
+	if filter.InStockOnly {
+		query = query.Where("products.stock > 0")
+	}
+
+	// Exclude soft deleted
+	return query.Where("products.deleted_at IS NULL")
+}
+
+/*
+*
+
+	listedInPriceRange selects the products listed in the price range of the filter, in three disjoint parts :
+	- Products of the currency without a running sale, on price (idx_products_price)
+	- Products of the currency on sale, on sale_price (idx_products_sale_price)
+	- Products of another currency, on their price list entry (idx_product_currency_prices_listing)
+
+*
+*/
+func listedInPriceRange(filter model.ProductFilter, at time.Time) clause.Expr {
+
+	priceRange := func(column string) clause.Expr {
+
+		expr := clause.Expr{SQL: "TRUE"}
+
+		if filter.MinPrice != nil {
+			expr.SQL += " AND " + column + " >= ?"
+			expr.Vars = append(expr.Vars, *filter.MinPrice)
+		}
+
+		if filter.MaxPrice != nil {
+			expr.SQL += " AND " + column + " <= ?"
+			expr.Vars = append(expr.Vars, *filter.MaxPrice)
+		}
+
+		return expr
+	}
+
+	return clause.Expr{
+		SQL: `SELECT products.id FROM products WHERE products.currency = ? AND ? AND NOT (` + saleRuns + `)
+			UNION ALL
+			SELECT products.id FROM products WHERE products.currency = ? AND ? AND ` + saleRuns + `
+			UNION ALL
+			SELECT product_id FROM product_currency_prices WHERE variant_id IS NULL AND currency = ? AND ?`,
+		Vars: []interface{}{
+			filter.Currency, priceRange("products.price"), at, at,
+			filter.Currency, priceRange("products.sale_price"), at, at,
+			filter.Currency, priceRange("price"),
+		},
+	}
+}
+
+// prefixTsQuery turns sanitized terms into "term1:* & term2:*"
+func prefixTsQuery(terms []string) string {
+
+	parts := make([]string, len(terms))
+
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+
+	return strings.Join(parts, " & ")
 }
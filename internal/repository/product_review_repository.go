@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"math"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProductReviewRepository interface {
+	Create(ctx context.Context, review entity.ProductReview) (entity.ProductReview, error)
+	FindByID(ctx context.Context, id int64) (entity.ProductReview, error)
+	FindWithFilters(ctx context.Context, filter model.ReviewFilter, pagination model.PaginationParams) ([]entity.ProductReview, int64, error)
+	Update(ctx context.Context, review entity.ProductReview) error
+	Delete(ctx context.Context, id int64) error
+	SummarizePublished(ctx context.Context, productID int64) (float64, int64, error)
+}
+
+type productReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewProductReviewRepository(db *gorm.DB) ProductReviewRepository {
+	return &productReviewRepository{db: db}
+}
+
+func (prr *productReviewRepository) Create(ctx context.Context, review entity.ProductReview) (entity.ProductReview, error) {
+
+	err := prr.db.WithContext(ctx).Create(&review).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return review, translateError(err)
+	}
+
+	return review, nil
+}
+
+// FindByID locks the review until the end of the transaction
+func (prr *productReviewRepository) FindByID(ctx context.Context, id int64) (entity.ProductReview, error) {
+
+	var review entity.ProductReview
+
+	err := prr.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return review, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return review, nil
+}
+
+// FindWithFilters lists reviews newest first
+func (prr *productReviewRepository) FindWithFilters(ctx context.Context, filter model.ReviewFilter, pagination model.PaginationParams) ([]entity.ProductReview, int64, error) {
+
+	query := prr.db.WithContext(ctx).Model(&entity.ProductReview{})
+
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+
+	if filter.AccountUsername != "" {
+		query = query.Where("account_username = ?", filter.AccountUsername)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+
+	err := query.Count(&total).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	query = query.Order("created_at DESC, id DESC")
+
+	if pagination.IsPaginate {
+		query = query.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	var reviews []entity.ProductReview
+
+	err = query.Find(&reviews).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return reviews, total, nil
+}
+
+// Update writes the review content and its moderation, the author and product never change
+func (prr *productReviewRepository) Update(ctx context.Context, review entity.ProductReview) error {
+
+	err := prr.db.WithContext(ctx).
+		Model(&entity.ProductReview{ID: review.ID}).
+		Select("rating", "body", "status", "moderation_note", "moderated_at", "moderated_by", "updated_at", "updated_by").
+		Updates(&review).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (prr *productReviewRepository) Delete(ctx context.Context, id int64) error {
+
+	err := prr.db.WithContext(ctx).Delete(&entity.ProductReview{}, id).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// SummarizePublished returns the average rating, rounded to 2 decimals, and the number of published reviews
+func (prr *productReviewRepository) SummarizePublished(ctx context.Context, productID int64) (float64, int64, error) {
+
+	var summary struct {
+		Average float64
+		Count   int64
+	}
+
+	err := prr.db.WithContext(ctx).
+		Model(&entity.ProductReview{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("product_id = ? AND status = ?", productID, constant.ReviewStatusPublished).
+		Scan(&summary).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return math.Round(summary.Average*100) / 100, summary.Count, nil
+}
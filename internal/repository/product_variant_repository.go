@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+*
+
+	Variants are read with their options (and option types), options follow the option type position.
+	Soft deleted variants are never returned.
+
+*
+*/
+type ProductVariantRepository interface {
+	FindByProductID(ctx context.Context, productID int64) ([]entity.ProductVariant, error)
+	FindByIDs(ctx context.Context, ids []int64) ([]entity.ProductVariant, error)
+	FindByID(ctx context.Context, id int64) (entity.ProductVariant, error)
+	LockByIDs(ctx context.Context, ids []int64) ([]entity.ProductVariant, error)
+	DecrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error)
+	IncrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error)
+	FindOptionTypesByProductID(ctx context.Context, productID int64) ([]entity.ProductOptionType, error)
+	Create(ctx context.Context, variant entity.ProductVariant) (entity.ProductVariant, error)
+	Update(ctx context.Context, variant entity.ProductVariant) error
+	CreateOptionType(ctx context.Context, optionType entity.ProductOptionType) (entity.ProductOptionType, error)
+	UpdateOptionType(ctx context.Context, optionType entity.ProductOptionType) error
+}
+
+type productVariantRepository struct {
+	db *gorm.DB
+}
+
+func NewProductVariantRepository(db *gorm.DB) ProductVariantRepository {
+	return &productVariantRepository{db: db}
+}
+
+func (pvr *productVariantRepository) FindByProductID(ctx context.Context, productID int64) ([]entity.ProductVariant, error) {
+
+	var variants []entity.ProductVariant
+
+	err := pvr.db.WithContext(ctx).
+		Preload("Options.OptionType").
+		Where("product_id = ? AND deleted_at IS NULL", productID).
+		Order("id").
+		Find(&variants).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	sortVariantOptions(variants)
+
+	return variants, nil
+}
+
+func (pvr *productVariantRepository) FindByIDs(ctx context.Context, ids []int64) ([]entity.ProductVariant, error) {
+
+	var variants []entity.ProductVariant
+
+	err := pvr.db.WithContext(ctx).
+		Preload("Options.OptionType").
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Order("id").
+		Find(&variants).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	sortVariantOptions(variants)
+
+	return variants, nil
+}
+
+func (pvr *productVariantRepository) FindByID(ctx context.Context, id int64) (entity.ProductVariant, error) {
+
+	query := pvr.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"})
+	var variant entity.ProductVariant
+
+	err := query.Preload("Options.OptionType").Where("deleted_at IS NULL").First(&variant, id).Error
+
+	if err != nil {
+		return variant, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	sortVariantOptions([]entity.ProductVariant{variant})
+
+	return variant, nil
+}
+
+// LockByIDs locks the variants FOR UPDATE in ascending id order, always after their products
+func (pvr *productVariantRepository) LockByIDs(ctx context.Context, ids []int64) ([]entity.ProductVariant, error) {
+
+	var variants []entity.ProductVariant
+
+	err := pvr.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Options.OptionType").
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Order("id").
+		Find(&variants).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	sortVariantOptions(variants)
+
+	return variants, nil
+}
+
+// DecrementStock takes quantity from the stock only when enough is left and returns the new stock
+func (pvr *productVariantRepository) DecrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	var stock []int64
+
+	err := pvr.db.WithContext(ctx).Raw(
+		"UPDATE product_variants SET stock = stock - ?, updated_at = ?, updated_by = ? WHERE id = ? AND stock >= ? RETURNING stock",
+		quantity, time.Now(), updatedBy, id, quantity).Scan(&stock).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, translateError(err)
+	}
+
+	if len(stock) == 0 {
+		err := fmt.Errorf("insufficient stock for variant: %v , requested : %v", id, quantity)
+		logrus.Error(err)
+		return 0, common.NewError(err, common.ErrValidation)
+	}
+
+	return stock[0], nil
+}
+
+// IncrementStock adds quantity to the stock and returns the new stock
+func (pvr *productVariantRepository) IncrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	var stock []int64
+
+	err := pvr.db.WithContext(ctx).Raw(
+		"UPDATE product_variants SET stock = stock + ?, updated_at = ?, updated_by = ? WHERE id = ? RETURNING stock",
+		quantity, time.Now(), updatedBy, id).Scan(&stock).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return 0, translateError(err)
+	}
+
+	if len(stock) == 0 {
+		err := fmt.Errorf("variant not found: %d", id)
+		logrus.Error(err)
+		return 0, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return stock[0], nil
+}
+
+func (pvr *productVariantRepository) FindOptionTypesByProductID(ctx context.Context, productID int64) ([]entity.ProductOptionType, error) {
+
+	var optionTypes []entity.ProductOptionType
+
+	err := pvr.db.WithContext(ctx).Where("product_id = ?", productID).Order("position, id").Find(&optionTypes).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return optionTypes, nil
+}
+
+// Create inserts the variant and its options, only OptionTypeID and Value of the options are kept
+func (pvr *productVariantRepository) Create(ctx context.Context, variant entity.ProductVariant) (entity.ProductVariant, error) {
+
+	options := variant.Options
+
+	err := pvr.db.WithContext(ctx).Omit(clause.Associations).Create(&variant).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return variant, translateError(err)
+	}
+
+	variant.Options = make([]entity.ProductVariantOption, len(options))
+
+	for i, option := range options {
+		variant.Options[i] = entity.ProductVariantOption{
+			VariantID:    variant.ID,
+			OptionTypeID: option.OptionTypeID,
+			Value:        option.Value,
+		}
+	}
+
+	if len(variant.Options) > 0 {
+
+		err = pvr.db.WithContext(ctx).Omit(clause.Associations).Create(&variant.Options).Error
+
+		if err != nil {
+			logrus.Error(err)
+			return variant, translateError(err)
+		}
+	}
+
+	return variant, nil
+}
+
+// Update writes the SKU, the price override and the activation, the stock only changes with the ledger
+func (pvr *productVariantRepository) Update(ctx context.Context, variant entity.ProductVariant) error {
+
+	err := pvr.db.WithContext(ctx).
+		Model(&entity.ProductVariant{ID: variant.ID}).
+		Select("sku", "price", "is_active", "updated_at", "updated_by").
+		Updates(&variant).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (pvr *productVariantRepository) CreateOptionType(ctx context.Context, optionType entity.ProductOptionType) (entity.ProductOptionType, error) {
+
+	err := pvr.db.WithContext(ctx).Create(&optionType).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return optionType, translateError(err)
+	}
+
+	return optionType, nil
+}
+
+func (pvr *productVariantRepository) UpdateOptionType(ctx context.Context, optionType entity.ProductOptionType) error {
+
+	err := pvr.db.WithContext(ctx).
+		Model(&entity.ProductOptionType{ID: optionType.ID}).
+		Select("name", "position").
+		Updates(&optionType).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func sortVariantOptions(variants []entity.ProductVariant) {
+
+	for _, variant := range variants {
+		sort.Slice(variant.Options, func(i, j int) bool {
+
+			a, b := variant.Options[i].OptionType, variant.Options[j].OptionType
+
+			if a.Position != b.Position {
+				return a.Position < b.Position
+			}
+
+			return a.ID < b.ID
+		})
+	}
+}
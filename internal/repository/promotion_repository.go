@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+*
+
+	Promotions (coupons) :
+	- Codes are unique, a duplicate code is an ErrConflict
+	- FindByID and FindByCode lock the promotion until the end of the transaction,
+	  redemptions of the same promotion are checked against its limits one order at a time
+
+*
+*/
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion entity.Promotion) (entity.Promotion, error)
+	FindByID(ctx context.Context, id int64) (entity.Promotion, error)
+	FindByCode(ctx context.Context, code string) (entity.Promotion, error)
+	FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.Promotion, int64, error)
+	Update(ctx context.Context, promotion entity.Promotion) error
+}
+
+type promotionRepository struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepository(db *gorm.DB) PromotionRepository {
+	return &promotionRepository{db: db}
+}
+
+func (pr *promotionRepository) Create(ctx context.Context, promotion entity.Promotion) (entity.Promotion, error) {
+
+	err := pr.db.WithContext(ctx).Create(&promotion).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return promotion, translateError(err)
+	}
+
+	return promotion, nil
+}
+
+func (pr *promotionRepository) FindByID(ctx context.Context, id int64) (entity.Promotion, error) {
+
+	var promotion entity.Promotion
+
+	err := pr.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&promotion, id).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return promotion, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return promotion, nil
+}
+
+func (pr *promotionRepository) FindByCode(ctx context.Context, code string) (entity.Promotion, error) {
+
+	var promotion entity.Promotion
+
+	err := pr.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", code).
+		First(&promotion).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return promotion, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return promotion, nil
+}
+
+// FindAll lists promotions newest first
+func (pr *promotionRepository) FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.Promotion, int64, error) {
+
+	query := pr.db.WithContext(ctx).Model(&entity.Promotion{})
+
+	var total int64
+
+	err := query.Count(&total).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	query = query.Order("created_at DESC, id DESC")
+
+	if pagination.IsPaginate {
+		query = query.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	var promotions []entity.Promotion
+
+	err = query.Find(&promotions).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return promotions, total, nil
+}
+
+// Update writes the schedule, the limits and the description, the discount itself never changes
+func (pr *promotionRepository) Update(ctx context.Context, promotion entity.Promotion) error {
+
+	err := pr.db.WithContext(ctx).
+		Model(&entity.Promotion{ID: promotion.ID}).
+		Select("description", "usage_limit", "per_account_limit", "starts_at", "ends_at", "is_active", "updated_at", "updated_by").
+		Updates(&promotion).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
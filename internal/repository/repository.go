@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repositories groups the repositories bound to the same connection or transaction
+type Repositories struct {
+	Account      AccountRepository
+	Order        OrderRepository
+	OrderItem    OrderItemRepository
+	Payment      PaymentRepository
+	Product      ProductRepository
+	Variant      ProductVariantRepository
+	Image        ProductImageRepository
+	Movement     InventoryMovementRepository
+	Subscription StockSubscriptionRepository
+	Review       ProductReviewRepository
+	Wishlist     WishlistRepository
+	Promotion    PromotionRepository
+	Discount     OrderDiscountRepository
+	PriceChange  ProductPriceChangeRepository
+	PriceHistory ProductPriceHistoryRepository
+	PriceList    ProductCurrencyPriceRepository
+	TaxRule      TaxRuleRepository
+	ItemTax      OrderItemTaxRepository
+	Shipping     ShippingMethodRepository
+	Shipment     ShipmentRepository
+	Address      AddressRepository
+	History      OrderHistoryRepository
+}
+
+// TransactionRunner runs fn inside a single transaction, the transaction is rolled back
+// when fn returns an error and committed otherwise
+type TransactionRunner interface {
+	WithinTransaction(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+func NewRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Account:      NewAccountRepository(db),
+		Order:        NewOrderRepository(db),
+		OrderItem:    NewOrderItemRepository(db),
+		Payment:      NewPaymentRepository(db),
+		Product:      NewProductRepository(db),
+		Variant:      NewProductVariantRepository(db),
+		Image:        NewProductImageRepository(db),
+		Movement:     NewInventoryMovementRepository(db),
+		Subscription: NewStockSubscriptionRepository(db),
+		Review:       NewProductReviewRepository(db),
+		Wishlist:     NewWishlistRepository(db),
+		Promotion:    NewPromotionRepository(db),
+		Discount:     NewOrderDiscountRepository(db),
+		PriceChange:  NewProductPriceChangeRepository(db),
+		PriceHistory: NewProductPriceHistoryRepository(db),
+		PriceList:    NewProductCurrencyPriceRepository(db),
+		TaxRule:      NewTaxRuleRepository(db),
+		ItemTax:      NewOrderItemTaxRepository(db),
+		Shipping:     NewShippingMethodRepository(db),
+		Shipment:     NewShipmentRepository(db),
+		Address:      NewAddressRepository(db),
+		History:      NewOrderHistoryRepository(db),
+	}
+}
+
+type gormTransactionRunner struct {
+	db *gorm.DB
+}
+
+func NewTransactionRunner(db *gorm.DB) TransactionRunner {
+	return &gormTransactionRunner{db: db}
+}
+
+func (tr *gormTransactionRunner) WithinTransaction(ctx context.Context, fn func(repos Repositories) error) error {
+
+	return tr.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepositories(tx))
+	})
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+*
+
+	Shipments :
+	- Create writes the shipment with its items, a tracking number already used by the carrier is an ErrConflict
+	- FindByReference locks the shipment until the end of the transaction
+	- The shipments of an order are loaded with it by OrderRepository.FindByIDWithItems
+
+*
+*/
+type ShipmentRepository interface {
+	Create(ctx context.Context, shipment entity.Shipment) error
+	FindByReference(ctx context.Context, shipmentReference string) (entity.Shipment, error)
+	Update(ctx context.Context, shipment entity.Shipment) error
+}
+
+type shipmentRepository struct {
+	db *gorm.DB
+}
+
+func NewShipmentRepository(db *gorm.DB) ShipmentRepository {
+	return &shipmentRepository{db: db}
+}
+
+func (sr *shipmentRepository) Create(ctx context.Context, shipment entity.Shipment) error {
+
+	err := sr.db.WithContext(ctx).Create(&shipment).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (sr *shipmentRepository) FindByReference(ctx context.Context, shipmentReference string) (entity.Shipment, error) {
+
+	var shipment entity.Shipment
+
+	err := sr.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Where("shipment_reference = ?", shipmentReference).
+		First(&shipment).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return shipment, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return shipment, nil
+}
+
+// Update writes the carrier, the tracking number and the dates, the items never change
+func (sr *shipmentRepository) Update(ctx context.Context, shipment entity.Shipment) error {
+
+	err := sr.db.WithContext(ctx).
+		Model(&entity.Shipment{ShipmentReference: shipment.ShipmentReference}).
+		Select("carrier", "tracking_number", "shipped_at", "delivered_at", "updated_at", "updated_by").
+		Updates(&shipment).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+*
+
+	Shipping methods :
+	- Codes are unique, a duplicate code is an ErrConflict
+	- FindByID locks the method until the end of the transaction
+
+*
+*/
+type ShippingMethodRepository interface {
+	Create(ctx context.Context, method entity.ShippingMethod) (entity.ShippingMethod, error)
+	FindByID(ctx context.Context, id int64) (entity.ShippingMethod, error)
+	FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.ShippingMethod, int64, error)
+	FindActive(ctx context.Context) ([]entity.ShippingMethod, error)
+	Update(ctx context.Context, method entity.ShippingMethod) error
+}
+
+type shippingMethodRepository struct {
+	db *gorm.DB
+}
+
+func NewShippingMethodRepository(db *gorm.DB) ShippingMethodRepository {
+	return &shippingMethodRepository{db: db}
+}
+
+func (smr *shippingMethodRepository) Create(ctx context.Context, method entity.ShippingMethod) (entity.ShippingMethod, error) {
+
+	err := smr.db.WithContext(ctx).Create(&method).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return method, translateError(err)
+	}
+
+	return method, nil
+}
+
+func (smr *shippingMethodRepository) FindByID(ctx context.Context, id int64) (entity.ShippingMethod, error) {
+
+	var method entity.ShippingMethod
+
+	err := smr.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&method, id).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return method, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return method, nil
+}
+
+// FindAll lists shipping methods newest first
+func (smr *shippingMethodRepository) FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.ShippingMethod, int64, error) {
+
+	query := smr.db.WithContext(ctx).Model(&entity.ShippingMethod{})
+
+	var total int64
+
+	err := query.Count(&total).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	query = query.Order("created_at DESC, id DESC")
+
+	if pagination.IsPaginate {
+		query = query.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	var methods []entity.ShippingMethod
+
+	err = query.Find(&methods).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return methods, total, nil
+}
+
+// FindActive lists the active methods by id
+func (smr *shippingMethodRepository) FindActive(ctx context.Context) ([]entity.ShippingMethod, error) {
+
+	var methods []entity.ShippingMethod
+
+	err := smr.db.WithContext(ctx).
+		Where("is_active").
+		Order("id").
+		Find(&methods).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return methods, nil
+}
+
+// Update writes the name, the fees and the limits, the code, the type and the region never change
+func (smr *shippingMethodRepository) Update(ctx context.Context, method entity.ShippingMethod) error {
+
+	err := smr.db.WithContext(ctx).
+		Model(&entity.ShippingMethod{ID: method.ID}).
+		Select("name", "flat_fee", "tiers", "free_above", "max_weight", "is_active", "updated_at", "updated_by").
+		Updates(&method).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+/*
+*
+
+	Pending subscriptions are the ones not notified yet :
+	- An account has at most one pending subscription per product (ErrConflict)
+	- ClaimPending marks every pending subscription of a product as notified and returns them,
+	  concurrent claims never return the same subscription twice
+
+*
+*/
+type StockSubscriptionRepository interface {
+	Create(ctx context.Context, subscription entity.StockSubscription) (entity.StockSubscription, error)
+	DeletePending(ctx context.Context, productID int64, username string) error
+	FindPendingByAccount(ctx context.Context, username string) ([]entity.StockSubscription, error)
+	ClaimPending(ctx context.Context, productID int64, notifiedAt time.Time) ([]entity.StockSubscription, error)
+}
+
+type stockSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewStockSubscriptionRepository(db *gorm.DB) StockSubscriptionRepository {
+	return &stockSubscriptionRepository{db: db}
+}
+
+func (ssr *stockSubscriptionRepository) Create(ctx context.Context, subscription entity.StockSubscription) (entity.StockSubscription, error) {
+
+	err := ssr.db.WithContext(ctx).Create(&subscription).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return subscription, translateError(err)
+	}
+
+	return subscription, nil
+}
+
+func (ssr *stockSubscriptionRepository) DeletePending(ctx context.Context, productID int64, username string) error {
+
+	result := ssr.db.WithContext(ctx).
+		Where("product_id = ? AND account_username = ? AND notified_at IS NULL", productID, username).
+		Delete(&entity.StockSubscription{})
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return translateError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		err := fmt.Errorf("no pending stock subscription for product %d", productID)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return nil
+}
+
+// FindPendingByAccount returns the pending subscriptions of an account, oldest first
+func (ssr *stockSubscriptionRepository) FindPendingByAccount(ctx context.Context, username string) ([]entity.StockSubscription, error) {
+
+	var subscriptions []entity.StockSubscription
+
+	err := ssr.db.WithContext(ctx).
+		Where("account_username = ? AND notified_at IS NULL", username).
+		Order("id").
+		Find(&subscriptions).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return subscriptions, nil
+}
+
+func (ssr *stockSubscriptionRepository) ClaimPending(ctx context.Context, productID int64, notifiedAt time.Time) ([]entity.StockSubscription, error) {
+
+	var subscriptions []entity.StockSubscription
+
+	err := ssr.db.WithContext(ctx).Raw(
+		"UPDATE stock_subscriptions SET notified_at = ? WHERE product_id = ? AND notified_at IS NULL RETURNING *",
+		notifiedAt, productID).Scan(&subscriptions).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, translateError(err)
+	}
+
+	return subscriptions, nil
+}
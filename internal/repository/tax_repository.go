@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+*
+
+	Tax rules :
+	- A tax has at most one active rule per scope (region and category), a duplicate is an ErrConflict
+	- FindByID locks the rule until the end of the transaction
+
+*
+*/
+type TaxRuleRepository interface {
+	Create(ctx context.Context, rule entity.TaxRule) (entity.TaxRule, error)
+	FindByID(ctx context.Context, id int64) (entity.TaxRule, error)
+	FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.TaxRule, int64, error)
+	FindActive(ctx context.Context) ([]entity.TaxRule, error)
+	Update(ctx context.Context, rule entity.TaxRule) error
+}
+
+type taxRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxRuleRepository(db *gorm.DB) TaxRuleRepository {
+	return &taxRuleRepository{db: db}
+}
+
+func (trr *taxRuleRepository) Create(ctx context.Context, rule entity.TaxRule) (entity.TaxRule, error) {
+
+	err := trr.db.WithContext(ctx).Create(&rule).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return rule, translateError(err)
+	}
+
+	return rule, nil
+}
+
+func (trr *taxRuleRepository) FindByID(ctx context.Context, id int64) (entity.TaxRule, error) {
+
+	var rule entity.TaxRule
+
+	err := trr.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&rule, id).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return rule, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return rule, nil
+}
+
+// FindAll lists tax rules newest first
+func (trr *taxRuleRepository) FindAll(ctx context.Context, pagination model.PaginationParams) ([]entity.TaxRule, int64, error) {
+
+	query := trr.db.WithContext(ctx).Model(&entity.TaxRule{})
+
+	var total int64
+
+	err := query.Count(&total).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	query = query.Order("created_at DESC, id DESC")
+
+	if pagination.IsPaginate {
+		query = query.Offset(pagination.GetOffset()).Limit(pagination.PerPage)
+	}
+
+	var rules []entity.TaxRule
+
+	err = query.Find(&rules).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, 0, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return rules, total, nil
+}
+
+// FindActive lists the active rules by id, the order computes its taxes from them
+func (trr *taxRuleRepository) FindActive(ctx context.Context) ([]entity.TaxRule, error) {
+
+	var rules []entity.TaxRule
+
+	err := trr.db.WithContext(ctx).
+		Where("is_active").
+		Order("id").
+		Find(&rules).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return rules, nil
+}
+
+// Update writes the name, the rate, the mode and the activation, the scope never changes
+func (trr *taxRuleRepository) Update(ctx context.Context, rule entity.TaxRule) error {
+
+	err := trr.db.WithContext(ctx).
+		Model(&entity.TaxRule{ID: rule.ID}).
+		Select("name", "rate", "mode", "is_active", "updated_at", "updated_by").
+		Updates(&rule).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+type OrderItemTaxRepository interface {
+	CreateBatch(ctx context.Context, taxes []entity.OrderItemTax) error
+	DeleteByOrderReference(ctx context.Context, orderReference string) error
+}
+
+type orderItemTaxRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderItemTaxRepository(db *gorm.DB) OrderItemTaxRepository {
+	return &orderItemTaxRepository{db: db}
+}
+
+func (oitr *orderItemTaxRepository) CreateBatch(ctx context.Context, taxes []entity.OrderItemTax) error {
+
+	if len(taxes) == 0 {
+		return nil
+	}
+
+	err := oitr.db.WithContext(ctx).Create(&taxes).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// DeleteByOrderReference deletes the taxes of every item of the order, they are computed again
+func (oitr *orderItemTaxRepository) DeleteByOrderReference(ctx context.Context, orderReference string) error {
+
+	err := oitr.db.WithContext(ctx).Where("order_reference = ?", orderReference).Delete(&entity.OrderItemTax{}).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return translateError(err)
+	}
+
+	return nil
+}
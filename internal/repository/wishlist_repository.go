@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+/*
+*
+
+	Wishlist of the accounts :
+	- An account saves a product (or variant) once, a duplicate is an ErrConflict
+	- Delete only removes items of the given account and returns how many were removed
+	- LowerReferencePrice succeeds only while the reference price is above the new price,
+	  concurrent price drop checks never notify the same drop twice
+
+*
+*/
+type WishlistRepository interface {
+	Create(ctx context.Context, item entity.WishlistItem) (entity.WishlistItem, error)
+	FindByAccount(ctx context.Context, username string) ([]entity.WishlistItem, error)
+	FindByProductIDs(ctx context.Context, productIDs []int64) ([]entity.WishlistItem, error)
+	FindProductIDs(ctx context.Context) ([]int64, error)
+	Delete(ctx context.Context, username string, ids []int64) (int64, error)
+	LowerReferencePrice(ctx context.Context, id int64, price int64) (bool, error)
+}
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+func (wr *wishlistRepository) Create(ctx context.Context, item entity.WishlistItem) (entity.WishlistItem, error) {
+
+	err := wr.db.WithContext(ctx).Create(&item).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return item, translateError(err)
+	}
+
+	return item, nil
+}
+
+// FindByAccount lists the wishlist of an account, last saved first
+func (wr *wishlistRepository) FindByAccount(ctx context.Context, username string) ([]entity.WishlistItem, error) {
+
+	var items []entity.WishlistItem
+
+	err := wr.db.WithContext(ctx).
+		Where("account_username = ?", username).
+		Order("created_at DESC, id DESC").
+		Find(&items).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return items, nil
+}
+
+func (wr *wishlistRepository) FindByProductIDs(ctx context.Context, productIDs []int64) ([]entity.WishlistItem, error) {
+
+	var items []entity.WishlistItem
+
+	err := wr.db.WithContext(ctx).
+		Where("product_id IN ?", productIDs).
+		Order("id").
+		Find(&items).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return items, nil
+}
+
+// FindProductIDs lists the products saved in at least one wishlist
+func (wr *wishlistRepository) FindProductIDs(ctx context.Context) ([]int64, error) {
+
+	var productIDs []int64
+
+	err := wr.db.WithContext(ctx).
+		Model(&entity.WishlistItem{}).
+		Distinct("product_id").
+		Order("product_id").
+		Pluck("product_id", &productIDs).Error
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrDBOperation)
+	}
+
+	return productIDs, nil
+}
+
+func (wr *wishlistRepository) Delete(ctx context.Context, username string, ids []int64) (int64, error) {
+
+	result := wr.db.WithContext(ctx).
+		Where("account_username = ? AND id IN ?", username, ids).
+		Delete(&entity.WishlistItem{})
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return 0, translateError(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+func (wr *wishlistRepository) LowerReferencePrice(ctx context.Context, id int64, price int64) (bool, error) {
+
+	result := wr.db.WithContext(ctx).
+		Model(&entity.WishlistItem{}).
+		Where("id = ? AND reference_price > ?", id, price).
+		Updates(map[string]interface{}{
+			"reference_price": price,
+			"updated_at":      time.Now(),
+		})
+
+	if result.Error != nil {
+		logrus.Error(result.Error)
+		return false, translateError(result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/jhasudungan/terraloom-core-api/internal/handler"
 )
 
-func SetupAccountRoutes(accountHandler *handler.AccountHandler, orderHandler *handler.OrderHandler, authMiddleware gin.HandlerFunc, router *gin.Engine) *gin.Engine {
+func SetupAccountRoutes(accountHandler *handler.AccountHandler, orderHandler *handler.OrderHandler, stockSubscriptionHandler *handler.StockSubscriptionHandler, productReviewHandler *handler.ProductReviewHandler, wishlistHandler *handler.WishlistHandler, addressHandler *handler.AddressHandler, guestCheckoutHandler *handler.GuestCheckoutHandler, authMiddleware gin.HandlerFunc, router *gin.Engine) *gin.Engine {
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -18,6 +18,28 @@ func SetupAccountRoutes(accountHandler *handler.AccountHandler, orderHandler *ha
 			account.GET("/orders", accountHandler.GetAccountOrders)
 			account.PUT("/update", accountHandler.UpdateAccount)
 			account.PUT("/update/password", accountHandler.UpdatePassword)
+			account.POST("/email/verification", accountHandler.RequestEmailVerification)
+			account.POST("/email/verify", accountHandler.VerifyEmail)
+			account.POST("/guest-orders/claim", guestCheckoutHandler.ClaimGuestOrders)
+
+			account.GET("/stock-subscriptions", stockSubscriptionHandler.GetStockSubscriptions)
+			account.POST("/stock-subscriptions", stockSubscriptionHandler.Subscribe)
+			account.DELETE("/stock-subscriptions/:productId", stockSubscriptionHandler.Unsubscribe)
+
+			account.GET("/reviews", productReviewHandler.GetAccountReviews)
+			account.POST("/reviews", productReviewHandler.CreateReview)
+			account.PUT("/reviews/:reviewId", productReviewHandler.UpdateReview)
+			account.DELETE("/reviews/:reviewId", productReviewHandler.DeleteReview)
+
+			account.GET("/wishlist", wishlistHandler.GetWishlist)
+			account.POST("/wishlist", wishlistHandler.AddToWishlist)
+			account.DELETE("/wishlist/:itemId", wishlistHandler.RemoveFromWishlist)
+			account.POST("/wishlist/order", wishlistHandler.OrderWishlistItems)
+
+			account.GET("/addresses", addressHandler.GetAddresses)
+			account.POST("/addresses", addressHandler.CreateAddress)
+			account.PUT("/addresses/:addressId", addressHandler.UpdateAddress)
+			account.DELETE("/addresses/:addressId", addressHandler.DeleteAddress)
 		}
 	}
 
@@ -0,0 +1,72 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/handler"
+)
+
+// Catalogue management, restricted to staff and admin accounts by roleMiddleware
+func SetupAdminRoutes(
+	productImageHandler *handler.ProductImageHandler,
+	inventoryHandler *handler.InventoryHandler,
+	productReviewHandler *handler.ProductReviewHandler,
+	promotionHandler *handler.PromotionHandler,
+	pricingHandler *handler.PricingHandler,
+	taxHandler *handler.TaxHandler,
+	shippingHandler *handler.ShippingHandler,
+	shipmentHandler *handler.ShipmentHandler,
+	variantHandler *handler.VariantHandler,
+	authMiddleware gin.HandlerFunc,
+	roleMiddleware gin.HandlerFunc,
+	router *gin.Engine) *gin.Engine {
+
+	// API v1 routes
+	v1 := router.Group("/api/v1")
+	{
+		admin := v1.Group("/admin")
+		admin.Use(authMiddleware, roleMiddleware)
+		{
+			admin.POST("/products/:id/images", productImageHandler.UploadProductImage)
+			admin.PATCH("/products/:id/images/:imageId", productImageHandler.UpdateProductImage)
+			admin.DELETE("/products/:id/images/:imageId", productImageHandler.DeleteProductImage)
+
+			admin.POST("/products/:id/option-types", variantHandler.CreateOptionType)
+			admin.PATCH("/products/:id/option-types/:optionTypeId", variantHandler.UpdateOptionType)
+			admin.POST("/products/:id/variants", variantHandler.CreateVariant)
+			admin.PATCH("/products/:id/variants/:variantId", variantHandler.UpdateVariant)
+
+			admin.GET("/products/:id/inventory-movements", inventoryHandler.GetInventoryMovements)
+			admin.POST("/products/:id/inventory-movements", inventoryHandler.AdjustStock)
+			admin.PUT("/products/:id/low-stock-threshold", inventoryHandler.UpdateLowStockThreshold)
+			admin.PUT("/products/:id/dimensions", shippingHandler.UpdateProductDimensions)
+
+			admin.GET("/products/:id/pricing", pricingHandler.GetPricing)
+			admin.POST("/products/:id/price-changes", pricingHandler.SchedulePriceChange)
+			admin.DELETE("/products/:id/price-changes/:changeId", pricingHandler.CancelPriceChange)
+			admin.PUT("/products/:id/sale", pricingHandler.SetSale)
+			admin.DELETE("/products/:id/sale", pricingHandler.EndSale)
+			admin.PUT("/products/:id/currency-prices", pricingHandler.SetCurrencyPrice)
+			admin.GET("/products/:id/price-history", pricingHandler.GetPriceHistory)
+
+			admin.GET("/reviews", productReviewHandler.GetReviewsForModeration)
+			admin.PATCH("/reviews/:reviewId", productReviewHandler.ModerateReview)
+
+			admin.GET("/promotions", promotionHandler.GetPromotions)
+			admin.POST("/promotions", promotionHandler.CreatePromotion)
+			admin.PATCH("/promotions/:promotionId", promotionHandler.UpdatePromotion)
+
+			admin.GET("/tax-rules", taxHandler.GetTaxRules)
+			admin.POST("/tax-rules", taxHandler.CreateTaxRule)
+			admin.PATCH("/tax-rules/:ruleId", taxHandler.UpdateTaxRule)
+
+			admin.GET("/shipping-methods", shippingHandler.GetShippingMethods)
+			admin.POST("/shipping-methods", shippingHandler.CreateShippingMethod)
+			admin.PATCH("/shipping-methods/:methodId", shippingHandler.UpdateShippingMethod)
+
+			admin.POST("/orders/:orderReference/shipments", shipmentHandler.CreateShipment)
+			admin.PATCH("/shipments/:shipmentReference", shipmentHandler.UpdateShipment)
+		}
+	}
+
+	return router
+}
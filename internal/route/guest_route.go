@@ -0,0 +1,23 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/handler"
+)
+
+// SetupGuestRoutes are the checkout routes without an account, a guest order is opened by its access token
+func SetupGuestRoutes(guestCheckoutHandler *handler.GuestCheckoutHandler, router *gin.Engine) *gin.Engine {
+
+	// API v1 routes
+	v1 := router.Group("/api/v1")
+	{
+		guest := v1.Group("/guest")
+		{
+			guest.POST("/orders", guestCheckoutHandler.SubmitGuestOrder)
+			guest.GET("/orders/:orderReference", guestCheckoutHandler.GetGuestOrder)
+			guest.POST("/orders/:orderReference/payment", guestCheckoutHandler.PayGuestOrder)
+		}
+	}
+
+	return router
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/jhasudungan/terraloom-core-api/internal/handler"
 )
 
-func SetupOrderRoutes(orderHandler *handler.OrderHandler, authMiddleware gin.HandlerFunc, router *gin.Engine) *gin.Engine {
+func SetupOrderRoutes(orderHandler *handler.OrderHandler, shipmentHandler *handler.ShipmentHandler, authMiddleware gin.HandlerFunc, router *gin.Engine) *gin.Engine {
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -17,6 +17,9 @@ func SetupOrderRoutes(orderHandler *handler.OrderHandler, authMiddleware gin.Han
 			order.POST("/submit", orderHandler.SubmitOrder)
 			order.POST("/cancel", orderHandler.CancelOrder)
 			order.GET("/detail/:orderReference", orderHandler.GetOrderDetail)
+			order.GET("/tracking/:orderReference", shipmentHandler.GetOrderTracking)
+			order.POST("/:orderReference/reorder", orderHandler.Reorder)
+			order.POST("/:orderReference/modify", orderHandler.ModifyOrder)
 		}
 
 	}
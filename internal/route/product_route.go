@@ -5,7 +5,7 @@ import (
 	"github.com/jhasudungan/terraloom-core-api/internal/handler"
 )
 
-func SetupProductRoutes(productHandler *handler.ProductHandler, router *gin.Engine) *gin.Engine {
+func SetupProductRoutes(productHandler *handler.ProductHandler, productReviewHandler *handler.ProductReviewHandler, router *gin.Engine) *gin.Engine {
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
@@ -14,11 +14,13 @@ func SetupProductRoutes(productHandler *handler.ProductHandler, router *gin.Engi
 		products := v1.Group("/products")
 		{
 			products.GET("", productHandler.GetProducts)
+			products.GET("/search", productHandler.SearchProducts)
 		}
 
 		product := v1.Group("/product")
 		{
 			product.GET("/:id", productHandler.GetProductDetail)
+			product.GET("/:id/reviews", productReviewHandler.GetProductReviews)
 		}
 	}
 
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/handler"
+)
+
+func SetupShippingRoutes(shippingHandler *handler.ShippingHandler, router *gin.Engine) *gin.Engine {
+
+	// API v1 routes
+	v1 := router.Group("/api/v1")
+	{
+		// Shipping routes
+		shipping := v1.Group("/shipping")
+		{
+			shipping.POST("/quote", shippingHandler.QuoteShipping)
+		}
+	}
+
+	return router
+}
@@ -2,29 +2,44 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"math/big"
 	"net/mail"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
 	"github.com/jhasudungan/terraloom-core-api/internal/constant"
 	"github.com/jhasudungan/terraloom-core-api/internal/entity"
 	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
 	"github.com/jhasudungan/terraloom-core-api/internal/repository"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	emailVerificationLifetime      = 30 * time.Minute
+	maxEmailVerificationAttempts   = 5
+	emailVerificationRequestWindow = time.Hour
+	maxEmailVerificationRequests   = 5
+)
+
 type AccountService struct {
 	jwtService        *JwtService
-	accountRepository *repository.AccountRepository
+	accountRepository repository.AccountRepository
+	notifier          notification.Notifier
 }
 
-func NewAccountService(jwtService *JwtService, accountRepository *repository.AccountRepository) *AccountService {
+func NewAccountService(jwtService *JwtService, accountRepository repository.AccountRepository, notifier notification.Notifier) *AccountService {
 	return &AccountService{
 		jwtService:        jwtService,
 		accountRepository: accountRepository,
+		notifier:          notifier,
 	}
 }
 
@@ -56,6 +71,7 @@ func (a *AccountService) Register(ctx context.Context, request model.RegisterReq
 		UpdatedAt:         time.Now(),
 		CreatedAt:         time.Now(),
 		IsActive:          true,
+		Role:              constant.AccountRoleCustomer,
 		RegisteredAddress: request.RegisteredAddress}
 
 	err = a.accountRepository.Create(ctx, newAccount)
@@ -75,8 +91,10 @@ func (a *AccountService) Register(ctx context.Context, request model.RegisterReq
 		Username:          newAccount.Username,
 		DisplayName:       newAccount.DisplayName,
 		Email:             newAccount.Email,
+		EmailVerified:     newAccount.EmailVerifiedAt != nil,
 		RegisteredAddress: newAccount.RegisteredAddress,
-		IsActive:          newAccount.IsActive}
+		IsActive:          newAccount.IsActive,
+		Role:              newAccount.Role}
 
 	responseData := model.RegisterResponseData{
 		Account: accountDTO,
@@ -121,8 +139,8 @@ func (a *AccountService) Login(ctx context.Context, request model.LoginRequest)
 	}
 
 	// Format as ISO 8601 string
-	expiry := time.Now().Add(24 * time.Hour).Unix()
-	expiredAt := time.Now().Add(24 * time.Hour)
+	expiredAt := time.Now().Add(a.jwtService.TokenLifetime)
+	expiry := expiredAt.Unix()
 	expiredAtString := expiredAt.Format(time.RFC3339Nano)
 
 	token, err := a.jwtService.GenerateJWT(account.Username, expiry)
@@ -142,8 +160,10 @@ func (a *AccountService) Login(ctx context.Context, request model.LoginRequest)
 		Username:          account.Username,
 		DisplayName:       account.DisplayName,
 		Email:             account.Email,
+		EmailVerified:     account.EmailVerifiedAt != nil,
 		RegisteredAddress: account.RegisteredAddress,
-		IsActive:          account.IsActive}
+		IsActive:          account.IsActive,
+		Role:              account.Role}
 
 	loginResponseData := model.LoginRepsonseData{
 		Token:   tokenDTO,
@@ -172,8 +192,10 @@ func (a *AccountService) GetAccountDetail(ctx context.Context, request model.Get
 		Username:          account.Username,
 		DisplayName:       account.DisplayName,
 		Email:             account.Email,
+		EmailVerified:     account.EmailVerifiedAt != nil,
 		RegisteredAddress: account.RegisteredAddress,
-		IsActive:          account.IsActive}
+		IsActive:          account.IsActive,
+		Role:              account.Role}
 
 	responseData := model.GetAccountDetailResponseData{
 		Account: accountDTO,
@@ -202,6 +224,13 @@ func (a *AccountService) UpdateAccount(ctx context.Context, request model.Update
 		return response, common.NewError(err, common.ErrValidation)
 	}
 
+	// A new email is not verified, a pending code was sent to the old one
+	if account.Email != request.Email {
+		account.EmailVerifiedAt = nil
+		account.EmailVerificationHash = ""
+		account.EmailVerificationExpiresAt = nil
+	}
+
 	account.Email = request.Email
 	account.DisplayName = request.DiplayName
 	account.RegisteredAddress = request.RegisteredAddress
@@ -219,8 +248,10 @@ func (a *AccountService) UpdateAccount(ctx context.Context, request model.Update
 		Username:          account.Username,
 		DisplayName:       account.DisplayName,
 		Email:             account.Email,
+		EmailVerified:     account.EmailVerifiedAt != nil,
 		RegisteredAddress: account.RegisteredAddress,
-		IsActive:          account.IsActive}
+		IsActive:          account.IsActive,
+		Role:              account.Role}
 
 	responseData := model.UpdateAccountResponseData{
 		Account: accountDTO,
@@ -282,8 +313,10 @@ func (a *AccountService) UpdatePassword(ctx context.Context, request model.Updat
 		Username:          account.Username,
 		DisplayName:       account.DisplayName,
 		Email:             account.Email,
+		EmailVerified:     account.EmailVerifiedAt != nil,
 		RegisteredAddress: account.RegisteredAddress,
-		IsActive:          account.IsActive}
+		IsActive:          account.IsActive,
+		Role:              account.Role}
 
 	responseData := model.UpdateAccountResponseData{
 		Account: accountDTO,
@@ -296,6 +329,164 @@ func (a *AccountService) UpdatePassword(ctx context.Context, request model.Updat
 	return response, nil
 }
 
+/*
+*
+
+	Email verification :
+	- A 6 digit code is sent to the email of the account, it expires after 30 minutes
+	- Requesting a new code replaces the pending one, a code allows 5 attempts
+	- An account requests up to 5 codes an hour, a new code does not give unlimited attempts
+	- Changing the email drops the verification, the new email is verified again
+
+*
+*/
+func (a *AccountService) RequestEmailVerification(ctx context.Context, request model.EmailVerificationRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	account, err := a.accountRepository.FindByUsername(ctx, request.Username)
+
+	if err != nil {
+		return response, err
+	}
+
+	if account.EmailVerifiedAt != nil {
+		err := fmt.Errorf("email of %s is already verified", account.Username)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrConflict)
+	}
+
+	number, err := rand.Int(rand.Reader, big.NewInt(1000000))
+
+	if err != nil {
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrConflict)
+	}
+
+	code := fmt.Sprintf("%06d", number.Int64())
+	now := time.Now()
+	expiresAt := now.Add(emailVerificationLifetime)
+
+	account.EmailVerificationHash = hashSecret(account.Username + ":" + code)
+	account.EmailVerificationExpiresAt = &expiresAt
+	account.UpdatedAt = now
+	account.UpdatedBy = account.Username
+
+	// Counted and saved in one statement, concurrent requests do not pass the limit
+	account, err = a.accountRepository.StartEmailVerification(ctx, account, now.Add(-emailVerificationRequestWindow), maxEmailVerificationRequests)
+
+	if err != nil {
+		return response, err
+	}
+
+	// The code is saved, a failed delivery is retried by requesting another code
+	err = a.notifier.NotifyEmailVerification(ctx, notification.EmailVerificationNotice{
+		AccountUsername: account.Username,
+		Email:           account.Email,
+		Code:            code,
+		ExpiresAt:       expiresAt,
+	})
+
+	if err != nil {
+		logrus.Errorf("email verification to %s: %v", account.Username, err)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.EmailVerificationResponseData{
+		Email:     account.Email,
+		ExpiresAt: expiresAt,
+	}
+
+	return response, nil
+}
+
+func (a *AccountService) VerifyEmail(ctx context.Context, request model.VerifyEmailRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	account, err := a.accountRepository.FindByUsername(ctx, request.Username)
+
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+
+	if account.EmailVerifiedAt != nil {
+		err := fmt.Errorf("email of %s is already verified", account.Username)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrConflict)
+	}
+
+	if account.EmailVerificationHash == "" || account.EmailVerificationExpiresAt == nil || !now.Before(*account.EmailVerificationExpiresAt) {
+		err := errors.New("no verification code pending, request a new code")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	// The attempt is counted before the code is compared, concurrent attempts do not pass the limit
+	account, err = a.accountRepository.CountEmailVerificationAttempt(ctx, account.Username, maxEmailVerificationAttempts)
+
+	if err != nil {
+		return response, err
+	}
+
+	if hashSecret(account.Username+":"+strings.TrimSpace(request.Code)) != account.EmailVerificationHash {
+		err := errors.New("verification code is not valid")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	account.EmailVerifiedAt = &now
+	account.EmailVerificationHash = ""
+	account.EmailVerificationExpiresAt = nil
+	account.UpdatedAt = now
+	account.UpdatedBy = account.Username
+
+	err = a.accountRepository.Update(ctx, account)
+
+	if err != nil {
+		return response, err
+	}
+
+	accountDTO := model.AccountDTO{
+		ID:                account.ID,
+		Username:          account.Username,
+		DisplayName:       account.DisplayName,
+		Email:             account.Email,
+		EmailVerified:     account.EmailVerifiedAt != nil,
+		RegisteredAddress: account.RegisteredAddress,
+		IsActive:          account.IsActive,
+		Role:              account.Role}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.UpdateAccountResponseData{
+		Account: accountDTO,
+	}
+
+	return response, nil
+}
+
+// Authorize fails with ErrAccessDenied unless the account is active and has one of the roles
+func (a *AccountService) Authorize(ctx context.Context, username string, roles ...string) error {
+
+	account, err := a.accountRepository.FindByUsername(ctx, username)
+
+	if err != nil {
+		return common.NewError(err, common.ErrAccessDenied)
+	}
+
+	if !account.IsActive || !slices.Contains(roles, account.Role) {
+		err := errors.New("insufficient role")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrAccessDenied)
+	}
+
+	return nil
+}
+
 func (a *AccountService) validateRegisterRequest(ctx context.Context, request model.RegisterRequest) error {
 
 	if request.Username == "" || request.DiplayName == "" || request.Email == "" || request.LoginPassword == "" {
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxAddressesPerAccount = 20
+	maxAddressNameLength   = 100
+	maxAddressLineLength   = 200
+	maxAddressCityLength   = 100
+)
+
+var (
+	phonePattern      = regexp.MustCompile(`^\+?[0-9 -]{6,20}$`)
+	postalCodePattern = regexp.MustCompile(`^[A-Z0-9 -]{3,10}$`)
+	countryPattern    = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+/*
+*
+
+	Address book of the accounts :
+	- The first address of an account becomes its default, isDefault moves the default to another address
+	- The default address stays the default until another one is chosen, deleting it promotes the oldest address left
+	- An order placed to an address keeps a copy of it, editing or deleting the address does not change the order
+	- The region is a tax and shipping region, the order takes its region from the address
+
+*
+*/
+type AddressService struct {
+	txRunner          repository.TransactionRunner
+	addressRepository repository.AddressRepository
+}
+
+func NewAddressService(txRunner repository.TransactionRunner, addressRepository repository.AddressRepository) *AddressService {
+	return &AddressService{
+		txRunner:          txRunner,
+		addressRepository: addressRepository,
+	}
+}
+
+func (as *AddressService) GetAddresses(ctx context.Context, request model.GetAddressesRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	addresses, err := as.addressRepository.FindByAccount(ctx, request.Username)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetAddressesResponseData{
+		Addresses: newAddressDTOs(addresses),
+	}
+
+	return response, nil
+}
+
+func (as *AddressService) CreateAddress(ctx context.Context, request model.SaveAddressRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	now := time.Now()
+
+	address := entity.Address{
+		AccountUsername: request.Username,
+		PostalAddress:   newPostalAddress(request.PostalAddressDTO),
+		IsDefault:       request.IsDefault,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err := validatePostalAddress(address.PostalAddress)
+
+	if err != nil {
+		return response, err
+	}
+
+	err = as.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		addresses, err := repos.Address.FindByAccount(ctx, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		if len(addresses) >= maxAddressesPerAccount {
+			err := fmt.Errorf("an account keeps at most %d addresses", maxAddressesPerAccount)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
+
+		if len(addresses) == 0 {
+			address.IsDefault = true
+		} else if address.IsDefault {
+			err = repos.Address.ClearDefault(ctx, request.Username)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		address, err = repos.Address.Create(ctx, address)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.AddressResponseData{
+		Address: newAddressDTO(address),
+	}
+
+	return response, nil
+}
+
+func (as *AddressService) UpdateAddress(ctx context.Context, request model.SaveAddressRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	postalAddress := newPostalAddress(request.PostalAddressDTO)
+
+	err := validatePostalAddress(postalAddress)
+
+	if err != nil {
+		return response, err
+	}
+
+	var address entity.Address
+
+	err = as.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		address, err = findOwnAddress(ctx, repos, request.AddressID, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		if request.IsDefault && !address.IsDefault {
+			err = repos.Address.ClearDefault(ctx, request.Username)
+
+			if err != nil {
+				return err
+			}
+
+			address.IsDefault = true
+		}
+
+		address.PostalAddress = postalAddress
+		address.UpdatedAt = time.Now()
+
+		return repos.Address.Update(ctx, address)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.AddressResponseData{
+		Address: newAddressDTO(address),
+	}
+
+	return response, nil
+}
+
+func (as *AddressService) DeleteAddress(ctx context.Context, request model.DeleteAddressRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var addresses []entity.Address
+
+	err := as.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		address, err := findOwnAddress(ctx, repos, request.AddressID, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		err = repos.Address.Delete(ctx, address.ID)
+
+		if err != nil {
+			return err
+		}
+
+		addresses, err = repos.Address.FindByAccount(ctx, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		if !address.IsDefault || len(addresses) == 0 {
+			return nil
+		}
+
+		// Without a default left the addresses are in id order, the first one is the oldest
+		addresses[0].IsDefault = true
+		addresses[0].UpdatedAt = time.Now()
+
+		return repos.Address.Update(ctx, addresses[0])
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetAddressesResponseData{
+		Addresses: newAddressDTOs(addresses),
+	}
+
+	return response, nil
+}
+
+// findOwnAddress locks an address of the account, the address of another account is an ErrAccessDenied
+func findOwnAddress(ctx context.Context, repos repository.Repositories, addressID int64, username string) (entity.Address, error) {
+
+	address, err := repos.Address.FindByID(ctx, addressID)
+
+	if err != nil {
+		return address, err
+	}
+
+	if address.AccountUsername != username {
+		err := fmt.Errorf("address %d does not belong to %s", addressID, username)
+		logrus.Error(err)
+		return address, common.NewError(err, common.ErrAccessDenied)
+	}
+
+	return address, nil
+}
+
+func newPostalAddress(dto model.PostalAddressDTO) entity.PostalAddress {
+	return entity.PostalAddress{
+		RecipientName: strings.TrimSpace(dto.RecipientName),
+		Phone:         strings.TrimSpace(dto.Phone),
+		Line1:         strings.TrimSpace(dto.Line1),
+		Line2:         strings.TrimSpace(dto.Line2),
+		City:          strings.TrimSpace(dto.City),
+		Region:        normalizeTaxRegion(dto.Region),
+		PostalCode:    strings.ToUpper(strings.TrimSpace(dto.PostalCode)),
+		Country:       strings.ToUpper(strings.TrimSpace(dto.Country)),
+	}
+}
+
+func validatePostalAddress(address entity.PostalAddress) error {
+
+	var err error
+
+	switch {
+	case address.RecipientName == "":
+		err = errors.New("recipientName is required")
+	case utf8.RuneCountInString(address.RecipientName) > maxAddressNameLength:
+		err = fmt.Errorf("recipientName is longer than %d characters", maxAddressNameLength)
+	case !phonePattern.MatchString(address.Phone):
+		err = errors.New("phone must be 6 to 20 digits, spaces or '-', with an optional leading '+'")
+	case address.Line1 == "":
+		err = errors.New("line1 is required")
+	case utf8.RuneCountInString(address.Line1) > maxAddressLineLength:
+		err = fmt.Errorf("line1 is longer than %d characters", maxAddressLineLength)
+	case utf8.RuneCountInString(address.Line2) > maxAddressLineLength:
+		err = fmt.Errorf("line2 is longer than %d characters", maxAddressLineLength)
+	case address.City == "":
+		err = errors.New("city is required")
+	case utf8.RuneCountInString(address.City) > maxAddressCityLength:
+		err = fmt.Errorf("city is longer than %d characters", maxAddressCityLength)
+	case !taxRegionPattern.MatchString(address.Region):
+		err = errors.New("region must be 2 to 10 letters, digits or '-'")
+	case !postalCodePattern.MatchString(address.PostalCode):
+		err = errors.New("postalCode must be 3 to 10 letters, digits, spaces or '-'")
+	case !countryPattern.MatchString(address.Country):
+		err = errors.New("country must be a 2 letter ISO 3166 code")
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func newPostalAddressDTO(address entity.PostalAddress) model.PostalAddressDTO {
+	return model.PostalAddressDTO{
+		RecipientName: address.RecipientName,
+		Phone:         address.Phone,
+		Line1:         address.Line1,
+		Line2:         address.Line2,
+		City:          address.City,
+		Region:        address.Region,
+		PostalCode:    address.PostalCode,
+		Country:       address.Country,
+	}
+}
+
+func newAddressDTO(address entity.Address) model.AddressDTO {
+	return model.AddressDTO{
+		ID:               address.ID,
+		PostalAddressDTO: newPostalAddressDTO(address.PostalAddress),
+		IsDefault:        address.IsDefault,
+		CreatedAt:        address.CreatedAt,
+	}
+}
+
+func newAddressDTOs(addresses []entity.Address) []model.AddressDTO {
+
+	addressesDTO := make([]model.AddressDTO, len(addresses))
+
+	for i, address := range addresses {
+		addressesDTO[i] = newAddressDTO(address)
+	}
+
+	return addressesDTO
+}
@@ -0,0 +1,245 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func addressRequest(recipientName string, isDefault bool) model.SaveAddressRequest {
+	return model.SaveAddressRequest{
+		PostalAddressDTO: model.PostalAddressDTO{
+			RecipientName: recipientName,
+			Phone:         "+62 812-3456-7890",
+			Line1:         "Jl. Merdeka 1",
+			City:          "Jakarta",
+			Region:        "id-jk",
+			PostalCode:    "10110",
+			Country:       "id",
+		},
+		IsDefault: isDefault,
+		Username:  testUsername,
+	}
+}
+
+func (f *fixture) createAddress(t *testing.T, request model.SaveAddressRequest) model.AddressDTO {
+
+	t.Helper()
+
+	response, err := f.addressService.CreateAddress(context.Background(), request)
+	if err != nil {
+		t.Fatalf("create address: %v", err)
+	}
+
+	return response.Data.(model.AddressResponseData).Address
+}
+
+func (f *fixture) addresses(t *testing.T, username string) []model.AddressDTO {
+
+	t.Helper()
+
+	response, err := f.addressService.GetAddresses(context.Background(), model.GetAddressesRequest{Username: username})
+	if err != nil {
+		t.Fatalf("get addresses: %v", err)
+	}
+
+	return response.Data.(model.GetAddressesResponseData).Addresses
+}
+
+func TestFirstAddressBecomesTheDefault(t *testing.T) {
+
+	f := newFixture(t)
+
+	home := f.createAddress(t, addressRequest("John Doe", false))
+
+	if !home.IsDefault {
+		t.Fatalf("first address should be the default")
+	}
+
+	if home.Region != "ID-JK" || home.Country != "ID" {
+		t.Fatalf("region and country should be normalized, got %s %s", home.Region, home.Country)
+	}
+
+	office := f.createAddress(t, addressRequest("John at work", false))
+
+	if office.IsDefault {
+		t.Fatalf("second address should not take the default")
+	}
+
+	addresses := f.addresses(t, testUsername)
+
+	if len(addresses) != 2 || addresses[0].ID != home.ID || !addresses[0].IsDefault {
+		t.Fatalf("expected the default address first, got %+v", addresses)
+	}
+}
+
+func TestDefaultAddressMovesAndIsPromotedOnDelete(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	home := f.createAddress(t, addressRequest("John Doe", false))
+	office := f.createAddress(t, addressRequest("John at work", false))
+	parents := f.createAddress(t, addressRequest("John at his parents", true))
+
+	if !parents.IsDefault {
+		t.Fatalf("isDefault should move the default to the new address")
+	}
+
+	// Clearing isDefault on the default address keeps it the default
+	request := addressRequest("John at his parents", false)
+	request.AddressID = parents.ID
+
+	response, err := f.addressService.UpdateAddress(ctx, request)
+	if err != nil {
+		t.Fatalf("update address: %v", err)
+	}
+
+	if !response.Data.(model.AddressResponseData).Address.IsDefault {
+		t.Fatalf("the default address should stay the default")
+	}
+
+	response, err = f.addressService.DeleteAddress(ctx, model.DeleteAddressRequest{AddressID: parents.ID, Username: testUsername})
+	if err != nil {
+		t.Fatalf("delete address: %v", err)
+	}
+
+	addresses := response.Data.(model.GetAddressesResponseData).Addresses
+
+	if len(addresses) != 2 || addresses[0].ID != home.ID || !addresses[0].IsDefault || addresses[1].ID != office.ID || addresses[1].IsDefault {
+		t.Fatalf("the oldest address should become the default, got %+v", addresses)
+	}
+}
+
+func TestAddressValidation(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	cases := map[string]func(*model.SaveAddressRequest){
+		"missing recipient": func(r *model.SaveAddressRequest) { r.RecipientName = " " },
+		"bad phone":         func(r *model.SaveAddressRequest) { r.Phone = "call me" },
+		"missing line1":     func(r *model.SaveAddressRequest) { r.Line1 = "" },
+		"missing city":      func(r *model.SaveAddressRequest) { r.City = "" },
+		"bad region":        func(r *model.SaveAddressRequest) { r.Region = "Jakarta Raya" },
+		"bad postal code":   func(r *model.SaveAddressRequest) { r.PostalCode = "1" },
+		"bad country":       func(r *model.SaveAddressRequest) { r.Country = "IDN" },
+	}
+
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			request := addressRequest("John Doe", false)
+			mutate(&request)
+
+			_, err := f.addressService.CreateAddress(ctx, request)
+			assertErrorKind(t, err, common.ErrValidation)
+		})
+	}
+}
+
+func TestAddressOfAnotherAccountIsDenied(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.addAccount(t, "janedoe")
+
+	request := addressRequest("Jane Doe", false)
+	request.Username = "janedoe"
+
+	janes := f.createAddress(t, request)
+
+	update := addressRequest("John Doe", false)
+	update.AddressID = janes.ID
+
+	_, err := f.addressService.UpdateAddress(ctx, update)
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	_, err = f.addressService.DeleteAddress(ctx, model.DeleteAddressRequest{AddressID: janes.ID, Username: testUsername})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	order := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	order.DeliveryAddress = ""
+	order.AddressID = janes.ID
+
+	_, err = f.orderService.SubmitOrder(ctx, order)
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	if f.stockOf(t, 1) != 10 {
+		t.Fatalf("a denied order should not reserve stock")
+	}
+}
+
+func TestOrderKeepsACopyOfTheAddress(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	home := f.createAddress(t, addressRequest("John Doe", false))
+
+	request := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.DeliveryAddress = ""
+	request.AddressID = home.ID
+
+	order := f.submitOrder(t, request)
+
+	// Moving house after the order does not move the parcel
+	update := addressRequest("John Doe", false)
+	update.AddressID = home.ID
+	update.Line1 = "Jl. Sudirman 99"
+
+	_, err := f.addressService.UpdateAddress(ctx, update)
+	if err != nil {
+		t.Fatalf("update address: %v", err)
+	}
+
+	_, err = f.addressService.DeleteAddress(ctx, model.DeleteAddressRequest{AddressID: home.ID, Username: testUsername})
+	if err != nil {
+		t.Fatalf("delete address: %v", err)
+	}
+
+	detail := f.orderDetail(t, order.OrderReference)
+
+	if detail.Delivery == nil || detail.Delivery.Line1 != "Jl. Merdeka 1" || detail.Delivery.PostalCode != "10110" {
+		t.Fatalf("expected the address at submission, got %+v", detail.Delivery)
+	}
+
+	if detail.DeliveryAddress != "John Doe, Jl. Merdeka 1, Jakarta, ID-JK 10110, ID" {
+		t.Fatalf("unexpected delivery address %q", detail.DeliveryAddress)
+	}
+
+	if detail.TaxRegion != "ID-JK" {
+		t.Fatalf("the order should take the region of the address, got %q", detail.TaxRegion)
+	}
+}
+
+func TestOrderAddressConflicts(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	home := f.createAddress(t, addressRequest("John Doe", false))
+
+	both := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	both.AddressID = home.ID
+
+	_, err := f.orderService.SubmitOrder(ctx, both)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	otherRegion := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	otherRegion.DeliveryAddress = ""
+	otherRegion.AddressID = home.ID
+	otherRegion.Region = "ID-JB"
+
+	_, err = f.orderService.SubmitOrder(ctx, otherRegion)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	free := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	if f.orderDetail(t, free.OrderReference).Delivery != nil {
+		t.Fatalf("a free text address has no structured delivery")
+	}
+}
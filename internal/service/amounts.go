@@ -0,0 +1,73 @@
+package service
+
+import (
+	"math/bits"
+)
+
+/*
+*
+
+	Amounts are integers in the minor unit of the currency, never floats :
+	- divideRoundHalfUp rounds a quotient of non negative amounts to the nearest unit, halves up
+	- allocate splits an amount in proportion to weights by largest remainder,
+	  the shares always add up to the amount
+
+*
+*/
+func divideRoundHalfUp(numerator int64, denominator int64) int64 {
+
+	quotient := numerator / denominator
+	remainder := numerator % denominator
+
+	if remainder*2 >= denominator {
+		quotient++
+	}
+
+	return quotient
+}
+
+// allocate splits a non negative amount between non negative weights, the earlier weight wins a tie
+func allocate(amount int64, weights []int64) []int64 {
+
+	shares := make([]int64, len(weights))
+	remainders := make([]uint64, len(weights))
+
+	totalWeight := int64(0)
+
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+
+	if amount == 0 || totalWeight == 0 {
+		return shares
+	}
+
+	allocated := int64(0)
+
+	for i, weight := range weights {
+
+		// amount * weight can exceed 64 bits, the quotient never does
+		hi, lo := bits.Mul64(uint64(amount), uint64(weight))
+		quotient, remainder := bits.Div64(hi, lo, uint64(totalWeight))
+
+		shares[i] = int64(quotient)
+		remainders[i] = remainder
+		allocated += shares[i]
+	}
+
+	for left := amount - allocated; left > 0; left-- {
+
+		largest := 0
+
+		for i := range remainders {
+			if remainders[i] > remainders[largest] {
+				largest = i
+			}
+		}
+
+		shares[largest]++
+		remainders[largest] = 0
+	}
+
+	return shares
+}
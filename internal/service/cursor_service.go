@@ -0,0 +1,81 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+*
+
+	Cursors are handed to clients as "<payload>.<signature>" :
+	- payload   : base64url of the JSON encoded model.Cursor
+	- signature : base64url of HMAC-SHA256(payload)
+
+	Clients cannot read meaning into them nor forge a position.
+
+*
+*/
+type CursorService struct {
+	secret []byte
+}
+
+func NewCursorService(secret string) *CursorService {
+	return &CursorService{secret: []byte(secret)}
+}
+
+func (cs *CursorService) Encode(cursor model.Cursor) (string, error) {
+
+	content, err := json.Marshal(cursor)
+
+	if err != nil {
+		logrus.Error(err)
+		return "", common.NewError(err, common.ErrValidation)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(content)
+
+	return payload + "." + cs.sign(payload), nil
+}
+
+func (cs *CursorService) Decode(token string) (model.Cursor, error) {
+
+	var cursor model.Cursor
+
+	payload, signature, found := strings.Cut(token, ".")
+
+	if !found || !hmac.Equal([]byte(signature), []byte(cs.sign(payload))) {
+		err := errors.New("invalid cursor")
+		logrus.Error(err)
+		return cursor, common.NewError(err, common.ErrValidation)
+	}
+
+	content, err := base64.RawURLEncoding.DecodeString(payload)
+
+	if err == nil {
+		err = json.Unmarshal(content, &cursor)
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return cursor, common.NewError(errors.New("invalid cursor"), common.ErrValidation)
+	}
+
+	return cursor, nil
+}
+
+func (cs *CursorService) sign(payload string) string {
+
+	mac := hmac.New(sha256.New, cs.secret)
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,207 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository/memory"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+)
+
+const testUsername = "johndoe"
+
+type fixture struct {
+	store          *memory.Store
+	repos          repository.Repositories
+	orderService   *service.OrderService
+	paymentService *service.PaymentService
+	productService *service.ProductService
+
+	inventoryService *service.InventoryService
+	variantService   *service.VariantService
+
+	stockNotificationService *service.StockNotificationService
+	notifier                 *recordingNotifier
+
+	productReviewService *service.ProductReviewService
+
+	wishlistService *service.WishlistService
+
+	promotionService *service.PromotionService
+
+	pricingService *service.PricingService
+
+	taxService *service.TaxService
+
+	shippingService *service.ShippingService
+
+	shipmentService *service.ShipmentService
+
+	addressService *service.AddressService
+
+	accountService       *service.AccountService
+	guestCheckoutService *service.GuestCheckoutService
+}
+
+func newFixture(t *testing.T) *fixture {
+
+	t.Helper()
+
+	store := memory.NewStore()
+	repos := store.Repositories()
+
+	err := repos.Account.Create(context.Background(), entity.Account{
+		Username:    testUsername,
+		DisplayName: "John Doe",
+		Email:       "john@example.com",
+		IsActive:    true,
+	})
+
+	if err != nil {
+		t.Fatalf("seed account: %v", err)
+	}
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store.SeedProducts(
+		entity.Product{ID: 1, CategoryID: 1, Name: "Clay Pot", Description: "Hand thrown clay pot", Price: 15000, Stock: 10, IsActive: true, CreatedAt: created},
+		entity.Product{ID: 2, CategoryID: 2, Name: "Linen Throw", Description: "Washed linen throw blanket", Price: 120000, Stock: 3, IsActive: true, CreatedAt: created.Add(time.Hour)},
+		entity.Product{ID: 3, CategoryID: 1, Name: "Retired Vase", Description: "No longer sold", Price: 50000, Stock: 5, IsActive: false, CreatedAt: created.Add(2 * time.Hour)},
+	)
+
+	// The ledger starts with the seeded stock
+	store.SeedMovements(
+		entity.InventoryMovement{ProductID: 1, MovementType: constant.MovementTypeOpeningBalance, Quantity: 10, StockAfter: 10},
+		entity.InventoryMovement{ProductID: 2, MovementType: constant.MovementTypeOpeningBalance, Quantity: 3, StockAfter: 3},
+		entity.InventoryMovement{ProductID: 3, MovementType: constant.MovementTypeOpeningBalance, Quantity: 5, StockAfter: 5},
+	)
+
+	cursorService := service.NewCursorService("test-cursor-secret")
+	notifier := &recordingNotifier{}
+	stockNotificationService := service.NewStockNotificationService(store, repos.Product, repos.Subscription, notifier)
+
+	orderService := service.NewOrderService(
+		store,
+		repos.Order,
+		repos.Product,
+		repos.OrderItem,
+		repos.Payment,
+		repos.Account,
+		common.NewIDGenerator(),
+		cursorService,
+		stockNotificationService)
+
+	paymentService := service.NewPaymentService(store, repos.Order, repos.Payment)
+	wishlistService := service.NewWishlistService(repos.Wishlist, repos.Product, repos.Variant, repos.PriceChange, repos.Account, orderService, notifier)
+
+	return &fixture{
+		store:          store,
+		repos:          repos,
+		orderService:   orderService,
+		paymentService: paymentService,
+		productService: service.NewProductService(repos.Product, repos.Variant, repos.Image, repos.PriceChange, cursorService),
+
+		inventoryService: service.NewInventoryService(store, repos.Product, repos.Movement, stockNotificationService),
+		variantService:   service.NewVariantService(store, stockNotificationService),
+
+		stockNotificationService: stockNotificationService,
+		notifier:                 notifier,
+
+		productReviewService: service.NewProductReviewService(store, repos.Review, repos.Product),
+
+		wishlistService: wishlistService,
+
+		promotionService: service.NewPromotionService(store, repos.Promotion),
+
+		pricingService: service.NewPricingService(store, repos.Product, repos.PriceChange, repos.PriceHistory, repos.PriceList, wishlistService),
+
+		taxService: service.NewTaxService(store, repos.TaxRule),
+
+		shippingService: service.NewShippingService(store, repos.Shipping, repos.Product, repos.Variant, repos.PriceChange, repos.PriceList),
+
+		shipmentService: service.NewShipmentService(store, repos.Order, common.NewIDGenerator()),
+
+		addressService: service.NewAddressService(store, repos.Address),
+
+		accountService:       service.NewAccountService(service.NewJwtService("test-jwt-secret", time.Hour), repos.Account, notifier),
+		guestCheckoutService: service.NewGuestCheckoutService(store, repos.Order, orderService, paymentService),
+	}
+}
+
+func (f *fixture) stockOf(t *testing.T, productID int64) int64 {
+
+	t.Helper()
+
+	product, err := f.repos.Product.FindByID(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("find product %d: %v", productID, err)
+	}
+
+	return product.Stock
+}
+
+// recordingNotifier keeps the notifications instead of delivering them
+type recordingNotifier struct {
+	mu          sync.Mutex
+	lowStock    []notification.LowStockAlert
+	backInStock []notification.BackInStockNotice
+	priceDrops  []notification.PriceDropNotice
+	emailCodes  []notification.EmailVerificationNotice
+}
+
+func (rn *recordingNotifier) NotifyLowStock(ctx context.Context, alert notification.LowStockAlert) error {
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.lowStock = append(rn.lowStock, alert)
+
+	return nil
+}
+
+func (rn *recordingNotifier) NotifyBackInStock(ctx context.Context, notice notification.BackInStockNotice) error {
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.backInStock = append(rn.backInStock, notice)
+
+	return nil
+}
+
+func (rn *recordingNotifier) NotifyPriceDrop(ctx context.Context, notice notification.PriceDropNotice) error {
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.priceDrops = append(rn.priceDrops, notice)
+
+	return nil
+}
+
+func (rn *recordingNotifier) NotifyEmailVerification(ctx context.Context, notice notification.EmailVerificationNotice) error {
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.emailCodes = append(rn.emailCodes, notice)
+
+	return nil
+}
+
+func assertErrorKind(t *testing.T, err error, kind error) {
+
+	t.Helper()
+
+	if !errors.Is(err, kind) {
+		t.Fatalf("expected error kind %q, got %v", kind, err)
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const maxGuestEmailLength = 200
+
+/*
+*
+
+	Guest checkout, orders placed without an account :
+	- The shopper gives an email and a delivery address, the order takes its region from the address
+	- The order is reached with the access token returned at checkout, only its hash is stored
+	- Coupons limited per account need an account, every other rule of a regular order applies
+	- An account whose verified email is the guest email claims the orders, the access token stops working
+
+*
+*/
+type GuestCheckoutService struct {
+	txRunner        repository.TransactionRunner
+	orderRepository repository.OrderRepository
+	orderService    *OrderService
+	paymentService  *PaymentService
+}
+
+func NewGuestCheckoutService(txRunner repository.TransactionRunner, orderRepository repository.OrderRepository, orderService *OrderService, paymentService *PaymentService) *GuestCheckoutService {
+	return &GuestCheckoutService{
+		txRunner:        txRunner,
+		orderRepository: orderRepository,
+		orderService:    orderService,
+		paymentService:  paymentService,
+	}
+}
+
+func (gs *GuestCheckoutService) SubmitGuestOrder(ctx context.Context, request model.SubmitGuestOrderRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	email := strings.TrimSpace(request.Email)
+
+	err := validateGuestEmail(email)
+
+	if err != nil {
+		return response, err
+	}
+
+	err = validatePostalAddress(newPostalAddress(request.Address))
+
+	if err != nil {
+		return response, err
+	}
+
+	accessToken, err := newAccessToken()
+
+	if err != nil {
+		return response, err
+	}
+
+	orderResponse, err := gs.orderService.SubmitOrder(ctx, model.SubmitOrderRequest{
+		OrderItems:     request.OrderItems,
+		CouponCode:     request.CouponCode,
+		Currency:       request.Currency,
+		ShippingMethod: request.ShippingMethod,
+		Guest: &model.GuestOrder{
+			Email:     email,
+			Address:   request.Address,
+			TokenHash: hashSecret(accessToken),
+		},
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.SubmitGuestOrderResponseData{
+		SubmitOrderResponseData: orderResponse.Data.(model.SubmitOrderResponseData),
+		Email:                   email,
+		AccessToken:             accessToken,
+	}
+
+	return response, nil
+}
+
+func (gs *GuestCheckoutService) GetGuestOrder(ctx context.Context, request model.GuestOrderRequest) (model.GeneralResponse, error) {
+
+	_, err := findGuestOrder(ctx, gs.orderRepository, request.OrderReference, request.AccessToken)
+
+	if err != nil {
+		return model.GeneralResponse{}, err
+	}
+
+	order, err := gs.orderRepository.FindByIDWithItems(ctx, request.OrderReference)
+
+	if err != nil {
+		return model.GeneralResponse{}, err
+	}
+
+	return gs.orderService.orderDetail(ctx, order)
+}
+
+func (gs *GuestCheckoutService) PayGuestOrder(ctx context.Context, request model.PayGuestOrderRequest) (model.GeneralResponse, error) {
+
+	paymentRequest := model.SubmitPaymentRequest{
+		OrderReference: request.OrderReference,
+		CardHolderName: request.CardHolderName,
+		CardNumber:     request.CardNumber,
+		Status:         request.Status,
+	}
+
+	// Checked on the locked order, it may be claimed into an account meanwhile
+	return gs.paymentService.pay(ctx, paymentRequest, func(order entity.Order) error {
+		return checkGuestAccess(order, request.AccessToken)
+	})
+}
+
+// ClaimGuestOrders moves the guest orders placed with the email of the account into the account
+func (gs *GuestCheckoutService) ClaimGuestOrders(ctx context.Context, request model.ClaimGuestOrdersRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var claimed []entity.Order
+
+	err := gs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		account, err := repos.Account.FindByUsername(ctx, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		if account.EmailVerifiedAt == nil {
+			err := fmt.Errorf("the email of %s is not verified", account.Username)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrAccessDenied)
+		}
+
+		orders, err := repos.Order.FindGuestOrdersByEmail(ctx, account.Email)
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		for _, order := range orders {
+
+			order.AccountUsername = &account.Username
+			order.GuestTokenHash = ""
+			order.UpdatedAt = now
+			order.UpdatedBy = account.Username
+
+			err = repos.Order.Update(ctx, order)
+
+			if err != nil {
+				return err
+			}
+
+			claimed = append(claimed, order)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	ordersDTO := make([]model.OrderDTO, len(claimed))
+
+	for i, order := range claimed {
+		ordersDTO[i] = model.OrderDTO{
+			OrderReference: order.OrderReference,
+			Status:         order.Status,
+			OrderDate:      order.OrderDate,
+			Currency:       order.Currency,
+			Total:          order.Total}
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ClaimGuestOrdersResponseData{
+		Orders: ordersDTO,
+	}
+
+	return response, nil
+}
+
+// findGuestOrder finds a guest order by its access token, a wrong token or a claimed order is an ErrAccessDenied
+func findGuestOrder(ctx context.Context, orderRepo repository.OrderRepository, orderReference string, accessToken string) (entity.Order, error) {
+
+	order, err := orderRepo.FindByID(ctx, orderReference)
+
+	if err != nil {
+		return order, err
+	}
+
+	return order, checkGuestAccess(order, accessToken)
+}
+
+// checkGuestAccess only opens a guest order with its own access token
+func checkGuestAccess(order entity.Order, accessToken string) error {
+
+	if !order.IsGuest() || subtle.ConstantTimeCompare([]byte(order.GuestTokenHash), []byte(hashSecret(accessToken))) != 1 {
+		err := fmt.Errorf("access token does not open order %s", order.OrderReference)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrAccessDenied)
+	}
+
+	return nil
+}
+
+func validateGuestEmail(email string) error {
+
+	var err error
+
+	if email == "" {
+		err = errors.New("email is required")
+	} else if len(email) > maxGuestEmailLength {
+		err = fmt.Errorf("email is longer than %d characters", maxGuestEmailLength)
+	} else if address, parseErr := mail.ParseAddress(email); parseErr != nil || address.Address != email {
+		err = errors.New("email is not valid")
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+// newAccessToken is a random token of 256 bits, URL safe
+func newAccessToken() (string, error) {
+
+	token := make([]byte, 32)
+
+	if _, err := rand.Read(token); err != nil {
+		logrus.Error(err)
+		return "", common.NewError(err, common.ErrConflict)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// hashSecret is the hex SHA-256 of a token or code, secrets are only stored hashed
+func hashSecret(secret string) string {
+
+	sum := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(sum[:])
+}
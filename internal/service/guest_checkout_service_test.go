@@ -0,0 +1,347 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func guestRequest(email string, items ...model.OrderItemRequest) model.SubmitGuestOrderRequest {
+	return model.SubmitGuestOrderRequest{
+		Email:      email,
+		Address:    addressRequest("Jane Guest", false).PostalAddressDTO,
+		OrderItems: items,
+	}
+}
+
+func (f *fixture) submitGuestOrder(t *testing.T, request model.SubmitGuestOrderRequest) model.SubmitGuestOrderResponseData {
+
+	t.Helper()
+
+	response, err := f.guestCheckoutService.SubmitGuestOrder(context.Background(), request)
+	if err != nil {
+		t.Fatalf("submit guest order: %v", err)
+	}
+
+	return response.Data.(model.SubmitGuestOrderResponseData)
+}
+
+// verifyEmail requests a code for the account and verifies it
+func (f *fixture) verifyEmail(t *testing.T, username string) {
+
+	t.Helper()
+
+	ctx := context.Background()
+
+	_, err := f.accountService.RequestEmailVerification(ctx, model.EmailVerificationRequest{Username: username})
+	if err != nil {
+		t.Fatalf("request email verification: %v", err)
+	}
+
+	code := f.notifier.emailCodes[len(f.notifier.emailCodes)-1].Code
+
+	_, err = f.accountService.VerifyEmail(ctx, model.VerifyEmailRequest{Code: code, Username: username})
+	if err != nil {
+		t.Fatalf("verify email: %v", err)
+	}
+}
+
+func TestGuestOrderIsOpenedWithItsAccessToken(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	order := f.submitGuestOrder(t, guestRequest("jane@example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2}))
+
+	if order.AccessToken == "" || order.Total != 30000 || order.OrderStatus != constant.OrderStatusPendingPayment {
+		t.Fatalf("unexpected guest order %+v", order)
+	}
+
+	if f.stockOf(t, 1) != 8 {
+		t.Fatalf("a guest order should reserve stock like any order")
+	}
+
+	stored, err := f.repos.Order.FindByID(ctx, order.OrderReference)
+	if err != nil {
+		t.Fatalf("find order: %v", err)
+	}
+
+	if !stored.IsGuest() || stored.GuestTokenHash == order.AccessToken {
+		t.Fatalf("the order should have no account and keep only a hash of the token, got %+v", stored)
+	}
+
+	_, err = f.guestCheckoutService.GetGuestOrder(ctx, model.GuestOrderRequest{OrderReference: order.OrderReference, AccessToken: "not-the-token"})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	response, err := f.guestCheckoutService.GetGuestOrder(ctx, model.GuestOrderRequest{OrderReference: order.OrderReference, AccessToken: order.AccessToken})
+	if err != nil {
+		t.Fatalf("get guest order: %v", err)
+	}
+
+	detail := response.Data.(model.GetOrderDetailReponseData).Order
+
+	if detail.Delivery == nil || detail.Delivery.RecipientName != "Jane Guest" || detail.TaxRegion != "ID-JK" {
+		t.Fatalf("expected the guest address and its region, got %+v", detail)
+	}
+
+	// The token of one order does not open another
+	other := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	_, err = f.guestCheckoutService.GetGuestOrder(ctx, model.GuestOrderRequest{OrderReference: other.OrderReference, AccessToken: order.AccessToken})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	_, err = f.guestCheckoutService.PayGuestOrder(ctx, model.PayGuestOrderRequest{
+		OrderReference: order.OrderReference,
+		AccessToken:    order.AccessToken,
+		CardHolderName: "Jane Guest",
+		CardNumber:     "4111 1111 1111 1111",
+		Status:         constant.PaymentStatusReceived,
+	})
+	if err != nil {
+		t.Fatalf("pay guest order: %v", err)
+	}
+
+	response, err = f.guestCheckoutService.GetGuestOrder(ctx, model.GuestOrderRequest{OrderReference: order.OrderReference, AccessToken: order.AccessToken})
+	if err != nil {
+		t.Fatalf("get guest order: %v", err)
+	}
+
+	if status := response.Data.(model.GetOrderDetailReponseData).Order.Status; status != constant.OrderStatusPaymentReceived {
+		t.Fatalf("expected the guest order to be paid, got %s", status)
+	}
+}
+
+func TestGuestOrderValidation(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	cases := map[string]func(*model.SubmitGuestOrderRequest){
+		"missing email":   func(r *model.SubmitGuestOrderRequest) { r.Email = " " },
+		"bad email":       func(r *model.SubmitGuestOrderRequest) { r.Email = "jane at example" },
+		"named email":     func(r *model.SubmitGuestOrderRequest) { r.Email = "Jane <jane@example.com>" },
+		"missing address": func(r *model.SubmitGuestOrderRequest) { r.Address = model.PostalAddressDTO{} },
+		"bad region":      func(r *model.SubmitGuestOrderRequest) { r.Address.Region = "Jakarta Raya" },
+		"no items":        func(r *model.SubmitGuestOrderRequest) { r.OrderItems = nil },
+	}
+
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+
+			request := guestRequest("jane@example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+			mutate(&request)
+
+			_, err := f.guestCheckoutService.SubmitGuestOrder(ctx, request)
+			assertErrorKind(t, err, common.ErrValidation)
+		})
+	}
+
+	if f.stockOf(t, 1) != 10 {
+		t.Fatalf("a refused guest order should not reserve stock")
+	}
+}
+
+func TestGuestCannotRedeemCouponsLimitedPerAccount(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "ONCE", Type: constant.PromotionTypeFixedAmount, Value: 1000, PerAccountLimit: int64Ptr(1)})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "EVERYONE", Type: constant.PromotionTypeFixedAmount, Value: 1000})
+
+	request := guestRequest("jane@example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.CouponCode = "ONCE"
+
+	_, err := f.guestCheckoutService.SubmitGuestOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	request.CouponCode = "EVERYONE"
+
+	if order := f.submitGuestOrder(t, request); order.DiscountTotal != 1000 {
+		t.Fatalf("expected the coupon to be redeemed, got %+v", order)
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	_, err := f.accountService.VerifyEmail(ctx, model.VerifyEmailRequest{Code: "123456", Username: testUsername})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	_, err = f.accountService.RequestEmailVerification(ctx, model.EmailVerificationRequest{Username: testUsername})
+	if err != nil {
+		t.Fatalf("request email verification: %v", err)
+	}
+
+	notice := f.notifier.emailCodes[0]
+
+	if notice.Email != "john@example.com" || len(notice.Code) != 6 {
+		t.Fatalf("unexpected verification notice %+v", notice)
+	}
+
+	wrong := "000000"
+	if notice.Code == wrong {
+		wrong = "111111"
+	}
+
+	// Five wrong codes use up the code, even the right one is refused afterwards
+	for range 5 {
+		_, err = f.accountService.VerifyEmail(ctx, model.VerifyEmailRequest{Code: wrong, Username: testUsername})
+		assertErrorKind(t, err, common.ErrValidation)
+	}
+
+	_, err = f.accountService.VerifyEmail(ctx, model.VerifyEmailRequest{Code: notice.Code, Username: testUsername})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	f.verifyEmail(t, testUsername)
+
+	account, err := f.repos.Account.FindByUsername(ctx, testUsername)
+	if err != nil {
+		t.Fatalf("find account: %v", err)
+	}
+
+	if account.EmailVerifiedAt == nil || account.EmailVerificationHash != "" {
+		t.Fatalf("expected a verified email without a pending code, got %+v", account)
+	}
+
+	_, err = f.accountService.RequestEmailVerification(ctx, model.EmailVerificationRequest{Username: testUsername})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	// A new email is verified again
+	response, err := f.accountService.UpdateAccount(ctx, model.UpdateAccountRequest{Username: testUsername, Email: "john.doe@example.com", DiplayName: "John Doe"})
+	if err != nil {
+		t.Fatalf("update account: %v", err)
+	}
+
+	if response.Data.(model.UpdateAccountResponseData).Account.EmailVerified {
+		t.Fatalf("changing the email should drop the verification")
+	}
+}
+
+func TestConcurrentEmailVerificationAttemptsStopAtTheLimit(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	_, err := f.accountService.RequestEmailVerification(ctx, model.EmailVerificationRequest{Username: testUsername})
+	if err != nil {
+		t.Fatalf("request email verification: %v", err)
+	}
+
+	wrong := "000000"
+	if f.notifier.emailCodes[0].Code == wrong {
+		wrong = "111111"
+	}
+
+	const attempts = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.accountService.VerifyEmail(ctx, model.VerifyEmailRequest{Code: wrong, Username: testUsername})
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	compared := 0
+	for err := range errs {
+		if errors.Is(err, common.ErrValidation) {
+			compared++
+			continue
+		}
+		assertErrorKind(t, err, common.ErrConflict)
+	}
+
+	if compared != 5 {
+		t.Fatalf("expected exactly 5 codes compared, got %d", compared)
+	}
+}
+
+func TestEmailVerificationRequestsAreLimited(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	for range 5 {
+		_, err := f.accountService.RequestEmailVerification(ctx, model.EmailVerificationRequest{Username: testUsername})
+		if err != nil {
+			t.Fatalf("request email verification: %v", err)
+		}
+	}
+
+	// A sixth code within the hour would reset the attempts again
+	_, err := f.accountService.RequestEmailVerification(ctx, model.EmailVerificationRequest{Username: testUsername})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	if len(f.notifier.emailCodes) != 5 {
+		t.Fatalf("expected 5 codes sent, got %d", len(f.notifier.emailCodes))
+	}
+
+	// The last code sent is still pending
+	_, err = f.accountService.VerifyEmail(ctx, model.VerifyEmailRequest{Code: f.notifier.emailCodes[4].Code, Username: testUsername})
+	if err != nil {
+		t.Fatalf("verify email: %v", err)
+	}
+}
+
+func TestVerifiedAccountClaimsItsGuestOrders(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	first := f.submitGuestOrder(t, guestRequest("John@Example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	second := f.submitGuestOrder(t, guestRequest("john@example.com", model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1}))
+	f.submitGuestOrder(t, guestRequest("jane@example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	_, err := f.guestCheckoutService.ClaimGuestOrders(ctx, model.ClaimGuestOrdersRequest{Username: testUsername})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	f.verifyEmail(t, testUsername)
+
+	response, err := f.guestCheckoutService.ClaimGuestOrders(ctx, model.ClaimGuestOrdersRequest{Username: testUsername})
+	if err != nil {
+		t.Fatalf("claim guest orders: %v", err)
+	}
+
+	claimed := response.Data.(model.ClaimGuestOrdersResponseData).Orders
+
+	if len(claimed) != 2 || claimed[0].OrderReference != first.OrderReference || claimed[1].OrderReference != second.OrderReference {
+		t.Fatalf("expected the two orders of john@example.com, got %+v", claimed)
+	}
+
+	// The claimed order belongs to the account, its token no longer opens it
+	_, err = f.guestCheckoutService.GetGuestOrder(ctx, model.GuestOrderRequest{OrderReference: first.OrderReference, AccessToken: first.AccessToken})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	orders, err := f.orderService.GetAccountOrders(ctx, model.GetAccountOrdersRequest{AccountUserame: testUsername})
+	if err != nil {
+		t.Fatalf("get account orders: %v", err)
+	}
+
+	if data := orders.Data.(model.GetAccountOrdersResponseData); len(data.Orders) != 2 {
+		t.Fatalf("expected the claimed orders in the account, got %+v", data.Orders)
+	}
+
+	// Claiming again finds nothing left
+	response, err = f.guestCheckoutService.ClaimGuestOrders(ctx, model.ClaimGuestOrdersRequest{Username: testUsername})
+	if err != nil {
+		t.Fatalf("claim guest orders: %v", err)
+	}
+
+	if claimed := response.Data.(model.ClaimGuestOrdersResponseData).Orders; len(claimed) != 0 {
+		t.Fatalf("expected nothing left to claim, got %+v", claimed)
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Larger images are refused before being decoded
+	maxImagePixels = 40_000_000
+
+	thumbnailJPEGQuality = 85
+)
+
+// Supported upload types and the extension used for their blobs
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// thumbnailSize bounds the longest edge of a generated thumbnail
+type thumbnailSize struct {
+	Name    string
+	MaxEdge int
+}
+
+var thumbnailSizes = []thumbnailSize{
+	{Name: "small", MaxEdge: 160},
+	{Name: "medium", MaxEdge: 480},
+	{Name: "large", MaxEdge: 1024},
+}
+
+type thumbnail struct {
+	Size        string
+	Content     []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+/*
+*
+
+	Decode an uploaded image and build its thumbnails :
+	- Only sizes smaller than the original are generated, the original is never upscaled
+	- JPEG thumbnails stay JPEG, PNG and GIF thumbnails are PNG (first GIF frame)
+
+*
+*/
+func decodeImage(content []byte) (image.Image, image.Config, error) {
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, config, common.NewError(errors.New("file is not a valid image"), common.ErrValidation)
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		err := fmt.Errorf("image must be at most %d pixels, got %dx%d", maxImagePixels, config.Width, config.Height)
+		logrus.Error(err)
+		return nil, config, common.NewError(err, common.ErrValidation)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+
+	if err != nil {
+		logrus.Error(err)
+		return nil, config, common.NewError(errors.New("file is not a valid image"), common.ErrValidation)
+	}
+
+	return img, config, nil
+}
+
+func buildThumbnails(img image.Image, contentType string) ([]thumbnail, error) {
+
+	bounds := img.Bounds()
+	var thumbnails []thumbnail
+
+	for _, size := range thumbnailSizes {
+
+		width, height, ok := fitWithin(bounds.Dx(), bounds.Dy(), size.MaxEdge)
+
+		if !ok {
+			continue
+		}
+
+		resized := resizeImage(img, width, height)
+
+		var buffer bytes.Buffer
+		var err error
+		thumbnailType := "image/png"
+
+		if contentType == "image/jpeg" {
+			thumbnailType = contentType
+			err = jpeg.Encode(&buffer, resized, &jpeg.Options{Quality: thumbnailJPEGQuality})
+		} else {
+			err = png.Encode(&buffer, resized)
+		}
+
+		if err != nil {
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+
+		thumbnails = append(thumbnails, thumbnail{
+			Size:        size.Name,
+			Content:     buffer.Bytes(),
+			ContentType: thumbnailType,
+			Width:       width,
+			Height:      height,
+		})
+	}
+
+	return thumbnails, nil
+}
+
+// fitWithin scales width x height so the longest edge is maxEdge, false when it already fits
+func fitWithin(width int, height int, maxEdge int) (int, int, bool) {
+
+	if width <= maxEdge && height <= maxEdge {
+		return width, height, false
+	}
+
+	if width >= height {
+		return maxEdge, max(1, height*maxEdge/width), true
+	}
+
+	return max(1, width*maxEdge/height), maxEdge, true
+}
+
+// resizeImage downscales by averaging the source pixels covered by every target pixel (box filter)
+func resizeImage(src image.Image, width int, height int) *image.RGBA {
+
+	bounds := src.Bounds()
+	source := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(source, source.Bounds(), src, bounds.Min, draw.Src)
+
+	sourceWidth, sourceHeight := source.Bounds().Dx(), source.Bounds().Dy()
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+
+		y0 := y * sourceHeight / height
+		y1 := max(y0+1, (y+1)*sourceHeight/height)
+
+		for x := 0; x < width; x++ {
+
+			x0 := x * sourceWidth / width
+			x1 := max(x0+1, (x+1)*sourceWidth/width)
+
+			var r, g, b, a, count uint64
+
+			for sy := y0; sy < y1; sy++ {
+				row := source.Pix[sy*source.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					pixel := row[sx*4 : sx*4+4]
+					r += uint64(pixel[0])
+					g += uint64(pixel[1])
+					b += uint64(pixel[2])
+					a += uint64(pixel[3])
+					count++
+				}
+			}
+
+			offset := y*target.Stride + x*4
+			target.Pix[offset] = uint8(r / count)
+			target.Pix[offset+1] = uint8(g / count)
+			target.Pix[offset+2] = uint8(b / count)
+			target.Pix[offset+3] = uint8(a / count)
+		}
+	}
+
+	return target
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const maxMovementNoteLength = 255
+
+type InventoryService struct {
+	txRunner                    repository.TransactionRunner
+	productRepository           repository.ProductRepository
+	inventoryMovementRepository repository.InventoryMovementRepository
+	stockNotificationService    *StockNotificationService
+}
+
+func NewInventoryService(
+	txRunner repository.TransactionRunner,
+	productRepository repository.ProductRepository,
+	inventoryMovementRepository repository.InventoryMovementRepository,
+	stockNotificationService *StockNotificationService) *InventoryService {
+	return &InventoryService{
+		txRunner:                    txRunner,
+		productRepository:           productRepository,
+		inventoryMovementRepository: inventoryMovementRepository,
+		stockNotificationService:    stockNotificationService,
+	}
+}
+
+// GetInventoryMovements lists the ledger of a product and its variants, newest first
+func (is *InventoryService) GetInventoryMovements(ctx context.Context, request model.GetInventoryMovementsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.IsPaginate {
+		if request.Page < 1 {
+			err := errors.New("page must be greater than 0")
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+		if request.PerPage < 1 || request.PerPage > 100 {
+			err := errors.New("perPage must be between 1 and 100")
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+	}
+
+	exists, err := is.productRepository.CheckById(ctx, request.ProductID)
+
+	if err != nil {
+		return response, err
+	}
+
+	if !exists {
+		err := fmt.Errorf("product %d not found", request.ProductID)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	paginationParams := model.PaginationParams{
+		IsPaginate: request.IsPaginate,
+		Page:       request.Page,
+		PerPage:    request.PerPage,
+	}
+
+	movements, totalData, err := is.inventoryMovementRepository.FindByProductID(ctx, request.ProductID, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	// The product is read after its movements, a concurrent order can only make the stock newer
+	products, err := is.productRepository.FindMultipleByIDs(ctx, []int64{request.ProductID})
+
+	if err != nil {
+		return response, err
+	}
+
+	movementsDTO := make([]model.InventoryMovementDTO, len(movements))
+
+	for i, movement := range movements {
+		movementsDTO[i] = newInventoryMovementDTO(movement)
+	}
+
+	metadata := model.MetadataDTO{}
+	metadata.Page = request.Page
+	metadata.TotalData = totalData
+	metadata.PerPage = request.PerPage
+
+	totalPage := 1
+	if request.IsPaginate && request.PerPage > 0 {
+		totalPage = int(math.Ceil(float64(totalData) / float64(request.PerPage)))
+	}
+
+	metadata.TotalPage = totalPage
+
+	responseData := model.GetInventoryMovementsResponseData{
+		ProductID: request.ProductID,
+		Movements: movementsDTO,
+		Metadata:  metadata,
+	}
+
+	if len(products) > 0 {
+		responseData.Stock = products[0].Stock
+		responseData.LowStockThreshold = products[0].LowStockThreshold
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = responseData
+
+	return response, nil
+}
+
+/*
+*
+
+	Book a stock change made outside of orders :
+	- RESTOCK : goods received, quantity must be positive
+	- MANUAL_ADJUSTMENT : stock count corrections (damage, loss, ...), any non zero quantity, a note is required
+	- Products sold by variant are adjusted per variant, the product stock follows
+	- The stock must not become negative, the change is applied with a conditional update
+	- Subscribers are notified when the product stock goes from 0 to above 0,
+	  staff are alerted when it falls to the low stock threshold
+
+*
+*/
+func (is *InventoryService) AdjustStock(ctx context.Context, request model.AdjustStockRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := is.validateAdjustStockRequest(request)
+
+	if err != nil {
+		return response, err
+	}
+
+	var product entity.Product
+	var movement entity.InventoryMovement
+	var lowStockAlerts []notification.LowStockAlert
+	var backInStockNotices []notification.BackInStockNotice
+
+	err = is.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		// Locks the product, stock changes of a product are serialized
+		product, err = repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		variants, err := repos.Variant.FindByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		var variant *entity.ProductVariant
+
+		if len(variants) > 0 {
+
+			if request.VariantID == nil {
+				err := fmt.Errorf("variantId is required, product %d is sold by variant", product.ID)
+				logrus.Error(err)
+				return common.NewError(err, common.ErrValidation)
+			}
+
+			isOwnVariant := slices.ContainsFunc(variants, func(variant entity.ProductVariant) bool {
+				return variant.ID == *request.VariantID
+			})
+
+			if !isOwnVariant {
+				err := fmt.Errorf("variant %d does not belong to product %d", *request.VariantID, product.ID)
+				logrus.Error(err)
+				return common.NewError(err, common.ErrValidation)
+			}
+
+			locked, err := repos.Variant.FindByID(ctx, *request.VariantID)
+
+			if err != nil {
+				return err
+			}
+
+			variant = &locked
+
+		} else if request.VariantID != nil {
+			err := fmt.Errorf("product %d has no variants", product.ID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		stockBefore := product.Stock
+
+		product.Stock, err = changeStock(ctx, repos.Product, product.ID, request.Quantity, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		if variant != nil {
+
+			variant.Stock, err = changeStock(ctx, repos.Variant, variant.ID, request.Quantity, request.Username)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		movements := []entity.InventoryMovement{newStockMovement(request.MovementType, request.Quantity, product, variant, "", request.Username)}
+		movements[0].Note = strings.TrimSpace(request.Note)
+
+		err = repos.Movement.CreateBatch(ctx, movements)
+
+		if err != nil {
+			return err
+		}
+
+		movement = movements[0]
+
+		if alert, crossed := lowStockAlert(product, stockBefore, ""); crossed {
+			lowStockAlerts = append(lowStockAlerts, alert)
+		}
+
+		if stockBefore <= 0 && product.Stock > 0 {
+
+			backInStockNotices, err = claimBackInStock(ctx, repos, product)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	is.stockNotificationService.dispatchLowStock(ctx, lowStockAlerts)
+	is.stockNotificationService.dispatchBackInStock(ctx, backInStockNotices)
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.AdjustStockResponseData{
+		ProductID: product.ID,
+		Stock:     product.Stock,
+		Movement:  newInventoryMovementDTO(movement),
+	}
+
+	return response, nil
+}
+
+// UpdateLowStockThreshold sets the stock at which staff are alerted, 0 disables the alert
+func (is *InventoryService) UpdateLowStockThreshold(ctx context.Context, request model.UpdateLowStockThresholdRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.Threshold == nil {
+		err := errors.New("threshold is required")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	if *request.Threshold < 0 {
+		err := errors.New("threshold must not be negative")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var product entity.Product
+
+	err := is.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		err := repos.Product.UpdateLowStockThreshold(ctx, request.ProductID, *request.Threshold, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		product, err = repos.Product.FindByID(ctx, request.ProductID)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.UpdateLowStockThresholdResponseData{
+		ProductID:         product.ID,
+		Stock:             product.Stock,
+		LowStockThreshold: product.LowStockThreshold,
+	}
+
+	return response, nil
+}
+
+// Reconcile lists the products and variants whose stock differs from the sum of their ledger
+func (is *InventoryService) Reconcile(ctx context.Context) ([]repository.StockDiscrepancy, error) {
+	return is.inventoryMovementRepository.FindDiscrepancies(ctx)
+}
+
+// stockChanger is implemented by the product and the variant repositories
+type stockChanger interface {
+	DecrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error)
+	IncrementStock(ctx context.Context, id int64, quantity int64, updatedBy string) (int64, error)
+}
+
+// changeStock applies a signed quantity atomically, a decrement never takes the stock below 0
+func changeStock(ctx context.Context, repo stockChanger, id int64, quantity int64, updatedBy string) (int64, error) {
+
+	if quantity < 0 {
+		return repo.DecrementStock(ctx, id, -quantity, updatedBy)
+	}
+
+	return repo.IncrementStock(ctx, id, quantity, updatedBy)
+}
+
+func (is *InventoryService) validateAdjustStockRequest(request model.AdjustStockRequest) error {
+
+	switch request.MovementType {
+	case constant.MovementTypeRestock:
+		if request.Quantity <= 0 {
+			err := errors.New("quantity of a restock must be greater than 0")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+	case constant.MovementTypeManualAdjustment:
+		if request.Quantity == 0 {
+			err := errors.New("quantity of an adjustment must not be 0")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+		if strings.TrimSpace(request.Note) == "" {
+			err := errors.New("note is required to explain an adjustment")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+	default:
+		err := fmt.Errorf("type must be %s or %s", constant.MovementTypeRestock, constant.MovementTypeManualAdjustment)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if len(strings.TrimSpace(request.Note)) > maxMovementNoteLength {
+		err := fmt.Errorf("note must not be longer than %d characters", maxMovementNoteLength)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func newInventoryMovementDTO(movement entity.InventoryMovement) model.InventoryMovementDTO {
+	return model.InventoryMovementDTO{
+		ID:         movement.ID,
+		VariantID:  movement.VariantID,
+		Type:       movement.MovementType,
+		Quantity:   movement.Quantity,
+		StockAfter: movement.StockAfter,
+		Reference:  movement.Reference,
+		Note:       movement.Note,
+		CreatedAt:  movement.CreatedAt,
+		CreatedBy:  movement.CreatedBy,
+	}
+}
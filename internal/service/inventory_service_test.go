@@ -0,0 +1,274 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) movementsOf(t *testing.T, productID int64) []model.InventoryMovementDTO {
+
+	t.Helper()
+
+	response, err := f.inventoryService.GetInventoryMovements(context.Background(), model.GetInventoryMovementsRequest{ProductID: productID})
+	if err != nil {
+		t.Fatalf("get movements: %v", err)
+	}
+
+	return response.Data.(model.GetInventoryMovementsResponseData).Movements
+}
+
+func (f *fixture) assertReconciled(t *testing.T) {
+
+	t.Helper()
+
+	discrepancies, err := f.inventoryService.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	if len(discrepancies) != 0 {
+		t.Fatalf("expected stock to match the ledger, got %+v", discrepancies)
+	}
+}
+
+func TestOrderLifecycleWritesLedger(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		payFirst   bool
+		returnType string
+	}{
+		{name: "pending order returns stock", payFirst: false, returnType: constant.MovementTypeCancellationReturn},
+		{name: "paid order refunds stock", payFirst: true, returnType: constant.MovementTypeRefund},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newFixture(t)
+			ctx := context.Background()
+
+			data := f.submitOrder(t, submitRequest(
+				model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 4},
+				model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2},
+			))
+
+			f.assertReconciled(t)
+
+			if tt.payFirst {
+				_, err := f.paymentService.SubmitPayment(ctx, model.SubmitPaymentRequest{
+					OrderReference:  data.OrderReference,
+					CardHolderName:  "John Doe",
+					CardNumber:      "4111 1111 1111 1111",
+					Status:          constant.PaymentStatusReceived,
+					AccountUsername: testUsername,
+				})
+
+				if err != nil {
+					t.Fatalf("submit payment: %v", err)
+				}
+			}
+
+			_, err := f.orderService.CancelOrder(ctx, model.CancelOrderRequest{OrderReference: data.OrderReference, AccountUsername: testUsername})
+			if err != nil {
+				t.Fatalf("cancel order: %v", err)
+			}
+
+			f.assertReconciled(t)
+
+			// Newest first, one movement per order line
+			movements := f.movementsOf(t, 1)
+
+			expected := []struct {
+				movementType string
+				quantity     int64
+				stockAfter   int64
+			}{
+				{tt.returnType, 2, 10},
+				{tt.returnType, 4, 8},
+				{constant.MovementTypeOrderReservation, -2, 4},
+				{constant.MovementTypeOrderReservation, -4, 6},
+				{constant.MovementTypeOpeningBalance, 10, 10},
+			}
+
+			if len(movements) != len(expected) {
+				t.Fatalf("expected %d movements, got %+v", len(expected), movements)
+			}
+
+			for i, want := range expected {
+
+				got := movements[i]
+
+				if got.Type != want.movementType || got.Quantity != want.quantity || got.StockAfter != want.stockAfter {
+					t.Fatalf("movement %d: expected %+v, got %+v", i, want, got)
+				}
+
+				if i < 4 && got.Reference != data.OrderReference {
+					t.Fatalf("movement %d must reference the order, got %q", i, got.Reference)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderLedgerBooksVariantMovements(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	data := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 3}))
+
+	movements := f.movementsOf(t, 4)
+
+	if len(movements) != 3 || movements[0].VariantID == nil || *movements[0].VariantID != 42 || movements[0].StockAfter != 2 {
+		t.Fatalf("unexpected movements %+v", movements)
+	}
+
+	_, err := f.orderService.CancelOrder(context.Background(), model.CancelOrderRequest{OrderReference: data.OrderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	movements = f.movementsOf(t, 4)
+
+	if movements[0].VariantID == nil || *movements[0].VariantID != 42 || movements[0].StockAfter != 5 || movements[0].Quantity != 3 {
+		t.Fatalf("unexpected return movement %+v", movements[0])
+	}
+
+	f.assertReconciled(t)
+}
+
+func TestAdjustStock(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+	ctx := context.Background()
+
+	response, err := f.inventoryService.AdjustStock(ctx, model.AdjustStockRequest{
+		ProductID:    1,
+		MovementType: constant.MovementTypeRestock,
+		Quantity:     5,
+		Username:     "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("restock: %v", err)
+	}
+
+	adjusted := response.Data.(model.AdjustStockResponseData)
+
+	if adjusted.Stock != 15 || adjusted.Movement.ID == 0 || adjusted.Movement.StockAfter != 15 || adjusted.Movement.CreatedBy != "janestaff" {
+		t.Fatalf("unexpected adjustment %+v", adjusted)
+	}
+
+	variantID := int64(41)
+
+	_, err = f.inventoryService.AdjustStock(ctx, model.AdjustStockRequest{
+		ProductID:    4,
+		VariantID:    &variantID,
+		MovementType: constant.MovementTypeManualAdjustment,
+		Quantity:     -1,
+		Note:         "Damaged in storage",
+	})
+
+	if err != nil {
+		t.Fatalf("adjust variant: %v", err)
+	}
+
+	if stock := f.variantStockOf(t, 41); stock != 1 {
+		t.Fatalf("expected variant stock 1, got %d", stock)
+	}
+
+	if stock := f.stockOf(t, 4); stock != 6 {
+		t.Fatalf("expected product stock 6, got %d", stock)
+	}
+
+	f.assertReconciled(t)
+
+	otherVariant := int64(99)
+
+	tests := []struct {
+		name    string
+		request model.AdjustStockRequest
+		kind    error
+	}{
+		{"unknown type", model.AdjustStockRequest{ProductID: 1, MovementType: constant.MovementTypeRefund, Quantity: 1}, common.ErrValidation},
+		{"negative restock", model.AdjustStockRequest{ProductID: 1, MovementType: constant.MovementTypeRestock, Quantity: -1}, common.ErrValidation},
+		{"adjustment without note", model.AdjustStockRequest{ProductID: 1, MovementType: constant.MovementTypeManualAdjustment, Quantity: -1}, common.ErrValidation},
+		{"negative stock", model.AdjustStockRequest{ProductID: 2, MovementType: constant.MovementTypeManualAdjustment, Quantity: -4, Note: "Lost"}, common.ErrValidation},
+		{"variant required", model.AdjustStockRequest{ProductID: 4, MovementType: constant.MovementTypeRestock, Quantity: 1}, common.ErrValidation},
+		{"foreign variant", model.AdjustStockRequest{ProductID: 4, VariantID: &otherVariant, MovementType: constant.MovementTypeRestock, Quantity: 1}, common.ErrValidation},
+		{"variant of a simple product", model.AdjustStockRequest{ProductID: 1, VariantID: &variantID, MovementType: constant.MovementTypeRestock, Quantity: 1}, common.ErrValidation},
+		{"unknown product", model.AdjustStockRequest{ProductID: 99, MovementType: constant.MovementTypeRestock, Quantity: 1}, common.ErrResourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := f.inventoryService.AdjustStock(ctx, tt.request)
+
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	// Rejected adjustments leave no trace
+	f.assertReconciled(t)
+
+	if stock := f.stockOf(t, 2); stock != 3 {
+		t.Fatalf("expected product 2 stock 3, got %d", stock)
+	}
+}
+
+func TestReconcileReportsStockOutsideTheLedger(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	// The stock moves without its ledger movement
+	_, err := f.repos.Product.IncrementStock(ctx, 2, 4, "janestaff")
+	if err != nil {
+		t.Fatalf("increment stock: %v", err)
+	}
+
+	discrepancies, err := f.inventoryService.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	if len(discrepancies) != 1 || discrepancies[0].ProductID != 2 || discrepancies[0].Stock != 7 || discrepancies[0].LedgerStock != 3 {
+		t.Fatalf("unexpected discrepancies %+v", discrepancies)
+	}
+}
+
+func TestGetInventoryMovementsPaginates(t *testing.T) {
+
+	f := newFixture(t)
+
+	for i := 0; i < 3; i++ {
+		f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	}
+
+	response, err := f.inventoryService.GetInventoryMovements(context.Background(), model.GetInventoryMovementsRequest{
+		ProductID:  1,
+		IsPaginate: true,
+		Page:       2,
+		PerPage:    3,
+	})
+
+	if err != nil {
+		t.Fatalf("get movements: %v", err)
+	}
+
+	data := response.Data.(model.GetInventoryMovementsResponseData)
+
+	if data.Stock != 7 || data.Metadata.TotalData != 4 || data.Metadata.TotalPage != 2 || len(data.Movements) != 1 || data.Movements[0].Type != constant.MovementTypeOpeningBalance {
+		t.Fatalf("unexpected page %+v", data)
+	}
+
+	_, err = f.inventoryService.GetInventoryMovements(context.Background(), model.GetInventoryMovementsRequest{ProductID: 99})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
@@ -1,18 +1,22 @@
 package service
 
 import (
+	"time"
+
 	"github.com/golang-jwt/jwt"
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
 	"github.com/sirupsen/logrus"
 )
 
 type JwtService struct {
-	Secret string
+	Secret        string
+	TokenLifetime time.Duration
 }
 
-func NewJwtService(secret string) *JwtService {
+func NewJwtService(secret string, tokenLifetime time.Duration) *JwtService {
 	return &JwtService{
-		Secret: secret,
+		Secret:        secret,
+		TokenLifetime: tokenLifetime,
 	}
 }
 
@@ -20,7 +24,7 @@ func (j *JwtService) GenerateJWT(username string, expiry int64) (string, error)
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": username,
-		"exp": expiry, // 24 hours
+		"exp": expiry,
 	})
 
 	tokenString, err := token.SignedString([]byte(j.Secret))
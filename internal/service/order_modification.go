@@ -0,0 +1,768 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+*
+
+	Modification of an order waiting for its payment :
+	- Only the account of the order modifies it, and only while it is PENDING PAYMENT
+	- A line keeps its price while its quantity does not grow, a grown line or an added line is charged the current price, priceUsed must match it
+	- The modified order is checked like a submitted order and keeps at least one line, cancelling is the way to drop them all
+	- The stock taken or released by the changes is booked in the same transaction, released stock is a MODIFICATION_RETURN
+	- The coupon of the order is computed again on the new lines without being redeemed again, the order must still reach its minimum
+	- Shipping, taxes, totals and the pending payment are computed again, the changes are recorded in the order history
+
+*
+*/
+func (os *OrderService) ModifyOrder(ctx context.Context, request model.ModifyOrderRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	deliveryChanged := request.AddressID != 0 || strings.TrimSpace(request.DeliveryAddress) != ""
+
+	if len(request.Items) == 0 && len(request.AddItems) == 0 && !deliveryChanged {
+		err := errors.New("nothing to modify")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	if !deliveryChanged && strings.TrimSpace(request.Region) != "" {
+		err := errors.New("region is only changed along with addressId or deliveryAddress")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var lowStockAlerts []notification.LowStockAlert
+
+	err := os.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		// The order stays locked until the modification is committed
+		order, err := repos.Order.FindByIDWithItems(ctx, request.OrderReference)
+
+		if err != nil {
+			return err
+		}
+
+		if !order.IsOwnedBy(request.Username) {
+			err := fmt.Errorf("order %s does not belong to %s", order.OrderReference, request.Username)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrAccessDenied)
+		}
+
+		if order.Status != constant.OrderStatusPendingPayment {
+			err := fmt.Errorf("order %s is %s, only an order waiting for its payment can be modified", order.OrderReference, order.Status)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
+
+		account, err := repos.Account.FindByUsername(ctx, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		if !account.IsActive {
+			err := errors.New("account inactive")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrAccessDenied)
+		}
+
+		payment, err := repos.Payment.FindByOrderReference(ctx, order.OrderReference)
+
+		if err != nil {
+			return err
+		}
+
+		previousItems := order.OrderItems
+		previousTotal := order.Total
+		previousDelivery := order.DeliveryAddress
+
+		kept, removed, changes, err := modifyOrderLines(previousItems, request.Items)
+
+		if err != nil {
+			return err
+		}
+
+		// The modified order is validated like a submitted one, kept lines at their price
+		submitRequest := model.SubmitOrderRequest{
+			AddressID:       request.AddressID,
+			DeliveryAddress: request.DeliveryAddress,
+			Region:          request.Region,
+			Currency:        order.Currency,
+		}
+
+		for _, line := range kept {
+			submitRequest.OrderItems = append(submitRequest.OrderItems, line.request)
+		}
+
+		submitRequest.OrderItems = append(submitRequest.OrderItems, request.AddItems...)
+
+		err = os.validateOrderRequest(submitRequest)
+
+		if err != nil {
+			return err
+		}
+
+		// Removed lines are locked too, their stock comes back
+		lockedItems := slices.Clone(submitRequest.OrderItems)
+
+		for _, orderItem := range removed {
+			lockedItems = append(lockedItems, newOrderItemRequest(orderItem))
+		}
+
+		usedProducts, err := os.lockRequestProducts(ctx, repos.Product, lockedItems)
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		_, err = applyDuePriceChanges(ctx, repos, usedProducts, now)
+
+		if err != nil {
+			return err
+		}
+
+		priceList, err := findPriceList(ctx, repos.PriceList, slices.Collect(maps.Keys(usedProducts)), order.Currency)
+
+		if err != nil {
+			return err
+		}
+
+		usedVariants, err := os.lockRequestVariants(ctx, repos.Variant, lockedItems)
+
+		if err != nil {
+			return err
+		}
+
+		// Grown lines are charged the current price
+		var orderItems []entity.OrderItem
+
+		for _, line := range kept {
+
+			if line.grown {
+
+				product := usedProducts[line.item.ProductID]
+
+				variant, err := os.orderVariant(ctx, repos.Variant, usedVariants, product, line.request)
+
+				if err != nil {
+					return err
+				}
+
+				price, err := modifiedLinePrice(product, variant, line.request, order.Currency, priceList, now)
+
+				if err != nil {
+					return err
+				}
+
+				line.item.PriceSnapshot = price
+			}
+
+			orderItems = append(orderItems, line.item)
+		}
+
+		for _, orderItemRequest := range request.AddItems {
+
+			product := usedProducts[orderItemRequest.ProductId]
+
+			variant, err := os.orderVariant(ctx, repos.Variant, usedVariants, product, orderItemRequest)
+
+			if err != nil {
+				return err
+			}
+
+			price, err := modifiedLinePrice(product, variant, orderItemRequest, order.Currency, priceList, now)
+
+			if err != nil {
+				return err
+			}
+
+			orderItem, err := os.createOrderItem(orderItemRequest, price, order, account, variant)
+
+			if err != nil {
+				return err
+			}
+
+			orderItems = append(orderItems, orderItem)
+			changes = append(changes, entity.OrderChange{
+				Type:               constant.OrderChangeItemAdded,
+				OrderItemReference: orderItem.OrderItemReference,
+				ProductID:          orderItem.ProductID,
+				VariantID:          orderItem.VariantID,
+				Quantity:           orderItem.Quantity,
+			})
+		}
+
+		// Stock to take (positive) or to release (negative) for each product, variant and line
+		productDeltas := make(map[int64]int64)
+		variantDeltas := make(map[int64]int64)
+		lineDeltas := make(map[lineKey]int64)
+
+		addDelta := func(orderItem entity.OrderItem, quantity int64) {
+
+			key := lineKey{ProductID: orderItem.ProductID}
+			productDeltas[orderItem.ProductID] += quantity
+
+			if orderItem.VariantID != nil {
+				key.VariantID = *orderItem.VariantID
+				variantDeltas[key.VariantID] += quantity
+			}
+
+			lineDeltas[key] += quantity
+		}
+
+		for _, orderItem := range previousItems {
+			addDelta(orderItem, -orderItem.Quantity)
+		}
+
+		for _, orderItem := range orderItems {
+			addDelta(orderItem, orderItem.Quantity)
+		}
+
+		stocksBefore := make(map[int64]int64, len(usedProducts))
+
+		// Ascending id order, products before variants like SubmitOrder
+		for _, productID := range slices.Sorted(maps.Keys(productDeltas)) {
+
+			product := usedProducts[productID]
+			stocksBefore[productID] = product.Stock
+			delta := productDeltas[productID]
+
+			switch {
+			case delta > 0:
+
+				if product.Stock < delta {
+					err := fmt.Errorf("insufficient stock for product: %v , requested : %v , available: %v ", product.ID, delta, product.Stock)
+					logrus.Error(err)
+					return common.NewError(err, common.ErrValidation)
+				}
+
+				product.Stock, err = repos.Product.DecrementStock(ctx, productID, delta, constant.SYSTEM)
+
+			case delta < 0:
+				product.Stock, err = repos.Product.IncrementStock(ctx, productID, -delta, constant.SYSTEM)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			usedProducts[productID] = product
+		}
+
+		for _, variantID := range slices.Sorted(maps.Keys(variantDeltas)) {
+
+			variant := usedVariants[variantID]
+			delta := variantDeltas[variantID]
+
+			switch {
+			case delta > 0:
+
+				if variant.Stock < delta {
+					err := fmt.Errorf("insufficient stock for variant: %v , requested : %v , available: %v ", variant.ID, delta, variant.Stock)
+					logrus.Error(err)
+					return common.NewError(err, common.ErrValidation)
+				}
+
+				variant.Stock, err = repos.Variant.DecrementStock(ctx, variantID, delta, constant.SYSTEM)
+
+			case delta < 0:
+				variant.Stock, err = repos.Variant.IncrementStock(ctx, variantID, -delta, constant.SYSTEM)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			usedVariants[variantID] = variant
+		}
+
+		// One movement per product or variant whose stock changed, in the same order as the stock
+		var movements []entity.InventoryMovement
+
+		keys := slices.SortedFunc(maps.Keys(lineDeltas), func(a lineKey, b lineKey) int {
+			if a.ProductID != b.ProductID {
+				return int(a.ProductID - b.ProductID)
+			}
+			return int(a.VariantID - b.VariantID)
+		})
+
+		for _, key := range keys {
+
+			delta := lineDeltas[key]
+
+			if delta == 0 {
+				continue
+			}
+
+			var variant *entity.ProductVariant
+
+			if key.VariantID != 0 {
+				current := usedVariants[key.VariantID]
+				variant = &current
+			}
+
+			movementType := constant.MovementTypeOrderReservation
+
+			if delta < 0 {
+				movementType = constant.MovementTypeModificationReturn
+			}
+
+			movements = append(movements, newStockMovement(movementType, -delta, usedProducts[key.ProductID], variant, order.OrderReference, account.Username))
+		}
+
+		// Every amount of the order is computed again
+		grandTotal := common.NewMoney(0, order.Currency)
+
+		for i := range orderItems {
+
+			orderItem := &orderItems[i]
+			orderItem.Total = orderItem.PriceSnapshot * orderItem.Quantity
+			orderItem.DiscountAmount = 0
+			orderItem.UpdatedAt = now
+			orderItem.UpdatedBy = account.Username
+
+			// Prevent integer overflow
+			if orderItem.Total < 0 || orderItem.Total < orderItem.PriceSnapshot || orderItem.Total < orderItem.Quantity {
+				err := fmt.Errorf("price calculation overflow for product: %v", orderItem.ProductID)
+				logrus.Error(err)
+				return common.NewError(err, common.ErrValidation)
+			}
+
+			grandTotal, err = grandTotal.Add(common.NewMoney(orderItem.Total, orderItem.Currency))
+
+			if err != nil {
+				logrus.Error(err)
+				return err
+			}
+		}
+
+		// Business rule: Maximum order total
+		if grandTotal.Amount > 10000000000 {
+			err := fmt.Errorf("order total exceeds maximum limit: %v", grandTotal)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		if deliveryChanged {
+
+			region := normalizeTaxRegion(request.Region)
+
+			if request.AddressID != 0 {
+
+				err = deliverToAddress(ctx, repos, &order, request.AddressID, region, account.Username)
+
+				if err != nil {
+					return err
+				}
+
+			} else {
+				order.AddressID = nil
+				order.Delivery = entity.PostalAddress{}
+				order.DeliveryAddress = strings.TrimSpace(request.DeliveryAddress)
+				order.TaxRegion = region
+			}
+
+			if order.DeliveryAddress != previousDelivery {
+				changes = append(changes, entity.OrderChange{
+					Type:             constant.OrderChangeDeliveryChanged,
+					PreviousDelivery: previousDelivery,
+					Delivery:         order.DeliveryAddress,
+				})
+			}
+		}
+
+		if len(changes) == 0 {
+			err := errors.New("nothing to modify")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		// The parcel is priced with the method chosen at checkout
+		order.ShippingWeight = parcelWeight(orderItems, usedProducts)
+
+		if order.ShippingMethod != "" {
+
+			shipping, err := selectShippingMethod(ctx, repos, order.ShippingMethod, order.TaxRegion, order.ShippingWeight, grandTotal)
+
+			if err != nil {
+				return err
+			}
+
+			order.ShippingMethodID = &shipping.Method.ID
+			order.ShippingFee = shipping.Fee
+		}
+
+		order.DiscountTotal = 0
+
+		for _, discount := range order.Discounts {
+
+			promotion, err := repos.Promotion.FindByID(ctx, discount.PromotionID)
+
+			if err != nil {
+				return err
+			}
+
+			amount, shares, err := recomputeDiscount(promotion, newPromotionLines(orderItems, usedProducts), grandTotal, order.ShippingFee)
+
+			if err != nil {
+				return err
+			}
+
+			for i := range orderItems {
+				orderItems[i].DiscountAmount += shares[i]
+			}
+
+			err = repos.Discount.UpdateAmount(ctx, discount.ID, amount)
+
+			if err != nil {
+				return err
+			}
+
+			order.DiscountTotal += amount
+		}
+
+		// Taxes of the new lines replace the previous ones
+		itemTaxes, err := computeOrderTaxes(ctx, repos, order.TaxRegion, orderItems, usedProducts, now)
+
+		if err != nil {
+			return err
+		}
+
+		order.TaxTotal = 0
+		order.TaxIncluded = 0
+
+		for _, tax := range itemTaxes {
+
+			order.TaxTotal += tax.Amount
+
+			if tax.Mode == constant.TaxModeInclusive {
+				order.TaxIncluded += tax.Amount
+			}
+		}
+
+		order.Subtotal = grandTotal.Amount
+		order.Total = order.Subtotal + order.ShippingFee - order.DiscountTotal + order.TaxTotal - order.TaxIncluded
+		order.UpdatedAt = now
+		order.UpdatedBy = account.Username
+
+		err = repos.ItemTax.DeleteByOrderReference(ctx, order.OrderReference)
+
+		if err != nil {
+			return err
+		}
+
+		removedReferences := make([]string, len(removed))
+
+		for i, orderItem := range removed {
+			removedReferences[i] = orderItem.OrderItemReference
+		}
+
+		err = repos.OrderItem.DeleteByReferences(ctx, removedReferences)
+
+		if err != nil {
+			return err
+		}
+
+		var addedItems []entity.OrderItem
+
+		for _, orderItem := range orderItems {
+
+			if !slices.ContainsFunc(previousItems, func(previous entity.OrderItem) bool {
+				return previous.OrderItemReference == orderItem.OrderItemReference
+			}) {
+				addedItems = append(addedItems, orderItem)
+				continue
+			}
+
+			err = repos.OrderItem.Update(ctx, orderItem)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(addedItems) > 0 {
+
+			err = repos.OrderItem.CreateBatch(ctx, addedItems, len(addedItems))
+
+			if err != nil {
+				return err
+			}
+		}
+
+		err = repos.ItemTax.CreateBatch(ctx, itemTaxes)
+
+		if err != nil {
+			return err
+		}
+
+		err = repos.Movement.CreateBatch(ctx, movements)
+
+		if err != nil {
+			return err
+		}
+
+		// The order is saved without its relations, they were written above
+		order.OrderItems = nil
+		order.Discounts = nil
+		order.Shipments = nil
+		order.History = nil
+
+		err = repos.Order.Update(ctx, order)
+
+		if err != nil {
+			return err
+		}
+
+		payment.Total = order.Total
+		payment.UpdatedAt = now
+		payment.UpdatedBy = account.Username
+
+		err = repos.Payment.Update(ctx, payment)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = repos.History.Create(ctx, entity.OrderHistory{
+			OrderReference: order.OrderReference,
+			Action:         constant.OrderActionModified,
+			Changes:        changes,
+			PreviousTotal:  previousTotal,
+			Total:          order.Total,
+			CreatedAt:      now,
+			CreatedBy:      account.Username,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, productID := range slices.Sorted(maps.Keys(productDeltas)) {
+			if alert, crossed := lowStockAlert(usedProducts[productID], stocksBefore[productID], order.OrderReference); crossed {
+				lowStockAlerts = append(lowStockAlerts, alert)
+			}
+		}
+
+		discounts, err := repos.Discount.FindByOrderReference(ctx, order.OrderReference)
+
+		if err != nil {
+			return err
+		}
+
+		response.ResponseCode = constant.SuccessCode
+		response.ResponseMessage = constant.SuccessMessage
+		response.Data = model.ModifyOrderResponseData{
+			SubmitOrderResponseData: model.SubmitOrderResponseData{
+				OrderReference: order.OrderReference,
+				OrderDate:      order.OrderDate,
+				OrderStatus:    order.Status,
+				Currency:       order.Currency,
+				Subtotal:       order.Subtotal,
+				DiscountTotal:  order.DiscountTotal,
+				ShippingMethod: order.ShippingMethod,
+				ShippingFee:    order.ShippingFee,
+				TaxTotal:       order.TaxTotal,
+				TaxIncluded:    order.TaxIncluded,
+				Total:          order.Total,
+				Discounts:      newOrderDiscountDTOs(discounts),
+			},
+			PreviousTotal: previousTotal,
+			Changes:       newOrderChangeDTOs(changes),
+		}
+
+		logrus.Info("Order modified:", order.OrderReference, "total:", previousTotal, "->", order.Total)
+		return nil
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	os.stockNotificationService.dispatchLowStock(ctx, lowStockAlerts)
+
+	return response, nil
+}
+
+// modifiedLine is a line kept by a modification, with the request that validates it
+type modifiedLine struct {
+	item    entity.OrderItem
+	request model.OrderItemRequest
+	grown   bool
+}
+
+// modifyOrderLines applies the quantity changes to the lines of the order, a quantity of 0 removes the line
+func modifyOrderLines(orderItems []entity.OrderItem, itemRequests []model.ModifyOrderItemRequest) ([]modifiedLine, []entity.OrderItem, entity.OrderChanges, error) {
+
+	requested := make(map[string]model.ModifyOrderItemRequest, len(itemRequests))
+
+	for _, itemRequest := range itemRequests {
+
+		if _, exists := requested[itemRequest.OrderItemReference]; exists {
+			err := fmt.Errorf("order item %s is listed twice", itemRequest.OrderItemReference)
+			logrus.Error(err)
+			return nil, nil, nil, common.NewError(err, common.ErrValidation)
+		}
+
+		if itemRequest.Quantity < 0 {
+			err := fmt.Errorf("invalid quantity for order item %s", itemRequest.OrderItemReference)
+			logrus.Error(err)
+			return nil, nil, nil, common.NewError(err, common.ErrValidation)
+		}
+
+		if !slices.ContainsFunc(orderItems, func(orderItem entity.OrderItem) bool {
+			return orderItem.OrderItemReference == itemRequest.OrderItemReference
+		}) {
+			err := fmt.Errorf("order item %s is not part of the order", itemRequest.OrderItemReference)
+			logrus.Error(err)
+			return nil, nil, nil, common.NewError(err, common.ErrValidation)
+		}
+
+		requested[itemRequest.OrderItemReference] = itemRequest
+	}
+
+	var kept []modifiedLine
+	var removed []entity.OrderItem
+	var changes entity.OrderChanges
+
+	for _, orderItem := range orderItems {
+
+		line := modifiedLine{item: orderItem, request: newOrderItemRequest(orderItem)}
+
+		itemRequest, exists := requested[orderItem.OrderItemReference]
+
+		if !exists || itemRequest.Quantity == orderItem.Quantity {
+			kept = append(kept, line)
+			continue
+		}
+
+		change := entity.OrderChange{
+			Type:               constant.OrderChangeQuantityChanged,
+			OrderItemReference: orderItem.OrderItemReference,
+			ProductID:          orderItem.ProductID,
+			VariantID:          orderItem.VariantID,
+			PreviousQuantity:   orderItem.Quantity,
+			Quantity:           itemRequest.Quantity,
+		}
+
+		if itemRequest.Quantity == 0 {
+			change.Type = constant.OrderChangeItemRemoved
+			changes = append(changes, change)
+			removed = append(removed, orderItem)
+			continue
+		}
+
+		// A grown line must be priced by the customer at the current price
+		line.grown = itemRequest.Quantity > orderItem.Quantity
+
+		if line.grown {
+			line.request.PriceUsed = itemRequest.PriceUsed
+		}
+
+		line.item.Quantity = itemRequest.Quantity
+		line.request.Quantity = itemRequest.Quantity
+
+		kept = append(kept, line)
+		changes = append(changes, change)
+	}
+
+	return kept, removed, changes, nil
+}
+
+// modifiedLinePrice is the current price of an added or grown line, the price used must be that price
+func modifiedLinePrice(product entity.Product, variant *entity.ProductVariant, orderItemRequest model.OrderItemRequest, currency string, priceList map[priceListKey]int64, now time.Time) (int64, error) {
+
+	if !product.IsActive {
+		err := fmt.Errorf("product is not active: %v", product.ID)
+		logrus.Error(err)
+		return 0, common.NewError(err, common.ErrValidation)
+	}
+
+	price, err := unitPrice(product, variant, currency, priceList, now)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if orderItemRequest.PriceUsed != price.Amount {
+		err := fmt.Errorf("price changed for product: %v , used : %v , current : %v", product.ID, orderItemRequest.PriceUsed, price)
+		logrus.Error(err)
+		return 0, common.NewError(err, common.ErrValidation)
+	}
+
+	return price.Amount, nil
+}
+
+// newOrderItemRequest describes an order item as the line that ordered it
+func newOrderItemRequest(orderItem entity.OrderItem) model.OrderItemRequest {
+
+	orderItemRequest := model.OrderItemRequest{
+		ProductId: orderItem.ProductID,
+		PriceUsed: orderItem.PriceSnapshot,
+		Quantity:  orderItem.Quantity,
+	}
+
+	if orderItem.VariantID != nil {
+		orderItemRequest.VariantId = *orderItem.VariantID
+	}
+
+	return orderItemRequest
+}
+
+func newOrderChangeDTOs(changes []entity.OrderChange) []model.OrderChangeDTO {
+
+	changesDTO := make([]model.OrderChangeDTO, len(changes))
+
+	for i, change := range changes {
+		changesDTO[i] = model.OrderChangeDTO{
+			Type:               change.Type,
+			OrderItemReference: change.OrderItemReference,
+			ProductID:          change.ProductID,
+			VariantID:          change.VariantID,
+			PreviousQuantity:   change.PreviousQuantity,
+			Quantity:           change.Quantity,
+			PreviousDelivery:   change.PreviousDelivery,
+			Delivery:           change.Delivery,
+		}
+	}
+
+	return changesDTO
+}
+
+func newOrderHistoryDTOs(history []entity.OrderHistory) []model.OrderHistoryDTO {
+
+	historyDTO := make([]model.OrderHistoryDTO, len(history))
+
+	for i, entry := range history {
+		historyDTO[i] = model.OrderHistoryDTO{
+			Action:        entry.Action,
+			Changes:       newOrderChangeDTOs(entry.Changes),
+			PreviousTotal: entry.PreviousTotal,
+			Total:         entry.Total,
+			CreatedAt:     entry.CreatedAt,
+			CreatedBy:     entry.CreatedBy,
+		}
+	}
+
+	return historyDTO
+}
@@ -0,0 +1,276 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) modifyOrder(t *testing.T, request model.ModifyOrderRequest) model.ModifyOrderResponseData {
+
+	t.Helper()
+
+	request.Username = testUsername
+
+	response, err := f.orderService.ModifyOrder(context.Background(), request)
+	if err != nil {
+		t.Fatalf("modify order: %v", err)
+	}
+
+	return response.Data.(model.ModifyOrderResponseData)
+}
+
+// itemReferences are the order item references of the order by product
+func (f *fixture) itemReferences(t *testing.T, orderReference string) map[int64]string {
+
+	t.Helper()
+
+	references := map[int64]string{}
+
+	for _, item := range f.orderDetail(t, orderReference).OrderItems {
+		references[item.Product.ID] = item.OrderItemReference
+	}
+
+	return references
+}
+
+func TestModifyOrderAppliesStockDeltasAndRecordsHistory(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	order := f.submitOrder(t, submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 4},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+	))
+
+	references := f.itemReferences(t, order.OrderReference)
+
+	modified := f.modifyOrder(t, model.ModifyOrderRequest{
+		OrderReference: order.OrderReference,
+		Items: []model.ModifyOrderItemRequest{
+			{OrderItemReference: references[1], Quantity: 6, PriceUsed: 15000},
+			{OrderItemReference: references[2], Quantity: 0},
+		},
+		AddItems: []model.OrderItemRequest{{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 1}},
+	})
+
+	if modified.PreviousTotal != 4*15000+120000 || modified.Total != 6*15000+95000 || modified.OrderStatus != constant.OrderStatusPendingPayment {
+		t.Fatalf("expected the total to go from 180000 to 185000, got %+v", modified)
+	}
+
+	if len(modified.Changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", modified.Changes)
+	}
+
+	if f.stockOf(t, 1) != 4 || f.stockOf(t, 2) != 3 || f.stockOf(t, 4) != 6 || f.variantStockOf(t, 42) != 4 {
+		t.Fatalf("expected the stock deltas applied, got %d %d %d %d", f.stockOf(t, 1), f.stockOf(t, 2), f.stockOf(t, 4), f.variantStockOf(t, 42))
+	}
+
+	detail := f.orderDetail(t, order.OrderReference)
+
+	if detail.Total != modified.Total || detail.Payment.Total != modified.Total || len(detail.OrderItems) != 2 {
+		t.Fatalf("expected the order and its pending payment recomputed, got %+v", detail)
+	}
+
+	if len(detail.History) != 1 || detail.History[0].Action != constant.OrderActionModified || detail.History[0].CreatedBy != testUsername {
+		t.Fatalf("expected one history entry, got %+v", detail.History)
+	}
+
+	changes := detail.History[0].Changes
+
+	if changes[0].Type != constant.OrderChangeQuantityChanged || changes[0].PreviousQuantity != 4 || changes[0].Quantity != 6 {
+		t.Fatalf("unexpected quantity change %+v", changes[0])
+	}
+
+	if changes[1].Type != constant.OrderChangeItemRemoved || changes[1].ProductID != 2 || changes[2].Type != constant.OrderChangeItemAdded || *changes[2].VariantID != 42 {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+
+	// The pots taken and the throw returned are in the ledger
+	pots := f.movementsOf(t, 1)
+
+	if last := pots[0]; last.Type != constant.MovementTypeOrderReservation || last.Quantity != -2 || last.StockAfter != 4 {
+		t.Fatalf("expected 2 more pots reserved, got %+v", last)
+	}
+
+	if last := f.movementsOf(t, 2)[0]; last.Type != constant.MovementTypeModificationReturn || last.Quantity != 1 || last.Reference != order.OrderReference {
+		t.Fatalf("expected the throw returned, got %+v", last)
+	}
+
+	f.assertReconciled(t)
+
+	// The modified order is cancelled like any other
+	_, err := f.orderService.CancelOrder(context.Background(), model.CancelOrderRequest{OrderReference: order.OrderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	if f.stockOf(t, 1) != 10 || f.variantStockOf(t, 42) != 5 {
+		t.Fatalf("expected the modified quantities returned on cancel")
+	}
+
+	f.assertReconciled(t)
+}
+
+func TestModifyOrderChargesTheCurrentPriceOnlyForMore(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2}))
+	reference := f.itemReferences(t, order.OrderReference)[1]
+
+	// Since then the pot costs more
+	f.store.SeedProducts(entity.Product{ID: 1, CategoryID: 1, Name: "Clay Pot", Price: 17000, Stock: 8, IsActive: true})
+
+	// Fewer pots keep the price of the order
+	modified := f.modifyOrder(t, model.ModifyOrderRequest{
+		OrderReference: order.OrderReference,
+		Items:          []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: 1}},
+	})
+
+	if modified.Total != 15000 {
+		t.Fatalf("expected the snapshot price kept, got %d", modified.Total)
+	}
+
+	// More pots are charged the current price, the price used must be that price
+	_, err := f.orderService.ModifyOrder(ctx, model.ModifyOrderRequest{
+		OrderReference: order.OrderReference,
+		Username:       testUsername,
+		Items:          []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: 3, PriceUsed: 15000}},
+	})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	modified = f.modifyOrder(t, model.ModifyOrderRequest{
+		OrderReference: order.OrderReference,
+		Items:          []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: 3, PriceUsed: 17000}},
+	})
+
+	if modified.PreviousTotal != 15000 || modified.Total != 3*17000 {
+		t.Fatalf("expected 51000, got %+v", modified)
+	}
+
+	if history := f.orderDetail(t, order.OrderReference).History; len(history) != 2 {
+		t.Fatalf("expected one history entry per modification, got %+v", history)
+	}
+
+	f.assertReconciled(t)
+}
+
+func TestModifyOrderRecomputesCouponAndDelivery(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "BIGSPENDER", Type: constant.PromotionTypePercentage, Value: 5, MinOrderAmount: 100000})
+
+	order := f.submitOrder(t, couponRequest("BIGSPENDER", testUsername,
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+	))
+
+	if order.DiscountTotal != 7500 {
+		t.Fatalf("expected a discount of 7500, got %d", order.DiscountTotal)
+	}
+
+	references := f.itemReferences(t, order.OrderReference)
+
+	// Without the throw the order misses the minimum of its coupon
+	_, err := f.orderService.ModifyOrder(ctx, model.ModifyOrderRequest{
+		OrderReference: order.OrderReference,
+		Username:       testUsername,
+		Items:          []model.ModifyOrderItemRequest{{OrderItemReference: references[2], Quantity: 0}},
+	})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	if f.stockOf(t, 2) != 2 {
+		t.Fatalf("a refused modification should not return stock")
+	}
+
+	address := f.createAddress(t, addressRequest("John Doe", false))
+
+	modified := f.modifyOrder(t, model.ModifyOrderRequest{
+		OrderReference: order.OrderReference,
+		Items:          []model.ModifyOrderItemRequest{{OrderItemReference: references[1], Quantity: 4, PriceUsed: 15000}},
+		AddressID:      address.ID,
+	})
+
+	if modified.Subtotal != 180000 || modified.DiscountTotal != 9000 || modified.Total != 171000 {
+		t.Fatalf("expected the coupon computed on 180000, got %+v", modified)
+	}
+
+	if len(modified.Discounts) != 1 || modified.Discounts[0].Amount != 9000 {
+		t.Fatalf("expected the discount updated, got %+v", modified.Discounts)
+	}
+
+	detail := f.orderDetail(t, order.OrderReference)
+
+	if detail.Delivery == nil || detail.Delivery.RecipientName != "John Doe" || detail.TaxRegion != "ID-JK" {
+		t.Fatalf("expected the order delivered to the address, got %+v", detail)
+	}
+
+	if last := modified.Changes[len(modified.Changes)-1]; last.Type != constant.OrderChangeDeliveryChanged || last.PreviousDelivery != "Jl. Merdeka 1, Jakarta" || last.Delivery != detail.DeliveryAddress {
+		t.Fatalf("expected the delivery change recorded, got %+v", last)
+	}
+
+	// A modification does not redeem the coupon again
+	promotion, err := f.repos.Promotion.FindByCode(ctx, "BIGSPENDER")
+	if err != nil {
+		t.Fatalf("find promotion: %v", err)
+	}
+
+	if redemptions, _, err := f.repos.Discount.CountRedemptions(ctx, promotion.ID, testUsername); err != nil || redemptions != 1 {
+		t.Fatalf("expected 1 redemption, got %d %v", redemptions, err)
+	}
+}
+
+func TestModifyOrderRefusals(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.addAccount(t, "janedoe")
+
+	order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2}))
+	reference := f.itemReferences(t, order.OrderReference)[1]
+
+	paid, paidReferences := f.paidOrder(t, model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1})
+
+	tests := []struct {
+		name    string
+		request model.ModifyOrderRequest
+		kind    error
+	}{
+		{name: "nothing to modify", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername}, kind: common.ErrValidation},
+		{name: "same quantity", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername, Items: []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: 2}}}, kind: common.ErrValidation},
+		{name: "region alone", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername, Region: "ID-JB"}, kind: common.ErrValidation},
+		{name: "unknown item", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername, Items: []model.ModifyOrderItemRequest{{OrderItemReference: "OI-MISSING", Quantity: 1}}}, kind: common.ErrValidation},
+		{name: "negative quantity", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername, Items: []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: -1}}}, kind: common.ErrValidation},
+		{name: "every line removed", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername, Items: []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: 0}}}, kind: common.ErrValidation},
+		{name: "more than the stock", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername, Items: []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: 11, PriceUsed: 15000}}}, kind: common.ErrValidation},
+		{name: "inactive product added", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: testUsername, AddItems: []model.OrderItemRequest{{ProductId: 3, PriceUsed: 50000, Quantity: 1}}}, kind: common.ErrValidation},
+		{name: "order of another account", request: model.ModifyOrderRequest{OrderReference: order.OrderReference, Username: "janedoe", Items: []model.ModifyOrderItemRequest{{OrderItemReference: reference, Quantity: 1}}}, kind: common.ErrAccessDenied},
+		{name: "paid order", request: model.ModifyOrderRequest{OrderReference: paid, Username: testUsername, Items: []model.ModifyOrderItemRequest{{OrderItemReference: paidReferences[2], Quantity: 0}}}, kind: common.ErrConflict},
+		{name: "unknown order", request: model.ModifyOrderRequest{OrderReference: "ORDER-MISSING", Username: testUsername, DeliveryAddress: "Jl. Sudirman 2, Jakarta"}, kind: common.ErrResourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.orderService.ModifyOrder(ctx, tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	if f.stockOf(t, 1) != 8 || f.stockOf(t, 3) != 5 {
+		t.Fatalf("refused modifications should not move stock")
+	}
+
+	if detail := f.orderDetail(t, order.OrderReference); detail.Total != 30000 || len(detail.History) != 0 {
+		t.Fatalf("expected the order unchanged, got %+v", detail)
+	}
+}
@@ -4,35 +4,45 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
 	"github.com/jhasudungan/terraloom-core-api/internal/constant"
 	"github.com/jhasudungan/terraloom-core-api/internal/entity"
 	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
 	"github.com/jhasudungan/terraloom-core-api/internal/repository"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
 type OrderService struct {
-	orderRepository     *repository.OrderRepository
-	productRepository   *repository.ProductRepository
-	orderItemRepository *repository.OrderItemRepository
-	paymentRepository   *repository.PaymentRepository
-	accountRepository   *repository.AccountRepository
+	txRunner            repository.TransactionRunner
+	orderRepository     repository.OrderRepository
+	productRepository   repository.ProductRepository
+	orderItemRepository repository.OrderItemRepository
+	paymentRepository   repository.PaymentRepository
+	accountRepository   repository.AccountRepository
 	idGenerator         *common.IdGenerator
+	cursorService       *CursorService
+
+	stockNotificationService *StockNotificationService
 }
 
-func NewOrderService(orderRepository *repository.OrderRepository, productRepository *repository.ProductRepository, orderItemRepository *repository.OrderItemRepository, paymentRepository *repository.PaymentRepository, accountRepository *repository.AccountRepository, idGenerator *common.IdGenerator) *OrderService {
+func NewOrderService(txRunner repository.TransactionRunner, orderRepository repository.OrderRepository, productRepository repository.ProductRepository, orderItemRepository repository.OrderItemRepository, paymentRepository repository.PaymentRepository, accountRepository repository.AccountRepository, idGenerator *common.IdGenerator, cursorService *CursorService, stockNotificationService *StockNotificationService) *OrderService {
 	return &OrderService{
+		txRunner:            txRunner,
 		productRepository:   productRepository,
 		orderRepository:     orderRepository,
 		orderItemRepository: orderItemRepository,
 		paymentRepository:   paymentRepository,
 		accountRepository:   accountRepository,
 		idGenerator:         idGenerator,
+		cursorService:       cursorService,
+
+		stockNotificationService: stockNotificationService,
 	}
 }
 
@@ -47,15 +57,18 @@ func (os *OrderService) SubmitOrder(ctx context.Context, submitOrderRequest mode
 		return response, err
 	}
 
-	// Use GORM transaction with callback for automatic rollback
-	err = os.orderRepository.GetDB().Transaction(func(tx *gorm.DB) error {
+	// Alerts are only sent once the order is committed
+	var lowStockAlerts []notification.LowStockAlert
+
+	// Run in a transaction with callback for automatic rollback
+	err = os.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
 
-		// Create transaction-scoped repositories
-		orderRepo := repository.NewOrderRepository(tx)
-		productRepo := repository.NewProductRepository(tx)
-		orderItemRepo := repository.NewOrderItemRepository(tx)
-		paymentRepo := repository.NewPaymentRepository(tx)
-		accountRepo := repository.NewAccountRepository(tx)
+		// Transaction-scoped repositories
+		orderRepo := repos.Order
+		productRepo := repos.Product
+		orderItemRepo := repos.OrderItem
+		paymentRepo := repos.Payment
+		accountRepo := repos.Account
 
 		// Generate order reference
 		newOrderReference, err := os.idGenerator.GenerateCommonID("ORDER")
@@ -63,51 +76,116 @@ func (os *OrderService) SubmitOrder(ctx context.Context, submitOrderRequest mode
 			return err
 		}
 
-		// check for account
-		account, err := accountRepo.FindByUsername(ctx, submitOrderRequest.AccountUsername)
+		// A guest order has no account, the rows it writes are created by GUEST
+		account := entity.Account{Username: constant.GUEST}
+		guest := submitOrderRequest.Guest
 
-		if err != nil {
-			return err
-		}
+		if guest == nil {
 
-		if !account.IsActive {
-			err := errors.New("account inactive")
-			logrus.Error(err)
-			return common.NewError(err, common.ErrAccessDenied)
+			account, err = accountRepo.FindByUsername(ctx, submitOrderRequest.AccountUsername)
+
+			if err != nil {
+				return err
+			}
+
+			if !account.IsActive {
+				err := errors.New("account inactive")
+				logrus.Error(err)
+				return common.NewError(err, common.ErrAccessDenied)
+			}
 		}
 
 		newOrder := entity.Order{
 			OrderReference:  newOrderReference,
 			Status:          constant.OrderStatusPendingPayment,
+			Currency:        common.NormalizeCurrency(submitOrderRequest.Currency),
 			DeliveryAddress: submitOrderRequest.DeliveryAddress,
 			OrderDate:       time.Now(),
 			CreatedAt:       time.Now(),
 			CreatedBy:       account.Username, // TODO: Get from context/JWT
 			UpdatedAt:       time.Now(),
 			UpdatedBy:       account.Username,
-			AccountUsername: account.Username,
 		}
 
-		// Pre-validate all products exist and are available
-		err = os.validateRequestProducts(ctx, productRepo, submitOrderRequest.OrderItems)
+		// Coupons limited per account count the redemptions of the account, a guest has none
+		customer := ""
+
+		if guest == nil {
+			newOrder.AccountUsername = &account.Username
+			customer = account.Username
+		} else {
+			newOrder.GuestEmail = guest.Email
+			newOrder.GuestTokenHash = guest.TokenHash
+			newOrder.Delivery = newPostalAddress(guest.Address)
+			newOrder.DeliveryAddress = newOrder.Delivery.String()
+		}
+
+		// An address book entry is copied into the order, later edits of the address leave the order as it is
+		orderRegion := normalizeTaxRegion(submitOrderRequest.Region)
+
+		if guest != nil {
+			orderRegion = newOrder.Delivery.Region
+		}
+
+		if submitOrderRequest.AddressID != 0 {
+
+			err = deliverToAddress(ctx, repos, &newOrder, submitOrderRequest.AddressID, orderRegion, account.Username)
+
+			if err != nil {
+				return err
+			}
+
+			orderRegion = newOrder.TaxRegion
+		}
+
+		// Lock every product then every variant of the order in ascending id order,
+		// concurrent orders take the locks in the same order and never deadlock
+		usedProducts, err := os.lockRequestProducts(ctx, productRepo, submitOrderRequest.OrderItems)
 
 		if err != nil {
 			return err
 		}
 
-		grandTotalOrder := int64(0)
+		// Prices are taken at submission, the scheduled changes due by now are written first
+		now := time.Now()
+
+		_, err = applyDuePriceChanges(ctx, repos, usedProducts, now)
+
+		if err != nil {
+			return err
+		}
+
+		priceList, err := findPriceList(ctx, repos.PriceList, slices.Collect(maps.Keys(usedProducts)), newOrder.Currency)
+
+		if err != nil {
+			return err
+		}
+
+		usedVariants, err := os.lockRequestVariants(ctx, repos.Variant, submitOrderRequest.OrderItems)
+
+		if err != nil {
+			return err
+		}
+
+		stocksBefore := make(map[int64]int64, len(usedProducts))
+
+		for _, product := range usedProducts {
+			stocksBefore[product.ID] = product.Stock
+		}
+
+		// Every amount of the order is in its currency, an amount in another currency never adds up
+		grandTotal := common.NewMoney(0, newOrder.Currency)
 		var orderItems []entity.OrderItem
-		var usedProducts []entity.Product
+		var movements []entity.InventoryMovement
+
+		// Quantities to reserve, lines of the same product or variant add up
+		productQuantities := make(map[int64]int64)
+		variantQuantities := make(map[int64]int64)
 
 		// Process each order item
 		for _, orderItemRequest := range submitOrderRequest.OrderItems {
 
-			// Find and lock product to prevent race conditions
-			product, err := productRepo.FindByID(ctx, orderItemRequest.ProductId)
-
-			if err != nil {
-				return err
-			}
+			product := usedProducts[orderItemRequest.ProductId]
 
 			// Validate product availability
 			if !product.IsActive {
@@ -123,50 +201,129 @@ func (os *OrderService) SubmitOrder(ctx context.Context, submitOrderRequest mode
 				return common.NewError(err, common.ErrValidation)
 			}
 
-			// lock the stock
+			// Track the remaining stock for the following lines and the ledger, the rows are locked
 			product.Stock = product.Stock - orderItemRequest.Quantity
-			product.UpdatedAt = time.Now()
-			product.UpdatedBy = constant.SYSTEM
+			usedProducts[product.ID] = product
+			productQuantities[product.ID] += orderItemRequest.Quantity
+
+			variant, err := os.reserveVariant(ctx, repos.Variant, usedVariants, product, orderItemRequest)
 
-			usedProducts = append(usedProducts, product)
+			if err != nil {
+				return err
+			}
+
+			if variant != nil {
+				variantQuantities[variant.ID] += orderItemRequest.Quantity
+			}
+
+			price, err := unitPrice(product, variant, newOrder.Currency, priceList, now)
+
+			if err != nil {
+				return err
+			}
+
+			// The effective price is charged, a line priced otherwise was shown a price that no longer holds
+			if orderItemRequest.PriceUsed != price.Amount {
+				err := fmt.Errorf("price changed for product: %v , used : %v , current : %v", product.ID, orderItemRequest.PriceUsed, price)
+				logrus.Error(err)
+				return common.NewError(err, common.ErrValidation)
+			}
 
 			// Create order item
-			orderItem, err := os.createOrderItem(orderItemRequest, newOrder, account)
+			orderItem, err := os.createOrderItem(orderItemRequest, price.Amount, newOrder, account, variant)
 
 			if err != nil {
 				return err
 			}
 
 			orderItems = append(orderItems, orderItem)
+			movements = append(movements, newStockMovement(constant.MovementTypeOrderReservation, -orderItem.Quantity, product, variant, newOrderReference, account.Username))
 
 			// Calculate grand total
-			newGrandTotal := grandTotalOrder + orderItem.Total
+			grandTotal, err = grandTotal.Add(common.NewMoney(orderItem.Total, orderItem.Currency))
 
-			if newGrandTotal < grandTotalOrder {
-				err := fmt.Errorf("grand total overflow")
+			if err != nil {
 				logrus.Error(err)
-				return common.NewError(err, common.ErrConflict)
+				return err
 			}
 
-			grandTotalOrder = newGrandTotal
-
 			// Business rule: Maximum order total
-			if grandTotalOrder > 10000000000 {
-				err := fmt.Errorf("order total exceeds maximum limit: %v", grandTotalOrder)
+			if grandTotal.Amount > 10000000000 {
+				err := fmt.Errorf("order total exceeds maximum limit: %v", grandTotal)
 				logrus.Error(err)
 				return common.NewError(err, common.ErrValidation)
 			}
 
 		}
 
-		// Set order total and create order
-		newOrder.Total = grandTotalOrder
+		grandTotalOrder := grandTotal.Amount
+
+		// The parcel is priced before the coupon, a FREE_SHIPPING coupon discounts its fee
+		newOrder.TaxRegion = orderRegion
+		newOrder.ShippingWeight = parcelWeight(orderItems, usedProducts)
+
+		shipping, err := selectShippingMethod(ctx, repos, submitOrderRequest.ShippingMethod, newOrder.TaxRegion, newOrder.ShippingWeight, grandTotal)
+
+		if err != nil {
+			return err
+		}
+
+		if shipping != nil {
+			newOrder.ShippingMethodID = &shipping.Method.ID
+			newOrder.ShippingMethod = shipping.Method.Code
+			newOrder.ShippingFee = shipping.Fee
+		}
+
+		// Redeem the coupon, the promotion stays locked until the order is committed
+		var discounts []entity.OrderDiscount
+
+		if submitOrderRequest.CouponCode != "" {
+
+			discount, shares, err := redeemCoupon(ctx, repos, submitOrderRequest.CouponCode, customer, newPromotionLines(orderItems, usedProducts), grandTotal, newOrder.ShippingFee, now)
+
+			if err != nil {
+				return err
+			}
+
+			for i := range orderItems {
+				orderItems[i].DiscountAmount += shares[i]
+			}
+
+			discount.OrderReference = newOrderReference
+			discounts = append(discounts, discount)
+			newOrder.DiscountTotal += discount.Amount
+		}
+
+		// Taxes apply to the items net of their discount, shipping is not taxed
+		itemTaxes, err := computeOrderTaxes(ctx, repos, newOrder.TaxRegion, orderItems, usedProducts, now)
+
+		if err != nil {
+			return err
+		}
+
+		for _, tax := range itemTaxes {
+
+			newOrder.TaxTotal += tax.Amount
+
+			if tax.Mode == constant.TaxModeInclusive {
+				newOrder.TaxIncluded += tax.Amount
+			}
+		}
+
+		// Set order total and create order, inclusive taxes are already in the subtotal
+		newOrder.Subtotal = grandTotalOrder
+		newOrder.Total = grandTotalOrder + newOrder.ShippingFee - newOrder.DiscountTotal + newOrder.TaxTotal - newOrder.TaxIncluded
 
 		err = orderRepo.Create(ctx, newOrder)
 		if err != nil {
 			return err
 		}
 
+		err = repos.Discount.CreateBatch(ctx, discounts)
+		if err != nil {
+			return err
+		}
+
 		for i, oi := range orderItems {
 			logrus.Infof("orderItem[%d] ref=%s product=%d qty=%d", i, oi.OrderItemReference, oi.ProductID, oi.Quantity)
 		}
@@ -177,12 +334,43 @@ func (os *OrderService) SubmitOrder(ctx context.Context, submitOrderRequest mode
 			return err
 		}
 
-		err = productRepo.BatchUpsert(ctx, usedProducts)
+		err = repos.ItemTax.CreateBatch(ctx, itemTaxes)
 
 		if err != nil {
 			return err
 		}
 
+		// Conditional decrements, the database refuses to take more than the remaining stock
+		for _, productID := range slices.Sorted(maps.Keys(productQuantities)) {
+
+			_, err = productRepo.DecrementStock(ctx, productID, productQuantities[productID], constant.SYSTEM)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, variantID := range slices.Sorted(maps.Keys(variantQuantities)) {
+
+			_, err = repos.Variant.DecrementStock(ctx, variantID, variantQuantities[variantID], constant.SYSTEM)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		err = repos.Movement.CreateBatch(ctx, movements)
+
+		if err != nil {
+			return err
+		}
+
+		for _, productID := range slices.Sorted(maps.Keys(productQuantities)) {
+			if alert, crossed := lowStockAlert(usedProducts[productID], stocksBefore[productID], newOrderReference); crossed {
+				lowStockAlerts = append(lowStockAlerts, alert)
+			}
+		}
+
 		// Create payment with status pending
 		newPayment, err := os.createPayment(newOrder, account)
 
@@ -199,7 +387,15 @@ func (os *OrderService) SubmitOrder(ctx context.Context, submitOrderRequest mode
 		// Prepare response data
 		responseData := model.SubmitOrderResponseData{
 			OrderReference: newOrderReference,
-			Total:          grandTotalOrder,
+			Currency:       newOrder.Currency,
+			Subtotal:       newOrder.Subtotal,
+			DiscountTotal:  newOrder.DiscountTotal,
+			TaxTotal:       newOrder.TaxTotal,
+			TaxIncluded:    newOrder.TaxIncluded,
+			ShippingMethod: newOrder.ShippingMethod,
+			ShippingFee:    newOrder.ShippingFee,
+			Total:          newOrder.Total,
+			Discounts:      newOrderDiscountDTOs(discounts),
 			OrderDate:      newOrder.OrderDate,
 			OrderStatus:    newOrder.Status,
 		}
@@ -208,7 +404,7 @@ func (os *OrderService) SubmitOrder(ctx context.Context, submitOrderRequest mode
 		response.ResponseMessage = constant.SuccessMessage
 		response.Data = responseData
 
-		logrus.Info("Order created successfully:", newOrderReference, "total:", grandTotalOrder)
+		logrus.Info("Order created successfully:", newOrderReference, "total:", newOrder.Total)
 		return nil
 	})
 
@@ -217,6 +413,8 @@ func (os *OrderService) SubmitOrder(ctx context.Context, submitOrderRequest mode
 		return response, err
 	}
 
+	os.stockNotificationService.dispatchLowStock(ctx, lowStockAlerts)
+
 	return response, nil
 }
 
@@ -260,6 +458,12 @@ func (os *OrderService) validateOrderRequest(request model.SubmitOrderRequest) e
 			return common.NewError(err, common.ErrValidation)
 		}
 
+		if item.VariantId < 0 {
+			err := errors.New("invalid variant ID at index " + string(rune(i)))
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
 		if item.Quantity <= 0 {
 			err := errors.New("invalid quantity at index " + string(rune(i)))
 			logrus.Error(err)
@@ -288,53 +492,259 @@ func (os *OrderService) validateOrderRequest(request model.SubmitOrderRequest) e
 		return common.NewError(err, common.ErrValidation)
 	}
 
+	if currency := common.NormalizeCurrency(request.Currency); !common.IsSupportedCurrency(currency) {
+		err := fmt.Errorf("currency is not supported: %q", request.Currency)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if request.AddressID < 0 {
+		err := errors.New("invalid address ID")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if request.AddressID != 0 && strings.TrimSpace(request.DeliveryAddress) != "" {
+		err := errors.New("addressId and deliveryAddress can not be used together")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if region := normalizeTaxRegion(request.Region); region != "" && !taxRegionPattern.MatchString(region) {
+		err := errors.New("region must be 2 to 10 letters, digits or '-'")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
 	return nil
 }
 
+// lockRequestProducts locks the ordered products, every one of them must exist
+func (os *OrderService) lockRequestProducts(ctx context.Context, productRepo repository.ProductRepository, orderItems []model.OrderItemRequest) (map[int64]entity.Product, error) {
+
+	productIDs := make([]int64, 0, len(orderItems))
+
+	for _, item := range orderItems {
+		productIDs = append(productIDs, item.ProductId)
+	}
+
+	slices.Sort(productIDs)
+	productIDs = slices.Compact(productIDs)
+
+	products, err := productRepo.LockByIDs(ctx, productIDs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	productMap := make(map[int64]entity.Product, len(products))
+
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	for _, requiredID := range productIDs {
+		if _, found := productMap[requiredID]; !found {
+			err := fmt.Errorf("product not found: %d", requiredID)
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+	}
+
+	return productMap, nil
+}
+
+// lockRequestVariants locks the ordered variants, reserveVariant reports the missing ones
+func (os *OrderService) lockRequestVariants(ctx context.Context, variantRepo repository.ProductVariantRepository, orderItems []model.OrderItemRequest) (map[int64]entity.ProductVariant, error) {
+
+	variantIDs := make([]int64, 0, len(orderItems))
+
+	for _, item := range orderItems {
+		if item.VariantId != 0 {
+			variantIDs = append(variantIDs, item.VariantId)
+		}
+	}
+
+	variantMap := make(map[int64]entity.ProductVariant, len(variantIDs))
+
+	if len(variantIDs) == 0 {
+		return variantMap, nil
+	}
+
+	slices.Sort(variantIDs)
+
+	variants, err := variantRepo.LockByIDs(ctx, slices.Compact(variantIDs))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range variants {
+		variantMap[variant.ID] = variant
+	}
+
+	return variantMap, nil
+}
+
 /*
 *
 
-	validate all the items ready to procces
+	Products sold by variant (at least one variant defined) :
+	- The line must reference one of their active variants
+	- The variant stock is reserved along with the product stock, which is the sum of its variants
+	- The variant must be locked already (see lockRequestVariants)
 
 *
 */
-func (os *OrderService) validateRequestProducts(ctx context.Context, productRepo *repository.ProductRepository, orderItems []model.OrderItemRequest) error {
+func (os *OrderService) reserveVariant(
+	ctx context.Context,
+	variantRepo repository.ProductVariantRepository,
+	usedVariants map[int64]entity.ProductVariant,
+	product entity.Product,
+	orderItemRequest model.OrderItemRequest) (*entity.ProductVariant, error) {
 
-	// Collect product IDs
-	productIDs := make([]int64, 0, len(orderItems))
+	variant, err := os.orderVariant(ctx, variantRepo, usedVariants, product, orderItemRequest)
 
-	for _, item := range orderItems {
-		productIDs = append(productIDs, item.ProductId)
+	if err != nil || variant == nil {
+		return nil, err
 	}
 
-	// Batch fetch all products to verify they exist
-	products, err := productRepo.FindMultipleByIDs(ctx, productIDs)
-	if err != nil {
+	if variant.Stock < orderItemRequest.Quantity {
+		err := fmt.Errorf("insufficient stock for variant: %v , requested : %v , available: %v ", variant.ID, orderItemRequest.Quantity, variant.Stock)
 		logrus.Error(err)
-		return common.NewError(err, common.ErrResourceNotFound)
+		return nil, common.NewError(err, common.ErrValidation)
 	}
 
-	// Verify all products were found
-	if len(products) != len(productIDs) {
+	// Remaining stock for the following lines and the ledger, the stock is written by DecrementStock
+	variant.Stock = variant.Stock - orderItemRequest.Quantity
+
+	usedVariants[variant.ID] = *variant
+
+	return variant, nil
+}
 
-		foundIDs := make(map[int64]bool)
-		for _, product := range products {
-			foundIDs[product.ID] = true
+// orderVariant is the variant of the line, nil for a product sold without variant, its stock is not checked
+func (os *OrderService) orderVariant(
+	ctx context.Context,
+	variantRepo repository.ProductVariantRepository,
+	usedVariants map[int64]entity.ProductVariant,
+	product entity.Product,
+	orderItemRequest model.OrderItemRequest) (*entity.ProductVariant, error) {
+
+	if orderItemRequest.VariantId == 0 {
+
+		variants, err := variantRepo.FindByProductID(ctx, product.ID)
+
+		if err != nil {
+			return nil, err
 		}
 
-		for _, requiredID := range productIDs {
-			if !foundIDs[requiredID] {
-				err := fmt.Errorf("product not found: %d", requiredID)
-				logrus.Error(err)
-				return common.NewError(err, common.ErrValidation)
-			}
+		if len(variants) > 0 {
+			err := fmt.Errorf("product %v is sold by variant, variantId is required", product.ID)
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
 		}
+
+		return nil, nil
+	}
+
+	variant, exists := usedVariants[orderItemRequest.VariantId]
+
+	if !exists {
+		err := fmt.Errorf("variant not found: %v", orderItemRequest.VariantId)
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
 	}
 
+	if variant.ProductID != product.ID {
+		err := fmt.Errorf("variant %v does not belong to product %v", variant.ID, product.ID)
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
+	}
+
+	if !variant.IsActive {
+		err := fmt.Errorf("variant is not active: %v", variant.ID)
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
+	}
+
+	return &variant, nil
+}
+
+// deliverToAddress copies the address book entry into the order, a region given with it must be the region of the address
+func deliverToAddress(ctx context.Context, repos repository.Repositories, order *entity.Order, addressID int64, region string, username string) error {
+
+	address, err := findOwnAddress(ctx, repos, addressID, username)
+
+	if err != nil {
+		return err
+	}
+
+	if region != "" && region != address.Region {
+		err := fmt.Errorf("region %s differs from the region %s of the address", region, address.Region)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	order.AddressID = &address.ID
+	order.Delivery = address.PostalAddress
+	order.DeliveryAddress = address.String()
+	order.TaxRegion = address.Region
+
 	return nil
 }
 
-func (os *OrderService) createOrderItem(orderItemRequest model.OrderItemRequest, order entity.Order, account entity.Account) (entity.OrderItem, error) {
+// newPromotionLines describes the order items to the promotions
+func newPromotionLines(orderItems []entity.OrderItem, products map[int64]entity.Product) []promotionLine {
+
+	lines := make([]promotionLine, len(orderItems))
+
+	for i, orderItem := range orderItems {
+		lines[i] = promotionLine{
+			ProductID:  orderItem.ProductID,
+			CategoryID: products[orderItem.ProductID].CategoryID,
+			Price:      orderItem.PriceSnapshot,
+			Quantity:   orderItem.Quantity,
+		}
+	}
+
+	return lines
+}
+
+// lineKey identifies the stock of an order line, VariantID is 0 for a product sold without variant
+type lineKey struct {
+	ProductID int64
+	VariantID int64
+}
+
+// newStockMovement books quantity on the variant when there is one, on the product otherwise
+func newStockMovement(
+	movementType string,
+	quantity int64,
+	product entity.Product,
+	variant *entity.ProductVariant,
+	reference string,
+	username string) entity.InventoryMovement {
+
+	movement := entity.InventoryMovement{
+		ProductID:    product.ID,
+		MovementType: movementType,
+		Quantity:     quantity,
+		StockAfter:   product.Stock,
+		Reference:    reference,
+		CreatedAt:    time.Now(),
+		CreatedBy:    username,
+	}
+
+	if variant != nil {
+		movement.VariantID = &variant.ID
+		movement.StockAfter = variant.Stock
+	}
+
+	return movement
+}
+
+func (os *OrderService) createOrderItem(orderItemRequest model.OrderItemRequest, price int64, order entity.Order, account entity.Account, variant *entity.ProductVariant) (entity.OrderItem, error) {
 
 	newOrderItemReference, err := os.idGenerator.GenerateCommonID("OI")
 
@@ -342,21 +752,22 @@ func (os *OrderService) createOrderItem(orderItemRequest model.OrderItemRequest,
 		return entity.OrderItem{}, err
 	}
 
-	total := orderItemRequest.PriceUsed * orderItemRequest.Quantity
+	total := price * orderItemRequest.Quantity
 
 	// Prevent integer overflow
-	if total < 0 || total < orderItemRequest.PriceUsed || total < orderItemRequest.Quantity {
+	if total < 0 || total < price || total < orderItemRequest.Quantity {
 		err := fmt.Errorf("price calculation overflow for product: %v", orderItemRequest.ProductId)
 		logrus.Error(err)
 		return entity.OrderItem{}, common.NewError(err, common.ErrValidation)
 	}
 
-	return entity.OrderItem{
+	orderItem := entity.OrderItem{
 		OrderItemReference:      newOrderItemReference,
 		OrderReference:          order.OrderReference,
 		ProductID:               orderItemRequest.ProductId,
 		Quantity:                orderItemRequest.Quantity,
-		PriceSnapshot:           orderItemRequest.PriceUsed,
+		PriceSnapshot:           price,
+		Currency:                order.Currency,
 		ProductNameSnapshot:     orderItemRequest.ProductName,
 		ProductImageUrlSnapshot: orderItemRequest.ProductImageUrl,
 		Total:                   total,
@@ -364,7 +775,24 @@ func (os *OrderService) createOrderItem(orderItemRequest model.OrderItemRequest,
 		CreatedBy:               account.Username,
 		UpdatedAt:               time.Now(),
 		UpdatedBy:               account.Username,
-	}, nil
+	}
+
+	// Snapshot the chosen options, they outlive later changes of the variant
+	if variant != nil {
+
+		orderItem.VariantID = &variant.ID
+		orderItem.SKUSnapshot = variant.SKU
+		orderItem.VariantOptionsSnapshot = entity.VariantOptionsSnapshot{}
+
+		for _, option := range variant.Options {
+			orderItem.VariantOptionsSnapshot = append(orderItem.VariantOptionsSnapshot, entity.VariantOptionSnapshot{
+				Name:  option.OptionType.Name,
+				Value: option.Value,
+			})
+		}
+	}
+
+	return orderItem, nil
 }
 
 func (os *OrderService) createPayment(order entity.Order, account entity.Account) (entity.Payment, error) {
@@ -380,6 +808,7 @@ func (os *OrderService) createPayment(order entity.Order, account entity.Account
 		OrderReference:   order.OrderReference,
 		Status:           constant.PaymentStatusPending,
 		Total:            order.Total,
+		Currency:         order.Currency,
 		CreatedAt:        time.Now(),
 		CreatedBy:        account.Username,
 		UpdatedAt:        time.Now(),
@@ -391,36 +820,56 @@ func (os *OrderService) CancelOrder(ctx context.Context, request model.CancelOrd
 
 	response := model.GeneralResponse{}
 
-	order, err := os.orderRepository.FindByIDWithItems(ctx, request.OrderReference)
+	account, err := os.accountRepository.FindByUsername(ctx, request.AccountUsername)
 
 	if err != nil {
 		return response, err
 	}
 
-	account, err := os.accountRepository.FindByUsername(ctx, request.AccountUsername)
+	var order entity.Order
+	var payment entity.Payment
 
-	if err != nil {
-		return response, err
-	}
+	err = os.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
 
-	// Order Processed can't be undone
-	if order.Status == constant.OrderStatusProcessed {
-		err := errors.New("order status already final")
-		logrus.Error(err)
-		return response, common.NewError(err, common.ErrConflict)
-	}
+		orderRepo := repos.Order
+		paymentRepo := repos.Payment
+		productRepo := repos.Product
 
-	payment, err := os.paymentRepository.FindByOrderReference(ctx, request.OrderReference)
+		// The order stays locked until the cancel is committed, a payment or a modification waits for it
+		order, err = orderRepo.FindByIDWithItems(ctx, request.OrderReference)
 
-	if err != nil {
-		return response, err
-	}
+		if err != nil {
+			return err
+		}
+
+		if !order.IsOwnedBy(account.Username) {
+			err := fmt.Errorf("order %s does not belong to %s", order.OrderReference, account.Username)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrAccessDenied)
+		}
 
-	err = os.orderRepository.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Order Processed, Shipped or Finished can't be undone
+		if order.Status == constant.OrderStatusProcessed || order.Status == constant.OrderStatusShipped || order.Status == constant.OrderStatusFinished {
+			err := errors.New("order status already final")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
+
+		// A cancelled order already gave its stock back
+		if order.Status == constant.OrderStatusCancelled {
+			err := fmt.Errorf("order %s is already cancelled", order.OrderReference)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
 
-		orderRepo := repository.NewOrderRepository(tx)
-		paymentRepo := repository.NewPaymentRepository(tx)
-		productRepo := repository.NewProductRepository(tx)
+		payment, err = paymentRepo.FindByOrderReference(ctx, request.OrderReference)
+
+		if err != nil {
+			return err
+		}
+
+		// Stock of a paid order comes back as a refund
+		movementType := constant.MovementTypeCancellationReturn
 
 		if order.Status == constant.OrderStatusPendingPayment {
 			payment.Status = constant.PaymentStatusCancelled
@@ -428,14 +877,23 @@ func (os *OrderService) CancelOrder(ctx context.Context, request model.CancelOrd
 
 		if order.Status == constant.OrderStatusPaymentReceived {
 			payment.Status = constant.PaymentStatusRefunded
+			movementType = constant.MovementTypeRefund
 		}
 
+		orderItems := order.OrderItems
+
 		order.Status = constant.OrderStatusCancelled
 		order.UpdatedBy = account.Username
 		order.UpdatedAt = time.Now()
 		payment.UpdatedBy = account.Username
 		payment.UpdatedAt = time.Now()
 
+		// The order is saved without its relations, Save would write them back
+		order.OrderItems = nil
+		order.Discounts = nil
+		order.Shipments = nil
+		order.History = nil
+
 		err = orderRepo.Update(ctx, order)
 
 		if err != nil {
@@ -448,53 +906,60 @@ func (os *OrderService) CancelOrder(ctx context.Context, request model.CancelOrd
 			return err
 		}
 
-		// Return product stock
-		productIDs := make([]int64, 0, len(order.OrderItems))
+		// Return product and variant stock, lines of the same product or variant add up
+		productQuantities := make(map[int64]int64)
+		variantQuantities := make(map[int64]int64)
 
-		for _, item := range order.OrderItems {
-			productIDs = append(productIDs, item.ProductID)
-		}
+		for _, item := range orderItems {
 
-		// find the returned
-		usedProducts, err := productRepo.FindMultipleByIDs(ctx, productIDs)
+			productQuantities[item.ProductID] += item.Quantity
 
-		if err != nil {
-			return err
+			if item.VariantID != nil {
+				variantQuantities[*item.VariantID] += item.Quantity
+			}
 		}
 
-		// Create a map for O(1) lookup
-		productMap := make(map[int64]entity.Product)
+		// Atomic increments in ascending id order, products before variants like SubmitOrder
+		productStocks := make(map[int64]int64)
+		variantStocks := make(map[int64]int64)
 
-		for i := range usedProducts {
-			productMap[usedProducts[i].ID] = usedProducts[i]
-		}
+		for _, productID := range slices.Sorted(maps.Keys(productQuantities)) {
 
-		// Update stock quantities
-		productsToUpdate := make([]entity.Product, 0, len(usedProducts))
+			productStocks[productID], err = productRepo.IncrementStock(ctx, productID, productQuantities[productID], constant.SYSTEM)
 
-		for _, orderItem := range order.OrderItems {
-			if product, exists := productMap[orderItem.ProductID]; exists {
-				product.Stock += orderItem.Quantity
-				product.UpdatedAt = time.Now()
-				product.UpdatedBy = constant.SYSTEM
-				productsToUpdate = append(productsToUpdate, product)
+			if err != nil {
+				return err
 			}
 		}
 
-		// Batch update all products
+		for _, variantID := range slices.Sorted(maps.Keys(variantQuantities)) {
 
-		for _, productToUpdate := range productsToUpdate {
-			logrus.Info(productToUpdate)
-		}
+			variantStocks[variantID], err = repos.Variant.IncrementStock(ctx, variantID, variantQuantities[variantID], constant.SYSTEM)
 
-		if len(productsToUpdate) > 0 {
-			err = productRepo.BatchUpsert(ctx, productsToUpdate)
 			if err != nil {
 				return err
 			}
 		}
 
-		return nil
+		// One movement per line, the stock after each line is rebuilt from the final stock
+		movements := make([]entity.InventoryMovement, 0, len(orderItems))
+
+		for _, orderItem := range orderItems {
+
+			productQuantities[orderItem.ProductID] -= orderItem.Quantity
+			product := entity.Product{ID: orderItem.ProductID, Stock: productStocks[orderItem.ProductID] - productQuantities[orderItem.ProductID]}
+
+			var variant *entity.ProductVariant
+
+			if orderItem.VariantID != nil {
+				variantQuantities[*orderItem.VariantID] -= orderItem.Quantity
+				variant = &entity.ProductVariant{ID: *orderItem.VariantID, Stock: variantStocks[*orderItem.VariantID] - variantQuantities[*orderItem.VariantID]}
+			}
+
+			movements = append(movements, newStockMovement(movementType, orderItem.Quantity, product, variant, order.OrderReference, account.Username))
+		}
+
+		return repos.Movement.CreateBatch(ctx, movements)
 	})
 
 	if err != nil {
@@ -539,10 +1004,16 @@ func (os *OrderService) GetAccountOrders(ctx context.Context, request model.GetA
 		OrderReference: request.OrderReference,
 	}
 
-	paginationParams := model.PaginationParams{
-		IsPaginate: request.IsPaginate,
-		Page:       request.Page,
-		PerPage:    request.PerPage,
+	paginationParams, err := os.cursorService.buildPaginationParams(
+		request.IsPaginate,
+		request.Page,
+		request.PerPage,
+		request.Mode,
+		request.Cursor,
+		request.SkipCount)
+
+	if err != nil {
+		return response, err
 	}
 
 	orders, totalData, err := os.orderRepository.FindWithAccountAndFilters(ctx, request.AccountUserame, filter, paginationParams)
@@ -551,6 +1022,12 @@ func (os *OrderService) GetAccountOrders(ctx context.Context, request model.GetA
 		return response, err
 	}
 
+	orders, metadata, err := buildPage(os.cursorService, orders, totalData, paginationParams, repository.OrderCursor)
+
+	if err != nil {
+		return response, err
+	}
+
 	ordersDTO := make([]model.OrderDTO, len(orders))
 
 	for i, order := range orders {
@@ -558,21 +1035,10 @@ func (os *OrderService) GetAccountOrders(ctx context.Context, request model.GetA
 			OrderReference: order.OrderReference,
 			Status:         order.Status,
 			OrderDate:      order.OrderDate,
+			Currency:       order.Currency,
 			Total:          order.Total}
 	}
 
-	metadata := model.MetadataDTO{}
-	metadata.Page = request.Page
-	metadata.TotalData = totalData
-
-	totalPage := 1
-	if request.IsPaginate && request.PerPage > 0 {
-		totalPage = int(math.Ceil(float64(totalData) / float64(request.PerPage)))
-	}
-
-	metadata.TotalPage = totalPage
-	metadata.PerPage = request.PerPage
-
 	responseData := model.GetAccountOrdersResponseData{
 		Orders:   ordersDTO,
 		Metadata: metadata,
@@ -588,15 +1054,27 @@ func (os *OrderService) GetAccountOrders(ctx context.Context, request model.GetA
 
 func (os *OrderService) GetOrderDetail(ctx context.Context, request model.GetOrderDetailRequest) (model.GeneralResponse, error) {
 
-	response := model.GeneralResponse{}
-
 	order, err := os.orderRepository.FindByIDWithItems(ctx, request.OrderReference)
 
 	if err != nil {
-		return response, err
+		return model.GeneralResponse{}, err
 	}
 
-	payment, err := os.paymentRepository.FindByOrderReference(ctx, request.OrderReference)
+	if !order.IsOwnedBy(request.AccountUsername) {
+		err := fmt.Errorf("order %s does not belong to %s", order.OrderReference, request.AccountUsername)
+		logrus.Error(err)
+		return model.GeneralResponse{}, common.NewError(err, common.ErrAccessDenied)
+	}
+
+	return os.orderDetail(ctx, order)
+}
+
+// orderDetail is the detail of an order whose access was checked, by its account or by its guest access token
+func (os *OrderService) orderDetail(ctx context.Context, order entity.Order) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	payment, err := os.paymentRepository.FindByOrderReference(ctx, order.OrderReference)
 
 	if err != nil {
 		return response, err
@@ -604,6 +1082,8 @@ func (os *OrderService) GetOrderDetail(ctx context.Context, request model.GetOrd
 
 	var orderItemsDTO []model.OrderItemDTO
 
+	shipped := shippedQuantities(order.Shipments)
+
 	for _, orderItem := range order.OrderItems {
 
 		orderItemProduct := model.OrderItemProductDTO{
@@ -617,9 +1097,28 @@ func (os *OrderService) GetOrderDetail(ctx context.Context, request model.GetOrd
 			Quantity:           orderItem.Quantity,
 			Price:              orderItem.PriceSnapshot,
 			Total:              orderItem.Total,
+			DiscountAmount:     orderItem.DiscountAmount,
+			Taxes:              newOrderItemTaxDTOs(orderItem.Taxes),
+			ShippedQuantity:    shipped[orderItem.OrderItemReference],
 			Product:            orderItemProduct,
 		}
 
+		if orderItem.VariantID != nil {
+
+			orderItemDTO.Variant = &model.OrderItemVariantDTO{
+				ID:      *orderItem.VariantID,
+				SKU:     orderItem.SKUSnapshot,
+				Options: []model.VariantOptionDTO{},
+			}
+
+			for _, option := range orderItem.VariantOptionsSnapshot {
+				orderItemDTO.Variant.Options = append(orderItemDTO.Variant.Options, model.VariantOptionDTO{
+					Name:  option.Name,
+					Value: option.Value,
+				})
+			}
+		}
+
 		orderItemsDTO = append(orderItemsDTO, orderItemDTO)
 	}
 
@@ -627,6 +1126,7 @@ func (os *OrderService) GetOrderDetail(ctx context.Context, request model.GetOrd
 		PaymentReference: payment.PaymentReference,
 		Status:           payment.Status,
 		Total:            payment.Total,
+		Currency:         payment.Currency,
 		CardHolderName:   payment.CardHolderName,
 		CardNumber:       payment.CardNumber,
 	}
@@ -635,10 +1135,23 @@ func (os *OrderService) GetOrderDetail(ctx context.Context, request model.GetOrd
 		OrderReference:  order.OrderReference,
 		OrderDate:       order.OrderDate,
 		DeliveryAddress: order.DeliveryAddress,
+		Delivery:        newOrderDeliveryDTO(order),
 		Status:          order.Status,
+		Currency:        order.Currency,
+		Subtotal:        order.Subtotal,
+		DiscountTotal:   order.DiscountTotal,
+		TaxRegion:       order.TaxRegion,
+		TaxTotal:        order.TaxTotal,
+		TaxIncluded:     order.TaxIncluded,
+		ShippingMethod:  order.ShippingMethod,
+		ShippingWeight:  order.ShippingWeight,
+		ShippingFee:     order.ShippingFee,
 		Total:           order.Total,
 		Payment:         paymentDTO,
 		OrderItems:      orderItemsDTO,
+		Discounts:       newOrderDiscountDTOs(order.Discounts),
+		Shipments:       newShipmentDTOs(order.Shipments, order.OrderItems),
+		History:         newOrderHistoryDTOs(order.History),
 	}
 
 	responseData := model.GetOrderDetailReponseData{
@@ -651,3 +1164,15 @@ func (os *OrderService) GetOrderDetail(ctx context.Context, request model.GetOrd
 
 	return response, nil
 }
+
+// newOrderDeliveryDTO is nil for the orders placed to a free text address
+func newOrderDeliveryDTO(order entity.Order) *model.PostalAddressDTO {
+
+	if order.Delivery == (entity.PostalAddress{}) {
+		return nil
+	}
+
+	delivery := newPostalAddressDTO(order.Delivery)
+
+	return &delivery
+}
@@ -0,0 +1,405 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func submitRequest(items ...model.OrderItemRequest) model.SubmitOrderRequest {
+	return model.SubmitOrderRequest{
+		AccountUsername: testUsername,
+		DeliveryAddress: "Jl. Merdeka 1, Jakarta",
+		OrderItems:      items,
+	}
+}
+
+func (f *fixture) submitOrder(t *testing.T, request model.SubmitOrderRequest) model.SubmitOrderResponseData {
+
+	t.Helper()
+
+	response, err := f.orderService.SubmitOrder(context.Background(), request)
+	if err != nil {
+		t.Fatalf("submit order: %v", err)
+	}
+
+	return response.Data.(model.SubmitOrderResponseData)
+}
+
+func TestSubmitOrderReservesStockAndCreatesPendingPayment(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	data := f.submitOrder(t, submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 4},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 3},
+	))
+
+	if data.Total != 4*15000+3*120000 {
+		t.Fatalf("unexpected total %d", data.Total)
+	}
+
+	if data.OrderStatus != constant.OrderStatusPendingPayment {
+		t.Fatalf("unexpected status %s", data.OrderStatus)
+	}
+
+	if stock := f.stockOf(t, 1); stock != 6 {
+		t.Fatalf("expected product 1 stock 6, got %d", stock)
+	}
+
+	if stock := f.stockOf(t, 2); stock != 0 {
+		t.Fatalf("expected product 2 stock 0, got %d", stock)
+	}
+
+	order, err := f.repos.Order.FindByIDWithItems(ctx, data.OrderReference)
+	if err != nil {
+		t.Fatalf("find order: %v", err)
+	}
+
+	if len(order.OrderItems) != 2 {
+		t.Fatalf("expected 2 order items, got %d", len(order.OrderItems))
+	}
+
+	payment, err := f.repos.Payment.FindByOrderReference(ctx, data.OrderReference)
+	if err != nil {
+		t.Fatalf("find payment: %v", err)
+	}
+
+	if payment.Status != constant.PaymentStatusPending || payment.Total != data.Total {
+		t.Fatalf("unexpected payment %s / %d", payment.Status, payment.Total)
+	}
+}
+
+func TestSubmitOrderRejectsInsufficientStockWithoutSideEffects(t *testing.T) {
+
+	f := newFixture(t)
+
+	_, err := f.orderService.SubmitOrder(context.Background(), submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 4},
+	))
+
+	assertErrorKind(t, err, common.ErrValidation)
+
+	// The first line was processed before the failure and must be rolled back
+	if stock := f.stockOf(t, 1); stock != 10 {
+		t.Fatalf("expected product 1 stock untouched, got %d", stock)
+	}
+
+	orders, total, err := f.repos.Order.FindWithAccountAndFilters(context.Background(), testUsername, model.OrderFilter{}, model.PaginationParams{})
+	if err != nil {
+		t.Fatalf("list orders: %v", err)
+	}
+
+	if total != 0 || len(orders) != 0 {
+		t.Fatalf("expected no order to be created, got %d", total)
+	}
+}
+
+func TestConcurrentSubmitOrderNeverOversells(t *testing.T) {
+
+	f := newFixture(t)
+
+	const attempts = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.orderService.SubmitOrder(context.Background(), submitRequest(
+				model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+				model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1},
+			))
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assertErrorKind(t, err, common.ErrValidation)
+	}
+
+	if succeeded != 3 {
+		t.Fatalf("expected exactly 3 orders for 3 units, got %d", succeeded)
+	}
+
+	if stock := f.stockOf(t, 2); stock != 0 {
+		t.Fatalf("expected product 2 stock 0, got %d", stock)
+	}
+
+	if stock := f.stockOf(t, 1); stock != 7 {
+		t.Fatalf("expected product 1 stock 7, got %d", stock)
+	}
+
+	f.assertReconciled(t)
+}
+
+func TestSubmitOrderValidation(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		request model.SubmitOrderRequest
+		kind    error
+	}{
+		{
+			name:    "empty items",
+			request: submitRequest(),
+			kind:    common.ErrValidation,
+		},
+		{
+			name:    "zero quantity",
+			request: submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 0}),
+			kind:    common.ErrValidation,
+		},
+		{
+			name:    "quantity above line limit",
+			request: submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1001}),
+			kind:    common.ErrValidation,
+		},
+		{
+			name:    "unknown product",
+			request: submitRequest(model.OrderItemRequest{ProductId: 99, PriceUsed: 15000, Quantity: 1}),
+			kind:    common.ErrValidation,
+		},
+		{
+			name:    "inactive product",
+			request: submitRequest(model.OrderItemRequest{ProductId: 3, PriceUsed: 50000, Quantity: 1}),
+			kind:    common.ErrValidation,
+		},
+		{
+			name: "unknown account",
+			request: model.SubmitOrderRequest{
+				AccountUsername: "nobody",
+				OrderItems:      []model.OrderItemRequest{{ProductId: 1, PriceUsed: 15000, Quantity: 1}},
+			},
+			kind: common.ErrResourceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newFixture(t)
+
+			_, err := f.orderService.SubmitOrder(context.Background(), tt.request)
+
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestSubmitOrderRejectsInactiveAccount(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	account, _ := f.repos.Account.FindByUsername(ctx, testUsername)
+	account.IsActive = false
+
+	err := f.repos.Account.Update(ctx, account)
+	if err != nil {
+		t.Fatalf("deactivate account: %v", err)
+	}
+
+	_, err = f.orderService.SubmitOrder(ctx, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	assertErrorKind(t, err, common.ErrAccessDenied)
+}
+
+func TestCancelOrder(t *testing.T) {
+
+	tests := []struct {
+		name          string
+		payFirst      bool
+		paymentStatus string
+	}{
+		{name: "pending payment is cancelled", payFirst: false, paymentStatus: constant.PaymentStatusCancelled},
+		{name: "received payment is refunded", payFirst: true, paymentStatus: constant.PaymentStatusRefunded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newFixture(t)
+			ctx := context.Background()
+
+			data := f.submitOrder(t, submitRequest(
+				model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 4},
+				model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+			))
+
+			if tt.payFirst {
+				_, err := f.paymentService.SubmitPayment(ctx, model.SubmitPaymentRequest{
+					OrderReference:  data.OrderReference,
+					CardHolderName:  "John Doe",
+					CardNumber:      "4111 1111 1111 1111",
+					Status:          constant.PaymentStatusReceived,
+					AccountUsername: testUsername,
+				})
+
+				if err != nil {
+					t.Fatalf("submit payment: %v", err)
+				}
+			}
+
+			response, err := f.orderService.CancelOrder(ctx, model.CancelOrderRequest{
+				OrderReference:  data.OrderReference,
+				AccountUsername: testUsername,
+			})
+
+			if err != nil {
+				t.Fatalf("cancel order: %v", err)
+			}
+
+			cancelled := response.Data.(model.CancelOrderResponseData)
+
+			if cancelled.OrderStatus != constant.OrderStatusCancelled || cancelled.PaymentStatus != tt.paymentStatus {
+				t.Fatalf("unexpected statuses %s / %s", cancelled.OrderStatus, cancelled.PaymentStatus)
+			}
+
+			// Stock is returned
+			if stock := f.stockOf(t, 1); stock != 10 {
+				t.Fatalf("expected product 1 stock 10, got %d", stock)
+			}
+
+			if stock := f.stockOf(t, 2); stock != 3 {
+				t.Fatalf("expected product 2 stock 3, got %d", stock)
+			}
+
+			payment, _ := f.repos.Payment.FindByOrderReference(ctx, data.OrderReference)
+
+			if payment.Status != tt.paymentStatus {
+				t.Fatalf("expected stored payment status %s, got %s", tt.paymentStatus, payment.Status)
+			}
+		})
+	}
+}
+
+func TestCancelOrderRejectsProcessedOrder(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	data := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2}))
+
+	order, _ := f.repos.Order.FindByID(ctx, data.OrderReference)
+	order.Status = constant.OrderStatusProcessed
+
+	err := f.repos.Order.Update(ctx, order)
+	if err != nil {
+		t.Fatalf("update order: %v", err)
+	}
+
+	_, err = f.orderService.CancelOrder(ctx, model.CancelOrderRequest{
+		OrderReference:  data.OrderReference,
+		AccountUsername: testUsername,
+	})
+
+	assertErrorKind(t, err, common.ErrConflict)
+
+	if stock := f.stockOf(t, 1); stock != 8 {
+		t.Fatalf("expected stock to stay reserved, got %d", stock)
+	}
+}
+
+func TestCancelOrderTwiceReturnsStockOnce(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	data := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2}))
+
+	request := model.CancelOrderRequest{OrderReference: data.OrderReference, AccountUsername: testUsername}
+
+	_, err := f.orderService.CancelOrder(ctx, request)
+	if err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	_, err = f.orderService.CancelOrder(ctx, request)
+	assertErrorKind(t, err, common.ErrConflict)
+
+	if stock := f.stockOf(t, 1); stock != 10 {
+		t.Fatalf("expected the stock returned once, got %d", stock)
+	}
+
+	if movements := f.movementsOf(t, 1); len(movements) != 3 {
+		t.Fatalf("expected one cancellation return, got %+v", movements)
+	}
+}
+
+func TestOrderDetailAndCancelOnlyOpenOwnOrders(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.addAccount(t, "janedoe")
+
+	order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	guest := f.submitGuestOrder(t, guestRequest("jane@example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	for _, orderReference := range []string{order.OrderReference, guest.OrderReference} {
+
+		_, err := f.orderService.GetOrderDetail(ctx, model.GetOrderDetailRequest{OrderReference: orderReference, AccountUsername: "janedoe"})
+		assertErrorKind(t, err, common.ErrAccessDenied)
+
+		_, err = f.orderService.CancelOrder(ctx, model.CancelOrderRequest{OrderReference: orderReference, AccountUsername: "janedoe"})
+		assertErrorKind(t, err, common.ErrAccessDenied)
+	}
+
+	// A guest order is only opened with its access token
+	_, err := f.orderService.GetOrderDetail(ctx, model.GetOrderDetailRequest{OrderReference: guest.OrderReference, AccountUsername: testUsername})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	if f.stockOf(t, 1) != 8 {
+		t.Fatalf("a refused cancel should not return stock")
+	}
+
+	if detail := f.orderDetail(t, order.OrderReference); detail.Status != constant.OrderStatusPendingPayment {
+		t.Fatalf("expected the order still pending, got %s", detail.Status)
+	}
+}
+
+func TestGetAccountOrdersPaginatesNewestFirst(t *testing.T) {
+
+	f := newFixture(t)
+
+	var references []string
+
+	for i := 0; i < 3; i++ {
+		data := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+		references = append(references, data.OrderReference)
+	}
+
+	response, err := f.orderService.GetAccountOrders(context.Background(), model.GetAccountOrdersRequest{
+		AccountUserame: testUsername,
+		IsPaginate:     true,
+		Page:           1,
+		PerPage:        2,
+	})
+
+	if err != nil {
+		t.Fatalf("get account orders: %v", err)
+	}
+
+	data := response.Data.(model.GetAccountOrdersResponseData)
+
+	if data.Metadata.TotalData != 3 || data.Metadata.TotalPage != 2 || len(data.Orders) != 2 {
+		t.Fatalf("unexpected metadata %+v with %d orders", data.Metadata, len(data.Orders))
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+*
+
+	Build the repository pagination from the request :
+	- mode offset (default) : page and perPage
+	- mode cursor           : perPage rows after the cursor, a cursor alone implies the cursor mode
+
+*
+*/
+func (cs *CursorService) buildPaginationParams(
+	isPaginate bool,
+	page int,
+	perPage int,
+	mode string,
+	cursorToken string,
+	skipCount bool) (model.PaginationParams, error) {
+
+	paginationParams := model.PaginationParams{
+		IsPaginate: isPaginate,
+		Page:       page,
+		PerPage:    perPage,
+		SkipCount:  skipCount,
+	}
+
+	if mode == "" && cursorToken != "" {
+		mode = constant.PaginationModeCursor
+	}
+
+	switch mode {
+	case "", constant.PaginationModeOffset:
+		if cursorToken != "" {
+			err := errors.New("cursor is not supported in offset mode")
+			logrus.Error(err)
+			return paginationParams, common.NewError(err, common.ErrValidation)
+		}
+
+		return paginationParams, nil
+
+	case constant.PaginationModeCursor:
+		if !isPaginate {
+			err := errors.New("cursor mode requires pagination")
+			logrus.Error(err)
+			return paginationParams, common.NewError(err, common.ErrValidation)
+		}
+
+	default:
+		err := fmt.Errorf("unsupported pagination mode: %s", mode)
+		logrus.Error(err)
+		return paginationParams, common.NewError(err, common.ErrValidation)
+	}
+
+	paginationParams.IsKeyset = true
+
+	if cursorToken != "" {
+
+		cursor, err := cs.Decode(cursorToken)
+		if err != nil {
+			return paginationParams, err
+		}
+
+		paginationParams.Cursor = &cursor
+	}
+
+	return paginationParams, nil
+}
+
+/*
+*
+
+	Turn repository rows into a page and its metadata.
+
+	In keyset mode the look-ahead row is dropped, backward pages are put back in sort order and :
+	- nextCursor is set when rows follow the page
+	- prevCursor is set when rows precede the page
+
+*
+*/
+func buildPage[T any](
+	cursorService *CursorService,
+	rows []T,
+	totalData int64,
+	pagination model.PaginationParams,
+	cursorOf func(row T) model.Cursor) ([]T, model.MetadataDTO, error) {
+
+	metadata := model.MetadataDTO{}
+	metadata.PerPage = pagination.PerPage
+	metadata.TotalData = totalData
+	metadata.TotalSkipped = pagination.SkipCount
+
+	if !pagination.SkipCount {
+
+		totalPage := 1
+		if pagination.IsPaginate && pagination.PerPage > 0 {
+			totalPage = int(math.Ceil(float64(totalData) / float64(pagination.PerPage)))
+		}
+
+		metadata.TotalPage = totalPage
+	}
+
+	if !pagination.IsKeyset {
+		metadata.Page = pagination.Page
+		return rows, metadata, nil
+	}
+
+	hasMore := len(rows) > pagination.PerPage
+
+	if hasMore {
+		rows = rows[:pagination.PerPage]
+	}
+
+	backward := pagination.IsBackward()
+
+	if backward {
+		slices.Reverse(rows)
+	}
+
+	if len(rows) == 0 {
+		return rows, metadata, nil
+	}
+
+	hasNext := hasMore
+	hasPrev := pagination.Cursor != nil
+
+	if backward {
+		hasNext = true
+		hasPrev = hasMore
+	}
+
+	if hasNext {
+
+		cursor := cursorOf(rows[len(rows)-1])
+
+		token, err := cursorService.Encode(cursor)
+		if err != nil {
+			return rows, metadata, err
+		}
+
+		metadata.NextCursor = token
+	}
+
+	if hasPrev {
+
+		cursor := cursorOf(rows[0])
+		cursor.Backward = true
+
+		token, err := cursorService.Encode(cursor)
+		if err != nil {
+			return rows, metadata, err
+		}
+
+		metadata.PrevCursor = token
+	}
+
+	return rows, metadata, nil
+}
+
+// validateOffsetPagination checks the page of a listing without cursor mode
+func validateOffsetPagination(isPaginate bool, page int, perPage int) error {
+
+	if !isPaginate {
+		return nil
+	}
+
+	if page < 1 {
+		err := errors.New("page must be greater than 0")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if perPage < 1 || perPage > 100 {
+		err := errors.New("perPage must be between 1 and 100")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func offsetMetadata(isPaginate bool, page int, perPage int, totalData int64) model.MetadataDTO {
+
+	totalPage := 1
+	if isPaginate && perPage > 0 {
+		totalPage = int(math.Ceil(float64(totalData) / float64(perPage)))
+	}
+
+	return model.MetadataDTO{
+		Page:      page,
+		PerPage:   perPage,
+		TotalData: totalData,
+		TotalPage: totalPage,
+	}
+}
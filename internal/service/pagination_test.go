@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) productPage(t *testing.T, request model.GetProductsRequest) model.GetProductsResponseData {
+
+	t.Helper()
+
+	response, err := f.productService.GetProducts(context.Background(), request)
+	if err != nil {
+		t.Fatalf("get products: %v", err)
+	}
+
+	return response.Data.(model.GetProductsResponseData)
+}
+
+func cursorRequest(cursor string) model.GetProductsRequest {
+	return model.GetProductsRequest{
+		IsPaginate: true,
+		Page:       1,
+		PerPage:    1,
+		Sort:       constant.ProductSortPrice,
+		Mode:       constant.PaginationModeCursor,
+		Cursor:     cursor,
+	}
+}
+
+func TestGetProductsCursorWalksBothWays(t *testing.T) {
+
+	f := newFixture(t)
+
+	// Price ascending : 1 (15000), 3 (50000), 2 (120000)
+	var forward []int64
+	var pages []model.GetProductsResponseData
+	cursor := ""
+
+	for {
+		data := f.productPage(t, cursorRequest(cursor))
+
+		if len(data.Products) != 1 {
+			t.Fatalf("expected one product per page, got %d", len(data.Products))
+		}
+
+		forward = append(forward, data.Products[0].ID)
+		pages = append(pages, data)
+
+		if data.Metadata.NextCursor == "" {
+			break
+		}
+
+		cursor = data.Metadata.NextCursor
+	}
+
+	assertIDs(t, forward, 1, 3, 2)
+
+	if pages[0].Metadata.PrevCursor != "" {
+		t.Fatalf("first page must not have a previous cursor")
+	}
+
+	// Walk back from the last page
+	var backward []int64
+	cursor = pages[len(pages)-1].Metadata.PrevCursor
+
+	for cursor != "" {
+		data := f.productPage(t, cursorRequest(cursor))
+		backward = append(backward, data.Products[0].ID)
+		cursor = data.Metadata.PrevCursor
+	}
+
+	assertIDs(t, backward, 3, 1)
+}
+
+func TestGetAccountOrdersCursorIgnoresNewOrders(t *testing.T) {
+
+	f := newFixture(t)
+
+	for i := 0; i < 3; i++ {
+		f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	}
+
+	request := model.GetAccountOrdersRequest{
+		AccountUserame: testUsername,
+		IsPaginate:     true,
+		Page:           1,
+		PerPage:        2,
+		Mode:           constant.PaginationModeCursor,
+	}
+
+	seen := make(map[string]bool)
+
+	for {
+		response, err := f.orderService.GetAccountOrders(context.Background(), request)
+		if err != nil {
+			t.Fatalf("get account orders: %v", err)
+		}
+
+		data := response.Data.(model.GetAccountOrdersResponseData)
+
+		for _, order := range data.Orders {
+			if seen[order.OrderReference] {
+				t.Fatalf("order %s returned twice", order.OrderReference)
+			}
+			seen[order.OrderReference] = true
+		}
+
+		if data.Metadata.NextCursor == "" {
+			break
+		}
+
+		// A new order lands on the first page, it must not shift the next one
+		f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+		request.Cursor = data.Metadata.NextCursor
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("expected the 3 orders placed before paging, got %d", len(seen))
+	}
+}
+
+func TestGetProductsSkipsCount(t *testing.T) {
+
+	f := newFixture(t)
+
+	request := cursorRequest("")
+	request.SkipCount = true
+
+	data := f.productPage(t, request)
+
+	if !data.Metadata.TotalSkipped || data.Metadata.TotalData != 0 || data.Metadata.NextCursor == "" {
+		t.Fatalf("unexpected metadata %+v", data.Metadata)
+	}
+}
+
+func TestGetProductsRejectsInvalidCursors(t *testing.T) {
+
+	f := newFixture(t)
+
+	first := f.productPage(t, cursorRequest(""))
+	payload, signature, _ := strings.Cut(first.Metadata.NextCursor, ".")
+
+	otherSort := cursorRequest(first.Metadata.NextCursor)
+	otherSort.Sort = constant.ProductSortName
+
+	offsetMode := cursorRequest(first.Metadata.NextCursor)
+	offsetMode.Mode = constant.PaginationModeOffset
+
+	bestSelling := cursorRequest("")
+	bestSelling.Sort = constant.ProductSortBestSelling
+
+	tests := []struct {
+		name    string
+		request model.GetProductsRequest
+	}{
+		{name: "tampered payload", request: cursorRequest("x" + payload + "." + signature)},
+		{name: "missing signature", request: cursorRequest(payload)},
+		{name: "issued for another sort", request: otherSort},
+		{name: "cursor in offset mode", request: offsetMode},
+		{name: "best-selling", request: bestSelling},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := f.productService.GetProducts(context.Background(), tt.request)
+
+			assertErrorKind(t, err, common.ErrValidation)
+		})
+	}
+}
@@ -3,38 +3,49 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
 	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
 	"github.com/jhasudungan/terraloom-core-api/internal/model"
 	"github.com/jhasudungan/terraloom-core-api/internal/repository"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
 type PaymentService struct {
-	orderRepository   *repository.OrderRepository
-	paymentRepository *repository.PaymentRepository
+	txRunner          repository.TransactionRunner
+	orderRepository   repository.OrderRepository
+	paymentRepository repository.PaymentRepository
 }
 
-func NewPaymentService(orderRepository *repository.OrderRepository, paymentRepository *repository.PaymentRepository) *PaymentService {
+func NewPaymentService(txRunner repository.TransactionRunner, orderRepository repository.OrderRepository, paymentRepository repository.PaymentRepository) *PaymentService {
 	return &PaymentService{
+		txRunner:          txRunner,
 		orderRepository:   orderRepository,
 		paymentRepository: paymentRepository}
 }
 
+// SubmitPayment pays an order of the account, a guest order is only paid with its access token through PayGuestOrder
 func (ps *PaymentService) SubmitPayment(ctx context.Context, request model.SubmitPaymentRequest) (model.GeneralResponse, error) {
 
-	response := model.GeneralResponse{}
+	return ps.pay(ctx, request, func(order entity.Order) error {
 
-	// find data order
-	order, err := ps.orderRepository.FindByID(ctx, request.OrderReference)
+		if !order.IsOwnedBy(request.AccountUsername) {
+			err := fmt.Errorf("order %s does not belong to %s", order.OrderReference, request.AccountUsername)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrAccessDenied)
+		}
 
-	if err != nil {
-		logrus.Error(err)
-		return response, common.NewError(err, common.ErrResourceNotFound)
-	}
+		return nil
+	})
+}
+
+// pay checks the access to the order once it is locked, before anything is read from or written to it
+func (ps *PaymentService) pay(ctx context.Context, request model.SubmitPaymentRequest, checkAccess func(order entity.Order) error) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
 
 	if request.Status != constant.PaymentStatusReceived && request.Status != constant.PaymentStatusCancelled {
 		err := errors.New("payment status not recognized")
@@ -42,11 +53,31 @@ func (ps *PaymentService) SubmitPayment(ctx context.Context, request model.Submi
 		return response, common.NewError(err, common.ErrValidation)
 	}
 
-	err = ps.orderRepository.GetDB().Transaction(func(tx *gorm.DB) error {
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		// Transaction-scoped repositories
+		orderRepo := repos.Order
+		paymentRepo := repos.Payment
 
-		// Create transaction-scoped repositories
-		orderRepo := repository.NewOrderRepository(tx)
-		paymentRepo := repository.NewPaymentRepository(tx)
+		// The order stays locked until the payment is committed, a modification or a cancel waits for it
+		order, err := orderRepo.FindByID(ctx, request.OrderReference)
+
+		if err != nil {
+			logrus.Error(err)
+			return err
+		}
+
+		err = checkAccess(order)
+
+		if err != nil {
+			return err
+		}
+
+		if order.Status != constant.OrderStatusPendingPayment {
+			err := fmt.Errorf("order %s is %s, only an order waiting for its payment can be paid", order.OrderReference, order.Status)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
 
 		payment, err := paymentRepo.FindByOrderReference(ctx, order.OrderReference)
 
@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestSubmitPaymentReceivedMarksOrderPaid(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	data := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	_, err := f.paymentService.SubmitPayment(ctx, model.SubmitPaymentRequest{
+		OrderReference:  data.OrderReference,
+		CardHolderName:  "John Doe",
+		CardNumber:      "4111-1111-1111-1234",
+		Status:          constant.PaymentStatusReceived,
+		AccountUsername: testUsername,
+	})
+
+	if err != nil {
+		t.Fatalf("submit payment: %v", err)
+	}
+
+	order, _ := f.repos.Order.FindByID(ctx, data.OrderReference)
+
+	if order.Status != constant.OrderStatusPaymentReceived {
+		t.Fatalf("expected order status %s, got %s", constant.OrderStatusPaymentReceived, order.Status)
+	}
+
+	payment, _ := f.repos.Payment.FindByOrderReference(ctx, data.OrderReference)
+
+	if payment.Status != constant.PaymentStatusReceived {
+		t.Fatalf("expected payment status %s, got %s", constant.PaymentStatusReceived, payment.Status)
+	}
+
+	if payment.CardNumber != "************1234" || payment.CardHolderName != "J*** D**" {
+		t.Fatalf("card data not masked: %s / %s", payment.CardNumber, payment.CardHolderName)
+	}
+}
+
+func TestSubmitPaymentRejectsUnknownStatus(t *testing.T) {
+
+	f := newFixture(t)
+
+	data := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	_, err := f.paymentService.SubmitPayment(context.Background(), model.SubmitPaymentRequest{
+		OrderReference:  data.OrderReference,
+		Status:          "MAYBE",
+		AccountUsername: testUsername,
+	})
+
+	assertErrorKind(t, err, common.ErrValidation)
+}
+
+func TestSubmitPaymentUnknownOrder(t *testing.T) {
+
+	f := newFixture(t)
+
+	_, err := f.paymentService.SubmitPayment(context.Background(), model.SubmitPaymentRequest{
+		OrderReference:  "ORDER-UNKNOWN",
+		Status:          constant.PaymentStatusReceived,
+		AccountUsername: testUsername,
+	})
+
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func TestSubmitPaymentOnlyPaysOrdersOfTheAccount(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	guest := f.submitGuestOrder(t, guestRequest("jane@example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	tests := []struct {
+		name           string
+		orderReference string
+		username       string
+	}{
+		{"order of another account", order.OrderReference, "janedoe"},
+		{"guest order", guest.OrderReference, testUsername},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := f.paymentService.SubmitPayment(ctx, model.SubmitPaymentRequest{
+				OrderReference:  tt.orderReference,
+				CardHolderName:  "Jane Doe",
+				CardNumber:      "4111-1111-1111-1234",
+				Status:          constant.PaymentStatusReceived,
+				AccountUsername: tt.username,
+			})
+
+			assertErrorKind(t, err, common.ErrAccessDenied)
+		})
+	}
+
+	for _, orderReference := range []string{order.OrderReference, guest.OrderReference} {
+
+		payment, _ := f.repos.Payment.FindByOrderReference(ctx, orderReference)
+
+		if payment.Status != constant.PaymentStatusPending {
+			t.Fatalf("expected the payment of %s to stay pending, got %s", orderReference, payment.Status)
+		}
+	}
+}
+
+func TestSubmitPaymentOnlyPaysPendingOrders(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	paid, _ := f.paidOrder(t, model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	cancelled := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	_, err := f.orderService.CancelOrder(ctx, model.CancelOrderRequest{OrderReference: cancelled.OrderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	for _, orderReference := range []string{paid, cancelled.OrderReference} {
+
+		_, err := f.paymentService.SubmitPayment(ctx, model.SubmitPaymentRequest{
+			OrderReference:  orderReference,
+			CardHolderName:  "John Doe",
+			CardNumber:      "4111-1111-1111-1234",
+			Status:          constant.PaymentStatusReceived,
+			AccountUsername: testUsername,
+		})
+
+		assertErrorKind(t, err, common.ErrConflict)
+	}
+
+	order, _ := f.repos.Order.FindByID(ctx, cancelled.OrderReference)
+
+	if order.Status != constant.OrderStatusCancelled {
+		t.Fatalf("expected the cancelled order to stay cancelled, got %s", order.Status)
+	}
+}
@@ -0,0 +1,901 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+*
+
+	Product pricing :
+	- price is the list price, the sale price replaces it between saleStartsAt and saleEndsAt
+	  (open bounds when nil) as long as it undercuts the list price
+	- Variants with their own price are not on sale, the other ones follow the product
+	- List price changes are scheduled at an effective time, a change without one or already due
+	  is applied right away
+	- Due changes are written with the product locked, by the pricing job and by the order
+	  submission, reads apply them on the fly so a late job never shows a stale price
+	- Every change of the list price or of the sale is recorded in the price history
+	- The product currency prices the list price, the sale and the variants. The price list prices the
+	  product and its variants in the other currencies, sales and scheduled changes do not apply to it
+	- A sale or a list price change applied right away notifies the wishlist price drops once committed
+
+*
+*/
+type PricingService struct {
+	txRunner               repository.TransactionRunner
+	productRepository      repository.ProductRepository
+	priceChangeRepository  repository.ProductPriceChangeRepository
+	priceHistoryRepository repository.ProductPriceHistoryRepository
+	priceListRepository    repository.ProductCurrencyPriceRepository
+	wishlistService        *WishlistService
+}
+
+func NewPricingService(
+	txRunner repository.TransactionRunner,
+	productRepository repository.ProductRepository,
+	priceChangeRepository repository.ProductPriceChangeRepository,
+	priceHistoryRepository repository.ProductPriceHistoryRepository,
+	priceListRepository repository.ProductCurrencyPriceRepository,
+	wishlistService *WishlistService) *PricingService {
+	return &PricingService{
+		txRunner:               txRunner,
+		productRepository:      productRepository,
+		priceChangeRepository:  priceChangeRepository,
+		priceHistoryRepository: priceHistoryRepository,
+		priceListRepository:    priceListRepository,
+		wishlistService:        wishlistService,
+	}
+}
+
+func (ps *PricingService) GetPricing(ctx context.Context, productID int64) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	product, err := ps.productRepository.FindByID(ctx, productID)
+
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+	products := []entity.Product{product}
+
+	err = withDuePrices(ctx, ps.priceChangeRepository, products, now)
+
+	if err != nil {
+		return response, err
+	}
+
+	pending, err := ps.priceChangeRepository.FindPendingByProductID(ctx, product.ID)
+
+	if err != nil {
+		return response, err
+	}
+
+	priceList, err := ps.priceListRepository.FindByProductIDs(ctx, []int64{product.ID}, "")
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductPricingResponseData{
+		Pricing: newProductPricingDTO(products[0], pending, priceList, now),
+	}
+
+	return response, nil
+}
+
+// SchedulePriceChange changes the list price at the effective time, right away when there is none or it is past
+func (ps *PricingService) SchedulePriceChange(ctx context.Context, request model.SchedulePriceChangeRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.Price <= 0 {
+		err := errors.New("price must be greater than 0")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	now := time.Now()
+	immediate := request.EffectiveAt == nil || !request.EffectiveAt.After(now)
+
+	var product entity.Product
+	var pending []entity.ProductPriceChange
+	var priceList []entity.ProductCurrencyPrice
+
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		product, err = ps.lockProduct(ctx, repos, request.ProductID, now)
+
+		if err != nil {
+			return err
+		}
+
+		if immediate {
+
+			product, err = changeListPrice(ctx, repos, product, request.Price, nil, request.Username, now)
+
+			if err != nil {
+				return err
+			}
+
+		} else {
+
+			_, err = repos.PriceChange.Create(ctx, entity.ProductPriceChange{
+				ProductID:   product.ID,
+				Price:       request.Price,
+				EffectiveAt: *request.EffectiveAt,
+				CreatedAt:   now,
+				CreatedBy:   request.Username,
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		pending, err = repos.PriceChange.FindPendingByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		priceList, err = repos.PriceList.FindByProductIDs(ctx, []int64{product.ID}, "")
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	if immediate {
+		ps.notifyPriceDrops(ctx, product.ID)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductPricingResponseData{
+		Pricing: newProductPricingDTO(product, pending, priceList, now),
+	}
+
+	return response, nil
+}
+
+// CancelPriceChange deletes a pending change, an applied change is part of the history and stays
+func (ps *PricingService) CancelPriceChange(ctx context.Context, request model.CancelPriceChangeRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	now := time.Now()
+
+	var product entity.Product
+	var pending []entity.ProductPriceChange
+	var priceList []entity.ProductCurrencyPrice
+
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		// Due changes are applied first, a change that took effect cannot be cancelled
+		product, err = ps.lockProduct(ctx, repos, request.ProductID, now)
+
+		if err != nil {
+			return err
+		}
+
+		deleted, err := repos.PriceChange.DeletePending(ctx, product.ID, request.ChangeID)
+
+		if err != nil {
+			return err
+		}
+
+		if deleted == 0 {
+			err := fmt.Errorf("pending price change not found: %d", request.ChangeID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrResourceNotFound)
+		}
+
+		pending, err = repos.PriceChange.FindPendingByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		priceList, err = repos.PriceList.FindByProductIDs(ctx, []int64{product.ID}, "")
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductPricingResponseData{
+		Pricing: newProductPricingDTO(product, pending, priceList, now),
+	}
+
+	return response, nil
+}
+
+// SetSale puts the product on sale, replacing the current sale
+func (ps *PricingService) SetSale(ctx context.Context, request model.SetSaleRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	now := time.Now()
+
+	if request.SalePrice <= 0 {
+		err := errors.New("salePrice must be greater than 0")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	if request.StartsAt != nil && request.EndsAt != nil && !request.EndsAt.After(*request.StartsAt) {
+		err := errors.New("endsAt must be after startsAt")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	if request.EndsAt != nil && !request.EndsAt.After(now) {
+		err := errors.New("endsAt must be in the future")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var product entity.Product
+	var pending []entity.ProductPriceChange
+	var priceList []entity.ProductCurrencyPrice
+
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		product, err = ps.lockProduct(ctx, repos, request.ProductID, now)
+
+		if err != nil {
+			return err
+		}
+
+		if request.SalePrice >= product.Price {
+			err := fmt.Errorf("salePrice must be lower than the price: %d", product.Price)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		product, err = changeSale(ctx, repos, product, &request.SalePrice, request.StartsAt, request.EndsAt, request.Username, now)
+
+		if err != nil {
+			return err
+		}
+
+		pending, err = repos.PriceChange.FindPendingByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		priceList, err = repos.PriceList.FindByProductIDs(ctx, []int64{product.ID}, "")
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	// The sale may start later, only a price lower now is notified
+	ps.notifyPriceDrops(ctx, product.ID)
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductPricingResponseData{
+		Pricing: newProductPricingDTO(product, pending, priceList, now),
+	}
+
+	return response, nil
+}
+
+// EndSale takes the product off sale, whether the sale is running or still to come
+func (ps *PricingService) EndSale(ctx context.Context, request model.EndSaleRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	now := time.Now()
+
+	var product entity.Product
+	var pending []entity.ProductPriceChange
+	var priceList []entity.ProductCurrencyPrice
+
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		product, err = ps.lockProduct(ctx, repos, request.ProductID, now)
+
+		if err != nil {
+			return err
+		}
+
+		if product.SalePrice == nil {
+			err := fmt.Errorf("product is not on sale: %d", product.ID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrResourceNotFound)
+		}
+
+		product, err = changeSale(ctx, repos, product, nil, nil, nil, request.Username, now)
+
+		if err != nil {
+			return err
+		}
+
+		pending, err = repos.PriceChange.FindPendingByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		priceList, err = repos.PriceList.FindByProductIDs(ctx, []int64{product.ID}, "")
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductPricingResponseData{
+		Pricing: newProductPricingDTO(product, pending, priceList, now),
+	}
+
+	return response, nil
+}
+
+// SetCurrencyPrice prices the product, or one of its variants, in another currency, a 0 price takes it off the price list
+func (ps *PricingService) SetCurrencyPrice(ctx context.Context, request model.SetCurrencyPriceRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	now := time.Now()
+	currency := strings.ToUpper(strings.TrimSpace(request.Currency))
+
+	if !common.IsSupportedCurrency(currency) {
+		err := fmt.Errorf("currency is not supported: %q", request.Currency)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	if request.Price < 0 {
+		err := errors.New("price must not be negative")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var product entity.Product
+	var pending []entity.ProductPriceChange
+	var priceList []entity.ProductCurrencyPrice
+
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		product, err = ps.lockProduct(ctx, repos, request.ProductID, now)
+
+		if err != nil {
+			return err
+		}
+
+		if currency == product.Currency {
+			err := fmt.Errorf("product %d is priced in %s by its list price", product.ID, currency)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		var variantID *int64
+
+		if request.VariantID != 0 {
+
+			variant, err := repos.Variant.FindByID(ctx, request.VariantID)
+
+			if err != nil {
+				return err
+			}
+
+			if variant.ProductID != product.ID {
+				err := fmt.Errorf("variant %d is not a variant of product %d", variant.ID, product.ID)
+				logrus.Error(err)
+				return common.NewError(err, common.ErrValidation)
+			}
+
+			variantID = &variant.ID
+		}
+
+		entries, err := repos.PriceList.FindByProductIDs(ctx, []int64{product.ID}, currency)
+
+		if err != nil {
+			return err
+		}
+
+		index := slices.IndexFunc(entries, func(entry entity.ProductCurrencyPrice) bool {
+			if variantID == nil || entry.VariantID == nil {
+				return variantID == entry.VariantID
+			}
+
+			return *entry.VariantID == *variantID
+		})
+
+		switch {
+		case index < 0 && request.Price > 0:
+			_, err = repos.PriceList.Create(ctx, entity.ProductCurrencyPrice{
+				ProductID: product.ID,
+				VariantID: variantID,
+				Currency:  currency,
+				Price:     request.Price,
+				CreatedAt: now,
+				UpdatedAt: now,
+				CreatedBy: request.Username,
+				UpdatedBy: request.Username,
+			})
+		case index >= 0 && request.Price > 0:
+			entries[index].Price = request.Price
+			entries[index].UpdatedAt = now
+			entries[index].UpdatedBy = request.Username
+			err = repos.PriceList.Update(ctx, entries[index])
+		case index >= 0:
+			err = repos.PriceList.Delete(ctx, entries[index].ID)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		pending, err = repos.PriceChange.FindPendingByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		priceList, err = repos.PriceList.FindByProductIDs(ctx, []int64{product.ID}, "")
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductPricingResponseData{
+		Pricing: newProductPricingDTO(product, pending, priceList, now),
+	}
+
+	return response, nil
+}
+
+func (ps *PricingService) GetPriceHistory(ctx context.Context, request model.GetPriceHistoryRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := validateOffsetPagination(request.IsPaginate, request.Page, request.PerPage)
+
+	if err != nil {
+		return response, err
+	}
+
+	exists, err := ps.productRepository.CheckById(ctx, request.ProductID)
+
+	if err != nil {
+		return response, err
+	}
+
+	if !exists {
+		err := fmt.Errorf("product not found: %d", request.ProductID)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	paginationParams := model.PaginationParams{
+		IsPaginate: request.IsPaginate,
+		Page:       request.Page,
+		PerPage:    request.PerPage,
+	}
+
+	entries, totalData, err := ps.priceHistoryRepository.FindByProductID(ctx, request.ProductID, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	historyDTO := make([]model.ProductPriceHistoryDTO, len(entries))
+
+	for i, entry := range entries {
+		historyDTO[i] = newProductPriceHistoryDTO(entry)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetPriceHistoryResponseData{
+		History:  historyDTO,
+		Metadata: offsetMetadata(request.IsPaginate, request.Page, request.PerPage, totalData),
+	}
+
+	return response, nil
+}
+
+// ApplyDuePriceChanges writes every scheduled change due by now and returns the ids of the repriced products
+func (ps *PricingService) ApplyDuePriceChanges(ctx context.Context) ([]int64, error) {
+
+	now := time.Now()
+
+	var changed []int64
+
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		due, err := repos.PriceChange.FindDue(ctx, nil, now)
+
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		productIDs := make([]int64, 0, len(due))
+
+		for _, change := range due {
+			productIDs = append(productIDs, change.ProductID)
+		}
+
+		slices.Sort(productIDs)
+
+		products, err := repos.Product.LockByIDs(ctx, slices.Compact(productIDs))
+
+		if err != nil {
+			return err
+		}
+
+		productMap := make(map[int64]entity.Product, len(products))
+
+		for _, product := range products {
+			productMap[product.ID] = product
+		}
+
+		changed, err = applyDuePriceChanges(ctx, repos, productMap, now)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+// notifyPriceDrops notifies the wishlists of the committed price, a failed notice is sent by the next wishlist price-drops run
+func (ps *PricingService) notifyPriceDrops(ctx context.Context, productID int64) {
+
+	_, err := ps.wishlistService.NotifyPriceDrops(ctx, productID)
+
+	if err != nil {
+		logrus.Errorf("price drop notices for product %d: %v", productID, err)
+	}
+}
+
+// lockProduct locks the product and writes its due price changes
+func (ps *PricingService) lockProduct(ctx context.Context, repos repository.Repositories, productID int64, now time.Time) (entity.Product, error) {
+
+	product, err := repos.Product.FindByID(ctx, productID)
+
+	if err != nil {
+		return product, err
+	}
+
+	products := map[int64]entity.Product{product.ID: product}
+
+	_, err = applyDuePriceChanges(ctx, repos, products, now)
+
+	if err != nil {
+		return product, err
+	}
+
+	return products[product.ID], nil
+}
+
+// applyDuePriceChanges writes the changes due by now of the locked products, in effective order,
+// and updates the products in place. It returns the ids of the repriced products
+func applyDuePriceChanges(ctx context.Context, repos repository.Repositories, products map[int64]entity.Product, now time.Time) ([]int64, error) {
+
+	if len(products) == 0 {
+		return nil, nil
+	}
+
+	due, err := repos.PriceChange.FindDue(ctx, slices.Sorted(maps.Keys(products)), now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []int64
+
+	for _, change := range due {
+
+		applied, err := repos.PriceChange.MarkApplied(ctx, change.ID, now)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !applied {
+			continue
+		}
+
+		product, err := changeListPrice(ctx, repos, products[change.ProductID], change.Price, &change.ID, constant.SYSTEM, now)
+
+		if err != nil {
+			return nil, err
+		}
+
+		products[product.ID] = product
+
+		if !slices.Contains(changed, product.ID) {
+			changed = append(changed, product.ID)
+		}
+	}
+
+	return changed, nil
+}
+
+// changeListPrice writes the list price of the locked product and records it in the history
+func changeListPrice(
+	ctx context.Context,
+	repos repository.Repositories,
+	product entity.Product,
+	price int64,
+	priceChangeID *int64,
+	username string,
+	now time.Time) (entity.Product, error) {
+
+	oldPrice := product.Price
+
+	err := repos.PriceHistory.Create(ctx, entity.ProductPriceHistory{
+		ProductID:     product.ID,
+		PriceType:     constant.PriceTypeBase,
+		OldPrice:      &oldPrice,
+		NewPrice:      &price,
+		PriceChangeID: priceChangeID,
+		CreatedAt:     now,
+		CreatedBy:     username,
+	})
+
+	if err != nil {
+		return product, err
+	}
+
+	err = repos.Product.UpdatePrice(ctx, product.ID, price, username)
+
+	if err != nil {
+		return product, err
+	}
+
+	product.Price = price
+
+	return product, nil
+}
+
+// changeSale writes the sale of the locked product and records it in the history, a nil salePrice ends the sale
+func changeSale(
+	ctx context.Context,
+	repos repository.Repositories,
+	product entity.Product,
+	salePrice *int64,
+	startsAt *time.Time,
+	endsAt *time.Time,
+	username string,
+	now time.Time) (entity.Product, error) {
+
+	err := repos.PriceHistory.Create(ctx, entity.ProductPriceHistory{
+		ProductID:    product.ID,
+		PriceType:    constant.PriceTypeSale,
+		OldPrice:     product.SalePrice,
+		NewPrice:     salePrice,
+		SaleStartsAt: startsAt,
+		SaleEndsAt:   endsAt,
+		CreatedAt:    now,
+		CreatedBy:    username,
+	})
+
+	if err != nil {
+		return product, err
+	}
+
+	err = repos.Product.UpdateSale(ctx, product.ID, salePrice, startsAt, endsAt, username)
+
+	if err != nil {
+		return product, err
+	}
+
+	product.SalePrice = salePrice
+	product.SaleStartsAt = startsAt
+	product.SaleEndsAt = endsAt
+
+	return product, nil
+}
+
+// withDuePrices gives the products the list price of their scheduled changes due by now that
+// the pricing job did not write yet, reads show the price an order would be charged
+func withDuePrices(ctx context.Context, priceChangeRepository repository.ProductPriceChangeRepository, products []entity.Product, now time.Time) error {
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]int64, len(products))
+
+	for i, product := range products {
+		productIDs[i] = product.ID
+	}
+
+	due, err := priceChangeRepository.FindDue(ctx, productIDs, now)
+
+	if err != nil {
+		return err
+	}
+
+	// In effective order, the last due change wins
+	prices := make(map[int64]int64, len(due))
+
+	for _, change := range due {
+		prices[change.ProductID] = change.Price
+	}
+
+	for i := range products {
+		if price, exists := prices[products[i].ID]; exists {
+			products[i].Price = price
+		}
+	}
+
+	return nil
+}
+
+// priceListKey identifies a price list entry of a currency, VariantID is 0 for the product entry
+type priceListKey struct {
+	ProductID int64
+	VariantID int64
+}
+
+// findPriceList loads the price list of the products in the currency
+func findPriceList(ctx context.Context, priceListRepository repository.ProductCurrencyPriceRepository, productIDs []int64, currency string) (map[priceListKey]int64, error) {
+
+	entries, err := priceListRepository.FindByProductIDs(ctx, productIDs, currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	priceList := make(map[priceListKey]int64, len(entries))
+
+	for _, entry := range entries {
+
+		key := priceListKey{ProductID: entry.ProductID}
+
+		if entry.VariantID != nil {
+			key.VariantID = *entry.VariantID
+		}
+
+		priceList[key] = entry.Price
+	}
+
+	return priceList, nil
+}
+
+// unitPrice is the price of a unit in the currency, the effective price in the product currency and the
+// price list entry of the variant, or else of the product, in any other. Without an entry the unit is not sold in the currency
+func unitPrice(product entity.Product, variant *entity.ProductVariant, currency string, priceList map[priceListKey]int64, now time.Time) (common.Money, error) {
+
+	if currency == product.Currency {
+
+		if variant != nil {
+			return common.NewMoney(variant.EffectivePrice(product, now), currency), nil
+		}
+
+		return common.NewMoney(product.PriceAt(now), currency), nil
+	}
+
+	if variant != nil {
+		if price, listed := priceList[priceListKey{ProductID: product.ID, VariantID: variant.ID}]; listed {
+			return common.NewMoney(price, currency), nil
+		}
+	}
+
+	if price, listed := priceList[priceListKey{ProductID: product.ID}]; listed {
+		return common.NewMoney(price, currency), nil
+	}
+
+	err := fmt.Errorf("product %d is not sold in %s", product.ID, currency)
+	logrus.Error(err)
+
+	return common.Money{}, common.NewError(err, common.ErrValidation)
+}
+
+func newProductPricingDTO(product entity.Product, pending []entity.ProductPriceChange, priceList []entity.ProductCurrencyPrice, now time.Time) model.ProductPricingDTO {
+
+	pendingDTO := make([]model.ProductPriceChangeDTO, len(pending))
+
+	for i, change := range pending {
+		pendingDTO[i] = model.ProductPriceChangeDTO{
+			ID:          change.ID,
+			Price:       change.Price,
+			EffectiveAt: change.EffectiveAt,
+			AppliedAt:   change.AppliedAt,
+			CreatedAt:   change.CreatedAt,
+			CreatedBy:   change.CreatedBy,
+		}
+	}
+
+	return model.ProductPricingDTO{
+		ProductID:      product.ID,
+		Currency:       product.Currency,
+		Price:          product.Price,
+		SalePrice:      product.SalePrice,
+		SaleStartsAt:   product.SaleStartsAt,
+		SaleEndsAt:     product.SaleEndsAt,
+		EffectivePrice: product.PriceAt(now),
+		IsOnSale:       product.IsOnSale(now),
+		PendingChanges: pendingDTO,
+		CurrencyPrices: newProductCurrencyPriceDTOs(priceList),
+	}
+}
+
+func newProductCurrencyPriceDTOs(priceList []entity.ProductCurrencyPrice) []model.ProductCurrencyPriceDTO {
+
+	priceListDTO := make([]model.ProductCurrencyPriceDTO, len(priceList))
+
+	for i, entry := range priceList {
+		priceListDTO[i] = model.ProductCurrencyPriceDTO{
+			Currency:  entry.Currency,
+			VariantID: entry.VariantID,
+			Price:     entry.Price,
+		}
+	}
+
+	return priceListDTO
+}
+
+func newProductPriceHistoryDTO(entry entity.ProductPriceHistory) model.ProductPriceHistoryDTO {
+	return model.ProductPriceHistoryDTO{
+		ID:            entry.ID,
+		PriceType:     entry.PriceType,
+		OldPrice:      entry.OldPrice,
+		NewPrice:      entry.NewPrice,
+		SaleStartsAt:  entry.SaleStartsAt,
+		SaleEndsAt:    entry.SaleEndsAt,
+		PriceChangeID: entry.PriceChangeID,
+		CreatedAt:     entry.CreatedAt,
+		CreatedBy:     entry.CreatedBy,
+	}
+}
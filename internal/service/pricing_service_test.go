@@ -0,0 +1,627 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func timePtr(value time.Time) *time.Time {
+	return &value
+}
+
+func (f *fixture) setSale(t *testing.T, request model.SetSaleRequest) model.ProductPricingDTO {
+
+	t.Helper()
+
+	request.Username = "janestaff"
+
+	response, err := f.pricingService.SetSale(context.Background(), request)
+	if err != nil {
+		t.Fatalf("set sale: %v", err)
+	}
+
+	return response.Data.(model.ProductPricingResponseData).Pricing
+}
+
+func (f *fixture) productDetail(t *testing.T, productID int64) model.ProductDTO {
+
+	t.Helper()
+
+	response, err := f.productService.GetProductDetail(context.Background(), model.GetProductDetailRequest{ID: productID})
+	if err != nil {
+		t.Fatalf("get product detail: %v", err)
+	}
+
+	return response.Data.(model.GetProductDetailResponseData).Product
+}
+
+// dueChange schedules a change already due, as if its effective time passed before the pricing job ran
+func (f *fixture) dueChange(t *testing.T, productID int64, price int64) entity.ProductPriceChange {
+
+	t.Helper()
+
+	change, err := f.repos.PriceChange.Create(context.Background(), entity.ProductPriceChange{
+		ProductID:   productID,
+		Price:       price,
+		EffectiveAt: time.Now().Add(-time.Minute),
+		CreatedBy:   "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("create price change: %v", err)
+	}
+
+	return change
+}
+
+func TestSetSaleValidation(t *testing.T) {
+
+	f := newFixture(t)
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		request model.SetSaleRequest
+		kind    error
+	}{
+		{name: "missing sale price", request: model.SetSaleRequest{ProductID: 1}, kind: common.ErrValidation},
+		{name: "not lower than the price", request: model.SetSaleRequest{ProductID: 1, SalePrice: 15000}, kind: common.ErrValidation},
+		{name: "ends before it starts", request: model.SetSaleRequest{ProductID: 1, SalePrice: 12000, StartsAt: timePtr(now.Add(2 * time.Hour)), EndsAt: timePtr(now.Add(time.Hour))}, kind: common.ErrValidation},
+		{name: "ended already", request: model.SetSaleRequest{ProductID: 1, SalePrice: 12000, EndsAt: timePtr(now.Add(-time.Hour))}, kind: common.ErrValidation},
+		{name: "unknown product", request: model.SetSaleRequest{ProductID: 99, SalePrice: 12000}, kind: common.ErrResourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.pricingService.SetSale(context.Background(), tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	_, err := f.pricingService.EndSale(context.Background(), model.EndSaleRequest{ProductID: 1})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func TestSalePriceIsShownWithTheOriginalPrice(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	endsAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+	pricing := f.setSale(t, model.SetSaleRequest{ProductID: 1, SalePrice: 12000, EndsAt: &endsAt})
+
+	if !pricing.IsOnSale || pricing.EffectivePrice != 12000 || pricing.Price != 15000 {
+		t.Fatalf("unexpected pricing %+v", pricing)
+	}
+
+	// A sale still to come does not change the price yet
+	f.setSale(t, model.SetSaleRequest{ProductID: 2, SalePrice: 100000, StartsAt: timePtr(time.Now().Add(time.Hour))})
+
+	response, err := f.productService.GetProducts(context.Background(), model.GetProductsRequest{Sort: constant.ProductSortName})
+	if err != nil {
+		t.Fatalf("get products: %v", err)
+	}
+
+	products := make(map[int64]model.ProductDTO)
+
+	for _, product := range response.Data.(model.GetProductsResponseData).Products {
+		products[product.ID] = product
+	}
+
+	if pot := products[1]; pot.Price != 12000 || pot.OriginalPrice == nil || *pot.OriginalPrice != 15000 || pot.SaleEndsAt == nil || !pot.SaleEndsAt.Equal(endsAt) {
+		t.Fatalf("expected the sale price with the original price, got %+v", pot)
+	}
+
+	if throw := products[2]; throw.Price != 120000 || throw.OriginalPrice != nil {
+		t.Fatalf("expected the list price before the sale starts, got %+v", throw)
+	}
+
+	// Variants follow the sale of the product unless they have their own price
+	f.setSale(t, model.SetSaleRequest{ProductID: 4, SalePrice: 80000})
+
+	tee := f.productDetail(t, 4)
+
+	if tee.Price != 80000 || *tee.OriginalPrice != 90000 {
+		t.Fatalf("unexpected tee %+v", tee)
+	}
+
+	for _, variant := range tee.Variants {
+
+		switch variant.ID {
+		case 41:
+			if variant.Price != 80000 || variant.OriginalPrice == nil || *variant.OriginalPrice != 90000 {
+				t.Fatalf("expected variant 41 on sale, got %+v", variant)
+			}
+		case 42:
+			if variant.Price != 95000 || variant.OriginalPrice != nil {
+				t.Fatalf("expected variant 42 at its own price, got %+v", variant)
+			}
+		}
+	}
+}
+
+func TestListingsFilterAndSortOnTheSalePrice(t *testing.T) {
+
+	f := newFixture(t)
+
+	// The throw is on sale below the pot, the vase has a sale still to come
+	f.setSale(t, model.SetSaleRequest{ProductID: 2, SalePrice: 10000})
+	f.setSale(t, model.SetSaleRequest{ProductID: 3, SalePrice: 1000, StartsAt: timePtr(time.Now().Add(time.Hour))})
+
+	// Price ascending : 2 (10000), 1 (15000), 3 (50000)
+	assertIDs(t, f.productIDs(t, model.GetProductsRequest{Sort: constant.ProductSortPrice}), 2, 1, 3)
+
+	assertIDs(t, f.productIDs(t, model.GetProductsRequest{MaxPrice: int64Ptr(12000)}), 2)
+	assertIDs(t, f.productIDs(t, model.GetProductsRequest{MinPrice: int64Ptr(20000)}), 3)
+
+	// A cursor holds the sale price too
+	first := f.productPage(t, cursorRequest(""))
+	second := f.productPage(t, cursorRequest(first.Metadata.NextCursor))
+
+	if len(first.Products) != 1 || first.Products[0].ID != 2 || len(second.Products) != 1 || second.Products[0].ID != 1 {
+		t.Fatalf("expected the throw then the pot, got %+v then %+v", first.Products, second.Products)
+	}
+}
+
+func TestListingsInAnotherCurrencyUseThePriceList(t *testing.T) {
+
+	f := newFixture(t)
+
+	// The throw sale stays in IDR, the USD price list carries no sale
+	f.setSale(t, model.SetSaleRequest{ProductID: 2, SalePrice: 10000})
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 2, Currency: "USD", Price: 900})
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 3, Currency: "USD", Price: 400})
+
+	// The pot has no USD price, it is not listed in USD
+	assertIDs(t, f.productIDs(t, model.GetProductsRequest{Currency: "usd", Sort: constant.ProductSortPrice}), 3, 2)
+	assertIDs(t, f.productIDs(t, model.GetProductsRequest{Currency: "USD", MaxPrice: int64Ptr(500)}), 3)
+
+	products := f.productPage(t, model.GetProductsRequest{Currency: "USD", MinPrice: int64Ptr(500)}).Products
+
+	if len(products) != 1 || products[0].Price != 900 || products[0].Currency != "USD" || products[0].OriginalPrice != nil {
+		t.Fatalf("expected the throw at its USD price, got %+v", products)
+	}
+
+	_, err := f.productService.GetProducts(context.Background(), model.GetProductsRequest{Currency: "EUR"})
+	assertErrorKind(t, err, common.ErrValidation)
+}
+
+func TestSubmitOrderChargesTheSalePrice(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	f.setSale(t, model.SetSaleRequest{ProductID: 1, SalePrice: 12000})
+	f.setSale(t, model.SetSaleRequest{ProductID: 4, SalePrice: 80000})
+
+	// The list price is no longer the price
+	_, err := f.orderService.SubmitOrder(context.Background(), submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	assertErrorKind(t, err, common.ErrValidation)
+
+	_, err = f.orderService.SubmitOrder(context.Background(), submitRequest(model.OrderItemRequest{ProductId: 4, VariantId: 41, PriceUsed: 90000, Quantity: 1}))
+	assertErrorKind(t, err, common.ErrValidation)
+
+	data := f.submitOrder(t, submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 12000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 4, VariantId: 41, PriceUsed: 80000, Quantity: 1},
+		model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 1},
+	))
+
+	if data.Total != 2*12000+80000+95000 {
+		t.Fatalf("expected the sale prices to be charged, got %d", data.Total)
+	}
+
+	// Ending the sale brings the list price back
+	_, err = f.pricingService.EndSale(context.Background(), model.EndSaleRequest{ProductID: 1, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("end sale: %v", err)
+	}
+
+	_, err = f.orderService.SubmitOrder(context.Background(), submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 12000, Quantity: 1}))
+	assertErrorKind(t, err, common.ErrValidation)
+
+	if pot := f.productDetail(t, 1); pot.Price != 15000 || pot.OriginalPrice != nil {
+		t.Fatalf("expected the list price, got %+v", pot)
+	}
+}
+
+func TestScheduledPriceChangeTakesEffectWhenDue(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	response, err := f.pricingService.SchedulePriceChange(ctx, model.SchedulePriceChangeRequest{
+		ProductID:   1,
+		Price:       18000,
+		EffectiveAt: timePtr(time.Now().Add(time.Hour)),
+		Username:    "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("schedule price change: %v", err)
+	}
+
+	pricing := response.Data.(model.ProductPricingResponseData).Pricing
+
+	if pricing.Price != 15000 || len(pricing.PendingChanges) != 1 || pricing.PendingChanges[0].Price != 18000 {
+		t.Fatalf("expected a pending change, got %+v", pricing)
+	}
+
+	// Due but not written yet, reads and orders already use it
+	change := f.dueChange(t, 1, 17000)
+
+	if pot := f.productDetail(t, 1); pot.Price != 17000 {
+		t.Fatalf("expected the due price, got %+v", pot)
+	}
+
+	_, err = f.orderService.SubmitOrder(ctx, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	assertErrorKind(t, err, common.ErrValidation)
+
+	data := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 17000, Quantity: 1}))
+
+	if data.Total != 17000 {
+		t.Fatalf("expected the due price to be charged, got %d", data.Total)
+	}
+
+	// The order wrote the change, the job has nothing left to do
+	product, err := f.repos.Product.FindByID(ctx, 1)
+	if err != nil || product.Price != 17000 {
+		t.Fatalf("expected the price to be written, got %+v %v", product, err)
+	}
+
+	repriced, err := f.pricingService.ApplyDuePriceChanges(ctx)
+	if err != nil || len(repriced) != 0 {
+		t.Fatalf("expected nothing to apply, got %v %v", repriced, err)
+	}
+
+	history, _, err := f.repos.PriceHistory.FindByProductID(ctx, 1, model.PaginationParams{})
+	if err != nil || len(history) != 1 || *history[0].OldPrice != 15000 || *history[0].NewPrice != 17000 || *history[0].PriceChangeID != change.ID {
+		t.Fatalf("expected the applied change in the history, got %+v %v", history, err)
+	}
+}
+
+func TestApplyDuePriceChanges(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.wish(t, 1, nil)
+
+	f.dueChange(t, 1, 14000)
+	f.dueChange(t, 1, 13000)
+	f.dueChange(t, 2, 125000)
+
+	repriced, err := f.pricingService.ApplyDuePriceChanges(ctx)
+	if err != nil || len(repriced) != 2 {
+		t.Fatalf("expected 2 repriced products, got %v %v", repriced, err)
+	}
+
+	// The last change due wins
+	if pot := f.productDetail(t, 1); pot.Price != 13000 {
+		t.Fatalf("expected 13000, got %+v", pot)
+	}
+
+	if throw := f.productDetail(t, 2); throw.Price != 125000 {
+		t.Fatalf("expected 125000, got %+v", throw)
+	}
+
+	sent, err := f.wishlistService.NotifyPriceDrops(ctx, repriced...)
+	if err != nil || sent != 1 || f.notifier.priceDrops[0].NewPrice != 13000 {
+		t.Fatalf("expected a price drop notice, got %d %v %+v", sent, err, f.notifier.priceDrops)
+	}
+}
+
+func TestPriceDropsAreNotifiedOnceCommitted(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.wish(t, 1, nil)
+
+	// A future change and a sale still to come lower nothing yet
+	later := time.Now().Add(time.Hour)
+
+	_, err := f.pricingService.SchedulePriceChange(ctx, model.SchedulePriceChangeRequest{ProductID: 1, Price: 12000, EffectiveAt: &later, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("schedule price change: %v", err)
+	}
+
+	_, err = f.pricingService.SetSale(ctx, model.SetSaleRequest{ProductID: 1, SalePrice: 11000, StartsAt: &later, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("set sale: %v", err)
+	}
+
+	if len(f.notifier.priceDrops) != 0 {
+		t.Fatalf("expected no notice before the price drops, got %+v", f.notifier.priceDrops)
+	}
+
+	_, err = f.pricingService.SchedulePriceChange(ctx, model.SchedulePriceChangeRequest{ProductID: 1, Price: 14000, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("change price: %v", err)
+	}
+
+	_, err = f.pricingService.SetSale(ctx, model.SetSaleRequest{ProductID: 1, SalePrice: 13000, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("set sale: %v", err)
+	}
+
+	if drops := f.notifier.priceDrops; len(drops) != 2 || drops[0].NewPrice != 14000 || drops[1].OldPrice != 14000 || drops[1].NewPrice != 13000 {
+		t.Fatalf("expected a notice per drop, got %+v", drops)
+	}
+}
+
+func TestPriceChangeHistoryAndCancellation(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	// Without an effective time the change is immediate
+	_, err := f.pricingService.SchedulePriceChange(ctx, model.SchedulePriceChangeRequest{ProductID: 1, Price: 16000, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("change price: %v", err)
+	}
+
+	f.setSale(t, model.SetSaleRequest{ProductID: 1, SalePrice: 12000})
+
+	_, err = f.pricingService.EndSale(ctx, model.EndSaleRequest{ProductID: 1, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("end sale: %v", err)
+	}
+
+	response, err := f.pricingService.SchedulePriceChange(ctx, model.SchedulePriceChangeRequest{
+		ProductID:   1,
+		Price:       20000,
+		EffectiveAt: timePtr(time.Now().Add(time.Hour)),
+		Username:    "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("schedule price change: %v", err)
+	}
+
+	pending := response.Data.(model.ProductPricingResponseData).Pricing.PendingChanges[0]
+
+	_, err = f.pricingService.CancelPriceChange(ctx, model.CancelPriceChangeRequest{ProductID: 2, ChangeID: pending.ID})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	response, err = f.pricingService.CancelPriceChange(ctx, model.CancelPriceChangeRequest{ProductID: 1, ChangeID: pending.ID})
+	if err != nil {
+		t.Fatalf("cancel price change: %v", err)
+	}
+
+	if pricing := response.Data.(model.ProductPricingResponseData).Pricing; pricing.Price != 16000 || len(pricing.PendingChanges) != 0 {
+		t.Fatalf("expected no pending change, got %+v", pricing)
+	}
+
+	_, err = f.pricingService.CancelPriceChange(ctx, model.CancelPriceChangeRequest{ProductID: 1, ChangeID: pending.ID})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	response, err = f.pricingService.GetPriceHistory(ctx, model.GetPriceHistoryRequest{ProductID: 1, IsPaginate: true, Page: 1, PerPage: 10})
+	if err != nil {
+		t.Fatalf("get price history: %v", err)
+	}
+
+	history := response.Data.(model.GetPriceHistoryResponseData).History
+
+	if len(history) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", history)
+	}
+
+	if ended := history[0]; ended.PriceType != constant.PriceTypeSale || *ended.OldPrice != 12000 || ended.NewPrice != nil {
+		t.Fatalf("expected the end of the sale first, got %+v", ended)
+	}
+
+	if started := history[1]; started.PriceType != constant.PriceTypeSale || started.OldPrice != nil || *started.NewPrice != 12000 {
+		t.Fatalf("expected the start of the sale, got %+v", started)
+	}
+
+	if changed := history[2]; changed.PriceType != constant.PriceTypeBase || *changed.OldPrice != 15000 || *changed.NewPrice != 16000 || changed.PriceChangeID != nil || changed.CreatedBy != "janestaff" {
+		t.Fatalf("expected the list price change, got %+v", changed)
+	}
+
+	_, err = f.pricingService.GetPriceHistory(ctx, model.GetPriceHistoryRequest{ProductID: 99})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func (f *fixture) setCurrencyPrice(t *testing.T, request model.SetCurrencyPriceRequest) model.ProductPricingDTO {
+
+	t.Helper()
+
+	request.Username = "janestaff"
+
+	response, err := f.pricingService.SetCurrencyPrice(context.Background(), request)
+	if err != nil {
+		t.Fatalf("set currency price: %v", err)
+	}
+
+	return response.Data.(model.ProductPricingResponseData).Pricing
+}
+
+func TestSetCurrencyPriceValidation(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	tests := []struct {
+		name    string
+		request model.SetCurrencyPriceRequest
+		kind    error
+	}{
+		{"unsupported currency", model.SetCurrencyPriceRequest{ProductID: 1, Currency: "EUR", Price: 100}, common.ErrValidation},
+		{"negative price", model.SetCurrencyPriceRequest{ProductID: 1, Currency: "USD", Price: -1}, common.ErrValidation},
+		{"product currency", model.SetCurrencyPriceRequest{ProductID: 1, Currency: "IDR", Price: 15000}, common.ErrValidation},
+		{"variant of another product", model.SetCurrencyPriceRequest{ProductID: 1, Currency: "USD", VariantID: 41, Price: 100}, common.ErrValidation},
+		{"unknown product", model.SetCurrencyPriceRequest{ProductID: 99, Currency: "USD", Price: 100}, common.ErrResourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			tt.request.Username = "janestaff"
+
+			_, err := f.pricingService.SetCurrencyPrice(context.Background(), tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestPriceListIsSetReplacedAndRemoved(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 4, Currency: "usd", Price: 600})
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 4, Currency: "USD", VariantID: 42, Price: 650})
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 4, Currency: "SGD", Price: 800})
+
+	pricing := f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 4, Currency: "USD", Price: 625})
+
+	if pricing.Currency != constant.CurrencyIDR || len(pricing.CurrencyPrices) != 3 {
+		t.Fatalf("expected an IDR product with 3 price list entries, got %+v", pricing)
+	}
+
+	usd := pricing.CurrencyPrices[2]
+
+	if pricing.CurrencyPrices[1].Currency != "USD" || pricing.CurrencyPrices[1].VariantID != nil || pricing.CurrencyPrices[1].Price != 625 {
+		t.Fatalf("expected the product USD price to be replaced, got %+v", pricing.CurrencyPrices)
+	}
+
+	if usd.VariantID == nil || *usd.VariantID != 42 || usd.Price != 650 {
+		t.Fatalf("expected the variant USD price after the product one, got %+v", usd)
+	}
+
+	pricing = f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 4, Currency: "SGD", Price: 0})
+
+	if len(pricing.CurrencyPrices) != 2 || pricing.CurrencyPrices[0].Currency != "USD" {
+		t.Fatalf("a price of 0 should remove the entry, got %+v", pricing.CurrencyPrices)
+	}
+}
+
+func TestOrderInAnotherCurrencyUsesThePriceList(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 1, Currency: "USD", Price: 150})
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 4, Currency: "USD", Price: 600})
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 4, Currency: "USD", VariantID: 42, Price: 650})
+
+	// The IDR price is not a USD price
+	request := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.Currency = "USD"
+
+	_, err := f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	// Product 2 has no USD price
+	request = submitRequest(model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1})
+	request.Currency = "USD"
+
+	_, err = f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	if f.stockOf(t, 1) != 10 || f.stockOf(t, 2) != 3 {
+		t.Fatalf("a refused order should not reserve stock")
+	}
+
+	// Variant 41 has no USD price of its own and follows the product
+	request = submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 150, Quantity: 2},
+		model.OrderItemRequest{ProductId: 4, VariantId: 41, PriceUsed: 600, Quantity: 1},
+		model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 650, Quantity: 1},
+	)
+	request.Currency = "usd"
+
+	data := f.submitOrder(t, request)
+
+	if data.Currency != "USD" || data.Total != 2*150+600+650 {
+		t.Fatalf("expected a USD order of 1550, got %s %d", data.Currency, data.Total)
+	}
+
+	detail := f.orderDetail(t, data.OrderReference)
+
+	if detail.Currency != "USD" || detail.Payment.Currency != "USD" || detail.Payment.Total != 1550 {
+		t.Fatalf("expected the payment in the order currency, got %+v", detail.Payment)
+	}
+
+	// Orders without a currency stay in the default currency
+	idr := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	if idr.Currency != constant.DefaultCurrency || idr.Total != 15000 {
+		t.Fatalf("expected an IDR order, got %s %d", idr.Currency, idr.Total)
+	}
+
+	request = submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.Currency = "EUR"
+
+	_, err = f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+}
+
+func TestCouponsAndShippingMethodsServeTheirCurrency(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 1, Currency: "USD", Price: 1000})
+
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "RP5000", Type: constant.PromotionTypeFixedAmount, Value: 5000})
+	f.createShippingMethod(t, model.CreateShippingMethodRequest{Code: "REG", Name: "Regular", Type: constant.ShippingTypeFlatRate, FlatFee: 10000})
+
+	// An IDR coupon does not take 5000 cents off a USD order
+	request := couponRequest("RP5000", testUsername, model.OrderItemRequest{ProductId: 1, PriceUsed: 1000, Quantity: 1})
+	request.Currency = "USD"
+
+	_, err := f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	// Without a USD shipping method a USD order ships without a fee, the IDR method does not apply
+	request.CouponCode = ""
+	request.ShippingMethod = "REG"
+
+	_, err = f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	f.createShippingMethod(t, model.CreateShippingMethodRequest{Code: "INTL", Name: "International", Type: constant.ShippingTypeFlatRate, Currency: "USD", FlatFee: 500})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "USD2", Type: constant.PromotionTypeFixedAmount, Currency: "USD", Value: 200})
+
+	request = couponRequest("USD2", testUsername, model.OrderItemRequest{ProductId: 1, PriceUsed: 1000, Quantity: 1})
+	request.Currency = "USD"
+	request.ShippingMethod = "INTL"
+
+	data := f.submitOrder(t, request)
+
+	if data.DiscountTotal != 200 || data.ShippingFee != 500 || data.Total != 1000-200+500 {
+		t.Fatalf("expected the USD coupon and shipping fee, got %+v", data)
+	}
+
+	quote := f.quoteShipping(t, "", model.ShippingQuoteItem{ProductID: 1, Quantity: 2})
+
+	if quote.Currency != constant.DefaultCurrency || len(quote.Methods) != 1 || quote.Methods[0].Code != "REG" {
+		t.Fatalf("an IDR quote should only list IDR methods, got %+v", quote)
+	}
+}
+
+func TestMoneyNeverMixesCurrencies(t *testing.T) {
+
+	idr := common.NewMoney(15000, constant.CurrencyIDR)
+	usd := common.NewMoney(1250, constant.CurrencyUSD)
+
+	_, err := idr.Add(usd)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	total, err := usd.Add(common.NewMoney(50, constant.CurrencyUSD))
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	if total.String() != "USD 13.00" || idr.String() != "IDR 15000" {
+		t.Fatalf("unexpected formatting %q %q", total.String(), idr.String())
+	}
+}
@@ -0,0 +1,472 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	MaxProductImageBytes = 10 << 20
+
+	maxImageAltTextLength = 255
+)
+
+type ProductImageService struct {
+	txRunner          repository.TransactionRunner
+	productRepository repository.ProductRepository
+	blobStore         storage.BlobStore
+	idGenerator       *common.IdGenerator
+}
+
+func NewProductImageService(
+	txRunner repository.TransactionRunner,
+	productRepository repository.ProductRepository,
+	blobStore storage.BlobStore,
+	idGenerator *common.IdGenerator) *ProductImageService {
+	return &ProductImageService{
+		txRunner:          txRunner,
+		productRepository: productRepository,
+		blobStore:         blobStore,
+		idGenerator:       idGenerator,
+	}
+}
+
+/*
+*
+
+	Upload a product image :
+	- The type is sniffed from the content (JPEG, PNG or GIF), the file name and header are ignored
+	- The original and its thumbnails are stored first, then the rows are written in one transaction.
+	  The stored files are removed again when the transaction fails
+	- The first image of a product becomes its primary image, products.image_url follows the primary image
+
+*
+*/
+func (pis *ProductImageService) UploadProductImage(ctx context.Context, request model.UploadProductImageRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := validateImageAttributes(request.AltText, request.Position)
+
+	if err != nil {
+		return response, err
+	}
+
+	if len(request.Content) == 0 {
+		err := errors.New("file is required")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	if len(request.Content) > MaxProductImageBytes {
+		err := fmt.Errorf("file must not be larger than %d bytes", MaxProductImageBytes)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	contentType := http.DetectContentType(request.Content)
+	extension, supported := imageExtensions[contentType]
+
+	if !supported {
+		err := fmt.Errorf("unsupported image type: %s", contentType)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	exists, err := pis.productRepository.CheckById(ctx, request.ProductID)
+
+	if err != nil {
+		return response, err
+	}
+
+	if !exists {
+		err := fmt.Errorf("product %d not found", request.ProductID)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	img, config, err := decodeImage(request.Content)
+
+	if err != nil {
+		return response, err
+	}
+
+	thumbnails, err := buildThumbnails(img, contentType)
+
+	if err != nil {
+		return response, err
+	}
+
+	name, err := pis.idGenerator.GenerateCommonID("IMG")
+
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+	prefix := fmt.Sprintf("products/%d/", request.ProductID)
+
+	image := entity.ProductImage{
+		ProductID:   request.ProductID,
+		StorageKey:  prefix + name + extension,
+		AltText:     strings.TrimSpace(request.AltText),
+		IsPrimary:   request.IsPrimary,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		CreatedBy:   request.Username,
+		UpdatedBy:   request.Username,
+	}
+
+	image.URL = pis.blobStore.URL(image.StorageKey)
+
+	// Store the files first, a blob without a row is harmless, a row without a blob is a broken image
+	var storedKeys []string
+
+	err = pis.blobStore.Put(ctx, image.StorageKey, bytes.NewReader(request.Content), contentType)
+
+	if err != nil {
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrDBOperation)
+	}
+
+	storedKeys = append(storedKeys, image.StorageKey)
+
+	for _, thumb := range thumbnails {
+
+		key := prefix + name + "_" + thumb.Size + imageExtensions[thumb.ContentType]
+
+		err = pis.blobStore.Put(ctx, key, bytes.NewReader(thumb.Content), thumb.ContentType)
+
+		if err != nil {
+			logrus.Error(err)
+			pis.deleteBlobs(storedKeys)
+			return response, common.NewError(err, common.ErrDBOperation)
+		}
+
+		storedKeys = append(storedKeys, key)
+
+		image.Sizes = append(image.Sizes, entity.ProductImageSize{
+			Size:       thumb.Size,
+			StorageKey: key,
+			URL:        pis.blobStore.URL(key),
+			Width:      thumb.Width,
+			Height:     thumb.Height,
+		})
+	}
+
+	err = pis.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		// Locks the product, gallery changes of a product are serialized
+		product, err := repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		gallery, err := repos.Image.FindByProductIDs(ctx, []int64{product.ID})
+
+		if err != nil {
+			return err
+		}
+
+		if len(gallery) == 0 {
+			image.IsPrimary = true
+		}
+
+		image.Position = len(gallery)
+
+		if request.Position != nil {
+			image.Position = *request.Position
+		}
+
+		if image.IsPrimary {
+			err = repos.Image.ClearPrimary(ctx, product.ID)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		image, err = repos.Image.Create(ctx, image)
+
+		if err != nil {
+			return err
+		}
+
+		if image.IsPrimary {
+			return pis.syncProductImageUrl(ctx, repos, product, image.URL, request.Username)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		pis.deleteBlobs(storedKeys)
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductImageResponseData{
+		ProductID: request.ProductID,
+		Image:     newProductImageDTO(image),
+	}
+
+	return response, nil
+}
+
+// UpdateProductImage changes the alt text, position or primary flag of an image
+func (pis *ProductImageService) UpdateProductImage(ctx context.Context, request model.UpdateProductImageRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.AltText == nil && request.Position == nil && request.IsPrimary == nil {
+		err := errors.New("nothing to update")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	altText := ""
+	if request.AltText != nil {
+		altText = *request.AltText
+	}
+
+	err := validateImageAttributes(altText, request.Position)
+
+	if err != nil {
+		return response, err
+	}
+
+	// The primary image can only be replaced, a product with images always has one
+	if request.IsPrimary != nil && !*request.IsPrimary {
+		err := errors.New("isPrimary can only be set to true, mark another image as primary instead")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var image entity.ProductImage
+
+	err = pis.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		product, err := repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		image, err = findProductImage(ctx, repos, product.ID, request.ImageID)
+
+		if err != nil {
+			return err
+		}
+
+		if request.AltText != nil {
+			image.AltText = strings.TrimSpace(*request.AltText)
+		}
+
+		if request.Position != nil {
+			image.Position = *request.Position
+		}
+
+		becomesPrimary := request.IsPrimary != nil && !image.IsPrimary
+
+		if becomesPrimary {
+			err = repos.Image.ClearPrimary(ctx, product.ID)
+
+			if err != nil {
+				return err
+			}
+
+			image.IsPrimary = true
+		}
+
+		image.UpdatedAt = time.Now()
+		image.UpdatedBy = request.Username
+
+		err = repos.Image.Update(ctx, image)
+
+		if err != nil {
+			return err
+		}
+
+		if becomesPrimary {
+			return pis.syncProductImageUrl(ctx, repos, product, image.URL, request.Username)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductImageResponseData{
+		ProductID: request.ProductID,
+		Image:     newProductImageDTO(image),
+	}
+
+	return response, nil
+}
+
+/*
+*
+
+	Delete an image and its thumbnails :
+	- The row is deleted first, the files once the transaction is committed
+	- Deleting the primary image promotes the next image of the gallery,
+	  products.image_url is cleared when the gallery becomes empty
+
+*
+*/
+func (pis *ProductImageService) DeleteProductImage(ctx context.Context, request model.DeleteProductImageRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var image entity.ProductImage
+
+	err := pis.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		product, err := repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		image, err = findProductImage(ctx, repos, product.ID, request.ImageID)
+
+		if err != nil {
+			return err
+		}
+
+		err = repos.Image.Delete(ctx, image.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if !image.IsPrimary {
+			return nil
+		}
+
+		gallery, err := repos.Image.FindByProductIDs(ctx, []int64{product.ID})
+
+		if err != nil {
+			return err
+		}
+
+		if len(gallery) == 0 {
+			return pis.syncProductImageUrl(ctx, repos, product, "", request.Username)
+		}
+
+		next := gallery[0]
+		next.IsPrimary = true
+		next.UpdatedAt = time.Now()
+		next.UpdatedBy = request.Username
+
+		err = repos.Image.Update(ctx, next)
+
+		if err != nil {
+			return err
+		}
+
+		return pis.syncProductImageUrl(ctx, repos, product, next.URL, request.Username)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	keys := []string{image.StorageKey}
+
+	for _, size := range image.Sizes {
+		keys = append(keys, size.StorageKey)
+	}
+
+	pis.deleteBlobs(keys)
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.DeleteProductImageResponseData{
+		ProductID: request.ProductID,
+		ImageID:   image.ID,
+	}
+
+	return response, nil
+}
+
+// syncProductImageUrl keeps products.image_url on the primary image, order items snapshot it
+func (pis *ProductImageService) syncProductImageUrl(ctx context.Context, repos repository.Repositories, product entity.Product, url string, username string) error {
+
+	product.ImageUrl = url
+	product.UpdatedAt = time.Now()
+	product.UpdatedBy = username
+
+	return repos.Product.Update(ctx, product)
+}
+
+// deleteBlobs is best effort, a leftover file is only wasted space
+func (pis *ProductImageService) deleteBlobs(keys []string) {
+
+	for _, key := range keys {
+
+		// The request may be cancelled already, cleanup must still run
+		err := pis.blobStore.Delete(context.Background(), key)
+
+		if err != nil {
+			logrus.WithField("key", key).Error(err)
+		}
+	}
+}
+
+// findProductImage returns ErrResourceNotFound when the image belongs to another product
+func findProductImage(ctx context.Context, repos repository.Repositories, productID int64, imageID int64) (entity.ProductImage, error) {
+
+	image, err := repos.Image.FindByID(ctx, imageID)
+
+	if err != nil {
+		return image, err
+	}
+
+	if image.ProductID != productID {
+		err := fmt.Errorf("image %d not found for product %d", imageID, productID)
+		logrus.Error(err)
+		return image, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return image, nil
+}
+
+func validateImageAttributes(altText string, position *int) error {
+
+	if len(strings.TrimSpace(altText)) > maxImageAltTextLength {
+		err := fmt.Errorf("altText must not be longer than %d characters", maxImageAltTextLength)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if position != nil && *position < 0 {
+		err := errors.New("position must not be negative")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+	"github.com/jhasudungan/terraloom-core-api/internal/storage"
+)
+
+func newImageService(t *testing.T, f *fixture) (*service.ProductImageService, string) {
+
+	t.Helper()
+
+	dir := t.TempDir()
+	blobStore := storage.NewLocalBlobStore(dir, "/media")
+
+	return service.NewProductImageService(f.store, f.repos.Product, blobStore, common.NewIDGenerator()), dir
+}
+
+func pngOf(t *testing.T, width int, height int) []byte {
+
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for x := 0; x < width; x++ {
+		img.Set(x, height/2, color.RGBA{R: 200, A: 255})
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	return buffer.Bytes()
+}
+
+func uploadImage(t *testing.T, imageService *service.ProductImageService, request model.UploadProductImageRequest) model.ProductImageDTO {
+
+	t.Helper()
+
+	response, err := imageService.UploadProductImage(context.Background(), request)
+	if err != nil {
+		t.Fatalf("upload image: %v", err)
+	}
+
+	return response.Data.(model.ProductImageResponseData).Image
+}
+
+func productDetail(t *testing.T, f *fixture, productID int64) model.ProductDTO {
+
+	t.Helper()
+
+	response, err := f.productService.GetProductDetail(context.Background(), model.GetProductDetailRequest{ID: productID})
+	if err != nil {
+		t.Fatalf("product detail: %v", err)
+	}
+
+	return response.Data.(model.GetProductDetailResponseData).Product
+}
+
+func TestUploadProductImageStoresThumbnailsAndBecomesPrimary(t *testing.T) {
+
+	f := newFixture(t)
+	imageService, dir := newImageService(t, f)
+
+	uploaded := uploadImage(t, imageService, model.UploadProductImageRequest{
+		ProductID: 1,
+		Content:   pngOf(t, 600, 300),
+		AltText:   " Clay pot, front ",
+		Username:  "janestaff",
+	})
+
+	if !uploaded.IsPrimary || uploaded.Position != 0 || uploaded.AltText != "Clay pot, front" {
+		t.Fatalf("unexpected image %+v", uploaded)
+	}
+
+	if uploaded.Width != 600 || uploaded.Height != 300 {
+		t.Fatalf("expected 600x300, got %dx%d", uploaded.Width, uploaded.Height)
+	}
+
+	// The 1024px size would upscale, it is not generated
+	if len(uploaded.Sizes) != 2 {
+		t.Fatalf("expected small and medium sizes, got %+v", uploaded.Sizes)
+	}
+
+	expected := map[string][2]int{"small": {160, 80}, "medium": {480, 240}}
+
+	for _, size := range uploaded.Sizes {
+
+		if dimensions := expected[size.Size]; dimensions != [2]int{size.Width, size.Height} {
+			t.Fatalf("unexpected %s size %dx%d", size.Size, size.Width, size.Height)
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(size.URL[len("/media/"):])))
+		if err != nil {
+			t.Fatalf("read thumbnail: %v", err)
+		}
+
+		config, err := png.DecodeConfig(bytes.NewReader(content))
+		if err != nil || config.Width != size.Width || config.Height != size.Height {
+			t.Fatalf("stored %s thumbnail is %dx%d (%v)", size.Size, config.Width, config.Height, err)
+		}
+	}
+
+	detail := productDetail(t, f, 1)
+
+	if detail.ImageUrl != uploaded.URL || len(detail.Images) != 1 || detail.Images[0].ID != uploaded.ID {
+		t.Fatalf("unexpected gallery %+v", detail)
+	}
+}
+
+func TestUploadProductImageRejectsInvalidFiles(t *testing.T) {
+
+	f := newFixture(t)
+	imageService, dir := newImageService(t, f)
+
+	tests := []struct {
+		name    string
+		request model.UploadProductImageRequest
+		kind    error
+	}{
+		{"empty file", model.UploadProductImageRequest{ProductID: 1}, common.ErrValidation},
+		{"not an image", model.UploadProductImageRequest{ProductID: 1, Content: []byte("%PDF-1.4 not an image")}, common.ErrValidation},
+		{"truncated image", model.UploadProductImageRequest{ProductID: 1, Content: pngOf(t, 20, 20)[:60]}, common.ErrValidation},
+		{"too large", model.UploadProductImageRequest{ProductID: 1, Content: make([]byte, service.MaxProductImageBytes+1)}, common.ErrValidation},
+		{"unknown product", model.UploadProductImageRequest{ProductID: 99, Content: pngOf(t, 20, 20)}, common.ErrResourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := imageService.UploadProductImage(context.Background(), tt.request)
+
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("rejected uploads left %d files", len(entries))
+	}
+}
+
+func TestUpdateProductImageReplacesPrimary(t *testing.T) {
+
+	f := newFixture(t)
+	imageService, _ := newImageService(t, f)
+
+	first := uploadImage(t, imageService, model.UploadProductImageRequest{ProductID: 1, Content: pngOf(t, 40, 40)})
+	second := uploadImage(t, imageService, model.UploadProductImageRequest{ProductID: 1, Content: pngOf(t, 40, 40)})
+
+	if second.IsPrimary || second.Position != 1 {
+		t.Fatalf("second image must be appended, got %+v", second)
+	}
+
+	isPrimary := true
+	position := 0
+
+	_, err := imageService.UpdateProductImage(context.Background(), model.UpdateProductImageRequest{
+		ProductID: 1,
+		ImageID:   second.ID,
+		IsPrimary: &isPrimary,
+		Position:  &position,
+	})
+
+	if err != nil {
+		t.Fatalf("update image: %v", err)
+	}
+
+	detail := productDetail(t, f, 1)
+
+	if detail.ImageUrl != second.URL {
+		t.Fatalf("expected primary url %s, got %s", second.URL, detail.ImageUrl)
+	}
+
+	// Same position, id decides
+	if len(detail.Images) != 2 || detail.Images[0].ID != first.ID || detail.Images[0].IsPrimary || !detail.Images[1].IsPrimary {
+		t.Fatalf("unexpected gallery %+v", detail.Images)
+	}
+
+	isPrimary = false
+
+	_, err = imageService.UpdateProductImage(context.Background(), model.UpdateProductImageRequest{ProductID: 1, ImageID: second.ID, IsPrimary: &isPrimary})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	// The image belongs to product 1
+	altText := "other"
+
+	_, err = imageService.UpdateProductImage(context.Background(), model.UpdateProductImageRequest{ProductID: 2, ImageID: second.ID, AltText: &altText})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func TestDeleteProductImagePromotesNextAndRemovesFiles(t *testing.T) {
+
+	f := newFixture(t)
+	imageService, dir := newImageService(t, f)
+
+	first := uploadImage(t, imageService, model.UploadProductImageRequest{ProductID: 1, Content: pngOf(t, 300, 200)})
+	second := uploadImage(t, imageService, model.UploadProductImageRequest{ProductID: 1, Content: pngOf(t, 40, 40)})
+
+	_, err := imageService.DeleteProductImage(context.Background(), model.DeleteProductImageRequest{ProductID: 1, ImageID: first.ID})
+	if err != nil {
+		t.Fatalf("delete image: %v", err)
+	}
+
+	detail := productDetail(t, f, 1)
+
+	if len(detail.Images) != 1 || !detail.Images[0].IsPrimary || detail.ImageUrl != second.URL {
+		t.Fatalf("expected the second image to be promoted, got %+v", detail)
+	}
+
+	_, err = imageService.DeleteProductImage(context.Background(), model.DeleteProductImageRequest{ProductID: 1, ImageID: second.ID})
+	if err != nil {
+		t.Fatalf("delete image: %v", err)
+	}
+
+	detail = productDetail(t, f, 1)
+
+	if len(detail.Images) != 0 || detail.ImageUrl != "" {
+		t.Fatalf("expected an empty gallery, got %+v", detail)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "products", "1"))
+	if len(entries) != 0 {
+		t.Fatalf("deleted images left %d files", len(entries))
+	}
+}
+
+func TestGetProductsIncludesGallery(t *testing.T) {
+
+	f := newFixture(t)
+	imageService, _ := newImageService(t, f)
+
+	uploaded := uploadImage(t, imageService, model.UploadProductImageRequest{ProductID: 2, Content: pngOf(t, 40, 40), AltText: "Linen throw"})
+
+	response, err := f.productService.GetProducts(context.Background(), model.GetProductsRequest{IsActive: true})
+	if err != nil {
+		t.Fatalf("get products: %v", err)
+	}
+
+	for _, product := range response.Data.(model.GetProductsResponseData).Products {
+
+		if product.Images == nil {
+			t.Fatalf("product %d has a nil gallery", product.ID)
+		}
+
+		if product.ID == 2 && (len(product.Images) != 1 || product.Images[0].ID != uploaded.ID || product.Images[0].AltText != "Linen throw") {
+			t.Fatalf("unexpected gallery %+v", product.Images)
+		}
+	}
+}
+
+func TestAuthorizeChecksRole(t *testing.T) {
+
+	f := newFixture(t)
+
+	err := f.repos.Account.Create(context.Background(), entity.Account{Username: "janestaff", Email: "jane@example.com", IsActive: true, Role: constant.AccountRoleStaff})
+	if err != nil {
+		t.Fatalf("seed staff: %v", err)
+	}
+
+	accountService := service.NewAccountService(nil, f.repos.Account, f.notifier)
+
+	err = accountService.Authorize(context.Background(), "janestaff", constant.AccountRoleStaff, constant.AccountRoleAdmin)
+	if err != nil {
+		t.Fatalf("staff must be authorized: %v", err)
+	}
+
+	err = accountService.Authorize(context.Background(), testUsername, constant.AccountRoleStaff, constant.AccountRoleAdmin)
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	err = accountService.Authorize(context.Background(), "nobody", constant.AccountRoleStaff)
+	assertErrorKind(t, err, common.ErrAccessDenied)
+}
@@ -0,0 +1,470 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxReviewBodyLength     = 2000
+	maxModerationNoteLength = 255
+)
+
+/*
+*
+
+	Product reviews :
+	- Only an account with a FINISHED order containing the product may review it, once
+	- The author edits or deletes the review, staff publish or hide it
+	- Hidden reviews stay visible to their author and staff, the edit of a hidden review keeps it hidden
+	- products.rating_average and products.review_count summarize the published reviews,
+	  they are refreshed in the transaction of every change
+
+*
+*/
+type ProductReviewService struct {
+	txRunner                repository.TransactionRunner
+	productReviewRepository repository.ProductReviewRepository
+	productRepository       repository.ProductRepository
+}
+
+func NewProductReviewService(
+	txRunner repository.TransactionRunner,
+	productReviewRepository repository.ProductReviewRepository,
+	productRepository repository.ProductRepository) *ProductReviewService {
+	return &ProductReviewService{
+		txRunner:                txRunner,
+		productReviewRepository: productReviewRepository,
+		productRepository:       productRepository,
+	}
+}
+
+// GetProductReviews lists the published reviews of a product, newest first
+func (prs *ProductReviewService) GetProductReviews(ctx context.Context, request model.GetReviewsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	exists, err := prs.productRepository.CheckById(ctx, request.ProductID)
+
+	if err != nil {
+		return response, err
+	}
+
+	if !exists {
+		err := fmt.Errorf("product %d not found", request.ProductID)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	request.Username = ""
+	request.Status = constant.ReviewStatusPublished
+
+	return prs.getReviews(ctx, request)
+}
+
+// GetAccountReviews lists the reviews written by an account, whatever their status
+func (prs *ProductReviewService) GetAccountReviews(ctx context.Context, request model.GetReviewsRequest) (model.GeneralResponse, error) {
+
+	request.ProductID = 0
+	request.Status = ""
+
+	return prs.getReviews(ctx, request)
+}
+
+// GetReviewsForModeration lists every review for staff, optionally of one status and product
+func (prs *ProductReviewService) GetReviewsForModeration(ctx context.Context, request model.GetReviewsRequest) (model.GeneralResponse, error) {
+
+	if request.Status != "" && !isReviewStatus(request.Status) {
+		err := fmt.Errorf("status must be %s or %s", constant.ReviewStatusPublished, constant.ReviewStatusHidden)
+		logrus.Error(err)
+		return model.GeneralResponse{}, common.NewError(err, common.ErrValidation)
+	}
+
+	request.Username = ""
+
+	return prs.getReviews(ctx, request)
+}
+
+func (prs *ProductReviewService) CreateReview(ctx context.Context, request model.CreateReviewRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.ProductID <= 0 {
+		err := errors.New("productId is required")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	body, err := validateReviewContent(request.Rating, request.Body)
+
+	if err != nil {
+		return response, err
+	}
+
+	var review entity.ProductReview
+	var rating model.ProductRatingDTO
+
+	err = prs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		// Locks the product, the rating summary of a product is refreshed one change at a time
+		product, err := repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		purchased, err := repos.OrderItem.CheckFinishedPurchase(ctx, request.Username, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if !purchased {
+			err := fmt.Errorf("product %d was not delivered to %s", product.ID, request.Username)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrAccessDenied)
+		}
+
+		now := time.Now()
+
+		review, err = repos.Review.Create(ctx, entity.ProductReview{
+			ProductID:       product.ID,
+			AccountUsername: request.Username,
+			Rating:          request.Rating,
+			Body:            body,
+			Status:          constant.ReviewStatusPublished,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			CreatedBy:       request.Username,
+			UpdatedBy:       request.Username,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		rating, err = refreshProductRating(ctx, repos, product.ID)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ReviewResponseData{
+		Review:  newReviewDTO(review),
+		Product: rating,
+	}
+
+	return response, nil
+}
+
+func (prs *ProductReviewService) UpdateReview(ctx context.Context, request model.UpdateReviewRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	body, err := validateReviewContent(request.Rating, request.Body)
+
+	if err != nil {
+		return response, err
+	}
+
+	var review entity.ProductReview
+	var rating model.ProductRatingDTO
+
+	err = prs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		review, err = findOwnReview(ctx, repos, request.ReviewID, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		review.Rating = request.Rating
+		review.Body = body
+		review.UpdatedAt = time.Now()
+		review.UpdatedBy = request.Username
+
+		err = repos.Review.Update(ctx, review)
+
+		if err != nil {
+			return err
+		}
+
+		rating, err = refreshProductRating(ctx, repos, review.ProductID)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ReviewResponseData{
+		Review:  newReviewDTO(review),
+		Product: rating,
+	}
+
+	return response, nil
+}
+
+func (prs *ProductReviewService) DeleteReview(ctx context.Context, request model.DeleteReviewRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var rating model.ProductRatingDTO
+
+	err := prs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		review, err := findOwnReview(ctx, repos, request.ReviewID, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		err = repos.Review.Delete(ctx, review.ID)
+
+		if err != nil {
+			return err
+		}
+
+		rating, err = refreshProductRating(ctx, repos, review.ProductID)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.DeleteReviewResponseData{
+		ReviewID: request.ReviewID,
+		Product:  rating,
+	}
+
+	return response, nil
+}
+
+// ModerateReview publishes or hides a review, the note explains the decision to the author
+func (prs *ProductReviewService) ModerateReview(ctx context.Context, request model.ModerateReviewRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if !isReviewStatus(request.Status) {
+		err := fmt.Errorf("status must be %s or %s", constant.ReviewStatusPublished, constant.ReviewStatusHidden)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	note := strings.TrimSpace(request.Note)
+
+	if utf8.RuneCountInString(note) > maxModerationNoteLength {
+		err := fmt.Errorf("note must not be longer than %d characters", maxModerationNoteLength)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var review entity.ProductReview
+	var rating model.ProductRatingDTO
+
+	err := prs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		review, err = repos.Review.FindByID(ctx, request.ReviewID)
+
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		review.Status = request.Status
+		review.ModerationNote = note
+		review.ModeratedAt = &now
+		review.ModeratedBy = request.Username
+		review.UpdatedAt = now
+		review.UpdatedBy = request.Username
+
+		err = repos.Review.Update(ctx, review)
+
+		if err != nil {
+			return err
+		}
+
+		rating, err = refreshProductRating(ctx, repos, review.ProductID)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ReviewResponseData{
+		Review:  newReviewDTO(review),
+		Product: rating,
+	}
+
+	return response, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+func (prs *ProductReviewService) getReviews(ctx context.Context, request model.GetReviewsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := validateOffsetPagination(request.IsPaginate, request.Page, request.PerPage)
+
+	if err != nil {
+		return response, err
+	}
+
+	filter := model.ReviewFilter{
+		ProductID:       request.ProductID,
+		AccountUsername: request.Username,
+		Status:          request.Status,
+	}
+
+	paginationParams := model.PaginationParams{
+		IsPaginate: request.IsPaginate,
+		Page:       request.Page,
+		PerPage:    request.PerPage,
+	}
+
+	reviews, totalData, err := prs.productReviewRepository.FindWithFilters(ctx, filter, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	reviewsDTO := make([]model.ReviewDTO, len(reviews))
+
+	for i, review := range reviews {
+		reviewsDTO[i] = newReviewDTO(review)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetReviewsResponseData{
+		Reviews:  reviewsDTO,
+		Metadata: offsetMetadata(request.IsPaginate, request.Page, request.PerPage, totalData),
+	}
+
+	return response, nil
+}
+
+// findOwnReview locks a review of the given author
+func findOwnReview(ctx context.Context, repos repository.Repositories, reviewID int64, username string) (entity.ProductReview, error) {
+
+	review, err := repos.Review.FindByID(ctx, reviewID)
+
+	if err != nil {
+		return review, err
+	}
+
+	if review.AccountUsername != username {
+		err := fmt.Errorf("review %d does not belong to %s", reviewID, username)
+		logrus.Error(err)
+		return review, common.NewError(err, common.ErrAccessDenied)
+	}
+
+	return review, nil
+}
+
+// refreshProductRating recomputes the summary of the published reviews of a product
+func refreshProductRating(ctx context.Context, repos repository.Repositories, productID int64) (model.ProductRatingDTO, error) {
+
+	// Locks the product when the caller did not already
+	_, err := repos.Product.FindByID(ctx, productID)
+
+	if err != nil {
+		return model.ProductRatingDTO{}, err
+	}
+
+	average, count, err := repos.Review.SummarizePublished(ctx, productID)
+
+	if err != nil {
+		return model.ProductRatingDTO{}, err
+	}
+
+	err = repos.Product.UpdateRating(ctx, productID, average, count)
+
+	if err != nil {
+		return model.ProductRatingDTO{}, err
+	}
+
+	return model.ProductRatingDTO{
+		ProductID:     productID,
+		RatingAverage: average,
+		ReviewCount:   count,
+	}, nil
+}
+
+// validateReviewContent returns the trimmed body
+func validateReviewContent(rating int, body string) (string, error) {
+
+	if rating < constant.ReviewRatingMin || rating > constant.ReviewRatingMax {
+		err := fmt.Errorf("rating must be between %d and %d", constant.ReviewRatingMin, constant.ReviewRatingMax)
+		logrus.Error(err)
+		return "", common.NewError(err, common.ErrValidation)
+	}
+
+	body = strings.TrimSpace(body)
+
+	if body == "" {
+		err := errors.New("body is required")
+		logrus.Error(err)
+		return "", common.NewError(err, common.ErrValidation)
+	}
+
+	if utf8.RuneCountInString(body) > maxReviewBodyLength {
+		err := fmt.Errorf("body must not be longer than %d characters", maxReviewBodyLength)
+		logrus.Error(err)
+		return "", common.NewError(err, common.ErrValidation)
+	}
+
+	return body, nil
+}
+
+func isReviewStatus(status string) bool {
+	return status == constant.ReviewStatusPublished || status == constant.ReviewStatusHidden
+}
+
+func newReviewDTO(review entity.ProductReview) model.ReviewDTO {
+	return model.ReviewDTO{
+		ID:             review.ID,
+		ProductID:      review.ProductID,
+		Author:         review.AccountUsername,
+		Rating:         review.Rating,
+		Body:           review.Body,
+		Status:         review.Status,
+		ModerationNote: review.ModerationNote,
+		ModeratedAt:    review.ModeratedAt,
+		CreatedAt:      review.CreatedAt,
+		UpdatedAt:      review.UpdatedAt,
+	}
+}
@@ -0,0 +1,287 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+// receive submits an order of the product for the account and marks it FINISHED
+func (f *fixture) receive(t *testing.T, username string, productID int64) {
+
+	t.Helper()
+
+	product, err := f.repos.Product.FindByID(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("find product %d: %v", productID, err)
+	}
+
+	request := submitRequest(model.OrderItemRequest{ProductId: productID, PriceUsed: product.Price, Quantity: 1})
+	request.AccountUsername = username
+
+	data := f.submitOrder(t, request)
+
+	order, err := f.repos.Order.FindByID(context.Background(), data.OrderReference)
+	if err != nil {
+		t.Fatalf("find order: %v", err)
+	}
+
+	order.Status = constant.OrderStatusFinished
+
+	err = f.repos.Order.Update(context.Background(), order)
+	if err != nil {
+		t.Fatalf("finish order: %v", err)
+	}
+}
+
+func (f *fixture) addAccount(t *testing.T, username string) {
+
+	t.Helper()
+
+	err := f.repos.Account.Create(context.Background(), entity.Account{
+		Username:    username,
+		DisplayName: username,
+		Email:       username + "@example.com",
+		IsActive:    true,
+	})
+
+	if err != nil {
+		t.Fatalf("seed account: %v", err)
+	}
+}
+
+func (f *fixture) review(t *testing.T, username string, productID int64, rating int) model.ReviewResponseData {
+
+	t.Helper()
+
+	response, err := f.productReviewService.CreateReview(context.Background(), model.CreateReviewRequest{
+		ProductID: productID,
+		Rating:    rating,
+		Body:      "  Sturdy and well made  ",
+		Username:  username,
+	})
+
+	if err != nil {
+		t.Fatalf("create review: %v", err)
+	}
+
+	return response.Data.(model.ReviewResponseData)
+}
+
+func (f *fixture) productRating(t *testing.T, productID int64) (float64, int64) {
+
+	t.Helper()
+
+	response, err := f.productService.GetProductDetail(context.Background(), model.GetProductDetailRequest{ID: productID})
+	if err != nil {
+		t.Fatalf("get product detail: %v", err)
+	}
+
+	product := response.Data.(model.GetProductDetailResponseData).Product
+
+	return product.RatingAverage, product.ReviewCount
+}
+
+func TestCreateReviewRequiresFinishedOrder(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	request := model.CreateReviewRequest{ProductID: 1, Rating: 5, Body: "Lovely", Username: testUsername}
+
+	_, err := f.productReviewService.CreateReview(ctx, request)
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	// An order that is not finished yet does not count
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	_, err = f.productReviewService.CreateReview(ctx, request)
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	f.receive(t, testUsername, 1)
+
+	data := f.review(t, testUsername, 1, 4)
+
+	if data.Review.Body != "Sturdy and well made" || data.Review.Status != constant.ReviewStatusPublished || data.Review.Author != testUsername {
+		t.Fatalf("unexpected review %+v", data.Review)
+	}
+
+	if data.Product.RatingAverage != 4 || data.Product.ReviewCount != 1 {
+		t.Fatalf("unexpected rating %+v", data.Product)
+	}
+
+	_, err = f.productReviewService.CreateReview(ctx, request)
+	assertErrorKind(t, err, common.ErrConflict)
+}
+
+func TestCreateReviewValidation(t *testing.T) {
+
+	f := newFixture(t)
+
+	tests := []struct {
+		name    string
+		request model.CreateReviewRequest
+		kind    error
+	}{
+		{name: "missing product", request: model.CreateReviewRequest{Rating: 3, Body: "Fine"}, kind: common.ErrValidation},
+		{name: "rating too low", request: model.CreateReviewRequest{ProductID: 1, Rating: 0, Body: "Fine"}, kind: common.ErrValidation},
+		{name: "rating too high", request: model.CreateReviewRequest{ProductID: 1, Rating: 6, Body: "Fine"}, kind: common.ErrValidation},
+		{name: "blank body", request: model.CreateReviewRequest{ProductID: 1, Rating: 3, Body: "   "}, kind: common.ErrValidation},
+		{name: "body too long", request: model.CreateReviewRequest{ProductID: 1, Rating: 3, Body: strings.Repeat("a", 2001)}, kind: common.ErrValidation},
+		{name: "unknown product", request: model.CreateReviewRequest{ProductID: 99, Rating: 3, Body: "Fine"}, kind: common.ErrResourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.request.Username = testUsername
+			_, err := f.productReviewService.CreateReview(context.Background(), tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestOnlyTheAuthorEditsOrDeletesAReview(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.addAccount(t, "janedoe")
+	f.receive(t, testUsername, 1)
+	f.receive(t, "janedoe", 1)
+
+	mine := f.review(t, testUsername, 1, 5)
+	f.review(t, "janedoe", 1, 2)
+
+	if average, count := f.productRating(t, 1); average != 3.5 || count != 2 {
+		t.Fatalf("expected 3.5 over 2 reviews, got %v over %d", average, count)
+	}
+
+	_, err := f.productReviewService.UpdateReview(ctx, model.UpdateReviewRequest{ReviewID: mine.Review.ID, Rating: 1, Body: "Changed", Username: "janedoe"})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	_, err = f.productReviewService.DeleteReview(ctx, model.DeleteReviewRequest{ReviewID: mine.Review.ID, Username: "janedoe"})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	response, err := f.productReviewService.UpdateReview(ctx, model.UpdateReviewRequest{ReviewID: mine.Review.ID, Rating: 3, Body: "Chipped after a month", Username: testUsername})
+	if err != nil {
+		t.Fatalf("update review: %v", err)
+	}
+
+	if data := response.Data.(model.ReviewResponseData); data.Review.Rating != 3 || data.Product.RatingAverage != 2.5 {
+		t.Fatalf("unexpected update %+v", data)
+	}
+
+	_, err = f.productReviewService.DeleteReview(ctx, model.DeleteReviewRequest{ReviewID: mine.Review.ID, Username: testUsername})
+	if err != nil {
+		t.Fatalf("delete review: %v", err)
+	}
+
+	if average, count := f.productRating(t, 1); average != 2 || count != 1 {
+		t.Fatalf("expected 2 over 1 review, got %v over %d", average, count)
+	}
+
+	_, err = f.productReviewService.DeleteReview(ctx, model.DeleteReviewRequest{ReviewID: mine.Review.ID, Username: testUsername})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func TestHiddenReviewsLeaveTheRatingAndPublicListing(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.addAccount(t, "janedoe")
+	f.receive(t, testUsername, 1)
+	f.receive(t, "janedoe", 1)
+
+	spam := f.review(t, testUsername, 1, 1)
+	f.review(t, "janedoe", 1, 5)
+
+	_, err := f.productReviewService.ModerateReview(ctx, model.ModerateReviewRequest{ReviewID: spam.Review.ID, Status: "DELETED", Username: "janestaff"})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	response, err := f.productReviewService.ModerateReview(ctx, model.ModerateReviewRequest{
+		ReviewID: spam.Review.ID,
+		Status:   constant.ReviewStatusHidden,
+		Note:     "Off topic",
+		Username: "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("moderate review: %v", err)
+	}
+
+	if data := response.Data.(model.ReviewResponseData); data.Review.ModeratedAt == nil || data.Review.ModerationNote != "Off topic" || data.Product.RatingAverage != 5 || data.Product.ReviewCount != 1 {
+		t.Fatalf("unexpected moderation %+v", data)
+	}
+
+	response, err = f.productReviewService.GetProductReviews(ctx, model.GetReviewsRequest{ProductID: 1})
+	if err != nil {
+		t.Fatalf("get product reviews: %v", err)
+	}
+
+	if reviews := response.Data.(model.GetReviewsResponseData).Reviews; len(reviews) != 1 || reviews[0].Author != "janedoe" {
+		t.Fatalf("expected only the published review, got %+v", reviews)
+	}
+
+	// The author still sees the hidden review, editing it does not publish it again
+	response, err = f.productReviewService.GetAccountReviews(ctx, model.GetReviewsRequest{Username: testUsername})
+	if err != nil {
+		t.Fatalf("get account reviews: %v", err)
+	}
+
+	if reviews := response.Data.(model.GetReviewsResponseData).Reviews; len(reviews) != 1 || reviews[0].Status != constant.ReviewStatusHidden {
+		t.Fatalf("expected the hidden review, got %+v", reviews)
+	}
+
+	_, err = f.productReviewService.UpdateReview(ctx, model.UpdateReviewRequest{ReviewID: spam.Review.ID, Rating: 2, Body: "On topic now", Username: testUsername})
+	if err != nil {
+		t.Fatalf("update review: %v", err)
+	}
+
+	if average, count := f.productRating(t, 1); average != 5 || count != 1 {
+		t.Fatalf("expected the hidden review to stay out of the rating, got %v over %d", average, count)
+	}
+
+	response, err = f.productReviewService.GetReviewsForModeration(ctx, model.GetReviewsRequest{Status: constant.ReviewStatusHidden})
+	if err != nil {
+		t.Fatalf("get reviews for moderation: %v", err)
+	}
+
+	if reviews := response.Data.(model.GetReviewsResponseData).Reviews; len(reviews) != 1 || reviews[0].ID != spam.Review.ID {
+		t.Fatalf("expected the hidden review in the moderation queue, got %+v", reviews)
+	}
+}
+
+func TestGetProductsSortsByRating(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.receive(t, testUsername, 1)
+	f.receive(t, testUsername, 2)
+
+	f.review(t, testUsername, 1, 3)
+	f.review(t, testUsername, 2, 5)
+
+	response, err := f.productService.GetProducts(context.Background(), model.GetProductsRequest{
+		IsActive:   true,
+		Sort:       constant.ProductSortRating,
+		IsPaginate: true,
+		Page:       1,
+		PerPage:    10,
+	})
+
+	if err != nil {
+		t.Fatalf("get products: %v", err)
+	}
+
+	products := response.Data.(model.GetProductsResponseData).Products
+
+	if len(products) != 2 || products[0].ID != 2 || products[0].RatingAverage != 5 || products[1].ReviewCount != 1 {
+		t.Fatalf("expected product 2 first, got %+v", products)
+	}
+}
@@ -3,21 +3,50 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jhasudungan/terraloom-core-api/internal/common"
 	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
 	"github.com/jhasudungan/terraloom-core-api/internal/model"
 	"github.com/jhasudungan/terraloom-core-api/internal/repository"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	maxSearchQueryLength = 200
+	maxSearchTerms       = 8
+)
+
+// Letters and digits only, everything else (tsquery operators included) separates words
+var searchTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
 type ProductService struct {
-	productRepository *repository.ProductRepository
+	productRepository        repository.ProductRepository
+	productVariantRepository repository.ProductVariantRepository
+	productImageRepository   repository.ProductImageRepository
+	priceChangeRepository    repository.ProductPriceChangeRepository
+	cursorService            *CursorService
 }
 
-func NewProductService(productRepository *repository.ProductRepository) *ProductService {
-	return &ProductService{productRepository: productRepository}
+func NewProductService(
+	productRepository repository.ProductRepository,
+	productVariantRepository repository.ProductVariantRepository,
+	productImageRepository repository.ProductImageRepository,
+	priceChangeRepository repository.ProductPriceChangeRepository,
+	cursorService *CursorService) *ProductService {
+	return &ProductService{
+		productRepository:        productRepository,
+		productVariantRepository: productVariantRepository,
+		productImageRepository:   productImageRepository,
+		priceChangeRepository:    priceChangeRepository,
+		cursorService:            cursorService,
+	}
 }
 
 func (ps *ProductService) GetProducts(ctx context.Context, request model.GetProductsRequest) (model.GeneralResponse, error) {
@@ -41,8 +70,205 @@ func (ps *ProductService) GetProducts(ctx context.Context, request model.GetProd
 
 	// Build param for repository
 	filter := model.ProductFilter{
-		Name:     request.Name,
-		IsActive: request.IsActive,
+		Name:        request.Name,
+		IsActive:    request.IsActive,
+		MinPrice:    request.MinPrice,
+		MaxPrice:    request.MaxPrice,
+		Currency:    common.NormalizeCurrency(request.Currency),
+		CategoryIDs: request.CategoryIDs,
+		InStockOnly: request.InStockOnly,
+	}
+
+	err := ps.validateProductFilter(filter)
+
+	if err != nil {
+		return response, err
+	}
+
+	sortParams, err := ps.buildProductSort(request.Sort, request.SortDirection)
+
+	if err != nil {
+		return response, err
+	}
+
+	paginationParams, err := ps.cursorService.buildPaginationParams(
+		request.IsPaginate,
+		request.Page,
+		request.PerPage,
+		request.Mode,
+		request.Cursor,
+		request.SkipCount)
+
+	if err != nil {
+		return response, err
+	}
+
+	// Sales keep changing, a best-selling position is not stable enough for a cursor
+	if paginationParams.IsKeyset && sortParams.By == constant.ProductSortBestSelling {
+		err := errors.New("cursor mode does not support the best-selling sort")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	products, totalData, err := ps.productRepository.FindWithFilters(ctx, filter, sortParams, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	products, metadata, err := buildPage(ps.cursorService, products, totalData, paginationParams, func(product entity.Product) model.Cursor {
+		return repository.ProductCursor(product, sortParams)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+
+	err = withDuePrices(ctx, ps.priceChangeRepository, products, now)
+
+	if err != nil {
+		return response, err
+	}
+
+	productsDTO := make([]model.ProductDTO, len(products))
+
+	for i, product := range products {
+		productsDTO[i] = newListingDTO(product, filter.Currency, now)
+	}
+
+	err = ps.attachImages(ctx, productsDTO)
+
+	if err != nil {
+		return response, err
+	}
+
+	responseData := model.GetProductsResponseData{
+		Products: productsDTO,
+		Metadata: metadata,
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = responseData
+
+	return response, err
+}
+
+func (ps *ProductService) GetProductDetail(ctx context.Context, request model.GetProductDetailRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	product, err := ps.productRepository.FindByID(ctx, request.ID)
+
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+	repriced := []entity.Product{product}
+
+	err = withDuePrices(ctx, ps.priceChangeRepository, repriced, now)
+
+	if err != nil {
+		return response, err
+	}
+
+	product = repriced[0]
+	productsDTO := newProductDTO(product, now)
+
+	gallery := []model.ProductDTO{productsDTO}
+	err = ps.attachImages(ctx, gallery)
+
+	if err != nil {
+		return response, err
+	}
+
+	productsDTO = gallery[0]
+
+	optionTypes, err := ps.productVariantRepository.FindOptionTypesByProductID(ctx, product.ID)
+
+	if err != nil {
+		return response, err
+	}
+
+	variants, err := ps.productVariantRepository.FindByProductID(ctx, product.ID)
+
+	if err != nil {
+		return response, err
+	}
+
+	productsDTO.Options, productsDTO.Variants = newProductVariantsDTO(product, optionTypes, variants, now)
+
+	responseData := model.GetProductDetailResponseData{
+		Product: productsDTO,
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = responseData
+
+	return response, nil
+
+}
+
+/*
+*
+
+	Search active products by name and description :
+	- The query is split into words, every word must match (prefix match, "cer" finds "ceramic")
+	- Results are ranked by relevance with matched words wrapped in <mark></mark>
+	- When nothing matches, fall back to typo tolerant trigram similarity on the name
+
+*
+*/
+func (ps *ProductService) SearchProducts(ctx context.Context, request model.SearchProductsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.IsPaginate {
+		if request.Page < 1 {
+			err := errors.New("page must be greater than 0")
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+		if request.PerPage < 1 || request.PerPage > 100 {
+			err := errors.New("perPage must be between 1 and 100")
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+	}
+
+	query := strings.TrimSpace(request.Query)
+
+	if len(query) > maxSearchQueryLength {
+		err := fmt.Errorf("q must not be longer than %d characters", maxSearchQueryLength)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	terms := searchTerms(query)
+
+	if len(terms) == 0 {
+		err := errors.New("q must contain at least one letter or digit")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	filter := model.ProductFilter{
+		IsActive:    request.IsActive,
+		MinPrice:    request.MinPrice,
+		MaxPrice:    request.MaxPrice,
+		Currency:    common.NormalizeCurrency(request.Currency),
+		CategoryIDs: request.CategoryIDs,
+		InStockOnly: request.InStockOnly,
+	}
+
+	err := ps.validateProductFilter(filter)
+
+	if err != nil {
+		return response, err
 	}
 
 	paginationParams := model.PaginationParams{
@@ -51,7 +277,30 @@ func (ps *ProductService) GetProducts(ctx context.Context, request model.GetProd
 		PerPage:    request.PerPage,
 	}
 
-	products, totalData, err := ps.productRepository.FindWithFilters(ctx, filter, paginationParams)
+	mode := constant.SearchModeFullText
+	hits, totalData, err := ps.productRepository.SearchFullText(ctx, terms, filter, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	if totalData == 0 {
+		mode = constant.SearchModeFuzzy
+		hits, totalData, err = ps.productRepository.SearchFuzzy(ctx, strings.Join(terms, " "), filter, paginationParams)
+
+		if err != nil {
+			return response, err
+		}
+	}
+
+	now := time.Now()
+	products := make([]entity.Product, len(hits))
+
+	for i, hit := range hits {
+		products[i] = hit.Product
+	}
+
+	err = withDuePrices(ctx, ps.priceChangeRepository, products, now)
 
 	if err != nil {
 		return response, err
@@ -60,21 +309,32 @@ func (ps *ProductService) GetProducts(ctx context.Context, request model.GetProd
 	productsDTO := make([]model.ProductDTO, len(products))
 
 	for i, product := range products {
-		productsDTO[i] = model.ProductDTO{
-			ID:          product.ID,
-			Name:        product.Name,
-			CategoryID:  product.CategoryID,
-			Price:       product.Price,
-			Stock:       product.Stock,
-			IsActive:    product.IsActive,
-			Description: product.Description,
-			ImageUrl:    product.ImageUrl,
+		productsDTO[i] = newListingDTO(product, filter.Currency, now)
+	}
+
+	err = ps.attachImages(ctx, productsDTO)
+
+	if err != nil {
+		return response, err
+	}
+
+	results := make([]model.ProductSearchResultDTO, len(hits))
+
+	for i, hit := range hits {
+		results[i] = model.ProductSearchResultDTO{
+			Product: productsDTO[i],
+			Rank:    hit.Rank,
+			Highlight: model.ProductHighlightDTO{
+				Name:        hit.NameHighlight,
+				Description: hit.DescriptionSnippet,
+			},
 		}
 	}
 
 	metadata := model.MetadataDTO{}
 	metadata.Page = request.Page
 	metadata.TotalData = totalData
+	metadata.PerPage = request.PerPage
 
 	totalPage := 1
 	if request.IsPaginate && request.PerPage > 0 {
@@ -82,49 +342,291 @@ func (ps *ProductService) GetProducts(ctx context.Context, request model.GetProd
 	}
 
 	metadata.TotalPage = totalPage
-	metadata.PerPage = request.PerPage
 
-	responseData := model.GetProductsResponseData{
-		Products: productsDTO,
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.SearchProductsResponseData{
+		Query:    query,
+		Mode:     mode,
+		Results:  results,
 		Metadata: metadata,
 	}
 
-	response.ResponseCode = constant.SuccessCode
-	response.ResponseMessage = constant.SuccessMessage
-	response.Data = responseData
+	return response, nil
+}
 
-	return response, err
+func (ps *ProductService) validateProductFilter(request model.ProductFilter) error {
+
+	if request.MinPrice != nil && *request.MinPrice < 0 {
+		err := errors.New("minPrice must not be negative")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if request.MaxPrice != nil && *request.MaxPrice < 0 {
+		err := errors.New("maxPrice must not be negative")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if !common.IsSupportedCurrency(request.Currency) {
+		err := fmt.Errorf("currency is not supported: %q", request.Currency)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if request.MinPrice != nil && request.MaxPrice != nil && *request.MinPrice > *request.MaxPrice {
+		err := errors.New("minPrice must not be greater than maxPrice")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if len(request.CategoryIDs) > 50 {
+		err := errors.New("too many categories")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
 }
 
-func (ps *ProductService) GetProductDetail(ctx context.Context, request model.GetProductDetailRequest) (model.GeneralResponse, error) {
+/*
+*
 
-	response := model.GeneralResponse{}
+	Whitelisted sort and its default direction :
+	- newest       : desc (default sort)
+	- best-selling : desc
+	- rating       : desc (average of the published reviews)
+	- price        : asc
+	- name         : asc
 
-	product, err := ps.productRepository.FindByID(ctx, request.ID)
+*
+*/
+func (ps *ProductService) buildProductSort(sortBy string, direction string) (model.SortParams, error) {
+
+	defaultDirections := map[string]string{
+		constant.ProductSortNewest:      constant.SortDirectionDesc,
+		constant.ProductSortBestSelling: constant.SortDirectionDesc,
+		constant.ProductSortRating:      constant.SortDirectionDesc,
+		constant.ProductSortPrice:       constant.SortDirectionAsc,
+		constant.ProductSortName:        constant.SortDirectionAsc,
+	}
+
+	if sortBy == "" {
+		sortBy = constant.ProductSortNewest
+	}
+
+	defaultDirection, ok := defaultDirections[sortBy]
+
+	if !ok {
+		err := fmt.Errorf("unsupported sort: %s", sortBy)
+		logrus.Error(err)
+		return model.SortParams{}, common.NewError(err, common.ErrValidation)
+	}
+
+	direction = strings.ToLower(direction)
+
+	if direction == "" {
+		direction = defaultDirection
+	}
+
+	if direction != constant.SortDirectionAsc && direction != constant.SortDirectionDesc {
+		err := fmt.Errorf("unsupported sort direction: %s", direction)
+		logrus.Error(err)
+		return model.SortParams{}, common.NewError(err, common.ErrValidation)
+	}
+
+	return model.SortParams{By: sortBy, Direction: direction}, nil
+}
+
+// searchTerms lower cases the words of the query, keeping the first maxSearchTerms distinct ones
+func searchTerms(query string) []string {
+
+	var terms []string
+	seen := make(map[string]bool)
+
+	for _, term := range searchTermPattern.FindAllString(strings.ToLower(query), -1) {
+
+		if seen[term] {
+			continue
+		}
+
+		seen[term] = true
+		terms = append(terms, term)
+
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+
+	return terms
+}
+
+// attachImages fills the gallery of every product with a single query
+func (ps *ProductService) attachImages(ctx context.Context, products []model.ProductDTO) error {
+
+	productIDs := make([]int64, len(products))
+
+	for i, product := range products {
+		productIDs[i] = product.ID
+	}
+
+	images, err := ps.productImageRepository.FindByProductIDs(ctx, productIDs)
 
 	if err != nil {
-		return response, err
+		return err
 	}
 
-	productsDTO := model.ProductDTO{
+	gallery := make(map[int64][]model.ProductImageDTO)
+
+	for _, image := range images {
+		gallery[image.ProductID] = append(gallery[image.ProductID], newProductImageDTO(image))
+	}
+
+	for i := range products {
+		products[i].Images = gallery[products[i].ID]
+
+		if products[i].Images == nil {
+			products[i].Images = []model.ProductImageDTO{}
+		}
+	}
+
+	return nil
+}
+
+func newProductImageDTO(image entity.ProductImage) model.ProductImageDTO {
+
+	sizes := make([]model.ProductImageSizeDTO, len(image.Sizes))
+
+	for i, size := range image.Sizes {
+		sizes[i] = model.ProductImageSizeDTO{
+			Size:   size.Size,
+			URL:    size.URL,
+			Width:  size.Width,
+			Height: size.Height,
+		}
+	}
+
+	return model.ProductImageDTO{
+		ID:        image.ID,
+		URL:       image.URL,
+		AltText:   image.AltText,
+		Position:  image.Position,
+		IsPrimary: image.IsPrimary,
+		Width:     image.Width,
+		Height:    image.Height,
+		Sizes:     sizes,
+	}
+}
+
+// newProductDTO prices the product at the given time, the list price is struck through while on sale
+func newProductDTO(product entity.Product, now time.Time) model.ProductDTO {
+
+	productDTO := model.ProductDTO{
 		ID:          product.ID,
 		Name:        product.Name,
 		CategoryID:  product.CategoryID,
-		Price:       product.Price,
+		Price:       product.PriceAt(now),
+		Currency:    product.Currency,
 		Stock:       product.Stock,
 		IsActive:    product.IsActive,
 		Description: product.Description,
 		ImageUrl:    product.ImageUrl,
+
+		RatingAverage: product.RatingAverage,
+		ReviewCount:   product.ReviewCount,
+
+		Weight: product.Weight,
+		Length: product.Length,
+		Width:  product.Width,
+		Height: product.Height,
 	}
 
-	responseData := model.GetProductDetailResponseData{
-		Product: productsDTO,
+	if product.IsOnSale(now) {
+		originalPrice := product.Price
+		productDTO.OriginalPrice = &originalPrice
+		productDTO.SaleEndsAt = product.SaleEndsAt
 	}
 
-	response.ResponseCode = constant.SuccessCode
-	response.ResponseMessage = constant.SuccessMessage
-	response.Data = responseData
+	return productDTO
+}
 
-	return response, nil
+// newListingDTO prices the product in the listing currency, a price list entry carries no sale
+func newListingDTO(product entity.Product, currency string, now time.Time) model.ProductDTO {
+
+	productDTO := newProductDTO(product, now)
+
+	if product.Currency != currency {
+		productDTO.Price = product.ListingPrice
+		productDTO.Currency = currency
+		productDTO.OriginalPrice = nil
+		productDTO.SaleEndsAt = nil
+	}
+
+	return productDTO
+}
+
+// newProductVariantsDTO lists every option type with the values used by active variants, and the variants
+func newProductVariantsDTO(
+	product entity.Product,
+	optionTypes []entity.ProductOptionType,
+	variants []entity.ProductVariant,
+	now time.Time) ([]model.ProductOptionDTO, []model.ProductVariantDTO) {
+
+	if len(variants) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[int64][]string)
+	variantsDTO := make([]model.ProductVariantDTO, len(variants))
+
+	for i, variant := range variants {
+
+		variantsDTO[i] = newProductVariantDTO(product, variant, now)
+
+		for _, option := range variant.Options {
+			if variant.IsActive && !slices.Contains(values[option.OptionTypeID], option.Value) {
+				values[option.OptionTypeID] = append(values[option.OptionTypeID], option.Value)
+			}
+		}
+	}
+
+	optionsDTO := make([]model.ProductOptionDTO, 0, len(optionTypes))
+
+	for _, optionType := range optionTypes {
+		optionsDTO = append(optionsDTO, model.ProductOptionDTO{
+			Name:   optionType.Name,
+			Values: append([]string{}, values[optionType.ID]...),
+		})
+	}
+
+	return optionsDTO, variantsDTO
+}
+
+// newProductVariantDTO expects the options with their option type
+func newProductVariantDTO(product entity.Product, variant entity.ProductVariant, now time.Time) model.ProductVariantDTO {
+
+	variantDTO := model.ProductVariantDTO{
+		ID:       variant.ID,
+		SKU:      variant.SKU,
+		Price:    variant.EffectivePrice(product, now),
+		Stock:    variant.Stock,
+		IsActive: variant.IsActive,
+		Options:  []model.VariantOptionDTO{},
+	}
+
+	// Variants with their own price are not on sale
+	if variant.Price == nil && product.IsOnSale(now) {
+		originalPrice := product.Price
+		variantDTO.OriginalPrice = &originalPrice
+	}
+
+	for _, option := range variant.Options {
+		variantDTO.Options = append(variantDTO.Options, model.VariantOptionDTO{
+			Name:  option.OptionType.Name,
+			Value: option.Value,
+		})
+	}
 
+	return variantDTO
 }
@@ -0,0 +1,211 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+func (f *fixture) productIDs(t *testing.T, request model.GetProductsRequest) []int64 {
+
+	t.Helper()
+
+	response, err := f.productService.GetProducts(context.Background(), request)
+	if err != nil {
+		t.Fatalf("get products: %v", err)
+	}
+
+	data := response.Data.(model.GetProductsResponseData)
+	ids := make([]int64, len(data.Products))
+
+	for i, product := range data.Products {
+		ids[i] = product.ID
+	}
+
+	return ids
+}
+
+func assertIDs(t *testing.T, got []int64, want ...int64) {
+
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestGetProductsFilters(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		request model.GetProductsRequest
+		want    []int64
+	}{
+		{
+			name:    "defaults to newest first",
+			request: model.GetProductsRequest{},
+			want:    []int64{3, 2, 1},
+		},
+		{
+			name:    "active only",
+			request: model.GetProductsRequest{IsActive: true},
+			want:    []int64{2, 1},
+		},
+		{
+			name:    "price range",
+			request: model.GetProductsRequest{MinPrice: int64Ptr(20000), MaxPrice: int64Ptr(120000)},
+			want:    []int64{3, 2},
+		},
+		{
+			name:    "category",
+			request: model.GetProductsRequest{CategoryIDs: []int64{1}},
+			want:    []int64{3, 1},
+		},
+		{
+			name:    "price ascending",
+			request: model.GetProductsRequest{Sort: constant.ProductSortPrice},
+			want:    []int64{1, 3, 2},
+		},
+		{
+			name:    "name descending",
+			request: model.GetProductsRequest{Sort: constant.ProductSortName, SortDirection: "DESC"},
+			want:    []int64{3, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t)
+			assertIDs(t, f.productIDs(t, tt.request), tt.want...)
+		})
+	}
+}
+
+func TestGetProductsInStockAndBestSelling(t *testing.T) {
+
+	f := newFixture(t)
+
+	// Sell out product 2 and sell one unit of product 1
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 3}))
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	assertIDs(t, f.productIDs(t, model.GetProductsRequest{IsActive: true, InStockOnly: true}), 1)
+	assertIDs(t, f.productIDs(t, model.GetProductsRequest{IsActive: true, Sort: constant.ProductSortBestSelling}), 2, 1)
+}
+
+func TestGetProductsRejectsInvalidParameters(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		request model.GetProductsRequest
+	}{
+		{name: "unknown sort", request: model.GetProductsRequest{Sort: "random"}},
+		{name: "unknown direction", request: model.GetProductsRequest{Sort: constant.ProductSortPrice, SortDirection: "up"}},
+		{name: "inverted price range", request: model.GetProductsRequest{MinPrice: int64Ptr(10), MaxPrice: int64Ptr(5)}},
+		{name: "negative price", request: model.GetProductsRequest{MinPrice: int64Ptr(-1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newFixture(t)
+
+			_, err := f.productService.GetProducts(context.Background(), tt.request)
+
+			assertErrorKind(t, err, common.ErrValidation)
+		})
+	}
+}
+
+func (f *fixture) search(t *testing.T, query string) model.SearchProductsResponseData {
+
+	t.Helper()
+
+	response, err := f.productService.SearchProducts(context.Background(), model.SearchProductsRequest{
+		Query:    query,
+		IsActive: true,
+	})
+	if err != nil {
+		t.Fatalf("search %q: %v", query, err)
+	}
+
+	return response.Data.(model.SearchProductsResponseData)
+}
+
+func TestSearchProducts(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		query string
+		mode  string
+		want  []int64
+	}{
+		{name: "name prefix", query: "cla", mode: constant.SearchModeFullText, want: []int64{1}},
+		{name: "description word", query: "blanket", mode: constant.SearchModeFullText, want: []int64{2}},
+		{name: "unmatched word falls back to fuzzy", query: "clay blanket", mode: constant.SearchModeFuzzy, want: []int64{1}},
+		{name: "operators are ignored", query: "linen & !(throw", mode: constant.SearchModeFullText, want: []int64{2}},
+		{name: "typo falls back to fuzzy", query: "linnen", mode: constant.SearchModeFuzzy, want: []int64{2}},
+		{name: "inactive products are hidden", query: "vase", mode: constant.SearchModeFuzzy, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newFixture(t)
+
+			data := f.search(t, tt.query)
+
+			if data.Mode != tt.mode {
+				t.Fatalf("expected mode %s, got %s", tt.mode, data.Mode)
+			}
+
+			ids := make([]int64, len(data.Results))
+			for i, result := range data.Results {
+				ids[i] = result.Product.ID
+			}
+
+			assertIDs(t, ids, tt.want...)
+		})
+	}
+}
+
+func TestSearchProductsRanksNameAboveDescription(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.store.SeedProducts(entity.Product{ID: 4, CategoryID: 1, Name: "Stoneware Bowl", Description: "Pairs with any clay pot", Price: 9000, Stock: 4, IsActive: true})
+
+	data := f.search(t, "clay")
+
+	if len(data.Results) != 2 || data.Results[0].Product.ID != 1 || data.Results[1].Product.ID != 4 {
+		t.Fatalf("expected products 1 then 4, got %+v", data.Results)
+	}
+
+	if data.Results[0].Highlight.Name != "<mark>Clay</mark> Pot" {
+		t.Fatalf("unexpected name highlight %q", data.Results[0].Highlight.Name)
+	}
+}
+
+func TestSearchProductsRejectsEmptyQuery(t *testing.T) {
+
+	f := newFixture(t)
+
+	for _, query := range []string{"", "   ", "&|!"} {
+		_, err := f.productService.SearchProducts(context.Background(), model.SearchProductsRequest{Query: query})
+		assertErrorKind(t, err, common.ErrValidation)
+	}
+}
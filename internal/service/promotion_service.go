@@ -0,0 +1,495 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const maxPromotionDescriptionLength = 255
+
+var promotionCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,50}$`)
+
+/*
+*
+
+	Promotions (coupons), one coupon per order :
+	- PERCENTAGE takes value percent off the items in scope, FIXED_AMOUNT takes value off them
+	- BUY_X_GET_Y gives getQuantity units free for every buyQuantity + getQuantity units in scope,
+	  the cheapest units are the free ones
+	- FREE_SHIPPING waives the shipping fee, it discounts no item and its amount is the fee of the order
+	- The scope is a product, a category or the whole order, minOrderAmount applies to the whole order
+	- Amounts are in the currency of the promotion, a coupon is only redeemed on the orders in its currency
+	- usageLimit and perAccountLimit count the non cancelled orders that redeemed the coupon,
+	  a cancelled order gives its redemption back
+	- A coupon with a perAccountLimit is not redeemed by a guest order, guests have no account to count
+	- The coupon is redeemed in the order transaction with the promotion locked, concurrent orders
+	  never redeem it beyond its limits
+
+*
+*/
+type PromotionService struct {
+	txRunner            repository.TransactionRunner
+	promotionRepository repository.PromotionRepository
+}
+
+func NewPromotionService(txRunner repository.TransactionRunner, promotionRepository repository.PromotionRepository) *PromotionService {
+	return &PromotionService{
+		txRunner:            txRunner,
+		promotionRepository: promotionRepository,
+	}
+}
+
+func (ps *PromotionService) GetPromotions(ctx context.Context, request model.GetPromotionsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := validateOffsetPagination(request.IsPaginate, request.Page, request.PerPage)
+
+	if err != nil {
+		return response, err
+	}
+
+	paginationParams := model.PaginationParams{
+		IsPaginate: request.IsPaginate,
+		Page:       request.Page,
+		PerPage:    request.PerPage,
+	}
+
+	promotions, totalData, err := ps.promotionRepository.FindAll(ctx, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	promotionsDTO := make([]model.PromotionDTO, len(promotions))
+
+	for i, promotion := range promotions {
+		promotionsDTO[i] = newPromotionDTO(promotion)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetPromotionsResponseData{
+		Promotions: promotionsDTO,
+		Metadata:   offsetMetadata(request.IsPaginate, request.Page, request.PerPage, totalData),
+	}
+
+	return response, nil
+}
+
+func (ps *PromotionService) CreatePromotion(ctx context.Context, request model.CreatePromotionRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	promotion := entity.Promotion{
+		Code:            normalizeCouponCode(request.Code),
+		Description:     strings.TrimSpace(request.Description),
+		PromotionType:   request.Type,
+		Currency:        common.NormalizeCurrency(request.Currency),
+		MinOrderAmount:  request.MinOrderAmount,
+		UsageLimit:      request.UsageLimit,
+		PerAccountLimit: request.PerAccountLimit,
+		ProductID:       request.ProductID,
+		CategoryID:      request.CategoryID,
+		StartsAt:        request.StartsAt,
+		EndsAt:          request.EndsAt,
+		IsActive:        request.IsActive == nil || *request.IsActive,
+		CreatedAt:       time.Now(),
+		CreatedBy:       request.Username,
+		UpdatedAt:       time.Now(),
+		UpdatedBy:       request.Username,
+	}
+
+	// Only the fields of the type are kept
+	switch request.Type {
+	case constant.PromotionTypePercentage, constant.PromotionTypeFixedAmount:
+		promotion.Value = request.Value
+	case constant.PromotionTypeBuyXGetY:
+		promotion.BuyQuantity = request.BuyQuantity
+		promotion.GetQuantity = request.GetQuantity
+	}
+
+	err := validatePromotion(promotion)
+
+	if err != nil {
+		return response, err
+	}
+
+	promotion, err = ps.promotionRepository.Create(ctx, promotion)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.PromotionResponseData{
+		Promotion: newPromotionDTO(promotion),
+	}
+
+	return response, nil
+}
+
+// UpdatePromotion changes the schedule, the limits or the description, the discount itself is fixed
+func (ps *PromotionService) UpdatePromotion(ctx context.Context, request model.UpdatePromotionRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var promotion entity.Promotion
+
+	err := ps.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		promotion, err = repos.Promotion.FindByID(ctx, request.PromotionID)
+
+		if err != nil {
+			return err
+		}
+
+		if request.Description != nil {
+			promotion.Description = strings.TrimSpace(*request.Description)
+		}
+
+		if request.UsageLimit != nil {
+			promotion.UsageLimit = optionalLimit(*request.UsageLimit)
+		}
+
+		if request.PerAccountLimit != nil {
+			promotion.PerAccountLimit = optionalLimit(*request.PerAccountLimit)
+		}
+
+		if request.StartsAt != nil {
+			promotion.StartsAt = request.StartsAt
+		}
+
+		if request.EndsAt != nil {
+			promotion.EndsAt = request.EndsAt
+		}
+
+		if request.IsActive != nil {
+			promotion.IsActive = *request.IsActive
+		}
+
+		promotion.UpdatedAt = time.Now()
+		promotion.UpdatedBy = request.Username
+
+		err = validatePromotion(promotion)
+
+		if err != nil {
+			return err
+		}
+
+		return repos.Promotion.Update(ctx, promotion)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.PromotionResponseData{
+		Promotion: newPromotionDTO(promotion),
+	}
+
+	return response, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+// promotionLine is an order line as seen by the promotions
+type promotionLine struct {
+	ProductID  int64
+	CategoryID int64
+	Price      int64
+	Quantity   int64
+}
+
+// redeemCoupon checks the coupon against the order and the limits of the promotion and returns the
+// discount line with its share of each line, it runs in the order transaction and locks the promotion
+// until the order is committed. The username is empty for a guest order
+func redeemCoupon(
+	ctx context.Context,
+	repos repository.Repositories,
+	code string,
+	username string,
+	lines []promotionLine,
+	subtotal common.Money,
+	shippingFee int64,
+	now time.Time) (entity.OrderDiscount, []int64, error) {
+
+	promotion, err := repos.Promotion.FindByCode(ctx, normalizeCouponCode(code))
+
+	if err != nil {
+		if errors.Is(err, common.ErrResourceNotFound) {
+			err := fmt.Errorf("unknown coupon: %s", code)
+			logrus.Error(err)
+			return entity.OrderDiscount{}, nil, common.NewError(err, common.ErrValidation)
+		}
+		return entity.OrderDiscount{}, nil, err
+	}
+
+	if !promotion.IsRunning(now) {
+		err := fmt.Errorf("coupon is not valid: %s", promotion.Code)
+		logrus.Error(err)
+		return entity.OrderDiscount{}, nil, common.NewError(err, common.ErrValidation)
+	}
+
+	if promotion.Currency != subtotal.Currency {
+		err := fmt.Errorf("coupon %s is only valid on %s orders", promotion.Code, promotion.Currency)
+		logrus.Error(err)
+		return entity.OrderDiscount{}, nil, common.NewError(err, common.ErrValidation)
+	}
+
+	if subtotal.Amount < promotion.MinOrderAmount {
+		err := fmt.Errorf("coupon %s requires an order of at least %d", promotion.Code, promotion.MinOrderAmount)
+		logrus.Error(err)
+		return entity.OrderDiscount{}, nil, common.NewError(err, common.ErrValidation)
+	}
+
+	if promotion.PerAccountLimit != nil && username == "" {
+		err := fmt.Errorf("coupon %s is limited per account, sign in to redeem it", promotion.Code)
+		logrus.Error(err)
+		return entity.OrderDiscount{}, nil, common.NewError(err, common.ErrValidation)
+	}
+
+	total, byAccount, err := repos.Discount.CountRedemptions(ctx, promotion.ID, username)
+
+	if err != nil {
+		return entity.OrderDiscount{}, nil, err
+	}
+
+	if promotion.UsageLimit != nil && total >= *promotion.UsageLimit {
+		err := fmt.Errorf("coupon usage limit reached: %s", promotion.Code)
+		logrus.Error(err)
+		return entity.OrderDiscount{}, nil, common.NewError(err, common.ErrConflict)
+	}
+
+	if promotion.PerAccountLimit != nil && byAccount >= *promotion.PerAccountLimit {
+		err := fmt.Errorf("coupon already redeemed by the account: %s", promotion.Code)
+		logrus.Error(err)
+		return entity.OrderDiscount{}, nil, common.NewError(err, common.ErrConflict)
+	}
+
+	amount, shares, err := couponDiscount(promotion, lines, shippingFee)
+
+	if err != nil {
+		return entity.OrderDiscount{}, nil, err
+	}
+
+	return entity.OrderDiscount{
+		PromotionID:   promotion.ID,
+		Code:          promotion.Code,
+		PromotionType: promotion.PromotionType,
+		Description:   promotion.Description,
+		Amount:        amount,
+		CreatedAt:     now,
+	}, shares, nil
+}
+
+// recomputeDiscount computes again the discount of a coupon the order redeemed before it was modified.
+// The coupon is not redeemed again, its limits and validity are not checked, the order must still reach its minimum
+func recomputeDiscount(promotion entity.Promotion, lines []promotionLine, subtotal common.Money, shippingFee int64) (int64, []int64, error) {
+
+	if subtotal.Amount < promotion.MinOrderAmount {
+		err := fmt.Errorf("coupon %s requires an order of at least %d", promotion.Code, promotion.MinOrderAmount)
+		logrus.Error(err)
+		return 0, nil, common.NewError(err, common.ErrValidation)
+	}
+
+	return couponDiscount(promotion, lines, shippingFee)
+}
+
+// couponDiscount is the discount of the coupon and its share of each line, FREE_SHIPPING discounts the shipping fee
+func couponDiscount(promotion entity.Promotion, lines []promotionLine, shippingFee int64) (int64, []int64, error) {
+
+	shares, err := promotionDiscount(promotion, lines)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	amount := int64(0)
+
+	for _, share := range shares {
+		amount += share
+	}
+
+	if promotion.PromotionType == constant.PromotionTypeFreeShipping {
+		amount = shippingFee
+	}
+
+	return amount, shares, nil
+}
+
+// promotionDiscount computes the discount of each line in the scope of the promotion, never more than the line total
+func promotionDiscount(promotion entity.Promotion, lines []promotionLine) ([]int64, error) {
+
+	shares := make([]int64, len(lines))
+
+	var eligible []int
+	eligibleTotals := make([]int64, len(lines))
+	eligibleTotal := int64(0)
+	eligibleUnits := int64(0)
+
+	for i, line := range lines {
+		if promotion.Applies(line.ProductID, line.CategoryID) {
+			eligible = append(eligible, i)
+			eligibleTotals[i] = line.Price * line.Quantity
+			eligibleTotal += eligibleTotals[i]
+			eligibleUnits += line.Quantity
+		}
+	}
+
+	if len(eligible) == 0 {
+		err := fmt.Errorf("coupon %s does not apply to any item of the order", promotion.Code)
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
+	}
+
+	switch promotion.PromotionType {
+
+	case constant.PromotionTypePercentage:
+		return allocate(eligibleTotal*promotion.Value/100, eligibleTotals), nil
+
+	case constant.PromotionTypeFixedAmount:
+		return allocate(min(promotion.Value, eligibleTotal), eligibleTotals), nil
+
+	case constant.PromotionTypeBuyXGetY:
+
+		freeUnits := eligibleUnits / (promotion.BuyQuantity + promotion.GetQuantity) * promotion.GetQuantity
+
+		if freeUnits == 0 {
+			err := fmt.Errorf("coupon %s requires %d items in scope", promotion.Code, promotion.BuyQuantity+promotion.GetQuantity)
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+
+		slices.SortStableFunc(eligible, func(a int, b int) int {
+			return cmp.Compare(lines[a].Price, lines[b].Price)
+		})
+
+		for _, i := range eligible {
+			units := min(lines[i].Quantity, freeUnits)
+			shares[i] = units * lines[i].Price
+			freeUnits -= units
+		}
+	}
+
+	// FREE_SHIPPING discounts no item
+	return shares, nil
+}
+
+func validatePromotion(promotion entity.Promotion) error {
+
+	var err error
+
+	switch {
+	case !promotionCodePattern.MatchString(promotion.Code):
+		err = errors.New("code must be 3 to 50 letters, digits, '-' or '_'")
+	case utf8.RuneCountInString(promotion.Description) > maxPromotionDescriptionLength:
+		err = fmt.Errorf("description must be at most %d characters", maxPromotionDescriptionLength)
+	case !isPromotionType(promotion.PromotionType):
+		err = fmt.Errorf("type must be one of %s, %s, %s or %s",
+			constant.PromotionTypePercentage, constant.PromotionTypeFixedAmount, constant.PromotionTypeFreeShipping, constant.PromotionTypeBuyXGetY)
+	case promotion.PromotionType == constant.PromotionTypePercentage && (promotion.Value < 1 || promotion.Value > 100):
+		err = errors.New("value must be a percentage between 1 and 100")
+	case promotion.PromotionType == constant.PromotionTypeFixedAmount && promotion.Value <= 0:
+		err = errors.New("value must be greater than 0")
+	case promotion.PromotionType == constant.PromotionTypeBuyXGetY && (promotion.BuyQuantity <= 0 || promotion.GetQuantity <= 0):
+		err = errors.New("buyQuantity and getQuantity must be greater than 0")
+	case !common.IsSupportedCurrency(promotion.Currency):
+		err = fmt.Errorf("currency is not supported: %q", promotion.Currency)
+	case promotion.MinOrderAmount < 0:
+		err = errors.New("minOrderAmount must not be negative")
+	case promotion.UsageLimit != nil && *promotion.UsageLimit <= 0, promotion.PerAccountLimit != nil && *promotion.PerAccountLimit <= 0:
+		err = errors.New("usage limits must be greater than 0")
+	case promotion.ProductID != nil && promotion.CategoryID != nil:
+		err = errors.New("a promotion is scoped to a product or a category, not both")
+	case promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt):
+		err = errors.New("endsAt must be after startsAt")
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func isPromotionType(promotionType string) bool {
+	return promotionType == constant.PromotionTypePercentage ||
+		promotionType == constant.PromotionTypeFixedAmount ||
+		promotionType == constant.PromotionTypeFreeShipping ||
+		promotionType == constant.PromotionTypeBuyXGetY
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// optionalLimit turns the 0 of an update into no limit
+func optionalLimit(limit int64) *int64 {
+
+	if limit == 0 {
+		return nil
+	}
+
+	return &limit
+}
+
+func newPromotionDTO(promotion entity.Promotion) model.PromotionDTO {
+	return model.PromotionDTO{
+		ID:              promotion.ID,
+		Code:            promotion.Code,
+		Description:     promotion.Description,
+		Type:            promotion.PromotionType,
+		Value:           promotion.Value,
+		Currency:        promotion.Currency,
+		BuyQuantity:     promotion.BuyQuantity,
+		GetQuantity:     promotion.GetQuantity,
+		MinOrderAmount:  promotion.MinOrderAmount,
+		UsageLimit:      promotion.UsageLimit,
+		PerAccountLimit: promotion.PerAccountLimit,
+		ProductID:       promotion.ProductID,
+		CategoryID:      promotion.CategoryID,
+		StartsAt:        promotion.StartsAt,
+		EndsAt:          promotion.EndsAt,
+		IsActive:        promotion.IsActive,
+		CreatedAt:       promotion.CreatedAt,
+	}
+}
+
+func newOrderDiscountDTOs(discounts []entity.OrderDiscount) []model.OrderDiscountDTO {
+
+	discountsDTO := make([]model.OrderDiscountDTO, len(discounts))
+
+	for i, discount := range discounts {
+		discountsDTO[i] = model.OrderDiscountDTO{
+			Code:        discount.Code,
+			Type:        discount.PromotionType,
+			Description: discount.Description,
+			Amount:      discount.Amount,
+		}
+	}
+
+	return discountsDTO
+}
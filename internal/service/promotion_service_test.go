@@ -0,0 +1,250 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) createPromotion(t *testing.T, request model.CreatePromotionRequest) model.PromotionDTO {
+
+	t.Helper()
+
+	request.Username = "janestaff"
+
+	response, err := f.promotionService.CreatePromotion(context.Background(), request)
+	if err != nil {
+		t.Fatalf("create promotion: %v", err)
+	}
+
+	return response.Data.(model.PromotionResponseData).Promotion
+}
+
+func couponRequest(code string, username string, items ...model.OrderItemRequest) model.SubmitOrderRequest {
+
+	request := submitRequest(items...)
+	request.AccountUsername = username
+	request.CouponCode = code
+
+	return request
+}
+
+func TestCreatePromotionValidation(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "WELCOME10", Type: constant.PromotionTypePercentage, Value: 10})
+
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		request model.CreatePromotionRequest
+		kind    error
+	}{
+		{name: "invalid code", request: model.CreatePromotionRequest{Code: "no", Type: constant.PromotionTypeFreeShipping}, kind: common.ErrValidation},
+		{name: "unknown type", request: model.CreatePromotionRequest{Code: "HALF", Type: "HALF_OFF"}, kind: common.ErrValidation},
+		{name: "percentage above 100", request: model.CreatePromotionRequest{Code: "TOOMUCH", Type: constant.PromotionTypePercentage, Value: 101}, kind: common.ErrValidation},
+		{name: "fixed amount without value", request: model.CreatePromotionRequest{Code: "NOTHING", Type: constant.PromotionTypeFixedAmount}, kind: common.ErrValidation},
+		{name: "buy x get y without quantities", request: model.CreatePromotionRequest{Code: "B2G1", Type: constant.PromotionTypeBuyXGetY, BuyQuantity: 2}, kind: common.ErrValidation},
+		{name: "zero usage limit", request: model.CreatePromotionRequest{Code: "LIMITED", Type: constant.PromotionTypeFreeShipping, UsageLimit: int64Ptr(0)}, kind: common.ErrValidation},
+		{name: "product and category", request: model.CreatePromotionRequest{Code: "BOTH", Type: constant.PromotionTypeFreeShipping, ProductID: int64Ptr(1), CategoryID: int64Ptr(1)}, kind: common.ErrValidation},
+		{name: "window ends before it starts", request: model.CreatePromotionRequest{Code: "BACKWARDS", Type: constant.PromotionTypeFreeShipping, StartsAt: &now, EndsAt: &earlier}, kind: common.ErrValidation},
+		{name: "unknown product", request: model.CreatePromotionRequest{Code: "GHOST", Type: constant.PromotionTypeFreeShipping, ProductID: int64Ptr(99)}, kind: common.ErrValidation},
+		{name: "duplicate code", request: model.CreatePromotionRequest{Code: " welcome10 ", Type: constant.PromotionTypeFreeShipping}, kind: common.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.promotionService.CreatePromotion(context.Background(), tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestPercentageCouponOnlyDiscountsItsCategory(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "POTS10", Description: "10% off pots", Type: constant.PromotionTypePercentage, Value: 10, CategoryID: int64Ptr(1)})
+
+	order := f.submitOrder(t, couponRequest("pots10", testUsername,
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+	))
+
+	if order.Subtotal != 150000 || order.DiscountTotal != 3000 || order.Total != 147000 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	if len(order.Discounts) != 1 || order.Discounts[0].Code != "POTS10" || order.Discounts[0].Amount != 3000 {
+		t.Fatalf("unexpected discounts %+v", order.Discounts)
+	}
+
+	response, err := f.orderService.GetOrderDetail(ctx, model.GetOrderDetailRequest{OrderReference: order.OrderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("get order detail: %v", err)
+	}
+
+	detail := response.Data.(model.GetOrderDetailReponseData).Order
+
+	if detail.Total != 147000 || detail.Payment.Total != 147000 || len(detail.Discounts) != 1 || detail.Discounts[0].Description != "10% off pots" {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+}
+
+func TestCouponDiscounts(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "POT20K", Type: constant.PromotionTypeFixedAmount, Value: 20000, ProductID: int64Ptr(1)})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "B2G1", Type: constant.PromotionTypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "SHIPFREE", Type: constant.PromotionTypeFreeShipping})
+
+	tests := []struct {
+		name     string
+		code     string
+		items    []model.OrderItemRequest
+		discount int64
+	}{
+		{
+			name:     "fixed amount never exceeds the items in scope",
+			code:     "POT20K",
+			items:    []model.OrderItemRequest{{ProductId: 1, PriceUsed: 15000, Quantity: 1}, {ProductId: 2, PriceUsed: 120000, Quantity: 1}},
+			discount: 15000,
+		},
+		{
+			name:     "cheapest unit is free",
+			code:     "B2G1",
+			items:    []model.OrderItemRequest{{ProductId: 2, PriceUsed: 120000, Quantity: 2}, {ProductId: 1, PriceUsed: 15000, Quantity: 2}},
+			discount: 15000,
+		},
+		{
+			name:     "free shipping discounts no item",
+			code:     "SHIPFREE",
+			items:    []model.OrderItemRequest{{ProductId: 1, PriceUsed: 15000, Quantity: 1}},
+			discount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			order := f.submitOrder(t, couponRequest(tt.code, testUsername, tt.items...))
+
+			if order.DiscountTotal != tt.discount || order.Total != order.Subtotal-tt.discount || len(order.Discounts) != 1 {
+				t.Fatalf("expected a discount of %d, got %+v", tt.discount, order)
+			}
+		})
+	}
+}
+
+func TestCouponRulesRejectTheOrder(t *testing.T) {
+
+	f := newFixture(t)
+
+	past := time.Now().Add(-48 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	tomorrow := time.Now().Add(24 * time.Hour)
+	inactive := false
+
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "PAUSED", Type: constant.PromotionTypePercentage, Value: 5, IsActive: &inactive})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "SOON", Type: constant.PromotionTypePercentage, Value: 5, StartsAt: &tomorrow})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "OVER", Type: constant.PromotionTypePercentage, Value: 5, StartsAt: &past, EndsAt: &yesterday})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "BIGSPENDER", Type: constant.PromotionTypePercentage, Value: 5, MinOrderAmount: 100000})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "THROWS", Type: constant.PromotionTypePercentage, Value: 5, ProductID: int64Ptr(2)})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "B2G1", Type: constant.PromotionTypeBuyXGetY, BuyQuantity: 2, GetQuantity: 1})
+
+	for _, code := range []string{"UNKNOWN", "PAUSED", "SOON", "OVER", "BIGSPENDER", "THROWS", "B2G1"} {
+		t.Run(code, func(t *testing.T) {
+			_, err := f.orderService.SubmitOrder(context.Background(), couponRequest(code, testUsername, model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2}))
+			assertErrorKind(t, err, common.ErrValidation)
+		})
+	}
+
+	if stock := f.stockOf(t, 1); stock != 10 {
+		t.Fatalf("expected the rejected orders to reserve nothing, got stock %d", stock)
+	}
+}
+
+func TestCouponUsageLimits(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.addAccount(t, "janedoe")
+	f.addAccount(t, "jimdoe")
+
+	promotion := f.createPromotion(t, model.CreatePromotionRequest{Code: "TWICE", Type: constant.PromotionTypeFixedAmount, Value: 1000, UsageLimit: int64Ptr(2), PerAccountLimit: int64Ptr(1)})
+
+	item := model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}
+
+	first := f.submitOrder(t, couponRequest("TWICE", testUsername, item))
+
+	_, err := f.orderService.SubmitOrder(ctx, couponRequest("TWICE", testUsername, item))
+	assertErrorKind(t, err, common.ErrConflict)
+
+	f.submitOrder(t, couponRequest("TWICE", "janedoe", item))
+
+	_, err = f.orderService.SubmitOrder(ctx, couponRequest("TWICE", "jimdoe", item))
+	assertErrorKind(t, err, common.ErrConflict)
+
+	// A cancelled order gives its redemption back
+	_, err = f.orderService.CancelOrder(ctx, model.CancelOrderRequest{OrderReference: first.OrderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	f.submitOrder(t, couponRequest("TWICE", "jimdoe", item))
+
+	// Removing the limit lets everyone redeem it again, once per account
+	_, err = f.promotionService.UpdatePromotion(ctx, model.UpdatePromotionRequest{PromotionID: promotion.ID, UsageLimit: int64Ptr(0), Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("update promotion: %v", err)
+	}
+
+	f.submitOrder(t, couponRequest("TWICE", testUsername, item))
+
+	_, err = f.orderService.SubmitOrder(ctx, couponRequest("TWICE", "janedoe", item))
+	assertErrorKind(t, err, common.ErrConflict)
+}
+
+func TestUpdatePromotionPausesTheCoupon(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	promotion := f.createPromotion(t, model.CreatePromotionRequest{Code: "SPRING", Type: constant.PromotionTypePercentage, Value: 15})
+
+	paused := false
+
+	response, err := f.promotionService.UpdatePromotion(ctx, model.UpdatePromotionRequest{PromotionID: promotion.ID, IsActive: &paused, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("update promotion: %v", err)
+	}
+
+	if updated := response.Data.(model.PromotionResponseData).Promotion; updated.IsActive || updated.Value != 15 {
+		t.Fatalf("unexpected promotion %+v", updated)
+	}
+
+	_, err = f.orderService.SubmitOrder(ctx, couponRequest("SPRING", testUsername, model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	assertErrorKind(t, err, common.ErrValidation)
+
+	_, err = f.promotionService.UpdatePromotion(ctx, model.UpdatePromotionRequest{PromotionID: 99, IsActive: &paused})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	response, err = f.promotionService.GetPromotions(ctx, model.GetPromotionsRequest{Page: 1, PerPage: 10, IsPaginate: true})
+	if err != nil {
+		t.Fatalf("get promotions: %v", err)
+	}
+
+	if data := response.Data.(model.GetPromotionsResponseData); len(data.Promotions) != 1 || data.Metadata.TotalData != 1 {
+		t.Fatalf("unexpected promotions %+v", data)
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+*
+
+	Reorder, a previous order of the account rebuilt as a cart :
+	- Lines of the same product or variant add up, the items are priced at the current price in the currency of the order
+	- A line no longer sold, out of stock or without a price in the currency is reported as unavailable
+	- A line asking for more than the stock, or than the 1000 a line of SubmitOrder takes, is reduced to what can be ordered
+	- With submit the cart is submitted as a new order, every rule of SubmitOrder applies again
+	- The new order is delivered to the address of the previous one with its shipping method unless the request says otherwise
+
+*
+*/
+func (os *OrderService) Reorder(ctx context.Context, request model.ReorderRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	previous, err := os.orderRepository.FindByIDWithItems(ctx, request.OrderReference)
+
+	if err != nil {
+		return response, err
+	}
+
+	if !previous.IsOwnedBy(request.Username) {
+		err := fmt.Errorf("order %s does not belong to %s", previous.OrderReference, request.Username)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrAccessDenied)
+	}
+
+	var items, unavailable []model.ReorderItemDTO
+
+	err = os.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		items, unavailable, err = repriceOrderItems(ctx, repos, previous, time.Now())
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	responseData := model.ReorderResponseData{
+		PreviousOrderReference: previous.OrderReference,
+		Currency:               previous.Currency,
+		Items:                  items,
+		Unavailable:            unavailable,
+	}
+
+	if request.Submit {
+
+		if len(items) == 0 {
+			err := fmt.Errorf("no item of order %s can be ordered again", previous.OrderReference)
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+
+		orderResponse, err := os.SubmitOrder(ctx, newReorderSubmitRequest(request, previous, items))
+
+		if err != nil {
+			return response, err
+		}
+
+		submitted := orderResponse.Data.(model.SubmitOrderResponseData)
+		responseData.Order = &submitted
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = responseData
+
+	return response, nil
+}
+
+// maxReorderLineQuantity is the quantity SubmitOrder accepts on one line
+const maxReorderLineQuantity = 1000
+
+// repriceOrderItems prices the lines of the order at the current prices, the lines that can not be ordered are returned apart
+func repriceOrderItems(ctx context.Context, repos repository.Repositories, order entity.Order, now time.Time) ([]model.ReorderItemDTO, []model.ReorderItemDTO, error) {
+
+	var keys []lineKey
+	lines := make(map[lineKey]model.ReorderItemDTO)
+	productIDs := make(map[int64]bool)
+	variantIDs := make(map[int64]bool)
+
+	for _, orderItem := range order.OrderItems {
+
+		key := lineKey{ProductID: orderItem.ProductID}
+
+		if orderItem.VariantID != nil {
+			key.VariantID = *orderItem.VariantID
+			variantIDs[key.VariantID] = true
+		}
+
+		productIDs[key.ProductID] = true
+
+		line, exists := lines[key]
+
+		if !exists {
+			keys = append(keys, key)
+			line = model.ReorderItemDTO{
+				ProductID:     orderItem.ProductID,
+				VariantID:     orderItem.VariantID,
+				ProductName:   orderItem.ProductNameSnapshot,
+				SKU:           orderItem.SKUSnapshot,
+				ImageUrl:      orderItem.ProductImageUrlSnapshot,
+				PreviousPrice: orderItem.PriceSnapshot,
+			}
+		}
+
+		line.PreviousQuantity += orderItem.Quantity
+		lines[key] = line
+	}
+
+	productList, err := repos.Product.FindMultipleByIDs(ctx, slices.Sorted(maps.Keys(productIDs)))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = withDuePrices(ctx, repos.PriceChange, productList, now)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	products := make(map[int64]entity.Product, len(productList))
+
+	for _, product := range productList {
+		products[product.ID] = product
+	}
+
+	variantList, err := repos.Variant.FindByIDs(ctx, slices.Sorted(maps.Keys(variantIDs)))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variants := make(map[int64]entity.ProductVariant, len(variantList))
+
+	for _, variant := range variantList {
+		variants[variant.ID] = variant
+	}
+
+	priceList, err := findPriceList(ctx, repos.PriceList, slices.Sorted(maps.Keys(productIDs)), order.Currency)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items, unavailable []model.ReorderItemDTO
+
+	for _, key := range keys {
+
+		line := lines[key]
+
+		reason, err := repriceReorderLine(ctx, repos.Variant, &line, products, variants, order.Currency, priceList, now)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line.Reason = reason
+
+		if line.Quantity == 0 {
+			unavailable = append(unavailable, line)
+			continue
+		}
+
+		items = append(items, line)
+	}
+
+	return items, unavailable, nil
+}
+
+// repriceReorderLine sets the current name, price and orderable quantity of the line, a quantity of 0 means the line is unavailable for the reason returned
+func repriceReorderLine(
+	ctx context.Context,
+	variantRepo repository.ProductVariantRepository,
+	line *model.ReorderItemDTO,
+	products map[int64]entity.Product,
+	variants map[int64]entity.ProductVariant,
+	currency string,
+	priceList map[priceListKey]int64,
+	now time.Time) (string, error) {
+
+	product, exists := products[line.ProductID]
+
+	if !exists || !product.IsActive || product.IsDeleted() {
+		return constant.ReorderReasonNotSold, nil
+	}
+
+	line.ProductName = product.Name
+	line.ImageUrl = product.ImageUrl
+	stock := product.Stock
+
+	var variant *entity.ProductVariant
+
+	if line.VariantID == nil {
+
+		productVariants, err := variantRepo.FindByProductID(ctx, product.ID)
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(productVariants) > 0 {
+			return constant.ReorderReasonVariantRequired, nil
+		}
+
+	} else {
+
+		current, exists := variants[*line.VariantID]
+
+		if !exists || !current.IsActive || current.ProductID != product.ID {
+			return constant.ReorderReasonNotSold, nil
+		}
+
+		variant = &current
+		line.SKU = current.SKU
+		stock = min(stock, current.Stock)
+	}
+
+	// Out of its own currency a unit is only sold with a price list entry of the variant or of the product
+	if currency != product.Currency {
+
+		key := priceListKey{ProductID: product.ID}
+		_, listed := priceList[key]
+
+		if variant != nil {
+			_, variantListed := priceList[priceListKey{ProductID: product.ID, VariantID: variant.ID}]
+			listed = listed || variantListed
+		}
+
+		if !listed {
+			return constant.ReorderReasonNotInCurrency, nil
+		}
+	}
+
+	price, err := unitPrice(product, variant, currency, priceList, now)
+
+	if err != nil {
+		return "", err
+	}
+
+	line.Price = price.Amount
+
+	if stock <= 0 {
+		return constant.ReorderReasonOutOfStock, nil
+	}
+
+	// Merged lines may add up over the limit of a line
+	line.Quantity = min(line.PreviousQuantity, stock, maxReorderLineQuantity)
+
+	if line.Quantity < line.PreviousQuantity {
+		return constant.ReorderReasonQuantityReduced, nil
+	}
+
+	return "", nil
+}
+
+// newReorderSubmitRequest is the order of the cart, delivered like the previous order unless the request gives a delivery
+func newReorderSubmitRequest(request model.ReorderRequest, previous entity.Order, items []model.ReorderItemDTO) model.SubmitOrderRequest {
+
+	submitRequest := model.SubmitOrderRequest{
+		AccountUsername: request.Username,
+		Currency:        previous.Currency,
+		AddressID:       request.AddressID,
+		DeliveryAddress: request.DeliveryAddress,
+		Region:          request.Region,
+		ShippingMethod:  request.ShippingMethod,
+		CouponCode:      request.CouponCode,
+	}
+
+	if request.AddressID == 0 && request.DeliveryAddress == "" && request.Region == "" {
+
+		if previous.AddressID != nil {
+			submitRequest.AddressID = *previous.AddressID
+		} else {
+			submitRequest.DeliveryAddress = previous.DeliveryAddress
+			submitRequest.Region = previous.TaxRegion
+		}
+	}
+
+	if submitRequest.ShippingMethod == "" {
+		submitRequest.ShippingMethod = previous.ShippingMethod
+	}
+
+	for _, item := range items {
+
+		orderItem := model.OrderItemRequest{
+			ProductId:       item.ProductID,
+			PriceUsed:       item.Price,
+			Quantity:        item.Quantity,
+			ProductName:     item.ProductName,
+			ProductImageUrl: item.ImageUrl,
+		}
+
+		if item.VariantID != nil {
+			orderItem.VariantId = *item.VariantID
+		}
+
+		submitRequest.OrderItems = append(submitRequest.OrderItems, orderItem)
+	}
+
+	return submitRequest
+}
@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) reorder(t *testing.T, request model.ReorderRequest) model.ReorderResponseData {
+
+	t.Helper()
+
+	request.Username = testUsername
+
+	response, err := f.orderService.Reorder(context.Background(), request)
+	if err != nil {
+		t.Fatalf("reorder: %v", err)
+	}
+
+	return response.Data.(model.ReorderResponseData)
+}
+
+func TestReorderRepricesTheCartAndReportsUnavailableItems(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	previous := f.submitOrder(t, submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+		model.OrderItemRequest{ProductId: 4, VariantId: 41, PriceUsed: 90000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1},
+		model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 1},
+	))
+
+	// Since then the pot costs more with 2 left, the throw is retired and the small tee is sold out
+	f.store.SeedProducts(
+		entity.Product{ID: 1, CategoryID: 1, Name: "Clay Pot", Price: 17000, Stock: 2, ImageUrl: "https://img.example.com/1.jpg", IsActive: true},
+		entity.Product{ID: 2, CategoryID: 2, Name: "Linen Throw", Price: 120000, Stock: 2, IsActive: false},
+	)
+
+	cart := f.reorder(t, model.ReorderRequest{OrderReference: previous.OrderReference})
+
+	if cart.PreviousOrderReference != previous.OrderReference || cart.Currency != constant.DefaultCurrency || cart.Order != nil {
+		t.Fatalf("unexpected cart %+v", cart)
+	}
+
+	if len(cart.Items) != 2 || len(cart.Unavailable) != 2 {
+		t.Fatalf("expected 2 items and 2 unavailable, got %+v", cart)
+	}
+
+	pot, tee := cart.Items[0], cart.Items[1]
+
+	if pot.ProductID != 1 || pot.Quantity != 2 || pot.PreviousQuantity != 3 || pot.Price != 17000 || pot.PreviousPrice != 15000 || pot.Reason != constant.ReorderReasonQuantityReduced {
+		t.Fatalf("expected the pots added up and reduced to the stock, got %+v", pot)
+	}
+
+	if tee.VariantID == nil || *tee.VariantID != 42 || tee.Quantity != 1 || tee.Price != 95000 || tee.SKU != "TEE-M-WHITE" || tee.Reason != "" {
+		t.Fatalf("unexpected tee %+v", tee)
+	}
+
+	if cart.Unavailable[0].ProductID != 2 || cart.Unavailable[0].Reason != constant.ReorderReasonNotSold {
+		t.Fatalf("expected the retired throw, got %+v", cart.Unavailable[0])
+	}
+
+	if small := cart.Unavailable[1]; small.VariantID == nil || *small.VariantID != 41 || small.Quantity != 0 || small.Reason != constant.ReorderReasonOutOfStock {
+		t.Fatalf("expected the sold out tee, got %+v", small)
+	}
+
+	if f.stockOf(t, 1) != 2 {
+		t.Fatalf("a cart should not reserve stock")
+	}
+
+	// Submitted, the cart is a new order at the current prices
+	reordered := f.reorder(t, model.ReorderRequest{OrderReference: previous.OrderReference, Submit: true})
+
+	if reordered.Order == nil || reordered.Order.OrderReference == previous.OrderReference || reordered.Order.Total != 2*17000+95000 {
+		t.Fatalf("expected a new order of 129000, got %+v", reordered.Order)
+	}
+
+	if f.stockOf(t, 1) != 0 {
+		t.Fatalf("the new order should reserve stock")
+	}
+
+	detail := f.orderDetail(t, reordered.Order.OrderReference)
+
+	if detail.DeliveryAddress != "Jl. Merdeka 1, Jakarta" || detail.Status != constant.OrderStatusPendingPayment {
+		t.Fatalf("expected the new order delivered like the previous one, got %+v", detail)
+	}
+
+	// The items keep the current name and image of their product
+	if pot := detail.OrderItems[0].Product; pot.Name != "Clay Pot" || pot.ImageUrl != "https://img.example.com/1.jpg" {
+		t.Fatalf("expected the pot snapshots, got %+v", pot)
+	}
+
+	if tee := detail.OrderItems[1].Product; tee.Name != "Linen Tee" {
+		t.Fatalf("expected the tee snapshot, got %+v", tee)
+	}
+}
+
+func TestReorderCapsMergedLinesAtTheLineLimit(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.store.SeedProducts(entity.Product{ID: 1, CategoryID: 1, Name: "Clay Pot", Price: 15000, Stock: 5000, IsActive: true})
+
+	previous := f.submitOrder(t, submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 700},
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 700},
+	))
+
+	cart := f.reorder(t, model.ReorderRequest{OrderReference: previous.OrderReference})
+
+	if len(cart.Items) != 1 || cart.Items[0].Quantity != 1000 || cart.Items[0].PreviousQuantity != 1400 || cart.Items[0].Reason != constant.ReorderReasonQuantityReduced {
+		t.Fatalf("expected the pots reduced to 1000, got %+v", cart)
+	}
+
+	// The capped cart is a valid order
+	reordered := f.reorder(t, model.ReorderRequest{OrderReference: previous.OrderReference, Submit: true})
+
+	if reordered.Order == nil || reordered.Order.Total != 1000*15000 {
+		t.Fatalf("expected a new order of 1000 pots, got %+v", reordered.Order)
+	}
+}
+
+func TestReorderDeliversToThePreviousAddress(t *testing.T) {
+
+	f := newFixture(t)
+
+	address := f.createAddress(t, addressRequest("John Doe", false))
+
+	request := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.DeliveryAddress = ""
+	request.AddressID = address.ID
+
+	previous := f.submitOrder(t, request)
+
+	reordered := f.reorder(t, model.ReorderRequest{OrderReference: previous.OrderReference, Submit: true})
+
+	detail := f.orderDetail(t, reordered.Order.OrderReference)
+
+	if detail.Delivery == nil || detail.Delivery.RecipientName != "John Doe" || detail.TaxRegion != "ID-JK" {
+		t.Fatalf("expected the address of the previous order, got %+v", detail)
+	}
+
+	// A delivery in the request replaces the previous one
+	reordered = f.reorder(t, model.ReorderRequest{OrderReference: previous.OrderReference, Submit: true, DeliveryAddress: "Jl. Sudirman 2, Jakarta"})
+
+	if detail := f.orderDetail(t, reordered.Order.OrderReference); detail.DeliveryAddress != "Jl. Sudirman 2, Jakarta" || detail.Delivery != nil {
+		t.Fatalf("expected the delivery of the request, got %+v", detail)
+	}
+}
+
+func TestReorderReportsItemsNoLongerSoldTheSameWay(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 1, Currency: "USD", Price: 150})
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 2, Currency: "USD", Price: 1200})
+
+	request := submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 150, Quantity: 1},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 1200, Quantity: 1},
+	)
+	request.Currency = "USD"
+
+	previous := f.submitOrder(t, request)
+
+	// The pot leaves the USD price list and the throw is now sold by variant
+	f.setCurrencyPrice(t, model.SetCurrencyPriceRequest{ProductID: 1, Currency: "USD", Price: 0})
+	f.store.SeedVariants(entity.ProductVariant{ID: 21, ProductID: 2, SKU: "THROW-GREY", Stock: 2, IsActive: true})
+
+	cart := f.reorder(t, model.ReorderRequest{OrderReference: previous.OrderReference})
+
+	if cart.Currency != "USD" || len(cart.Items) != 0 || len(cart.Unavailable) != 2 {
+		t.Fatalf("expected every item unavailable, got %+v", cart)
+	}
+
+	if cart.Unavailable[0].Reason != constant.ReorderReasonNotInCurrency || cart.Unavailable[1].Reason != constant.ReorderReasonVariantRequired {
+		t.Fatalf("unexpected reasons %+v", cart.Unavailable)
+	}
+
+	// Nothing left to submit
+	_, err := f.orderService.Reorder(context.Background(), model.ReorderRequest{OrderReference: previous.OrderReference, Username: testUsername, Submit: true})
+	assertErrorKind(t, err, common.ErrValidation)
+}
+
+func TestReorderOnlyOpensOwnOrders(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.addAccount(t, "janedoe")
+
+	previous := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	guest := f.submitGuestOrder(t, guestRequest("john@example.com", model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	_, err := f.orderService.Reorder(ctx, model.ReorderRequest{OrderReference: previous.OrderReference, Username: "janedoe", Submit: true})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	_, err = f.orderService.Reorder(ctx, model.ReorderRequest{OrderReference: guest.OrderReference, Username: testUsername})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	_, err = f.orderService.Reorder(ctx, model.ReorderRequest{OrderReference: "ORDER-MISSING", Username: testUsername})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	if f.stockOf(t, 1) != 8 {
+		t.Fatalf("a refused reorder should not reserve stock")
+	}
+}
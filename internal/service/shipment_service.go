@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const maxCarrierLength = 50
+
+var trackingNumberPattern = regexp.MustCompile(`^[A-Z0-9-]{4,100}$`)
+
+/*
+*
+
+	Shipments, a paid order ships in one or more parcels :
+	- A shipment carries quantities of the order items, never more than is left to ship,
+	  a shipment without items carries everything left
+	- The order is PROCESSED while items are left to ship, SHIPPED once every item shipped
+	  and FINISHED once every shipment is delivered
+	- Staff correct the carrier, the tracking number and the dates, a delivery date is never removed
+	- Customers follow the shipments of their own orders
+
+*
+*/
+type ShipmentService struct {
+	txRunner        repository.TransactionRunner
+	orderRepository repository.OrderRepository
+	idGenerator     *common.IdGenerator
+}
+
+func NewShipmentService(txRunner repository.TransactionRunner, orderRepository repository.OrderRepository, idGenerator *common.IdGenerator) *ShipmentService {
+	return &ShipmentService{
+		txRunner:        txRunner,
+		orderRepository: orderRepository,
+		idGenerator:     idGenerator,
+	}
+}
+
+func (ss *ShipmentService) CreateShipment(ctx context.Context, request model.CreateShipmentRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	now := time.Now()
+
+	shipment := entity.Shipment{
+		Carrier:        strings.TrimSpace(request.Carrier),
+		TrackingNumber: normalizeTrackingNumber(request.TrackingNumber),
+		ShippedAt:      now,
+		CreatedAt:      now,
+		CreatedBy:      request.Username,
+		UpdatedAt:      now,
+		UpdatedBy:      request.Username,
+	}
+
+	if request.ShippedAt != nil {
+		shipment.ShippedAt = *request.ShippedAt
+	}
+
+	err := validateShipment(shipment, now)
+
+	if err != nil {
+		return response, err
+	}
+
+	for _, item := range request.Items {
+		if item.Quantity <= 0 {
+			err := errors.New("items need a quantity greater than 0")
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+	}
+
+	var order entity.Order
+
+	err = ss.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		order, err = repos.Order.FindByIDWithItems(ctx, request.OrderReference)
+
+		if err != nil {
+			return err
+		}
+
+		if order.Status != constant.OrderStatusPaymentReceived && order.Status != constant.OrderStatusProcessed {
+			err := fmt.Errorf("order %s can not ship, its status is %s", order.OrderReference, order.Status)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
+
+		if shipment.ShippedAt.Before(order.OrderDate) {
+			err := errors.New("shippedAt is before the order date")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		shipment.ShipmentReference, err = ss.idGenerator.GenerateCommonID("SHIPMENT")
+
+		if err != nil {
+			return err
+		}
+
+		shipment.OrderReference = order.OrderReference
+		shipment.Items, err = shipmentItems(order, request.Items)
+
+		if err != nil {
+			return err
+		}
+
+		err = repos.Shipment.Create(ctx, shipment)
+
+		if err != nil {
+			return err
+		}
+
+		order.Shipments = append(order.Shipments, shipment)
+
+		return updateShipmentOrderStatus(ctx, repos, order, request.Username)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ShipmentResponseData{
+		OrderReference: order.OrderReference,
+		OrderStatus:    shipmentOrderStatus(order),
+		Shipment:       newShipmentDTO(shipment, order.OrderItems),
+	}
+
+	return response, nil
+}
+
+func (ss *ShipmentService) UpdateShipment(ctx context.Context, request model.UpdateShipmentRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var order entity.Order
+	var shipment entity.Shipment
+
+	err := ss.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		shipment, err = repos.Shipment.FindByReference(ctx, request.ShipmentReference)
+
+		if err != nil {
+			return err
+		}
+
+		order, err = repos.Order.FindByIDWithItems(ctx, shipment.OrderReference)
+
+		if err != nil {
+			return err
+		}
+
+		if request.Carrier != nil {
+			shipment.Carrier = strings.TrimSpace(*request.Carrier)
+		}
+
+		if request.TrackingNumber != nil {
+			shipment.TrackingNumber = normalizeTrackingNumber(*request.TrackingNumber)
+		}
+
+		if request.ShippedAt != nil {
+			shipment.ShippedAt = *request.ShippedAt
+		}
+
+		if request.DeliveredAt != nil {
+			shipment.DeliveredAt = request.DeliveredAt
+		}
+
+		shipment.UpdatedAt = time.Now()
+		shipment.UpdatedBy = request.Username
+
+		err = validateShipment(shipment, shipment.UpdatedAt)
+
+		if err != nil {
+			return err
+		}
+
+		err = repos.Shipment.Update(ctx, shipment)
+
+		if err != nil {
+			return err
+		}
+
+		for i := range order.Shipments {
+			if order.Shipments[i].ShipmentReference == shipment.ShipmentReference {
+				order.Shipments[i] = shipment
+			}
+		}
+
+		return updateShipmentOrderStatus(ctx, repos, order, request.Username)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ShipmentResponseData{
+		OrderReference: order.OrderReference,
+		OrderStatus:    shipmentOrderStatus(order),
+		Shipment:       newShipmentDTO(shipment, order.OrderItems),
+	}
+
+	return response, nil
+}
+
+// GetOrderTracking lists the shipments of an order of the account
+func (ss *ShipmentService) GetOrderTracking(ctx context.Context, request model.GetOrderTrackingRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	order, err := ss.orderRepository.FindByIDWithItems(ctx, request.OrderReference)
+
+	if err != nil {
+		return response, err
+	}
+
+	if !order.IsOwnedBy(request.AccountUsername) {
+		err := fmt.Errorf("order %s does not belong to %s", order.OrderReference, request.AccountUsername)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrAccessDenied)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetOrderTrackingResponseData{
+		OrderReference: order.OrderReference,
+		OrderStatus:    order.Status,
+		ShippingMethod: order.ShippingMethod,
+		Shipments:      newShipmentDTOs(order.Shipments, order.OrderItems),
+	}
+
+	return response, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+// shipmentItems takes the requested quantities off what is left to ship, everything left when none is requested
+func shipmentItems(order entity.Order, requested []model.ShipmentItemRequest) ([]entity.ShipmentItem, error) {
+
+	shipped := shippedQuantities(order.Shipments)
+	remaining := make(map[string]int64, len(order.OrderItems))
+
+	for _, orderItem := range order.OrderItems {
+		remaining[orderItem.OrderItemReference] = orderItem.Quantity - shipped[orderItem.OrderItemReference]
+	}
+
+	var items []entity.ShipmentItem
+
+	if len(requested) == 0 {
+
+		for _, orderItem := range order.OrderItems {
+			if left := remaining[orderItem.OrderItemReference]; left > 0 {
+				items = append(items, entity.ShipmentItem{OrderItemReference: orderItem.OrderItemReference, Quantity: left})
+			}
+		}
+
+		if len(items) == 0 {
+			err := fmt.Errorf("order %s has nothing left to ship", order.OrderReference)
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrConflict)
+		}
+
+		return items, nil
+	}
+
+	for _, item := range requested {
+
+		left, exists := remaining[item.OrderItemReference]
+
+		if !exists {
+			err := fmt.Errorf("order item %s is not part of order %s", item.OrderItemReference, order.OrderReference)
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+
+		if item.Quantity > left {
+			err := fmt.Errorf("order item %s has %d left to ship, requested %d", item.OrderItemReference, left, item.Quantity)
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+
+		remaining[item.OrderItemReference] -= item.Quantity
+
+		// Lines of the same order item add up
+		index := slices.IndexFunc(items, func(shipmentItem entity.ShipmentItem) bool {
+			return shipmentItem.OrderItemReference == item.OrderItemReference
+		})
+
+		if index >= 0 {
+			items[index].Quantity += item.Quantity
+			continue
+		}
+
+		items = append(items, entity.ShipmentItem{OrderItemReference: item.OrderItemReference, Quantity: item.Quantity})
+	}
+
+	return items, nil
+}
+
+// shippedQuantities sums the shipped quantity of each order item
+func shippedQuantities(shipments []entity.Shipment) map[string]int64 {
+
+	shipped := make(map[string]int64)
+
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			shipped[item.OrderItemReference] += item.Quantity
+		}
+	}
+
+	return shipped
+}
+
+// shipmentOrderStatus is the status of a paid order given its shipments
+func shipmentOrderStatus(order entity.Order) string {
+
+	if len(order.Shipments) == 0 {
+		return order.Status
+	}
+
+	shipped := shippedQuantities(order.Shipments)
+
+	for _, orderItem := range order.OrderItems {
+		if shipped[orderItem.OrderItemReference] < orderItem.Quantity {
+			return constant.OrderStatusProcessed
+		}
+	}
+
+	for _, shipment := range order.Shipments {
+		if !shipment.IsDelivered() {
+			return constant.OrderStatusShipped
+		}
+	}
+
+	return constant.OrderStatusFinished
+}
+
+// updateShipmentOrderStatus moves the order to the status of its shipments
+func updateShipmentOrderStatus(ctx context.Context, repos repository.Repositories, order entity.Order, username string) error {
+
+	status := shipmentOrderStatus(order)
+
+	if status == order.Status {
+		return nil
+	}
+
+	order.Status = status
+	order.UpdatedAt = time.Now()
+	order.UpdatedBy = username
+
+	// Only the order row is written, its items and shipments are already stored
+	order.OrderItems = nil
+	order.Discounts = nil
+	order.Shipments = nil
+
+	return repos.Order.Update(ctx, order)
+}
+
+func validateShipment(shipment entity.Shipment, now time.Time) error {
+
+	var err error
+
+	switch {
+	case shipment.Carrier == "" || utf8.RuneCountInString(shipment.Carrier) > maxCarrierLength:
+		err = fmt.Errorf("carrier must be 1 to %d characters", maxCarrierLength)
+	case !trackingNumberPattern.MatchString(shipment.TrackingNumber):
+		err = errors.New("trackingNumber must be 4 to 100 letters, digits or '-'")
+	case shipment.ShippedAt.After(now):
+		err = errors.New("shippedAt must not be in the future")
+	case shipment.DeliveredAt != nil && shipment.DeliveredAt.After(now):
+		err = errors.New("deliveredAt must not be in the future")
+	case shipment.DeliveredAt != nil && shipment.DeliveredAt.Before(shipment.ShippedAt):
+		err = errors.New("deliveredAt must not be before shippedAt")
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func normalizeTrackingNumber(trackingNumber string) string {
+	return strings.ToUpper(strings.TrimSpace(trackingNumber))
+}
+
+func newShipmentDTOs(shipments []entity.Shipment, orderItems []entity.OrderItem) []model.ShipmentDTO {
+
+	shipmentsDTO := make([]model.ShipmentDTO, len(shipments))
+
+	for i, shipment := range shipments {
+		shipmentsDTO[i] = newShipmentDTO(shipment, orderItems)
+	}
+
+	return shipmentsDTO
+}
+
+func newShipmentDTO(shipment entity.Shipment, orderItems []entity.OrderItem) model.ShipmentDTO {
+
+	shipmentDTO := model.ShipmentDTO{
+		ShipmentReference: shipment.ShipmentReference,
+		Carrier:           shipment.Carrier,
+		TrackingNumber:    shipment.TrackingNumber,
+		Status:            constant.ShipmentStatusInTransit,
+		ShippedAt:         shipment.ShippedAt,
+		DeliveredAt:       shipment.DeliveredAt,
+		Items:             make([]model.ShipmentItemDTO, len(shipment.Items)),
+	}
+
+	if shipment.IsDelivered() {
+		shipmentDTO.Status = constant.ShipmentStatusDelivered
+	}
+
+	for i, item := range shipment.Items {
+
+		shipmentDTO.Items[i] = model.ShipmentItemDTO{
+			OrderItemReference: item.OrderItemReference,
+			Quantity:           item.Quantity,
+		}
+
+		for _, orderItem := range orderItems {
+			if orderItem.OrderItemReference == item.OrderItemReference {
+				shipmentDTO.Items[i].ProductName = orderItem.ProductNameSnapshot
+			}
+		}
+	}
+
+	return shipmentDTO
+}
@@ -0,0 +1,232 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+// paidOrder submits an order of the items and marks it paid, it returns the order item reference of each product
+func (f *fixture) paidOrder(t *testing.T, items ...model.OrderItemRequest) (string, map[int64]string) {
+
+	t.Helper()
+
+	order := f.submitOrder(t, submitRequest(items...))
+
+	_, err := f.paymentService.SubmitPayment(context.Background(), model.SubmitPaymentRequest{
+		OrderReference:  order.OrderReference,
+		CardHolderName:  "John Doe",
+		CardNumber:      "4111-1111-1111-1234",
+		Status:          constant.PaymentStatusReceived,
+		AccountUsername: testUsername,
+	})
+	if err != nil {
+		t.Fatalf("submit payment: %v", err)
+	}
+
+	references := map[int64]string{}
+
+	for _, item := range f.orderDetail(t, order.OrderReference).OrderItems {
+		references[item.Product.ID] = item.OrderItemReference
+	}
+
+	return order.OrderReference, references
+}
+
+func (f *fixture) createShipment(t *testing.T, request model.CreateShipmentRequest) model.ShipmentResponseData {
+
+	t.Helper()
+
+	request.Username = "janestaff"
+
+	response, err := f.shipmentService.CreateShipment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("create shipment: %v", err)
+	}
+
+	return response.Data.(model.ShipmentResponseData)
+}
+
+func (f *fixture) deliverShipment(t *testing.T, shipmentReference string) model.ShipmentResponseData {
+
+	t.Helper()
+
+	deliveredAt := time.Now()
+
+	response, err := f.shipmentService.UpdateShipment(context.Background(), model.UpdateShipmentRequest{ShipmentReference: shipmentReference, DeliveredAt: &deliveredAt, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("deliver shipment: %v", err)
+	}
+
+	return response.Data.(model.ShipmentResponseData)
+}
+
+func TestShipmentsMoveTheOrderToShippedThenFinished(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	orderReference, items := f.paidOrder(t,
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 3},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+	)
+
+	first := f.createShipment(t, model.CreateShipmentRequest{
+		OrderReference: orderReference,
+		Carrier:        "JNE",
+		TrackingNumber: " jne-0001 ",
+		Items:          []model.ShipmentItemRequest{{OrderItemReference: items[1], Quantity: 1}, {OrderItemReference: items[1], Quantity: 1}},
+	})
+
+	if first.OrderStatus != constant.OrderStatusProcessed || first.Shipment.TrackingNumber != "JNE-0001" || first.Shipment.Status != constant.ShipmentStatusInTransit {
+		t.Fatalf("unexpected shipment %+v", first)
+	}
+
+	if len(first.Shipment.Items) != 1 || first.Shipment.Items[0].Quantity != 2 || first.Shipment.Items[0].OrderItemReference != items[1] {
+		t.Fatalf("unexpected shipment items %+v", first.Shipment.Items)
+	}
+
+	// A partly shipped order can not be cancelled
+	_, err := f.orderService.CancelOrder(ctx, model.CancelOrderRequest{OrderReference: orderReference, AccountUsername: testUsername})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	// Everything left ships without items
+	second := f.createShipment(t, model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "SiCepat", TrackingNumber: "SC-0002"})
+
+	if second.OrderStatus != constant.OrderStatusShipped || len(second.Shipment.Items) != 2 {
+		t.Fatalf("unexpected shipment %+v", second)
+	}
+
+	detail := f.orderDetail(t, orderReference)
+
+	if detail.Status != constant.OrderStatusShipped || len(detail.Shipments) != 2 {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	for _, item := range detail.OrderItems {
+		if item.ShippedQuantity != item.Quantity {
+			t.Fatalf("expected every item shipped, got %+v", item)
+		}
+	}
+
+	if delivered := f.deliverShipment(t, first.Shipment.ShipmentReference); delivered.OrderStatus != constant.OrderStatusShipped || delivered.Shipment.Status != constant.ShipmentStatusDelivered {
+		t.Fatalf("expected the order shipped until every shipment is delivered, got %+v", delivered)
+	}
+
+	if delivered := f.deliverShipment(t, second.Shipment.ShipmentReference); delivered.OrderStatus != constant.OrderStatusFinished {
+		t.Fatalf("expected the order finished, got %+v", delivered)
+	}
+
+	response, err := f.shipmentService.GetOrderTracking(ctx, model.GetOrderTrackingRequest{OrderReference: orderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("get order tracking: %v", err)
+	}
+
+	tracking := response.Data.(model.GetOrderTrackingResponseData)
+
+	if tracking.OrderStatus != constant.OrderStatusFinished || len(tracking.Shipments) != 2 || tracking.Shipments[1].DeliveredAt == nil {
+		t.Fatalf("unexpected tracking %+v", tracking)
+	}
+}
+
+func TestCreateShipmentValidation(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	orderReference, items := f.paidOrder(t, model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2})
+	unpaid := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	f.createShipment(t, model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE-0001", Items: []model.ShipmentItemRequest{{OrderItemReference: items[1], Quantity: 1}}})
+
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		request model.CreateShipmentRequest
+		kind    error
+	}{
+		{name: "unpaid order", request: model.CreateShipmentRequest{OrderReference: unpaid.OrderReference, Carrier: "JNE", TrackingNumber: "JNE-0002"}, kind: common.ErrConflict},
+		{name: "unknown order", request: model.CreateShipmentRequest{OrderReference: "ORDER-GHOST", Carrier: "JNE", TrackingNumber: "JNE-0002"}, kind: common.ErrResourceNotFound},
+		{name: "missing carrier", request: model.CreateShipmentRequest{OrderReference: orderReference, Carrier: " ", TrackingNumber: "JNE-0002"}, kind: common.ErrValidation},
+		{name: "invalid tracking number", request: model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE 0002"}, kind: common.ErrValidation},
+		{name: "shipped in the future", request: model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE-0002", ShippedAt: &future}, kind: common.ErrValidation},
+		{name: "more than left to ship", request: model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE-0002", Items: []model.ShipmentItemRequest{{OrderItemReference: items[1], Quantity: 2}}}, kind: common.ErrValidation},
+		{name: "item of another order", request: model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE-0002", Items: []model.ShipmentItemRequest{{OrderItemReference: "ITEM-GHOST", Quantity: 1}}}, kind: common.ErrValidation},
+		{name: "tracking number of the carrier", request: model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "jne", TrackingNumber: "jne-0001"}, kind: common.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.shipmentService.CreateShipment(ctx, tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	// Nothing is left once everything shipped
+	f.createShipment(t, model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE-0002"})
+
+	_, err := f.shipmentService.CreateShipment(ctx, model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE-0003"})
+	assertErrorKind(t, err, common.ErrConflict)
+}
+
+func TestUpdateShipmentValidation(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	orderReference, _ := f.paidOrder(t, model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	shipment := f.createShipment(t, model.CreateShipmentRequest{OrderReference: orderReference, Carrier: "JNE", TrackingNumber: "JNE-0001"}).Shipment
+
+	before := shipment.ShippedAt.Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	_, err := f.shipmentService.UpdateShipment(ctx, model.UpdateShipmentRequest{ShipmentReference: shipment.ShipmentReference, DeliveredAt: &before})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	_, err = f.shipmentService.UpdateShipment(ctx, model.UpdateShipmentRequest{ShipmentReference: shipment.ShipmentReference, DeliveredAt: &future})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	_, err = f.shipmentService.UpdateShipment(ctx, model.UpdateShipmentRequest{ShipmentReference: "SHIPMENT-GHOST", Carrier: stringPtr("JNE")})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	// The tracking number is corrected without changing the order
+	response, err := f.shipmentService.UpdateShipment(ctx, model.UpdateShipmentRequest{ShipmentReference: shipment.ShipmentReference, TrackingNumber: stringPtr("jne-0009")})
+	if err != nil {
+		t.Fatalf("update shipment: %v", err)
+	}
+
+	if updated := response.Data.(model.ShipmentResponseData); updated.Shipment.TrackingNumber != "JNE-0009" || updated.OrderStatus != constant.OrderStatusShipped {
+		t.Fatalf("unexpected shipment %+v", updated)
+	}
+}
+
+func TestOrderTrackingBelongsToTheAccount(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	err := f.repos.Account.Create(ctx, entity.Account{Username: "janedoe", Email: "jane@example.com", IsActive: true})
+	if err != nil {
+		t.Fatalf("seed account: %v", err)
+	}
+
+	orderReference, _ := f.paidOrder(t, model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+
+	_, err = f.shipmentService.GetOrderTracking(ctx, model.GetOrderTrackingRequest{OrderReference: orderReference, AccountUsername: "janedoe"})
+	assertErrorKind(t, err, common.ErrAccessDenied)
+
+	response, err := f.shipmentService.GetOrderTracking(ctx, model.GetOrderTrackingRequest{OrderReference: orderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("get order tracking: %v", err)
+	}
+
+	if tracking := response.Data.(model.GetOrderTrackingResponseData); tracking.OrderStatus != constant.OrderStatusPaymentReceived || len(tracking.Shipments) != 0 {
+		t.Fatalf("unexpected tracking %+v", tracking)
+	}
+}
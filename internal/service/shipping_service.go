@@ -0,0 +1,629 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const maxShippingNameLength = 100
+
+var shippingCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{2,50}$`)
+
+/*
+*
+
+	Shipping, the parcel of an order is priced by the methods serving its region :
+	- The parcel weight is the sum of the shipping weight of the units, the volumetric weight
+	  (length * width * height / 5000) of a unit when it is bulkier than heavy, in grams
+	- FLAT_RATE charges flatFee, WEIGHT_TIERED the fee of the first tier whose upToWeight carries the parcel,
+	  a parcel heavier than the last tier or than maxWeight is not carried
+	- A cart whose items subtotal reaches freeAbove ships for free, the subtotal is taken before discounts
+	  like the minimum of the coupons
+	- The order keeps the method code, the parcel weight and the fee, later changes never change an order
+	- Once a method is active an order must select one, a FREE_SHIPPING coupon discounts its fee
+	- Fees are in the currency of the method, a method only carries the orders in its currency
+
+*
+*/
+type ShippingService struct {
+	txRunner                 repository.TransactionRunner
+	shippingMethodRepository repository.ShippingMethodRepository
+	productRepository        repository.ProductRepository
+	variantRepository        repository.ProductVariantRepository
+	priceChangeRepository    repository.ProductPriceChangeRepository
+	priceListRepository      repository.ProductCurrencyPriceRepository
+}
+
+func NewShippingService(
+	txRunner repository.TransactionRunner,
+	shippingMethodRepository repository.ShippingMethodRepository,
+	productRepository repository.ProductRepository,
+	variantRepository repository.ProductVariantRepository,
+	priceChangeRepository repository.ProductPriceChangeRepository,
+	priceListRepository repository.ProductCurrencyPriceRepository) *ShippingService {
+	return &ShippingService{
+		txRunner:                 txRunner,
+		shippingMethodRepository: shippingMethodRepository,
+		productRepository:        productRepository,
+		variantRepository:        variantRepository,
+		priceChangeRepository:    priceChangeRepository,
+		priceListRepository:      priceListRepository,
+	}
+}
+
+func (ss *ShippingService) GetShippingMethods(ctx context.Context, request model.GetShippingMethodsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := validateOffsetPagination(request.IsPaginate, request.Page, request.PerPage)
+
+	if err != nil {
+		return response, err
+	}
+
+	paginationParams := model.PaginationParams{
+		IsPaginate: request.IsPaginate,
+		Page:       request.Page,
+		PerPage:    request.PerPage,
+	}
+
+	methods, totalData, err := ss.shippingMethodRepository.FindAll(ctx, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	methodsDTO := make([]model.ShippingMethodDTO, len(methods))
+
+	for i, method := range methods {
+		methodsDTO[i] = newShippingMethodDTO(method)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetShippingMethodsResponseData{
+		ShippingMethods: methodsDTO,
+		Metadata:        offsetMetadata(request.IsPaginate, request.Page, request.PerPage, totalData),
+	}
+
+	return response, nil
+}
+
+func (ss *ShippingService) CreateShippingMethod(ctx context.Context, request model.CreateShippingMethodRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	method := entity.ShippingMethod{
+		Code:       normalizeCouponCode(request.Code),
+		Name:       strings.TrimSpace(request.Name),
+		MethodType: request.Type,
+		Currency:   common.NormalizeCurrency(request.Currency),
+		FreeAbove:  request.FreeAbove,
+		MaxWeight:  request.MaxWeight,
+		IsActive:   request.IsActive == nil || *request.IsActive,
+		CreatedAt:  time.Now(),
+		CreatedBy:  request.Username,
+		UpdatedAt:  time.Now(),
+		UpdatedBy:  request.Username,
+	}
+
+	if request.Region != nil {
+		region := normalizeTaxRegion(*request.Region)
+		method.Region = &region
+	}
+
+	// Only the fee of the type is kept
+	switch request.Type {
+	case constant.ShippingTypeFlatRate:
+		method.FlatFee = request.FlatFee
+	case constant.ShippingTypeWeightTiered:
+		method.Tiers = newShippingTiers(request.Tiers)
+	}
+
+	err := validateShippingMethod(method)
+
+	if err != nil {
+		return response, err
+	}
+
+	method, err = ss.shippingMethodRepository.Create(ctx, method)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ShippingMethodResponseData{
+		ShippingMethod: newShippingMethodDTO(method),
+	}
+
+	return response, nil
+}
+
+// UpdateShippingMethod changes the name, the fee, the limits or the activation, the code, type and region are fixed
+func (ss *ShippingService) UpdateShippingMethod(ctx context.Context, request model.UpdateShippingMethodRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var method entity.ShippingMethod
+
+	err := ss.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		method, err = repos.Shipping.FindByID(ctx, request.ShippingMethodID)
+
+		if err != nil {
+			return err
+		}
+
+		if request.Name != nil {
+			method.Name = strings.TrimSpace(*request.Name)
+		}
+
+		if request.FlatFee != nil && method.MethodType == constant.ShippingTypeFlatRate {
+			method.FlatFee = *request.FlatFee
+		}
+
+		if request.Tiers != nil && method.MethodType == constant.ShippingTypeWeightTiered {
+			method.Tiers = newShippingTiers(request.Tiers)
+		}
+
+		if request.FreeAbove != nil {
+			method.FreeAbove = optionalLimit(*request.FreeAbove)
+		}
+
+		if request.MaxWeight != nil {
+			method.MaxWeight = optionalLimit(*request.MaxWeight)
+		}
+
+		if request.IsActive != nil {
+			method.IsActive = *request.IsActive
+		}
+
+		method.UpdatedAt = time.Now()
+		method.UpdatedBy = request.Username
+
+		err = validateShippingMethod(method)
+
+		if err != nil {
+			return err
+		}
+
+		return repos.Shipping.Update(ctx, method)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ShippingMethodResponseData{
+		ShippingMethod: newShippingMethodDTO(method),
+	}
+
+	return response, nil
+}
+
+// UpdateProductDimensions sets the parcel size of one unit of the product
+func (ss *ShippingService) UpdateProductDimensions(ctx context.Context, request model.UpdateProductDimensionsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.Weight < 0 || request.Length < 0 || request.Width < 0 || request.Height < 0 {
+		err := errors.New("weight and dimensions must not be negative")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var product entity.Product
+
+	err := ss.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		err := repos.Product.UpdateDimensions(ctx, request.ProductID, request.Weight, request.Length, request.Width, request.Height, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		product, err = repos.Product.FindByID(ctx, request.ProductID)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.ProductDimensionsResponseData{
+		ProductID:      product.ID,
+		Weight:         product.Weight,
+		Length:         product.Length,
+		Width:          product.Width,
+		Height:         product.Height,
+		ShippingWeight: product.ShippingWeight(),
+	}
+
+	return response, nil
+}
+
+// QuoteShipping lists the methods able to carry the cart to the region, at the prices of the moment
+func (ss *ShippingService) QuoteShipping(ctx context.Context, request model.QuoteShippingRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	region := normalizeTaxRegion(request.Region)
+	currency := common.NormalizeCurrency(request.Currency)
+
+	err := validateShippingQuote(request, region, currency)
+
+	if err != nil {
+		return response, err
+	}
+
+	productIDs := make([]int64, 0, len(request.Items))
+	variantIDs := make([]int64, 0, len(request.Items))
+
+	for _, item := range request.Items {
+
+		productIDs = append(productIDs, item.ProductID)
+
+		if item.VariantID != 0 {
+			variantIDs = append(variantIDs, item.VariantID)
+		}
+	}
+
+	products, err := ss.productRepository.FindMultipleByIDs(ctx, productIDs)
+
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+
+	err = withDuePrices(ctx, ss.priceChangeRepository, products, now)
+
+	if err != nil {
+		return response, err
+	}
+
+	productMap := make(map[int64]entity.Product, len(products))
+
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	variantMap := make(map[int64]entity.ProductVariant, len(variantIDs))
+
+	if len(variantIDs) > 0 {
+
+		variants, err := ss.variantRepository.FindByIDs(ctx, variantIDs)
+
+		if err != nil {
+			return response, err
+		}
+
+		for _, variant := range variants {
+			variantMap[variant.ID] = variant
+		}
+	}
+
+	priceList, err := findPriceList(ctx, ss.priceListRepository, productIDs, currency)
+
+	if err != nil {
+		return response, err
+	}
+
+	subtotal := common.NewMoney(0, currency)
+	weight := int64(0)
+
+	for _, item := range request.Items {
+
+		product, exists := productMap[item.ProductID]
+
+		if !exists || !product.IsActive {
+			err := fmt.Errorf("product is not available: %d", item.ProductID)
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+
+		var variant *entity.ProductVariant
+
+		if item.VariantID != 0 {
+
+			found, exists := variantMap[item.VariantID]
+
+			if !exists || found.ProductID != product.ID || !found.IsActive {
+				err := fmt.Errorf("variant is not available: %d", item.VariantID)
+				logrus.Error(err)
+				return response, common.NewError(err, common.ErrValidation)
+			}
+
+			variant = &found
+		}
+
+		price, err := unitPrice(product, variant, currency, priceList, now)
+
+		if err != nil {
+			return response, err
+		}
+
+		subtotal, err = subtotal.Add(common.NewMoney(price.Amount*item.Quantity, price.Currency))
+
+		if err != nil {
+			return response, err
+		}
+
+		weight += product.ShippingWeight() * item.Quantity
+	}
+
+	methods, err := ss.shippingMethodRepository.FindActive(ctx)
+
+	if err != nil {
+		return response, err
+	}
+
+	quotes := shippingQuotes(methods, region, weight, subtotal)
+	quotesDTO := make([]model.ShippingQuoteDTO, len(quotes))
+
+	for i, quote := range quotes {
+		quotesDTO[i] = model.ShippingQuoteDTO{
+			Code:   quote.Method.Code,
+			Name:   quote.Method.Name,
+			Type:   quote.Method.MethodType,
+			Fee:    quote.Fee,
+			IsFree: quote.Fee == 0,
+		}
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.QuoteShippingResponseData{
+		Currency: currency,
+		Subtotal: subtotal.Amount,
+		Weight:   weight,
+		Methods:  quotesDTO,
+	}
+
+	return response, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+// shippingQuote is a method able to carry a parcel and its fee
+type shippingQuote struct {
+	Method entity.ShippingMethod
+	Fee    int64
+}
+
+// shippingQuotes prices the parcel with every method serving the region in the currency of the subtotal, cheapest first
+func shippingQuotes(methods []entity.ShippingMethod, region string, weight int64, subtotal common.Money) []shippingQuote {
+
+	quotes := []shippingQuote{}
+
+	for _, method := range methods {
+		if fee, carried := shippingFee(method, region, weight, subtotal); carried {
+			quotes = append(quotes, shippingQuote{Method: method, Fee: fee})
+		}
+	}
+
+	slices.SortStableFunc(quotes, func(a shippingQuote, b shippingQuote) int {
+		return cmp.Compare(a.Fee, b.Fee)
+	})
+
+	return quotes
+}
+
+// shippingFee is the fee of the parcel, false when the method does not carry it
+func shippingFee(method entity.ShippingMethod, region string, weight int64, subtotal common.Money) (int64, bool) {
+
+	if !method.IsActive || method.Currency != subtotal.Currency || !method.Serves(region) {
+		return 0, false
+	}
+
+	if method.MaxWeight != nil && weight > *method.MaxWeight {
+		return 0, false
+	}
+
+	fee := method.FlatFee
+
+	if method.MethodType == constant.ShippingTypeWeightTiered {
+
+		index := slices.IndexFunc(method.Tiers, func(tier entity.ShippingTier) bool {
+			return weight <= tier.UpToWeight
+		})
+
+		if index < 0 {
+			return 0, false
+		}
+
+		fee = method.Tiers[index].Fee
+	}
+
+	if method.FreeAbove != nil && subtotal.Amount >= *method.FreeAbove {
+		return 0, true
+	}
+
+	return fee, true
+}
+
+// selectShippingMethod prices the order parcel with the selected method, nil when no method is configured
+func selectShippingMethod(ctx context.Context, repos repository.Repositories, code string, region string, weight int64, subtotal common.Money) (*shippingQuote, error) {
+
+	methods, err := repos.Shipping.FindActive(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	code = normalizeCouponCode(code)
+
+	if code == "" {
+
+		// Only the methods of the order currency can carry it
+		if slices.ContainsFunc(methods, func(method entity.ShippingMethod) bool { return method.Currency == subtotal.Currency }) {
+			err := errors.New("shippingMethod is required")
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+
+		return nil, nil
+	}
+
+	for _, quote := range shippingQuotes(methods, region, weight, subtotal) {
+		if quote.Method.Code == code {
+			return &quote, nil
+		}
+	}
+
+	err = fmt.Errorf("shipping method %s does not carry the %s order to region %q (%d g)", code, subtotal.Currency, region, weight)
+	logrus.Error(err)
+
+	return nil, common.NewError(err, common.ErrValidation)
+}
+
+// parcelWeight sums the shipping weight of the ordered units
+func parcelWeight(orderItems []entity.OrderItem, products map[int64]entity.Product) int64 {
+
+	weight := int64(0)
+
+	for _, orderItem := range orderItems {
+		product := products[orderItem.ProductID]
+		weight += product.ShippingWeight() * orderItem.Quantity
+	}
+
+	return weight
+}
+
+func validateShippingMethod(method entity.ShippingMethod) error {
+
+	var err error
+
+	switch {
+	case !shippingCodePattern.MatchString(method.Code):
+		err = errors.New("code must be 2 to 50 letters, digits, '-' or '_'")
+	case method.Name == "" || utf8.RuneCountInString(method.Name) > maxShippingNameLength:
+		err = fmt.Errorf("name must be 1 to %d characters", maxShippingNameLength)
+	case method.MethodType != constant.ShippingTypeFlatRate && method.MethodType != constant.ShippingTypeWeightTiered:
+		err = fmt.Errorf("type must be %s or %s", constant.ShippingTypeFlatRate, constant.ShippingTypeWeightTiered)
+	case !common.IsSupportedCurrency(method.Currency):
+		err = fmt.Errorf("currency is not supported: %q", method.Currency)
+	case method.FlatFee < 0:
+		err = errors.New("flatFee must not be negative")
+	case method.MethodType == constant.ShippingTypeWeightTiered && len(method.Tiers) == 0:
+		err = errors.New("tiers are required")
+	case method.Region != nil && !taxRegionPattern.MatchString(*method.Region):
+		err = errors.New("region must be 2 to 10 letters, digits or '-'")
+	case method.FreeAbove != nil && *method.FreeAbove <= 0, method.MaxWeight != nil && *method.MaxWeight <= 0:
+		err = errors.New("freeAbove and maxWeight must be greater than 0")
+	}
+
+	for i, tier := range method.Tiers {
+
+		if err != nil {
+			break
+		}
+
+		switch {
+		case tier.UpToWeight <= 0 || tier.Fee < 0:
+			err = errors.New("tiers need an upToWeight greater than 0 and a fee not negative")
+		case i > 0 && tier.UpToWeight <= method.Tiers[i-1].UpToWeight:
+			err = errors.New("tiers must be sorted by ascending upToWeight")
+		}
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func validateShippingQuote(request model.QuoteShippingRequest, region string, currency string) error {
+
+	var err error
+
+	switch {
+	case len(request.Items) == 0:
+		err = errors.New("empty items")
+	case len(request.Items) > 100:
+		err = errors.New("too many items")
+	case region != "" && !taxRegionPattern.MatchString(region):
+		err = errors.New("region must be 2 to 10 letters, digits or '-'")
+	case !common.IsSupportedCurrency(currency):
+		err = fmt.Errorf("currency is not supported: %q", request.Currency)
+	}
+
+	for _, item := range request.Items {
+
+		if err != nil {
+			break
+		}
+
+		if item.ProductID <= 0 || item.VariantID < 0 || item.Quantity <= 0 || item.Quantity > 1000 {
+			err = errors.New("items need a productId and a quantity between 1 and 1000")
+		}
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func newShippingTiers(tiers []model.ShippingTierDTO) entity.ShippingTiers {
+
+	shippingTiers := make(entity.ShippingTiers, len(tiers))
+
+	for i, tier := range tiers {
+		shippingTiers[i] = entity.ShippingTier{UpToWeight: tier.UpToWeight, Fee: tier.Fee}
+	}
+
+	return shippingTiers
+}
+
+func newShippingMethodDTO(method entity.ShippingMethod) model.ShippingMethodDTO {
+
+	methodDTO := model.ShippingMethodDTO{
+		ID:        method.ID,
+		Code:      method.Code,
+		Name:      method.Name,
+		Type:      method.MethodType,
+		Currency:  method.Currency,
+		FlatFee:   method.FlatFee,
+		FreeAbove: method.FreeAbove,
+		Region:    method.Region,
+		MaxWeight: method.MaxWeight,
+		IsActive:  method.IsActive,
+		CreatedAt: method.CreatedAt,
+	}
+
+	for _, tier := range method.Tiers {
+		methodDTO.Tiers = append(methodDTO.Tiers, model.ShippingTierDTO{UpToWeight: tier.UpToWeight, Fee: tier.Fee})
+	}
+
+	return methodDTO
+}
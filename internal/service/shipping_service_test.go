@@ -0,0 +1,250 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) createShippingMethod(t *testing.T, request model.CreateShippingMethodRequest) model.ShippingMethodDTO {
+
+	t.Helper()
+
+	request.Username = "janestaff"
+
+	response, err := f.shippingService.CreateShippingMethod(context.Background(), request)
+	if err != nil {
+		t.Fatalf("create shipping method: %v", err)
+	}
+
+	return response.Data.(model.ShippingMethodResponseData).ShippingMethod
+}
+
+// seedShipping gives the products a size and creates a flat, a tiered and a regional method
+func (f *fixture) seedShipping(t *testing.T) {
+
+	t.Helper()
+
+	ctx := context.Background()
+
+	// 800 g but 200 x 200 x 200 mm, shipped as 1600 g
+	_, err := f.shippingService.UpdateProductDimensions(ctx, model.UpdateProductDimensionsRequest{ProductID: 1, Weight: 800, Length: 200, Width: 200, Height: 200, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("update dimensions: %v", err)
+	}
+
+	_, err = f.shippingService.UpdateProductDimensions(ctx, model.UpdateProductDimensionsRequest{ProductID: 2, Weight: 1500, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("update dimensions: %v", err)
+	}
+
+	f.createShippingMethod(t, model.CreateShippingMethodRequest{Code: "REG", Name: "Regular", Type: constant.ShippingTypeFlatRate, FlatFee: 10000, FreeAbove: int64Ptr(200000)})
+	f.createShippingMethod(t, model.CreateShippingMethodRequest{Code: "EXP", Name: "Express", Type: constant.ShippingTypeWeightTiered, Tiers: []model.ShippingTierDTO{
+		{UpToWeight: 1000, Fee: 8000},
+		{UpToWeight: 3000, Fee: 15000},
+		{UpToWeight: 5000, Fee: 25000},
+	}})
+	f.createShippingMethod(t, model.CreateShippingMethodRequest{Code: "LOCAL", Name: "Bali courier", Type: constant.ShippingTypeFlatRate, FlatFee: 5000, Region: stringPtr("id-ba"), MaxWeight: int64Ptr(2000)})
+}
+
+func (f *fixture) quoteShipping(t *testing.T, region string, items ...model.ShippingQuoteItem) model.QuoteShippingResponseData {
+
+	t.Helper()
+
+	response, err := f.shippingService.QuoteShipping(context.Background(), model.QuoteShippingRequest{Region: region, Items: items})
+	if err != nil {
+		t.Fatalf("quote shipping: %v", err)
+	}
+
+	return response.Data.(model.QuoteShippingResponseData)
+}
+
+func quoteFees(quote model.QuoteShippingResponseData) map[string]int64 {
+
+	fees := map[string]int64{}
+
+	for _, method := range quote.Methods {
+		fees[method.Code] = method.Fee
+	}
+
+	return fees
+}
+
+func TestCreateShippingMethodValidation(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createShippingMethod(t, model.CreateShippingMethodRequest{Code: "REG", Name: "Regular", Type: constant.ShippingTypeFlatRate, FlatFee: 10000})
+
+	tests := []struct {
+		name    string
+		request model.CreateShippingMethodRequest
+		kind    error
+	}{
+		{name: "invalid code", request: model.CreateShippingMethodRequest{Code: "r", Name: "Regular", Type: constant.ShippingTypeFlatRate}, kind: common.ErrValidation},
+		{name: "missing name", request: model.CreateShippingMethodRequest{Code: "ECO", Name: " ", Type: constant.ShippingTypeFlatRate}, kind: common.ErrValidation},
+		{name: "unknown type", request: model.CreateShippingMethodRequest{Code: "ECO", Name: "Economy", Type: "BY_DISTANCE"}, kind: common.ErrValidation},
+		{name: "negative fee", request: model.CreateShippingMethodRequest{Code: "ECO", Name: "Economy", Type: constant.ShippingTypeFlatRate, FlatFee: -1}, kind: common.ErrValidation},
+		{name: "tiered without tiers", request: model.CreateShippingMethodRequest{Code: "ECO", Name: "Economy", Type: constant.ShippingTypeWeightTiered}, kind: common.ErrValidation},
+		{name: "unsorted tiers", request: model.CreateShippingMethodRequest{Code: "ECO", Name: "Economy", Type: constant.ShippingTypeWeightTiered, Tiers: []model.ShippingTierDTO{{UpToWeight: 2000, Fee: 9000}, {UpToWeight: 1000, Fee: 5000}}}, kind: common.ErrValidation},
+		{name: "zero free threshold", request: model.CreateShippingMethodRequest{Code: "ECO", Name: "Economy", Type: constant.ShippingTypeFlatRate, FreeAbove: int64Ptr(0)}, kind: common.ErrValidation},
+		{name: "invalid region", request: model.CreateShippingMethodRequest{Code: "ECO", Name: "Economy", Type: constant.ShippingTypeFlatRate, Region: stringPtr("bali island")}, kind: common.ErrValidation},
+		{name: "duplicate code", request: model.CreateShippingMethodRequest{Code: " reg ", Name: "Regular", Type: constant.ShippingTypeFlatRate}, kind: common.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.shippingService.CreateShippingMethod(context.Background(), tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	inactive := false
+
+	_, err := f.shippingService.UpdateShippingMethod(context.Background(), model.UpdateShippingMethodRequest{ShippingMethodID: 99, IsActive: &inactive})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	_, err = f.shippingService.UpdateProductDimensions(context.Background(), model.UpdateProductDimensionsRequest{ProductID: 1, Weight: -1})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	_, err = f.shippingService.UpdateProductDimensions(context.Background(), model.UpdateProductDimensionsRequest{ProductID: 99, Weight: 100})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func TestUpdateProductDimensionsUsesVolumetricWeight(t *testing.T) {
+
+	f := newFixture(t)
+
+	response, err := f.shippingService.UpdateProductDimensions(context.Background(), model.UpdateProductDimensionsRequest{ProductID: 1, Weight: 800, Length: 200, Width: 200, Height: 200})
+	if err != nil {
+		t.Fatalf("update dimensions: %v", err)
+	}
+
+	if dimensions := response.Data.(model.ProductDimensionsResponseData); dimensions.Weight != 800 || dimensions.ShippingWeight != 1600 {
+		t.Fatalf("expected the volumetric weight, got %+v", dimensions)
+	}
+
+	response, err = f.shippingService.UpdateProductDimensions(context.Background(), model.UpdateProductDimensionsRequest{ProductID: 1, Weight: 2000, Length: 200, Width: 200, Height: 200})
+	if err != nil {
+		t.Fatalf("update dimensions: %v", err)
+	}
+
+	if dimensions := response.Data.(model.ProductDimensionsResponseData); dimensions.ShippingWeight != 2000 {
+		t.Fatalf("expected the actual weight, got %+v", dimensions)
+	}
+}
+
+func TestQuoteShippingListsMethodsCheapestFirst(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedShipping(t)
+
+	// 1600 g + 1500 g, above the second express tier
+	quote := f.quoteShipping(t, "",
+		model.ShippingQuoteItem{ProductID: 1, Quantity: 1},
+		model.ShippingQuoteItem{ProductID: 2, Quantity: 1},
+	)
+
+	if quote.Subtotal != 135000 || quote.Weight != 3100 || len(quote.Methods) != 2 || quote.Methods[0].Code != "REG" || quote.Methods[1].Fee != 25000 {
+		t.Fatalf("unexpected quote %+v", quote)
+	}
+
+	tests := []struct {
+		name   string
+		region string
+		items  []model.ShippingQuoteItem
+		fees   map[string]int64
+	}{
+		{name: "regional method", region: "ID-BA", items: []model.ShippingQuoteItem{{ProductID: 1, Quantity: 1}}, fees: map[string]int64{"LOCAL": 5000, "EXP": 15000, "REG": 10000}},
+		{name: "too heavy for the regional method", region: "ID-BA", items: []model.ShippingQuoteItem{{ProductID: 1, Quantity: 2}}, fees: map[string]int64{"EXP": 25000, "REG": 10000}},
+		{name: "free above the threshold", region: "", items: []model.ShippingQuoteItem{{ProductID: 2, Quantity: 2}}, fees: map[string]int64{"EXP": 15000, "REG": 0}},
+		{name: "too heavy for every tier", region: "", items: []model.ShippingQuoteItem{{ProductID: 1, Quantity: 4}}, fees: map[string]int64{"REG": 10000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fees := quoteFees(f.quoteShipping(t, tt.region, tt.items...))
+
+			if len(fees) != len(tt.fees) {
+				t.Fatalf("expected %v, got %v", tt.fees, fees)
+			}
+
+			for code, fee := range tt.fees {
+				if actual, exists := fees[code]; !exists || actual != fee {
+					t.Fatalf("expected %v, got %v", tt.fees, fees)
+				}
+			}
+		})
+	}
+
+	_, err := f.shippingService.QuoteShipping(context.Background(), model.QuoteShippingRequest{Items: []model.ShippingQuoteItem{{ProductID: 3, Quantity: 1}}})
+	assertErrorKind(t, err, common.ErrValidation)
+}
+
+func TestSubmitOrderChargesTheShippingFee(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedShipping(t)
+
+	request := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+
+	// A method must be selected once one is active
+	_, err := f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	request.ShippingMethod = "LOCAL"
+
+	_, err = f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+
+	request.ShippingMethod = "exp"
+
+	order := f.submitOrder(t, request)
+
+	if order.ShippingMethod != "EXP" || order.ShippingFee != 15000 || order.Total != 30000 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	detail := f.orderDetail(t, order.OrderReference)
+
+	if detail.ShippingMethod != "EXP" || detail.ShippingWeight != 1600 || detail.ShippingFee != 15000 || detail.Payment.Total != 30000 {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	// Fee changes never change a placed order
+	_, err = f.shippingService.UpdateShippingMethod(context.Background(), model.UpdateShippingMethodRequest{ShippingMethodID: 2, Tiers: []model.ShippingTierDTO{{UpToWeight: 5000, Fee: 1000}}, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("update shipping method: %v", err)
+	}
+
+	if detail := f.orderDetail(t, order.OrderReference); detail.ShippingFee != 15000 || detail.Total != 30000 {
+		t.Fatalf("expected the placed order to keep its fee, got %+v", detail)
+	}
+}
+
+func TestFreeShippingCouponDiscountsTheFee(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedShipping(t)
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1000, Mode: constant.TaxModeExclusive})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "SHIPFREE", Type: constant.PromotionTypeFreeShipping})
+
+	request := couponRequest("SHIPFREE", testUsername, model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.ShippingMethod = "REG"
+
+	order := f.submitOrder(t, request)
+
+	// The fee is waived and not taxed, the items keep their full tax
+	if order.ShippingFee != 10000 || order.DiscountTotal != 10000 || order.TaxTotal != 1500 || order.Total != 16500 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	if len(order.Discounts) != 1 || order.Discounts[0].Amount != 10000 {
+		t.Fatalf("unexpected discounts %+v", order.Discounts)
+	}
+}
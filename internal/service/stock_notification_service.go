@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+*
+
+	Stock notifications :
+	- Staff get a low stock alert when an order or an adjustment takes the stock of a product
+	  from above its threshold to its threshold or below
+	- Customers subscribe to an out of stock product and get a single back in stock notice
+	  once an admin adjustment brings the stock above 0, they may subscribe again afterwards
+
+*
+*/
+type StockNotificationService struct {
+	txRunner                    repository.TransactionRunner
+	productRepository           repository.ProductRepository
+	stockSubscriptionRepository repository.StockSubscriptionRepository
+	notifier                    notification.Notifier
+}
+
+func NewStockNotificationService(
+	txRunner repository.TransactionRunner,
+	productRepository repository.ProductRepository,
+	stockSubscriptionRepository repository.StockSubscriptionRepository,
+	notifier notification.Notifier) *StockNotificationService {
+	return &StockNotificationService{
+		txRunner:                    txRunner,
+		productRepository:           productRepository,
+		stockSubscriptionRepository: stockSubscriptionRepository,
+		notifier:                    notifier,
+	}
+}
+
+func (sns *StockNotificationService) Subscribe(ctx context.Context, request model.SubscribeStockRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.ProductID <= 0 {
+		err := errors.New("productId is required")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var product entity.Product
+	var subscription entity.StockSubscription
+
+	err := sns.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		// Locks the product, a restock cannot slip in between the stock check and the insert
+		product, err = repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		if !product.IsActive || product.IsDeleted() {
+			err := fmt.Errorf("product is not active: %v", product.ID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		if product.Stock > 0 {
+			err := fmt.Errorf("product is in stock: %v", product.ID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		subscription, err = repos.Subscription.Create(ctx, entity.StockSubscription{
+			ProductID:       product.ID,
+			AccountUsername: request.Username,
+			CreatedAt:       time.Now(),
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.StockSubscriptionResponseData{
+		Subscription: newStockSubscriptionDTO(subscription, product),
+	}
+
+	return response, nil
+}
+
+func (sns *StockNotificationService) Unsubscribe(ctx context.Context, request model.UnsubscribeStockRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := sns.stockSubscriptionRepository.DeletePending(ctx, request.ProductID, request.Username)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+
+	return response, nil
+}
+
+// GetStockSubscriptions lists the subscriptions of an account still waiting for their notice
+func (sns *StockNotificationService) GetStockSubscriptions(ctx context.Context, username string) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	subscriptions, err := sns.stockSubscriptionRepository.FindPendingByAccount(ctx, username)
+
+	if err != nil {
+		return response, err
+	}
+
+	productIDs := make([]int64, len(subscriptions))
+
+	for i, subscription := range subscriptions {
+		productIDs[i] = subscription.ProductID
+	}
+
+	products, err := sns.productRepository.FindMultipleByIDs(ctx, productIDs)
+
+	if err != nil {
+		return response, err
+	}
+
+	productMap := make(map[int64]entity.Product, len(products))
+
+	for _, product := range products {
+		productMap[product.ID] = product
+	}
+
+	subscriptionsDTO := make([]model.StockSubscriptionDTO, len(subscriptions))
+
+	for i, subscription := range subscriptions {
+		subscriptionsDTO[i] = newStockSubscriptionDTO(subscription, productMap[subscription.ProductID])
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetStockSubscriptionsResponseData{
+		Subscriptions: subscriptionsDTO,
+	}
+
+	return response, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+// lowStockAlert reports whether the stock change crossed the threshold of the product, product holds the new stock
+func lowStockAlert(product entity.Product, stockBefore int64, reference string) (notification.LowStockAlert, bool) {
+
+	threshold := product.LowStockThreshold
+
+	if threshold <= 0 || stockBefore <= threshold || product.Stock > threshold {
+		return notification.LowStockAlert{}, false
+	}
+
+	return notification.LowStockAlert{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		Stock:       product.Stock,
+		Threshold:   threshold,
+		Reference:   reference,
+	}, true
+}
+
+// claimBackInStock marks the pending subscriptions of a product as notified, it runs in the
+// transaction of the stock change so a rolled back restock notifies nobody
+func claimBackInStock(ctx context.Context, repos repository.Repositories, product entity.Product) ([]notification.BackInStockNotice, error) {
+
+	subscriptions, err := repos.Subscription.ClaimPending(ctx, product.ID, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	notices := make([]notification.BackInStockNotice, 0, len(subscriptions))
+
+	for _, subscription := range subscriptions {
+
+		account, err := repos.Account.FindByUsername(ctx, subscription.AccountUsername)
+
+		if err != nil {
+			return nil, err
+		}
+
+		notices = append(notices, notification.BackInStockNotice{
+			ProductID:       product.ID,
+			ProductName:     product.Name,
+			Stock:           product.Stock,
+			AccountUsername: account.Username,
+			Email:           account.Email,
+		})
+	}
+
+	return notices, nil
+}
+
+// dispatchLowStock sends the alerts once the stock change is committed, failures are only logged
+func (sns *StockNotificationService) dispatchLowStock(ctx context.Context, alerts []notification.LowStockAlert) {
+
+	for _, alert := range alerts {
+
+		err := sns.notifier.NotifyLowStock(ctx, alert)
+
+		if err != nil {
+			logrus.Errorf("low stock alert for product %d: %v", alert.ProductID, err)
+		}
+	}
+}
+
+// dispatchBackInStock sends the claimed notices, a failed notice is not retried
+func (sns *StockNotificationService) dispatchBackInStock(ctx context.Context, notices []notification.BackInStockNotice) {
+
+	for _, notice := range notices {
+
+		err := sns.notifier.NotifyBackInStock(ctx, notice)
+
+		if err != nil {
+			logrus.Errorf("back in stock notice for product %d to %s: %v", notice.ProductID, notice.AccountUsername, err)
+		}
+	}
+}
+
+func newStockSubscriptionDTO(subscription entity.StockSubscription, product entity.Product) model.StockSubscriptionDTO {
+	return model.StockSubscriptionDTO{
+		ID:          subscription.ID,
+		ProductID:   subscription.ProductID,
+		ProductName: product.Name,
+		CreatedAt:   subscription.CreatedAt,
+	}
+}
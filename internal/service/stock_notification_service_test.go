@@ -0,0 +1,249 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) setThreshold(t *testing.T, productID int64, threshold int64) {
+
+	t.Helper()
+
+	_, err := f.inventoryService.UpdateLowStockThreshold(context.Background(), model.UpdateLowStockThresholdRequest{
+		ProductID: productID,
+		Threshold: &threshold,
+		Username:  "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("set threshold: %v", err)
+	}
+}
+
+func (f *fixture) restock(t *testing.T, productID int64, quantity int64) {
+
+	t.Helper()
+
+	_, err := f.inventoryService.AdjustStock(context.Background(), model.AdjustStockRequest{
+		ProductID:    productID,
+		MovementType: constant.MovementTypeRestock,
+		Quantity:     quantity,
+		Username:     "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("restock: %v", err)
+	}
+}
+
+func (f *fixture) subscribe(t *testing.T, productID int64) model.StockSubscriptionDTO {
+
+	t.Helper()
+
+	response, err := f.stockNotificationService.Subscribe(context.Background(), model.SubscribeStockRequest{ProductID: productID, Username: testUsername})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	return response.Data.(model.StockSubscriptionResponseData).Subscription
+}
+
+func TestLowStockAlertIsSentOnceWhenThresholdIsCrossed(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.setThreshold(t, 1, 5)
+
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 4}))
+
+	if len(f.notifier.lowStock) != 0 {
+		t.Fatalf("expected no alert above the threshold, got %+v", f.notifier.lowStock)
+	}
+
+	order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	if len(f.notifier.lowStock) != 1 {
+		t.Fatalf("expected 1 alert, got %+v", f.notifier.lowStock)
+	}
+
+	alert := f.notifier.lowStock[0]
+
+	if alert.ProductID != 1 || alert.Stock != 5 || alert.Threshold != 5 || alert.Reference != order.OrderReference {
+		t.Fatalf("unexpected alert %+v", alert)
+	}
+
+	// Already below the threshold, no new alert
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	if len(f.notifier.lowStock) != 1 {
+		t.Fatalf("expected no second alert, got %+v", f.notifier.lowStock)
+	}
+
+	// A restock above the threshold re-arms the alert, an adjustment can trigger it
+	f.restock(t, 1, 6)
+
+	_, err := f.inventoryService.AdjustStock(context.Background(), model.AdjustStockRequest{
+		ProductID:    1,
+		MovementType: constant.MovementTypeManualAdjustment,
+		Quantity:     -8,
+		Note:         "Damaged in storage",
+		Username:     "janestaff",
+	})
+
+	if err != nil {
+		t.Fatalf("adjust stock: %v", err)
+	}
+
+	if len(f.notifier.lowStock) != 2 || f.notifier.lowStock[1].Stock != 2 || f.notifier.lowStock[1].Reference != "" {
+		t.Fatalf("expected an adjustment alert, got %+v", f.notifier.lowStock)
+	}
+}
+
+func TestFailedOrderSendsNoLowStockAlert(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.setThreshold(t, 1, 5)
+
+	_, err := f.orderService.SubmitOrder(context.Background(), submitRequest(
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 6},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 4},
+	))
+
+	assertErrorKind(t, err, common.ErrValidation)
+
+	if len(f.notifier.lowStock) != 0 {
+		t.Fatalf("expected no alert for a rolled back order, got %+v", f.notifier.lowStock)
+	}
+}
+
+func TestUpdateLowStockThresholdValidation(t *testing.T) {
+
+	f := newFixture(t)
+
+	negative := int64(-1)
+	zero := int64(0)
+
+	tests := []struct {
+		name    string
+		request model.UpdateLowStockThresholdRequest
+		kind    error
+	}{
+		{name: "missing threshold", request: model.UpdateLowStockThresholdRequest{ProductID: 1}, kind: common.ErrValidation},
+		{name: "negative threshold", request: model.UpdateLowStockThresholdRequest{ProductID: 1, Threshold: &negative}, kind: common.ErrValidation},
+		{name: "unknown product", request: model.UpdateLowStockThresholdRequest{ProductID: 99, Threshold: &zero}, kind: common.ErrResourceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.inventoryService.UpdateLowStockThreshold(context.Background(), tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestSubscribeRequiresAnOutOfStockActiveProduct(t *testing.T) {
+
+	f := newFixture(t)
+
+	tests := []struct {
+		name      string
+		productID int64
+		kind      error
+	}{
+		{name: "in stock", productID: 1, kind: common.ErrValidation},
+		{name: "inactive", productID: 3, kind: common.ErrValidation},
+		{name: "unknown", productID: 99, kind: common.ErrResourceNotFound},
+		{name: "missing product id", productID: 0, kind: common.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.stockNotificationService.Subscribe(context.Background(), model.SubscribeStockRequest{ProductID: tt.productID, Username: testUsername})
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestRestockNotifiesSubscribersOnce(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 3}))
+
+	subscription := f.subscribe(t, 2)
+
+	if subscription.ProductID != 2 || subscription.ProductName != "Linen Throw" {
+		t.Fatalf("unexpected subscription %+v", subscription)
+	}
+
+	_, err := f.stockNotificationService.Subscribe(ctx, model.SubscribeStockRequest{ProductID: 2, Username: testUsername})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	response, err := f.stockNotificationService.GetStockSubscriptions(ctx, testUsername)
+	if err != nil {
+		t.Fatalf("get subscriptions: %v", err)
+	}
+
+	if subscriptions := response.Data.(model.GetStockSubscriptionsResponseData).Subscriptions; len(subscriptions) != 1 {
+		t.Fatalf("expected 1 pending subscription, got %+v", subscriptions)
+	}
+
+	f.restock(t, 2, 2)
+
+	if len(f.notifier.backInStock) != 1 {
+		t.Fatalf("expected 1 notice, got %+v", f.notifier.backInStock)
+	}
+
+	notice := f.notifier.backInStock[0]
+
+	if notice.ProductID != 2 || notice.Stock != 2 || notice.AccountUsername != testUsername || notice.Email != "john@example.com" {
+		t.Fatalf("unexpected notice %+v", notice)
+	}
+
+	response, err = f.stockNotificationService.GetStockSubscriptions(ctx, testUsername)
+	if err != nil {
+		t.Fatalf("get subscriptions: %v", err)
+	}
+
+	if subscriptions := response.Data.(model.GetStockSubscriptionsResponseData).Subscriptions; len(subscriptions) != 0 {
+		t.Fatalf("expected the subscription to be consumed, got %+v", subscriptions)
+	}
+
+	// Stock was already above 0, nobody is left to notify anyway
+	f.restock(t, 2, 1)
+
+	if len(f.notifier.backInStock) != 1 {
+		t.Fatalf("expected no new notice, got %+v", f.notifier.backInStock)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	request := model.UnsubscribeStockRequest{ProductID: 2, Username: testUsername}
+
+	_, err := f.stockNotificationService.Unsubscribe(ctx, request)
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 3}))
+	f.subscribe(t, 2)
+
+	_, err = f.stockNotificationService.Unsubscribe(ctx, request)
+	if err != nil {
+		t.Fatalf("unsubscribe: %v", err)
+	}
+
+	f.restock(t, 2, 1)
+
+	if len(f.notifier.backInStock) != 0 {
+		t.Fatalf("expected no notice after unsubscribing, got %+v", f.notifier.backInStock)
+	}
+}
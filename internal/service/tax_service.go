@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxTaxNameLength = 50
+	basisPoints      = 10000 // a rate of basisPoints is 100%
+)
+
+var taxRegionPattern = regexp.MustCompile(`^[A-Z0-9-]{2,10}$`)
+
+/*
+*
+
+	Taxes, computed per order item at submission :
+	- A rule charges rate basis points (1100 is 11%) of the items in its region and category,
+	  a rule without region or category covers every region or category
+	- Rules of the same name are one tax, an item pays it once under the most specific rule :
+	  region and category, then region, then category, then the rule covering every item
+	  (a 0 rate rule exempts its scope from the tax)
+	- Taxes apply to the item total minus its share of the order discounts
+	- INCLUSIVE taxes are part of the price : the item net amount is amount * 10000 / (10000 + inclusive rates)
+	  rounded half up, the difference is split between the inclusive taxes by rate
+	- EXCLUSIVE taxes are charged on the net amount, each rounded half up, and added to the order total
+	- The tax lines keep the rule as applied, later changes of the rules never change an order
+
+*
+*/
+type TaxService struct {
+	txRunner          repository.TransactionRunner
+	taxRuleRepository repository.TaxRuleRepository
+}
+
+func NewTaxService(txRunner repository.TransactionRunner, taxRuleRepository repository.TaxRuleRepository) *TaxService {
+	return &TaxService{
+		txRunner:          txRunner,
+		taxRuleRepository: taxRuleRepository,
+	}
+}
+
+func (ts *TaxService) GetTaxRules(ctx context.Context, request model.GetTaxRulesRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	err := validateOffsetPagination(request.IsPaginate, request.Page, request.PerPage)
+
+	if err != nil {
+		return response, err
+	}
+
+	paginationParams := model.PaginationParams{
+		IsPaginate: request.IsPaginate,
+		Page:       request.Page,
+		PerPage:    request.PerPage,
+	}
+
+	rules, totalData, err := ts.taxRuleRepository.FindAll(ctx, paginationParams)
+
+	if err != nil {
+		return response, err
+	}
+
+	rulesDTO := make([]model.TaxRuleDTO, len(rules))
+
+	for i, rule := range rules {
+		rulesDTO[i] = newTaxRuleDTO(rule)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetTaxRulesResponseData{
+		TaxRules: rulesDTO,
+		Metadata: offsetMetadata(request.IsPaginate, request.Page, request.PerPage, totalData),
+	}
+
+	return response, nil
+}
+
+func (ts *TaxService) CreateTaxRule(ctx context.Context, request model.CreateTaxRuleRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	rule := entity.TaxRule{
+		Name:       normalizeTaxName(request.Name),
+		CategoryID: request.CategoryID,
+		Rate:       request.Rate,
+		Mode:       request.Mode,
+		IsActive:   request.IsActive == nil || *request.IsActive,
+		CreatedAt:  time.Now(),
+		CreatedBy:  request.Username,
+		UpdatedAt:  time.Now(),
+		UpdatedBy:  request.Username,
+	}
+
+	if request.Region != nil {
+		region := normalizeTaxRegion(*request.Region)
+		rule.Region = &region
+	}
+
+	err := validateTaxRule(rule)
+
+	if err != nil {
+		return response, err
+	}
+
+	rule, err = ts.taxRuleRepository.Create(ctx, rule)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.TaxRuleResponseData{
+		TaxRule: newTaxRuleDTO(rule),
+	}
+
+	return response, nil
+}
+
+// UpdateTaxRule changes the name, the rate, the mode or the activation, the scope is fixed
+func (ts *TaxService) UpdateTaxRule(ctx context.Context, request model.UpdateTaxRuleRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var rule entity.TaxRule
+
+	err := ts.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		rule, err = repos.TaxRule.FindByID(ctx, request.TaxRuleID)
+
+		if err != nil {
+			return err
+		}
+
+		if request.Name != nil {
+			rule.Name = normalizeTaxName(*request.Name)
+		}
+
+		if request.Rate != nil {
+			rule.Rate = *request.Rate
+		}
+
+		if request.Mode != nil {
+			rule.Mode = *request.Mode
+		}
+
+		if request.IsActive != nil {
+			rule.IsActive = *request.IsActive
+		}
+
+		rule.UpdatedAt = time.Now()
+		rule.UpdatedBy = request.Username
+
+		err = validateTaxRule(rule)
+
+		if err != nil {
+			return err
+		}
+
+		return repos.TaxRule.Update(ctx, rule)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.TaxRuleResponseData{
+		TaxRule: newTaxRuleDTO(rule),
+	}
+
+	return response, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+// computeOrderTaxes returns the tax lines of the order items, DiscountAmount of the items must be set.
+// An order without region is refused while a rule is scoped to a region, it would skip the regional taxes
+func computeOrderTaxes(
+	ctx context.Context,
+	repos repository.Repositories,
+	region string,
+	orderItems []entity.OrderItem,
+	products map[int64]entity.Product,
+	now time.Time) ([]entity.OrderItemTax, error) {
+
+	rules, err := repos.TaxRule.FindActive(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if region == "" && slices.ContainsFunc(rules, func(rule entity.TaxRule) bool { return rule.Region != nil }) {
+		err := errors.New("region is required, taxes depend on the region of the delivery address")
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
+	}
+
+	var taxes []entity.OrderItemTax
+
+	for _, orderItem := range orderItems {
+
+		itemRules := selectTaxRules(rules, region, products[orderItem.ProductID].CategoryID)
+
+		for _, tax := range itemTaxes(itemRules, orderItem.Total-orderItem.DiscountAmount) {
+			tax.OrderReference = orderItem.OrderReference
+			tax.OrderItemReference = orderItem.OrderItemReference
+			tax.CreatedAt = now
+			taxes = append(taxes, tax)
+		}
+	}
+
+	return taxes, nil
+}
+
+// selectTaxRules keeps the most specific matching rule of each tax, by tax name
+func selectTaxRules(rules []entity.TaxRule, region string, categoryID int64) []entity.TaxRule {
+
+	selected := make(map[string]entity.TaxRule)
+
+	for _, rule := range rules {
+
+		if !rule.Matches(region, categoryID) {
+			continue
+		}
+
+		current, exists := selected[rule.Name]
+
+		if !exists || rule.Specificity() > current.Specificity() {
+			selected[rule.Name] = rule
+		}
+	}
+
+	itemRules := make([]entity.TaxRule, 0, len(selected))
+
+	for _, name := range slices.Sorted(maps.Keys(selected)) {
+		itemRules = append(itemRules, selected[name])
+	}
+
+	return itemRules
+}
+
+// itemTaxes computes the taxes of an item amount, the net amount excludes every tax
+func itemTaxes(rules []entity.TaxRule, amount int64) []entity.OrderItemTax {
+
+	inclusiveRate := int64(0)
+	var inclusiveRates []int64
+
+	for _, rule := range rules {
+		if rule.Mode == constant.TaxModeInclusive {
+			inclusiveRate += rule.Rate
+			inclusiveRates = append(inclusiveRates, rule.Rate)
+		}
+	}
+
+	net := divideRoundHalfUp(amount*basisPoints, basisPoints+inclusiveRate)
+	inclusiveShares := allocate(amount-net, inclusiveRates)
+
+	taxes := make([]entity.OrderItemTax, len(rules))
+
+	for i, rule := range rules {
+
+		taxes[i] = entity.OrderItemTax{
+			TaxRuleID:     rule.ID,
+			Name:          rule.Name,
+			Rate:          rule.Rate,
+			Mode:          rule.Mode,
+			TaxableAmount: net,
+		}
+
+		if rule.Mode == constant.TaxModeInclusive {
+			taxes[i].Amount, inclusiveShares = inclusiveShares[0], inclusiveShares[1:]
+		} else {
+			taxes[i].Amount = divideRoundHalfUp(net*rule.Rate, basisPoints)
+		}
+	}
+
+	return taxes
+}
+
+func validateTaxRule(rule entity.TaxRule) error {
+
+	var err error
+
+	switch {
+	case rule.Name == "" || utf8.RuneCountInString(rule.Name) > maxTaxNameLength:
+		err = fmt.Errorf("name must be 1 to %d characters", maxTaxNameLength)
+	case rule.Region != nil && !taxRegionPattern.MatchString(*rule.Region):
+		err = errors.New("region must be 2 to 10 letters, digits or '-'")
+	case rule.CategoryID != nil && *rule.CategoryID <= 0:
+		err = errors.New("categoryId must be greater than 0")
+	case rule.Rate < 0 || rule.Rate > basisPoints:
+		err = fmt.Errorf("rate must be between 0 and %d basis points", basisPoints)
+	case rule.Mode != constant.TaxModeInclusive && rule.Mode != constant.TaxModeExclusive:
+		err = fmt.Errorf("mode must be %s or %s", constant.TaxModeInclusive, constant.TaxModeExclusive)
+	}
+
+	if err != nil {
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func normalizeTaxName(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+func normalizeTaxRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
+
+func newTaxRuleDTO(rule entity.TaxRule) model.TaxRuleDTO {
+	return model.TaxRuleDTO{
+		ID:         rule.ID,
+		Name:       rule.Name,
+		Region:     rule.Region,
+		CategoryID: rule.CategoryID,
+		Rate:       rule.Rate,
+		Mode:       rule.Mode,
+		IsActive:   rule.IsActive,
+		CreatedAt:  rule.CreatedAt,
+	}
+}
+
+func newOrderItemTaxDTOs(taxes []entity.OrderItemTax) []model.OrderItemTaxDTO {
+
+	taxesDTO := make([]model.OrderItemTaxDTO, len(taxes))
+
+	for i, tax := range taxes {
+		taxesDTO[i] = model.OrderItemTaxDTO{
+			Name:          tax.Name,
+			Rate:          tax.Rate,
+			Mode:          tax.Mode,
+			TaxableAmount: tax.TaxableAmount,
+			Amount:        tax.Amount,
+		}
+	}
+
+	return taxesDTO
+}
@@ -0,0 +1,281 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) createTaxRule(t *testing.T, request model.CreateTaxRuleRequest) model.TaxRuleDTO {
+
+	t.Helper()
+
+	request.Username = "janestaff"
+
+	response, err := f.taxService.CreateTaxRule(context.Background(), request)
+	if err != nil {
+		t.Fatalf("create tax rule: %v", err)
+	}
+
+	return response.Data.(model.TaxRuleResponseData).TaxRule
+}
+
+func (f *fixture) orderDetail(t *testing.T, orderReference string) model.OrderWithPaymentAndItemDTO {
+
+	t.Helper()
+
+	response, err := f.orderService.GetOrderDetail(context.Background(), model.GetOrderDetailRequest{OrderReference: orderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("get order detail: %v", err)
+	}
+
+	return response.Data.(model.GetOrderDetailReponseData).Order
+}
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestCreateTaxRuleValidation(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1100, Mode: constant.TaxModeExclusive})
+
+	tests := []struct {
+		name    string
+		request model.CreateTaxRuleRequest
+		kind    error
+	}{
+		{name: "missing name", request: model.CreateTaxRuleRequest{Name: " ", Rate: 1100, Mode: constant.TaxModeExclusive}, kind: common.ErrValidation},
+		{name: "rate above 100%", request: model.CreateTaxRuleRequest{Name: "LUXURY", Rate: 10001, Mode: constant.TaxModeExclusive}, kind: common.ErrValidation},
+		{name: "negative rate", request: model.CreateTaxRuleRequest{Name: "LUXURY", Rate: -1, Mode: constant.TaxModeExclusive}, kind: common.ErrValidation},
+		{name: "unknown mode", request: model.CreateTaxRuleRequest{Name: "LUXURY", Rate: 1000, Mode: "ON_TOP"}, kind: common.ErrValidation},
+		{name: "invalid region", request: model.CreateTaxRuleRequest{Name: "LUXURY", Region: stringPtr("jakarta raya"), Rate: 1000, Mode: constant.TaxModeExclusive}, kind: common.ErrValidation},
+		{name: "same tax and scope", request: model.CreateTaxRuleRequest{Name: " vat ", Rate: 1200, Mode: constant.TaxModeExclusive}, kind: common.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.taxService.CreateTaxRule(context.Background(), tt.request)
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	// The scope is free again once the rule is deactivated
+	inactive := false
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Region: stringPtr("id-jk"), Rate: 1200, Mode: constant.TaxModeExclusive, IsActive: &inactive})
+	rule := f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Region: stringPtr("ID-JK"), Rate: 1200, Mode: constant.TaxModeExclusive})
+
+	if rule.Region == nil || *rule.Region != "ID-JK" {
+		t.Fatalf("expected a normalized region, got %+v", rule)
+	}
+
+	_, err := f.taxService.UpdateTaxRule(context.Background(), model.UpdateTaxRuleRequest{TaxRuleID: 99, IsActive: &inactive})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func TestExclusiveTaxIsAddedToTheTotal(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1100, Mode: constant.TaxModeExclusive})
+
+	order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2}))
+
+	if order.Subtotal != 30000 || order.TaxTotal != 3300 || order.TaxIncluded != 0 || order.Total != 33300 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	detail := f.orderDetail(t, order.OrderReference)
+
+	if detail.TaxTotal != 3300 || detail.Total != 33300 || detail.Payment.Total != 33300 {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	taxes := detail.OrderItems[0].Taxes
+
+	if len(taxes) != 1 || taxes[0].Name != "VAT" || taxes[0].TaxableAmount != 30000 || taxes[0].Amount != 3300 {
+		t.Fatalf("unexpected tax lines %+v", taxes)
+	}
+}
+
+func TestInclusiveTaxesAreSplitFromThePrice(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1000, Mode: constant.TaxModeInclusive})
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "CITY", Rate: 500, Mode: constant.TaxModeInclusive})
+
+	order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	// 15000 / 1.15 = 13043.48, the 1957 of taxes split 2:1 by largest remainder
+	if order.Subtotal != 15000 || order.TaxTotal != 1957 || order.TaxIncluded != 1957 || order.Total != 15000 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	taxes := f.orderDetail(t, order.OrderReference).OrderItems[0].Taxes
+
+	if len(taxes) != 2 || taxes[0].Name != "CITY" || taxes[0].Amount != 652 || taxes[1].Name != "VAT" || taxes[1].Amount != 1305 {
+		t.Fatalf("unexpected tax lines %+v", taxes)
+	}
+
+	if taxes[0].TaxableAmount != 13043 || taxes[1].TaxableAmount != 13043 {
+		t.Fatalf("expected the net amount as taxable amount, got %+v", taxes)
+	}
+}
+
+func TestMostSpecificTaxRuleWins(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1100, Mode: constant.TaxModeExclusive})
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", CategoryID: int64Ptr(2), Rate: 0, Mode: constant.TaxModeExclusive})
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Region: stringPtr("ID-BA"), Rate: 1200, Mode: constant.TaxModeExclusive})
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "TOURISM", Region: stringPtr("ID-BA"), CategoryID: int64Ptr(1), Rate: 250, Mode: constant.TaxModeExclusive})
+
+	items := []model.OrderItemRequest{
+		{ProductId: 1, PriceUsed: 15000, Quantity: 1},
+		{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+	}
+
+	tests := []struct {
+		name   string
+		region string
+		tax    int64
+	}{
+		// 11% of 15000, the category exempts product 2
+		{name: "other region", region: "ID-JK", tax: 1650},
+		// 12% of both items plus 2.5% of product 1
+		{name: "region rules", region: " id-ba ", tax: 1800 + 14400 + 375},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			request := submitRequest(items...)
+			request.Region = tt.region
+
+			order := f.submitOrder(t, request)
+
+			if order.TaxTotal != tt.tax || order.Total != 135000+tt.tax {
+				t.Fatalf("expected taxes of %d, got %+v", tt.tax, order)
+			}
+
+			// Stock goes back for the next case
+			_, err := f.orderService.CancelOrder(context.Background(), model.CancelOrderRequest{OrderReference: order.OrderReference, AccountUsername: testUsername})
+			if err != nil {
+				t.Fatalf("cancel order: %v", err)
+			}
+		})
+	}
+}
+
+func TestTaxesApplyToTheDiscountedItems(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1100, Mode: constant.TaxModeExclusive})
+	f.createPromotion(t, model.CreatePromotionRequest{Code: "TENOFF", Type: constant.PromotionTypePercentage, Value: 10})
+
+	order := f.submitOrder(t, couponRequest("TENOFF", testUsername,
+		model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 1},
+	))
+
+	// 11% of 27000 and of 108000
+	if order.DiscountTotal != 15000 || order.TaxTotal != 2970+11880 || order.Total != 150000-15000+14850 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	detail := f.orderDetail(t, order.OrderReference)
+
+	discounts := map[int64]int64{}
+
+	for _, item := range detail.OrderItems {
+		discounts[item.Product.ID] = item.DiscountAmount
+	}
+
+	if discounts[1] != 3000 || discounts[2] != 12000 {
+		t.Fatalf("unexpected discount shares %+v", discounts)
+	}
+}
+
+func TestTaxRuleChangesKeepPlacedOrders(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	rule := f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1100, Mode: constant.TaxModeExclusive})
+
+	first := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	rate := int64(1200)
+
+	_, err := f.taxService.UpdateTaxRule(ctx, model.UpdateTaxRuleRequest{TaxRuleID: rule.ID, Rate: &rate, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("update tax rule: %v", err)
+	}
+
+	second := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	inactive := false
+
+	_, err = f.taxService.UpdateTaxRule(ctx, model.UpdateTaxRuleRequest{TaxRuleID: rule.ID, IsActive: &inactive, Username: "janestaff"})
+	if err != nil {
+		t.Fatalf("deactivate tax rule: %v", err)
+	}
+
+	third := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+
+	if first.TaxTotal != 1650 || second.TaxTotal != 1800 || third.TaxTotal != 0 {
+		t.Fatalf("unexpected taxes %d, %d, %d", first.TaxTotal, second.TaxTotal, third.TaxTotal)
+	}
+
+	if taxes := f.orderDetail(t, first.OrderReference).OrderItems[0].Taxes; len(taxes) != 1 || taxes[0].Rate != 1100 || taxes[0].Amount != 1650 {
+		t.Fatalf("expected the first order to keep its tax lines, got %+v", taxes)
+	}
+}
+
+func TestSubmitOrderRequiresRegionForRegionalTaxes(t *testing.T) {
+
+	f := newFixture(t)
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Rate: 1100, Mode: constant.TaxModeExclusive})
+
+	// Without regional rules every region pays the same taxes
+	if order := f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})); order.TaxTotal != 1650 {
+		t.Fatalf("expected taxes of 1650, got %+v", order)
+	}
+
+	f.createTaxRule(t, model.CreateTaxRuleRequest{Name: "VAT", Region: stringPtr("ID-BA"), Rate: 1200, Mode: constant.TaxModeExclusive})
+
+	_, err := f.orderService.SubmitOrder(context.Background(), submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1}))
+	assertErrorKind(t, err, common.ErrValidation)
+
+	// The region of an address book entry is used
+	home := f.createAddress(t, addressRequest("John Doe", true))
+
+	request := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.DeliveryAddress = ""
+	request.AddressID = home.ID
+
+	if order := f.submitOrder(t, request); order.TaxTotal != 1650 {
+		t.Fatalf("expected taxes of 1650 in ID-JK, got %+v", order)
+	}
+}
+
+func TestSubmitOrderRejectsInvalidRegion(t *testing.T) {
+
+	f := newFixture(t)
+
+	request := submitRequest(model.OrderItemRequest{ProductId: 1, PriceUsed: 15000, Quantity: 1})
+	request.Region = "JAKARTA RAYA"
+
+	_, err := f.orderService.SubmitOrder(context.Background(), request)
+	assertErrorKind(t, err, common.ErrValidation)
+}
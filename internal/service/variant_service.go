@@ -0,0 +1,548 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxSKULength         = 64
+	maxOptionValueLength = 100
+)
+
+/*
+*
+
+	Catalogue management of the variants of a product :
+	- Option types (Size, Colour, ...) are defined before the variants, every variant picks one value per type,
+	  so no option type can be added once the product is sold by variant
+	- SKUs are unique across every variant, two variants of a product never share the same values
+	- The stock of a product sold by variant is the sum of the stock of its variants, the stock of a new
+	  variant is booked as its OPENING_BALANCE, a later stock change as a MANUAL_ADJUSTMENT
+	- Only a product without stock of its own can get its first variant, its stock would no longer add up
+
+*
+*/
+type VariantService struct {
+	txRunner                 repository.TransactionRunner
+	stockNotificationService *StockNotificationService
+}
+
+func NewVariantService(txRunner repository.TransactionRunner, stockNotificationService *StockNotificationService) *VariantService {
+	return &VariantService{
+		txRunner:                 txRunner,
+		stockNotificationService: stockNotificationService,
+	}
+}
+
+func (vs *VariantService) CreateOptionType(ctx context.Context, request model.CreateOptionTypeRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	optionType := entity.ProductOptionType{
+		ProductID: request.ProductID,
+		Name:      strings.TrimSpace(request.Name),
+		Position:  request.Position,
+	}
+
+	err := validateOptionType(optionType)
+
+	if err != nil {
+		return response, err
+	}
+
+	err = vs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		// Locks the product, variants of a product are created one at a time
+		_, err := repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		variants, err := repos.Variant.FindByProductID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		if len(variants) > 0 {
+			err := fmt.Errorf("product %d is already sold by variant, its option types can not be added to", request.ProductID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
+
+		optionType, err = repos.Variant.CreateOptionType(ctx, optionType)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.OptionTypeResponseData{
+		ProductID:  optionType.ProductID,
+		OptionType: newOptionTypeDTO(optionType),
+	}
+
+	return response, nil
+}
+
+// UpdateOptionType renames or reorders an option type, the values of the variants are left unchanged
+func (vs *VariantService) UpdateOptionType(ctx context.Context, request model.UpdateOptionTypeRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	var optionType entity.ProductOptionType
+
+	err := vs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		_, err := repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		optionType, err = findOptionType(ctx, repos, request.ProductID, request.OptionTypeID)
+
+		if err != nil {
+			return err
+		}
+
+		if request.Name != nil {
+			optionType.Name = strings.TrimSpace(*request.Name)
+		}
+
+		if request.Position != nil {
+			optionType.Position = *request.Position
+		}
+
+		err = validateOptionType(optionType)
+
+		if err != nil {
+			return err
+		}
+
+		return repos.Variant.UpdateOptionType(ctx, optionType)
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.OptionTypeResponseData{
+		ProductID:  optionType.ProductID,
+		OptionType: newOptionTypeDTO(optionType),
+	}
+
+	return response, nil
+}
+
+func (vs *VariantService) CreateVariant(ctx context.Context, request model.CreateVariantRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	variant := entity.ProductVariant{
+		ProductID: request.ProductID,
+		SKU:       strings.TrimSpace(request.SKU),
+		Price:     request.Price,
+		Stock:     request.Stock,
+		IsActive:  request.IsActive == nil || *request.IsActive,
+		CreatedAt: time.Now(),
+		CreatedBy: request.Username,
+		UpdatedAt: time.Now(),
+		UpdatedBy: request.Username,
+	}
+
+	for _, option := range request.Options {
+		variant.Options = append(variant.Options, entity.ProductVariantOption{
+			OptionTypeID: option.OptionTypeID,
+			Value:        strings.TrimSpace(option.Value),
+		})
+	}
+
+	err := validateVariant(variant)
+
+	if err != nil {
+		return response, err
+	}
+
+	if variant.Stock < 0 {
+		err := errors.New("stock must not be negative")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var product entity.Product
+	var backInStockNotices []notification.BackInStockNotice
+
+	err = vs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		// Locks the product, stock changes of a product are serialized
+		product, err = repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		variants, err := repos.Variant.FindByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if len(variants) == 0 && product.Stock != 0 {
+			err := fmt.Errorf("product %d has %d in stock of its own, adjust it to 0 before selling it by variant", product.ID, product.Stock)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
+
+		optionTypes, err := repos.Variant.FindOptionTypesByProductID(ctx, product.ID)
+
+		if err != nil {
+			return err
+		}
+
+		err = validateVariantOptions(variant, optionTypes, variants)
+
+		if err != nil {
+			return err
+		}
+
+		variant, err = repos.Variant.Create(ctx, variant)
+
+		if err != nil {
+			return err
+		}
+
+		stockBefore := product.Stock
+
+		if variant.Stock > 0 {
+
+			product.Stock, err = changeStock(ctx, repos.Product, product.ID, variant.Stock, request.Username)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		err = repos.Movement.CreateBatch(ctx, []entity.InventoryMovement{
+			newStockMovement(constant.MovementTypeOpeningBalance, variant.Stock, product, &variant, "", request.Username),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if stockBefore <= 0 && product.Stock > 0 {
+
+			backInStockNotices, err = claimBackInStock(ctx, repos, product)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// Read back with the option types of its options
+		variant, err = repos.Variant.FindByID(ctx, variant.ID)
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	vs.stockNotificationService.dispatchBackInStock(ctx, backInStockNotices)
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.VariantResponseData{
+		ProductID: product.ID,
+		Stock:     product.Stock,
+		Variant:   newProductVariantDTO(product, variant, time.Now()),
+	}
+
+	return response, nil
+}
+
+// UpdateVariant changes the SKU, the price override, the activation or the stock, the options are fixed
+func (vs *VariantService) UpdateVariant(ctx context.Context, request model.UpdateVariantRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.Stock != nil && *request.Stock < 0 {
+		err := errors.New("stock must not be negative")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	if len(strings.TrimSpace(request.Note)) > maxMovementNoteLength {
+		err := fmt.Errorf("note must not be longer than %d characters", maxMovementNoteLength)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	var product entity.Product
+	var variant entity.ProductVariant
+	var lowStockAlerts []notification.LowStockAlert
+	var backInStockNotices []notification.BackInStockNotice
+
+	err := vs.txRunner.WithinTransaction(ctx, func(repos repository.Repositories) error {
+
+		var err error
+
+		// Locks the product before its variant, like orders do
+		product, err = repos.Product.FindByID(ctx, request.ProductID)
+
+		if err != nil {
+			return err
+		}
+
+		variant, err = repos.Variant.FindByID(ctx, request.VariantID)
+
+		if err != nil {
+			return err
+		}
+
+		if variant.ProductID != product.ID {
+			err := fmt.Errorf("variant %d not found for product %d", request.VariantID, product.ID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrResourceNotFound)
+		}
+
+		if request.SKU != nil {
+			variant.SKU = strings.TrimSpace(*request.SKU)
+		}
+
+		if request.Price != nil && *request.Price == 0 {
+			variant.Price = nil
+		} else if request.Price != nil {
+			price := *request.Price
+			variant.Price = &price
+		}
+
+		if request.IsActive != nil {
+			variant.IsActive = *request.IsActive
+		}
+
+		variant.UpdatedAt = time.Now()
+		variant.UpdatedBy = request.Username
+
+		err = validateVariant(variant)
+
+		if err != nil {
+			return err
+		}
+
+		err = repos.Variant.Update(ctx, variant)
+
+		if err != nil {
+			return err
+		}
+
+		if request.Stock == nil || *request.Stock == variant.Stock {
+			return nil
+		}
+
+		if strings.TrimSpace(request.Note) == "" {
+			err := errors.New("note is required to explain a stock change")
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		quantity := *request.Stock - variant.Stock
+		stockBefore := product.Stock
+
+		product.Stock, err = changeStock(ctx, repos.Product, product.ID, quantity, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		variant.Stock, err = changeStock(ctx, repos.Variant, variant.ID, quantity, request.Username)
+
+		if err != nil {
+			return err
+		}
+
+		movement := newStockMovement(constant.MovementTypeManualAdjustment, quantity, product, &variant, "", request.Username)
+		movement.Note = strings.TrimSpace(request.Note)
+
+		err = repos.Movement.CreateBatch(ctx, []entity.InventoryMovement{movement})
+
+		if err != nil {
+			return err
+		}
+
+		if alert, crossed := lowStockAlert(product, stockBefore, ""); crossed {
+			lowStockAlerts = append(lowStockAlerts, alert)
+		}
+
+		if stockBefore <= 0 && product.Stock > 0 {
+			backInStockNotices, err = claimBackInStock(ctx, repos, product)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	vs.stockNotificationService.dispatchLowStock(ctx, lowStockAlerts)
+	vs.stockNotificationService.dispatchBackInStock(ctx, backInStockNotices)
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.VariantResponseData{
+		ProductID: product.ID,
+		Stock:     product.Stock,
+		Variant:   newProductVariantDTO(product, variant, time.Now()),
+	}
+
+	return response, nil
+}
+
+func findOptionType(ctx context.Context, repos repository.Repositories, productID int64, optionTypeID int64) (entity.ProductOptionType, error) {
+
+	optionTypes, err := repos.Variant.FindOptionTypesByProductID(ctx, productID)
+
+	if err != nil {
+		return entity.ProductOptionType{}, err
+	}
+
+	index := slices.IndexFunc(optionTypes, func(optionType entity.ProductOptionType) bool {
+		return optionType.ID == optionTypeID
+	})
+
+	if index < 0 {
+		err := fmt.Errorf("option type %d not found for product %d", optionTypeID, productID)
+		logrus.Error(err)
+		return entity.ProductOptionType{}, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	return optionTypes[index], nil
+}
+
+func validateOptionType(optionType entity.ProductOptionType) error {
+
+	if optionType.Name == "" || utf8.RuneCountInString(optionType.Name) > maxOptionValueLength {
+		err := fmt.Errorf("name is required and must not be longer than %d characters", maxOptionValueLength)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if optionType.Position < 0 {
+		err := errors.New("position must not be negative")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+func validateVariant(variant entity.ProductVariant) error {
+
+	if variant.SKU == "" || utf8.RuneCountInString(variant.SKU) > maxSKULength {
+		err := fmt.Errorf("sku is required and must not be longer than %d characters", maxSKULength)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	if variant.Price != nil && *variant.Price <= 0 {
+		err := errors.New("price must be greater than 0")
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	return nil
+}
+
+// validateVariantOptions requires one value per option type and values no other variant of the product has
+func validateVariantOptions(variant entity.ProductVariant, optionTypes []entity.ProductOptionType, variants []entity.ProductVariant) error {
+
+	if len(optionTypes) == 0 {
+		err := fmt.Errorf("product %d has no option types, create them before its variants", variant.ProductID)
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	values := make(map[int64]string, len(variant.Options))
+
+	for _, option := range variant.Options {
+
+		isOwnOptionType := slices.ContainsFunc(optionTypes, func(optionType entity.ProductOptionType) bool {
+			return optionType.ID == option.OptionTypeID
+		})
+
+		if !isOwnOptionType {
+			err := fmt.Errorf("option type %d does not belong to product %d", option.OptionTypeID, variant.ProductID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		if _, exists := values[option.OptionTypeID]; exists {
+			err := fmt.Errorf("option type %d is given more than once", option.OptionTypeID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		if option.Value == "" || utf8.RuneCountInString(option.Value) > maxOptionValueLength {
+			err := fmt.Errorf("value of option type %d is required and must not be longer than %d characters", option.OptionTypeID, maxOptionValueLength)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrValidation)
+		}
+
+		values[option.OptionTypeID] = option.Value
+	}
+
+	if len(values) != len(optionTypes) {
+		err := fmt.Errorf("a variant of product %d needs a value for each of its %d option types", variant.ProductID, len(optionTypes))
+		logrus.Error(err)
+		return common.NewError(err, common.ErrValidation)
+	}
+
+	for _, existing := range variants {
+
+		isSame := len(existing.Options) == len(values) && !slices.ContainsFunc(existing.Options, func(option entity.ProductVariantOption) bool {
+			return values[option.OptionTypeID] != option.Value
+		})
+
+		if isSame {
+			err := fmt.Errorf("variant %d of product %d already has these options", existing.ID, variant.ProductID)
+			logrus.Error(err)
+			return common.NewError(err, common.ErrConflict)
+		}
+	}
+
+	return nil
+}
+
+func newOptionTypeDTO(optionType entity.ProductOptionType) model.OptionTypeDTO {
+	return model.OptionTypeDTO{
+		ID:       optionType.ID,
+		Name:     optionType.Name,
+		Position: optionType.Position,
+	}
+}
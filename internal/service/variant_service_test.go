@@ -0,0 +1,229 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestCreateVariantBooksItsOpeningBalance(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+	ctx := context.Background()
+
+	request := model.CreateVariantRequest{
+		ProductID: 4,
+		SKU:       " TEE-XL-WHITE ",
+		Stock:     4,
+		Options:   []model.VariantOptionRequest{{OptionTypeID: 1, Value: "XL"}, {OptionTypeID: 2, Value: "White"}},
+		Username:  "janestaff",
+	}
+
+	response, err := f.variantService.CreateVariant(ctx, request)
+	if err != nil {
+		t.Fatalf("create variant: %v", err)
+	}
+
+	data := response.Data.(model.VariantResponseData)
+
+	if data.Stock != 11 || data.Variant.SKU != "TEE-XL-WHITE" || data.Variant.Price != 90000 || data.Variant.Stock != 4 || !data.Variant.IsActive {
+		t.Fatalf("unexpected variant %+v", data)
+	}
+
+	if len(data.Variant.Options) != 2 || data.Variant.Options[0].Name != "Size" || data.Variant.Options[0].Value != "XL" {
+		t.Fatalf("expected the options in option type order, got %+v", data.Variant.Options)
+	}
+
+	movement := f.movementsOf(t, 4)[0]
+
+	if movement.Type != constant.MovementTypeOpeningBalance || movement.VariantID == nil || *movement.VariantID != data.Variant.ID || movement.Quantity != 4 {
+		t.Fatalf("expected the opening balance of the variant, got %+v", movement)
+	}
+
+	f.assertReconciled(t)
+
+	tests := []struct {
+		name    string
+		sku     string
+		options []model.VariantOptionRequest
+		kind    error
+	}{
+		{"sku taken", "TEE-S-WHITE", []model.VariantOptionRequest{{OptionTypeID: 1, Value: "XS"}, {OptionTypeID: 2, Value: "White"}}, common.ErrConflict},
+		{"same options", "TEE-S-WHITE-2", []model.VariantOptionRequest{{OptionTypeID: 1, Value: "S"}, {OptionTypeID: 2, Value: "White"}}, common.ErrConflict},
+		{"missing option", "TEE-XS", []model.VariantOptionRequest{{OptionTypeID: 1, Value: "XS"}}, common.ErrValidation},
+		{"option given twice", "TEE-XS", []model.VariantOptionRequest{{OptionTypeID: 1, Value: "XS"}, {OptionTypeID: 1, Value: "S"}}, common.ErrValidation},
+		{"unknown option type", "TEE-XS", []model.VariantOptionRequest{{OptionTypeID: 1, Value: "XS"}, {OptionTypeID: 9, Value: "White"}}, common.ErrValidation},
+		{"empty value", "TEE-XS", []model.VariantOptionRequest{{OptionTypeID: 1, Value: "XS"}, {OptionTypeID: 2, Value: " "}}, common.ErrValidation},
+		{"no sku", " ", []model.VariantOptionRequest{{OptionTypeID: 1, Value: "XS"}, {OptionTypeID: 2, Value: "White"}}, common.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := f.variantService.CreateVariant(ctx, model.CreateVariantRequest{ProductID: 4, SKU: tt.sku, Stock: 1, Options: tt.options})
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	if stock := f.stockOf(t, 4); stock != 11 {
+		t.Fatalf("expected the rejected variants to leave the stock, got %d", stock)
+	}
+
+	f.assertReconciled(t)
+}
+
+func TestFirstVariantNeedsAProductWithoutStockOfItsOwn(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	request := model.CreateVariantRequest{
+		ProductID: 1,
+		SKU:       "POT-SMALL",
+		Stock:     3,
+		Options:   []model.VariantOptionRequest{{OptionTypeID: 1, Value: "Small"}},
+	}
+
+	_, err := f.variantService.CreateVariant(ctx, request)
+	assertErrorKind(t, err, common.ErrConflict)
+
+	response, err := f.variantService.CreateOptionType(ctx, model.CreateOptionTypeRequest{ProductID: 1, Name: " Size "})
+	if err != nil {
+		t.Fatalf("create option type: %v", err)
+	}
+
+	optionType := response.Data.(model.OptionTypeResponseData).OptionType
+
+	if optionType.Name != "Size" {
+		t.Fatalf("unexpected option type %+v", optionType)
+	}
+
+	_, err = f.variantService.CreateOptionType(ctx, model.CreateOptionTypeRequest{ProductID: 1, Name: "Size"})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	request.Options[0].OptionTypeID = optionType.ID
+
+	_, err = f.variantService.CreateVariant(ctx, request)
+	assertErrorKind(t, err, common.ErrConflict)
+
+	_, err = f.inventoryService.AdjustStock(ctx, model.AdjustStockRequest{
+		ProductID:    1,
+		MovementType: constant.MovementTypeManualAdjustment,
+		Quantity:     -10,
+		Note:         "Sold by size from now on",
+	})
+	if err != nil {
+		t.Fatalf("empty the stock: %v", err)
+	}
+
+	_, err = f.variantService.CreateVariant(ctx, request)
+	if err != nil {
+		t.Fatalf("create the first variant: %v", err)
+	}
+
+	if stock := f.stockOf(t, 1); stock != 3 {
+		t.Fatalf("expected the stock of the variant, got %d", stock)
+	}
+
+	// Every variant picks one value per option type
+	_, err = f.variantService.CreateOptionType(ctx, model.CreateOptionTypeRequest{ProductID: 1, Name: "Colour"})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	f.assertReconciled(t)
+}
+
+func TestUpdateVariant(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+	ctx := context.Background()
+
+	sku, price, stock := "TEE-S-OFFWHITE", int64(88000), int64(6)
+
+	_, err := f.variantService.UpdateVariant(ctx, model.UpdateVariantRequest{ProductID: 4, VariantID: 41, SKU: &sku, Price: &price, Stock: &stock})
+	assertErrorKind(t, err, common.ErrValidation)
+
+	response, err := f.variantService.UpdateVariant(ctx, model.UpdateVariantRequest{
+		ProductID: 4,
+		VariantID: 41,
+		SKU:       &sku,
+		Price:     &price,
+		Stock:     &stock,
+		Note:      "Recount",
+		Username:  "janestaff",
+	})
+	if err != nil {
+		t.Fatalf("update variant: %v", err)
+	}
+
+	data := response.Data.(model.VariantResponseData)
+
+	if data.Stock != 11 || data.Variant.SKU != sku || data.Variant.Price != 88000 || data.Variant.Stock != 6 {
+		t.Fatalf("unexpected variant %+v", data)
+	}
+
+	movement := f.movementsOf(t, 4)[0]
+
+	if movement.Type != constant.MovementTypeManualAdjustment || movement.Quantity != 4 || movement.Note != "Recount" {
+		t.Fatalf("expected the stock change in the ledger, got %+v", movement)
+	}
+
+	// A price of 0 removes the override
+	zero := int64(0)
+
+	response, err = f.variantService.UpdateVariant(ctx, model.UpdateVariantRequest{ProductID: 4, VariantID: 42, Price: &zero})
+	if err != nil {
+		t.Fatalf("remove the price: %v", err)
+	}
+
+	if variant := response.Data.(model.VariantResponseData).Variant; variant.Price != 90000 {
+		t.Fatalf("expected the product price, got %+v", variant)
+	}
+
+	_, err = f.variantService.UpdateVariant(ctx, model.UpdateVariantRequest{ProductID: 4, VariantID: 42, SKU: &sku})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	_, err = f.variantService.UpdateVariant(ctx, model.UpdateVariantRequest{ProductID: 1, VariantID: 42, SKU: &sku})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	f.assertReconciled(t)
+}
+
+func TestUpdateOptionType(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+	ctx := context.Background()
+
+	name, position := "Color", 0
+
+	response, err := f.variantService.UpdateOptionType(ctx, model.UpdateOptionTypeRequest{ProductID: 4, OptionTypeID: 2, Name: &name, Position: &position})
+	if err != nil {
+		t.Fatalf("update option type: %v", err)
+	}
+
+	if optionType := response.Data.(model.OptionTypeResponseData).OptionType; optionType.Name != "Color" || optionType.Position != 0 {
+		t.Fatalf("unexpected option type %+v", optionType)
+	}
+
+	detail, err := f.productService.GetProductDetail(ctx, model.GetProductDetailRequest{ID: 4})
+	if err != nil {
+		t.Fatalf("product detail: %v", err)
+	}
+
+	if options := detail.Data.(model.GetProductDetailResponseData).Product.Options; len(options) != 2 || options[0].Name != "Color" {
+		t.Fatalf("expected the renamed option type first, got %+v", options)
+	}
+
+	name = "Size"
+
+	_, err = f.variantService.UpdateOptionType(ctx, model.UpdateOptionTypeRequest{ProductID: 4, OptionTypeID: 2, Name: &name})
+	assertErrorKind(t, err, common.ErrConflict)
+
+	_, err = f.variantService.UpdateOptionType(ctx, model.UpdateOptionTypeRequest{ProductID: 1, OptionTypeID: 2, Name: &name})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
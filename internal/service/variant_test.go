@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+/*
+*
+
+	Product 4 "Linen Tee" 90000, sold by variant, its stock is the sum of its variants :
+	- Variant 41 TEE-S-WHITE  stock 2
+	- Variant 42 TEE-M-WHITE  stock 5 price 95000
+	- Variant 43 TEE-L-WHITE  stock 0 inactive
+
+*
+*/
+func (f *fixture) seedTee() {
+
+	price := int64(95000)
+
+	f.store.SeedProducts(entity.Product{ID: 4, CategoryID: 2, Name: "Linen Tee", Price: 90000, Stock: 7, IsActive: true})
+
+	f.store.SeedOptionTypes(
+		entity.ProductOptionType{ID: 1, ProductID: 4, Name: "Size", Position: 1},
+		entity.ProductOptionType{ID: 2, ProductID: 4, Name: "Colour", Position: 2},
+	)
+
+	f.store.SeedVariants(
+		entity.ProductVariant{ID: 41, ProductID: 4, SKU: "TEE-S-WHITE", Stock: 2, IsActive: true, Options: []entity.ProductVariantOption{
+			{OptionTypeID: 2, Value: "White"}, {OptionTypeID: 1, Value: "S"},
+		}},
+		entity.ProductVariant{ID: 42, ProductID: 4, SKU: "TEE-M-WHITE", Price: &price, Stock: 5, IsActive: true, Options: []entity.ProductVariantOption{
+			{OptionTypeID: 1, Value: "M"}, {OptionTypeID: 2, Value: "White"},
+		}},
+		entity.ProductVariant{ID: 43, ProductID: 4, SKU: "TEE-L-WHITE", Stock: 0, IsActive: false, Options: []entity.ProductVariantOption{
+			{OptionTypeID: 1, Value: "L"}, {OptionTypeID: 2, Value: "White"},
+		}},
+	)
+
+	variantS, variantM := int64(41), int64(42)
+
+	f.store.SeedMovements(
+		entity.InventoryMovement{ProductID: 4, VariantID: &variantS, MovementType: constant.MovementTypeOpeningBalance, Quantity: 2, StockAfter: 2},
+		entity.InventoryMovement{ProductID: 4, VariantID: &variantM, MovementType: constant.MovementTypeOpeningBalance, Quantity: 5, StockAfter: 5},
+	)
+}
+
+func (f *fixture) variantStockOf(t *testing.T, variantID int64) int64 {
+
+	t.Helper()
+
+	variant, err := f.repos.Variant.FindByID(context.Background(), variantID)
+	if err != nil {
+		t.Fatalf("find variant %d: %v", variantID, err)
+	}
+
+	return variant.Stock
+}
+
+func TestSubmitOrderReservesVariantStockAndSnapshotsOptions(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	data := f.submitOrder(t, submitRequest(
+		model.OrderItemRequest{ProductId: 4, VariantId: 41, PriceUsed: 90000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 1},
+	))
+
+	if data.Total != 2*90000+95000 {
+		t.Fatalf("unexpected total %d", data.Total)
+	}
+
+	if stock := f.stockOf(t, 4); stock != 4 {
+		t.Fatalf("expected product stock 4, got %d", stock)
+	}
+
+	if f.variantStockOf(t, 41) != 0 || f.variantStockOf(t, 42) != 4 {
+		t.Fatalf("unexpected variant stock %d / %d", f.variantStockOf(t, 41), f.variantStockOf(t, 42))
+	}
+
+	response, err := f.orderService.GetOrderDetail(context.Background(), model.GetOrderDetailRequest{OrderReference: data.OrderReference, AccountUsername: testUsername})
+	if err != nil {
+		t.Fatalf("get order detail: %v", err)
+	}
+
+	items := response.Data.(model.GetOrderDetailReponseData).Order.OrderItems
+
+	for _, item := range items {
+
+		if item.Variant == nil || len(item.Variant.Options) != 2 {
+			t.Fatalf("expected a variant snapshot, got %+v", item)
+		}
+
+		// Options follow the option type position
+		if item.Variant.Options[0].Name != "Size" || item.Variant.Options[1].Value != "White" {
+			t.Fatalf("unexpected options %+v", item.Variant.Options)
+		}
+	}
+}
+
+func TestSubmitOrderRejectsInvalidVariantLines(t *testing.T) {
+
+	tests := []struct {
+		name string
+		item model.OrderItemRequest
+	}{
+		{name: "missing variant", item: model.OrderItemRequest{ProductId: 4, PriceUsed: 90000, Quantity: 1}},
+		{name: "unknown variant", item: model.OrderItemRequest{ProductId: 4, VariantId: 99, PriceUsed: 90000, Quantity: 1}},
+		{name: "variant of another product", item: model.OrderItemRequest{ProductId: 1, VariantId: 41, PriceUsed: 15000, Quantity: 1}},
+		{name: "inactive variant", item: model.OrderItemRequest{ProductId: 4, VariantId: 43, PriceUsed: 90000, Quantity: 1}},
+		{name: "insufficient variant stock", item: model.OrderItemRequest{ProductId: 4, VariantId: 41, PriceUsed: 90000, Quantity: 3}},
+		{name: "product price instead of variant price", item: model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 90000, Quantity: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newFixture(t)
+			f.seedTee()
+
+			// The first line succeeds on its own, it must be rolled back too
+			_, err := f.orderService.SubmitOrder(context.Background(), submitRequest(
+				model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 1},
+				tt.item,
+			))
+
+			assertErrorKind(t, err, common.ErrValidation)
+
+			if f.stockOf(t, 4) != 7 || f.variantStockOf(t, 42) != 5 {
+				t.Fatalf("stock changed after a rejected order")
+			}
+		})
+	}
+}
+
+func TestCancelOrderReturnsVariantStock(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	data := f.submitOrder(t, submitRequest(
+		model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 2},
+		model.OrderItemRequest{ProductId: 4, VariantId: 42, PriceUsed: 95000, Quantity: 1},
+	))
+
+	_, err := f.orderService.CancelOrder(context.Background(), model.CancelOrderRequest{
+		OrderReference:  data.OrderReference,
+		AccountUsername: testUsername,
+	})
+	if err != nil {
+		t.Fatalf("cancel order: %v", err)
+	}
+
+	if f.stockOf(t, 4) != 7 || f.variantStockOf(t, 42) != 5 {
+		t.Fatalf("expected stock to be returned, got %d / %d", f.stockOf(t, 4), f.variantStockOf(t, 42))
+	}
+}
+
+func TestGetProductDetailListsVariants(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	response, err := f.productService.GetProductDetail(context.Background(), model.GetProductDetailRequest{ID: 4})
+	if err != nil {
+		t.Fatalf("get product detail: %v", err)
+	}
+
+	product := response.Data.(model.GetProductDetailResponseData).Product
+
+	if len(product.Variants) != 3 || product.Variants[1].Price != 95000 || product.Variants[0].Price != 90000 {
+		t.Fatalf("unexpected variants %+v", product.Variants)
+	}
+
+	// Inactive variants do not offer their values
+	if len(product.Options) != 2 || len(product.Options[0].Values) != 2 || product.Options[1].Values[0] != "White" {
+		t.Fatalf("unexpected options %+v", product.Options)
+	}
+
+	response, err = f.productService.GetProductDetail(context.Background(), model.GetProductDetailRequest{ID: 1})
+	if err != nil {
+		t.Fatalf("get product detail: %v", err)
+	}
+
+	if product := response.Data.(model.GetProductDetailResponseData).Product; product.Variants != nil || product.Options != nil {
+		t.Fatalf("expected no variants for product 1, got %+v", product)
+	}
+}
@@ -0,0 +1,564 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+*
+
+	Wishlist of the accounts :
+	- A product sold by variant is saved with one of its active variants, any other product without
+	- Items are listed with the live price and availability, not the price they were saved at
+	- Ordering items submits a regular order at the live prices and takes the items off the wishlist
+	- Prices are in the currency of the product, items priced in different currencies are ordered apart
+	- A price drop is notified once per item and price, the reference price follows every notified drop
+
+*
+*/
+type WishlistService struct {
+	wishlistRepository repository.WishlistRepository
+	productRepository  repository.ProductRepository
+	variantRepository  repository.ProductVariantRepository
+	accountRepository  repository.AccountRepository
+	orderService       *OrderService
+	notifier           notification.Notifier
+
+	priceChangeRepository repository.ProductPriceChangeRepository
+}
+
+func NewWishlistService(
+	wishlistRepository repository.WishlistRepository,
+	productRepository repository.ProductRepository,
+	variantRepository repository.ProductVariantRepository,
+	priceChangeRepository repository.ProductPriceChangeRepository,
+	accountRepository repository.AccountRepository,
+	orderService *OrderService,
+	notifier notification.Notifier) *WishlistService {
+	return &WishlistService{
+		wishlistRepository: wishlistRepository,
+		productRepository:  productRepository,
+		variantRepository:  variantRepository,
+		accountRepository:  accountRepository,
+		orderService:       orderService,
+		notifier:           notifier,
+
+		priceChangeRepository: priceChangeRepository,
+	}
+}
+
+func (ws *WishlistService) GetWishlist(ctx context.Context, username string) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	items, err := ws.wishlistRepository.FindByAccount(ctx, username)
+
+	if err != nil {
+		return response, err
+	}
+
+	products, variants, err := ws.findLiveItems(ctx, items)
+
+	if err != nil {
+		return response, err
+	}
+
+	itemsDTO := make([]model.WishlistItemDTO, len(items))
+
+	for i, item := range items {
+		itemsDTO[i] = newWishlistItemDTO(item, products, variants)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.GetWishlistResponseData{
+		Items: itemsDTO,
+	}
+
+	return response, nil
+}
+
+func (ws *WishlistService) AddToWishlist(ctx context.Context, request model.AddWishlistItemRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if request.ProductID <= 0 {
+		err := errors.New("productId is required")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	product, err := ws.productRepository.FindByID(ctx, request.ProductID)
+
+	if err != nil {
+		return response, err
+	}
+
+	if !product.IsActive || product.IsDeleted() {
+		err := fmt.Errorf("product is not active: %v", product.ID)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	variant, err := ws.findWishlistVariant(ctx, product, request.VariantID)
+
+	if err != nil {
+		return response, err
+	}
+
+	now := time.Now()
+	repriced := []entity.Product{product}
+
+	err = withDuePrices(ctx, ws.priceChangeRepository, repriced, now)
+
+	if err != nil {
+		return response, err
+	}
+
+	product = repriced[0]
+	price := product.PriceAt(now)
+
+	if variant != nil {
+		price = variant.EffectivePrice(product, now)
+	}
+
+	item, err := ws.wishlistRepository.Create(ctx, entity.WishlistItem{
+		AccountUsername: request.Username,
+		ProductID:       product.ID,
+		VariantID:       request.VariantID,
+		AddedPrice:      price,
+		ReferencePrice:  price,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	})
+
+	if err != nil {
+		return response, err
+	}
+
+	products := map[int64]entity.Product{product.ID: product}
+	variants := map[int64]entity.ProductVariant{}
+
+	if variant != nil {
+		variants[variant.ID] = *variant
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.WishlistItemResponseData{
+		Item: newWishlistItemDTO(item, products, variants),
+	}
+
+	return response, nil
+}
+
+func (ws *WishlistService) RemoveFromWishlist(ctx context.Context, request model.RemoveWishlistItemRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	deleted, err := ws.wishlistRepository.Delete(ctx, request.Username, []int64{request.ItemID})
+
+	if err != nil {
+		return response, err
+	}
+
+	// Items of other accounts are reported as missing too
+	if deleted == 0 {
+		err := fmt.Errorf("wishlist item not found: %d", request.ItemID)
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrResourceNotFound)
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+
+	return response, nil
+}
+
+// OrderWishlistItems submits an order of saved items at their live price, the ordered items
+// are taken off the wishlist once the order is placed
+func (ws *WishlistService) OrderWishlistItems(ctx context.Context, request model.OrderWishlistItemsRequest) (model.GeneralResponse, error) {
+
+	response := model.GeneralResponse{}
+
+	if len(request.Items) == 0 {
+		err := errors.New("items are required")
+		logrus.Error(err)
+		return response, common.NewError(err, common.ErrValidation)
+	}
+
+	items, err := ws.wishlistRepository.FindByAccount(ctx, request.Username)
+
+	if err != nil {
+		return response, err
+	}
+
+	itemMap := make(map[int64]entity.WishlistItem, len(items))
+
+	for _, item := range items {
+		itemMap[item.ID] = item
+	}
+
+	orderedItems := make([]entity.WishlistItem, 0, len(request.Items))
+	itemIDs := make([]int64, 0, len(request.Items))
+
+	for _, line := range request.Items {
+
+		item, exists := itemMap[line.ItemID]
+
+		if !exists {
+			err := fmt.Errorf("wishlist item not found: %d", line.ItemID)
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrResourceNotFound)
+		}
+
+		if slices.Contains(itemIDs, item.ID) {
+			err := fmt.Errorf("wishlist item ordered twice: %d", item.ID)
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+
+		orderedItems = append(orderedItems, item)
+		itemIDs = append(itemIDs, item.ID)
+	}
+
+	products, variants, err := ws.findLiveItems(ctx, orderedItems)
+
+	if err != nil {
+		return response, err
+	}
+
+	submitOrderRequest := model.SubmitOrderRequest{
+		AccountUsername: request.Username,
+		AddressID:       request.AddressID,
+		DeliveryAddress: request.DeliveryAddress,
+		Region:          request.Region,
+		ShippingMethod:  request.ShippingMethod,
+	}
+
+	for i, item := range orderedItems {
+
+		quantity := request.Items[i].Quantity
+
+		if quantity == 0 {
+			quantity = 1
+		}
+
+		// Availability is checked by SubmitOrder, under the product locks
+		orderItem := model.OrderItemRequest{
+			ProductId:       item.ProductID,
+			PriceUsed:       livePrice(item, products, variants),
+			Quantity:        quantity,
+			ProductName:     products[item.ProductID].Name,
+			ProductImageUrl: products[item.ProductID].ImageUrl,
+		}
+
+		if item.VariantID != nil {
+			orderItem.VariantId = *item.VariantID
+		}
+
+		// Items are ordered at their live price, in the currency the product is priced in
+		currency := products[item.ProductID].Currency
+
+		if submitOrderRequest.Currency == "" {
+			submitOrderRequest.Currency = currency
+		} else if submitOrderRequest.Currency != currency {
+			err := fmt.Errorf("wishlist items priced in %s and %s can not be ordered together", submitOrderRequest.Currency, currency)
+			logrus.Error(err)
+			return response, common.NewError(err, common.ErrValidation)
+		}
+
+		submitOrderRequest.OrderItems = append(submitOrderRequest.OrderItems, orderItem)
+	}
+
+	orderResponse, err := ws.orderService.SubmitOrder(ctx, submitOrderRequest)
+
+	if err != nil {
+		return response, err
+	}
+
+	// The order stands even if the items could not be removed, they can be removed by hand
+	_, err = ws.wishlistRepository.Delete(ctx, request.Username, itemIDs)
+
+	if err != nil {
+		logrus.Errorf("remove ordered wishlist items of %s: %v", request.Username, err)
+		itemIDs = []int64{}
+	}
+
+	response.ResponseCode = constant.SuccessCode
+	response.ResponseMessage = constant.SuccessMessage
+	response.Data = model.OrderWishlistItemsResponseData{
+		Order:          orderResponse.Data.(model.SubmitOrderResponseData),
+		RemovedItemIDs: itemIDs,
+	}
+
+	return response, nil
+}
+
+// NotifyPriceDrops notifies the accounts whose saved items got cheaper than their reference price,
+// every wishlisted product is checked when no product is given. It returns the number of notices sent
+func (ws *WishlistService) NotifyPriceDrops(ctx context.Context, productIDs ...int64) (int, error) {
+
+	var err error
+
+	if len(productIDs) == 0 {
+
+		productIDs, err = ws.wishlistRepository.FindProductIDs(ctx)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(productIDs) == 0 {
+		return 0, nil
+	}
+
+	items, err := ws.wishlistRepository.FindByProductIDs(ctx, productIDs)
+
+	if err != nil {
+		return 0, err
+	}
+
+	products, variants, err := ws.findLiveItems(ctx, items)
+
+	if err != nil {
+		return 0, err
+	}
+
+	accounts := make(map[string]entity.Account)
+	sent := 0
+
+	for _, item := range items {
+
+		// Unavailable items keep their reference price until they are sold again
+		if !isWishlistItemOrderable(item, products, variants) {
+			continue
+		}
+
+		price := livePrice(item, products, variants)
+
+		if price >= item.ReferencePrice {
+			continue
+		}
+
+		// Claiming the drop first, a concurrent check cannot notify it again
+		claimed, err := ws.wishlistRepository.LowerReferencePrice(ctx, item.ID, price)
+
+		if err != nil {
+			return sent, err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		account, exists := accounts[item.AccountUsername]
+
+		if !exists {
+
+			account, err = ws.accountRepository.FindByUsername(ctx, item.AccountUsername)
+
+			if err != nil {
+				return sent, err
+			}
+
+			accounts[account.Username] = account
+		}
+
+		err = ws.notifier.NotifyPriceDrop(ctx, notification.PriceDropNotice{
+			ProductID:       item.ProductID,
+			VariantID:       item.VariantID,
+			ProductName:     products[item.ProductID].Name,
+			OldPrice:        item.ReferencePrice,
+			NewPrice:        price,
+			AccountUsername: account.Username,
+			Email:           account.Email,
+		})
+
+		if err != nil {
+			logrus.Errorf("price drop notice for product %d to %s: %v", item.ProductID, item.AccountUsername, err)
+			continue
+		}
+
+		sent++
+	}
+
+	return sent, nil
+}
+
+/**
+	Unexported function (internal use only)
+**/
+
+// findWishlistVariant checks the variant of a new item, products sold by variant require one
+func (ws *WishlistService) findWishlistVariant(ctx context.Context, product entity.Product, variantID *int64) (*entity.ProductVariant, error) {
+
+	if variantID == nil {
+
+		variants, err := ws.variantRepository.FindByProductID(ctx, product.ID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(variants) > 0 {
+			err := fmt.Errorf("product %v is sold by variant, variantId is required", product.ID)
+			logrus.Error(err)
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+
+		return nil, nil
+	}
+
+	variant, err := ws.variantRepository.FindByID(ctx, *variantID)
+
+	if err != nil {
+		if errors.Is(err, common.ErrResourceNotFound) {
+			return nil, common.NewError(err, common.ErrValidation)
+		}
+		return nil, err
+	}
+
+	if variant.ProductID != product.ID {
+		err := fmt.Errorf("variant %v does not belong to product %v", variant.ID, product.ID)
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
+	}
+
+	if !variant.IsActive {
+		err := fmt.Errorf("variant is not active: %v", variant.ID)
+		logrus.Error(err)
+		return nil, common.NewError(err, common.ErrValidation)
+	}
+
+	return &variant, nil
+}
+
+// findLiveItems loads the current products and variants of the items, deleted variants are left out
+func (ws *WishlistService) findLiveItems(ctx context.Context, items []entity.WishlistItem) (map[int64]entity.Product, map[int64]entity.ProductVariant, error) {
+
+	productIDs := make([]int64, 0, len(items))
+	variantIDs := make([]int64, 0, len(items))
+
+	for _, item := range items {
+
+		productIDs = append(productIDs, item.ProductID)
+
+		if item.VariantID != nil {
+			variantIDs = append(variantIDs, *item.VariantID)
+		}
+	}
+
+	products := make(map[int64]entity.Product, len(productIDs))
+	variants := make(map[int64]entity.ProductVariant, len(variantIDs))
+
+	if len(productIDs) == 0 {
+		return products, variants, nil
+	}
+
+	productList, err := ws.productRepository.FindMultipleByIDs(ctx, productIDs)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = withDuePrices(ctx, ws.priceChangeRepository, productList, time.Now())
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, product := range productList {
+		products[product.ID] = product
+	}
+
+	if len(variantIDs) == 0 {
+		return products, variants, nil
+	}
+
+	variantList, err := ws.variantRepository.FindByIDs(ctx, variantIDs)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, variant := range variantList {
+		variants[variant.ID] = variant
+	}
+
+	return products, variants, nil
+}
+
+// livePrice is the current price of the item, its variant price when it has one, sales included
+func livePrice(item entity.WishlistItem, products map[int64]entity.Product, variants map[int64]entity.ProductVariant) int64 {
+
+	product := products[item.ProductID]
+	now := time.Now()
+
+	if item.VariantID != nil {
+		if variant, exists := variants[*item.VariantID]; exists {
+			return variant.EffectivePrice(product, now)
+		}
+	}
+
+	return product.PriceAt(now)
+}
+
+// isWishlistItemOrderable reports whether the product and the variant of the item are still sold
+func isWishlistItemOrderable(item entity.WishlistItem, products map[int64]entity.Product, variants map[int64]entity.ProductVariant) bool {
+
+	product, exists := products[item.ProductID]
+
+	if !exists || !product.IsActive || product.IsDeleted() {
+		return false
+	}
+
+	if item.VariantID == nil {
+		return true
+	}
+
+	variant, exists := variants[*item.VariantID]
+
+	return exists && variant.IsActive
+}
+
+func newWishlistItemDTO(item entity.WishlistItem, products map[int64]entity.Product, variants map[int64]entity.ProductVariant) model.WishlistItemDTO {
+
+	product := products[item.ProductID]
+
+	itemDTO := model.WishlistItemDTO{
+		ID:          item.ID,
+		ProductID:   item.ProductID,
+		VariantID:   item.VariantID,
+		ProductName: product.Name,
+		ImageUrl:    product.ImageUrl,
+		Price:       livePrice(item, products, variants),
+		Currency:    product.Currency,
+		AddedPrice:  item.AddedPrice,
+		Stock:       product.Stock,
+		AddedAt:     item.CreatedAt,
+	}
+
+	if item.VariantID != nil {
+		variant := variants[*item.VariantID]
+		itemDTO.SKU = variant.SKU
+		itemDTO.Stock = variant.Stock
+	}
+
+	itemDTO.IsAvailable = isWishlistItemOrderable(item, products, variants) && itemDTO.Stock > 0
+
+	return itemDTO
+}
@@ -0,0 +1,271 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/common"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (f *fixture) wish(t *testing.T, productID int64, variantID *int64) model.WishlistItemDTO {
+
+	t.Helper()
+
+	response, err := f.wishlistService.AddToWishlist(context.Background(), model.AddWishlistItemRequest{
+		ProductID: productID,
+		VariantID: variantID,
+		Username:  testUsername,
+	})
+
+	if err != nil {
+		t.Fatalf("add to wishlist: %v", err)
+	}
+
+	return response.Data.(model.WishlistItemResponseData).Item
+}
+
+func (f *fixture) wishlist(t *testing.T) []model.WishlistItemDTO {
+
+	t.Helper()
+
+	response, err := f.wishlistService.GetWishlist(context.Background(), testUsername)
+	if err != nil {
+		t.Fatalf("get wishlist: %v", err)
+	}
+
+	return response.Data.(model.GetWishlistResponseData).Items
+}
+
+// setPrice changes the catalog price, prices are managed outside of the API
+func (f *fixture) setPrice(t *testing.T, productID int64, price int64) {
+
+	t.Helper()
+
+	product, err := f.repos.Product.FindByID(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("find product %d: %v", productID, err)
+	}
+
+	product.Price = price
+	f.store.SeedProducts(product)
+}
+
+func TestAddToWishlistValidation(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	f.wish(t, 1, nil)
+
+	tests := []struct {
+		name      string
+		productID int64
+		variantID *int64
+		kind      error
+	}{
+		{name: "missing product id", productID: 0, kind: common.ErrValidation},
+		{name: "unknown product", productID: 99, kind: common.ErrResourceNotFound},
+		{name: "inactive product", productID: 3, kind: common.ErrValidation},
+		{name: "variant required", productID: 4, kind: common.ErrValidation},
+		{name: "unknown variant", productID: 4, variantID: int64Ptr(99), kind: common.ErrValidation},
+		{name: "inactive variant", productID: 4, variantID: int64Ptr(43), kind: common.ErrValidation},
+		{name: "variant of another product", productID: 2, variantID: int64Ptr(41), kind: common.ErrValidation},
+		{name: "already saved", productID: 1, kind: common.ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.wishlistService.AddToWishlist(context.Background(), model.AddWishlistItemRequest{
+				ProductID: tt.productID,
+				VariantID: tt.variantID,
+				Username:  testUsername,
+			})
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+}
+
+func TestGetWishlistShowsLivePriceAndAvailability(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	potItem := f.wish(t, 1, nil)
+	throwItem := f.wish(t, 2, nil)
+	teeItem := f.wish(t, 4, int64Ptr(42))
+
+	if teeItem.Price != 95000 || teeItem.AddedPrice != 95000 || teeItem.SKU != "TEE-M-WHITE" || teeItem.Stock != 5 || !teeItem.IsAvailable {
+		t.Fatalf("unexpected variant item %+v", teeItem)
+	}
+
+	f.setPrice(t, 1, 12000)
+	f.submitOrder(t, submitRequest(model.OrderItemRequest{ProductId: 2, PriceUsed: 120000, Quantity: 3}))
+
+	items := f.wishlist(t)
+
+	if len(items) != 3 || items[0].ID != teeItem.ID || items[1].ID != throwItem.ID || items[2].ID != potItem.ID {
+		t.Fatalf("expected the last saved item first, got %+v", items)
+	}
+
+	if pot := items[2]; pot.Price != 12000 || pot.AddedPrice != 15000 || !pot.IsAvailable {
+		t.Fatalf("expected the live price, got %+v", pot)
+	}
+
+	if throw := items[1]; throw.Stock != 0 || throw.IsAvailable {
+		t.Fatalf("expected the sold out item to be unavailable, got %+v", throw)
+	}
+}
+
+func TestRemoveFromWishlist(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	item := f.wish(t, 1, nil)
+
+	f.addAccount(t, "janedoe")
+
+	_, err := f.wishlistService.RemoveFromWishlist(ctx, model.RemoveWishlistItemRequest{ItemID: item.ID, Username: "janedoe"})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+
+	_, err = f.wishlistService.RemoveFromWishlist(ctx, model.RemoveWishlistItemRequest{ItemID: item.ID, Username: testUsername})
+	if err != nil {
+		t.Fatalf("remove from wishlist: %v", err)
+	}
+
+	if items := f.wishlist(t); len(items) != 0 {
+		t.Fatalf("expected an empty wishlist, got %+v", items)
+	}
+
+	_, err = f.wishlistService.RemoveFromWishlist(ctx, model.RemoveWishlistItemRequest{ItemID: item.ID, Username: testUsername})
+	assertErrorKind(t, err, common.ErrResourceNotFound)
+}
+
+func TestOrderWishlistItemsAtLivePrices(t *testing.T) {
+
+	f := newFixture(t)
+	f.seedTee()
+
+	potItem := f.wish(t, 1, nil)
+	teeItem := f.wish(t, 4, int64Ptr(42))
+	throwItem := f.wish(t, 2, nil)
+
+	f.setPrice(t, 1, 12000)
+
+	response, err := f.wishlistService.OrderWishlistItems(context.Background(), model.OrderWishlistItemsRequest{
+		DeliveryAddress: "Jl. Merdeka 1, Jakarta",
+		Items: []model.WishlistOrderLine{
+			{ItemID: potItem.ID, Quantity: 2},
+			{ItemID: teeItem.ID},
+		},
+		Username: testUsername,
+	})
+
+	if err != nil {
+		t.Fatalf("order wishlist items: %v", err)
+	}
+
+	data := response.Data.(model.OrderWishlistItemsResponseData)
+
+	if data.Order.Total != 2*12000+95000 || len(data.RemovedItemIDs) != 2 {
+		t.Fatalf("unexpected order %+v", data)
+	}
+
+	if stock := f.stockOf(t, 1); stock != 8 {
+		t.Fatalf("expected stock 8, got %d", stock)
+	}
+
+	if stock := f.variantStockOf(t, 42); stock != 4 {
+		t.Fatalf("expected variant stock 4, got %d", stock)
+	}
+
+	if items := f.wishlist(t); len(items) != 1 || items[0].ID != throwItem.ID {
+		t.Fatalf("expected only the item left out of the order, got %+v", items)
+	}
+}
+
+func TestOrderWishlistItemsFailureKeepsTheItems(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	item := f.wish(t, 2, nil)
+
+	tests := []struct {
+		name  string
+		lines []model.WishlistOrderLine
+		kind  error
+	}{
+		{name: "no items", kind: common.ErrValidation},
+		{name: "unknown item", lines: []model.WishlistOrderLine{{ItemID: 99}}, kind: common.ErrResourceNotFound},
+		{name: "item twice", lines: []model.WishlistOrderLine{{ItemID: item.ID}, {ItemID: item.ID}}, kind: common.ErrValidation},
+		{name: "insufficient stock", lines: []model.WishlistOrderLine{{ItemID: item.ID, Quantity: 4}}, kind: common.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.wishlistService.OrderWishlistItems(ctx, model.OrderWishlistItemsRequest{
+				DeliveryAddress: "Jl. Merdeka 1, Jakarta",
+				Items:           tt.lines,
+				Username:        testUsername,
+			})
+			assertErrorKind(t, err, tt.kind)
+		})
+	}
+
+	if items := f.wishlist(t); len(items) != 1 {
+		t.Fatalf("expected the item to stay on the wishlist, got %+v", items)
+	}
+
+	if stock := f.stockOf(t, 2); stock != 3 {
+		t.Fatalf("expected stock 3, got %d", stock)
+	}
+}
+
+func TestNotifyPriceDropsOncePerDrop(t *testing.T) {
+
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.wish(t, 1, nil)
+	f.wish(t, 2, nil)
+
+	sent, err := f.wishlistService.NotifyPriceDrops(ctx)
+	if err != nil || sent != 0 {
+		t.Fatalf("expected no notice without a price change, got %d %v", sent, err)
+	}
+
+	f.setPrice(t, 1, 12000)
+
+	sent, err = f.wishlistService.NotifyPriceDrops(ctx)
+	if err != nil || sent != 1 {
+		t.Fatalf("expected 1 notice, got %d %v", sent, err)
+	}
+
+	notice := f.notifier.priceDrops[0]
+
+	if notice.ProductID != 1 || notice.OldPrice != 15000 || notice.NewPrice != 12000 || notice.AccountUsername != testUsername || notice.Email != "john@example.com" {
+		t.Fatalf("unexpected notice %+v", notice)
+	}
+
+	// The same drop is not notified twice, a rise is not a drop
+	f.setPrice(t, 1, 13000)
+
+	sent, err = f.wishlistService.NotifyPriceDrops(ctx, 1)
+	if err != nil || sent != 0 {
+		t.Fatalf("expected no notice, got %d %v", sent, err)
+	}
+
+	// Drops are measured against the last notified price
+	f.setPrice(t, 1, 11000)
+
+	sent, err = f.wishlistService.NotifyPriceDrops(ctx, 1, 2)
+	if err != nil || sent != 1 || f.notifier.priceDrops[1].OldPrice != 12000 || f.notifier.priceDrops[1].NewPrice != 11000 {
+		t.Fatalf("expected a notice from 12000 to 11000, got %d %v %+v", sent, err, f.notifier.priceDrops)
+	}
+
+	if items := f.wishlist(t); items[1].AddedPrice != 15000 {
+		t.Fatalf("expected the added price to stay, got %+v", items[1])
+	}
+}
@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+/*
+*
+
+	BlobStore keeps uploaded files (product images, thumbnails) outside the database :
+	- Keys are slash separated relative paths such as "products/12/abc.jpg"
+	- Put overwrites an existing key, Delete of a missing key is not an error
+	- URL is the public address of a key, it does not check the key exists
+
+*
+*/
+type BlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
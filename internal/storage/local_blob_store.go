@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBlobStore writes blobs under a directory of the local filesystem, the directory is
+// expected to be served at baseURL (see app.NewRouter)
+type LocalBlobStore struct {
+	root    string
+	baseURL string
+}
+
+func NewLocalBlobStore(root string, baseURL string) *LocalBlobStore {
+	return &LocalBlobStore{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (ls *LocalBlobStore) Put(ctx context.Context, key string, content io.Reader, contentType string) error {
+
+	target, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(target), 0o755)
+	if err != nil {
+		return fmt.Errorf("create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first so a reader never sees a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create blob: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, contextReader{ctx: ctx, reader: content})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("write blob: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), target)
+	if err != nil {
+		return fmt.Errorf("store blob: %w", err)
+	}
+
+	return nil
+}
+
+func (ls *LocalBlobStore) Delete(ctx context.Context, key string) error {
+
+	target, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete blob: %w", err)
+	}
+
+	return nil
+}
+
+func (ls *LocalBlobStore) URL(key string) string {
+	return ls.baseURL + "/" + key
+}
+
+// path rejects keys escaping the root directory
+func (ls *LocalBlobStore) path(key string) (string, error) {
+
+	cleaned := path.Clean("/" + key)
+
+	if key == "" || cleaned == "/" || cleaned != "/"+key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(ls.root, filepath.FromSlash(key)), nil
+}
+
+// contextReader stops a copy once the context is done
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return cr.reader.Read(p)
+}
@@ -0,0 +1,120 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestGetAccountDetail(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/account/detail", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetAccountDetailResponseData](t, rec)
+
+	if data.Account.Username != fixtureUsername || data.Account.Email != fixtureEmail {
+		t.Fatalf("unexpected account %+v", data.Account)
+	}
+}
+
+func TestUpdateAccount(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodPut, "/api/v1/account/update", map[string]string{
+		"displayName":       "Johnny",
+		"email":             "johnny@example.com",
+		"registeredAddress": "Jl. Thamrin 3, Jakarta",
+	}, token)
+
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.UpdateAccountResponseData](t, rec)
+
+	if data.Account.DisplayName != "Johnny" || data.Account.Email != "johnny@example.com" {
+		t.Fatalf("unexpected account %+v", data.Account)
+	}
+}
+
+func TestUpdatePassword(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodPut, "/api/v1/account/update/password", map[string]string{
+		"oldPassword": fixturePassword,
+		"newPassword": "Brand#New789",
+	}, token)
+
+	expectStatus(t, rec, http.StatusOK)
+
+	if token := h.Login(t, fixtureUsername, "Brand#New789"); token == "" {
+		t.Fatal("expected a token with the new password")
+	}
+}
+
+func TestGetAccountOrders(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	first := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1})
+	h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 2})
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/account/orders?isPaginate=true&page=1&perPage=10", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetAccountOrdersResponseData](t, rec)
+
+	if data.Metadata.TotalData != 2 || len(data.Orders) != 2 {
+		t.Fatalf("expected 2 orders, got %+v", data.Metadata)
+	}
+
+	// ILIKE filter on the order reference
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/orders?isPaginate=false&orderReference="+first.OrderReference[5:], nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	data = decodeData[model.GetAccountOrdersResponseData](t, rec)
+
+	if len(data.Orders) != 1 || data.Orders[0].OrderReference != first.OrderReference {
+		t.Fatalf("expected only %s, got %+v", first.OrderReference, data.Orders)
+	}
+}
+
+func TestGetAccountOrdersCursorPagination(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	older := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1})
+	newer := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1})
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/account/orders?mode=cursor&perPage=1", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	first := decodeData[model.GetAccountOrdersResponseData](t, rec)
+
+	if len(first.Orders) != 1 || first.Orders[0].OrderReference != newer.OrderReference || first.Metadata.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	// An order placed meanwhile does not shift the next page
+	h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1})
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/orders?perPage=1&cursor="+first.Metadata.NextCursor, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	second := decodeData[model.GetAccountOrdersResponseData](t, rec)
+
+	if len(second.Orders) != 1 || second.Orders[0].OrderReference != older.OrderReference || second.Metadata.NextCursor != "" {
+		t.Fatalf("unexpected second page %+v", second)
+	}
+}
@@ -0,0 +1,87 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestAddressBookAndOrderAddressSnapshot(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	home := map[string]interface{}{
+		"recipientName": "John Doe",
+		"phone":         "+62 812-3456-7890",
+		"line1":         "Jl. Merdeka 1",
+		"city":          "Jakarta",
+		"region":        "id-jk",
+		"postalCode":    "10110",
+		"country":       "id",
+	}
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/account/addresses", map[string]interface{}{"recipientName": "John Doe"}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/addresses", home, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	address := decodeData[model.AddressResponseData](t, rec).Address
+
+	if !address.IsDefault || address.Region != "ID-JK" || address.Country != "ID" {
+		t.Fatalf("unexpected address %+v", address)
+	}
+
+	path := "/api/v1/account/addresses/" + strconv.FormatInt(address.ID, 10)
+
+	// Staff have an address book of their own
+	rec = h.Do(t, http.MethodPut, path, home, staff)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"addressId":  address.ID,
+		"orderItems": []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	order := decodeData[model.SubmitOrderResponseData](t, rec)
+
+	home["line1"] = "Jl. Sudirman 99"
+
+	rec = h.Do(t, http.MethodPut, path, home, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodDelete, path, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if addresses := decodeData[model.GetAddressesResponseData](t, rec).Addresses; len(addresses) != 0 {
+		t.Fatalf("expected no address left, got %+v", addresses)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.Delivery == nil || detail.Delivery.Line1 != "Jl. Merdeka 1" || detail.TaxRegion != "ID-JK" {
+		t.Fatalf("expected the address at submission, got %+v in %s", detail.Delivery, detail.TaxRegion)
+	}
+
+	var addressID *int64
+
+	err := h.DB.Raw("SELECT address_id FROM orders WHERE order_reference = ?", order.OrderReference).Scan(&addressID).Error
+	if err != nil {
+		t.Fatalf("read order: %v", err)
+	}
+
+	if addressID != nil {
+		t.Fatalf("deleting the address should clear address_id, got %d", *addressID)
+	}
+}
@@ -0,0 +1,81 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestRegisterThenLogin(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/auth/register", map[string]string{
+		"username":          "janedoe",
+		"displayName":       "Jane Doe",
+		"email":             "jane@example.com",
+		"loginPassword":     "Another#456",
+		"registeredAddress": "Jl. Sudirman 2, Jakarta",
+	}, "")
+
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.RegisterResponseData](t, rec)
+
+	if data.Account.Username != "janedoe" || !data.Account.IsActive {
+		t.Fatalf("unexpected account %+v", data.Account)
+	}
+
+	if token := h.Login(t, "janedoe", "Another#456"); token == "" {
+		t.Fatal("expected a token")
+	}
+}
+
+func TestRegisterRejectsTakenUsernameAndEmail(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/auth/register", map[string]string{
+		"username":      fixtureUsername,
+		"displayName":   "Other John",
+		"email":         "other@example.com",
+		"loginPassword": "Another#456",
+	}, "")
+
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/auth/register", map[string]string{
+		"username":      "otherjohn",
+		"displayName":   "Other John",
+		"email":         fixtureEmail,
+		"loginPassword": "Another#456",
+	}, "")
+
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestLoginRejectsWrongPassword(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"username": fixtureUsername,
+		"password": "Wrong#Password1",
+	}, "")
+
+	expectStatus(t, rec, http.StatusUnauthorized)
+}
+
+func TestProtectedRoutesRequireToken(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/account/detail", nil, "")
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/detail", nil, "not-a-token")
+	expectStatus(t, rec, http.StatusForbidden)
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestOrderInAnotherCurrencyIsChargedFromThePriceList(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPut, "/api/v1/admin/products/1/currency-prices", map[string]interface{}{"currency": "USD", "price": 150}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/currency-prices", map[string]interface{}{"currency": "IDR", "price": 15000}, staff)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/currency-prices", map[string]interface{}{"currency": "EUR", "price": 150}, staff)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/currency-prices", map[string]interface{}{"currency": "usd", "price": 150}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	pricing := decodeData[model.ProductPricingResponseData](t, rec).Pricing
+
+	if pricing.Currency != constant.CurrencyIDR || len(pricing.CurrencyPrices) != 1 || pricing.CurrencyPrices[0].Currency != "USD" || pricing.CurrencyPrices[0].Price != 150 {
+		t.Fatalf("unexpected pricing %+v", pricing)
+	}
+
+	// Listings in USD only hold the products with a USD price, at that price
+	rec = h.Do(t, http.MethodGet, "/api/v1/products?isActive=false&currency=USD&maxPrice=200&sort=price", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	listed := decodeData[model.GetProductsResponseData](t, rec).Products
+
+	if len(listed) != 1 || listed[0].ID != 1 || listed[0].Price != 150 || listed[0].Currency != "USD" {
+		t.Fatalf("expected only the USD priced product, got %+v", listed)
+	}
+
+	order := map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"currency":        "USD",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", order, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	order["orderItems"] = []map[string]interface{}{{"productId": 1, "priceUsed": 150, "quantity": 2}}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", order, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	submitted := decodeData[model.SubmitOrderResponseData](t, rec)
+
+	if submitted.Currency != "USD" || submitted.Total != 300 {
+		t.Fatalf("expected a USD order of 300, got %+v", submitted)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+submitted.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.Currency != "USD" || detail.Payment.Currency != "USD" || detail.Payment.Total != 300 {
+		t.Fatalf("expected the payment in the order currency, got %+v", detail.Payment)
+	}
+
+	// The database refuses an item in another currency than its order
+	err := h.DB.Exec("UPDATE order_items SET currency = 'IDR' WHERE order_reference = ?", submitted.OrderReference).Error
+	if err == nil {
+		t.Fatalf("an order item should not change currency apart from its order")
+	}
+
+	// A price of 0 takes the product off the USD price list
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/currency-prices", map[string]interface{}{"currency": "USD", "price": 0}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if pricing := decodeData[model.ProductPricingResponseData](t, rec).Pricing; len(pricing.CurrencyPrices) != 0 {
+		t.Fatalf("expected an empty price list, got %+v", pricing.CurrencyPrices)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", order, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
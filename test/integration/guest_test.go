@@ -0,0 +1,139 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/handler"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+// DoGuest sends a request with the access token of a guest order
+func (h *Harness) DoGuest(t *testing.T, method string, path string, body interface{}, orderToken string) *httptest.ResponseRecorder {
+
+	t.Helper()
+
+	content, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("encode body: %v", err)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(content))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handler.OrderTokenHeader, orderToken)
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestGuestCheckoutAndClaim(t *testing.T) {
+
+	h := newHarness(t)
+
+	guestOrder := map[string]interface{}{
+		"email": "John@Example.com",
+		"address": map[string]interface{}{
+			"recipientName": "John Doe",
+			"phone":         "+62 812-3456-7890",
+			"line1":         "Jl. Merdeka 1",
+			"city":          "Jakarta",
+			"region":        "ID-JK",
+			"postalCode":    "10110",
+			"country":       "ID",
+		},
+		"orderItems": []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 2}},
+	}
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/guest/orders", map[string]interface{}{"email": "nobody"}, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/guest/orders", guestOrder, "")
+	expectStatus(t, rec, http.StatusCreated)
+
+	order := decodeData[model.SubmitGuestOrderResponseData](t, rec)
+
+	if order.AccessToken == "" || order.Total != 30000 {
+		t.Fatalf("unexpected guest order %+v", order)
+	}
+
+	path := "/api/v1/guest/orders/" + order.OrderReference
+
+	rec = h.DoGuest(t, http.MethodGet, path, nil, "")
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.DoGuest(t, http.MethodGet, path, nil, order.AccessToken)
+	expectStatus(t, rec, http.StatusOK)
+
+	if detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order; detail.Delivery == nil || detail.TaxRegion != "ID-JK" {
+		t.Fatalf("expected the guest address, got %+v", detail)
+	}
+
+	rec = h.DoGuest(t, http.MethodPost, path+"/payment", map[string]interface{}{
+		"cardHolderName": "John Doe",
+		"cardNumber":     "4111 1111 1111 1111",
+		"status":         constant.PaymentStatusReceived,
+	}, order.AccessToken)
+	expectStatus(t, rec, http.StatusOK)
+
+	// The account of the same email claims the order once the email is verified
+	token := h.LoginFixture(t)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/guest-orders/claim", nil, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/email/verification", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/email/verify", map[string]interface{}{"code": "not a code"}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// The code only reaches the log, the test replaces it with a known one
+	sum := sha256.Sum256([]byte(fixtureUsername + ":424242"))
+
+	err := h.DB.Exec("UPDATE accounts SET email_verification_hash = ?, email_verification_attempts = 0 WHERE username = ?", hex.EncodeToString(sum[:]), fixtureUsername).Error
+	if err != nil {
+		t.Fatalf("set verification code: %v", err)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/email/verify", map[string]interface{}{"code": "424242"}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if account := decodeData[model.UpdateAccountResponseData](t, rec).Account; !account.EmailVerified {
+		t.Fatalf("expected a verified email, got %+v", account)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/guest-orders/claim", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	claimed := decodeData[model.ClaimGuestOrdersResponseData](t, rec).Orders
+
+	if len(claimed) != 1 || claimed[0].OrderReference != order.OrderReference || claimed[0].Status != constant.OrderStatusPaymentReceived {
+		t.Fatalf("expected the paid guest order to be claimed, got %+v", claimed)
+	}
+
+	rec = h.DoGuest(t, http.MethodGet, path, nil, order.AccessToken)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/orders", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if orders := decodeData[model.GetAccountOrdersResponseData](t, rec).Orders; len(orders) != 1 || orders[0].OrderReference != order.OrderReference {
+		t.Fatalf("expected the claimed order in the account, got %+v", orders)
+	}
+
+	// The database refuses an order with neither an account nor a guest
+	err = h.DB.Exec("UPDATE orders SET account_username = NULL, guest_token_hash = '' WHERE order_reference = ?", order.OrderReference).Error
+	if err == nil {
+		t.Fatalf("an order should belong to an account or to a guest")
+	}
+}
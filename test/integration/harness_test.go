@@ -0,0 +1,303 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jhasudungan/terraloom-core-api/internal/app"
+	"github.com/jhasudungan/terraloom-core-api/internal/config"
+	"github.com/jhasudungan/terraloom-core-api/internal/migration"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testDatabase = "terraloom_test"
+
+	fixtureUsername = "johndoe"
+	fixturePassword = "Secret#123"
+	fixtureEmail    = "john@example.com"
+
+	staffUsername = "janestaff"
+	staffEmail    = "staff@example.com"
+)
+
+var (
+	// skipReason is set when no local PostgreSQL can be started, every test is skipped
+	skipReason string
+
+	sharedDB     *gorm.DB
+	sharedRouter *gin.Engine
+	passwordHash string
+)
+
+type Harness struct {
+	DB     *gorm.DB
+	Router *gin.Engine
+}
+
+func TestMain(m *testing.M) {
+
+	gin.SetMode(gin.TestMode)
+	logrus.SetOutput(io.Discard)
+
+	pg, err := startPostgres()
+	if err != nil {
+		skipReason = err.Error()
+		os.Exit(m.Run())
+	}
+
+	code, err := runWithDatabase(pg, m)
+	pg.stop()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "integration harness:", err)
+		os.Exit(1)
+	}
+
+	os.Exit(code)
+}
+
+func runWithDatabase(pg *ephemeralPostgres, m *testing.M) (int, error) {
+
+	admin, err := app.OpenDatabase(pg.config("postgres"))
+	if err != nil {
+		return 0, err
+	}
+
+	err = admin.Exec("CREATE DATABASE " + testDatabase).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sqlAdmin, _ := admin.DB()
+	sqlAdmin.Close()
+
+	cfg := config.Default()
+	cfg.Database = pg.config(testDatabase)
+	cfg.Auth.JWTSecret = "integration-test-secret"
+
+	mediaDir, err := os.MkdirTemp("", "terraloom-media-")
+	if err != nil {
+		return 0, err
+	}
+
+	defer os.RemoveAll(mediaDir)
+
+	cfg.Storage.LocalDir = mediaDir
+
+	db, err := app.OpenDatabase(cfg.Database)
+	if err != nil {
+		return 0, err
+	}
+
+	db.Logger = logger.Default.LogMode(logger.Silent)
+
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(fixturePassword), bcrypt.MinCost)
+	if err != nil {
+		return 0, err
+	}
+
+	sharedDB = db
+	sharedRouter = app.NewRouter(cfg, db)
+	passwordHash = string(hash)
+
+	return m.Run(), nil
+}
+
+// newHarness returns the shared router over a freshly truncated and seeded database
+func newHarness(t *testing.T) *Harness {
+
+	t.Helper()
+
+	if skipReason != "" {
+		t.Skip(skipReason)
+	}
+
+	h := &Harness{DB: sharedDB, Router: sharedRouter}
+
+	h.reset(t)
+	h.seed(t)
+
+	return h
+}
+
+func (h *Harness) reset(t *testing.T) {
+
+	t.Helper()
+
+	var tables []string
+	err := h.DB.Raw("SELECT tablename FROM pg_tables WHERE schemaname = 'public' AND tablename <> 'schema_migrations'").Scan(&tables).Error
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+
+	for _, table := range tables {
+		err = h.DB.Exec(fmt.Sprintf("TRUNCATE TABLE public.%q RESTART IDENTITY CASCADE", table)).Error
+		if err != nil {
+			t.Fatalf("truncate %s: %v", table, err)
+		}
+	}
+
+	// id sequences are not owned by their columns, RESTART IDENTITY leaves them untouched
+	err = h.DB.Exec("SELECT setval(c.oid, 1, false) FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind = 'S' AND n.nspname = 'public'").Error
+	if err != nil {
+		t.Fatalf("reset sequences: %v", err)
+	}
+}
+
+/*
+*
+
+	Fixtures :
+	- Category 1
+	- Product 1 "Clay Pot"     price 15000  stock 10 active
+	- Product 2 "Linen Throw"  price 120000 stock 3  active
+	- Product 3 "Retired Vase" price 50000  stock 5  inactive
+	- Account "johndoe" with password fixturePassword
+
+*
+*/
+func (h *Harness) seed(t *testing.T) {
+
+	t.Helper()
+
+	statements := []string{
+		`INSERT INTO categories (id, name, description, is_active) VALUES (1, 'Home', 'Home goods', true)`,
+		`INSERT INTO products (id, category_id, name, description, stock, price, image_url, is_active) VALUES
+			(1, 1, 'Clay Pot', 'Hand thrown clay pot', 10, 15000, 'https://img.example.com/1.jpg', true),
+			(2, 1, 'Linen Throw', 'Washed linen throw blanket', 3, 120000, 'https://img.example.com/2.jpg', true),
+			(3, 1, 'Retired Vase', 'No longer sold', 5, 50000, 'https://img.example.com/3.jpg', false)`,
+		`INSERT INTO inventory_movements (product_id, movement_type, quantity, stock_after, created_by)
+			SELECT id, 'OPENING_BALANCE', stock, stock, 'SYSTEM' FROM products`,
+		`SELECT setval('product_id_sequence', 3)`,
+		`SELECT setval('category_id_sequence', 1)`,
+	}
+
+	for _, statement := range statements {
+		err := h.DB.Exec(statement).Error
+		if err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	err := h.DB.Exec(`INSERT INTO accounts (username, display_name, email, login_password, registered_address, is_active)
+		VALUES (?, 'John Doe', ?, ?, 'Jl. Merdeka 1, Jakarta', true)`, fixtureUsername, fixtureEmail, passwordHash).Error
+	if err != nil {
+		t.Fatalf("seed account: %v", err)
+	}
+
+	err = h.DB.Exec(`INSERT INTO accounts (username, display_name, email, login_password, registered_address, is_active, role)
+		VALUES (?, 'Jane Staff', ?, ?, 'Jl. Merdeka 2, Jakarta', true, 'STAFF')`, staffUsername, staffEmail, passwordHash).Error
+	if err != nil {
+		t.Fatalf("seed staff account: %v", err)
+	}
+}
+
+// Do sends a request through the router, body is encoded as JSON unless nil
+func (h *Harness) Do(t *testing.T, method string, path string, body interface{}, token string) *httptest.ResponseRecorder {
+
+	t.Helper()
+
+	var reader io.Reader
+
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(content)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// Login returns a bearer token for the given credentials
+func (h *Harness) Login(t *testing.T, username string, password string) string {
+
+	t.Helper()
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/auth/login", map[string]string{
+		"username": username,
+		"password": password,
+	}, "")
+
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[struct {
+		Token struct {
+			Token string `json:"token"`
+		} `json:"token"`
+	}](t, rec)
+
+	return data.Token.Token
+}
+
+// LoginFixture logs in as the seeded account
+func (h *Harness) LoginFixture(t *testing.T) string {
+	return h.Login(t, fixtureUsername, fixturePassword)
+}
+
+// LoginStaff logs in as the seeded staff account
+func (h *Harness) LoginStaff(t *testing.T) string {
+	return h.Login(t, staffUsername, fixturePassword)
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, status int) {
+
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+}
+
+// decodeData decodes the `data` field of a GeneralResponse
+func decodeData[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+
+	t.Helper()
+
+	var envelope struct {
+		ResponseCode string `json:"responseCode"`
+		Data         T      `json:"data"`
+	}
+
+	err := json.Unmarshal(rec.Body.Bytes(), &envelope)
+	if err != nil {
+		t.Fatalf("decode response: %v: %s", err, rec.Body.String())
+	}
+
+	return envelope.Data
+}
@@ -0,0 +1,105 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+)
+
+func (h *Harness) assertReconciled(t *testing.T) {
+
+	t.Helper()
+
+	inventoryService := service.NewInventoryService(
+		repository.NewTransactionRunner(h.DB),
+		repository.NewProductRepository(h.DB),
+		repository.NewInventoryMovementRepository(h.DB),
+		nil) // Reconcile sends no notifications
+
+	discrepancies, err := inventoryService.Reconcile(context.Background())
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	if len(discrepancies) != 0 {
+		t.Fatalf("expected stock to match the ledger, got %+v", discrepancies)
+	}
+}
+
+func TestInventoryLedgerFollowsOrdersAndAdjustments(t *testing.T) {
+
+	h := newHarness(t)
+	h.seedTee(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	order := h.SubmitOrder(t, token,
+		map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 2},
+		map[string]interface{}{"productId": 4, "variantId": 2, "priceUsed": 95000, "quantity": 1},
+	)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/order/cancel", map[string]string{"orderReference": order.OrderReference}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/1/inventory-movements", map[string]interface{}{
+		"type":     constant.MovementTypeRestock,
+		"quantity": 6,
+		"note":     "Delivery 42",
+	}, staff)
+	expectStatus(t, rec, http.StatusCreated)
+
+	h.assertReconciled(t)
+
+	if stock := h.stockOf(t, 1); stock != 16 {
+		t.Fatalf("expected stock 16, got %d", stock)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/admin/products/1/inventory-movements", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetInventoryMovementsResponseData](t, rec)
+
+	types := make([]string, len(data.Movements))
+	for i, movement := range data.Movements {
+		types[i] = movement.Type
+	}
+
+	expected := []string{
+		constant.MovementTypeRestock,
+		constant.MovementTypeCancellationReturn,
+		constant.MovementTypeOrderReservation,
+		constant.MovementTypeOpeningBalance,
+	}
+
+	if len(types) != len(expected) || data.Stock != 16 {
+		t.Fatalf("unexpected ledger %+v", data)
+	}
+
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Fatalf("expected movements %v, got %v", expected, types)
+		}
+	}
+
+	// The ledger is append-only
+	err := h.DB.Exec("DELETE FROM inventory_movements").Error
+	if err == nil {
+		t.Fatal("expected deleting movements to fail")
+	}
+}
+
+func TestInventoryMovementsRequireStaff(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/admin/products/1/inventory-movements", nil, h.LoginFixture(t))
+	expectStatus(t, rec, http.StatusForbidden)
+}
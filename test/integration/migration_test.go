@@ -0,0 +1,57 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/migration"
+	"gorm.io/gorm"
+)
+
+func TestEnsureUpToDateReportsEveryMissingMigration(t *testing.T) {
+
+	h := newHarness(t)
+	ctx := context.Background()
+
+	migrator, err := migration.NewMigrator(h.DB)
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+
+	err = migrator.EnsureUpToDate(ctx)
+	if err != nil {
+		t.Fatalf("expected the schema to be up to date, got %v", err)
+	}
+
+	rollback := errors.New("rollback")
+
+	// Versions below the latest one are forgotten, the rollback restores them
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+
+		err := tx.Exec("DELETE FROM schema_migrations WHERE version IN (3, 9)").Error
+		if err != nil {
+			return err
+		}
+
+		migrator, err := migration.NewMigrator(tx)
+		if err != nil {
+			return err
+		}
+
+		err = migrator.EnsureUpToDate(ctx)
+
+		if err == nil || !strings.Contains(err.Error(), "0003_product_search_indexes, 0009_inventory_movements") {
+			t.Errorf("expected both gaps to be reported, got %v", err)
+		}
+
+		return rollback
+	})
+
+	if !errors.Is(err, rollback) {
+		t.Fatalf("check the gaps: %v", err)
+	}
+}
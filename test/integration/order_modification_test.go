@@ -0,0 +1,91 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestModifyOrderBeforePayment(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+
+	order := h.SubmitOrder(t, token,
+		map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 2},
+		map[string]interface{}{"productId": 2, "priceUsed": 120000, "quantity": 1},
+	)
+
+	detailPath := "/api/v1/order/detail/" + order.OrderReference
+
+	rec := h.Do(t, http.MethodGet, detailPath, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	references := map[int64]string{}
+
+	for _, item := range decodeData[model.GetOrderDetailReponseData](t, rec).Order.OrderItems {
+		references[item.Product.ID] = item.OrderItemReference
+	}
+
+	path := "/api/v1/order/" + order.OrderReference + "/modify"
+	body := map[string]interface{}{
+		"items": []map[string]interface{}{
+			{"orderItemReference": references[1], "quantity": 4, "priceUsed": 15000},
+			{"orderItemReference": references[2], "quantity": 0},
+		},
+		"deliveryAddress": "Jl. Sudirman 2, Jakarta",
+	}
+
+	rec = h.Do(t, http.MethodPost, path, body, h.LoginStaff(t))
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPost, path, body, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	modified := decodeData[model.ModifyOrderResponseData](t, rec)
+
+	if modified.PreviousTotal != 150000 || modified.Total != 60000 || len(modified.Changes) != 3 {
+		t.Fatalf("expected the total to go from 150000 to 60000, got %+v", modified)
+	}
+
+	if h.stockOf(t, 1) != 6 || h.stockOf(t, 2) != 3 {
+		t.Fatalf("expected the stock deltas applied, got %d %d", h.stockOf(t, 1), h.stockOf(t, 2))
+	}
+
+	var returned int64
+
+	err := h.DB.Raw("SELECT COUNT(*) FROM inventory_movements WHERE movement_type = ? AND reference = ?", constant.MovementTypeModificationReturn, order.OrderReference).Scan(&returned).Error
+	if err != nil || returned != 1 {
+		t.Fatalf("expected the throw returned in the ledger, got %d %v", returned, err)
+	}
+
+	rec = h.Do(t, http.MethodGet, detailPath, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.Total != 60000 || detail.Payment.Total != 60000 || detail.DeliveryAddress != "Jl. Sudirman 2, Jakarta" || len(detail.OrderItems) != 1 {
+		t.Fatalf("expected the order and its payment recomputed, got %+v", detail)
+	}
+
+	if len(detail.History) != 1 || detail.History[0].Action != constant.OrderActionModified || len(detail.History[0].Changes) != 3 {
+		t.Fatalf("expected the modification in the history, got %+v", detail.History)
+	}
+
+	// Once paid the order can no longer be modified
+	rec = h.Do(t, http.MethodPost, "/api/v1/payment/submit", map[string]string{
+		"orderReference": order.OrderReference,
+		"cardHolderName": "John Doe",
+		"cardNumber":     "4111 1111 1111 1234",
+		"status":         constant.PaymentStatusReceived,
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodPost, path, map[string]interface{}{"deliveryAddress": "Jl. Thamrin 3, Jakarta"}, token)
+	expectStatus(t, rec, http.StatusConflict)
+}
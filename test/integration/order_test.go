@@ -0,0 +1,244 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+// SubmitOrder submits the given order items and fails the test unless it succeeds
+func (h *Harness) SubmitOrder(t *testing.T, token string, items ...map[string]interface{}) model.SubmitOrderResponseData {
+
+	t.Helper()
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      items,
+	}, token)
+
+	expectStatus(t, rec, http.StatusOK)
+
+	return decodeData[model.SubmitOrderResponseData](t, rec)
+}
+
+func (h *Harness) stockOf(t *testing.T, productID int64) int64 {
+
+	t.Helper()
+
+	var product entity.Product
+
+	err := h.DB.First(&product, productID).Error
+	if err != nil {
+		t.Fatalf("find product %d: %v", productID, err)
+	}
+
+	return product.Stock
+}
+
+func TestSubmitOrderAndGetDetail(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	order := h.SubmitOrder(t, token,
+		map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 2},
+		map[string]interface{}{"productId": 2, "priceUsed": 120000, "quantity": 1},
+	)
+
+	if order.Total != 150000 || order.OrderStatus != constant.OrderStatusPendingPayment {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	if stock := h.stockOf(t, 1); stock != 8 {
+		t.Fatalf("expected product 1 stock 8, got %d", stock)
+	}
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec)
+
+	if len(detail.Order.OrderItems) != 2 || detail.Order.Payment.Status != constant.PaymentStatusPending || detail.Order.Payment.Total != 150000 {
+		t.Fatalf("unexpected detail %+v", detail.Order)
+	}
+}
+
+func TestSubmitOrderRejectsInsufficientStock(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems": []map[string]interface{}{
+			{"productId": 1, "priceUsed": 15000, "quantity": 1},
+			{"productId": 2, "priceUsed": 120000, "quantity": 4},
+		},
+	}, token)
+
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	if stock := h.stockOf(t, 1); stock != 10 {
+		t.Fatalf("expected product 1 stock untouched, got %d", stock)
+	}
+}
+
+// The conditional stock decrement must prevent overselling the last units
+func TestConcurrentSubmitOrderDoesNotOversell(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	const attempts = 10
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, attempts)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+				"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+				"orderItems":      []map[string]interface{}{{"productId": 2, "priceUsed": 120000, "quantity": 1}},
+			}, token)
+			statuses <- rec.Code
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	succeeded := 0
+	for status := range statuses {
+		if status == http.StatusOK {
+			succeeded++
+		}
+	}
+
+	if succeeded != 3 {
+		t.Fatalf("expected exactly 3 orders for 3 units, got %d", succeeded)
+	}
+
+	if stock := h.stockOf(t, 2); stock != 0 {
+		t.Fatalf("expected product 2 stock 0, got %d", stock)
+	}
+}
+
+// Orders listing the same products in opposite order lock rows by id, so they never deadlock
+func TestConcurrentMultiProductOrdersDoNotDeadlock(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	const attempts = 8
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, attempts)
+
+	for i := 0; i < attempts; i++ {
+		items := []map[string]interface{}{
+			{"productId": 1, "priceUsed": 15000, "quantity": 1},
+			{"productId": 2, "priceUsed": 120000, "quantity": 1},
+		}
+
+		if i%2 == 1 {
+			items[0], items[1] = items[1], items[0]
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+				"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+				"orderItems":      items,
+			}, token)
+			statuses <- rec.Code
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	succeeded := 0
+	for status := range statuses {
+		switch status {
+		case http.StatusOK:
+			succeeded++
+		case http.StatusBadRequest:
+		default:
+			t.Fatalf("unexpected status %d", status)
+		}
+	}
+
+	if succeeded != 3 {
+		t.Fatalf("expected exactly 3 orders for 3 units of product 2, got %d", succeeded)
+	}
+
+	if stock := h.stockOf(t, 1); stock != 7 {
+		t.Fatalf("expected product 1 stock 7, got %d", stock)
+	}
+
+	if stock := h.stockOf(t, 2); stock != 0 {
+		t.Fatalf("expected product 2 stock 0, got %d", stock)
+	}
+
+	h.assertReconciled(t)
+}
+
+func TestCancelOrderReturnsStock(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	order := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 4})
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/order/cancel", map[string]string{"orderReference": order.OrderReference}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.CancelOrderResponseData](t, rec)
+
+	if data.OrderStatus != constant.OrderStatusCancelled || data.PaymentStatus != constant.PaymentStatusCancelled {
+		t.Fatalf("unexpected statuses %+v", data)
+	}
+
+	if stock := h.stockOf(t, 1); stock != 10 {
+		t.Fatalf("expected product 1 stock 10, got %d", stock)
+	}
+}
+
+func TestOrderDetailAndCancelOnlyOpenOwnOrders(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+	staffToken := h.LoginStaff(t)
+
+	order := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 2})
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, staffToken)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/cancel", map[string]string{"orderReference": order.OrderReference}, staffToken)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	if stock := h.stockOf(t, 1); stock != 8 {
+		t.Fatalf("a refused cancel should not return stock, got %d", stock)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/cancel", map[string]string{"orderReference": order.OrderReference}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	// A cancelled order is cancelled once
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/cancel", map[string]string{"orderReference": order.OrderReference}, token)
+	expectStatus(t, rec, http.StatusConflict)
+
+	if stock := h.stockOf(t, 1); stock != 10 {
+		t.Fatalf("expected the stock returned once, got %d", stock)
+	}
+}
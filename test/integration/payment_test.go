@@ -0,0 +1,82 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestSubmitPayment(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	order := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1})
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/payment/submit", map[string]string{
+		"orderReference": order.OrderReference,
+		"cardHolderName": "John Doe",
+		"cardNumber":     "4111 1111 1111 1234",
+		"status":         constant.PaymentStatusReceived,
+	}, token)
+
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec)
+
+	if detail.Order.Status != constant.OrderStatusPaymentReceived || detail.Order.Payment.CardNumber != "************1234" {
+		t.Fatalf("unexpected order after payment %+v", detail.Order)
+	}
+}
+
+func TestSubmitPaymentUnknownOrder(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/payment/submit", map[string]string{
+		"orderReference": "ORDER-UNKNOWN",
+		"status":         constant.PaymentStatusReceived,
+	}, token)
+
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestSubmitPaymentOnlyPaysOrdersOfTheAccount(t *testing.T) {
+
+	h := newHarness(t)
+
+	order := h.SubmitOrder(t, h.LoginFixture(t), map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1})
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/auth/register", map[string]string{
+		"username":          "janedoe",
+		"displayName":       "Jane Doe",
+		"email":             "jane@example.com",
+		"loginPassword":     "Another#456",
+		"registeredAddress": "Jl. Sudirman 2, Jakarta",
+	}, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/payment/submit", map[string]string{
+		"orderReference": order.OrderReference,
+		"cardHolderName": "Jane Doe",
+		"cardNumber":     "4111 1111 1111 1234",
+		"status":         constant.PaymentStatusReceived,
+	}, h.Login(t, "janedoe", "Another#456"))
+
+	expectStatus(t, rec, http.StatusForbidden)
+
+	var status string
+
+	err := h.DB.Raw("SELECT status FROM orders WHERE order_reference = ?", order.OrderReference).Scan(&status).Error
+	if err != nil || status != constant.OrderStatusPendingPayment {
+		t.Fatalf("expected the order to stay unpaid, got %s %v", status, err)
+	}
+}
@@ -0,0 +1,147 @@
+//go:build integration
+
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/config"
+)
+
+// ephemeralPostgres is a throwaway server started from the locally installed
+// PostgreSQL binaries, listening on 127.0.0.1 only and removed after the run
+type ephemeralPostgres struct {
+	binDir  string
+	rootDir string
+	port    int
+}
+
+/*
+*
+
+	Locate initdb and pg_ctl, in order :
+	- TERRALOOM_PG_BIN directory
+	- PATH
+	- Common distribution install directories
+
+*
+*/
+func findPostgresBinaries() (string, error) {
+
+	if dir := os.Getenv("TERRALOOM_PG_BIN"); dir != "" {
+		return dir, nil
+	}
+
+	if initdb, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(initdb), nil
+	}
+
+	patterns := []string{
+		"/usr/lib/postgresql/*/bin/initdb",
+		"/usr/pgsql-*/bin/initdb",
+		"/usr/local/pgsql/bin/initdb",
+		"/opt/homebrew/opt/postgresql*/bin/initdb",
+		"/usr/local/opt/postgresql*/bin/initdb",
+	}
+
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		if len(matches) > 0 {
+			// globs sort ascending, prefer the newest major version
+			return filepath.Dir(matches[len(matches)-1]), nil
+		}
+	}
+
+	return "", errors.New("PostgreSQL binaries (initdb, pg_ctl) not found, install PostgreSQL or set TERRALOOM_PG_BIN")
+}
+
+func startPostgres() (*ephemeralPostgres, error) {
+
+	if os.Geteuid() == 0 {
+		return nil, errors.New("PostgreSQL refuses to run as root, run the integration tests as a regular user")
+	}
+
+	binDir, err := findPostgresBinaries()
+	if err != nil {
+		return nil, err
+	}
+
+	rootDir, err := os.MkdirTemp("", "terraloom-pg-")
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(rootDir)
+		return nil, err
+	}
+
+	pg := &ephemeralPostgres{binDir: binDir, rootDir: rootDir, port: port}
+
+	err = pg.run("initdb", "-D", pg.dataDir(), "-U", "postgres", "-A", "trust", "-E", "UTF8", "--locale=C", "--no-sync")
+	if err != nil {
+		os.RemoveAll(rootDir)
+		return nil, err
+	}
+
+	options := fmt.Sprintf("-F -p %d -k %s -c listen_addresses=127.0.0.1", port, rootDir)
+
+	err = pg.run("pg_ctl", "-D", pg.dataDir(), "-l", filepath.Join(rootDir, "postgres.log"), "-o", options, "-w", "start")
+	if err != nil {
+		os.RemoveAll(rootDir)
+		return nil, err
+	}
+
+	return pg, nil
+}
+
+func (pg *ephemeralPostgres) stop() {
+	_ = pg.run("pg_ctl", "-D", pg.dataDir(), "-m", "immediate", "-w", "stop")
+	os.RemoveAll(pg.rootDir)
+}
+
+func (pg *ephemeralPostgres) config(dbName string) config.DatabaseConfig {
+
+	cfg := config.Default().Database
+	cfg.Host = "127.0.0.1"
+	cfg.Port = strconv.Itoa(pg.port)
+	cfg.User = "postgres"
+	cfg.Name = dbName
+
+	return cfg
+}
+
+func (pg *ephemeralPostgres) dataDir() string {
+	return filepath.Join(pg.rootDir, "data")
+}
+
+func (pg *ephemeralPostgres) run(name string, args ...string) error {
+
+	cmd := exec.Command(filepath.Join(pg.binDir, name), args...)
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%s: %w\n%s", name, err, output)
+	}
+
+	return nil
+}
+
+func freePort() (int, error) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,184 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+)
+
+func TestSalePriceIsChargedAndRecorded(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPut, "/api/v1/admin/products/1/sale", map[string]interface{}{"salePrice": 12000}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/sale", map[string]interface{}{"salePrice": 15000}, staff)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	endsAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/sale", map[string]interface{}{"salePrice": 12000, "endsAt": endsAt}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if pricing := decodeData[model.ProductPricingResponseData](t, rec).Pricing; !pricing.IsOnSale || pricing.EffectivePrice != 12000 {
+		t.Fatalf("unexpected pricing %+v", pricing)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/product/1", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	if product := decodeData[model.GetProductDetailResponseData](t, rec).Product; product.Price != 12000 || product.OriginalPrice == nil || *product.OriginalPrice != 15000 || product.SaleEndsAt == nil {
+		t.Fatalf("expected the sale price with the original price, got %+v", product)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+	}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	order := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 12000, "quantity": 2})
+
+	if order.Total != 24000 {
+		t.Fatalf("expected the sale price to be charged, got %+v", order)
+	}
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/admin/products/1/sale", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/admin/products/1/sale", nil, staff)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/admin/products/1/price-history", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	history := decodeData[model.GetPriceHistoryResponseData](t, rec).History
+
+	if len(history) != 2 || history[0].NewPrice != nil || *history[1].NewPrice != 12000 || history[1].PriceType != constant.PriceTypeSale {
+		t.Fatalf("unexpected history %+v", history)
+	}
+}
+
+func TestScheduledPriceChangeIsAppliedOnce(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/products/2/price-changes", map[string]interface{}{"price": 0}, staff)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/2/price-changes", map[string]interface{}{"price": 130000, "effectiveAt": time.Now().Add(time.Hour)}, staff)
+	expectStatus(t, rec, http.StatusCreated)
+
+	pending := decodeData[model.ProductPricingResponseData](t, rec).Pricing.PendingChanges
+
+	if len(pending) != 1 {
+		t.Fatalf("expected a pending change, got %+v", pending)
+	}
+
+	// Bring the change due, as if the hour passed
+	err := h.DB.Exec("UPDATE product_price_changes SET effective_at = ? WHERE id = ?", time.Now().Add(-time.Minute), pending[0].ID).Error
+	if err != nil {
+		t.Fatalf("bring the change due: %v", err)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/product/2", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	if product := decodeData[model.GetProductDetailResponseData](t, rec).Product; product.Price != 130000 {
+		t.Fatalf("expected the due price, got %+v", product)
+	}
+
+	order := h.SubmitOrder(t, token, map[string]interface{}{"productId": 2, "priceUsed": 130000, "quantity": 1})
+
+	if order.Total != 130000 {
+		t.Fatalf("expected the due price to be charged, got %+v", order)
+	}
+
+	pricingService := service.NewPricingService(
+		repository.NewTransactionRunner(h.DB),
+		repository.NewProductRepository(h.DB),
+		repository.NewProductPriceChangeRepository(h.DB),
+		repository.NewProductPriceHistoryRepository(h.DB),
+		repository.NewProductCurrencyPriceRepository(h.DB),
+		nil) // the job leaves the price drops to its caller
+
+	repriced, err := pricingService.ApplyDuePriceChanges(context.Background())
+	if err != nil || len(repriced) != 0 {
+		t.Fatalf("expected the order to have applied the change, got %v %v", repriced, err)
+	}
+
+	var price int64
+
+	err = h.DB.Raw("SELECT price FROM products WHERE id = 2").Scan(&price).Error
+	if err != nil || price != 130000 {
+		t.Fatalf("expected the price to be written, got %d %v", price, err)
+	}
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/admin/products/2/price-changes/1", nil, staff)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/admin/products/2/price-history", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	history := decodeData[model.GetPriceHistoryResponseData](t, rec).History
+
+	if len(history) != 1 || *history[0].OldPrice != 120000 || *history[0].NewPrice != 130000 || history[0].PriceChangeID == nil || history[0].CreatedBy != constant.SYSTEM {
+		t.Fatalf("unexpected history %+v", history)
+	}
+}
+
+func TestListingsFilterAndSortOnTheSalePrice(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodPut, "/api/v1/admin/products/2/sale", map[string]interface{}{"salePrice": 10000}, h.LoginStaff(t))
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/products?isActive=false&sort=price", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	// Price ascending : 2 (10000 on sale), 1 (15000), 3 (50000)
+	if products := decodeData[model.GetProductsResponseData](t, rec).Products; len(products) != 3 || products[0].ID != 2 || products[1].ID != 1 || products[2].ID != 3 {
+		t.Fatalf("expected the sale price to drive the sort, got %+v", products)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/products?isActive=false&maxPrice=12000", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	if products := decodeData[model.GetProductsResponseData](t, rec).Products; len(products) != 1 || products[0].ID != 2 {
+		t.Fatalf("expected only the product on sale under 12000, got %+v", products)
+	}
+
+	path := "/api/v1/products?isActive=false&sort=price&mode=cursor&perPage=1&withTotal=false"
+
+	rec = h.Do(t, http.MethodGet, path, nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	first := decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(first.Products) != 1 || first.Products[0].ID != 2 || first.Metadata.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	rec = h.Do(t, http.MethodGet, path+"&cursor="+first.Metadata.NextCursor, nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	if second := decodeData[model.GetProductsResponseData](t, rec); len(second.Products) != 1 || second.Products[0].ID != 1 {
+		t.Fatalf("unexpected second page %+v", second)
+	}
+}
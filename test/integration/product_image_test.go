@@ -0,0 +1,111 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type imageData struct {
+	Image struct {
+		ID        int64  `json:"id"`
+		URL       string `json:"url"`
+		IsPrimary bool   `json:"isPrimary"`
+		Sizes     []struct {
+			Size string `json:"size"`
+			URL  string `json:"url"`
+		} `json:"sizes"`
+	} `json:"image"`
+}
+
+// uploadImage posts a width x height PNG as a multipart form
+func (h *Harness) uploadImage(t *testing.T, productID string, width int, height int, token string) *httptest.ResponseRecorder {
+
+	t.Helper()
+
+	var content bytes.Buffer
+	if err := png.Encode(&content, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	file, err := form.CreateFormFile("file", "photo.png")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+
+	file.Write(content.Bytes())
+	form.WriteField("altText", "Front view")
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/products/"+productID+"/images", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestProductImageUploadIsServedAndListed(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginStaff(t)
+
+	rec := h.uploadImage(t, "1", 800, 600, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	uploaded := decodeData[imageData](t, rec)
+
+	if !uploaded.Image.IsPrimary || len(uploaded.Image.Sizes) != 2 {
+		t.Fatalf("unexpected image %+v", uploaded.Image)
+	}
+
+	// Original and thumbnails are served from the media directory
+	for _, url := range []string{uploaded.Image.URL, uploaded.Image.Sizes[0].URL} {
+		rec = h.Do(t, http.MethodGet, url, nil, "")
+		expectStatus(t, rec, http.StatusOK)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/product/1", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[struct {
+		Product struct {
+			ImageUrl string `json:"imageUrl"`
+			Images   []struct {
+				ID int64 `json:"id"`
+			} `json:"images"`
+		} `json:"product"`
+	}](t, rec)
+
+	if detail.Product.ImageUrl != uploaded.Image.URL || len(detail.Product.Images) != 1 || detail.Product.Images[0].ID != uploaded.Image.ID {
+		t.Fatalf("unexpected product %+v", detail.Product)
+	}
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/admin/products/1/images/1", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, uploaded.Image.URL, nil, "")
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestProductImageManagementRequiresStaff(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.uploadImage(t, "1", 40, 40, h.LoginFixture(t))
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.uploadImage(t, "99", 40, 40, h.LoginStaff(t))
+	expectStatus(t, rec, http.StatusNotFound)
+}
@@ -0,0 +1,201 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestGetProductsFiltersByNameCaseInsensitively(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/products?name=LINEN&isActive=true&isPaginate=false", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(data.Products) != 1 || data.Products[0].ID != 2 {
+		t.Fatalf("expected only product 2, got %+v", data.Products)
+	}
+}
+
+func TestGetProductsActiveOnlyWithPagination(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/products?isActive=true&isPaginate=true&page=1&perPage=1", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetProductsResponseData](t, rec)
+
+	if data.Metadata.TotalData != 2 || data.Metadata.TotalPage != 2 || len(data.Products) != 1 {
+		t.Fatalf("unexpected page %+v with %d products", data.Metadata, len(data.Products))
+	}
+}
+
+func TestGetProductDetail(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/product/1", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetProductDetailResponseData](t, rec)
+
+	if data.Product.Name != "Clay Pot" || data.Product.Stock != 10 {
+		t.Fatalf("unexpected product %+v", data.Product)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/product/999", nil, "")
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/product/abc", nil, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestGetProductsWithoutParametersUsesDefaults(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/products", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetProductsResponseData](t, rec)
+
+	// Active products only, newest first (equal timestamps fall back to id)
+	if data.Metadata.TotalData != 2 || len(data.Products) != 2 || data.Products[0].ID != 2 {
+		t.Fatalf("unexpected products %+v", data.Products)
+	}
+}
+
+func TestGetProductsPriceRangeCategoryAndSort(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/products?isActive=false&minPrice=15000&maxPrice=60000&categoryId=1&sort=price&direction=desc", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(data.Products) != 2 || data.Products[0].ID != 3 || data.Products[1].ID != 1 {
+		t.Fatalf("unexpected products %+v", data.Products)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/products?sort=random", nil, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestGetProductsBestSellingAndInStock(t *testing.T) {
+
+	h := newHarness(t)
+	token := h.LoginFixture(t)
+
+	h.SubmitOrder(t, token, map[string]interface{}{"productId": 2, "priceUsed": 120000, "quantity": 3})
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/products?sort=best-selling", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(data.Products) != 2 || data.Products[0].ID != 2 {
+		t.Fatalf("expected product 2 first, got %+v", data.Products)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/products?inStock=true", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data = decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(data.Products) != 1 || data.Products[0].ID != 1 {
+		t.Fatalf("expected only product 1 in stock, got %+v", data.Products)
+	}
+}
+
+func TestSearchProductsRanksAndHighlights(t *testing.T) {
+
+	h := newHarness(t)
+
+	err := h.DB.Exec(`INSERT INTO products (id, category_id, name, description, stock, price, image_url, is_active)
+		VALUES (4, 1, 'Stoneware Bowl', 'Pairs with any clay pot', 4, 9000, 'https://img.example.com/4.jpg', true)`).Error
+	if err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/products/search?q=cla", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.SearchProductsResponseData](t, rec)
+
+	if data.Mode != constant.SearchModeFullText || len(data.Results) != 2 {
+		t.Fatalf("unexpected search result %+v", data)
+	}
+
+	// Name match ranks above description match
+	if data.Results[0].Product.ID != 1 || data.Results[1].Product.ID != 4 {
+		t.Fatalf("unexpected ranking %+v", data.Results)
+	}
+
+	if data.Results[0].Highlight.Name != "<mark>Clay</mark> Pot" {
+		t.Fatalf("unexpected highlight %q", data.Results[0].Highlight.Name)
+	}
+}
+
+func TestSearchProductsFallsBackToFuzzy(t *testing.T) {
+
+	h := newHarness(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/products/search?q=linnen", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	data := decodeData[model.SearchProductsResponseData](t, rec)
+
+	if data.Mode != constant.SearchModeFuzzy || len(data.Results) != 1 || data.Results[0].Product.ID != 2 {
+		t.Fatalf("unexpected search result %+v", data)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/products/search?q=%26%7C", nil, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestGetProductsCursorPagination(t *testing.T) {
+
+	h := newHarness(t)
+
+	path := "/api/v1/products?isActive=false&sort=price&mode=cursor&perPage=2&withTotal=false"
+
+	rec := h.Do(t, http.MethodGet, path, nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	first := decodeData[model.GetProductsResponseData](t, rec)
+
+	// Price ascending : 1 (15000), 3 (50000), 2 (120000)
+	if len(first.Products) != 2 || first.Products[0].ID != 1 || first.Products[1].ID != 3 || first.Metadata.NextCursor == "" || !first.Metadata.TotalSkipped {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	rec = h.Do(t, http.MethodGet, path+"&cursor="+first.Metadata.NextCursor, nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	second := decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(second.Products) != 1 || second.Products[0].ID != 2 || second.Metadata.NextCursor != "" || second.Metadata.PrevCursor == "" {
+		t.Fatalf("unexpected second page %+v", second)
+	}
+
+	rec = h.Do(t, http.MethodGet, path+"&cursor="+second.Metadata.PrevCursor, nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	back := decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(back.Products) != 2 || back.Products[0].ID != 1 || back.Products[1].ID != 3 {
+		t.Fatalf("unexpected previous page %+v", back)
+	}
+
+	rec = h.Do(t, http.MethodGet, path+"&cursor=forged.cursor", nil, "")
+	expectStatus(t, rec, http.StatusBadRequest)
+}
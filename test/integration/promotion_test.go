@@ -0,0 +1,151 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (h *Harness) createPromotion(t *testing.T, token string, body map[string]interface{}) model.PromotionDTO {
+
+	t.Helper()
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/promotions", body, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	return decodeData[model.PromotionResponseData](t, rec).Promotion
+}
+
+func TestCouponIsStoredOnTheOrder(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/promotions", map[string]interface{}{"code": "WELCOME10", "type": constant.PromotionTypePercentage, "value": 10}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	h.createPromotion(t, staff, map[string]interface{}{
+		"code":        "WELCOME10",
+		"description": "10% off your first order",
+		"type":        constant.PromotionTypePercentage,
+		"value":       10,
+		"categoryId":  1,
+	})
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/promotions", map[string]interface{}{"code": "WELCOME10", "type": constant.PromotionTypeFreeShipping}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/promotions", map[string]interface{}{"code": "NOWHERE", "type": constant.PromotionTypeFreeShipping, "categoryId": 99}, staff)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 2}},
+		"couponCode":      "welcome10",
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	order := decodeData[model.SubmitOrderResponseData](t, rec)
+
+	if order.Subtotal != 30000 || order.DiscountTotal != 3000 || order.Total != 27000 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.Payment.Total != 27000 || len(detail.Discounts) != 1 || detail.Discounts[0].Code != "WELCOME10" || detail.Discounts[0].Amount != 3000 {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+		"couponCode":      "NOSUCHCODE",
+	}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/admin/promotions", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.GetPromotionsResponseData](t, rec); len(data.Promotions) != 1 || data.Promotions[0].CategoryID == nil {
+		t.Fatalf("unexpected promotions %+v", data.Promotions)
+	}
+
+	rec = h.Do(t, http.MethodPatch, "/api/v1/admin/promotions/1", map[string]interface{}{"isActive": false}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+		"couponCode":      "WELCOME10",
+	}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+// The promotion is locked in the order transaction, concurrent orders never redeem it beyond its limit
+func TestConcurrentCouponRedemptionsRespectTheUsageLimit(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	h.createPromotion(t, staff, map[string]interface{}{"code": "FIRST3", "type": constant.PromotionTypeFixedAmount, "value": 1000, "usageLimit": 3})
+
+	const attempts = 8
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, attempts)
+
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+				"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+				"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+				"couponCode":      "FIRST3",
+			}, token)
+			statuses <- rec.Code
+		}()
+	}
+
+	wg.Wait()
+	close(statuses)
+
+	succeeded := 0
+	for status := range statuses {
+		if status == http.StatusOK {
+			succeeded++
+		}
+	}
+
+	if succeeded != 3 {
+		t.Fatalf("expected exactly 3 redemptions, got %d", succeeded)
+	}
+
+	var redemptions int64
+
+	err := h.DB.Table("order_discounts").Count(&redemptions).Error
+	if err != nil {
+		t.Fatalf("count redemptions: %v", err)
+	}
+
+	if redemptions != 3 {
+		t.Fatalf("expected 3 discount lines, got %d", redemptions)
+	}
+
+	if stock := h.stockOf(t, 1); stock != 7 {
+		t.Fatalf("expected only the redeemed orders to reserve stock, got %d", stock)
+	}
+}
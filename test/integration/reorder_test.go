@@ -0,0 +1,72 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestReorderRebuildsThePreviousOrder(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+
+	previous := h.SubmitOrder(t, token,
+		map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 2},
+		map[string]interface{}{"productId": 2, "priceUsed": 120000, "quantity": 1},
+	)
+
+	path := "/api/v1/order/" + previous.OrderReference + "/reorder"
+
+	rec := h.Do(t, http.MethodPost, path, map[string]interface{}{}, h.LoginStaff(t))
+	expectStatus(t, rec, http.StatusForbidden)
+
+	// Since then the throw is retired and the pot costs more
+	err := h.DB.Exec("UPDATE products SET is_active = false WHERE id = 2").Error
+	if err != nil {
+		t.Fatalf("retire product: %v", err)
+	}
+
+	err = h.DB.Exec("UPDATE products SET price = 16000 WHERE id = 1").Error
+	if err != nil {
+		t.Fatalf("reprice product: %v", err)
+	}
+
+	rec = h.Do(t, http.MethodPost, path, map[string]interface{}{}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	cart := decodeData[model.ReorderResponseData](t, rec)
+
+	if len(cart.Items) != 1 || cart.Items[0].Price != 16000 || cart.Items[0].PreviousPrice != 15000 || cart.Items[0].Quantity != 2 || cart.Order != nil {
+		t.Fatalf("expected the pot at its current price, got %+v", cart)
+	}
+
+	if len(cart.Unavailable) != 1 || cart.Unavailable[0].ProductID != 2 || cart.Unavailable[0].Reason != constant.ReorderReasonNotSold {
+		t.Fatalf("expected the retired throw unavailable, got %+v", cart.Unavailable)
+	}
+
+	if h.stockOf(t, 1) != 8 {
+		t.Fatalf("a cart should not reserve stock")
+	}
+
+	rec = h.Do(t, http.MethodPost, path, map[string]interface{}{"submit": true}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	order := decodeData[model.ReorderResponseData](t, rec).Order
+
+	if order == nil || order.OrderReference == previous.OrderReference || order.Total != 32000 || order.OrderStatus != constant.OrderStatusPendingPayment {
+		t.Fatalf("expected a new order of 32000, got %+v", order)
+	}
+
+	if h.stockOf(t, 1) != 6 {
+		t.Fatalf("the new order should reserve stock")
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/ORDER-MISSING/reorder", map[string]interface{}{}, token)
+	expectStatus(t, rec, http.StatusNotFound)
+}
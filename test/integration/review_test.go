@@ -0,0 +1,117 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (h *Harness) finishOrder(t *testing.T, orderReference string) {
+
+	t.Helper()
+
+	err := h.DB.Exec("UPDATE orders SET status = ? WHERE order_reference = ?", constant.OrderStatusFinished, orderReference).Error
+	if err != nil {
+		t.Fatalf("finish order: %v", err)
+	}
+}
+
+func TestReviewLifecycle(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	review := map[string]interface{}{"productId": 1, "rating": 4, "body": "Holds water, looks great"}
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/account/reviews", review, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	order := h.SubmitOrder(t, token, map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1})
+	h.finishOrder(t, order.OrderReference)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/reviews", review, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	created := decodeData[model.ReviewResponseData](t, rec)
+
+	if created.Product.RatingAverage != 4 || created.Product.ReviewCount != 1 {
+		t.Fatalf("unexpected rating %+v", created.Product)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/reviews", review, token)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/product/1", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	if product := decodeData[model.GetProductDetailResponseData](t, rec).Product; product.RatingAverage != 4 || product.ReviewCount != 1 {
+		t.Fatalf("unexpected product rating %+v", product)
+	}
+
+	rec = h.Do(t, http.MethodPatch, "/api/v1/admin/reviews/1", map[string]interface{}{"status": constant.ReviewStatusHidden, "note": "Spam"}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPatch, "/api/v1/admin/reviews/1", map[string]interface{}{"status": constant.ReviewStatusHidden, "note": "Spam"}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/product/1/reviews", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	if reviews := decodeData[model.GetReviewsResponseData](t, rec).Reviews; len(reviews) != 0 {
+		t.Fatalf("expected no published review, got %+v", reviews)
+	}
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/account/reviews/1", map[string]interface{}{"rating": 5, "body": "Even better"}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/account/reviews/1", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/reviews", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if reviews := decodeData[model.GetReviewsResponseData](t, rec).Reviews; len(reviews) != 0 {
+		t.Fatalf("expected the review to be deleted, got %+v", reviews)
+	}
+}
+
+func TestProductsSortByRatingInCursorMode(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+
+	order := h.SubmitOrder(t, token,
+		map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 1},
+		map[string]interface{}{"productId": 2, "priceUsed": 120000, "quantity": 1},
+	)
+	h.finishOrder(t, order.OrderReference)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/account/reviews", map[string]interface{}{"productId": 1, "rating": 2, "body": "Cracked"}, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/reviews", map[string]interface{}{"productId": 2, "rating": 5, "body": "So soft"}, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/products?sort=rating&mode=cursor&perPage=1", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	first := decodeData[model.GetProductsResponseData](t, rec)
+
+	if len(first.Products) != 1 || first.Products[0].ID != 2 || first.Metadata.NextCursor == "" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/products?sort=rating&perPage=1&cursor="+first.Metadata.NextCursor, nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	if second := decodeData[model.GetProductsResponseData](t, rec); len(second.Products) != 1 || second.Products[0].ID != 1 || second.Products[0].RatingAverage != 2 {
+		t.Fatalf("unexpected second page %+v", second)
+	}
+}
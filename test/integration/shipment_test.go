@@ -0,0 +1,105 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestShipmentsAreTrackedUntilDelivery(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	order := h.SubmitOrder(t, token,
+		map[string]interface{}{"productId": 1, "priceUsed": 15000, "quantity": 2},
+		map[string]interface{}{"productId": 2, "priceUsed": 120000, "quantity": 1},
+	)
+
+	// An unpaid order does not ship
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/orders/"+order.OrderReference+"/shipments", map[string]interface{}{"carrier": "JNE", "trackingNumber": "JNE-0001"}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/payment/submit", map[string]string{
+		"orderReference": order.OrderReference,
+		"cardHolderName": "John Doe",
+		"cardNumber":     "4111 1111 1111 1234",
+		"status":         constant.PaymentStatusReceived,
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	items := map[int64]string{}
+
+	for _, item := range decodeData[model.GetOrderDetailReponseData](t, rec).Order.OrderItems {
+		items[item.Product.ID] = item.OrderItemReference
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/orders/"+order.OrderReference+"/shipments", map[string]interface{}{"carrier": "JNE", "trackingNumber": "JNE-0001"}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/orders/"+order.OrderReference+"/shipments", map[string]interface{}{
+		"carrier":        "JNE",
+		"trackingNumber": "jne-0001",
+		"items":          []map[string]interface{}{{"orderItemReference": items[1], "quantity": 2}},
+	}, staff)
+	expectStatus(t, rec, http.StatusCreated)
+
+	first := decodeData[model.ShipmentResponseData](t, rec)
+
+	if first.OrderStatus != constant.OrderStatusProcessed || first.Shipment.TrackingNumber != "JNE-0001" {
+		t.Fatalf("unexpected shipment %+v", first)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/orders/"+order.OrderReference+"/shipments", map[string]interface{}{"carrier": "jne", "trackingNumber": "JNE-0001"}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/orders/"+order.OrderReference+"/shipments", map[string]interface{}{"carrier": "SiCepat", "trackingNumber": "SC-0002"}, staff)
+	expectStatus(t, rec, http.StatusCreated)
+
+	second := decodeData[model.ShipmentResponseData](t, rec)
+
+	if second.OrderStatus != constant.OrderStatusShipped || len(second.Shipment.Items) != 1 || second.Shipment.Items[0].OrderItemReference != items[2] {
+		t.Fatalf("unexpected shipment %+v", second)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/tracking/"+order.OrderReference, nil, staff)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/tracking/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if tracking := decodeData[model.GetOrderTrackingResponseData](t, rec); tracking.OrderStatus != constant.OrderStatusShipped || len(tracking.Shipments) != 2 {
+		t.Fatalf("unexpected tracking %+v", tracking)
+	}
+
+	for _, shipment := range []model.ShipmentDTO{first.Shipment, second.Shipment} {
+
+		rec = h.Do(t, http.MethodPatch, "/api/v1/admin/shipments/"+shipment.ShipmentReference, map[string]interface{}{"deliveredAt": time.Now()}, staff)
+		expectStatus(t, rec, http.StatusOK)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.Status != constant.OrderStatusFinished || len(detail.Shipments) != 2 || detail.Shipments[0].Status != constant.ShipmentStatusDelivered {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	for _, item := range detail.OrderItems {
+		if item.ShippedQuantity != item.Quantity {
+			t.Fatalf("expected every item shipped, got %+v", item)
+		}
+	}
+}
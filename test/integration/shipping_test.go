@@ -0,0 +1,111 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (h *Harness) createShippingMethod(t *testing.T, token string, body map[string]interface{}) model.ShippingMethodDTO {
+
+	t.Helper()
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/shipping-methods", body, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	return decodeData[model.ShippingMethodResponseData](t, rec).ShippingMethod
+}
+
+func TestShippingFeeIsChargedOnTheOrder(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPut, "/api/v1/admin/products/1/dimensions", map[string]interface{}{"weight": 800, "length": 200, "width": 200, "height": 200}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/dimensions", map[string]interface{}{"weight": 800, "length": 200, "width": 200, "height": 200}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if dimensions := decodeData[model.ProductDimensionsResponseData](t, rec); dimensions.ShippingWeight != 1600 {
+		t.Fatalf("unexpected dimensions %+v", dimensions)
+	}
+
+	h.createShippingMethod(t, staff, map[string]interface{}{"code": "REG", "name": "Regular", "type": constant.ShippingTypeFlatRate, "flatFee": 10000, "freeAbove": 200000})
+	express := h.createShippingMethod(t, staff, map[string]interface{}{
+		"code": "EXP", "name": "Express", "type": constant.ShippingTypeWeightTiered,
+		"tiers": []map[string]interface{}{{"upToWeight": 1000, "fee": 8000}, {"upToWeight": 3000, "fee": 15000}},
+	})
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/shipping-methods", map[string]interface{}{"code": "reg", "name": "Regular", "type": constant.ShippingTypeFlatRate}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	// The quote needs no login
+	rec = h.Do(t, http.MethodPost, "/api/v1/shipping/quote", map[string]interface{}{
+		"items": []map[string]interface{}{{"productId": 1, "quantity": 1}},
+	}, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	quote := decodeData[model.QuoteShippingResponseData](t, rec)
+
+	if quote.Weight != 1600 || len(quote.Methods) != 2 || quote.Methods[0].Code != "REG" || quote.Methods[1].Fee != 15000 {
+		t.Fatalf("unexpected quote %+v", quote)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+	}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"shippingMethod":  "EXP",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	order := decodeData[model.SubmitOrderResponseData](t, rec)
+
+	if order.ShippingFee != 15000 || order.Total != 30000 {
+		t.Fatalf("unexpected order %+v", order)
+	}
+
+	rec = h.Do(t, http.MethodPatch, fmt.Sprintf("/api/v1/admin/shipping-methods/%d", express.ID), map[string]interface{}{"isActive": false}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.ShippingMethod != "EXP" || detail.ShippingWeight != 1600 || detail.ShippingFee != 15000 || detail.Payment.Total != 30000 {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	// Free above the threshold of the method
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"shippingMethod":  "REG",
+		"orderItems":      []map[string]interface{}{{"productId": 2, "priceUsed": 120000, "quantity": 2}},
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if order := decodeData[model.SubmitOrderResponseData](t, rec); order.ShippingMethod != "REG" || order.ShippingFee != 0 || order.Total != 240000 {
+		t.Fatalf("expected free shipping, got %+v", order)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/admin/shipping-methods", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.GetShippingMethodsResponseData](t, rec); len(data.ShippingMethods) != 2 {
+		t.Fatalf("unexpected shipping methods %+v", data.ShippingMethods)
+	}
+}
@@ -0,0 +1,94 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func TestStockSubscriptionIsNotifiedOnRestock(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	// In stock, nothing to wait for
+	rec := h.Do(t, http.MethodPost, "/api/v1/account/stock-subscriptions", map[string]interface{}{"productId": 2}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	h.SubmitOrder(t, token, map[string]interface{}{"productId": 2, "priceUsed": 120000, "quantity": 3})
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/stock-subscriptions", map[string]interface{}{"productId": 2}, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/stock-subscriptions", map[string]interface{}{"productId": 2}, token)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/stock-subscriptions", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.GetStockSubscriptionsResponseData](t, rec); len(data.Subscriptions) != 1 || data.Subscriptions[0].ProductName != "Linen Throw" {
+		t.Fatalf("unexpected subscriptions %+v", data.Subscriptions)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/2/inventory-movements", map[string]interface{}{
+		"type":     constant.MovementTypeRestock,
+		"quantity": 4,
+	}, staff)
+	expectStatus(t, rec, http.StatusCreated)
+
+	var subscription entity.StockSubscription
+
+	err := h.DB.Where("product_id = ?", 2).First(&subscription).Error
+	if err != nil {
+		t.Fatalf("find subscription: %v", err)
+	}
+
+	if subscription.NotifiedAt == nil {
+		t.Fatalf("expected the subscription to be notified")
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/stock-subscriptions", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.GetStockSubscriptionsResponseData](t, rec); len(data.Subscriptions) != 0 {
+		t.Fatalf("expected no pending subscription, got %+v", data.Subscriptions)
+	}
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/account/stock-subscriptions/2", nil, token)
+	expectStatus(t, rec, http.StatusNotFound)
+}
+
+func TestLowStockThresholdRequiresStaff(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPut, "/api/v1/admin/products/1/low-stock-threshold", map[string]interface{}{"threshold": 4}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/low-stock-threshold", map[string]interface{}{"threshold": -1}, staff)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPut, "/api/v1/admin/products/1/low-stock-threshold", map[string]interface{}{"threshold": 4}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.UpdateLowStockThresholdResponseData](t, rec); data.LowStockThreshold != 4 || data.Stock != 10 {
+		t.Fatalf("unexpected response %+v", data)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/admin/products/1/inventory-movements", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.GetInventoryMovementsResponseData](t, rec); data.LowStockThreshold != 4 {
+		t.Fatalf("expected threshold 4 in the ledger response, got %d", data.LowStockThreshold)
+	}
+}
@@ -0,0 +1,116 @@
+//go:build integration
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/constant"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+func (h *Harness) createTaxRule(t *testing.T, token string, body map[string]interface{}) model.TaxRuleDTO {
+
+	t.Helper()
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/tax-rules", body, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	return decodeData[model.TaxRuleResponseData](t, rec).TaxRule
+}
+
+func TestTaxLinesAreStoredOnTheOrder(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/tax-rules", map[string]interface{}{"name": "VAT", "rate": 1100, "mode": constant.TaxModeExclusive}, token)
+	expectStatus(t, rec, http.StatusForbidden)
+
+	vat := h.createTaxRule(t, staff, map[string]interface{}{"name": "VAT", "rate": 1100, "mode": constant.TaxModeExclusive})
+	h.createTaxRule(t, staff, map[string]interface{}{"name": "VAT", "region": "ID-BA", "categoryId": 1, "rate": 1200, "mode": constant.TaxModeInclusive})
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/tax-rules", map[string]interface{}{"name": "vat", "rate": 1000, "mode": constant.TaxModeExclusive}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/tax-rules", map[string]interface{}{"name": "LUXURY", "categoryId": 99, "rate": 1000, "mode": constant.TaxModeExclusive}, staff)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// A rule is scoped to a region, an order without region would skip it
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 2}},
+	}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	// Exclusive VAT on top of the price
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"region":          "ID-JK",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 2}},
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	order := decodeData[model.SubmitOrderResponseData](t, rec)
+
+	if order.Subtotal != 30000 || order.TaxTotal != 3300 || order.Total != 33300 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.TaxTotal != 3300 || detail.Payment.Total != 33300 || len(detail.OrderItems[0].Taxes) != 1 || detail.OrderItems[0].Taxes[0].Amount != 3300 {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	// The regional rule of the category is included in the price, 15000 / 1.12 = 13392.86
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Sunset 9, Denpasar",
+		"region":          "id-ba",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	order = decodeData[model.SubmitOrderResponseData](t, rec)
+
+	if order.TaxTotal != 1607 || order.TaxIncluded != 1607 || order.Total != 15000 {
+		t.Fatalf("unexpected totals %+v", order)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail = decodeData[model.GetOrderDetailReponseData](t, rec).Order
+
+	if detail.TaxRegion != "ID-BA" || detail.OrderItems[0].Taxes[0].TaxableAmount != 13393 || detail.OrderItems[0].Taxes[0].Mode != constant.TaxModeInclusive {
+		t.Fatalf("unexpected order detail %+v", detail)
+	}
+
+	rec = h.Do(t, http.MethodPatch, fmt.Sprintf("/api/v1/admin/tax-rules/%d", vat.ID), map[string]interface{}{"isActive": false}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/admin/tax-rules", nil, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.GetTaxRulesResponseData](t, rec); len(data.TaxRules) != 2 {
+		t.Fatalf("unexpected tax rules %+v", data.TaxRules)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"region":          "ID-JK",
+		"orderItems":      []map[string]interface{}{{"productId": 1, "priceUsed": 15000, "quantity": 1}},
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if order := decodeData[model.SubmitOrderResponseData](t, rec); order.TaxTotal != 0 || order.Total != 15000 {
+		t.Fatalf("expected no tax once the rule is inactive, got %+v", order)
+	}
+}
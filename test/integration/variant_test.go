@@ -0,0 +1,190 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+)
+
+// seedTee adds product 4 sold in sizes S (variant 1, stock 2) and M (variant 2, stock 5, price 95000)
+func (h *Harness) seedTee(t *testing.T) {
+
+	t.Helper()
+
+	statements := []string{
+		`INSERT INTO products (id, category_id, name, description, stock, price, image_url, is_active)
+			VALUES (4, 1, 'Linen Tee', 'Washed linen tee', 7, 90000, 'https://img.example.com/4.jpg', true)`,
+		`INSERT INTO product_option_types (id, product_id, name, position) VALUES (1, 4, 'Size', 1), (2, 4, 'Colour', 2)`,
+		`INSERT INTO product_variants (id, product_id, sku, price, stock) VALUES
+			(1, 4, 'TEE-S-WHITE', NULL, 2),
+			(2, 4, 'TEE-M-WHITE', 95000, 5)`,
+		`INSERT INTO product_variant_options (variant_id, option_type_id, value) VALUES
+			(1, 1, 'S'), (1, 2, 'White'), (2, 1, 'M'), (2, 2, 'White')`,
+		`INSERT INTO inventory_movements (product_id, variant_id, movement_type, quantity, stock_after, created_by)
+			SELECT product_id, id, 'OPENING_BALANCE', stock, stock, 'SYSTEM' FROM product_variants`,
+		`SELECT setval('product_id_sequence', 4)`,
+		`SELECT setval('product_option_type_id_sequence', 2)`,
+		`SELECT setval('product_variant_id_sequence', 2)`,
+	}
+
+	for _, statement := range statements {
+		err := h.DB.Exec(statement).Error
+		if err != nil {
+			t.Fatalf("seed tee: %v", err)
+		}
+	}
+}
+
+func (h *Harness) variantStockOf(t *testing.T, variantID int64) int64 {
+
+	t.Helper()
+
+	var variant entity.ProductVariant
+
+	err := h.DB.First(&variant, variantID).Error
+	if err != nil {
+		t.Fatalf("find variant %d: %v", variantID, err)
+	}
+
+	return variant.Stock
+}
+
+func TestSubmitAndCancelVariantOrder(t *testing.T) {
+
+	h := newHarness(t)
+	h.seedTee(t)
+	token := h.LoginFixture(t)
+
+	order := h.SubmitOrder(t, token,
+		map[string]interface{}{"productId": 4, "variantId": 1, "priceUsed": 90000, "quantity": 1},
+		map[string]interface{}{"productId": 4, "variantId": 2, "priceUsed": 95000, "quantity": 2},
+	)
+
+	if h.stockOf(t, 4) != 4 || h.variantStockOf(t, 1) != 1 || h.variantStockOf(t, 2) != 3 {
+		t.Fatalf("unexpected stock after submit")
+	}
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/order/detail/"+order.OrderReference, nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	detail := decodeData[model.GetOrderDetailReponseData](t, rec)
+
+	for _, item := range detail.Order.OrderItems {
+		if item.Variant == nil || len(item.Variant.Options) != 2 || item.Variant.Options[0].Name != "Size" {
+			t.Fatalf("expected a variant snapshot, got %+v", item)
+		}
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/order/cancel", map[string]string{"orderReference": order.OrderReference}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if h.stockOf(t, 4) != 7 || h.variantStockOf(t, 1) != 2 || h.variantStockOf(t, 2) != 5 {
+		t.Fatalf("unexpected stock after cancel")
+	}
+}
+
+func TestSubmitOrderRequiresVariant(t *testing.T) {
+
+	h := newHarness(t)
+	h.seedTee(t)
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/order/submit", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"orderItems":      []map[string]interface{}{{"productId": 4, "priceUsed": 90000, "quantity": 1}},
+	}, token)
+
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestGetProductDetailListsVariants(t *testing.T) {
+
+	h := newHarness(t)
+	h.seedTee(t)
+
+	rec := h.Do(t, http.MethodGet, "/api/v1/product/4", nil, "")
+	expectStatus(t, rec, http.StatusOK)
+
+	product := decodeData[model.GetProductDetailResponseData](t, rec).Product
+
+	if len(product.Variants) != 2 || product.Variants[0].SKU != "TEE-S-WHITE" || product.Variants[0].Price != 90000 || product.Variants[1].Price != 95000 {
+		t.Fatalf("unexpected variants %+v", product.Variants)
+	}
+
+	if len(product.Options) != 2 || product.Options[0].Name != "Size" || len(product.Options[0].Values) != 2 {
+		t.Fatalf("unexpected options %+v", product.Options)
+	}
+}
+
+func TestAdminCreatesAndUpdatesVariants(t *testing.T) {
+
+	h := newHarness(t)
+	h.seedTee(t)
+
+	staff := h.LoginStaff(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/admin/products/1/option-types", map[string]interface{}{"name": "Size"}, h.LoginFixture(t))
+	expectStatus(t, rec, http.StatusForbidden)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/1/option-types", map[string]interface{}{"name": "Size"}, staff)
+	expectStatus(t, rec, http.StatusCreated)
+
+	optionType := decodeData[model.OptionTypeResponseData](t, rec).OptionType
+
+	// Product 1 still has stock of its own
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/1/variants", map[string]interface{}{
+		"sku":     "POT-SMALL",
+		"stock":   3,
+		"options": []map[string]interface{}{{"optionTypeId": optionType.ID, "value": "Small"}},
+	}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	// Every variant of product 4 already picks a size and a colour
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/4/option-types", map[string]interface{}{"name": "Fit"}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	body := map[string]interface{}{
+		"sku":     "TEE-XL-WHITE",
+		"stock":   4,
+		"options": []map[string]interface{}{{"optionTypeId": 1, "value": "XL"}, {"optionTypeId": 2, "value": "White"}},
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/4/variants", body, staff)
+	expectStatus(t, rec, http.StatusCreated)
+
+	created := decodeData[model.VariantResponseData](t, rec)
+
+	if created.Stock != 11 || created.Variant.ID != 3 || created.Variant.Price != 90000 || len(created.Variant.Options) != 2 {
+		t.Fatalf("unexpected variant %+v", created)
+	}
+
+	body["options"] = []map[string]interface{}{{"optionTypeId": 1, "value": "XXL"}, {"optionTypeId": 2, "value": "White"}}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/admin/products/4/variants", body, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodPatch, "/api/v1/admin/products/4/variants/3", map[string]interface{}{"sku": "TEE-S-WHITE"}, staff)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodPatch, "/api/v1/admin/products/4/variants/3", map[string]interface{}{"price": 99000, "stock": 6, "note": "Recount"}, staff)
+	expectStatus(t, rec, http.StatusOK)
+
+	if updated := decodeData[model.VariantResponseData](t, rec); updated.Stock != 13 || updated.Variant.Price != 99000 || updated.Variant.Stock != 6 {
+		t.Fatalf("unexpected variant %+v", updated)
+	}
+
+	if h.stockOf(t, 4) != 13 || h.variantStockOf(t, 3) != 6 {
+		t.Fatalf("expected the stock written, got %d %d", h.stockOf(t, 4), h.variantStockOf(t, 3))
+	}
+
+	var movements []string
+
+	err := h.DB.Raw("SELECT movement_type FROM inventory_movements WHERE variant_id = 3 ORDER BY id").Scan(&movements).Error
+	if err != nil || len(movements) != 2 || movements[0] != "OPENING_BALANCE" || movements[1] != "MANUAL_ADJUSTMENT" {
+		t.Fatalf("expected the opening balance and the adjustment, got %v %v", movements, err)
+	}
+}
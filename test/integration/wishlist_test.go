@@ -0,0 +1,141 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jhasudungan/terraloom-core-api/internal/entity"
+	"github.com/jhasudungan/terraloom-core-api/internal/model"
+	"github.com/jhasudungan/terraloom-core-api/internal/notification"
+	"github.com/jhasudungan/terraloom-core-api/internal/repository"
+	"github.com/jhasudungan/terraloom-core-api/internal/service"
+)
+
+func TestWishlistLifecycle(t *testing.T) {
+
+	h := newHarness(t)
+	h.seedTee(t)
+
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/account/wishlist", map[string]interface{}{"productId": 4}, token)
+	expectStatus(t, rec, http.StatusBadRequest)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/wishlist", map[string]interface{}{"productId": 4, "variantId": 2}, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	teeItem := decodeData[model.WishlistItemResponseData](t, rec).Item
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/wishlist", map[string]interface{}{"productId": 4, "variantId": 2}, token)
+	expectStatus(t, rec, http.StatusConflict)
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/wishlist", map[string]interface{}{"productId": 1}, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	potItem := decodeData[model.WishlistItemResponseData](t, rec).Item
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/wishlist", map[string]interface{}{"productId": 2}, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	if throwItem := decodeData[model.WishlistItemResponseData](t, rec).Item; throwItem.ID != 3 {
+		t.Fatalf("expected item 3, got %+v", throwItem)
+	}
+
+	err := h.DB.Exec("UPDATE products SET price = 12000 WHERE id = 1").Error
+	if err != nil {
+		t.Fatalf("update price: %v", err)
+	}
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/wishlist", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	items := decodeData[model.GetWishlistResponseData](t, rec).Items
+
+	if len(items) != 3 || items[1].ID != potItem.ID || items[1].Price != 12000 || items[1].AddedPrice != 15000 || items[2].SKU != "TEE-M-WHITE" {
+		t.Fatalf("unexpected wishlist %+v", items)
+	}
+
+	rec = h.Do(t, http.MethodPost, "/api/v1/account/wishlist/order", map[string]interface{}{
+		"deliveryAddress": "Jl. Merdeka 1, Jakarta",
+		"items": []map[string]interface{}{
+			{"itemId": potItem.ID, "quantity": 2},
+			{"itemId": teeItem.ID},
+		},
+	}, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if data := decodeData[model.OrderWishlistItemsResponseData](t, rec); data.Order.Total != 2*12000+95000 || len(data.RemovedItemIDs) != 2 {
+		t.Fatalf("unexpected order %+v", data)
+	}
+
+	if stock := h.variantStockOf(t, 2); stock != 4 {
+		t.Fatalf("expected variant stock 4, got %d", stock)
+	}
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/account/wishlist/3", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	rec = h.Do(t, http.MethodDelete, "/api/v1/account/wishlist/3", nil, token)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = h.Do(t, http.MethodGet, "/api/v1/account/wishlist", nil, token)
+	expectStatus(t, rec, http.StatusOK)
+
+	if items := decodeData[model.GetWishlistResponseData](t, rec).Items; len(items) != 0 {
+		t.Fatalf("expected an empty wishlist, got %+v", items)
+	}
+
+	h.assertReconciled(t)
+}
+
+func TestWishlistPriceDropIsNotifiedOnce(t *testing.T) {
+
+	h := newHarness(t)
+
+	token := h.LoginFixture(t)
+
+	rec := h.Do(t, http.MethodPost, "/api/v1/account/wishlist", map[string]interface{}{"productId": 1}, token)
+	expectStatus(t, rec, http.StatusCreated)
+
+	item := decodeData[model.WishlistItemResponseData](t, rec).Item
+
+	wishlistService := service.NewWishlistService(
+		repository.NewWishlistRepository(h.DB),
+		repository.NewProductRepository(h.DB),
+		repository.NewProductVariantRepository(h.DB),
+		repository.NewProductPriceChangeRepository(h.DB),
+		repository.NewAccountRepository(h.DB),
+		nil,
+		notification.NewLogNotifier())
+
+	err := h.DB.Exec("UPDATE products SET price = 12000 WHERE id = 1").Error
+	if err != nil {
+		t.Fatalf("update price: %v", err)
+	}
+
+	for i, expected := range []int{1, 0} {
+
+		sent, err := wishlistService.NotifyPriceDrops(context.Background())
+		if err != nil {
+			t.Fatalf("notify price drops: %v", err)
+		}
+
+		if sent != expected {
+			t.Fatalf("run %d: expected %d notices, got %d", i, expected, sent)
+		}
+	}
+
+	var saved entity.WishlistItem
+
+	err = h.DB.First(&saved, item.ID).Error
+	if err != nil {
+		t.Fatalf("find wishlist item: %v", err)
+	}
+
+	if saved.ReferencePrice != 12000 || saved.AddedPrice != 15000 {
+		t.Fatalf("unexpected prices %+v", saved)
+	}
+}